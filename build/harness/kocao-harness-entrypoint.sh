@@ -97,6 +97,46 @@ fetch_with_retry() {
   return 1
 }
 
+# ---------------------------------------------------------------------------
+# Publish step — when KOCAO_PUSH_BRANCH is set, whatever the command left in
+# the repo is committed onto that branch and pushed with the run's git
+# credentials. The operator opens the pull request once the run succeeds. A
+# run that changed nothing pushes nothing.
+# ---------------------------------------------------------------------------
+# Reports the push outcome as the last line of the container termination
+# message, which the operator reads to tell a run with no changes from one
+# that never pushed. It appends, so a report the command already wrote there
+# (the Symphony worker's) survives ahead of it.
+report_push() {
+  local log_path="${KOCAO_TERMINATION_LOG:-/dev/termination-log}"
+  [[ -w "${log_path}" ]] || return 0
+  local sep=""
+  [[ -s "${log_path}" ]] && sep=$'\n'
+  printf '%skocao-push: %s\n' "${sep}" "$1" 2>/dev/null >>"${log_path}" || true
+}
+
+push_branch() {
+  local repo="$1" branch="$2" message="$3" start_commit="$4"
+
+  # Called from an || list, so set -e does not apply here.
+  git -C "${repo}" checkout -B "${branch}" || return 1
+  git -C "${repo}" add -A || return 1
+  if ! git -C "${repo}" diff --cached --quiet; then
+    git -C "${repo}" \
+      -c user.name="${GIT_AUTHOR_NAME:-kocao}" \
+      -c user.email="${GIT_AUTHOR_EMAIL:-kocao@users.noreply.github.com}" \
+      commit --quiet -m "${message}" || return 1
+  fi
+  if [[ "$(git -C "${repo}" rev-parse HEAD)" == "${start_commit}" ]]; then
+    log "no changes to push to ${branch}"
+    report_push no-changes
+    return 0
+  fi
+  git -C "${repo}" push --force origin "HEAD:refs/heads/${branch}" || return 1
+  log "pushed ${branch}"
+  report_push pushed
+}
+
 canon_path() {
   local p
   p="$1"
@@ -240,4 +280,26 @@ if [[ "$#" -eq 0 ]]; then
   exec sleep infinity
 fi
 
+if [[ -n "${KOCAO_PUSH_BRANCH:-}" ]]; then
+  start_commit=$(git -C "${repo_dir}" rev-parse --verify --quiet HEAD || true)
+  # The command runs as a child so the branch can be pushed afterwards; pass
+  # termination signals on to it and exit without pushing.
+  "$@" &
+  child_pid=$!
+  forward_signal() {
+    kill -s "$1" "${child_pid}" 2>/dev/null || true
+    wait "${child_pid}" 2>/dev/null || true
+    exit "$2"
+  }
+  trap 'forward_signal TERM 143' TERM
+  trap 'forward_signal INT 130' INT
+  status=0
+  wait "${child_pid}" || status=$?
+  trap - TERM INT
+  [[ "${status}" -eq 0 ]] || exit "${status}"
+  push_branch "${repo_dir}" "${KOCAO_PUSH_BRANCH}" "${KOCAO_PUSH_COMMIT_MESSAGE:-kocao: ${KOCAO_PUSH_BRANCH}}" "${start_commit}" \
+    || die "failed to push ${KOCAO_PUSH_BRANCH}"
+  exit 0
+fi
+
 exec "$@"
@@ -44,7 +44,38 @@ func main() {
 		os.Exit(1)
 	}
 
-	api, err := controlplaneapi.New(ns, cfg.AuditPath, cfg.BootstrapToken, ctrl.GetConfigOrDie(), k8s, controlplaneapi.Options{Env: cfg.Env, AttachWSAllowedOrigins: cfg.AttachWSAllowedOrigins})
+	claimScopes := make([]controlplaneapi.OIDCClaimScope, 0, len(cfg.OIDC.ClaimScopes))
+	for _, m := range cfg.OIDC.ClaimScopes {
+		claimScopes = append(claimScopes, controlplaneapi.OIDCClaimScope{Claim: m.Claim, Value: m.Value, Scopes: m.Scopes})
+	}
+	api, err := controlplaneapi.New(ns, cfg.AuditPath, cfg.BootstrapToken, ctrl.GetConfigOrDie(), k8s, controlplaneapi.Options{
+		Env:                    cfg.Env,
+		AttachWSAllowedOrigins: cfg.AttachWSAllowedOrigins,
+		BootstrapTokenSHA256:   cfg.BootstrapTokenSHA256,
+		OIDC: controlplaneapi.OIDCOptions{
+			IssuerURL:     cfg.OIDC.IssuerURL,
+			Audience:      cfg.OIDC.Audience,
+			IdentityClaim: cfg.OIDC.IdentityClaim,
+			TeamsClaim:    cfg.OIDC.TeamsClaim,
+			ClaimScopes:   claimScopes,
+		},
+		ArtifactStore: controlplaneapi.ArtifactStoreOptions{
+			Backend: cfg.ArtifactStore.Backend,
+			Dir:     cfg.ArtifactStore.Dir,
+			S3: controlplaneapi.S3ArtifactStoreOptions{
+				Endpoint:        cfg.ArtifactStore.S3Endpoint,
+				Bucket:          cfg.ArtifactStore.S3Bucket,
+				Region:          cfg.ArtifactStore.S3Region,
+				Prefix:          cfg.ArtifactStore.S3Prefix,
+				AccessKeyID:     cfg.ArtifactStore.S3AccessKeyID,
+				SecretAccessKey: cfg.ArtifactStore.S3SecretAccessKey,
+			},
+		},
+		GitHubWebhookSecret:   cfg.GitHubWebhookSecret,
+		RunResourceMaximums:   cfg.RunResourceMaximums,
+		VolumeSnapshotClass:   cfg.VolumeSnapshotClass,
+		WorkspaceArchiveImage: cfg.WorkspaceArchiveImage,
+	})
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "api init error: %v\n", err)
 		os.Exit(1)
@@ -62,6 +93,9 @@ func main() {
 	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
 	defer stop()
 
+	go api.RemoteAgentOrchestration.RunReconciler(ctx)
+	go api.WorkspaceSnapshots.RunReconciler(ctx)
+
 	go func() {
 		fmt.Printf("control-plane-api listening on %s\n", cfg.HTTPAddr)
 		err := srv.ListenAndServe()
@@ -65,8 +65,9 @@ func main() {
 		os.Exit(1)
 	}
 	if err := (&operatorcontrollers.HarnessRunReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:   mgr.GetClient(),
+		Scheme:   mgr.GetScheme(),
+		Recorder: mgr.GetEventRecorderFor("harnessrun-controller"),
 	}).SetupWithManager(mgr); err != nil {
 		fmt.Fprintf(os.Stderr, "unable to create harnessrun controller: %v\n", err)
 		os.Exit(1)
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/withakay/kocao/internal/auditlog"
+	"github.com/withakay/kocao/internal/egressproxy"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+func main() {
+	addr := flag.String("listen", envOrDefault("KOCAO_EGRESS_PROXY_ADDR", ":3128"), "Proxy listen address")
+	namespace := flag.String("namespace", envOrDefault("POD_NAMESPACE", ""), "Namespace of the harness pods (default: auto-detect from in-cluster)")
+	auditPath := flag.String("audit-path", envOrDefault("CP_AUDIT_PATH", ""), "Optional audit log file for denied connections; denials are always logged to stdout")
+	apiURL := flag.String("api-url", envOrDefault("KOCAO_API_URL", ""), "Control-plane API to forward denials to as audit events")
+	apiToken := flag.String("api-token", envOrDefault("KOCAO_TOKEN", ""), "Bearer token with the audit:write scope for -api-url")
+	flag.Parse()
+
+	// Denials are logged as JSON on stdout so the cluster's log pipeline
+	// collects them from the otherwise stateless proxy.
+	slog.SetDefault(slog.New(slog.NewJSONHandler(os.Stdout, nil)))
+
+	ns := *namespace
+	if ns == "" {
+		data, err := os.ReadFile("/var/run/secrets/kubernetes.io/serviceaccount/namespace")
+		if err == nil {
+			ns = strings.TrimSpace(string(data))
+		}
+		if ns == "" {
+			ns = "default"
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	cfg, err := rest.InClusterConfig()
+	if err != nil {
+		slog.Error("failed to get in-cluster config", "error", err)
+		os.Exit(1)
+	}
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		slog.Error("failed to create kubernetes client", "error", err)
+		os.Exit(1)
+	}
+	lookup, err := egressproxy.NewPodLookup(ctx, clientset, ns)
+	if err != nil {
+		slog.Error("failed to watch harness pods", "error", err)
+		os.Exit(1)
+	}
+
+	var auditors teeAuditor
+	if *auditPath != "" {
+		auditors = append(auditors, auditlog.New(*auditPath, nil))
+	}
+	if *apiURL != "" && *apiToken != "" {
+		forwarder := egressproxy.NewAuditForwarder(*apiURL, *apiToken, nil)
+		go forwarder.Run(ctx)
+		auditors = append(auditors, forwarder)
+	} else {
+		slog.Warn("denials are not forwarded to the control-plane audit log; set KOCAO_API_URL and KOCAO_TOKEN")
+	}
+	proxy := &egressproxy.Server{Lookup: lookup}
+	if len(auditors) > 0 {
+		proxy.Audit = auditors
+	}
+	srv := &http.Server{
+		Addr:              *addr,
+		Handler:           proxy,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(shutdownCtx)
+	}()
+
+	slog.Info("kocao-egress-proxy starting", "namespace", ns, "listen", *addr)
+	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		slog.Error("proxy exited with error", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("kocao-egress-proxy shutting down")
+}
+
+// teeAuditor records each event with every auditor in turn.
+type teeAuditor []egressproxy.Auditor
+
+func (t teeAuditor) Append(ctx context.Context, actor, action, resourceType, resourceID, outcome string, metadata any) {
+	for _, a := range t {
+		a.Append(ctx, actor, action, resourceType, resourceID, outcome, metadata)
+	}
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,79 @@
+// Command kocao-symphony-worker runs a rendered Symphony task inside the
+// harness pod. The report is written to the container's termination message so
+// the operator can copy usage onto the HarnessRun without the pod needing API
+// access.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/withakay/kocao/internal/symphony/worker"
+)
+
+const (
+	defaultTaskPath   = "/var/run/kocao/symphony/" + worker.TaskFileName
+	defaultReportPath = "/dev/termination-log"
+)
+
+func main() {
+	taskPath := flag.String("task", envOrDefault("KOCAO_SYMPHONY_TASK", defaultTaskPath), "Path to the rendered task JSON")
+	workspace := flag.String("workspace", os.Getenv("KOCAO_REPO_DIR"), "Workspace to run Codex in (default: $KOCAO_REPO_DIR, then the current directory)")
+	reportPath := flag.String("report", defaultReportPath, "Where to write the JSON report")
+	flag.Parse()
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGTERM, syscall.SIGINT)
+	defer stop()
+
+	if err := run(ctx, *taskPath, *workspace, *reportPath); err != nil {
+		slog.Error("symphony worker failed", "error", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, taskPath, workspace, reportPath string) error {
+	if workspace == "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		workspace = wd
+	}
+	task, err := worker.ReadTask(taskPath)
+	if err != nil {
+		writeReport(reportPath, worker.Report{Error: err.Error()})
+		return err
+	}
+	slog.Info("symphony worker starting", "title", task.Title, "workspace", workspace)
+	report, runErr := worker.Run(ctx, task, workspace)
+	writeReport(reportPath, report)
+	if runErr != nil {
+		return runErr
+	}
+	slog.Info("symphony worker finished", "last_event", report.LastEvent, "total_tokens", report.TotalTokens)
+	return nil
+}
+
+func writeReport(path string, report worker.Report) {
+	data, err := worker.EncodeReport(report)
+	if err != nil {
+		slog.Error("encode report", "error", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		slog.Error("write report", "path", path, "error", err)
+	}
+	fmt.Fprintln(os.Stdout, string(data))
+}
+
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
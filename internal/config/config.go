@@ -1,12 +1,17 @@
 package config
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
 	"net"
+	"net/url"
 	"os"
 	"strings"
 
 	"github.com/joho/godotenv"
+	"k8s.io/apimachinery/pkg/api/resource"
 )
 
 type Runtime struct {
@@ -22,8 +27,70 @@ type Runtime struct {
 	// BootstrapToken, when set, is inserted (if missing) into the token store with
 	// wildcard scopes. Use this for initial bring-up only.
 	BootstrapToken string
+	// BootstrapTokenSHA256 is the hex SHA-256 digest of a wildcard bring-up token.
+	// Unlike BootstrapToken it is allowed in prod, since the raw secret never
+	// reaches the control-plane environment.
+	BootstrapTokenSHA256 string
 	// Namespace is required when running in-cluster.
 	Namespace string
+
+	// OIDC configures JWT bearer authentication; disabled when IssuerURL is empty.
+	OIDC OIDC
+
+	// ArtifactStore selects the remote-agent artifact blob backend.
+	ArtifactStore ArtifactStore
+
+	// GitHubWebhookSecret verifies GitHub webhook signatures; the webhook
+	// endpoint is disabled when it is empty.
+	GitHubWebhookSecret string
+
+	// RunResourceMaximums caps the cpu, memory and ephemeral-storage a harness
+	// run in the namespace may request or be limited to, keyed by resource
+	// name. Read from CP_RUN_MAX_CPU, CP_RUN_MAX_MEMORY and
+	// CP_RUN_MAX_EPHEMERAL_STORAGE.
+	RunResourceMaximums map[string]string
+
+	// VolumeSnapshotClass names the VolumeSnapshotClass used for workspace
+	// snapshots (CP_VOLUME_SNAPSHOT_CLASS); empty uses the cluster default.
+	VolumeSnapshotClass string
+	// WorkspaceArchiveImage is the image that runs tar for workspace
+	// snapshots on clusters without the CSI snapshot API
+	// (CP_WORKSPACE_ARCHIVE_IMAGE).
+	WorkspaceArchiveImage string
+}
+
+// ArtifactStore configures where uploaded artifact content is kept. Backend is
+// "filesystem" (default, rooted at Dir) or "s3" (any S3-compatible endpoint).
+type ArtifactStore struct {
+	Backend           string
+	Dir               string
+	S3Endpoint        string
+	S3Bucket          string
+	S3Region          string
+	S3Prefix          string
+	S3AccessKeyID     string
+	S3SecretAccessKey string
+}
+
+// OIDC holds issuer settings and the claim-to-scope mapping table.
+type OIDC struct {
+	IssuerURL string
+	Audience  string
+	// IdentityClaim names the claim used as the audit actor (default "email",
+	// honoured only when email_verified is true).
+	IdentityClaim string
+	// TeamsClaim names the claim listing the caller's teams (default "groups").
+	TeamsClaim  string
+	ClaimScopes []OIDCClaimScope
+}
+
+// OIDCClaimScope grants Scopes to principals whose Claim equals (or, for
+// array claims like "groups", contains) Value. Parsed from CP_OIDC_CLAIM_SCOPES
+// as a JSON array, e.g. [{"claim":"groups","value":"kocao-admins","scopes":["*"]}].
+type OIDCClaimScope struct {
+	Claim  string   `json:"claim"`
+	Value  string   `json:"value"`
+	Scopes []string `json:"scopes"`
 }
 
 func Load() (Runtime, error) {
@@ -71,6 +138,27 @@ func LoadFrom(getenv func(string) string) (Runtime, error) {
 	if env == "prod" && bootstrapToken != "" {
 		return Runtime{}, fmt.Errorf("CP_BOOTSTRAP_TOKEN is not allowed when CP_ENV=prod")
 	}
+	bootstrapTokenSHA256 := strings.ToLower(strings.TrimSpace(getenv("CP_BOOTSTRAP_TOKEN_SHA256")))
+	if bootstrapTokenSHA256 != "" {
+		if b, err := hex.DecodeString(bootstrapTokenSHA256); err != nil || len(b) != sha256.Size {
+			return Runtime{}, fmt.Errorf("CP_BOOTSTRAP_TOKEN_SHA256 must be a hex-encoded sha256 digest")
+		}
+	}
+
+	oidc, err := loadOIDC(env, getenv)
+	if err != nil {
+		return Runtime{}, err
+	}
+
+	artifactStore, err := loadArtifactStore(getenv)
+	if err != nil {
+		return Runtime{}, err
+	}
+
+	runMaximums, err := loadRunResourceMaximums(getenv)
+	if err != nil {
+		return Runtime{}, err
+	}
 
 	var allowedOrigins []string
 	if raw := strings.TrimSpace(getenv("CP_ATTACH_WS_ALLOWED_ORIGINS")); raw != "" {
@@ -89,10 +177,108 @@ func LoadFrom(getenv func(string) string) (Runtime, error) {
 		AuditPath:              auditPath,
 		AttachWSAllowedOrigins: allowedOrigins,
 		BootstrapToken:         bootstrapToken,
+		BootstrapTokenSHA256:   bootstrapTokenSHA256,
 		Namespace:              ns,
+		OIDC:                   oidc,
+		ArtifactStore:          artifactStore,
+		GitHubWebhookSecret:    strings.TrimSpace(getenv("CP_GITHUB_WEBHOOK_SECRET")),
+		RunResourceMaximums:    runMaximums,
+		VolumeSnapshotClass:    strings.TrimSpace(getenv("CP_VOLUME_SNAPSHOT_CLASS")),
+		WorkspaceArchiveImage:  strings.TrimSpace(getenv("CP_WORKSPACE_ARCHIVE_IMAGE")),
 	}, nil
 }
 
+func loadRunResourceMaximums(getenv func(string) string) (map[string]string, error) {
+	var out map[string]string
+	for _, entry := range []struct{ env, name string }{
+		{"CP_RUN_MAX_CPU", "cpu"},
+		{"CP_RUN_MAX_MEMORY", "memory"},
+		{"CP_RUN_MAX_EPHEMERAL_STORAGE", "ephemeral-storage"},
+	} {
+		raw := strings.TrimSpace(getenv(entry.env))
+		if raw == "" {
+			continue
+		}
+		if q, err := resource.ParseQuantity(raw); err != nil || q.Sign() <= 0 {
+			return nil, fmt.Errorf("%s must be a positive quantity (got %q)", entry.env, raw)
+		}
+		if out == nil {
+			out = map[string]string{}
+		}
+		out[entry.name] = raw
+	}
+	return out, nil
+}
+
+func loadArtifactStore(getenv func(string) string) (ArtifactStore, error) {
+	out := ArtifactStore{
+		Backend:           strings.ToLower(strings.TrimSpace(getenv("CP_ARTIFACT_STORE"))),
+		Dir:               strings.TrimSpace(getenv("CP_ARTIFACT_DIR")),
+		S3Endpoint:        strings.TrimSpace(getenv("CP_ARTIFACT_S3_ENDPOINT")),
+		S3Bucket:          strings.TrimSpace(getenv("CP_ARTIFACT_S3_BUCKET")),
+		S3Region:          strings.TrimSpace(getenv("CP_ARTIFACT_S3_REGION")),
+		S3Prefix:          strings.TrimSpace(getenv("CP_ARTIFACT_S3_PREFIX")),
+		S3AccessKeyID:     strings.TrimSpace(getenv("CP_ARTIFACT_S3_ACCESS_KEY_ID")),
+		S3SecretAccessKey: strings.TrimSpace(getenv("CP_ARTIFACT_S3_SECRET_ACCESS_KEY")),
+	}
+	switch out.Backend {
+	case "":
+		out.Backend = "filesystem"
+	case "filesystem":
+	case "s3":
+		if out.S3Endpoint == "" || out.S3Bucket == "" {
+			return ArtifactStore{}, fmt.Errorf("CP_ARTIFACT_S3_ENDPOINT and CP_ARTIFACT_S3_BUCKET are required when CP_ARTIFACT_STORE=s3")
+		}
+		if u, err := url.Parse(out.S3Endpoint); err != nil || u.Host == "" {
+			return ArtifactStore{}, fmt.Errorf("CP_ARTIFACT_S3_ENDPOINT invalid (%q)", out.S3Endpoint)
+		}
+		if out.S3AccessKeyID == "" || out.S3SecretAccessKey == "" {
+			return ArtifactStore{}, fmt.Errorf("CP_ARTIFACT_S3_ACCESS_KEY_ID and CP_ARTIFACT_S3_SECRET_ACCESS_KEY are required when CP_ARTIFACT_STORE=s3")
+		}
+		if out.S3Region == "" {
+			out.S3Region = "us-east-1"
+		}
+	default:
+		return ArtifactStore{}, fmt.Errorf("CP_ARTIFACT_STORE must be one of filesystem|s3 (got %q)", out.Backend)
+	}
+	return out, nil
+}
+
+func loadOIDC(env string, getenv func(string) string) (OIDC, error) {
+	issuer := strings.TrimSpace(getenv("CP_OIDC_ISSUER_URL"))
+	if issuer == "" {
+		return OIDC{}, nil
+	}
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" {
+		return OIDC{}, fmt.Errorf("CP_OIDC_ISSUER_URL invalid (%q)", issuer)
+	}
+	if u.Scheme != "https" && (env == "prod" || u.Scheme != "http") {
+		return OIDC{}, fmt.Errorf("CP_OIDC_ISSUER_URL must use https when CP_ENV=prod")
+	}
+	audience := strings.TrimSpace(getenv("CP_OIDC_AUDIENCE"))
+	if audience == "" {
+		return OIDC{}, fmt.Errorf("CP_OIDC_AUDIENCE is required when CP_OIDC_ISSUER_URL is set")
+	}
+	out := OIDC{
+		IssuerURL:     issuer,
+		Audience:      audience,
+		IdentityClaim: strings.TrimSpace(getenv("CP_OIDC_IDENTITY_CLAIM")),
+		TeamsClaim:    strings.TrimSpace(getenv("CP_OIDC_TEAMS_CLAIM")),
+	}
+	if raw := strings.TrimSpace(getenv("CP_OIDC_CLAIM_SCOPES")); raw != "" {
+		if err := json.Unmarshal([]byte(raw), &out.ClaimScopes); err != nil {
+			return OIDC{}, fmt.Errorf("CP_OIDC_CLAIM_SCOPES must be a JSON array of {claim,value,scopes}: %w", err)
+		}
+		for i, m := range out.ClaimScopes {
+			if strings.TrimSpace(m.Claim) == "" || len(m.Scopes) == 0 {
+				return OIDC{}, fmt.Errorf("CP_OIDC_CLAIM_SCOPES[%d] requires claim and scopes", i)
+			}
+		}
+	}
+	return out, nil
+}
+
 func inCluster(getenv func(string) string) bool {
 	if strings.EqualFold(strings.TrimSpace(getenv("CP_IN_CLUSTER")), "true") {
 		return true
@@ -1,6 +1,9 @@
 package config
 
-import "testing"
+import (
+	"strings"
+	"testing"
+)
 
 func TestLoadFrom_ValidDefaults(t *testing.T) {
 	cfg, err := LoadFrom(mapGetenv(map[string]string{}))
@@ -79,6 +82,90 @@ func TestLoadFrom_ProdRejectsBootstrapToken(t *testing.T) {
 	}
 }
 
+func TestLoadFrom_ProdAllowsBootstrapTokenDigest(t *testing.T) {
+	digest := "9F86D081884C7D659A2FEAA0C55AD015A3BF4F1B2B0B822CD15D6C15B0F00A08"
+	cfg, err := LoadFrom(mapGetenv(map[string]string{"CP_ENV": "prod", "CP_BOOTSTRAP_TOKEN_SHA256": digest}))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if cfg.BootstrapTokenSHA256 != "9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08" {
+		t.Fatalf("BootstrapTokenSHA256 = %q", cfg.BootstrapTokenSHA256)
+	}
+}
+
+func TestLoadFrom_RejectsMalformedBootstrapTokenDigest(t *testing.T) {
+	_, err := LoadFrom(mapGetenv(map[string]string{"CP_BOOTSTRAP_TOKEN_SHA256": "not-a-digest"}))
+	if err == nil {
+		t.Fatalf("expected error")
+	}
+}
+
+func TestLoadFrom_OIDCClaimScopes(t *testing.T) {
+	cfg, err := LoadFrom(mapGetenv(map[string]string{
+		"CP_OIDC_ISSUER_URL":   "https://idp.example.com",
+		"CP_OIDC_AUDIENCE":     "kocao",
+		"CP_OIDC_CLAIM_SCOPES": `[{"claim":"groups","value":"platform","scopes":["workspace-session:read","workspace-session:write"]}]`,
+	}))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if cfg.OIDC.IssuerURL != "https://idp.example.com" || cfg.OIDC.Audience != "kocao" {
+		t.Fatalf("unexpected oidc config: %+v", cfg.OIDC)
+	}
+	if len(cfg.OIDC.ClaimScopes) != 1 || cfg.OIDC.ClaimScopes[0].Value != "platform" || len(cfg.OIDC.ClaimScopes[0].Scopes) != 2 {
+		t.Fatalf("unexpected claim scopes: %+v", cfg.OIDC.ClaimScopes)
+	}
+}
+
+func TestLoadFrom_OIDCRequiresAudienceAndValidMapping(t *testing.T) {
+	cases := []map[string]string{
+		{"CP_OIDC_ISSUER_URL": "https://idp.example.com"},
+		{"CP_OIDC_ISSUER_URL": "https://idp.example.com", "CP_OIDC_AUDIENCE": "kocao", "CP_OIDC_CLAIM_SCOPES": "groups=admins"},
+		{"CP_OIDC_ISSUER_URL": "https://idp.example.com", "CP_OIDC_AUDIENCE": "kocao", "CP_OIDC_CLAIM_SCOPES": `[{"claim":"groups","value":"x"}]`},
+		{"CP_ENV": "prod", "CP_OIDC_ISSUER_URL": "http://idp.example.com", "CP_OIDC_AUDIENCE": "kocao"},
+	}
+	for i, env := range cases {
+		if _, err := LoadFrom(mapGetenv(env)); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
+func TestLoadFrom_ArtifactStore(t *testing.T) {
+	cfg, err := LoadFrom(mapGetenv(map[string]string{}))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if cfg.ArtifactStore.Backend != "filesystem" {
+		t.Fatalf("default artifact backend = %q", cfg.ArtifactStore.Backend)
+	}
+
+	cfg, err = LoadFrom(mapGetenv(map[string]string{
+		"CP_ARTIFACT_STORE":                "s3",
+		"CP_ARTIFACT_S3_ENDPOINT":          "http://minio:9000",
+		"CP_ARTIFACT_S3_BUCKET":            "kocao",
+		"CP_ARTIFACT_S3_ACCESS_KEY_ID":     "minio",
+		"CP_ARTIFACT_S3_SECRET_ACCESS_KEY": "minio123",
+	}))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if cfg.ArtifactStore.S3Region != "us-east-1" || cfg.ArtifactStore.S3Bucket != "kocao" {
+		t.Fatalf("unexpected s3 config: %+v", cfg.ArtifactStore)
+	}
+
+	cases := []map[string]string{
+		{"CP_ARTIFACT_STORE": "gcs"},
+		{"CP_ARTIFACT_STORE": "s3", "CP_ARTIFACT_S3_BUCKET": "kocao"},
+		{"CP_ARTIFACT_STORE": "s3", "CP_ARTIFACT_S3_ENDPOINT": "http://minio:9000", "CP_ARTIFACT_S3_BUCKET": "kocao"},
+	}
+	for i, env := range cases {
+		if _, err := LoadFrom(mapGetenv(env)); err == nil {
+			t.Fatalf("case %d: expected error", i)
+		}
+	}
+}
+
 func TestLoadFrom_AttachAllowedOrigins_ParsesCSV(t *testing.T) {
 	cfg, err := LoadFrom(mapGetenv(map[string]string{"CP_ATTACH_WS_ALLOWED_ORIGINS": " https://a.example ,http://localhost:5173, ,https://b.example "}))
 	if err != nil {
@@ -98,6 +185,19 @@ func TestLoadFrom_AttachAllowedOrigins_ParsesCSV(t *testing.T) {
 	}
 }
 
+func TestLoadFrom_RunResourceMaximums(t *testing.T) {
+	cfg, err := LoadFrom(mapGetenv(map[string]string{"CP_RUN_MAX_CPU": "8", "CP_RUN_MAX_MEMORY": " 16Gi "}))
+	if err != nil {
+		t.Fatalf("expected nil error, got %v", err)
+	}
+	if len(cfg.RunResourceMaximums) != 2 || cfg.RunResourceMaximums["cpu"] != "8" || cfg.RunResourceMaximums["memory"] != "16Gi" {
+		t.Fatalf("RunResourceMaximums=%v", cfg.RunResourceMaximums)
+	}
+	if _, err := LoadFrom(mapGetenv(map[string]string{"CP_RUN_MAX_EPHEMERAL_STORAGE": "lots"})); err == nil || !strings.Contains(err.Error(), "CP_RUN_MAX_EPHEMERAL_STORAGE") {
+		t.Fatalf("expected ephemeral-storage maximum error, got %v", err)
+	}
+}
+
 func mapGetenv(m map[string]string) func(string) string {
 	return func(key string) string {
 		return m[key]
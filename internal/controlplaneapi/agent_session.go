@@ -32,6 +32,7 @@ const (
 	agentSessionBlockerRepoAccess            = "repo-access"
 	agentSessionBlockerNetwork               = "network"
 	agentSessionBlockerImagePull             = "image-pull"
+	agentSessionBlockerResources             = "resources"
 	agentSessionSandboxAgentPort             = 2468
 )
 
@@ -415,6 +416,7 @@ type AgentSessionService struct {
 	transport  agentSessionTransport
 	store      *AgentSessionStore
 	serviceCtx context.Context
+	activity   *runActivityRecorder
 
 	mu      sync.Mutex
 	bridges map[string]*agentSessionBridge
@@ -681,6 +683,7 @@ func (s *AgentSessionService) consumeSSE(stream io.Reader, bridge *agentSessionB
 				if json.Valid([]byte(payload)) {
 					event := bridge.appendEvent(json.RawMessage(append([]byte(nil), payload...)))
 					s.store.AppendEvent(bridge.runID, event)
+					s.activity.TouchRun(s.serviceCtx, bridge.runID)
 				}
 				dataLines = dataLines[:0]
 			}
@@ -734,6 +737,7 @@ func (s *AgentSessionService) Prompt(ctx context.Context, run *operatorv1alpha1.
 			message:    fmt.Sprintf("agent session is %s", strings.ToLower(string(state.Phase))),
 		}
 	}
+	s.activity.TouchRun(ctx, run.Name)
 	bridge := s.bridgeFor(run)
 	bridge.mu.Lock()
 	bridge.transitionLocked(operatorv1alpha1.AgentSessionPhaseRunning)
@@ -749,6 +753,12 @@ func (s *AgentSessionService) Prompt(ctx context.Context, run *operatorv1alpha1.
 		},
 	})
 	if err != nil {
+		if ctx.Err() != nil {
+			// The caller abandoned the turn, not the session: tell the agent
+			// to stop working on it and keep the session usable.
+			s.cancelPrompt(run, bridge.serverID, state.SessionID)
+			return nil, bridge.snapshot(), err
+		}
 		bridge.mu.Lock()
 		bridge.transitionLocked(operatorv1alpha1.AgentSessionPhaseFailed)
 		bridge.mu.Unlock()
@@ -760,6 +770,21 @@ func (s *AgentSessionService) Prompt(ctx context.Context, run *operatorv1alpha1.
 	return json.RawMessage(append([]byte(nil), body...)), state, nil
 }
 
+// cancelPrompt sends the ACP session/cancel notification for an in-flight
+// prompt turn.
+func (s *AgentSessionService) cancelPrompt(run *operatorv1alpha1.HarnessRun, serverID, sessionID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	_, err := s.transport.PostACP(ctx, run.Status.PodName, serverID, "", jsonRPCEnvelope{
+		JSONRPC: "2.0",
+		Method:  "session/cancel",
+		Params:  map[string]any{"sessionId": sessionID},
+	})
+	if err != nil {
+		slog.Warn("agent session prompt: cancel failed", "run", run.Name, "error", err)
+	}
+}
+
 func (s *AgentSessionService) ListEvents(offset int64, limit int, runIDs ...string) ([]agentSessionEvent, int64, bool) {
 	return s.store.ListEvents(offset, limit, runIDs...)
 }
@@ -1037,6 +1062,14 @@ func (a *API) agentSessionDiagnostic(ctx context.Context, run *operatorv1alpha1.
 		}
 	}
 
+	if pod.Status.Reason == "Evicted" {
+		return &agentSessionDiagnosticDTO{
+			Class:   agentSessionBlockerResources,
+			Summary: "Harness pod was evicted.",
+			Detail:  diagnosticDetail(pod.Status.Reason, pod.Status.Message),
+		}
+	}
+
 	for _, cond := range pod.Status.Conditions {
 		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
 			return &agentSessionDiagnosticDTO{
@@ -1099,6 +1132,8 @@ func diagnosticFromContainerStatus(status corev1.ContainerStatus, initContainer
 func classifyAgentSessionBlocker(containerName, reason, message string) (string, string, bool) {
 	combined := strings.ToLower(strings.Join([]string{containerName, reason, message}, " "))
 	switch {
+	case containsAny(combined, "oomkilled", "evicted", "ephemeral-storage", "ephemeral local storage"):
+		return agentSessionBlockerResources, "Resource limits are blocking session readiness.", true
 	case containsAny(combined, "imagepullbackoff", "errimagepull", "failed to pull image", "pull access denied", "back-off pulling image", "invalidimagename"):
 		return agentSessionBlockerImagePull, "Image pull is blocking session readiness.", true
 	case containsAny(combined, "dial tcp", "i/o timeout", "no such host", "network is unreachable", "connection refused", "tls handshake timeout", "temporary failure in name resolution", "egress"):
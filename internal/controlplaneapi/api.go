@@ -10,9 +10,11 @@ import (
 	"strings"
 	"time"
 
+	"github.com/withakay/kocao/internal/egressproxy"
 	"github.com/withakay/kocao/internal/namegen"
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
 	"github.com/withakay/kocao/internal/operator/controllers"
+	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
@@ -44,6 +46,12 @@ type API struct {
 	Attach                   *AttachService
 	AgentSessions            *AgentSessionService
 	RemoteAgentOrchestration *RemoteAgentOrchestrationService
+	ArtifactBlobs            ArtifactBlobStore
+	GitHubWebhooks           *GitHubWebhookService
+	WorkspaceSnapshots       *WorkspaceSnapshotService
+	// RunResourceMaximums caps the harness container resources of runs
+	// created through the API.
+	RunResourceMaximums corev1.ResourceList
 
 	attachOrigins attachOriginAllowlist
 }
@@ -51,6 +59,25 @@ type API struct {
 type Options struct {
 	Env                    string
 	AttachWSAllowedOrigins []string
+	// BootstrapTokenSHA256 seeds a wildcard token by digest, for environments
+	// where the raw bootstrap secret must not be configured.
+	BootstrapTokenSHA256 string
+	// OIDC, when IssuerURL is set, accepts issuer-signed JWTs as bearer tokens.
+	OIDC OIDCOptions
+	// ArtifactStore selects where uploaded remote-agent artifact content lives.
+	ArtifactStore ArtifactStoreOptions
+	// GitHubWebhookSecret enables the GitHub webhook endpoint; deliveries
+	// must be signed with it.
+	GitHubWebhookSecret string
+	// RunResourceMaximums caps run resources, keyed by cpu, memory and
+	// ephemeral-storage.
+	RunResourceMaximums map[string]string
+	// VolumeSnapshotClass is the VolumeSnapshotClass of workspace snapshots;
+	// empty uses the cluster default.
+	VolumeSnapshotClass string
+	// WorkspaceArchiveImage runs tar for workspace snapshots on clusters
+	// without the CSI snapshot API.
+	WorkspaceArchiveImage string
 }
 
 func (a *API) Handler() http.Handler {
@@ -80,7 +107,17 @@ func (a *API) serveAuthz(w http.ResponseWriter, r *http.Request, required []stri
 		writeError(w, http.StatusInternalServerError, "endpoint misconfigured")
 		return
 	}
-	h := requireScopes(required, a.Audit, describe)(http.HandlerFunc(next))
+	h := requireScopes(required, a.Audit, describe, nil)(http.HandlerFunc(next))
+	h.ServeHTTP(w, r)
+}
+
+// serveOwnedAuthz is serveAuthz plus per-owner authorization of the addressed resource.
+func (a *API) serveOwnedAuthz(w http.ResponseWriter, r *http.Request, required []string, describe func(*http.Request) (string, string, string), owner ownerResolver, next http.HandlerFunc) {
+	if len(required) == 0 || owner == nil {
+		writeError(w, http.StatusInternalServerError, "endpoint misconfigured")
+		return
+	}
+	h := requireScopes(required, a.Audit, describe, owner)(http.HandlerFunc(next))
 	h.ServeHTTP(w, r)
 }
 
@@ -109,62 +146,102 @@ func (a *API) serveAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	case len(segs) == 2 && segs[0] == "workspace-sessions" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"workspace-session:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"workspace-session:read"}, func(_ *http.Request) (string, string, string) {
 			return "workspace-session.get", "workspace-session", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleSessionGet(w, r, id) })
+		}, a.workspaceSessionOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleSessionGet(w, r, id) })
 		return
 	case len(segs) == 2 && segs[0] == "workspace-sessions" && r.Method == http.MethodDelete:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"workspace-session:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"workspace-session:write"}, func(_ *http.Request) (string, string, string) {
 			return "workspace-session.delete", "workspace-session", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleSessionDelete(w, r, id) })
+		}, a.workspaceSessionOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleSessionDelete(w, r, id) })
 		return
 	case len(segs) == 2 && segs[0] == "workspace-sessions":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "agent-sessions" && r.Method == http.MethodGet:
 		workspaceSessionID := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
 			return "agent-session.list", "workspace-session", workspaceSessionID
-		}, func(w http.ResponseWriter, r *http.Request) {
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) {
 			a.handleWorkspaceAgentSessionsList(w, r, workspaceSessionID)
 		})
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "agent-sessions":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
+	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "snapshots" && r.Method == http.MethodGet:
+		workspaceSessionID := segs[1]
+		a.serveOwnedAuthz(w, r, []string{"workspace-session:read"}, func(_ *http.Request) (string, string, string) {
+			return "workspace-snapshot.list", "workspace-session", workspaceSessionID
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) {
+			a.handleWorkspaceSnapshotsList(w, r, workspaceSessionID)
+		})
+		return
+	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "snapshots" && r.Method == http.MethodPost:
+		workspaceSessionID := segs[1]
+		a.serveOwnedAuthz(w, r, []string{"workspace-session:write"}, func(_ *http.Request) (string, string, string) {
+			return "workspace-snapshot.create", "workspace-session", workspaceSessionID
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) {
+			a.handleWorkspaceSnapshotsCreate(w, r, workspaceSessionID)
+		})
+		return
+	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "snapshots":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	case len(segs) == 2 && segs[0] == "workspace-snapshots" && r.Method == http.MethodGet:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{"workspace-session:read"}, func(_ *http.Request) (string, string, string) {
+			return "workspace-snapshot.get", "workspace-snapshot", id
+		}, a.workspaceSnapshotOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleWorkspaceSnapshotGet(w, r, id) })
+		return
+	case len(segs) == 2 && segs[0] == "workspace-snapshots" && r.Method == http.MethodDelete:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{"workspace-session:write"}, func(_ *http.Request) (string, string, string) {
+			return "workspace-snapshot.delete", "workspace-snapshot", id
+		}, a.workspaceSnapshotOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleWorkspaceSnapshotDelete(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "workspace-snapshots" && segs[2] == "restore" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{"workspace-session:write"}, func(_ *http.Request) (string, string, string) {
+			return "workspace-snapshot.restore", "workspace-snapshot", id
+		}, a.workspaceSnapshotOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleWorkspaceSnapshotRestore(w, r, id) })
+		return
+	case len(segs) >= 2 && segs[0] == "workspace-snapshots":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "harness-runs" && r.Method == http.MethodPost:
 		workspaceSessionID := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
 			return "harness-run.start", "workspace-session", workspaceSessionID
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleSessionRunsCreate(w, r, workspaceSessionID) })
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) { a.handleSessionRunsCreate(w, r, workspaceSessionID) })
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "harness-runs":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "attach-control" && r.Method == http.MethodPatch:
 		workspaceSessionID := segs[1]
-		a.serveAuthz(w, r, []string{"control:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"control:write"}, func(_ *http.Request) (string, string, string) {
 			return "attach-control.update", "workspace-session", workspaceSessionID
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleAttachControlPatch(w, r, workspaceSessionID) })
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) { a.handleAttachControlPatch(w, r, workspaceSessionID) })
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "attach-control":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "attach-token" && r.Method == http.MethodPost:
 		workspaceSessionID := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
 			return "attach.token.issue", "workspace-session", workspaceSessionID
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleAttachTokenIssue(w, r, workspaceSessionID) })
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) { a.handleAttachTokenIssue(w, r, workspaceSessionID) })
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "attach-token":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "attach-cookie" && r.Method == http.MethodPost:
 		workspaceSessionID := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
 			return "attach.cookie.issue", "workspace-session", workspaceSessionID
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleAttachCookieIssue(w, r, workspaceSessionID) })
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) { a.handleAttachCookieIssue(w, r, workspaceSessionID) })
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "attach-cookie":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -177,9 +254,9 @@ func (a *API) serveAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "egress-override" && r.Method == http.MethodPatch:
 		workspaceSessionID := segs[1]
-		a.serveAuthz(w, r, []string{"control:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"control:write"}, func(_ *http.Request) (string, string, string) {
 			return "egress-override.update", "workspace-session", workspaceSessionID
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleEgressOverridePatch(w, r, workspaceSessionID) })
+		}, a.workspaceSessionOwner(workspaceSessionID), func(w http.ResponseWriter, r *http.Request) { a.handleEgressOverridePatch(w, r, workspaceSessionID) })
 		return
 	case len(segs) == 3 && segs[0] == "workspace-sessions" && segs[2] == "egress-override":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
@@ -224,6 +301,15 @@ func (a *API) serveAPI(w http.ResponseWriter, r *http.Request) {
 	case len(segs) == 2 && segs[0] == "remote-agents":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
+	case len(segs) == 3 && segs[0] == "remote-agents" && segs[2] == "heartbeat" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent.heartbeat", "remote-agent", id
+		}, a.remoteAgentOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentHeartbeat(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "remote-agents" && segs[2] == "heartbeat":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
 	case len(segs) == 1 && segs[0] == "remote-agent-tasks" && r.Method == http.MethodGet:
 		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
 			return "remote-agent-task.list", "remote-agent-task", "*"
@@ -239,114 +325,232 @@ func (a *API) serveAPI(w http.ResponseWriter, r *http.Request) {
 		return
 	case len(segs) == 2 && segs[0] == "remote-agent-tasks" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
 			return "remote-agent-task.get", "remote-agent-task", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskGet(w, r, id) })
+		}, a.remoteAgentTaskOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskGet(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "cancel" && r.Method == http.MethodPost:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskWrite}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskWrite}, func(_ *http.Request) (string, string, string) {
 			return "remote-agent-task.cancel", "remote-agent-task", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskCancel(w, r, id) })
+		}, a.remoteAgentTaskOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskCancel(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "retry" && r.Method == http.MethodPost:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskWrite}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskWrite}, func(_ *http.Request) (string, string, string) {
 			return "remote-agent-task.retry", "remote-agent-task", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskRetry(w, r, id) })
+		}, a.remoteAgentTaskOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskRetry(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "transcript" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
 			return "remote-agent-task.transcript", "remote-agent-task", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskTranscriptGet(w, r, id) })
+		}, a.remoteAgentTaskOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskTranscriptGet(w, r, id) })
+		return
+	// Worker routes report on an assigned task. They take the worker scope
+	// that agents heartbeat with and the ownership of the assigned agent
+	// rather than the requester's, so a requester cannot forge progress and
+	// one agent's principal cannot report for another's task.
+	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "start" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-task.start", "remote-agent-task", id
+		}, a.remoteAgentTaskWorkerOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskStart(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "complete" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-task.complete", "remote-agent-task", id
+		}, a.remoteAgentTaskWorkerOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskComplete(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "fail" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-task.fail", "remote-agent-task", id
+		}, a.remoteAgentTaskWorkerOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskFail(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "transcript" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-task.transcript.append", "remote-agent-task", id
+		}, a.remoteAgentTaskWorkerOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskTranscriptAppend(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "artifacts" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-task.artifact.create", "remote-agent-task", id
+		}, a.remoteAgentTaskWorkerOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskArtifactCreate(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "remote-agent-tasks" && segs[2] == "artifacts" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
 			return "remote-agent-task.artifacts", "remote-agent-task", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskArtifactsGet(w, r, id) })
+		}, a.remoteAgentTaskOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskArtifactsGet(w, r, id) })
+		return
+	case len(segs) == 4 && segs[0] == "remote-agent-tasks" && segs[2] == "artifacts" && segs[3] == "upload" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-task.artifact.upload", "remote-agent-task", id
+		}, a.remoteAgentTaskWorkerOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentTaskArtifactUpload(w, r, id) })
+		return
+	case len(segs) == 5 && segs[0] == "remote-agent-tasks" && segs[2] == "artifacts" && segs[4] == "content" && r.Method == http.MethodGet:
+		id, artifactID := segs[1], segs[3]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-task.artifact.download", "remote-agent-task", id
+		}, a.remoteAgentTaskOwner(id), func(w http.ResponseWriter, r *http.Request) {
+			a.handleRemoteAgentTaskArtifactDownload(w, r, id, artifactID)
+		})
 		return
 	case len(segs) >= 2 && segs[0] == "remote-agent-tasks":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
+	case len(segs) == 1 && segs[0] == "remote-agent-workflows" && r.Method == http.MethodGet:
+		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-workflow.list", "remote-agent-workflow", "*"
+		}, a.handleRemoteAgentWorkflowsList)
+		return
+	case len(segs) == 1 && segs[0] == "remote-agent-workflows" && r.Method == http.MethodPost:
+		a.serveAuthz(w, r, []string{ScopeRemoteAgentTaskWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-workflow.create", "remote-agent-workflow", "(new)"
+		}, a.handleRemoteAgentWorkflowsCreate)
+		return
+	case len(segs) == 1 && segs[0] == "remote-agent-workflows":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	case len(segs) == 2 && segs[0] == "remote-agent-workflows" && r.Method == http.MethodGet:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskRead}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-workflow.get", "remote-agent-workflow", id
+		}, a.remoteAgentWorkflowOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentWorkflowGet(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "remote-agent-workflows" && segs[2] == "cancel" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveOwnedAuthz(w, r, []string{ScopeRemoteAgentTaskWrite}, func(_ *http.Request) (string, string, string) {
+			return "remote-agent-workflow.cancel", "remote-agent-workflow", id
+		}, a.remoteAgentWorkflowOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRemoteAgentWorkflowCancel(w, r, id) })
+		return
+	case len(segs) >= 2 && segs[0] == "remote-agent-workflows":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
 	case len(segs) == 1 && segs[0] == "harness-runs":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 2 && segs[0] == "harness-runs" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
 			return "harness-run.get", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunGet(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunGet(w, r, id) })
 		return
 	case len(segs) == 2 && segs[0] == "harness-runs":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 3 && segs[0] == "harness-runs" && segs[2] == "agent-session" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
 			return "agent-session.get", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionGet(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionGet(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "harness-runs" && segs[2] == "agent-session" && r.Method == http.MethodPost:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
 			return "agent-session.create", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionCreate(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionCreate(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "harness-runs" && segs[2] == "agent-session":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 4 && segs[0] == "harness-runs" && segs[2] == "agent-session" && segs[3] == "prompt" && r.Method == http.MethodPost:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
 			return "agent-session.prompt", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionPrompt(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionPrompt(w, r, id) })
 		return
 	case len(segs) == 4 && segs[0] == "harness-runs" && segs[2] == "agent-session" && segs[3] == "events" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
 			return "agent-session.events", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionEvents(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionEvents(w, r, id) })
 		return
 	case len(segs) == 5 && segs[0] == "harness-runs" && segs[2] == "agent-session" && segs[3] == "events" && segs[4] == "stream" && r.Method == http.MethodGet:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:read"}, func(_ *http.Request) (string, string, string) {
 			return "agent-session.events.stream", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionEventsStream(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionEventsStream(w, r, id) })
 		return
 	case len(segs) == 4 && segs[0] == "harness-runs" && segs[2] == "agent-session" && segs[3] == "stop" && r.Method == http.MethodPost:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
 			return "agent-session.stop", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionStop(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunAgentSessionStop(w, r, id) })
 		return
 	case len(segs) >= 4 && segs[0] == "harness-runs" && segs[2] == "agent-session":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 3 && segs[0] == "harness-runs" && segs[2] == "stop" && r.Method == http.MethodPost:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
 			return "harness-run.stop", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunStopPost(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunStopPost(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "harness-runs" && segs[2] == "stop":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
 	case len(segs) == 3 && segs[0] == "harness-runs" && segs[2] == "resume" && r.Method == http.MethodPost:
 		id := segs[1]
-		a.serveAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
+		a.serveOwnedAuthz(w, r, []string{"harness-run:write"}, func(_ *http.Request) (string, string, string) {
 			return "harness-run.resume", "harness-run", id
-		}, func(w http.ResponseWriter, r *http.Request) { a.handleRunResumePost(w, r, id) })
+		}, a.harnessRunOwner(id), func(w http.ResponseWriter, r *http.Request) { a.handleRunResumePost(w, r, id) })
 		return
 	case len(segs) == 3 && segs[0] == "harness-runs" && segs[2] == "resume":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
+	case len(segs) == 1 && segs[0] == "tokens" && r.Method == http.MethodGet:
+		a.serveAuthz(w, r, []string{ScopeTokenRead}, func(_ *http.Request) (string, string, string) {
+			return "token.list", "token", "*"
+		}, a.handleTokensList)
+		return
+	case len(segs) == 1 && segs[0] == "tokens" && r.Method == http.MethodPost:
+		a.serveAuthz(w, r, []string{ScopeTokenWrite}, func(_ *http.Request) (string, string, string) {
+			return "token.create", "token", "(new)"
+		}, a.handleTokensCreate)
+		return
+	case len(segs) == 1 && segs[0] == "tokens":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	case len(segs) == 2 && segs[0] == "tokens" && r.Method == http.MethodGet:
+		id := segs[1]
+		a.serveAuthz(w, r, []string{ScopeTokenRead}, func(_ *http.Request) (string, string, string) {
+			return "token.get", "token", id
+		}, func(w http.ResponseWriter, r *http.Request) { a.handleTokenGet(w, r, id) })
+		return
+	case len(segs) == 2 && segs[0] == "tokens" && r.Method == http.MethodDelete:
+		id := segs[1]
+		a.serveAuthz(w, r, []string{ScopeTokenWrite}, func(_ *http.Request) (string, string, string) {
+			return "token.revoke", "token", id
+		}, func(w http.ResponseWriter, r *http.Request) { a.handleTokenRevoke(w, r, id) })
+		return
+	case len(segs) == 2 && segs[0] == "tokens":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	case len(segs) == 3 && segs[0] == "tokens" && segs[2] == "rotate" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveAuthz(w, r, []string{ScopeTokenWrite}, func(_ *http.Request) (string, string, string) {
+			return "token.rotate", "token", id
+		}, func(w http.ResponseWriter, r *http.Request) { a.handleTokenRotate(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "tokens" && segs[2] == "rotate":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
 	case len(segs) == 1 && segs[0] == "audit" && r.Method == http.MethodGet:
 		a.serveAuthz(w, r, []string{"audit:read"}, func(_ *http.Request) (string, string, string) {
 			return "audit.list", "audit", "*"
 		}, a.handleAuditList)
 		return
+	case len(segs) == 1 && segs[0] == "audit" && r.Method == http.MethodPost:
+		a.serveAuthz(w, r, []string{ScopeAuditWrite}, func(_ *http.Request) (string, string, string) {
+			return "audit.ingest", "audit", "(new)"
+		}, a.handleAuditIngest)
+		return
 	case len(segs) == 1 && segs[0] == "audit":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
@@ -401,9 +605,36 @@ func (a *API) serveAPI(w http.ResponseWriter, r *http.Request) {
 			return "symphony-project.refresh", "symphony-project", id
 		}, func(w http.ResponseWriter, r *http.Request) { a.handleSymphonyProjectRefresh(w, r, id) })
 		return
+	case len(segs) == 3 && segs[0] == "symphony-projects" && segs[2] == "plan" && r.Method == http.MethodPost:
+		id := segs[1]
+		a.serveAuthz(w, r, []string{ScopeSymphonyProjectControl}, func(_ *http.Request) (string, string, string) {
+			return "symphony-project.plan", "symphony-project", id
+		}, func(w http.ResponseWriter, r *http.Request) { a.handleSymphonyProjectPlanRequest(w, r, id) })
+		return
+	case len(segs) == 3 && segs[0] == "symphony-projects" && segs[2] == "plan" && r.Method == http.MethodGet:
+		id := segs[1]
+		a.serveAuthz(w, r, []string{ScopeSymphonyProjectRead}, func(_ *http.Request) (string, string, string) {
+			return "symphony-project.plan.get", "symphony-project", id
+		}, func(w http.ResponseWriter, r *http.Request) { a.handleSymphonyProjectPlanGet(w, r, id) })
+		return
 	case len(segs) == 3 && segs[0] == "symphony-projects":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
+	case len(segs) == 2 && segs[0] == "webhooks" && segs[1] == "github" && r.Method == http.MethodPost:
+		// Authenticated by the delivery's HMAC signature, not a bearer token.
+		a.handleGitHubWebhook(w, r)
+		return
+	case len(segs) == 2 && segs[0] == "webhooks" && segs[1] == "github":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	case len(segs) == 3 && segs[0] == "webhooks" && segs[1] == "github" && segs[2] == "deliveries" && r.Method == http.MethodGet:
+		a.serveAuthz(w, r, []string{ScopeSymphonyProjectRead}, func(_ *http.Request) (string, string, string) {
+			return "github-webhook.deliveries", "github-webhook", "*"
+		}, a.handleGitHubWebhookDeliveries)
+		return
+	case len(segs) == 3 && segs[0] == "webhooks" && segs[1] == "github" && segs[2] == "deliveries":
+		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
 	case len(segs) == 1 && segs[0] == "cluster-overview":
 		writeError(w, http.StatusMethodNotAllowed, "method not allowed")
 		return
@@ -425,14 +656,21 @@ func (a *API) serveAPI(w http.ResponseWriter, r *http.Request) {
 type sessionCreateRequest struct {
 	DisplayName string `json:"displayName,omitempty"`
 	RepoURL     string `json:"repoURL,omitempty"`
+	Team        string `json:"team,omitempty"`
 }
 
 type sessionResponse struct {
 	ID          string                        `json:"id"`
 	DisplayName string                        `json:"displayName,omitempty"`
 	RepoURL     string                        `json:"repoURL,omitempty"`
+	Owner       string                        `json:"owner,omitempty"`
+	Team        string                        `json:"team,omitempty"`
 	Phase       operatorv1alpha1.SessionPhase `json:"phase,omitempty"`
 	CreatedAt   string                        `json:"createdAt,omitempty"`
+
+	// RestoredFrom is the workspace snapshot the session was restored from.
+	RestoredFrom string `json:"restoredFrom,omitempty"`
+	RestorePhase string `json:"restorePhase,omitempty"`
 }
 
 func sessionToResponse(s *operatorv1alpha1.Session) sessionResponse {
@@ -440,7 +678,8 @@ func sessionToResponse(s *operatorv1alpha1.Session) sessionResponse {
 	if !s.CreationTimestamp.IsZero() {
 		createdAt = s.CreationTimestamp.Time.UTC().Format(time.RFC3339)
 	}
-	return sessionResponse{ID: s.Name, DisplayName: s.Spec.DisplayName, RepoURL: s.Spec.RepoURL, Phase: s.Status.Phase, CreatedAt: createdAt}
+	owner := sessionOwner(s)
+	return sessionResponse{ID: s.Name, DisplayName: s.Spec.DisplayName, RepoURL: s.Spec.RepoURL, Owner: owner.Owner, Team: owner.Team, Phase: s.Status.Phase, CreatedAt: createdAt, RestoredFrom: s.Annotations[annotationWorkspaceRestoredFrom], RestorePhase: s.Annotations[annotationWorkspaceRestorePhase]}
 }
 
 func (a *API) handleSessionsList(w http.ResponseWriter, r *http.Request) {
@@ -451,6 +690,9 @@ func (a *API) handleSessionsList(w http.ResponseWriter, r *http.Request) {
 	}
 	out := make([]sessionResponse, 0, len(list.Items))
 	for i := range list.Items {
+		if !visibleTo(r.Context(), sessionOwner(&list.Items[i])) {
+			continue
+		}
 		out = append(out, sessionToResponse(&list.Items[i]))
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"workspaceSessions": out})
@@ -462,11 +704,27 @@ func (a *API) handleSessionsCreate(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, err)
 		return
 	}
-	displayName := strings.TrimSpace(req.DisplayName)
+	team, err := resolveRequestTeam(r.Context(), req.Team)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	sess, err := a.createSession(r.Context(), req.DisplayName, req.RepoURL, team, nil)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, sessionToResponse(sess))
+}
+
+// createSession creates a workspace session owned by the caller, generating
+// a display name when none is given.
+func (a *API) createSession(ctx context.Context, displayName, repoURL, team string, annotations map[string]string) (*operatorv1alpha1.Session, error) {
+	displayName = strings.TrimSpace(displayName)
 	if displayName == "" {
 		existing := func(candidate string) bool {
 			var list operatorv1alpha1.SessionList
-			if err := a.K8s.List(r.Context(), &list, client.InNamespace(a.Namespace)); err != nil {
+			if err := a.K8s.List(ctx, &list, client.InNamespace(a.Namespace)); err != nil {
 				return true
 			}
 			for _, s := range list.Items {
@@ -478,20 +736,17 @@ func (a *API) handleSessionsCreate(w http.ResponseWriter, r *http.Request) {
 		}
 		name, err := namegen.GenerateUnique(existing)
 		if err != nil {
-			writeError(w, http.StatusInternalServerError, "failed to generate unique display name")
-			return
+			return nil, &requestError{status: http.StatusInternalServerError, msg: "failed to generate unique display name"}
 		}
 		displayName = name
 	} else {
 		var list operatorv1alpha1.SessionList
-		if err := a.K8s.List(r.Context(), &list, client.InNamespace(a.Namespace)); err != nil {
-			writeError(w, http.StatusInternalServerError, "list workspace sessions failed")
-			return
+		if err := a.K8s.List(ctx, &list, client.InNamespace(a.Namespace)); err != nil {
+			return nil, &requestError{status: http.StatusInternalServerError, msg: "list workspace sessions failed"}
 		}
 		for _, s := range list.Items {
 			if s.Spec.DisplayName == displayName {
-				writeError(w, http.StatusConflict, "display name already in use")
-				return
+				return nil, &requestError{status: http.StatusConflict, msg: "display name already in use"}
 			}
 		}
 	}
@@ -499,14 +754,17 @@ func (a *API) handleSessionsCreate(w http.ResponseWriter, r *http.Request) {
 	id := newID()
 	sess := &operatorv1alpha1.Session{
 		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "Session"},
-		ObjectMeta: metav1.ObjectMeta{Name: id, Namespace: a.Namespace},
-		Spec:       operatorv1alpha1.SessionSpec{DisplayName: displayName, RepoURL: req.RepoURL},
+		ObjectMeta: metav1.ObjectMeta{Name: id, Namespace: a.Namespace, Labels: map[string]string{}, Annotations: map[string]string{}},
+		Spec:       operatorv1alpha1.SessionSpec{DisplayName: displayName, RepoURL: repoURL},
 	}
-	if err := a.K8s.Create(r.Context(), sess); err != nil {
-		writeError(w, http.StatusInternalServerError, "create workspace session failed")
-		return
+	for k, v := range annotations {
+		sess.Annotations[k] = v
 	}
-	writeJSON(w, http.StatusCreated, sessionToResponse(sess))
+	stampOwner(ctx, sess.Labels, sess.Annotations, team)
+	if err := a.K8s.Create(ctx, sess); err != nil {
+		return nil, &requestError{status: http.StatusInternalServerError, msg: "create workspace session failed"}
+	}
+	return sess, nil
 }
 
 func (a *API) handleSessionGet(w http.ResponseWriter, r *http.Request, id string) {
@@ -651,6 +909,20 @@ type runCreateRequest struct {
 	AgentSession            *operatorv1alpha1.AgentSessionSpec        `json:"agentSession,omitempty"`
 	ImagePullSecrets        []string                                  `json:"imagePullSecrets,omitempty"`
 	TTLSecondsAfterFinished *int32                                    `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// PullRequest pushes the run's changes and opens a pull request once it
+	// succeeds; it needs gitAuth.
+	PullRequest *operatorv1alpha1.HarnessRunPullRequestSpec `json:"pullRequest,omitempty"`
+	// Resources overrides the image profile's resource defaults.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+	// RunClass picks an admin-defined scheduling class for the run's pod.
+	RunClass string `json:"runClass,omitempty"`
+	// EgressAllowedHosts lists the hostnames reachable in proxy egress mode.
+	EgressAllowedHosts []string `json:"egressAllowedHosts,omitempty"`
+	// ActiveDeadlineSeconds and IdleTimeoutSeconds stop the run after a
+	// wall-clock limit or a stretch without activity.
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+	IdleTimeoutSeconds    *int64 `json:"idleTimeoutSeconds,omitempty"`
 }
 
 // isAllowedRepoURL validates that the repo URL uses an https scheme to prevent
@@ -671,6 +943,8 @@ func normalizeRunEgressMode(mode string) (string, bool) {
 		return "", true
 	case "restricted", "github-only", "github":
 		return "restricted", true
+	case "proxy", "allowlist":
+		return "proxy", true
 	case "full", "full-internet", "internet":
 		return "full", true
 	default:
@@ -697,11 +971,18 @@ type runResponse struct {
 	Phase              operatorv1alpha1.HarnessRunPhase                 `json:"phase,omitempty"`
 	PodName            string                                           `json:"podName,omitempty"`
 	AgentSession       *agentSessionResponse                            `json:"agentSession,omitempty"`
+	Owner              string                                           `json:"owner,omitempty"`
+	Team               string                                           `json:"team,omitempty"`
 
 	// GitHub outcome metadata (optional)
 	GitHubBranch      string `json:"gitHubBranch,omitempty"`
 	PullRequestURL    string `json:"pullRequestURL,omitempty"`
 	PullRequestStatus string `json:"pullRequestStatus,omitempty"`
+
+	// Reason and Message say why the run stopped early, e.g. TimedOut.
+	Reason         string       `json:"reason,omitempty"`
+	Message        string       `json:"message,omitempty"`
+	LastActivityAt *metav1.Time `json:"lastActivityAt,omitempty"`
 }
 
 func runToResponse(run *operatorv1alpha1.HarnessRun, sessionDisplayName string) runResponse {
@@ -747,9 +1028,14 @@ func runToResponse(run *operatorv1alpha1.HarnessRun, sessionDisplayName string)
 		Phase:              run.Status.Phase,
 		PodName:            run.Status.PodName,
 		AgentSession:       agentSession,
+		Owner:              ann[annotationOwner],
+		Team:               run.Labels[labelTeam],
 		GitHubBranch:       ann[controllers.AnnotationGitHubBranch],
 		PullRequestURL:     ann[controllers.AnnotationPullRequestURL],
 		PullRequestStatus:  ann[controllers.AnnotationPullRequestStatus],
+		Reason:             run.Status.Reason,
+		Message:            run.Status.Message,
+		LastActivityAt:     run.Status.LastActivityTime,
 	}
 }
 
@@ -763,6 +1049,14 @@ func (a *API) handleSessionRunsCreate(w http.ResponseWriter, r *http.Request, wo
 		writeError(w, http.StatusInternalServerError, "get workspace session failed")
 		return
 	}
+	switch sess.Annotations[annotationWorkspaceRestorePhase] {
+	case workspaceSnapshotPhasePending:
+		writeError(w, http.StatusConflict, "workspace restore in progress")
+		return
+	case workspaceSnapshotPhaseFailed:
+		writeError(w, http.StatusConflict, "workspace restore failed")
+		return
+	}
 
 	var req runCreateRequest
 	if err := readJSON(w, r, &req); err != nil {
@@ -800,6 +1094,24 @@ func (a *API) handleSessionRunsCreate(w http.ResponseWriter, r *http.Request, wo
 			return
 		}
 	}
+	if req.ActiveDeadlineSeconds != nil && *req.ActiveDeadlineSeconds < 0 {
+		writeError(w, http.StatusBadRequest, "activeDeadlineSeconds must not be negative")
+		return
+	}
+	if req.IdleTimeoutSeconds != nil && *req.IdleTimeoutSeconds < 0 {
+		writeError(w, http.StatusBadRequest, "idleTimeoutSeconds must not be negative")
+		return
+	}
+	if req.PullRequest != nil {
+		if req.GitAuth == nil || strings.TrimSpace(req.GitAuth.SecretName) == "" {
+			writeError(w, http.StatusBadRequest, "pullRequest requires gitAuth")
+			return
+		}
+		if strings.TrimSpace(req.PullRequest.Branch) == "" || strings.TrimSpace(req.PullRequest.Title) == "" {
+			writeError(w, http.StatusBadRequest, "pullRequest.branch and pullRequest.title required")
+			return
+		}
+	}
 	if req.AgentSession != nil {
 		req.AgentSession.ApplyDefaults()
 		if !a.allowMockAgentFixture(req.AgentSession) {
@@ -814,11 +1126,25 @@ func (a *API) handleSessionRunsCreate(w http.ResponseWriter, r *http.Request, wo
 		writeError(w, http.StatusBadRequest, "invalid egressMode")
 		return
 	}
+	if err := operatorv1alpha1.ValidateEgressAllowedHosts("egressAllowedHosts", req.EgressAllowedHosts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
 	imageProfileSpec, imageProfileStatus, err := normalizeHarnessImageProfileSelection(req.ImageProfile)
 	if err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
+	resources, err := a.resolveRunResources(imageProfileStatus, req.Resources)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	scheduling, err := runClassScheduling(req.RunClass)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
 
 	id := newID()
 	var agentSessionStatus *operatorv1alpha1.AgentSessionStatus
@@ -856,8 +1182,26 @@ func (a *API) handleSessionRunsCreate(w http.ResponseWriter, r *http.Request, wo
 			AgentSession:            req.AgentSession,
 			ImagePullSecrets:        req.ImagePullSecrets,
 			TTLSecondsAfterFinished: req.TTLSecondsAfterFinished,
+			PullRequest:             req.PullRequest,
+			Resources:               resources,
+			Scheduling:              scheduling,
+			EgressAllowedHosts:      req.EgressAllowedHosts,
+			ActiveDeadlineSeconds:   req.ActiveDeadlineSeconds,
+			IdleTimeoutSeconds:      req.IdleTimeoutSeconds,
 		},
 	}
+	// Runs inherit the session's stamp so ownership follows the workspace.
+	if owner := sessionOwner(&sess); !owner.unowned() {
+		if run.Annotations == nil {
+			run.Annotations = map[string]string{}
+		}
+		if owner.Owner != "" {
+			run.Annotations[annotationOwner] = owner.Owner
+		}
+		if owner.Team != "" {
+			run.Labels[labelTeam] = owner.Team
+		}
+	}
 	if err := a.K8s.Create(r.Context(), run); err != nil {
 		writeError(w, http.StatusInternalServerError, "create harness run failed")
 		return
@@ -953,6 +1297,9 @@ func (a *API) handleRunsList(w http.ResponseWriter, r *http.Request) {
 
 	out := make([]runResponse, 0, len(list.Items))
 	for i := range list.Items {
+		if !visibleTo(r.Context(), runOwner(&list.Items[i])) {
+			continue
+		}
 		dn := sessionNames[list.Items[i].Spec.WorkspaceSessionName]
 		out = append(out, runToResponse(&list.Items[i], dn))
 	}
@@ -1028,6 +1375,9 @@ func (a *API) handleRunResumePost(w http.ResponseWriter, r *http.Request, id str
 			copy.Annotations[key] = value
 		}
 	}
+	if team := run.Labels[labelTeam]; team != "" {
+		copy.Labels[labelTeam] = team
+	}
 	copy.Spec.TTLSecondsAfterFinished = run.Spec.TTLSecondsAfterFinished
 	if err := a.K8s.Create(r.Context(), copy); err != nil {
 		writeError(w, http.StatusInternalServerError, "create resumed harness run failed")
@@ -1079,10 +1429,6 @@ func (a *API) handleEgressOverridePatch(w http.ResponseWriter, r *http.Request,
 		writeJSONError(w, err)
 		return
 	}
-	if len(req.AllowedHosts) != 0 {
-		writeError(w, http.StatusBadRequest, "allowedHosts is not supported: host-based egress allowlisting is not enforced")
-		return
-	}
 	mode, ok := normalizeRunEgressMode(req.Mode)
 	if !ok {
 		writeError(w, http.StatusBadRequest, "invalid mode")
@@ -1092,6 +1438,16 @@ func (a *API) handleEgressOverridePatch(w http.ResponseWriter, r *http.Request,
 		writeError(w, http.StatusBadRequest, "mode required")
 		return
 	}
+	// Hostnames are enforced by the egress proxy, so only proxy mode takes them.
+	if len(req.AllowedHosts) != 0 && mode != "proxy" {
+		writeError(w, http.StatusBadRequest, "allowedHosts is not supported with mode "+mode+"; use mode proxy")
+		return
+	}
+	if err := operatorv1alpha1.ValidateEgressAllowedHosts("allowedHosts", req.AllowedHosts); err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	allowedHosts := egressproxy.NormalizeHosts(req.AllowedHosts)
 	var sess operatorv1alpha1.Session
 	if err := a.K8s.Get(r.Context(), client.ObjectKey{Namespace: a.Namespace, Name: workspaceSessionID}, &sess); err != nil {
 		if apierrors.IsNotFound(err) {
@@ -1106,11 +1462,16 @@ func (a *API) handleEgressOverridePatch(w http.ResponseWriter, r *http.Request,
 		updated.Annotations = map[string]string{}
 	}
 	updated.Annotations[annotationEgressMode] = mode
+	if len(allowedHosts) != 0 {
+		updated.Annotations[controllers.AnnotationEgressHosts] = strings.Join(allowedHosts, ",")
+	} else {
+		delete(updated.Annotations, controllers.AnnotationEgressHosts)
+	}
 	if err := a.K8s.Patch(r.Context(), updated, client.MergeFrom(&sess)); err != nil {
 		writeError(w, http.StatusInternalServerError, "update egress override failed")
 		return
 	}
-	a.Audit.Append(r.Context(), principal(r.Context()), "egress-override.changed", "workspace-session", workspaceSessionID, "allowed", map[string]any{"mode": mode})
+	a.Audit.Append(r.Context(), principal(r.Context()), "egress-override.changed", "workspace-session", workspaceSessionID, "allowed", map[string]any{"mode": mode, "allowedHosts": allowedHosts})
 	writeJSON(w, http.StatusOK, map[string]any{"updated": true})
 }
 
@@ -1135,6 +1496,40 @@ func (a *API) handleAuditList(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, http.StatusOK, map[string]any{"events": events})
 }
 
+// ingestableAuditActions are the events in-cluster components without their
+// own audit store may submit. Anything else is recorded by the API itself.
+var ingestableAuditActions = map[string]struct{}{
+	"egress.denied": {},
+}
+
+type auditIngestRequest struct {
+	Action       string         `json:"action"`
+	ResourceType string         `json:"resourceType"`
+	ResourceID   string         `json:"resourceID"`
+	Outcome      string         `json:"outcome"`
+	Metadata     map[string]any `json:"metadata,omitempty"`
+}
+
+func (a *API) handleAuditIngest(w http.ResponseWriter, r *http.Request) {
+	var req auditIngestRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	action := strings.TrimSpace(req.Action)
+	if _, ok := ingestableAuditActions[action]; !ok {
+		writeError(w, http.StatusBadRequest, "unsupported audit action")
+		return
+	}
+	if strings.TrimSpace(req.ResourceType) == "" || strings.TrimSpace(req.Outcome) == "" {
+		writeError(w, http.StatusBadRequest, "resourceType and outcome required")
+		return
+	}
+	// The actor is always the caller's principal, never a submitted value.
+	a.Audit.Append(r.Context(), principal(r.Context()), action, strings.TrimSpace(req.ResourceType), strings.TrimSpace(req.ResourceID), strings.TrimSpace(req.Outcome), req.Metadata)
+	writeJSON(w, http.StatusAccepted, map[string]any{"accepted": true})
+}
+
 func validateAPI(a *API) error {
 	if a.K8s == nil {
 		return errors.New("k8s client required")
@@ -1166,11 +1561,28 @@ func New(namespace, auditPath, bootstrapToken string, restCfg *rest.Config, k8s
 		return nil, err
 	}
 
-	tokens := newTokenStore()
+	tokens := newTokenStore(tokenStorePath(auditPath))
 	if err := tokens.EnsureBootstrapToken(context.Background(), bootstrapToken); err != nil {
 		return nil, err
 	}
+	if err := tokens.EnsureBootstrapTokenHash(context.Background(), opts.BootstrapTokenSHA256); err != nil {
+		return nil, err
+	}
+	oidc, err := newOIDCVerifier(opts.OIDC)
+	if err != nil {
+		return nil, err
+	}
+	artifacts, err := newArtifactBlobStore(opts.ArtifactStore, auditPath)
+	if err != nil {
+		return nil, err
+	}
+	runMaximums, err := parseRunResourceMaximums(opts.RunResourceMaximums)
+	if err != nil {
+		return nil, err
+	}
 	var cs kubernetes.Interface
+	var archiver workspaceArchiver
+	var volumeSnapshots func(context.Context) bool
 	var agentTransport agentSessionTransport
 	if restCfg != nil {
 		clientset, err := kubernetes.NewForConfig(restCfg)
@@ -1187,6 +1599,8 @@ func New(namespace, auditPath, bootstrapToken string, restCfg *rest.Config, k8s
 			return nil, err
 		}
 		agentTransport = newPodProxyAgentSessionTransport(namespace, cs, httpClient, baseURL, "")
+		archiver = newPodWorkspaceArchiver(namespace, cs, restCfg, opts.WorkspaceArchiveImage)
+		volumeSnapshots = clusterServesVolumeSnapshots(cs)
 	}
 
 	api := &API{
@@ -1194,18 +1608,29 @@ func New(namespace, auditPath, bootstrapToken string, restCfg *rest.Config, k8s
 		Namespace:     namespace,
 		K8s:           k8s,
 		Clientset:     cs,
-		Auth:          newAuthenticator(tokens),
+		Auth:          newAuthenticator(tokens, oidc),
 		Tokens:        tokens,
 		Audit:         newAuditStore(auditPath),
+		ArtifactBlobs: artifacts,
 		attachOrigins: origins,
+
+		RunResourceMaximums: runMaximums,
 	}
+	activity := newRunActivityRecorder(namespace, k8s)
 	if restCfg != nil {
 		api.Attach = newAttachService(namespace, restCfg, k8s, tokens, api.Audit)
+		api.Attach.activity = activity
 	}
 	if agentTransport != nil {
 		api.AgentSessions = newAgentSessionService(agentTransport, newAgentSessionStore(agentSessionStorePath(auditPath)))
+		api.AgentSessions.activity = activity
+	}
+	if secret := strings.TrimSpace(opts.GitHubWebhookSecret); secret != "" {
+		api.GitHubWebhooks = newGitHubWebhookService(secret, githubWebhookDeliveryLogPath(auditPath))
 	}
+	api.WorkspaceSnapshots = newWorkspaceSnapshotService(namespace, k8s, artifacts, archiver, volumeSnapshots, opts.VolumeSnapshotClass, workspaceSnapshotStorePath(auditPath))
 	api.RemoteAgentOrchestration = newRemoteAgentOrchestrationService(newRemoteAgentOrchestrationStore(remoteAgentOrchestrationStorePath(auditPath)), namespace, k8s, api.AgentSessions)
+	api.WorkspaceSnapshots.blobInUse = api.RemoteAgentOrchestration.referencesArtifactDigest
 	if err := validateAPI(api); err != nil {
 		return nil, err
 	}
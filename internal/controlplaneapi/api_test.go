@@ -19,9 +19,13 @@ import (
 
 	"github.com/gorilla/websocket"
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/operator/controllers"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
@@ -288,6 +292,51 @@ func TestRBAC_MissingScope_DeniedAndAudited(t *testing.T) {
 	}
 }
 
+func TestAudit_IngestRecordsEgressDenialsUnderTheCallersActor(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-proxy", "proxy", []string{ScopeAuditWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, body := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/audit", "proxy", map[string]any{
+		"action":       "egress.denied",
+		"resourceType": "harness-run",
+		"resourceID":   "run-1",
+		"outcome":      "denied",
+		"metadata":     map[string]any{"host": "evil.example.com", "reason": "host_not_allowed"},
+	})
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("status = %d, want 202 (body=%s)", resp.StatusCode, body)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/audit", "proxy", map[string]any{
+		"action": "token.create", "resourceType": "token", "resourceID": "t-x", "outcome": "allowed",
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("forged action status = %d, want 400", resp.StatusCode)
+	}
+
+	evs, err := api.Audit.List(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	var denials []AuditEvent
+	for _, ev := range evs {
+		if ev.Action == "egress.denied" {
+			denials = append(denials, ev)
+		}
+		if ev.Action == "token.create" {
+			t.Fatalf("forged audit action was recorded: %+v", ev)
+		}
+	}
+	if len(denials) != 1 || denials[0].Actor != "t-proxy" || denials[0].ResourceID != "run-1" || !strings.Contains(string(denials[0].Metadata), "evil.example.com") {
+		t.Fatalf("egress denials = %+v, want one from t-proxy for run-1", denials)
+	}
+}
+
 func TestJSON_BodyTooLarge_Returns413(t *testing.T) {
 	api, cleanup := newTestAPI(t)
 	defer cleanup()
@@ -543,6 +592,131 @@ func TestCreateHarnessRunAllowsMockAgentFixtureWithEnv(t *testing.T) {
 	}
 }
 
+func TestNewRejectsRunResourceMaximumBelowProfileDefaults(t *testing.T) {
+	scheme := runtime.NewScheme()
+	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
+	k8s := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	// The full profile requests 2Gi of memory by default.
+	_, err := New("test-ns", "", "", nil, k8s, Options{Env: "test", RunResourceMaximums: map[string]string{"memory": "1Gi"}})
+	if err == nil || !strings.Contains(err.Error(), "default request") {
+		t.Fatalf("New() error = %v, want default request above maximum", err)
+	}
+	if _, err := New("test-ns", "", "", nil, k8s, Options{Env: "test", RunResourceMaximums: map[string]string{"memory": "2Gi"}}); err != nil {
+		t.Fatalf("New() with maximum at the largest default: %v", err)
+	}
+}
+
+func TestCreateHarnessRunResolvesProfileResourcesWithinNamespaceMaximums(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	api.RunResourceMaximums = corev1.ResourceList{corev1.ResourceCPU: resource.MustParse("8"), corev1.ResourceMemory: resource.MustParse("6Gi")}
+
+	if err := api.Tokens.Create(context.Background(), "t-full", "full", []string{"workspace-session:write", "workspace-session:read", "harness-run:write", "harness-run:read"}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions", "full", map[string]any{"repoURL": "https://example.com/repo"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create session status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var sess sessionResponse
+	_ = json.Unmarshal(b, &sess)
+	createRun := func(resources map[string]any) (*http.Response, []byte) {
+		return doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions/"+sess.ID+"/harness-runs", "full", map[string]any{
+			"repoURL":      "https://example.com/repo",
+			"image":        "alpine:3",
+			"imageProfile": map[string]any{"profile": "go"},
+			"resources":    resources,
+		})
+	}
+
+	resp, b = createRun(map[string]any{"requests": map[string]any{"memory": "3Gi"}})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var created runResponse
+	_ = json.Unmarshal(b, &created)
+	var run operatorv1alpha1.HarnessRun
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: created.ID}, &run); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	got := run.Spec.Resources
+	if got == nil {
+		t.Fatal("expected resources on the run spec")
+	}
+	for name, want := range map[string]string{"requests.memory": "3Gi", "requests.cpu": "1", "limits.memory": "6Gi", "limits.cpu": "4", "limits.ephemeral-storage": "16Gi"} {
+		kind, resourceName, _ := strings.Cut(name, ".")
+		list := got.Requests
+		if kind == "limits" {
+			list = got.Limits
+		}
+		if q := list[corev1.ResourceName(resourceName)]; q.String() != want {
+			t.Fatalf("%s = %s, want %s", name, q.String(), want)
+		}
+	}
+
+	resp, b = createRun(map[string]any{"limits": map[string]any{"memory": "10Gi"}})
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(b), "namespace maximum") {
+		t.Fatalf("status = %d, want 400 namespace maximum (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, b = createRun(map[string]any{"requests": map[string]any{"cpu": "2"}, "limits": map[string]any{"cpu": "1"}})
+	if resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(b), "must not exceed") {
+		t.Fatalf("status = %d, want 400 request above limit (body=%s)", resp.StatusCode, string(b))
+	}
+}
+
+func TestAgentSessionDiagnostic_ReportsOOMKillAndEviction(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	api.AgentSessions = newAgentSessionService(newFakeAgentSessionTransport(), newAgentSessionStore(""))
+
+	for name, status := range map[string]corev1.PodStatus{
+		"oom": {Phase: corev1.PodFailed, ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "harness",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+		}}},
+		"evicted": {Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: ephemeral-storage."},
+	} {
+		run := &operatorv1alpha1.HarnessRun{
+			ObjectMeta: metav1.ObjectMeta{Name: "run-" + name, Namespace: api.Namespace},
+			Spec: operatorv1alpha1.HarnessRunSpec{
+				RepoURL:      "https://github.com/withakay/kocao",
+				Image:        "ghcr.io/withakay/kocao-agent:latest",
+				AgentSession: &operatorv1alpha1.AgentSessionSpec{Runtime: operatorv1alpha1.AgentRuntimeSandboxAgent, Agent: operatorv1alpha1.AgentKindCodex},
+			},
+			Status: operatorv1alpha1.HarnessRunStatus{
+				Phase:   operatorv1alpha1.HarnessRunPhaseFailed,
+				PodName: "pod-" + name,
+				AgentSession: &operatorv1alpha1.AgentSessionStatus{
+					Runtime: operatorv1alpha1.AgentRuntimeSandboxAgent,
+					Agent:   operatorv1alpha1.AgentKindCodex,
+					Phase:   operatorv1alpha1.AgentSessionPhaseFailed,
+				},
+			},
+		}
+		if err := api.K8s.Create(context.Background(), run); err != nil {
+			t.Fatalf("create run: %v", err)
+		}
+		pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-" + name, Namespace: api.Namespace}, Status: status}
+		if err := api.K8s.Create(context.Background(), pod); err != nil {
+			t.Fatalf("create pod: %v", err)
+		}
+
+		state, _ := agentSessionStateFromHarnessRun(run)
+		diag := api.agentSessionDiagnostic(context.Background(), run, state)
+		if diag == nil || diag.Class != agentSessionBlockerResources {
+			t.Fatalf("%s diagnostic = %#v, want class %q", name, diag, agentSessionBlockerResources)
+		}
+		if name == "evicted" && !strings.Contains(diag.Detail, "ephemeral-storage") {
+			t.Fatalf("eviction detail = %q", diag.Detail)
+		}
+	}
+}
+
 func TestAgentSessionLifecycle_API(t *testing.T) {
 	api, cleanup := newTestAPI(t)
 	defer cleanup()
@@ -1015,6 +1189,187 @@ func TestAgentSessionGet_AuthRepoAndNetworkDiagnostics(t *testing.T) {
 	}
 }
 
+func TestSymphonyProjectCreateLinearSource_API(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-symphony", "symphony", []string{ScopeSymphonyProjectRead, ScopeSymphonyProjectWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	source := map[string]any{
+		"kind":           "linear",
+		"linear":         map[string]any{"teamKey": "ENG"},
+		"githubToken":    "lin_api_should_not_be_inlined",
+		"activeStates":   []string{"Todo"},
+		"terminalStates": []string{"Done"},
+	}
+	body := map[string]any{
+		"name": "linear-demo",
+		"spec": map[string]any{
+			"source":       source,
+			"repositories": []map[string]any{{"owner": "withakay", "name": "kocao"}},
+			"runtime":      map[string]any{"image": "ghcr.io/withakay/kocao-harness:latest"},
+		},
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects", "symphony", body); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("inline linear key status = %d, want 400 (body=%s)", resp.StatusCode, string(b))
+	}
+
+	delete(source, "githubToken")
+	source["tokenSecretRef"] = map[string]any{"name": "linear-api-key"}
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects", "symphony", body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create linear symphony project status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var created symphonyProjectResponse
+	_ = json.Unmarshal(b, &created)
+	if created.Spec.Source.Kind != operatorv1alpha1.SymphonySourceKindLinear || created.Spec.Source.Linear == nil || created.Spec.Source.Linear.TeamKey != "ENG" {
+		t.Fatalf("created source = %#v", created.Spec.Source)
+	}
+	if created.Spec.Source.Linear.RepositoryLabelPrefix != operatorv1alpha1.DefaultLinearRepositoryLabelPrefix {
+		t.Fatalf("repository label prefix = %q", created.Spec.Source.Linear.RepositoryLabelPrefix)
+	}
+}
+
+func TestSymphonyProjectCreateWithWriteBack_API(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-symphony", "symphony", []string{ScopeSymphonyProjectRead, ScopeSymphonyProjectWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	writeBack := map[string]any{
+		"comment":    true,
+		"runURLBase": "https://kocao.example.com",
+		"onClaim":    map[string]any{"status": "In Review"},
+		"onSuccess":  map[string]any{"status": "In Review", "addLabels": []string{"symphony:done"}},
+	}
+	body := map[string]any{
+		"name": "writeback-demo",
+		"spec": map[string]any{
+			"source": map[string]any{
+				"project":        map[string]any{"owner": "withakay", "number": 42},
+				"tokenSecretRef": map[string]any{"name": "github-token"},
+				"activeStates":   []string{"Todo", "In Progress"},
+				"terminalStates": []string{"Done"},
+			},
+			"repositories": []map[string]any{{"owner": "withakay", "name": "kocao"}},
+			"runtime":      map[string]any{"image": "ghcr.io/withakay/kocao-harness:latest"},
+			"writeBack":    writeBack,
+			"priority":     map[string]any{"pinned": []string{"withakay/kocao#1"}},
+		},
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects", "symphony", body); resp.StatusCode != http.StatusBadRequest || !strings.Contains(string(b), "onClaim.status") {
+		t.Fatalf("inactive claim status = %d, want 400 (body=%s)", resp.StatusCode, string(b))
+	}
+
+	writeBack["onClaim"] = map[string]any{"status": "In Progress", "addLabels": []string{"symphony"}}
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects", "symphony", body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var created symphonyProjectResponse
+	_ = json.Unmarshal(b, &created)
+	if created.Spec.WriteBack == nil || !created.Spec.WriteBack.Comment || created.Spec.WriteBack.OnClaim.Status != "In Progress" || created.Spec.WriteBack.OnSuccess.AddLabels[0] != "symphony:done" {
+		t.Fatalf("created writeBack = %#v", created.Spec.WriteBack)
+	}
+	if created.Spec.Priority == nil || len(created.Spec.Priority.Pinned) != 1 {
+		t.Fatalf("created priority = %#v", created.Spec.Priority)
+	}
+}
+
+func TestRunClassSchedulingFromAPI(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-full", "full", []string{"workspace-session:write", "workspace-session:read", "harness-run:write", "harness-run:read", ScopeSymphonyProjectRead, ScopeSymphonyProjectWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if err := api.Tokens.Create(context.Background(), "t-admin", "admin", []string{ScopeSymphonyProjectWrite, ScopeAdmin}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions", "full", map[string]any{"repoURL": "https://example.com/repo"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create session status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var sess sessionResponse
+	_ = json.Unmarshal(b, &sess)
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions/"+sess.ID+"/harness-runs", "full", map[string]any{
+		"repoURL":  "https://example.com/repo",
+		"image":    "alpine:3",
+		"runClass": "large-build",
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create run status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var created runResponse
+	_ = json.Unmarshal(b, &created)
+	var run operatorv1alpha1.HarnessRun
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: created.ID}, &run); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	if run.Spec.Scheduling == nil || run.Spec.Scheduling.RunClass != "large-build" {
+		t.Fatalf("scheduling = %#v", run.Spec.Scheduling)
+	}
+
+	project := func(name string, scheduling map[string]any) map[string]any {
+		return map[string]any{
+			"name": name,
+			"spec": map[string]any{
+				"source": map[string]any{
+					"project":        map[string]any{"owner": "withakay", "number": 42},
+					"tokenSecretRef": map[string]any{"name": "github-token"},
+					"activeStates":   []string{"Todo"},
+					"terminalStates": []string{"Done"},
+				},
+				"repositories": []map[string]any{{"owner": "withakay", "name": "kocao"}},
+				"runtime":      map[string]any{"image": "ghcr.io/withakay/kocao-harness:latest", "scheduling": scheduling},
+			},
+		}
+	}
+	raw := map[string]any{"nodeSelector": map[string]any{"pool": "build"}}
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects", "full", project("raw", raw)); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("raw scheduling status = %d, want 403 (body=%s)", resp.StatusCode, string(b))
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects", "admin", project("raw", raw)); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("admin raw scheduling status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects", "full", project("classed", map[string]any{"runClass": "small"})); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("run class status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var classed operatorv1alpha1.SymphonyProject
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: "classed"}, &classed); err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if classed.Spec.Runtime.Scheduling == nil || classed.Spec.Runtime.Scheduling.RunClass != "small" || classed.Annotations[annotationOwner] == "" {
+		t.Fatalf("project = %#v %#v", classed.Annotations, classed.Spec.Runtime.Scheduling)
+	}
+
+	// Runs of the admin's project carry the admin's owner stamp, so switching
+	// it to a restricted class is checked against the caller instead.
+	if err := api.K8s.Create(context.Background(), &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: controllers.RunClassesConfigMapName, Namespace: api.Namespace},
+		Data:       map[string]string{"gpu": "allowedOwners: [t-admin]\n", "small": "{}\n"},
+	}); err != nil {
+		t.Fatalf("create run classes: %v", err)
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodPatch, srv.URL+"/api/v1/symphony-projects/raw", "full", project("raw", map[string]any{"runClass": "gpu"})); resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("restricted run class patch status = %d, want 403 (body=%s)", resp.StatusCode, string(b))
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodPatch, srv.URL+"/api/v1/symphony-projects/raw", "full", project("raw", map[string]any{"runClass": "small"})); resp.StatusCode != http.StatusOK {
+		t.Fatalf("open run class patch status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+}
+
 func TestSymphonyProjectLifecycle_API(t *testing.T) {
 	api, cleanup := newTestAPI(t)
 	defer cleanup()
@@ -1179,6 +1534,42 @@ func TestSymphonyProjectLifecycle_API(t *testing.T) {
 	if stored.Annotations[annotationSymphonyRefreshRequestedAt] == "" {
 		t.Fatalf("expected refresh annotation, got %#v", stored.Annotations)
 	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/symphony-projects/demo/plan", "symphony", nil)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("plan symphony project status = %d, want 202 (body=%s)", resp.StatusCode, string(b))
+	}
+	var planned symphonyPlanResponse
+	if err := json.Unmarshal(b, &planned); err != nil {
+		t.Fatalf("decode plan response: %v", err)
+	}
+	if planned.RequestedAt == "" || planned.Ready {
+		t.Fatalf("plan response = %#v, want a pending request", planned)
+	}
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: "demo"}, &stored); err != nil {
+		t.Fatalf("get planned symphony project: %v", err)
+	}
+	if stored.Annotations[controllers.AnnotationSymphonyPlanRequestedAt] != planned.RequestedAt {
+		t.Fatalf("expected plan annotation %q, got %#v", planned.RequestedAt, stored.Annotations)
+	}
+	stored.Status.Plan = &operatorv1alpha1.SymphonyProjectPlanStatus{
+		RequestedAt: planned.RequestedAt,
+		Items:       []operatorv1alpha1.SymphonyProjectPlanItemStatus{{Action: operatorv1alpha1.SymphonyPlanActionClaim, ItemID: "PVT_item_1", Prompt: "Fix it."}},
+	}
+	if err := api.K8s.Status().Update(context.Background(), &stored); err != nil {
+		t.Fatalf("record plan: %v", err)
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/symphony-projects/demo/plan", "symphony", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get symphony plan status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	planned = symphonyPlanResponse{}
+	if err := json.Unmarshal(b, &planned); err != nil {
+		t.Fatalf("decode plan: %v", err)
+	}
+	if !planned.Ready || planned.Plan == nil || len(planned.Plan.Items) != 1 || planned.Plan.Items[0].Prompt != "Fix it." {
+		t.Fatalf("plan = %#v", planned)
+	}
 }
 
 func TestSymphonyProjectCreate_RejectsPATInSecretName(t *testing.T) {
@@ -1245,6 +1636,51 @@ func TestEgressOverride_RejectsAllowedHosts(t *testing.T) {
 	}
 }
 
+func TestEgressOverride_ProxyModeStoresAllowedHosts(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-writer", "writer", []string{"workspace-session:write", "control:write"}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions", "writer", map[string]any{"repoURL": "https://example.com/repo"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create session status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var sess sessionResponse
+	_ = json.Unmarshal(b, &sess)
+	override := func(body map[string]any) (*http.Response, []byte) {
+		return doJSON(t, srv.Client(), http.MethodPatch, srv.URL+"/api/v1/workspace-sessions/"+sess.ID+"/egress-override", "writer", body)
+	}
+
+	if resp, b := override(map[string]any{"mode": "proxy", "allowedHosts": []string{"10.0.0.1"}}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("ip allowed host status = %d, want 400 (body=%s)", resp.StatusCode, string(b))
+	}
+	if resp, b := override(map[string]any{"mode": "proxy", "allowedHosts": []string{"Registry.npmjs.org", "*.githubusercontent.com"}}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("proxy override status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	var got operatorv1alpha1.Session
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: sess.ID}, &got); err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if got.Annotations[annotationEgressMode] != "proxy" || got.Annotations[controllers.AnnotationEgressHosts] != "registry.npmjs.org,*.githubusercontent.com" {
+		t.Fatalf("session annotations = %v", got.Annotations)
+	}
+
+	if resp, b := override(map[string]any{"mode": "restricted"}); resp.StatusCode != http.StatusOK {
+		t.Fatalf("restricted override status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: sess.ID}, &got); err != nil {
+		t.Fatalf("get session: %v", err)
+	}
+	if _, ok := got.Annotations[controllers.AnnotationEgressHosts]; ok {
+		t.Fatalf("expected allowed hosts to be cleared, got %v", got.Annotations)
+	}
+}
+
 func TestAttachWS_OriginAllowlist(t *testing.T) {
 	api, cleanup := newTestAPIWithAttachOptions(t, Options{Env: "prod", AttachWSAllowedOrigins: []string{"https://allowed.example"}})
 	defer cleanup()
@@ -3230,3 +3666,355 @@ func TestIsStalePodProxyError_NarrowMatching(t *testing.T) {
 		})
 	}
 }
+
+func TestRunActivityRecorderStampsActiveRunsAndThrottles(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	ctx := context.Background()
+	for name, phase := range map[string]operatorv1alpha1.HarnessRunPhase{"run-active": operatorv1alpha1.HarnessRunPhaseRunning, "run-done": operatorv1alpha1.HarnessRunPhaseSucceeded} {
+		run := &operatorv1alpha1.HarnessRun{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns", Labels: map[string]string{controllers.LabelWorkspaceSessionName: "ws-1"}},
+			Spec:       operatorv1alpha1.HarnessRunSpec{RepoURL: "https://github.com/withakay/kocao", Image: "busybox"},
+		}
+		if err := api.K8s.Create(ctx, run); err != nil {
+			t.Fatalf("create run: %v", err)
+		}
+		run.Status.Phase = phase
+		if err := api.K8s.Status().Update(ctx, run); err != nil {
+			t.Fatalf("update run status: %v", err)
+		}
+	}
+	lastActivity := func(name string) *metav1.Time {
+		t.Helper()
+		var run operatorv1alpha1.HarnessRun
+		if err := api.K8s.Get(ctx, client.ObjectKey{Namespace: "test-ns", Name: name}, &run); err != nil {
+			t.Fatalf("get run: %v", err)
+		}
+		return run.Status.LastActivityTime
+	}
+
+	now := time.Unix(1000, 0)
+	activity := newRunActivityRecorder("test-ns", api.K8s)
+	activity.now = func() time.Time { return now }
+
+	activity.TouchWorkspaceSession(ctx, "ws-1")
+	if got := lastActivity("run-active"); got == nil || !got.Time.Equal(now) {
+		t.Fatalf("active run lastActivityTime = %v, want %v", got, now)
+	}
+	if got := lastActivity("run-done"); got != nil {
+		t.Fatalf("finished run lastActivityTime = %v, want unset", got)
+	}
+
+	now = now.Add(10 * time.Second)
+	activity.TouchWorkspaceSession(ctx, "ws-1")
+	if got := lastActivity("run-active"); !got.Time.Equal(time.Unix(1000, 0)) {
+		t.Fatalf("throttled lastActivityTime = %v", got)
+	}
+	activity.TouchRun(ctx, "run-active")
+	if got := lastActivity("run-active"); !got.Time.Equal(now) {
+		t.Fatalf("agent event lastActivityTime = %v, want %v", got, now)
+	}
+}
+
+type stubWorkspaceArchiver struct {
+	mu        sync.Mutex
+	extracted map[string][]byte
+	release   chan struct{}
+}
+
+func (s *stubWorkspaceArchiver) Archive(_ context.Context, pvc string, w io.Writer) error {
+	_, err := io.WriteString(w, "tarball of "+pvc)
+	return err
+}
+
+func (s *stubWorkspaceArchiver) Extract(_ context.Context, pvc string, r io.Reader) error {
+	<-s.release
+	b, err := io.ReadAll(r)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.extracted[pvc] = b
+	return err
+}
+
+func createSnapshotTestSession(t *testing.T, api *API, srv *httptest.Server) sessionResponse {
+	t.Helper()
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions", "writer", map[string]any{"repoURL": "https://example.com/repo", "displayName": "source"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create session status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var sess sessionResponse
+	_ = json.Unmarshal(b, &sess)
+	pvc := &corev1.PersistentVolumeClaim{ObjectMeta: metav1.ObjectMeta{Name: controllers.SessionWorkspacePVCName(sess.ID), Namespace: api.Namespace}}
+	if err := api.K8s.Create(context.Background(), pvc); err != nil {
+		t.Fatalf("create pvc: %v", err)
+	}
+	return sess
+}
+
+func TestWorkspaceSnapshots_VolumeSnapshotRestoresIntoNewSession(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	ctx := context.Background()
+	api.WorkspaceSnapshots = newWorkspaceSnapshotService(api.Namespace, api.K8s, nil, nil, func(context.Context) bool { return true }, "csi-snapclass", "")
+
+	if err := api.Tokens.Create(ctx, "t-writer", "writer", []string{"workspace-session:write", "workspace-session:read"}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+	source := createSnapshotTestSession(t, api, srv)
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions/"+source.ID+"/snapshots", "writer", map[string]any{"name": "before refactor"})
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("create snapshot status = %d, want 202 (body=%s)", resp.StatusCode, string(b))
+	}
+	var snap workspaceSnapshot
+	_ = json.Unmarshal(b, &snap)
+	if snap.Method != workspaceSnapshotMethodVolumeSnapshot || snap.Phase != workspaceSnapshotPhasePending || snap.VolumeSnapshotName == "" {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+
+	vs := &unstructured.Unstructured{}
+	vs.SetGroupVersionKind(volumeSnapshotGVK)
+	if err := api.K8s.Get(ctx, client.ObjectKey{Namespace: api.Namespace, Name: snap.VolumeSnapshotName}, vs); err != nil {
+		t.Fatalf("get volume snapshot: %v", err)
+	}
+	if pvc, _, _ := unstructured.NestedString(vs.Object, "spec", "source", "persistentVolumeClaimName"); pvc != controllers.SessionWorkspacePVCName(source.ID) {
+		t.Fatalf("volume snapshot source = %q", pvc)
+	}
+	if class, _, _ := unstructured.NestedString(vs.Object, "spec", "volumeSnapshotClassName"); class != "csi-snapclass" {
+		t.Fatalf("volume snapshot class = %q", class)
+	}
+
+	restore := func() (*http.Response, []byte) {
+		return doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-snapshots/"+snap.ID+"/restore", "writer", map[string]any{"displayName": "fork"})
+	}
+	if resp, b := restore(); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("restore pending snapshot status = %d, want 409 (body=%s)", resp.StatusCode, string(b))
+	}
+
+	_ = unstructured.SetNestedField(vs.Object, true, "status", "readyToUse")
+	_ = unstructured.SetNestedField(vs.Object, "1Gi", "status", "restoreSize")
+	if err := api.K8s.Update(ctx, vs); err != nil {
+		t.Fatalf("update volume snapshot: %v", err)
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/"+source.ID+"/snapshots", "writer", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list snapshots status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var list struct {
+		Snapshots []workspaceSnapshot `json:"snapshots"`
+	}
+	_ = json.Unmarshal(b, &list)
+	if len(list.Snapshots) != 1 || list.Snapshots[0].Phase != workspaceSnapshotPhaseReady || list.Snapshots[0].SizeBytes != 1<<30 {
+		t.Fatalf("snapshots = %+v", list.Snapshots)
+	}
+
+	// Snapshots outlive the session they were taken from.
+	if resp, b := doJSON(t, srv.Client(), http.MethodDelete, srv.URL+"/api/v1/workspace-sessions/"+source.ID, "writer", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete session status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, b = restore()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("restore status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var restored sessionResponse
+	_ = json.Unmarshal(b, &restored)
+	if restored.ID == source.ID || restored.DisplayName != "fork" || restored.RepoURL != "https://example.com/repo" || restored.RestoredFrom != snap.ID || restored.RestorePhase != "" {
+		t.Fatalf("restored session = %+v", restored)
+	}
+	var got operatorv1alpha1.Session
+	if err := api.K8s.Get(ctx, client.ObjectKey{Namespace: api.Namespace, Name: restored.ID}, &got); err != nil {
+		t.Fatalf("get restored session: %v", err)
+	}
+	if got.Annotations[controllers.AnnotationRestoreVolumeSnapshot] != snap.VolumeSnapshotName {
+		t.Fatalf("restored session annotations = %v", got.Annotations)
+	}
+
+	if resp, b := doJSON(t, srv.Client(), http.MethodDelete, srv.URL+"/api/v1/workspace-snapshots/"+snap.ID, "writer", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete snapshot status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	if err := api.K8s.Get(ctx, client.ObjectKey{Namespace: api.Namespace, Name: snap.VolumeSnapshotName}, vs); !apierrors.IsNotFound(err) {
+		t.Fatalf("get deleted volume snapshot err = %v, want not found", err)
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-snapshots/"+snap.ID, "writer", nil); resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("get deleted snapshot status = %d, want 404 (body=%s)", resp.StatusCode, string(b))
+	}
+}
+
+func TestWorkspaceSnapshots_ListAfterSessionDeleteIsScopedToOwner(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	ctx := context.Background()
+	api.WorkspaceSnapshots = newWorkspaceSnapshotService(api.Namespace, api.K8s, nil, nil, func(context.Context) bool { return true }, "csi-snapclass", "")
+
+	scopes := []string{"workspace-session:write", "workspace-session:read"}
+	if err := api.Tokens.Create(ctx, "t-writer", "writer", scopes); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if err := api.Tokens.Create(ctx, "t-other", "other", scopes); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+	source := createSnapshotTestSession(t, api, srv)
+
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions/"+source.ID+"/snapshots", "writer", map[string]any{"name": "private"}); resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("create snapshot status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	if resp, b := doJSON(t, srv.Client(), http.MethodDelete, srv.URL+"/api/v1/workspace-sessions/"+source.ID, "writer", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete session status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+
+	list := func(token string) []workspaceSnapshot {
+		t.Helper()
+		resp, b := doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/"+source.ID+"/snapshots", token, nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("list snapshots status = %d (body=%s)", resp.StatusCode, string(b))
+		}
+		var out struct {
+			Snapshots []workspaceSnapshot `json:"snapshots"`
+		}
+		_ = json.Unmarshal(b, &out)
+		return out.Snapshots
+	}
+	if got := list("other"); len(got) != 0 {
+		t.Fatalf("foreign caller sees snapshots of a deleted session: %+v", got)
+	}
+	if got := list("writer"); len(got) != 1 {
+		t.Fatalf("owner sees %d snapshots, want 1", len(got))
+	}
+}
+
+func TestWorkspaceSnapshots_ArchiveFallbackRestoresThroughArtifactStore(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	ctx := context.Background()
+	archiver := &stubWorkspaceArchiver{extracted: map[string][]byte{}, release: make(chan struct{})}
+	storePath := filepath.Join(t.TempDir(), "kocao.workspace_snapshots.jsonl")
+	api.ArtifactBlobs = newFilesystemArtifactBlobStore(t.TempDir())
+	api.WorkspaceSnapshots = newWorkspaceSnapshotService(api.Namespace, api.K8s, api.ArtifactBlobs, archiver, nil, "", storePath)
+
+	if err := api.Tokens.Create(ctx, "t-writer", "writer", []string{"workspace-session:write", "workspace-session:read", "harness-run:write"}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+	source := createSnapshotTestSession(t, api, srv)
+
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions/"+source.ID+"/snapshots", "writer", map[string]any{"method": "volumeSnapshot"}); resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("volumeSnapshot without CSI status = %d, want 400 (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions/"+source.ID+"/snapshots", "writer", map[string]any{})
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("create snapshot status = %d, want 202 (body=%s)", resp.StatusCode, string(b))
+	}
+	var snap workspaceSnapshot
+	_ = json.Unmarshal(b, &snap)
+	if snap.Method != workspaceSnapshotMethodArchive {
+		t.Fatalf("snapshot method = %q, want archive", snap.Method)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for snap.Phase != workspaceSnapshotPhaseReady {
+		if time.Now().After(deadline) {
+			t.Fatalf("snapshot never became ready: %+v", snap)
+		}
+		time.Sleep(10 * time.Millisecond)
+		_, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-snapshots/"+snap.ID, "writer", nil)
+		_ = json.Unmarshal(b, &snap)
+	}
+	archive := "tarball of " + controllers.SessionWorkspacePVCName(source.ID)
+	if snap.ArtifactDigest != remoteAgentArtifactDigestPrefix+sha256Hex([]byte(archive)) || snap.SizeBytes != int64(len(archive)) {
+		t.Fatalf("snapshot = %+v", snap)
+	}
+	if reloaded, ok := newWorkspaceSnapshotService(api.Namespace, api.K8s, nil, nil, nil, "", storePath).Get(ctx, snap.ID); !ok || reloaded.Phase != workspaceSnapshotPhaseReady {
+		t.Fatalf("reloaded snapshot = %+v, %v", reloaded, ok)
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-snapshots/"+snap.ID+"/restore", "writer", map[string]any{})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("restore status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var restored sessionResponse
+	_ = json.Unmarshal(b, &restored)
+	if restored.RestorePhase != workspaceSnapshotPhasePending {
+		t.Fatalf("restored session = %+v", restored)
+	}
+	runsURL := srv.URL + "/api/v1/workspace-sessions/" + restored.ID + "/harness-runs"
+	if resp, b := doJSON(t, srv.Client(), http.MethodPost, runsURL, "writer", map[string]any{"repoURL": "https://example.com/repo", "image": "busybox"}); resp.StatusCode != http.StatusConflict {
+		t.Fatalf("run during restore status = %d, want 409 (body=%s)", resp.StatusCode, string(b))
+	}
+
+	close(archiver.release)
+	deadline = time.Now().Add(5 * time.Second)
+	for restored.RestorePhase != workspaceSnapshotPhaseReady {
+		if time.Now().After(deadline) {
+			t.Fatalf("restore never finished: %+v", restored)
+		}
+		time.Sleep(10 * time.Millisecond)
+		_, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/"+restored.ID, "writer", nil)
+		_ = json.Unmarshal(b, &restored)
+	}
+	archiver.mu.Lock()
+	defer archiver.mu.Unlock()
+	if got := string(archiver.extracted[controllers.SessionWorkspacePVCName(restored.ID)]); got != archive {
+		t.Fatalf("extracted %q, want %q", got, archive)
+	}
+
+	if resp, b := doJSON(t, srv.Client(), http.MethodDelete, srv.URL+"/api/v1/workspace-snapshots/"+snap.ID, "writer", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("delete snapshot status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	if _, _, err := api.ArtifactBlobs.Open(ctx, sha256Hex([]byte(archive))); err != errArtifactBlobNotFound {
+		t.Fatalf("open deleted archive err = %v, want not found", err)
+	}
+	if _, ok := newWorkspaceSnapshotService(api.Namespace, api.K8s, nil, nil, nil, "", storePath).Get(ctx, snap.ID); ok {
+		t.Fatalf("deleted snapshot reloaded from store")
+	}
+}
+
+func TestWorkspaceSnapshots_ExpireStaleFailsAbandonedArchivesAndRestores(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	ctx := context.Background()
+	now := time.Now().UTC()
+	storePath := filepath.Join(t.TempDir(), "kocao.workspace_snapshots.jsonl")
+
+	// A previous API process left these Pending when it exited.
+	previous := newWorkspaceSnapshotService(api.Namespace, api.K8s, nil, nil, nil, "", storePath)
+	previous.record(workspaceSnapshot{ID: "snap-stale", Method: workspaceSnapshotMethodArchive, Phase: workspaceSnapshotPhasePending, CreatedAt: now.Add(-time.Hour)})
+	previous.record(workspaceSnapshot{ID: "snap-recent", Method: workspaceSnapshotMethodArchive, Phase: workspaceSnapshotPhasePending, CreatedAt: now.Add(-time.Minute)})
+	for name, created := range map[string]time.Time{"ws-stale": now.Add(-time.Hour), "ws-recent": now.Add(-time.Minute)} {
+		sess := &operatorv1alpha1.Session{ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         api.Namespace,
+			CreationTimestamp: metav1.NewTime(created),
+			Annotations:       map[string]string{annotationWorkspaceRestorePhase: workspaceSnapshotPhasePending},
+		}}
+		if err := api.K8s.Create(ctx, sess); err != nil {
+			t.Fatalf("create session: %v", err)
+		}
+	}
+
+	service := newWorkspaceSnapshotService(api.Namespace, api.K8s, nil, nil, nil, "", storePath)
+	service.ExpireStale(ctx, now)
+
+	if snap, _ := service.Get(ctx, "snap-stale"); snap.Phase != workspaceSnapshotPhaseFailed || snap.Error == "" {
+		t.Fatalf("stale archive = %+v, want failed", snap)
+	}
+	if snap, _ := service.Get(ctx, "snap-recent"); snap.Phase != workspaceSnapshotPhasePending {
+		t.Fatalf("recent archive = %+v, want pending", snap)
+	}
+	if reloaded, _ := newWorkspaceSnapshotService(api.Namespace, api.K8s, nil, nil, nil, "", storePath).Get(ctx, "snap-stale"); reloaded.Phase != workspaceSnapshotPhaseFailed {
+		t.Fatalf("reloaded stale archive = %+v, want failed", reloaded)
+	}
+	for name, want := range map[string]string{"ws-stale": workspaceSnapshotPhaseFailed, "ws-recent": workspaceSnapshotPhasePending} {
+		var sess operatorv1alpha1.Session
+		if err := api.K8s.Get(ctx, client.ObjectKey{Namespace: api.Namespace, Name: name}, &sess); err != nil {
+			t.Fatalf("get session: %v", err)
+		}
+		if got := sess.Annotations[annotationWorkspaceRestorePhase]; got != want {
+			t.Fatalf("%s restore phase = %q, want %q", name, got, want)
+		}
+	}
+}
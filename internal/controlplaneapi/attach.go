@@ -297,6 +297,7 @@ type AttachService struct {
 	k8s       client.Client
 	tokens    *TokenStore
 	audit     *AuditStore
+	activity  *runActivityRecorder
 
 	mu       sync.Mutex
 	sessions map[string]*attachSession
@@ -796,6 +797,8 @@ func (s *AttachService) handleConn(ctx context.Context, actor, workspaceSessionI
 		}
 	}
 
+	s.activity.TouchWorkspaceSession(ctx, workspaceSessionID)
+
 	cli.send <- attachMsg{Type: "hello", WorkspaceSessionID: workspaceSessionID, ClientID: clientID, Role: string(cli.role), Mode: string(sessMode), DriverID: state.DriverID, LeaseMS: state.LeaseMS}
 
 	for {
@@ -888,6 +891,7 @@ func (s *AttachService) handleConn(ctx context.Context, actor, workspaceSessionI
 			if s.audit != nil {
 				s.audit.Append(ctx, actor, "attach.stdin", "workspace-session", workspaceSessionID, "allowed", map[string]any{"clientID": clientID, "bytes": len(payload), "mode": string(sessMode)})
 			}
+			s.activity.TouchWorkspaceSession(ctx, workspaceSessionID)
 			if w == nil {
 				podName, err := s.findAttachPod(ctx, workspaceSessionID)
 				if err != nil {
@@ -159,7 +159,8 @@ func TestAttachToken_RoleEnforcementAndTakeControl(t *testing.T) {
 	if err := api.Tokens.Create(context.Background(), "t-full", "full", []string{"workspace-session:write", "workspace-session:read", "harness-run:write", "harness-run:read", "control:write"}); err != nil {
 		t.Fatalf("create token: %v", err)
 	}
-	if err := api.Tokens.Create(context.Background(), "t-run", "run", []string{"harness-run:read"}); err != nil {
+	// admin only bypasses session ownership; the role checks under test still apply.
+	if err := api.Tokens.Create(context.Background(), "t-run", "run", []string{"harness-run:read", ScopeAdmin}); err != nil {
 		t.Fatalf("create token: %v", err)
 	}
 
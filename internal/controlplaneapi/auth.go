@@ -2,6 +2,7 @@ package controlplaneapi
 
 import (
 	"context"
+	"log/slog"
 	"net/http"
 	"strings"
 )
@@ -17,18 +18,55 @@ const (
 	ScopeRemoteAgentTaskWrite   = "remote-agent-task:write"
 	ScopeControlWrite           = "control:write"
 	ScopeAuditRead              = "audit:read"
+	ScopeAuditWrite             = "audit:write"
 	ScopeClusterRead            = "cluster:read"
 	ScopeSymphonyProjectRead    = "symphony-project:read"
 	ScopeSymphonyProjectWrite   = "symphony-project:write"
 	ScopeSymphonyProjectControl = "symphony-project:control"
+	ScopeTokenRead              = "token:read"
+	ScopeTokenWrite             = "token:write"
+	// ScopeAdmin bypasses per-owner resource authorization.
+	ScopeAdmin = "admin"
 )
 
+// knownScopes lists every scope a managed token may be granted; "*" is
+// accepted separately as the wildcard.
+var knownScopes = map[string]struct{}{
+	ScopeWorkspaceSessionRead:   {},
+	ScopeWorkspaceSessionWrite:  {},
+	ScopeHarnessRunRead:         {},
+	ScopeHarnessRunWrite:        {},
+	ScopeRemoteAgentRead:        {},
+	ScopeRemoteAgentWrite:       {},
+	ScopeRemoteAgentTaskRead:    {},
+	ScopeRemoteAgentTaskWrite:   {},
+	ScopeControlWrite:           {},
+	ScopeAuditRead:              {},
+	ScopeAuditWrite:             {},
+	ScopeClusterRead:            {},
+	ScopeSymphonyProjectRead:    {},
+	ScopeSymphonyProjectWrite:   {},
+	ScopeSymphonyProjectControl: {},
+	ScopeTokenRead:              {},
+	ScopeTokenWrite:             {},
+	ScopeAdmin:                  {},
+}
+
+func isKnownScope(scope string) bool {
+	if scope == "*" {
+		return true
+	}
+	_, ok := knownScopes[scope]
+	return ok
+}
+
 type Authenticator struct {
 	tokens *TokenStore
+	oidc   *oidcVerifier
 }
 
-func newAuthenticator(tokens *TokenStore) *Authenticator {
-	return &Authenticator{tokens: tokens}
+func newAuthenticator(tokens *TokenStore, oidc *oidcVerifier) *Authenticator {
+	return &Authenticator{tokens: tokens, oidc: oidc}
 }
 
 func bearerToken(r *http.Request) string {
@@ -78,23 +116,37 @@ func (a *Authenticator) Authenticate(next http.Handler, _ *AuditStore) http.Hand
 			return
 		}
 		if rec == nil {
+			if a.oidc != nil && looksLikeJWT(tok) {
+				p, err := a.oidc.Verify(r.Context(), tok)
+				if err != nil {
+					slog.Warn("oidc bearer rejected", "error", err)
+				} else {
+					r = r.WithContext(withPrincipal(r.Context(), p))
+				}
+			}
 			next.ServeHTTP(w, r)
 			return
 		}
 		p := &Principal{TokenID: rec.ID, Scopes: parseScopes(rec.Scopes)}
+		if rec.Team != "" {
+			p.Teams = []string{rec.Team}
+		}
 		ctx := withPrincipal(r.Context(), p)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
 
-func requireScopes(required []string, audit *AuditStore, describe func(*http.Request) (string, string, string)) func(http.Handler) http.Handler {
+// requireScopes enforces scopes and, when owner is non-nil, resource-level
+// ownership: non-admin principals may only reach resources they or their team
+// own. Ownership denials answer 404 so resource IDs cannot be probed.
+func requireScopes(required []string, audit *AuditStore, describe func(*http.Request) (string, string, string), owner ownerResolver) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			p, ok := principalFrom(r.Context())
 			act, rType, rID := describe(r)
 			actor := "anonymous"
 			if ok {
-				actor = p.TokenID
+				actor = p.Actor()
 			}
 			if !ok {
 				audit.Append(r.Context(), actor, act, rType, rID, "denied", map[string]any{"reason": "missing_token"})
@@ -108,6 +160,18 @@ func requireScopes(required []string, audit *AuditStore, describe func(*http.Req
 					return
 				}
 			}
+			if owner != nil && !isAdmin(p) {
+				o, found, err := owner(r)
+				if err != nil {
+					writeError(w, http.StatusInternalServerError, "authorization lookup failed")
+					return
+				}
+				if found && !p.canAccess(o) {
+					audit.Append(r.Context(), actor, act, rType, rID, "denied", map[string]any{"reason": "not_owner"})
+					writeError(w, http.StatusNotFound, "not found")
+					return
+				}
+			}
 			audit.Append(r.Context(), actor, act, rType, rID, "allowed", nil)
 			next.ServeHTTP(w, r)
 		})
@@ -116,7 +180,7 @@ func requireScopes(required []string, audit *AuditStore, describe func(*http.Req
 
 func principal(ctx context.Context) string {
 	if p, ok := principalFrom(ctx); ok {
-		return p.TokenID
+		return p.Actor()
 	}
 	return "anonymous"
 }
@@ -6,8 +6,28 @@ type principalKey struct{}
 
 // Principal is the authenticated caller identity for the request.
 type Principal struct {
+	// TokenID is set for opaque bearer tokens.
 	TokenID string
-	Scopes  map[string]struct{}
+	// Identity, Subject and Issuer are set for OIDC-authenticated humans.
+	Identity string
+	Subject  string
+	Issuer   string
+	// Teams grants shared access to resources stamped with any of these teams.
+	Teams  []string
+	Scopes map[string]struct{}
+}
+
+// Actor is the name recorded in audit events and ownership stamps: the
+// issuer-qualified human identity when known, otherwise the token ID. The
+// "oidc:" prefix keeps a human's identity from ever matching a token ID.
+func (p *Principal) Actor() string {
+	if p == nil {
+		return "anonymous"
+	}
+	if p.Identity != "" {
+		return "oidc:" + p.Issuer + "/" + p.Identity
+	}
+	return p.TokenID
 }
 
 func withPrincipal(ctx context.Context, p *Principal) context.Context {
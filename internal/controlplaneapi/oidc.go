@@ -0,0 +1,544 @@
+package controlplaneapi
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	_ "crypto/sha256"
+	_ "crypto/sha512"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	defaultOIDCIdentityClaim = "email"
+	defaultOIDCTeamsClaim    = "groups"
+	defaultOIDCJWKSCacheTTL  = 10 * time.Minute
+	// oidcJWKSRefreshCooldown rate-limits JWKS refetches triggered by unknown
+	// key IDs so forged tokens cannot be used to hammer the issuer.
+	oidcJWKSRefreshCooldown = 30 * time.Second
+	oidcClockSkew           = time.Minute
+	maxOIDCDocumentBytes    = 1 << 20
+)
+
+// OIDCClaimScope grants Scopes to any OIDC principal whose Claim equals Value
+// (or, for array claims such as groups, contains Value).
+type OIDCClaimScope struct {
+	Claim  string
+	Value  string
+	Scopes []string
+}
+
+// OIDCOptions enables JWT bearer authentication against an OIDC issuer.
+type OIDCOptions struct {
+	IssuerURL string
+	Audience  string
+	// IdentityClaim names the claim recorded as the audit actor (default "email",
+	// falling back to "sub" when absent). The email claim only counts when
+	// email_verified is true.
+	IdentityClaim string
+	// TeamsClaim names the string or string-array claim listing the caller's
+	// teams for shared resource ownership (default "groups").
+	TeamsClaim  string
+	ClaimScopes []OIDCClaimScope
+
+	HTTPClient   *http.Client
+	JWKSCacheTTL time.Duration
+}
+
+type oidcVerifier struct {
+	issuer        string
+	audience      string
+	identityClaim string
+	teamsClaim    string
+	claimScopes   []OIDCClaimScope
+	httpClient    *http.Client
+	cacheTTL      time.Duration
+	now           func() time.Time
+
+	// refreshes collapses concurrent JWKS fetches into one; mu is never held
+	// while fetching, so verification of cached keys does not wait on the
+	// issuer.
+	refreshes singleflight.Group
+
+	mu          sync.Mutex
+	jwksURI     string
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+}
+
+func newOIDCVerifier(opts OIDCOptions) (*oidcVerifier, error) {
+	issuer := strings.TrimRight(strings.TrimSpace(opts.IssuerURL), "/")
+	if issuer == "" {
+		return nil, nil
+	}
+	u, err := url.Parse(issuer)
+	if err != nil || u.Host == "" || (u.Scheme != "https" && u.Scheme != "http") {
+		return nil, fmt.Errorf("oidc issuer must be an absolute http(s) URL")
+	}
+	audience := strings.TrimSpace(opts.Audience)
+	if audience == "" {
+		return nil, errors.New("oidc audience required")
+	}
+	for _, m := range opts.ClaimScopes {
+		if strings.TrimSpace(m.Claim) == "" {
+			return nil, errors.New("oidc claim scope mapping requires a claim")
+		}
+		if len(m.Scopes) == 0 {
+			return nil, fmt.Errorf("oidc claim scope mapping for %q requires scopes", m.Claim)
+		}
+		for _, s := range m.Scopes {
+			if !isKnownScope(strings.TrimSpace(s)) {
+				return nil, fmt.Errorf("oidc claim scope mapping for %q has unknown scope %q", m.Claim, s)
+			}
+		}
+	}
+	identityClaim := strings.TrimSpace(opts.IdentityClaim)
+	if identityClaim == "" {
+		identityClaim = defaultOIDCIdentityClaim
+	}
+	teamsClaim := strings.TrimSpace(opts.TeamsClaim)
+	if teamsClaim == "" {
+		teamsClaim = defaultOIDCTeamsClaim
+	}
+	hc := opts.HTTPClient
+	if hc == nil {
+		hc = &http.Client{Timeout: 10 * time.Second}
+	}
+	ttl := opts.JWKSCacheTTL
+	if ttl <= 0 {
+		ttl = defaultOIDCJWKSCacheTTL
+	}
+	return &oidcVerifier{
+		issuer:        issuer,
+		audience:      audience,
+		identityClaim: identityClaim,
+		teamsClaim:    teamsClaim,
+		claimScopes:   opts.ClaimScopes,
+		httpClient:    hc,
+		cacheTTL:      ttl,
+		now:           time.Now,
+	}, nil
+}
+
+// looksLikeJWT cheaply distinguishes compact JWS bearer tokens from opaque ones.
+func looksLikeJWT(raw string) bool {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 || parts[0] == "" || parts[2] == "" {
+		return false
+	}
+	b, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return false
+	}
+	var hdr struct {
+		Alg string `json:"alg"`
+	}
+	return json.Unmarshal(b, &hdr) == nil && hdr.Alg != ""
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	Typ string `json:"typ"`
+}
+
+// Verify validates a compact JWT and returns the mapped principal.
+func (v *oidcVerifier) Verify(ctx context.Context, raw string) (*Principal, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed jwt")
+	}
+	hb, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errors.New("malformed jwt header")
+	}
+	var hdr jwtHeader
+	if err := json.Unmarshal(hb, &hdr); err != nil {
+		return nil, errors.New("malformed jwt header")
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errors.New("malformed jwt signature")
+	}
+	key, err := v.key(ctx, hdr.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifyJWTSignature(hdr.Alg, key, []byte(parts[0]+"."+parts[1]), sig); err != nil {
+		return nil, err
+	}
+
+	pb, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errors.New("malformed jwt payload")
+	}
+	dec := json.NewDecoder(bytes.NewReader(pb))
+	dec.UseNumber()
+	var claims map[string]any
+	if err := dec.Decode(&claims); err != nil {
+		return nil, errors.New("malformed jwt payload")
+	}
+	if err := v.validateClaims(claims); err != nil {
+		return nil, err
+	}
+	// An unverified email must not name the caller or match a scope mapping.
+	if !claimTrue(claims["email_verified"]) {
+		delete(claims, "email")
+	}
+
+	subject, _ := claims["sub"].(string)
+	identity, _ := claims[v.identityClaim].(string)
+	if strings.TrimSpace(identity) == "" {
+		identity = subject
+	}
+	if strings.TrimSpace(identity) == "" {
+		return nil, errors.New("jwt has no identity claim")
+	}
+	return &Principal{
+		Identity: identity,
+		Subject:  subject,
+		Issuer:   v.issuer,
+		Teams:    claimStrings(claims[v.teamsClaim]),
+		Scopes:   v.mapScopes(claims),
+	}, nil
+}
+
+func (v *oidcVerifier) validateClaims(claims map[string]any) error {
+	iss, _ := claims["iss"].(string)
+	if strings.TrimRight(iss, "/") != v.issuer {
+		return errors.New("jwt issuer mismatch")
+	}
+	if !claimContains(claims["aud"], v.audience) {
+		return errors.New("jwt audience mismatch")
+	}
+	now := v.now()
+	exp, ok := numericClaim(claims["exp"])
+	if !ok {
+		return errors.New("jwt missing exp")
+	}
+	if now.After(exp.Add(oidcClockSkew)) {
+		return errors.New("jwt expired")
+	}
+	if nbf, ok := numericClaim(claims["nbf"]); ok && now.Add(oidcClockSkew).Before(nbf) {
+		return errors.New("jwt not yet valid")
+	}
+	return nil
+}
+
+func (v *oidcVerifier) mapScopes(claims map[string]any) map[string]struct{} {
+	out := map[string]struct{}{}
+	for _, m := range v.claimScopes {
+		if !claimContains(claims[m.Claim], m.Value) {
+			continue
+		}
+		for _, s := range m.Scopes {
+			if s = strings.TrimSpace(s); s != "" {
+				out[s] = struct{}{}
+			}
+		}
+	}
+	return out
+}
+
+func claimContains(claim any, want string) bool {
+	switch c := claim.(type) {
+	case string:
+		return c == want
+	case []any:
+		for _, item := range c {
+			if s, ok := item.(string); ok && s == want {
+				return true
+			}
+		}
+	case bool:
+		return strings.EqualFold(want, fmt.Sprint(c))
+	}
+	return false
+}
+
+// claimTrue accepts a boolean true or the string "true", which some issuers
+// send for email_verified.
+func claimTrue(claim any) bool {
+	switch c := claim.(type) {
+	case bool:
+		return c
+	case string:
+		return strings.EqualFold(strings.TrimSpace(c), "true")
+	}
+	return false
+}
+
+func claimStrings(claim any) []string {
+	switch c := claim.(type) {
+	case string:
+		if c = strings.TrimSpace(c); c != "" {
+			return []string{c}
+		}
+	case []any:
+		out := make([]string, 0, len(c))
+		for _, item := range c {
+			if s, ok := item.(string); ok && strings.TrimSpace(s) != "" {
+				out = append(out, strings.TrimSpace(s))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func numericClaim(v any) (time.Time, bool) {
+	n, ok := v.(json.Number)
+	if !ok {
+		return time.Time{}, false
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(int64(f), 0), true
+}
+
+func verifyJWTSignature(alg string, key crypto.PublicKey, signed, sig []byte) error {
+	var hash crypto.Hash
+	switch alg {
+	case "RS256", "ES256", "PS256":
+		hash = crypto.SHA256
+	case "RS384", "ES384", "PS384":
+		hash = crypto.SHA384
+	case "RS512", "PS512":
+		hash = crypto.SHA512
+	default:
+		return fmt.Errorf("unsupported jwt alg %q", alg)
+	}
+	h := hash.New()
+	_, _ = h.Write(signed)
+	digest := h.Sum(nil)
+
+	switch k := key.(type) {
+	case *rsa.PublicKey:
+		switch alg[:2] {
+		case "RS":
+			if rsa.VerifyPKCS1v15(k, hash, digest, sig) != nil {
+				return errors.New("jwt signature invalid")
+			}
+			return nil
+		case "PS":
+			if rsa.VerifyPSS(k, hash, digest, sig, nil) != nil {
+				return errors.New("jwt signature invalid")
+			}
+			return nil
+		}
+	case *ecdsa.PublicKey:
+		// Each ES alg is bound to one curve, so an ES384 token cannot be
+		// checked against a P-256 key.
+		if alg != ecdsaAlg(k.Curve) {
+			break
+		}
+		size := (k.Curve.Params().BitSize + 7) / 8
+		if len(sig) != 2*size {
+			return errors.New("jwt signature invalid")
+		}
+		r := new(big.Int).SetBytes(sig[:size])
+		s := new(big.Int).SetBytes(sig[size:])
+		if !ecdsa.Verify(k, digest, r, s) {
+			return errors.New("jwt signature invalid")
+		}
+		return nil
+	}
+	return fmt.Errorf("jwt alg %q does not match signing key", alg)
+}
+
+func ecdsaAlg(curve elliptic.Curve) string {
+	switch curve {
+	case elliptic.P256():
+		return "ES256"
+	case elliptic.P384():
+		return "ES384"
+	}
+	return ""
+}
+
+func (v *oidcVerifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	now := v.now()
+	k, found := v.lookupKeyLocked(kid)
+	fresh := v.keys != nil && now.Sub(v.fetchedAt) <= v.cacheTTL
+	// An unknown kid may mean the issuer rotated keys, but refetches are
+	// rate-limited, and a failed refresh keeps serving the cached keys.
+	recent := now.Sub(v.attemptedAt) < oidcJWKSRefreshCooldown
+	v.mu.Unlock()
+	if found && (fresh || recent) {
+		return k, nil
+	}
+	if recent {
+		return nil, errors.New("jwt signing key not found")
+	}
+
+	_, err, _ := v.refreshes.Do("jwks", func() (any, error) {
+		return nil, v.refresh(context.WithoutCancel(ctx))
+	})
+	v.mu.Lock()
+	k, found = v.lookupKeyLocked(kid)
+	v.mu.Unlock()
+	if found {
+		return k, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return nil, errors.New("jwt signing key not found")
+}
+
+func (v *oidcVerifier) lookupKeyLocked(kid string) (crypto.PublicKey, bool) {
+	if kid != "" {
+		k, ok := v.keys[kid]
+		return k, ok
+	}
+	// Tokens without a kid are only accepted when the issuer publishes one key.
+	if len(v.keys) == 1 {
+		for _, k := range v.keys {
+			return k, true
+		}
+	}
+	return nil, false
+}
+
+// refresh fetches the JWKS, discovering its URI first if needed. It only
+// replaces the cached keys on success.
+func (v *oidcVerifier) refresh(ctx context.Context) error {
+	v.mu.Lock()
+	v.attemptedAt = v.now()
+	jwksURI := v.jwksURI
+	v.mu.Unlock()
+
+	if jwksURI == "" {
+		var doc struct {
+			Issuer  string `json:"issuer"`
+			JWKSURI string `json:"jwks_uri"`
+		}
+		if err := v.getJSON(ctx, v.issuer+"/.well-known/openid-configuration", &doc); err != nil {
+			return fmt.Errorf("oidc discovery: %w", err)
+		}
+		if strings.TrimRight(doc.Issuer, "/") != v.issuer {
+			return errors.New("oidc discovery: issuer mismatch")
+		}
+		if strings.TrimSpace(doc.JWKSURI) == "" {
+			return errors.New("oidc discovery: jwks_uri missing")
+		}
+		jwksURI = doc.JWKSURI
+		v.mu.Lock()
+		v.jwksURI = jwksURI
+		v.mu.Unlock()
+	}
+	var set struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := v.getJSON(ctx, jwksURI, &set); err != nil {
+		return fmt.Errorf("oidc jwks: %w", err)
+	}
+	keys := map[string]crypto.PublicKey{}
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		k, err := jwk.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[jwk.Kid] = k
+	}
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = v.now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *oidcVerifier) getJSON(ctx context.Context, target string, dst any) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxOIDCDocumentBytes)).Decode(dst)
+}
+
+type jsonWebKey struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (k jsonWebKey) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		exp := new(big.Int).SetBytes(e)
+		if !exp.IsInt64() || exp.Int64() < 3 || exp.Int64() > 1<<31-1 {
+			return nil, errors.New("invalid rsa exponent")
+		}
+		return &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exp.Int64())}, nil
+	case "EC":
+		var curve elliptic.Curve
+		switch k.Crv {
+		case "P-256":
+			curve = elliptic.P256()
+		case "P-384":
+			curve = elliptic.P384()
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		size := (curve.Params().BitSize + 7) / 8
+		if len(x) != size || len(y) != size {
+			return nil, errors.New("invalid ec point")
+		}
+		point := append([]byte{4}, append(x, y...)...)
+		return ecdsa.ParseUncompressedPublicKey(curve, point)
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
@@ -0,0 +1,308 @@
+package controlplaneapi
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeOIDCIssuer serves discovery and JWKS documents and mints RS256 tokens.
+type fakeOIDCIssuer struct {
+	srv *httptest.Server
+
+	mu        sync.Mutex
+	kid       string
+	key       *rsa.PrivateKey
+	jwksFetch int
+	jwksDown  bool
+}
+
+func newFakeOIDCIssuer(t *testing.T) *fakeOIDCIssuer {
+	t.Helper()
+	f := &fakeOIDCIssuer{}
+	f.rotate(t, "key-1")
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(map[string]any{"issuer": f.srv.URL, "jwks_uri": f.srv.URL + "/jwks"})
+	})
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, _ *http.Request) {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		f.jwksFetch++
+		if f.jwksDown {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"keys": []map[string]any{{
+			"kty": "RSA",
+			"kid": f.kid,
+			"use": "sig",
+			"alg": "RS256",
+			"n":   base64.RawURLEncoding.EncodeToString(f.key.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(f.key.E)).Bytes()),
+		}}})
+	})
+	f.srv = httptest.NewServer(mux)
+	t.Cleanup(f.srv.Close)
+	return f
+}
+
+func (f *fakeOIDCIssuer) rotate(t *testing.T, kid string) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	f.mu.Lock()
+	f.kid, f.key = kid, key
+	f.mu.Unlock()
+}
+
+func (f *fakeOIDCIssuer) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+	f.mu.Lock()
+	kid, key := f.kid, f.key
+	f.mu.Unlock()
+	hdr, _ := json.Marshal(map[string]any{"alg": "RS256", "typ": "JWT", "kid": kid})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(hdr) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (f *fakeOIDCIssuer) claims(overrides map[string]any) map[string]any {
+	c := map[string]any{
+		"iss":            f.srv.URL,
+		"aud":            []string{"kocao"},
+		"sub":            "user-123",
+		"email":          "alice@example.com",
+		"email_verified": true,
+		"groups":         []string{"platform"},
+		"exp":            time.Now().Add(time.Hour).Unix(),
+		"iat":            time.Now().Unix(),
+	}
+	for k, v := range overrides {
+		if v == nil {
+			delete(c, k)
+			continue
+		}
+		c[k] = v
+	}
+	return c
+}
+
+func newOIDCTestAPI(t *testing.T, issuer *fakeOIDCIssuer) *API {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(corev1.AddToScheme(scheme))
+	utilruntime.Must(appsv1.AddToScheme(scheme))
+	utilruntime.Must(operatorv1alpha1.AddToScheme(scheme))
+	k8s := fake.NewClientBuilder().WithScheme(scheme).Build()
+
+	api, err := New("test-ns", "", "", nil, k8s, Options{Env: "test", OIDC: OIDCOptions{
+		IssuerURL: issuer.srv.URL,
+		Audience:  "kocao",
+		ClaimScopes: []OIDCClaimScope{
+			{Claim: "groups", Value: "platform", Scopes: []string{ScopeWorkspaceSessionRead, ScopeAuditRead}},
+			{Claim: "email", Value: "bob@example.com", Scopes: []string{ScopeHarnessRunRead}},
+		},
+	}})
+	if err != nil {
+		t.Fatalf("New() error: %v", err)
+	}
+	return api
+}
+
+func TestOIDC_MapsClaimsToScopesAndAuditsHumanIdentity(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	api := newOIDCTestAPI(t, issuer)
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	jwt := issuer.sign(t, issuer.claims(nil))
+	resp, b := doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions", jwt, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list sessions status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/harness-runs", jwt, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("unmapped scope status = %d, want 403", resp.StatusCode)
+	}
+
+	evs, err := api.Audit.List(context.Background(), 10)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	found := false
+	for _, ev := range evs {
+		if ev.Action == "workspace-session.list" && ev.Outcome == "allowed" {
+			found = true
+			if want := "oidc:" + issuer.srv.URL + "/alice@example.com"; ev.Actor != want {
+				t.Fatalf("audit actor = %q, want %s", ev.Actor, want)
+			}
+		}
+	}
+	if !found {
+		t.Fatalf("expected allowed workspace-session.list audit event")
+	}
+}
+
+func TestOIDC_RejectsInvalidTokens(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	api := newOIDCTestAPI(t, issuer)
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	other := newFakeOIDCIssuer(t)
+	cases := map[string]string{
+		"wrong audience": issuer.sign(t, issuer.claims(map[string]any{"aud": "someone-else"})),
+		"wrong issuer":   issuer.sign(t, issuer.claims(map[string]any{"iss": "https://evil.example.com"})),
+		"expired":        issuer.sign(t, issuer.claims(map[string]any{"exp": time.Now().Add(-time.Hour).Unix()})),
+		"missing exp":    issuer.sign(t, issuer.claims(map[string]any{"exp": nil})),
+		"foreign key":    other.sign(t, issuer.claims(nil)),
+	}
+	for name, jwt := range cases {
+		resp, _ := doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions", jwt, nil)
+		if resp.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("%s: status = %d, want 401", name, resp.StatusCode)
+		}
+	}
+}
+
+func TestOIDC_RefetchesJWKSOnKeyRotation(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	v, err := newOIDCVerifier(OIDCOptions{IssuerURL: issuer.srv.URL, Audience: "kocao", ClaimScopes: []OIDCClaimScope{{Claim: "email", Value: "bob@example.com", Scopes: []string{ScopeHarnessRunRead}}}})
+	if err != nil {
+		t.Fatalf("newOIDCVerifier: %v", err)
+	}
+	now := time.Now()
+	v.now = func() time.Time { return now }
+
+	p, err := v.Verify(context.Background(), issuer.sign(t, issuer.claims(map[string]any{"email": "bob@example.com"})))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if p.Actor() != "oidc:"+issuer.srv.URL+"/bob@example.com" || !hasScope(p.Scopes, ScopeHarnessRunRead) || !p.inTeam("platform") {
+		t.Fatalf("unexpected principal: %+v", p)
+	}
+	if _, err := v.Verify(context.Background(), issuer.sign(t, issuer.claims(nil))); err != nil {
+		t.Fatalf("cached verify: %v", err)
+	}
+
+	issuer.rotate(t, "key-2")
+	rotated := issuer.sign(t, issuer.claims(nil))
+	if _, err := v.Verify(context.Background(), rotated); err == nil {
+		t.Fatalf("expected unknown kid to be rejected inside refresh cooldown")
+	}
+	now = now.Add(oidcJWKSRefreshCooldown + time.Second)
+	if _, err := v.Verify(context.Background(), rotated); err != nil {
+		t.Fatalf("verify after rotation: %v", err)
+	}
+	issuer.mu.Lock()
+	fetches := issuer.jwksFetch
+	issuer.mu.Unlock()
+	if fetches != 2 {
+		t.Fatalf("jwks fetches = %d, want 2", fetches)
+	}
+}
+
+func TestOIDC_RejectsUnknownMappedScope(t *testing.T) {
+	_, err := newOIDCVerifier(OIDCOptions{IssuerURL: "https://idp.example.com", Audience: "kocao", ClaimScopes: []OIDCClaimScope{{Claim: "groups", Value: "x", Scopes: []string{"bogus"}}}})
+	if err == nil {
+		t.Fatalf("expected unknown scope error")
+	}
+}
+
+func TestOIDC_IgnoresUnverifiedEmail(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	v, err := newOIDCVerifier(OIDCOptions{IssuerURL: issuer.srv.URL, Audience: "kocao", ClaimScopes: []OIDCClaimScope{{Claim: "email", Value: "bob@example.com", Scopes: []string{ScopeHarnessRunRead}}}})
+	if err != nil {
+		t.Fatalf("newOIDCVerifier: %v", err)
+	}
+	p, err := v.Verify(context.Background(), issuer.sign(t, issuer.claims(map[string]any{"email": "bob@example.com", "email_verified": false})))
+	if err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+	if p.Actor() != "oidc:"+issuer.srv.URL+"/user-123" || hasScope(p.Scopes, ScopeHarnessRunRead) {
+		t.Fatalf("unverified email was honoured: actor=%q scopes=%v", p.Actor(), p.Scopes)
+	}
+}
+
+func TestOIDC_ServesCachedKeysWhenRefreshFails(t *testing.T) {
+	issuer := newFakeOIDCIssuer(t)
+	v, err := newOIDCVerifier(OIDCOptions{IssuerURL: issuer.srv.URL, Audience: "kocao", JWKSCacheTTL: time.Minute})
+	if err != nil {
+		t.Fatalf("newOIDCVerifier: %v", err)
+	}
+	now := time.Now()
+	v.now = func() time.Time { return now }
+	jwt := issuer.sign(t, issuer.claims(nil))
+	if _, err := v.Verify(context.Background(), jwt); err != nil {
+		t.Fatalf("verify: %v", err)
+	}
+
+	issuer.mu.Lock()
+	issuer.jwksDown = true
+	issuer.mu.Unlock()
+	now = now.Add(2 * time.Minute)
+	if _, err := v.Verify(context.Background(), jwt); err != nil {
+		t.Fatalf("verify with issuer down after ttl: %v", err)
+	}
+	// The failed refresh is rate-limited like any other.
+	if _, err := v.Verify(context.Background(), jwt); err != nil {
+		t.Fatalf("second verify with issuer down: %v", err)
+	}
+	issuer.mu.Lock()
+	fetches := issuer.jwksFetch
+	issuer.mu.Unlock()
+	if fetches != 2 {
+		t.Fatalf("jwks fetches = %d, want 2", fetches)
+	}
+}
+
+func TestOIDC_BindsECAlgToCurve(t *testing.T) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generate key: %v", err)
+	}
+	signed := []byte("header.payload")
+	digest := sha256.Sum256(signed)
+	r, s, err := ecdsa.Sign(rand.Reader, key, digest[:])
+	if err != nil {
+		t.Fatalf("sign: %v", err)
+	}
+	sig := make([]byte, 64)
+	r.FillBytes(sig[:32])
+	s.FillBytes(sig[32:])
+	if err := verifyJWTSignature("ES256", &key.PublicKey, signed, sig); err != nil {
+		t.Fatalf("ES256 with P-256: %v", err)
+	}
+	if err := verifyJWTSignature("ES384", &key.PublicKey, signed, sig); err == nil {
+		t.Fatalf("expected ES384 to be rejected for a P-256 key")
+	}
+}
@@ -23,24 +23,42 @@ var openAPISpec = []byte(`{
     "/api/v1/remote-agent-pools": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/remote-agents": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/remote-agents/{agentID}": {"get": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agents/{agentID}/heartbeat": {"post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/remote-agent-tasks": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/remote-agent-tasks/{taskID}": {"get": {"security": [{"bearerAuth": []}] }},
     "/api/v1/remote-agent-tasks/{taskID}/cancel": {"post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/remote-agent-tasks/{taskID}/retry": {"post": {"security": [{"bearerAuth": []}] }},
-    "/api/v1/remote-agent-tasks/{taskID}/transcript": {"get": {"security": [{"bearerAuth": []}] }},
-    "/api/v1/remote-agent-tasks/{taskID}/artifacts": {"get": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-tasks/{taskID}/start": {"post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-tasks/{taskID}/complete": {"post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-tasks/{taskID}/fail": {"post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-tasks/{taskID}/transcript": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-tasks/{taskID}/artifacts": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-tasks/{taskID}/artifacts/upload": {"post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-tasks/{taskID}/artifacts/{artifactID}/content": {"get": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-workflows": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-workflows/{workflowID}": {"get": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/remote-agent-workflows/{workflowID}/cancel": {"post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/workspace-sessions/{workspaceSessionID}/attach-control": {"patch": {"security": [{"bearerAuth": []}] }},
     "/api/v1/workspace-sessions/{workspaceSessionID}/attach-token": {"post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/workspace-sessions/{workspaceSessionID}/attach": {"get": {"security": [{"bearerAuth": []}] }},
     "/api/v1/workspace-sessions/{workspaceSessionID}/egress-override": {"patch": {"security": [{"bearerAuth": []}] }},
-    "/api/v1/audit": {"get": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/workspace-sessions/{workspaceSessionID}/snapshots": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/workspace-snapshots/{snapshotID}": {"get": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/workspace-snapshots/{snapshotID}/restore": {"post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/tokens": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/tokens/{tokenID}": {"get": {"security": [{"bearerAuth": []}] }, "delete": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/tokens/{tokenID}/rotate": {"post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/audit": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/cluster-overview": {"get": {"security": [{"bearerAuth": []}] }},
     "/api/v1/pods/{podName}/logs": {"get": {"security": [{"bearerAuth": []}] }},
     "/api/v1/symphony-projects": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/symphony-projects/{projectName}": {"get": {"security": [{"bearerAuth": []}] }, "patch": {"security": [{"bearerAuth": []}] }},
     "/api/v1/symphony-projects/{projectName}/pause": {"post": {"security": [{"bearerAuth": []}] }},
     "/api/v1/symphony-projects/{projectName}/resume": {"post": {"security": [{"bearerAuth": []}] }},
-    "/api/v1/symphony-projects/{projectName}/refresh": {"post": {"security": [{"bearerAuth": []}] }}
+    "/api/v1/symphony-projects/{projectName}/refresh": {"post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/symphony-projects/{projectName}/plan": {"get": {"security": [{"bearerAuth": []}] }, "post": {"security": [{"bearerAuth": []}] }},
+    "/api/v1/webhooks/github": {"post": {"security": []}},
+    "/api/v1/webhooks/github/deliveries": {"get": {"security": [{"bearerAuth": []}] }}
   },
   "components": {
     "securitySchemes": {
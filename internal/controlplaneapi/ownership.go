@@ -0,0 +1,229 @@
+package controlplaneapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/operator/controllers"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// The ownership stamp is shared with the operator, which checks it against
+// run classes.
+const (
+	annotationOwner = controllers.AnnotationOwner
+	labelTeam       = controllers.LabelTeam
+)
+
+// resourceOwner is the ownership stamp of a session, run or task. Resources
+// created before ownership existed carry no stamp and stay visible to any
+// principal that holds the route's scope.
+type resourceOwner struct {
+	Owner string
+	Team  string
+}
+
+func (o resourceOwner) unowned() bool {
+	return o.Owner == "" && o.Team == ""
+}
+
+// ownerResolver loads the ownership stamp of the resource addressed by a
+// request. found=false lets the handler produce its own not-found response.
+type ownerResolver func(*http.Request) (owner resourceOwner, found bool, err error)
+
+func isAdmin(p *Principal) bool {
+	return p != nil && hasScope(p.Scopes, ScopeAdmin)
+}
+
+func (p *Principal) inTeam(team string) bool {
+	if p == nil || team == "" {
+		return false
+	}
+	for _, t := range p.Teams {
+		if t == team {
+			return true
+		}
+	}
+	return false
+}
+
+// canAccess reports whether p may see or mutate a resource with owner o.
+func (p *Principal) canAccess(o resourceOwner) bool {
+	if p == nil {
+		return false
+	}
+	if isAdmin(p) || o.unowned() {
+		return true
+	}
+	if o.Owner != "" && o.Owner == p.Actor() {
+		return true
+	}
+	return p.inTeam(o.Team)
+}
+
+func visibleTo(ctx context.Context, o resourceOwner) bool {
+	p, ok := principalFrom(ctx)
+	return ok && p.canAccess(o)
+}
+
+// resolveRequestTeam validates an optional team for a new resource: callers
+// may only assign teams they belong to unless they hold the admin scope.
+func resolveRequestTeam(ctx context.Context, raw string) (string, error) {
+	team := strings.TrimSpace(raw)
+	if team == "" {
+		return "", nil
+	}
+	if errs := validation.IsValidLabelValue(team); len(errs) != 0 {
+		return "", &requestError{status: http.StatusBadRequest, msg: "invalid team"}
+	}
+	p, _ := principalFrom(ctx)
+	if !isAdmin(p) && !p.inTeam(team) {
+		return "", &requestError{status: http.StatusForbidden, msg: "caller is not a member of team"}
+	}
+	return team, nil
+}
+
+// stampOwner records the calling principal and team on new resource metadata.
+func stampOwner(ctx context.Context, labels, annotations map[string]string, team string) {
+	if p, ok := principalFrom(ctx); ok && p.Actor() != "" {
+		annotations[annotationOwner] = p.Actor()
+	}
+	if team != "" {
+		labels[labelTeam] = team
+	}
+}
+
+func ownerFromMeta(labels, annotations map[string]string) resourceOwner {
+	return resourceOwner{Owner: annotations[annotationOwner], Team: labels[labelTeam]}
+}
+
+func sessionOwner(s *operatorv1alpha1.Session) resourceOwner {
+	return ownerFromMeta(s.Labels, s.Annotations)
+}
+
+func runOwner(run *operatorv1alpha1.HarnessRun) resourceOwner {
+	return ownerFromMeta(run.Labels, run.Annotations)
+}
+
+func taskOwner(t remoteAgentTask) resourceOwner {
+	return resourceOwner{Owner: t.RequestedBy, Team: t.Team}
+}
+
+func workflowOwner(w remoteAgentWorkflow) resourceOwner {
+	return resourceOwner{Owner: w.RequestedBy, Team: w.Team}
+}
+
+// agentOwner is the stamp worker routes check: only the principal or team that
+// registered an agent may report for it.
+func agentOwner(a remoteAgent) resourceOwner {
+	return resourceOwner{Owner: a.RegisteredBy, Team: a.Team}
+}
+
+func snapshotOwner(s workspaceSnapshot) resourceOwner {
+	return resourceOwner{Owner: s.Owner, Team: s.Team}
+}
+
+func (a *API) workspaceSessionOwner(id string) ownerResolver {
+	return func(r *http.Request) (resourceOwner, bool, error) {
+		var sess operatorv1alpha1.Session
+		if err := a.K8s.Get(r.Context(), client.ObjectKey{Namespace: a.Namespace, Name: id}, &sess); err != nil {
+			if apierrors.IsNotFound(err) {
+				return resourceOwner{}, false, nil
+			}
+			return resourceOwner{}, false, err
+		}
+		return sessionOwner(&sess), true, nil
+	}
+}
+
+func (a *API) harnessRunOwner(id string) ownerResolver {
+	return func(r *http.Request) (resourceOwner, bool, error) {
+		var run operatorv1alpha1.HarnessRun
+		if err := a.K8s.Get(r.Context(), client.ObjectKey{Namespace: a.Namespace, Name: id}, &run); err != nil {
+			if apierrors.IsNotFound(err) {
+				return resourceOwner{}, false, nil
+			}
+			return resourceOwner{}, false, err
+		}
+		return runOwner(&run), true, nil
+	}
+}
+
+func (a *API) remoteAgentTaskOwner(id string) ownerResolver {
+	return func(_ *http.Request) (resourceOwner, bool, error) {
+		if a.RemoteAgentOrchestration == nil {
+			return resourceOwner{}, false, nil
+		}
+		task, ok := a.RemoteAgentOrchestration.GetTask(id)
+		if !ok {
+			return resourceOwner{}, false, nil
+		}
+		return taskOwner(task), true, nil
+	}
+}
+
+func (a *API) remoteAgentOwner(id string) ownerResolver {
+	return func(_ *http.Request) (resourceOwner, bool, error) {
+		if a.RemoteAgentOrchestration == nil {
+			return resourceOwner{}, false, nil
+		}
+		agent, ok := a.RemoteAgentOrchestration.GetAgent(id)
+		if !ok {
+			return resourceOwner{}, false, nil
+		}
+		return agentOwner(agent), true, nil
+	}
+}
+
+// remoteAgentTaskWorkerOwner resolves a task to the agent it is assigned to,
+// so only that agent's principal can start, complete or report on it. A task
+// without an agent is left to the handler, which rejects reports for it.
+func (a *API) remoteAgentTaskWorkerOwner(id string) ownerResolver {
+	return func(_ *http.Request) (resourceOwner, bool, error) {
+		if a.RemoteAgentOrchestration == nil {
+			return resourceOwner{}, false, nil
+		}
+		task, ok := a.RemoteAgentOrchestration.GetTask(id)
+		if !ok || task.AgentID == "" {
+			return resourceOwner{}, false, nil
+		}
+		agent, ok := a.RemoteAgentOrchestration.GetAgent(task.AgentID)
+		if !ok {
+			return resourceOwner{}, false, nil
+		}
+		return agentOwner(agent), true, nil
+	}
+}
+
+func (a *API) remoteAgentWorkflowOwner(id string) ownerResolver {
+	return func(_ *http.Request) (resourceOwner, bool, error) {
+		if a.RemoteAgentOrchestration == nil {
+			return resourceOwner{}, false, nil
+		}
+		workflow, ok := a.RemoteAgentOrchestration.GetWorkflow(id)
+		if !ok {
+			return resourceOwner{}, false, nil
+		}
+		return workflowOwner(workflow), true, nil
+	}
+}
+
+// workspaceSnapshotOwner resolves from the snapshot record, which carries the
+// source session's stamp, so snapshots stay reachable after that session is
+// deleted.
+func (a *API) workspaceSnapshotOwner(id string) ownerResolver {
+	return func(r *http.Request) (resourceOwner, bool, error) {
+		if a.WorkspaceSnapshots == nil {
+			return resourceOwner{}, false, nil
+		}
+		snap, ok := a.WorkspaceSnapshots.Get(r.Context(), id)
+		if !ok {
+			return resourceOwner{}, false, nil
+		}
+		return snapshotOwner(snap), true, nil
+	}
+}
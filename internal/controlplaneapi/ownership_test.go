@@ -0,0 +1,235 @@
+package controlplaneapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestOwnership_SessionsAreScopedToOwnerAndTeam(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	scopes := []string{ScopeWorkspaceSessionRead, ScopeWorkspaceSessionWrite}
+	for _, tok := range []struct{ id, raw string }{{"t-alice", "alice"}, {"t-bob", "bob"}} {
+		if err := api.Tokens.Create(context.Background(), tok.id, tok.raw, scopes); err != nil {
+			t.Fatalf("create token: %v", err)
+		}
+	}
+	if err := api.Tokens.Create(context.Background(), "t-admin", "admin", []string{ScopeWorkspaceSessionRead, ScopeAdmin}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	_, carolRaw, err := api.Tokens.Mint(context.Background(), TokenMintRequest{Name: "carol", Scopes: scopes, Team: "platform"})
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+	_, daveRaw, err := api.Tokens.Mint(context.Background(), TokenMintRequest{Name: "dave", Scopes: scopes, Team: "platform"})
+	if err != nil {
+		t.Fatalf("mint token: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions", "alice", map[string]any{"repoURL": "https://example.com/repo"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create session status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var private sessionResponse
+	_ = json.Unmarshal(b, &private)
+	if private.Owner != "t-alice" {
+		t.Fatalf("owner = %q, want t-alice", private.Owner)
+	}
+
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/"+private.ID, "bob", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("foreign get status = %d, want 404", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodDelete, srv.URL+"/api/v1/workspace-sessions/"+private.ID, "bob", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("foreign delete status = %d, want 404", resp.StatusCode)
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions", "bob", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list status = %d", resp.StatusCode)
+	}
+	var list struct {
+		WorkspaceSessions []sessionResponse `json:"workspaceSessions"`
+	}
+	_ = json.Unmarshal(b, &list)
+	if len(list.WorkspaceSessions) != 0 {
+		t.Fatalf("bob sees %d sessions, want 0", len(list.WorkspaceSessions))
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/"+private.ID, "admin", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("admin get status = %d, want 200", resp.StatusCode)
+	}
+
+	// Team members share access; callers cannot claim teams they are not in.
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions", "alice", map[string]any{"repoURL": "https://example.com/repo", "team": "platform"})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("non-member team create status = %d, want 403", resp.StatusCode)
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/workspace-sessions", carolRaw, map[string]any{"repoURL": "https://example.com/repo", "team": "platform"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("team create status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var shared sessionResponse
+	_ = json.Unmarshal(b, &shared)
+	if shared.Team != "platform" {
+		t.Fatalf("team = %q, want platform", shared.Team)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/"+shared.ID, daveRaw, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("teammate get status = %d, want 200", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/"+shared.ID, "bob", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("non-member get status = %d, want 404", resp.StatusCode)
+	}
+
+	evs, err := api.Audit.List(context.Background(), 50)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	denied := false
+	for _, ev := range evs {
+		if ev.Actor == "t-bob" && ev.Action == "workspace-session.delete" && ev.Outcome == "denied" && strings.Contains(string(ev.Metadata), "not_owner") {
+			denied = true
+		}
+	}
+	if !denied {
+		t.Fatalf("expected audited not_owner denial for bob's delete")
+	}
+}
+
+func TestOwnership_LegacyUnownedSessionsStayVisible(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-bob", "bob", []string{ScopeWorkspaceSessionRead}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	legacy := &operatorv1alpha1.Session{ObjectMeta: metav1.ObjectMeta{Name: "legacy", Namespace: "test-ns"}}
+	if err := api.K8s.Create(context.Background(), legacy); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, _ := doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/workspace-sessions/legacy", "bob", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("legacy get status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestOwnership_RemoteAgentTasksAreScopedToRequester(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	scopes := []string{ScopeRemoteAgentTaskRead, ScopeRemoteAgentTaskWrite}
+	for _, tok := range []struct{ id, raw string }{{"t-alice", "alice"}, {"t-bob", "bob"}} {
+		if err := api.Tokens.Create(context.Background(), tok.id, tok.raw, scopes); err != nil {
+			t.Fatalf("create token: %v", err)
+		}
+	}
+	pool, err := api.RemoteAgentOrchestration.CreatePool(remoteAgentPoolCreateRequest{Name: "reviewers"})
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	agent, err := api.RemoteAgentOrchestration.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer", PoolID: pool.ID})
+	if err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	task, err := api.RemoteAgentOrchestration.DispatchTask("t-alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Review"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, _ := doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/remote-agent-tasks/"+task.ID, "bob", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("foreign task get status = %d, want 404", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agent-tasks/"+task.ID+"/cancel", "bob", nil)
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("foreign task cancel status = %d, want 404", resp.StatusCode)
+	}
+	resp, b := doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/remote-agent-tasks", "bob", nil)
+	var list struct {
+		RemoteAgentTasks []remoteAgentTask `json:"remoteAgentTasks"`
+	}
+	_ = json.Unmarshal(b, &list)
+	if resp.StatusCode != http.StatusOK || len(list.RemoteAgentTasks) != 0 {
+		t.Fatalf("bob task list status=%d tasks=%d, want 200 and 0", resp.StatusCode, len(list.RemoteAgentTasks))
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/remote-agent-tasks/"+task.ID, "alice", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("owner task get status = %d, want 200", resp.StatusCode)
+	}
+}
+
+func TestOwnership_RemoteAgentWorkerRoutesAreBoundToTheAssignedAgent(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	scopes := []string{ScopeRemoteAgentRead, ScopeRemoteAgentWrite}
+	for _, tok := range []struct{ id, raw string }{{"t-worker-a", "worker-a"}, {"t-worker-b", "worker-b"}} {
+		if err := api.Tokens.Create(context.Background(), tok.id, tok.raw, scopes); err != nil {
+			t.Fatalf("create token: %v", err)
+		}
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agents", "worker-a", map[string]any{"name": "agent-a"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("register agent status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var agent remoteAgent
+	_ = json.Unmarshal(b, &agent)
+	if agent.RegisteredBy != "t-worker-a" {
+		t.Fatalf("registeredBy = %q, want t-worker-a", agent.RegisteredBy)
+	}
+	task, err := api.RemoteAgentOrchestration.DispatchTask("t-requester", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Review"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+
+	base := srv.URL + "/api/v1/remote-agent-tasks/" + task.ID
+	for _, tc := range []struct {
+		name string
+		url  string
+		body any
+	}{
+		{"heartbeat", srv.URL + "/api/v1/remote-agents/" + agent.ID + "/heartbeat", nil},
+		{"start", base + "/start", nil},
+		{"transcript", base + "/transcript", map[string]any{"role": "assistant", "text": "forged"}},
+		{"artifact", base + "/artifacts", map[string]any{"name": "report", "kind": "file", "uri": "s3://bucket/report"}},
+		{"upload", base + "/artifacts/upload?name=report", nil},
+		{"complete", base + "/complete", map[string]any{"summary": "forged"}},
+	} {
+		resp, _ := doJSON(t, srv.Client(), http.MethodPost, tc.url, "worker-b", tc.body)
+		if resp.StatusCode != http.StatusNotFound {
+			t.Fatalf("foreign %s status = %d, want 404", tc.name, resp.StatusCode)
+		}
+	}
+
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, base+"/start", "worker-a", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("assigned agent start status = %d, want 200", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, base+"/complete", "worker-a", map[string]any{"summary": "done"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("assigned agent complete status = %d, want 200", resp.StatusCode)
+	}
+}
@@ -0,0 +1,272 @@
+package controlplaneapi
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// s3ArtifactBlobStore talks to any S3-compatible endpoint with path-style
+// addressing and AWS Signature Version 4. It deliberately avoids an SDK: the
+// control plane only needs HEAD, PUT, GET and DELETE on single objects.
+type s3ArtifactBlobStore struct {
+	endpoint *url.URL
+	bucket   string
+	region   string
+	prefix   string
+	keyID    string
+	secret   string
+	client   *http.Client
+	now      func() time.Time
+}
+
+const s3EmptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+func newS3ArtifactBlobStore(opts S3ArtifactStoreOptions, client *http.Client) (*s3ArtifactBlobStore, error) {
+	endpoint := strings.TrimSpace(opts.Endpoint)
+	if endpoint == "" {
+		return nil, fmt.Errorf("s3 artifact store requires an endpoint")
+	}
+	parsed, err := url.Parse(endpoint)
+	if err != nil || parsed.Scheme == "" || parsed.Host == "" {
+		return nil, fmt.Errorf("invalid s3 artifact store endpoint %q", endpoint)
+	}
+	bucket := strings.TrimSpace(opts.Bucket)
+	if bucket == "" {
+		return nil, fmt.Errorf("s3 artifact store requires a bucket")
+	}
+	if strings.TrimSpace(opts.AccessKeyID) == "" || strings.TrimSpace(opts.SecretAccessKey) == "" {
+		return nil, fmt.Errorf("s3 artifact store requires access key credentials")
+	}
+	region := strings.TrimSpace(opts.Region)
+	if region == "" {
+		region = "us-east-1"
+	}
+	prefix := strings.Trim(strings.TrimSpace(opts.Prefix), "/")
+	if prefix != "" {
+		prefix += "/"
+	}
+	if client == nil {
+		client = &http.Client{Timeout: 5 * time.Minute}
+	}
+	parsed.Path = strings.TrimRight(parsed.Path, "/")
+	return &s3ArtifactBlobStore{
+		endpoint: parsed,
+		bucket:   bucket,
+		region:   region,
+		prefix:   prefix,
+		keyID:    strings.TrimSpace(opts.AccessKeyID),
+		secret:   strings.TrimSpace(opts.SecretAccessKey),
+		client:   client,
+		now:      time.Now,
+	}, nil
+}
+
+func (s *s3ArtifactBlobStore) objectURL(digest string) string {
+	u := *s.endpoint
+	u.Path = u.Path + "/" + s.bucket + "/" + s.prefix + "sha256/" + digest
+	return u.String()
+}
+
+func (s *s3ArtifactBlobStore) Put(ctx context.Context, digest string, body io.Reader, size int64) error {
+	exists, err := s.exists(ctx, digest)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return nil
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, s.objectURL(digest), body)
+	if err != nil {
+		return err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", "application/octet-stream")
+	// The digest was verified before Put, so it doubles as the signed payload hash.
+	s.sign(req, digest)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode/100 != 2 {
+		return s3ResponseError("put", resp)
+	}
+	return nil
+}
+
+func (s *s3ArtifactBlobStore) exists(ctx context.Context, digest string) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, s.objectURL(digest), nil)
+	if err != nil {
+		return false, err
+	}
+	s.sign(req, s3EmptyPayloadHash)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return false, nil
+	case resp.StatusCode/100 == 2:
+		return true, nil
+	default:
+		return false, s3ResponseError("head", resp)
+	}
+}
+
+func (s *s3ArtifactBlobStore) Open(ctx context.Context, digest string) (io.ReadCloser, int64, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.objectURL(digest), nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	s.sign(req, s3EmptyPayloadHash)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, 0, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		_ = resp.Body.Close()
+		return nil, 0, errArtifactBlobNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		defer func() { _ = resp.Body.Close() }()
+		return nil, 0, s3ResponseError("get", resp)
+	}
+	return resp.Body, resp.ContentLength, nil
+}
+
+func (s *s3ArtifactBlobStore) Delete(ctx context.Context, digest string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, s.objectURL(digest), nil)
+	if err != nil {
+		return err
+	}
+	s.sign(req, s3EmptyPayloadHash)
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusNotFound && resp.StatusCode/100 != 2 {
+		return s3ResponseError("delete", resp)
+	}
+	return nil
+}
+
+func s3ResponseError(op string, resp *http.Response) error {
+	msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	return fmt.Errorf("s3 %s: %s: %s", op, resp.Status, strings.TrimSpace(string(msg)))
+}
+
+// sign adds SigV4 headers to req. payloadHash is the hex sha256 of the body.
+func (s *s3ArtifactBlobStore) sign(req *http.Request, payloadHash string) {
+	now := s.now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signedHeaders, canonicalHeaders := s3CanonicalHeaders(req)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		s3CanonicalURI(req.URL),
+		s3CanonicalQuery(req.URL),
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		s3SHA256Hex([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := s3HMAC([]byte("AWS4"+s.secret), day)
+	key = s3HMAC(key, s.region)
+	key = s3HMAC(key, "s3")
+	key = s3HMAC(key, "aws4_request")
+	signature := hex.EncodeToString(s3HMAC(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", s.keyID, scope, signedHeaders, signature))
+}
+
+func s3CanonicalHeaders(req *http.Request) (string, string) {
+	values := map[string]string{"host": req.URL.Host}
+	if req.Host != "" {
+		values["host"] = req.Host
+	}
+	for name, vals := range req.Header {
+		lower := strings.ToLower(name)
+		if lower == "content-type" || lower == "range" || lower == "content-md5" || strings.HasPrefix(lower, "x-amz-") {
+			values[lower] = strings.TrimSpace(strings.Join(vals, ","))
+		}
+	}
+	if req.ContentLength > 0 {
+		values["content-length"] = strconv.FormatInt(req.ContentLength, 10)
+	}
+	names := make([]string, 0, len(values))
+	for name := range values {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonical strings.Builder
+	for _, name := range names {
+		canonical.WriteString(name)
+		canonical.WriteByte(':')
+		canonical.WriteString(values[name])
+		canonical.WriteByte('\n')
+	}
+	return strings.Join(names, ";"), canonical.String()
+}
+
+func s3CanonicalURI(u *url.URL) string {
+	path := u.EscapedPath()
+	if path == "" {
+		return "/"
+	}
+	return path
+}
+
+func s3CanonicalQuery(u *url.URL) string {
+	query := u.Query()
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		vals := append([]string(nil), query[key]...)
+		sort.Strings(vals)
+		for _, val := range vals {
+			parts = append(parts, s3Escape(key)+"="+s3Escape(val))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+func s3Escape(v string) string {
+	return strings.ReplaceAll(url.QueryEscape(v), "+", "%20")
+}
+
+func s3SHA256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	_, _ = mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
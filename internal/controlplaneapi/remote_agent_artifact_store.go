@@ -0,0 +1,307 @@
+package controlplaneapi
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Artifact content lives in a blob store keyed by sha256 digest, so the same
+// patch or report uploaded twice is stored once and a reference passed between
+// workflow steps stays valid regardless of which task produced it.
+
+const (
+	remoteAgentArtifactMaxUploadBytes = 512 << 20
+	remoteAgentArtifactDigestPrefix   = "sha256:"
+	remoteAgentArtifactBlobURIPrefix  = "kocao-artifact://sha256/"
+	remoteAgentArtifactDigestHeader   = "X-Kocao-Artifact-Digest"
+)
+
+var errArtifactBlobNotFound = errors.New("artifact blob not found")
+
+// ArtifactBlobStore stores artifact content by its hex sha256 digest. Put is
+// only called with content whose digest has already been verified.
+type ArtifactBlobStore interface {
+	Put(ctx context.Context, digest string, body io.Reader, size int64) error
+	Open(ctx context.Context, digest string) (io.ReadCloser, int64, error)
+	// Delete removes a blob; deleting a missing blob is not an error.
+	Delete(ctx context.Context, digest string) error
+}
+
+// ArtifactStoreOptions selects the artifact blob backend. Backend is
+// "filesystem" (the default) or "s3".
+type ArtifactStoreOptions struct {
+	Backend string
+	// Dir is the filesystem backend root; defaults to kocao.artifacts next to
+	// the audit log.
+	Dir string
+	S3  S3ArtifactStoreOptions
+}
+
+// S3ArtifactStoreOptions configures an S3-compatible endpoint (AWS S3, MinIO,
+// R2, ...). Objects are addressed path-style as <endpoint>/<bucket>/<prefix>.
+type S3ArtifactStoreOptions struct {
+	Endpoint        string
+	Bucket          string
+	Region          string
+	Prefix          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+func newArtifactBlobStore(opts ArtifactStoreOptions, auditPath string) (ArtifactBlobStore, error) {
+	switch strings.ToLower(strings.TrimSpace(opts.Backend)) {
+	case "", "filesystem", "fs":
+		dir := strings.TrimSpace(opts.Dir)
+		if dir == "" && auditPath != "" {
+			dir = filepath.Join(filepath.Dir(auditPath), "kocao.artifacts")
+		}
+		if dir == "" {
+			return nil, nil
+		}
+		return newFilesystemArtifactBlobStore(dir), nil
+	case "s3":
+		return newS3ArtifactBlobStore(opts.S3, nil)
+	default:
+		return nil, fmt.Errorf("unknown artifact store backend %q", opts.Backend)
+	}
+}
+
+// parseArtifactDigest accepts "sha256:<hex>" or bare hex and returns the
+// lower-case hex digest.
+func parseArtifactDigest(raw string) (string, bool) {
+	digest := strings.ToLower(strings.TrimSpace(raw))
+	digest = strings.TrimPrefix(digest, remoteAgentArtifactDigestPrefix)
+	if len(digest) != sha256.Size*2 {
+		return "", false
+	}
+	if _, err := hex.DecodeString(digest); err != nil {
+		return "", false
+	}
+	return digest, true
+}
+
+type filesystemArtifactBlobStore struct {
+	root string
+}
+
+func newFilesystemArtifactBlobStore(root string) *filesystemArtifactBlobStore {
+	return &filesystemArtifactBlobStore{root: root}
+}
+
+func (s *filesystemArtifactBlobStore) path(digest string) string {
+	return filepath.Join(s.root, "sha256", digest[:2], digest)
+}
+
+func (s *filesystemArtifactBlobStore) Put(_ context.Context, digest string, body io.Reader, _ int64) error {
+	target := s.path(digest)
+	if _, err := os.Stat(target); err == nil {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(target), digest+".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	if _, err := io.Copy(tmp, body); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), target)
+}
+
+func (s *filesystemArtifactBlobStore) Open(_ context.Context, digest string) (io.ReadCloser, int64, error) {
+	f, err := os.Open(s.path(digest))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, 0, errArtifactBlobNotFound
+		}
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+func (s *filesystemArtifactBlobStore) Delete(_ context.Context, digest string) error {
+	if err := os.Remove(s.path(digest)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
+// spoolArtifactUpload copies an upload to a temporary file while hashing it,
+// so the digest can be checked before anything reaches the blob store.
+func spoolArtifactUpload(body io.Reader) (*os.File, string, int64, error) {
+	f, err := os.CreateTemp("", "kocao-artifact-*")
+	if err != nil {
+		return nil, "", 0, err
+	}
+	hasher := sha256.New()
+	size, err := io.Copy(f, io.TeeReader(body, hasher))
+	if err == nil {
+		_, err = f.Seek(0, io.SeekStart)
+	}
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+		return nil, "", 0, err
+	}
+	return f, hex.EncodeToString(hasher.Sum(nil)), size, nil
+}
+
+func (a *API) handleRemoteAgentTaskArtifactUpload(w http.ResponseWriter, r *http.Request, id string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	if a.ArtifactBlobs == nil {
+		writeError(w, http.StatusNotImplemented, "artifact store not configured")
+		return
+	}
+	query := r.URL.Query()
+	req := remoteAgentArtifactCreateRequest{
+		Name:      strings.TrimSpace(query.Get("name")),
+		Kind:      remoteAgentArtifactKind(strings.TrimSpace(query.Get("kind"))),
+		MediaType: strings.TrimSpace(query.Get("mediaType")),
+		Path:      strings.TrimSpace(query.Get("path")),
+	}
+	if req.Kind == "" {
+		req.Kind = remoteAgentArtifactKindFile
+	}
+	if req.MediaType == "" {
+		req.MediaType = strings.TrimSpace(r.Header.Get("Content-Type"))
+	}
+	expected := strings.TrimSpace(query.Get("digest"))
+	if expected == "" {
+		expected = strings.TrimSpace(r.Header.Get(remoteAgentArtifactDigestHeader))
+	}
+	expectedDigest := ""
+	if expected != "" {
+		digest, ok := parseArtifactDigest(expected)
+		if !ok {
+			writeError(w, http.StatusBadRequest, "digest must be sha256:<hex>")
+			return
+		}
+		expectedDigest = digest
+	}
+	// The URI is filled in once the digest is known; validate the rest first.
+	if err := validateRemoteAgentArtifactCreateRequest(remoteAgentArtifactCreateRequest{Name: req.Name, Kind: req.Kind, URI: remoteAgentArtifactBlobURIPrefix}); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	if task, ok := a.RemoteAgentOrchestration.GetTask(id); !ok {
+		writeError(w, http.StatusNotFound, "remote agent task not found")
+		return
+	} else if task.State != remoteAgentTaskStateAssigned && task.State != remoteAgentTaskStateRunning {
+		writeError(w, http.StatusConflict, "task artifacts are immutable outside active states")
+		return
+	}
+
+	f, digest, size, err := spoolArtifactUpload(http.MaxBytesReader(w, r.Body, remoteAgentArtifactMaxUploadBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			writeError(w, http.StatusRequestEntityTooLarge, "artifact exceeds upload limit")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "read artifact body")
+		return
+	}
+	defer func() {
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}()
+	if expectedDigest != "" && expectedDigest != digest {
+		writeError(w, http.StatusBadRequest, fmt.Sprintf("digest mismatch: content is %s%s", remoteAgentArtifactDigestPrefix, digest))
+		return
+	}
+	if err := a.ArtifactBlobs.Put(r.Context(), digest, f, size); err != nil {
+		writeError(w, http.StatusBadGateway, "store artifact content")
+		return
+	}
+
+	req.URI = remoteAgentArtifactBlobURIPrefix + digest
+	req.SizeBytes = size
+	task, err := a.RemoteAgentOrchestration.addUploadedArtifact(id, req, remoteAgentArtifactDigestPrefix+digest)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, task.OutputArtifacts[len(task.OutputArtifacts)-1])
+}
+
+func (a *API) handleRemoteAgentTaskArtifactDownload(w http.ResponseWriter, r *http.Request, id, artifactID string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	if a.ArtifactBlobs == nil {
+		writeError(w, http.StatusNotImplemented, "artifact store not configured")
+		return
+	}
+	inputs, outputs, err := a.RemoteAgentOrchestration.TaskArtifacts(id)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	var artifact *remoteAgentArtifactRef
+	for _, candidate := range append(outputs, inputs...) {
+		if candidate.ID == artifactID {
+			artifact = &candidate
+			break
+		}
+	}
+	if artifact == nil {
+		writeError(w, http.StatusNotFound, "remote agent artifact not found")
+		return
+	}
+	digest, ok := parseArtifactDigest(artifact.Digest)
+	if !ok {
+		writeError(w, http.StatusNotFound, "artifact has no stored content")
+		return
+	}
+	body, size, err := a.ArtifactBlobs.Open(r.Context(), digest)
+	if err != nil {
+		if errors.Is(err, errArtifactBlobNotFound) {
+			writeError(w, http.StatusNotFound, "artifact has no stored content")
+			return
+		}
+		writeError(w, http.StatusBadGateway, "read artifact content")
+		return
+	}
+	defer func() { _ = body.Close() }()
+
+	mediaType := artifact.MediaType
+	if mediaType == "" {
+		mediaType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	w.Header().Set(remoteAgentArtifactDigestHeader, remoteAgentArtifactDigestPrefix+digest)
+	w.Header().Set("Content-Disposition", "attachment; filename*=UTF-8''"+url.PathEscape(filepath.Base(artifact.Name)))
+	w.WriteHeader(http.StatusOK)
+	_, _ = io.Copy(w, body)
+}
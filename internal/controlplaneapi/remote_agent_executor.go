@@ -0,0 +1,306 @@
+package controlplaneapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// remoteAgentExecutionTimeout bounds a prompt turn for tasks dispatched
+// without their own timeout.
+const remoteAgentExecutionTimeout = time.Hour
+
+// remoteAgentTranscriptSettle is how long the session stream must stay quiet
+// after the prompt returns before the executor stops mirroring it, so
+// trailing SSE events that race the prompt response still land in the
+// transcript. remoteAgentTranscriptDrainLimit bounds the whole drain for a
+// stream that never goes quiet.
+var (
+	remoteAgentTranscriptSettle     = 250 * time.Millisecond
+	remoteAgentTranscriptDrainLimit = 5 * time.Second
+)
+
+// executeTaskLocked hands a freshly assigned task to its bound agent session.
+// Tasks whose agent has no live session binding are left for an external
+// worker to drive through the start, complete and fail endpoints.
+func (s *RemoteAgentOrchestrationService) executeTaskLocked(task remoteAgentTask) {
+	if s.agentSessions == nil || s.k8s == nil || task.CurrentSession == nil {
+		return
+	}
+	runID := strings.TrimSpace(task.CurrentSession.HarnessRunID)
+	if runID == "" {
+		return
+	}
+	timeout := remoteAgentExecutionTimeout
+	if task.TimeoutSeconds > 0 {
+		timeout = time.Duration(task.TimeoutSeconds) * time.Second
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	if previous, ok := s.executions[task.ID]; ok {
+		previous()
+	}
+	s.executions[task.ID] = cancel
+	go s.executeTask(ctx, cancel, task.ID, task.Attempt, runID, task.Prompt)
+}
+
+func (s *RemoteAgentOrchestrationService) executeTask(ctx context.Context, cancel context.CancelFunc, taskID string, attempt int, runID, prompt string) {
+	defer func() {
+		cancel()
+		s.mu.Lock()
+		if task, ok := s.tasks[taskID]; !ok || task.Attempt == attempt {
+			delete(s.executions, taskID)
+		}
+		s.mu.Unlock()
+	}()
+	logger := slog.With("task", taskID, "harnessRun", runID)
+
+	var run operatorv1alpha1.HarnessRun
+	if err := s.k8s.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: runID}, &run); err != nil {
+		s.finishExecution(logger, taskID, attempt, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{
+			Summary: fmt.Sprintf("load bound harness run: %v", err),
+			Outcome: "session_unavailable",
+		})
+		return
+	}
+	if _, err := s.StartTask(taskID); err != nil {
+		logger.Warn("remote agent executor: start task failed", "error", err)
+		return
+	}
+	_, _ = s.AppendTranscript(taskID, remoteAgentTranscriptEntry{Role: remoteAgentTranscriptRoleUser, Kind: "prompt", Text: prompt})
+
+	events, unsubscribe := s.agentSessions.bridgeFor(&run).subscribe()
+	mirror := &remoteAgentTranscriptMirror{service: s, taskID: taskID, runID: runID}
+	mirrored := make(chan struct{})
+	activity := make(chan struct{}, 1)
+	turnEnded := make(chan struct{})
+	go func() {
+		defer close(mirrored)
+		ended := false
+		for ev := range events {
+			mirror.observe(ev)
+			if !ended && isACPTurnEnd(ev) {
+				ended = true
+				close(turnEnded)
+			}
+			select {
+			case activity <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	body, _, err := s.agentSessions.Prompt(ctx, &run, prompt)
+	if err == nil {
+		drainTranscript(activity, turnEnded)
+	}
+	unsubscribe()
+	<-mirrored
+	mirror.flush()
+
+	if err != nil {
+		s.finishExecution(logger, taskID, attempt, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{
+			Summary: fmt.Sprintf("agent session prompt failed: %v", err),
+			Outcome: "prompt_failed",
+		})
+		return
+	}
+	var resp struct {
+		Result struct {
+			StopReason string `json:"stopReason"`
+		} `json:"result"`
+		Error *jsonRPCError `json:"error,omitempty"`
+	}
+	if err := json.Unmarshal(body, &resp); err != nil {
+		s.finishExecution(logger, taskID, attempt, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{
+			Summary: "agent session returned an invalid prompt response",
+			Outcome: "prompt_failed",
+		})
+		return
+	}
+	if resp.Error != nil {
+		s.finishExecution(logger, taskID, attempt, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{
+			Summary: resp.Error.Message,
+			Outcome: "prompt_failed",
+		})
+		return
+	}
+	stopReason := strings.TrimSpace(resp.Result.StopReason)
+	switch stopReason {
+	case "refusal", "cancelled":
+		s.finishExecution(logger, taskID, attempt, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{
+			Summary: mirror.lastMessage,
+			Outcome: stopReason,
+		})
+	default:
+		s.finishExecution(logger, taskID, attempt, remoteAgentTaskStateCompleted, remoteAgentTaskCompleteRequest{
+			Summary: mirror.lastMessage,
+			Outcome: stopReason,
+		})
+	}
+}
+
+// drainTranscript waits for the session stream to catch up with a returned
+// prompt: until the turn-end notification arrives, the stream has been quiet
+// for remoteAgentTranscriptSettle, or remoteAgentTranscriptDrainLimit passes.
+func drainTranscript(activity, turnEnded <-chan struct{}) {
+	quiet := time.NewTimer(remoteAgentTranscriptSettle)
+	defer quiet.Stop()
+	limit := time.NewTimer(remoteAgentTranscriptDrainLimit)
+	defer limit.Stop()
+	for {
+		select {
+		case <-turnEnded:
+			return
+		case <-quiet.C:
+			return
+		case <-limit.C:
+			return
+		case <-activity:
+			quiet.Reset(remoteAgentTranscriptSettle)
+		}
+	}
+}
+
+// isACPTurnEnd reports whether a streamed envelope ends the prompt turn: the
+// session/prompt response, which carries the stop reason, when the bridge
+// echoes it onto the stream.
+func isACPTurnEnd(ev agentSessionEvent) bool {
+	var env struct {
+		Method string `json:"method"`
+		Result *struct {
+			StopReason string `json:"stopReason"`
+		} `json:"result"`
+	}
+	if err := json.Unmarshal(ev.Envelope, &env); err != nil {
+		return false
+	}
+	return env.Method == "" && env.Result != nil && env.Result.StopReason != ""
+}
+
+// finishExecution records the turn outcome. A task cancelled or timed out
+// while the prompt was in flight keeps its terminal state, and a task retried
+// since this execution started belongs to the newer attempt.
+func (s *RemoteAgentOrchestrationService) finishExecution(logger *slog.Logger, taskID string, attempt int, next remoteAgentTaskState, result remoteAgentTaskCompleteRequest) {
+	s.expireTimedOutTask(taskID, time.Now().UTC())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if task, ok := s.tasks[taskID]; !ok || task.Attempt != attempt {
+		logger.Info("remote agent executor: task moved to a newer attempt", "attempt", attempt)
+		return
+	}
+	if _, err := s.finishTaskLocked(taskID, next, result); err != nil {
+		logger.Info("remote agent executor: task no longer active", "state", next, "error", err)
+	}
+}
+
+// remoteAgentTranscriptMirror folds ACP session/update notifications into
+// task transcript entries. Streamed message and thought chunks are coalesced
+// into one entry per contiguous run.
+type remoteAgentTranscriptMirror struct {
+	service     *RemoteAgentOrchestrationService
+	taskID      string
+	runID       string
+	pendingKind string
+	pendingRef  string
+	pending     strings.Builder
+	lastMessage string
+}
+
+// acpSessionUpdate is the subset of an ACP session update the mirror reads.
+// Agents nest it under params.update; older bridges flatten it into params.
+type acpSessionUpdate struct {
+	SessionUpdate string          `json:"sessionUpdate"`
+	Content       json.RawMessage `json:"content,omitempty"`
+	Title         string          `json:"title,omitempty"`
+	Status        string          `json:"status,omitempty"`
+}
+
+func (m *remoteAgentTranscriptMirror) observe(ev agentSessionEvent) {
+	var env struct {
+		Method string `json:"method"`
+		Params struct {
+			acpSessionUpdate
+			Update *acpSessionUpdate `json:"update,omitempty"`
+		} `json:"params"`
+	}
+	if err := json.Unmarshal(ev.Envelope, &env); err != nil || env.Method != "session/update" {
+		return
+	}
+	update := env.Params.acpSessionUpdate
+	if env.Params.Update != nil {
+		update = *env.Params.Update
+	}
+	ref := fmt.Sprintf("agent-session:%s:%d", m.runID, ev.Sequence)
+	switch update.SessionUpdate {
+	case "agent_message_chunk":
+		m.buffer("message", ref, acpContentText(update.Content))
+	case "agent_thought_chunk":
+		m.buffer("thought", ref, acpContentText(update.Content))
+	case "tool_call":
+		m.flush()
+		m.append(remoteAgentTranscriptRoleTool, "tool_call", update.Title, ref)
+	case "tool_call_update":
+		if update.Status != "completed" && update.Status != "failed" {
+			return
+		}
+		m.flush()
+		text := update.Status
+		if update.Title != "" {
+			text = update.Title + ": " + update.Status
+		}
+		m.append(remoteAgentTranscriptRoleTool, "tool_result", text, ref)
+	}
+}
+
+func (m *remoteAgentTranscriptMirror) buffer(kind, ref, text string) {
+	if text == "" {
+		return
+	}
+	if m.pendingKind != kind {
+		m.flush()
+		m.pendingKind = kind
+		m.pendingRef = ref
+	}
+	m.pending.WriteString(text)
+}
+
+func (m *remoteAgentTranscriptMirror) flush() {
+	if m.pendingKind == "" {
+		return
+	}
+	text := m.pending.String()
+	if m.pendingKind == "message" && strings.TrimSpace(text) != "" {
+		m.lastMessage = strings.TrimSpace(text)
+	}
+	m.append(remoteAgentTranscriptRoleAgent, m.pendingKind, text, m.pendingRef)
+	m.pendingKind = ""
+	m.pendingRef = ""
+	m.pending.Reset()
+}
+
+func (m *remoteAgentTranscriptMirror) append(role remoteAgentTranscriptRole, kind, text, ref string) {
+	if strings.TrimSpace(text) == "" {
+		return
+	}
+	_, _ = m.service.AppendTranscript(m.taskID, remoteAgentTranscriptEntry{Role: role, Kind: kind, Text: text, EventRef: ref})
+}
+
+func acpContentText(raw json.RawMessage) string {
+	if len(raw) == 0 {
+		return ""
+	}
+	var content struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	}
+	if err := json.Unmarshal(raw, &content); err != nil || content.Type != "text" {
+		return ""
+	}
+	return content.Text
+}
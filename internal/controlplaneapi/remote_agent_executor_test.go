@@ -0,0 +1,254 @@
+package controlplaneapi
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func newRemoteAgentExecutorFixture(t *testing.T, transport agentSessionTransport) (*API, remoteAgent) {
+	t.Helper()
+	api, _ := newTestAPI(t)
+	api.AgentSessions = newAgentSessionService(transport, newAgentSessionStore(""))
+	api.RemoteAgentOrchestration = newRemoteAgentOrchestrationService(nil, api.Namespace, api.K8s, api.AgentSessions)
+
+	createHarnessRunForRemoteAgent(t, api, operatorv1alpha1.HarnessRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-exec", Namespace: api.Namespace},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			WorkspaceSessionName: "ws-exec",
+			AgentSession: &operatorv1alpha1.AgentSessionSpec{
+				Runtime: operatorv1alpha1.AgentRuntimeSandboxAgent,
+				Agent:   operatorv1alpha1.AgentKindCodex,
+			},
+		},
+	})
+	var run operatorv1alpha1.HarnessRun
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: "run-exec"}, &run); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	run.Status.PodName = "pod-exec"
+	run.Status.Phase = operatorv1alpha1.HarnessRunPhaseRunning
+	if err := api.K8s.Status().Update(context.Background(), &run); err != nil {
+		t.Fatalf("update run status: %v", err)
+	}
+
+	agent, err := api.RemoteAgentOrchestration.CreateAgent("", remoteAgentCreateRequest{
+		Name:               "worker",
+		WorkspaceSessionID: "ws-exec",
+		CurrentSession:     &remoteAgentSessionBinding{HarnessRunID: "run-exec"},
+	})
+	if err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	return api, agent
+}
+
+func waitForRemoteAgentTaskTerminal(t *testing.T, service *RemoteAgentOrchestrationService, taskID string) remoteAgentTask {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if task, ok := service.GetTask(taskID); ok && task.isTerminal() {
+			return task
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	task, _ := service.GetTask(taskID)
+	t.Fatalf("task %s did not finish; state=%s", taskID, task.State)
+	return remoteAgentTask{}
+}
+
+func TestRemoteAgentExecutor_PromptsBoundSessionAndMirrorsTranscript(t *testing.T) {
+	transport := newFakeAgentSessionTransport()
+	api, agent := newRemoteAgentExecutorFixture(t, transport)
+
+	// Open the session up front so the SSE pipe exists before the executor
+	// prompts; otherwise the fake transport drops the streamed updates.
+	var run operatorv1alpha1.HarnessRun
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: "run-exec"}, &run); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	if _, err := api.AgentSessions.EnsureSession(context.Background(), &run); err != nil {
+		t.Fatalf("ensure session: %v", err)
+	}
+	transport.waitWriter(t, 5*time.Second)
+
+	task, err := api.RemoteAgentOrchestration.DispatchTask("t-alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Review the diff"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+	done := waitForRemoteAgentTaskTerminal(t, api.RemoteAgentOrchestration, task.ID)
+	if done.State != remoteAgentTaskStateCompleted {
+		t.Fatalf("state = %s, want completed (result=%+v)", done.State, done.Result)
+	}
+	if done.StartedAt == "" {
+		t.Fatalf("expected task to pass through running")
+	}
+	if done.Result == nil || done.Result.Outcome != "completed" || done.Result.Summary != "ack" {
+		t.Fatalf("unexpected result: %+v", done.Result)
+	}
+	if transport.lastPrompt != "Review the diff" {
+		t.Fatalf("prompt sent to session = %q", transport.lastPrompt)
+	}
+	if len(done.Transcript) != 2 {
+		t.Fatalf("transcript = %+v, want prompt and agent message", done.Transcript)
+	}
+	if got := done.Transcript[0]; got.Role != remoteAgentTranscriptRoleUser || got.Text != "Review the diff" {
+		t.Fatalf("first transcript entry = %+v", got)
+	}
+	if got := done.Transcript[1]; got.Role != remoteAgentTranscriptRoleAgent || got.Kind != "message" || got.Text != "ack" || !strings.HasPrefix(got.EventRef, "agent-session:run-exec:") {
+		t.Fatalf("agent transcript entry = %+v", got)
+	}
+	if got, _ := api.RemoteAgentOrchestration.GetAgent(agent.ID); got.Availability != remoteAgentAvailabilityIdle || got.CurrentTaskID != "" {
+		t.Fatalf("agent not released: %+v", got)
+	}
+}
+
+func TestRemoteAgentExecutor_FailsTaskWhenPromptFails(t *testing.T) {
+	transport := &fakePromptFailureTransport{fakeAgentSessionTransport: newFakeAgentSessionTransport(), promptErr: errors.New("sandbox unreachable")}
+	api, agent := newRemoteAgentExecutorFixture(t, transport)
+
+	task, err := api.RemoteAgentOrchestration.DispatchTask("t-alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Review the diff"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+	done := waitForRemoteAgentTaskTerminal(t, api.RemoteAgentOrchestration, task.ID)
+	if done.State != remoteAgentTaskStateFailed {
+		t.Fatalf("state = %s, want failed", done.State)
+	}
+	if done.Result == nil || done.Result.Outcome != "prompt_failed" || !strings.Contains(done.Result.Summary, "sandbox unreachable") {
+		t.Fatalf("unexpected result: %+v", done.Result)
+	}
+	if got, _ := api.RemoteAgentOrchestration.GetAgent(agent.ID); got.Availability != remoteAgentAvailabilityIdle {
+		t.Fatalf("agent availability = %s, want idle", got.Availability)
+	}
+}
+
+// fakeBlockingPromptTransport holds session/prompt open until the caller
+// gives up, and holds session/cancel until release is closed.
+type fakeBlockingPromptTransport struct {
+	*fakeAgentSessionTransport
+	prompts chan struct{}
+	cancels chan struct{}
+	release chan struct{}
+}
+
+func (f *fakeBlockingPromptTransport) PostACP(ctx context.Context, podName, serverID, agent string, payload any) ([]byte, error) {
+	env, ok := payload.(jsonRPCEnvelope)
+	if !ok {
+		return nil, fmt.Errorf("unexpected payload type %T", payload)
+	}
+	switch env.Method {
+	case "session/prompt":
+		f.prompts <- struct{}{}
+		<-ctx.Done()
+		return nil, ctx.Err()
+	case "session/cancel":
+		f.cancels <- struct{}{}
+		<-f.release
+	}
+	return f.fakeAgentSessionTransport.PostACP(ctx, podName, serverID, agent, payload)
+}
+
+func TestRemoteAgentExecutor_CancelStopsAgentTurnWithoutTouchingRetry(t *testing.T) {
+	transport := &fakeBlockingPromptTransport{
+		fakeAgentSessionTransport: newFakeAgentSessionTransport(),
+		prompts:                   make(chan struct{}, 2),
+		cancels:                   make(chan struct{}, 2),
+		release:                   make(chan struct{}),
+	}
+	api, agent := newRemoteAgentExecutorFixture(t, transport)
+	service := api.RemoteAgentOrchestration
+	wait := func(ch chan struct{}, what string) {
+		t.Helper()
+		select {
+		case <-ch:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for %s", what)
+		}
+	}
+
+	task, err := service.DispatchTask("t-alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Review the diff"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+	wait(transport.prompts, "first prompt")
+	if _, err := service.CancelTask(task.ID); err != nil {
+		t.Fatalf("cancel task: %v", err)
+	}
+	wait(transport.cancels, "session/cancel")
+
+	// Retry while the cancelled execution is still unwinding; its outcome
+	// must not land on the new attempt.
+	if _, err := service.RetryTask(task.ID); err != nil {
+		t.Fatalf("retry task: %v", err)
+	}
+	wait(transport.prompts, "retried prompt")
+	close(transport.release)
+	time.Sleep(100 * time.Millisecond)
+
+	got, _ := service.GetTask(task.ID)
+	if got.Attempt != 2 || got.State != remoteAgentTaskStateRunning || got.Result != nil {
+		t.Fatalf("retried task = state %s attempt %d result %+v, want running attempt 2", got.State, got.Attempt, got.Result)
+	}
+	if state := api.AgentSessions.GetState(&operatorv1alpha1.HarnessRun{ObjectMeta: metav1.ObjectMeta{Name: "run-exec"}, Spec: operatorv1alpha1.HarnessRunSpec{AgentSession: &operatorv1alpha1.AgentSessionSpec{Runtime: operatorv1alpha1.AgentRuntimeSandboxAgent}}}); state.Phase.IsTerminal() {
+		t.Fatalf("agent session phase = %s after cancel, want usable", state.Phase)
+	}
+	if _, err := service.CancelTask(task.ID); err != nil {
+		t.Fatalf("cancel retried task: %v", err)
+	}
+	waitForRemoteAgentTaskTerminal(t, service, task.ID)
+}
+
+func TestRemoteAgentExecutor_TimeoutStopsAgentTurn(t *testing.T) {
+	transport := &fakeBlockingPromptTransport{
+		fakeAgentSessionTransport: newFakeAgentSessionTransport(),
+		prompts:                   make(chan struct{}, 1),
+		cancels:                   make(chan struct{}, 1),
+		release:                   make(chan struct{}),
+	}
+	close(transport.release)
+	api, agent := newRemoteAgentExecutorFixture(t, transport)
+	service := api.RemoteAgentOrchestration
+
+	task, err := service.DispatchTask("t-alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Review the diff", TimeoutSeconds: 600})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+	select {
+	case <-transport.prompts:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for prompt")
+	}
+
+	service.expireTimedOutTasks(time.Now().UTC().Add(time.Hour))
+	select {
+	case <-transport.cancels:
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed-out task left its agent turn running")
+	}
+	got := waitForRemoteAgentTaskTerminal(t, service, task.ID)
+	if got.State != remoteAgentTaskStateTimedOut {
+		t.Fatalf("task state = %s, want timed_out", got.State)
+	}
+}
+
+func TestIsACPTurnEnd(t *testing.T) {
+	cases := map[string]bool{
+		`{"jsonrpc":"2.0","id":3,"result":{"stopReason":"end_turn"}}`:                                             true,
+		`{"jsonrpc":"2.0","method":"session/update","params":{"update":{"sessionUpdate":"agent_message_chunk"}}}`: false,
+		`{"jsonrpc":"2.0","id":2,"result":{"sessionId":"s-1"}}`:                                                   false,
+		`not json`: false,
+	}
+	for raw, want := range cases {
+		if got := isACPTurnEnd(agentSessionEvent{Envelope: []byte(raw)}); got != want {
+			t.Errorf("isACPTurnEnd(%s) = %v, want %v", raw, got, want)
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package controlplaneapi
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// remoteAgentHeartbeatTTL is how long an agent that has sent heartbeats may
+	// stay silent before the reconciler marks it offline.
+	remoteAgentHeartbeatTTL = 90 * time.Second
+	// remoteAgentReconcileInterval is how often RunReconciler checks agents.
+	remoteAgentReconcileInterval = 30 * time.Second
+	// remoteAgentAvailabilityHistoryLimit bounds the per-agent history.
+	remoteAgentAvailabilityHistoryLimit = 50
+)
+
+func (s *RemoteAgentOrchestrationService) Heartbeat(agentID string) (remoteAgent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	agent, ok := s.agents[strings.TrimSpace(agentID)]
+	if !ok {
+		return remoteAgent{}, &requestError{status: http.StatusNotFound, msg: "remote agent not found"}
+	}
+	now := nowRFC3339()
+	agent.LastHeartbeatAt = now
+	agent.LastActivityAt = now
+	agent.UpdatedAt = now
+	if agent.Availability == remoteAgentAvailabilityOffline {
+		setRemoteAgentAvailability(&agent, remoteAgentAvailabilityIdle, "heartbeat received", now)
+	}
+	s.updateAgentLocked(agent)
+	s.scheduleLocked()
+	return s.agents[agent.ID], nil
+}
+
+// setRemoteAgentAvailability changes an agent's availability and records
+// online/offline transitions in its history. Idle/busy flips caused by task
+// assignment are not recorded; the task log already covers them.
+func setRemoteAgentAvailability(agent *remoteAgent, next remoteAgentAvailability, reason, at string) {
+	previous := agent.Availability
+	agent.Availability = next
+	if (previous == remoteAgentAvailabilityOffline) == (next == remoteAgentAvailabilityOffline) {
+		return
+	}
+	agent.AvailabilityHistory = append(agent.AvailabilityHistory, remoteAgentAvailabilityEvent{At: at, Availability: next, Reason: reason})
+	if extra := len(agent.AvailabilityHistory) - remoteAgentAvailabilityHistoryLimit; extra > 0 {
+		agent.AvailabilityHistory = append([]remoteAgentAvailabilityEvent(nil), agent.AvailabilityHistory[extra:]...)
+	}
+}
+
+// RunReconciler periodically cross-checks registered agents against their
+// heartbeats and bound HarnessRun pods until ctx is done.
+func (s *RemoteAgentOrchestrationService) RunReconciler(ctx context.Context) {
+	ticker := time.NewTicker(remoteAgentReconcileInterval)
+	defer ticker.Stop()
+	for {
+		s.ReconcileAgents(ctx, time.Now().UTC())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ReconcileAgents marks stale agents offline, releasing their in-flight
+// tasks, and brings session-bound agents back once their pod is healthy.
+func (s *RemoteAgentOrchestrationService) ReconcileAgents(ctx context.Context, now time.Time) {
+	s.mu.Lock()
+	agents := make([]remoteAgent, 0, len(s.agents))
+	for _, agent := range s.agents {
+		agents = append(agents, agent)
+	}
+	s.mu.Unlock()
+	sort.Slice(agents, func(i, j int) bool { return agents[i].ID < agents[j].ID })
+
+	for _, agent := range agents {
+		stale, reason, known := s.remoteAgentStaleReason(ctx, agent, now)
+		if !known {
+			continue
+		}
+		s.mu.Lock()
+		current, ok := s.agents[agent.ID]
+		switch {
+		case !ok:
+		case stale && current.Availability != remoteAgentAvailabilityOffline:
+			s.markAgentOfflineLocked(current, reason, now)
+		case !stale && current.Availability == remoteAgentAvailabilityOffline && current.CurrentSession != nil:
+			at := now.UTC().Format(time.RFC3339)
+			setRemoteAgentAvailability(&current, remoteAgentAvailabilityIdle, reason, at)
+			current.UpdatedAt = at
+			s.updateAgentLocked(current)
+			s.scheduleLocked()
+		}
+		s.mu.Unlock()
+	}
+}
+
+// remoteAgentStaleReason reports whether an agent looks dead. known=false
+// means the agent has neither heartbeats nor a session binding, or the
+// cluster could not be queried, so its availability is left untouched.
+func (s *RemoteAgentOrchestrationService) remoteAgentStaleReason(ctx context.Context, agent remoteAgent, now time.Time) (stale bool, reason string, known bool) {
+	if last := strings.TrimSpace(agent.LastHeartbeatAt); last != "" {
+		if at, err := time.Parse(time.RFC3339, last); err == nil && now.Sub(at) > remoteAgentHeartbeatTTL {
+			return true, fmt.Sprintf("no heartbeat since %s", last), true
+		}
+	}
+	if agent.CurrentSession == nil || strings.TrimSpace(agent.CurrentSession.HarnessRunID) == "" || s.k8s == nil {
+		return false, "", strings.TrimSpace(agent.LastHeartbeatAt) != ""
+	}
+	runID := strings.TrimSpace(agent.CurrentSession.HarnessRunID)
+	var run operatorv1alpha1.HarnessRun
+	if err := s.k8s.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: runID}, &run); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, fmt.Sprintf("harness run %s not found", runID), true
+		}
+		slog.Warn("remote agent reconciler: get harness run failed", "agent", agent.ID, "harnessRun", runID, "error", err)
+		return false, "", false
+	}
+	switch run.Status.Phase {
+	case operatorv1alpha1.HarnessRunPhaseSucceeded, operatorv1alpha1.HarnessRunPhaseFailed:
+		return true, fmt.Sprintf("harness run %s %s", runID, strings.ToLower(string(run.Status.Phase))), true
+	}
+	podName := strings.TrimSpace(run.Status.PodName)
+	if podName == "" {
+		return true, fmt.Sprintf("harness run %s has no pod", runID), true
+	}
+	var pod corev1.Pod
+	if err := s.k8s.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: podName}, &pod); err != nil {
+		if apierrors.IsNotFound(err) {
+			return true, fmt.Sprintf("pod %s not found", podName), true
+		}
+		slog.Warn("remote agent reconciler: get pod failed", "agent", agent.ID, "pod", podName, "error", err)
+		return false, "", false
+	}
+	switch pod.Status.Phase {
+	case corev1.PodFailed, corev1.PodSucceeded:
+		return true, fmt.Sprintf("pod %s %s", podName, strings.ToLower(string(pod.Status.Phase))), true
+	case corev1.PodRunning:
+		return false, fmt.Sprintf("pod %s running", podName), true
+	default:
+		return false, "", false
+	}
+}
+
+// markAgentOfflineLocked takes an agent offline. Its in-flight task goes back
+// to the pool queue when it was pool-scheduled and fails otherwise.
+func (s *RemoteAgentOrchestrationService) markAgentOfflineLocked(agent remoteAgent, reason string, now time.Time) {
+	at := now.UTC().Format(time.RFC3339)
+	taskID := strings.TrimSpace(agent.CurrentTaskID)
+	setRemoteAgentAvailability(&agent, remoteAgentAvailabilityOffline, reason, at)
+	agent.CurrentTaskID = ""
+	agent.UpdatedAt = at
+	s.updateAgentLocked(agent)
+	slog.Info("remote agent marked offline", "agent", agent.ID, "reason", reason)
+
+	task, ok := s.tasks[taskID]
+	if !ok || !remoteAgentTaskActive(task) {
+		s.scheduleLocked()
+		return
+	}
+	if cancel, ok := s.executions[task.ID]; ok {
+		cancel()
+	}
+	if task.QueuedAt != "" && task.RetryCount < remoteAgentMaxRequeues {
+		s.requeueTaskLocked(task)
+		return
+	}
+	if _, err := s.finishTaskLocked(task.ID, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{
+		Summary: "remote agent went offline: " + reason,
+		Outcome: "agent_offline",
+	}); err != nil {
+		slog.Warn("remote agent reconciler: fail in-flight task", "task", task.ID, "error", err)
+	}
+}
+
+func (a *API) handleRemoteAgentHeartbeat(w http.ResponseWriter, _ *http.Request, id string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	agent, err := a.RemoteAgentOrchestration.Heartbeat(id)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, agent)
+}
@@ -0,0 +1,150 @@
+package controlplaneapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func TestRemoteAgentReconciler_MarksAgentOfflineWhenPodDisappears(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	service := api.RemoteAgentOrchestration
+	ctx := context.Background()
+
+	createHarnessRunForRemoteAgent(t, api, operatorv1alpha1.HarnessRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-health", Namespace: api.Namespace},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			WorkspaceSessionName: "ws-health",
+			AgentSession: &operatorv1alpha1.AgentSessionSpec{
+				Runtime: operatorv1alpha1.AgentRuntimeSandboxAgent,
+				Agent:   operatorv1alpha1.AgentKindCodex,
+			},
+		},
+	})
+	var run operatorv1alpha1.HarnessRun
+	if err := api.K8s.Get(ctx, client.ObjectKey{Namespace: api.Namespace, Name: "run-health"}, &run); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	run.Status.PodName = "pod-health"
+	run.Status.Phase = operatorv1alpha1.HarnessRunPhaseRunning
+	if err := api.K8s.Status().Update(ctx, &run); err != nil {
+		t.Fatalf("update run status: %v", err)
+	}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-health", Namespace: api.Namespace}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	if err := api.K8s.Create(ctx, pod); err != nil {
+		t.Fatalf("create pod: %v", err)
+	}
+
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "worker", WorkspaceSessionID: "ws-health", CurrentSession: &remoteAgentSessionBinding{HarnessRunID: "run-health"}})
+	if err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	task, err := service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Review"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+
+	service.ReconcileAgents(ctx, time.Now().UTC())
+	if got, _ := service.GetAgent(agent.ID); got.Availability != remoteAgentAvailabilityBusy {
+		t.Fatalf("healthy agent availability = %s, want busy", got.Availability)
+	}
+
+	if err := api.K8s.Delete(ctx, pod); err != nil {
+		t.Fatalf("delete pod: %v", err)
+	}
+	service.ReconcileAgents(ctx, time.Now().UTC())
+	offline, _ := service.GetAgent(agent.ID)
+	if offline.Availability != remoteAgentAvailabilityOffline || offline.CurrentTaskID != "" {
+		t.Fatalf("agent after pod loss = %+v, want offline without a task", offline)
+	}
+	failed, _ := service.GetTask(task.ID)
+	if failed.State != remoteAgentTaskStateFailed || failed.Result == nil || failed.Result.Outcome != "agent_offline" {
+		t.Fatalf("in-flight task = %s (%+v), want failed with agent_offline", failed.State, failed.Result)
+	}
+	if _, err := service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Again"}); err == nil {
+		t.Fatalf("expected dispatch to an offline agent to fail")
+	}
+
+	pod = &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "pod-health", Namespace: api.Namespace}, Status: corev1.PodStatus{Phase: corev1.PodRunning}}
+	if err := api.K8s.Create(ctx, pod); err != nil {
+		t.Fatalf("recreate pod: %v", err)
+	}
+	service.ReconcileAgents(ctx, time.Now().UTC())
+	recovered, _ := service.GetAgent(agent.ID)
+	if recovered.Availability != remoteAgentAvailabilityIdle {
+		t.Fatalf("agent after pod recovery = %s, want idle", recovered.Availability)
+	}
+	var states []remoteAgentAvailability
+	for _, ev := range recovered.AvailabilityHistory {
+		states = append(states, ev.Availability)
+	}
+	if len(states) != 3 || states[0] != remoteAgentAvailabilityIdle || states[1] != remoteAgentAvailabilityOffline || states[2] != remoteAgentAvailabilityIdle {
+		t.Fatalf("availability history = %+v", recovered.AvailabilityHistory)
+	}
+	if recovered.AvailabilityHistory[1].Reason != "pod pod-health not found" {
+		t.Fatalf("offline reason = %q", recovered.AvailabilityHistory[1].Reason)
+	}
+}
+
+func TestRemoteAgentHeartbeat_ExpiryRequeuesPoolTask(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	service := api.RemoteAgentOrchestration
+
+	if err := api.Tokens.Create(context.Background(), "t-worker", "worker", []string{ScopeRemoteAgentWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if _, err := service.CreatePool(remoteAgentPoolCreateRequest{Name: "reviewers"}); err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer", PoolName: "reviewers"})
+	if err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	heartbeat := func() remoteAgent {
+		t.Helper()
+		resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agents/"+agent.ID+"/heartbeat", "worker", nil)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("heartbeat status = %d (body=%s)", resp.StatusCode, string(b))
+		}
+		var out remoteAgent
+		if err := json.Unmarshal(b, &out); err != nil {
+			t.Fatalf("unmarshal agent: %v", err)
+		}
+		return out
+	}
+	if got := heartbeat(); got.LastHeartbeatAt == "" {
+		t.Fatalf("heartbeat not recorded: %+v", got)
+	}
+
+	task, err := service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{PoolName: "reviewers"}, Prompt: "Review"})
+	if err != nil || task.State != remoteAgentTaskStateAssigned {
+		t.Fatalf("dispatch = %+v, %v", task, err)
+	}
+
+	service.ReconcileAgents(context.Background(), time.Now().UTC().Add(2*remoteAgentHeartbeatTTL))
+	if got, _ := service.GetAgent(agent.ID); got.Availability != remoteAgentAvailabilityOffline {
+		t.Fatalf("agent availability = %s, want offline after missed heartbeats", got.Availability)
+	}
+	requeued, _ := service.GetTask(task.ID)
+	if requeued.State != remoteAgentTaskStateQueued || requeued.Attempt != 2 || requeued.AgentID != "" {
+		t.Fatalf("task after agent loss = %+v, want requeued", requeued)
+	}
+
+	if got := heartbeat(); got.Availability != remoteAgentAvailabilityBusy || got.CurrentTaskID != task.ID {
+		t.Fatalf("agent after heartbeat = %s on %q, want busy with the requeued task", got.Availability, got.CurrentTaskID)
+	}
+}
@@ -33,6 +33,7 @@ const (
 type remoteAgentTaskState string
 
 const (
+	remoteAgentTaskStateQueued    remoteAgentTaskState = "queued"
 	remoteAgentTaskStateAssigned  remoteAgentTaskState = "assigned"
 	remoteAgentTaskStateRunning   remoteAgentTaskState = "running"
 	remoteAgentTaskStateCompleted remoteAgentTaskState = "completed"
@@ -73,26 +74,37 @@ type remoteAgentPool struct {
 	DisplayName        string `json:"displayName,omitempty"`
 	Description        string `json:"description,omitempty"`
 	WorkspaceSessionID string `json:"workspaceSessionId,omitempty"`
+	MaxConcurrency     int    `json:"maxConcurrency,omitempty"`
 	CreatedAt          string `json:"createdAt,omitempty"`
 	UpdatedAt          string `json:"updatedAt,omitempty"`
 }
 
 type remoteAgent struct {
-	ID                 string                        `json:"id"`
-	Name               string                        `json:"name"`
-	DisplayName        string                        `json:"displayName,omitempty"`
-	Description        string                        `json:"description,omitempty"`
-	PoolID             string                        `json:"poolId,omitempty"`
-	PoolName           string                        `json:"poolName,omitempty"`
-	WorkspaceSessionID string                        `json:"workspaceSessionId,omitempty"`
-	Runtime            operatorv1alpha1.AgentRuntime `json:"runtime,omitempty"`
-	Agent              operatorv1alpha1.AgentKind    `json:"agent,omitempty"`
-	Availability       remoteAgentAvailability       `json:"availability,omitempty"`
-	CurrentTaskID      string                        `json:"currentTaskId,omitempty"`
-	LastActivityAt     string                        `json:"lastActivityAt,omitempty"`
-	CurrentSession     *remoteAgentSessionBinding    `json:"currentSession,omitempty"`
-	CreatedAt          string                        `json:"createdAt,omitempty"`
-	UpdatedAt          string                        `json:"updatedAt,omitempty"`
+	ID                  string                         `json:"id"`
+	Name                string                         `json:"name"`
+	DisplayName         string                         `json:"displayName,omitempty"`
+	Description         string                         `json:"description,omitempty"`
+	PoolID              string                         `json:"poolId,omitempty"`
+	PoolName            string                         `json:"poolName,omitempty"`
+	WorkspaceSessionID  string                         `json:"workspaceSessionId,omitempty"`
+	Runtime             operatorv1alpha1.AgentRuntime  `json:"runtime,omitempty"`
+	Agent               operatorv1alpha1.AgentKind     `json:"agent,omitempty"`
+	Availability        remoteAgentAvailability        `json:"availability,omitempty"`
+	CurrentTaskID       string                         `json:"currentTaskId,omitempty"`
+	LastActivityAt      string                         `json:"lastActivityAt,omitempty"`
+	LastHeartbeatAt     string                         `json:"lastHeartbeatAt,omitempty"`
+	CurrentSession      *remoteAgentSessionBinding     `json:"currentSession,omitempty"`
+	AvailabilityHistory []remoteAgentAvailabilityEvent `json:"availabilityHistory,omitempty"`
+	RegisteredBy        string                         `json:"registeredBy,omitempty"`
+	Team                string                         `json:"team,omitempty"`
+	CreatedAt           string                         `json:"createdAt,omitempty"`
+	UpdatedAt           string                         `json:"updatedAt,omitempty"`
+}
+
+type remoteAgentAvailabilityEvent struct {
+	At           string                  `json:"at"`
+	Availability remoteAgentAvailability `json:"availability"`
+	Reason       string                  `json:"reason,omitempty"`
 }
 
 type remoteAgentArtifactRef struct {
@@ -126,6 +138,7 @@ type remoteAgentTaskResult struct {
 type remoteAgentTask struct {
 	ID                 string                       `json:"id"`
 	RequestedBy        string                       `json:"requestedBy,omitempty"`
+	Team               string                       `json:"team,omitempty"`
 	AgentID            string                       `json:"agentId,omitempty"`
 	AgentName          string                       `json:"agentName,omitempty"`
 	PoolID             string                       `json:"poolId,omitempty"`
@@ -133,16 +146,23 @@ type remoteAgentTask struct {
 	WorkspaceSessionID string                       `json:"workspaceSessionId,omitempty"`
 	Prompt             string                       `json:"prompt,omitempty"`
 	State              remoteAgentTaskState         `json:"state"`
+	Priority           int                          `json:"priority,omitempty"`
+	QueuePosition      int                          `json:"queuePosition,omitempty"`
+	QueueSequence      int64                        `json:"queueSequence,omitempty"`
+	WaitSeconds        int64                        `json:"waitSeconds,omitempty"`
 	TimeoutSeconds     int32                        `json:"timeoutSeconds,omitempty"`
 	Attempt            int                          `json:"attempt,omitempty"`
 	RetryCount         int                          `json:"retryCount,omitempty"`
 	CurrentSession     *remoteAgentSessionBinding   `json:"currentSession,omitempty"`
 	CreatedAt          string                       `json:"createdAt,omitempty"`
+	QueuedAt           string                       `json:"queuedAt,omitempty"`
 	AssignedAt         string                       `json:"assignedAt,omitempty"`
 	StartedAt          string                       `json:"startedAt,omitempty"`
 	CompletedAt        string                       `json:"completedAt,omitempty"`
 	CancelledAt        string                       `json:"cancelledAt,omitempty"`
 	LastTransitionAt   string                       `json:"lastTransitionAt,omitempty"`
+	WorkflowID         string                       `json:"workflowId,omitempty"`
+	WorkflowStep       string                       `json:"workflowStep,omitempty"`
 	Result             *remoteAgentTaskResult       `json:"result,omitempty"`
 	InputArtifacts     []remoteAgentArtifactRef     `json:"inputArtifacts,omitempty"`
 	OutputArtifacts    []remoteAgentArtifactRef     `json:"outputArtifacts,omitempty"`
@@ -163,6 +183,7 @@ type remoteAgentPoolCreateRequest struct {
 	DisplayName        string `json:"displayName,omitempty"`
 	Description        string `json:"description,omitempty"`
 	WorkspaceSessionID string `json:"workspaceSessionId,omitempty"`
+	MaxConcurrency     int    `json:"maxConcurrency,omitempty"`
 }
 
 type remoteAgentCreateRequest struct {
@@ -175,6 +196,7 @@ type remoteAgentCreateRequest struct {
 	Runtime            operatorv1alpha1.AgentRuntime `json:"runtime,omitempty"`
 	Agent              operatorv1alpha1.AgentKind    `json:"agent,omitempty"`
 	CurrentSession     *remoteAgentSessionBinding    `json:"currentSession,omitempty"`
+	Team               string                        `json:"team,omitempty"`
 }
 
 type remoteAgentTaskTarget struct {
@@ -197,8 +219,17 @@ type remoteAgentArtifactCreateRequest struct {
 type remoteAgentTaskCreateRequest struct {
 	Target         remoteAgentTaskTarget              `json:"target"`
 	Prompt         string                             `json:"prompt"`
+	Priority       int                                `json:"priority,omitempty"`
 	TimeoutSeconds int32                              `json:"timeoutSeconds,omitempty"`
 	InputArtifacts []remoteAgentArtifactCreateRequest `json:"inputArtifacts,omitempty"`
+	Team           string                             `json:"team,omitempty"`
+}
+
+type remoteAgentTranscriptAppendRequest struct {
+	Role     remoteAgentTranscriptRole `json:"role"`
+	Kind     string                    `json:"kind,omitempty"`
+	Text     string                    `json:"text,omitempty"`
+	EventRef string                    `json:"eventRef,omitempty"`
 }
 
 type remoteAgentTaskCompleteRequest struct {
@@ -207,11 +238,12 @@ type remoteAgentTaskCompleteRequest struct {
 }
 
 type remoteAgentOrchestrationStoreRecord struct {
-	Type  string           `json:"type"`
-	At    time.Time        `json:"at"`
-	Pool  *remoteAgentPool `json:"pool,omitempty"`
-	Agent *remoteAgent     `json:"agent,omitempty"`
-	Task  *remoteAgentTask `json:"task,omitempty"`
+	Type     string               `json:"type"`
+	At       time.Time            `json:"at"`
+	Pool     *remoteAgentPool     `json:"pool,omitempty"`
+	Agent    *remoteAgent         `json:"agent,omitempty"`
+	Task     *remoteAgentTask     `json:"task,omitempty"`
+	Workflow *remoteAgentWorkflow `json:"workflow,omitempty"`
 }
 
 var remoteAgentSensitiveValuePattern = regexp.MustCompile(`(?i)("?(?:api[_ -]?key|authorization|credential|password|secret|token)"?\s*[:=]\s*"?)([^"\s,;]+)`)
@@ -297,6 +329,27 @@ func sanitizeRemoteAgentStoreRecord(record remoteAgentOrchestrationStoreRecord)
 		task.OutputArtifacts = sanitizeRemoteAgentArtifacts(task.OutputArtifacts)
 		record.Task = &task
 	}
+	if record.Workflow != nil {
+		workflow := *record.Workflow
+		steps := make([]remoteAgentWorkflowStep, len(workflow.Steps))
+		for i, step := range workflow.Steps {
+			step.Prompt = sanitizeRemoteAgentText(step.Prompt)
+			step.WaitingReason = sanitizeRemoteAgentText(step.WaitingReason)
+			if len(step.InputArtifacts) != 0 {
+				inputs := make([]remoteAgentArtifactCreateRequest, len(step.InputArtifacts))
+				for j, artifact := range step.InputArtifacts {
+					artifact.Name = sanitizeRemoteAgentText(artifact.Name)
+					artifact.Path = sanitizeRemoteAgentText(artifact.Path)
+					artifact.URI = sanitizeRemoteAgentURI(artifact.URI)
+					inputs[j] = artifact
+				}
+				step.InputArtifacts = inputs
+			}
+			steps[i] = step
+		}
+		workflow.Steps = steps
+		record.Workflow = &workflow
+	}
 	return record
 }
 
@@ -374,6 +427,10 @@ func (s *RemoteAgentOrchestrationStore) SaveTask(task remoteAgentTask) {
 	s.append(remoteAgentOrchestrationStoreRecord{Type: "task", At: time.Now().UTC(), Task: &task})
 }
 
+func (s *RemoteAgentOrchestrationStore) SaveWorkflow(workflow remoteAgentWorkflow) {
+	s.append(remoteAgentOrchestrationStoreRecord{Type: "workflow", At: time.Now().UTC(), Workflow: &workflow})
+}
+
 func (s *RemoteAgentOrchestrationStore) records() ([]remoteAgentOrchestrationStoreRecord, error) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -406,14 +463,15 @@ func (s *RemoteAgentOrchestrationStore) records() ([]remoteAgentOrchestrationSto
 	return out, nil
 }
 
-func (s *RemoteAgentOrchestrationStore) load() (map[string]remoteAgentPool, map[string]remoteAgent, map[string]remoteAgentTask, error) {
+func (s *RemoteAgentOrchestrationStore) load() (map[string]remoteAgentPool, map[string]remoteAgent, map[string]remoteAgentTask, map[string]remoteAgentWorkflow, error) {
 	records, err := s.records()
 	if err != nil {
-		return nil, nil, nil, err
+		return nil, nil, nil, nil, err
 	}
 	pools := map[string]remoteAgentPool{}
 	agents := map[string]remoteAgent{}
 	tasks := map[string]remoteAgentTask{}
+	workflows := map[string]remoteAgentWorkflow{}
 	for _, rec := range records {
 		if rec.Pool != nil && rec.Pool.ID != "" {
 			pools[rec.Pool.ID] = *rec.Pool
@@ -424,8 +482,11 @@ func (s *RemoteAgentOrchestrationStore) load() (map[string]remoteAgentPool, map[
 		if rec.Task != nil && rec.Task.ID != "" {
 			tasks[rec.Task.ID] = *rec.Task
 		}
+		if rec.Workflow != nil && rec.Workflow.ID != "" {
+			workflows[rec.Workflow.ID] = *rec.Workflow
+		}
 	}
-	return pools, agents, tasks, nil
+	return pools, agents, tasks, workflows, nil
 }
 
 type RemoteAgentOrchestrationService struct {
@@ -437,6 +498,13 @@ type RemoteAgentOrchestrationService struct {
 	pools         map[string]remoteAgentPool
 	agents        map[string]remoteAgent
 	tasks         map[string]remoteAgentTask
+	workflows     map[string]remoteAgentWorkflow
+	executions    map[string]context.CancelFunc
+	queueSequence int64
+	// poolQueued and poolActive index the queued and assigned or running
+	// tasks of each pool by task ID, so scheduling never scans every task.
+	poolQueued map[string]map[string]struct{}
+	poolActive map[string]map[string]struct{}
 }
 
 func newRemoteAgentOrchestrationService(store *RemoteAgentOrchestrationStore, namespace string, k8s client.Client, agentSessions *AgentSessionService) *RemoteAgentOrchestrationService {
@@ -448,14 +516,25 @@ func newRemoteAgentOrchestrationService(store *RemoteAgentOrchestrationStore, na
 		pools:         map[string]remoteAgentPool{},
 		agents:        map[string]remoteAgent{},
 		tasks:         map[string]remoteAgentTask{},
+		workflows:     map[string]remoteAgentWorkflow{},
+		executions:    map[string]context.CancelFunc{},
+		poolQueued:    map[string]map[string]struct{}{},
+		poolActive:    map[string]map[string]struct{}{},
 	}
 	if store == nil {
 		return service
 	}
-	if pools, agents, tasks, err := store.load(); err == nil {
+	if pools, agents, tasks, workflows, err := store.load(); err == nil {
 		service.pools = pools
 		service.agents = agents
-		service.tasks = tasks
+		service.workflows = workflows
+		for _, task := range tasks {
+			service.setTaskLocked(task)
+			if task.QueueSequence > service.queueSequence {
+				service.queueSequence = task.QueueSequence
+			}
+		}
+		service.scheduleLocked()
 	} else {
 		slog.Error("remote agent orchestration store: load failed", "path", store.path, "error", err)
 	}
@@ -478,6 +557,9 @@ func validateRemoteAgentPoolRequest(req remoteAgentPoolCreateRequest) error {
 	if normalizePoolName(req.Name) == "" {
 		return &requestError{status: http.StatusBadRequest, msg: "name required"}
 	}
+	if req.MaxConcurrency < 0 {
+		return &requestError{status: http.StatusBadRequest, msg: "maxConcurrency must be >= 0"}
+	}
 	return nil
 }
 
@@ -548,8 +630,8 @@ func validateRemoteAgentTaskCreateRequest(req remoteAgentTaskCreateRequest) erro
 	if strings.TrimSpace(req.Prompt) == "" {
 		return &requestError{status: http.StatusBadRequest, msg: "prompt required"}
 	}
-	if strings.TrimSpace(req.Target.AgentID) == "" && strings.TrimSpace(req.Target.AgentName) == "" {
-		return &requestError{status: http.StatusBadRequest, msg: "target.agentId or target.agentName required"}
+	if strings.TrimSpace(req.Target.AgentID) == "" && strings.TrimSpace(req.Target.AgentName) == "" && strings.TrimSpace(req.Target.PoolName) == "" {
+		return &requestError{status: http.StatusBadRequest, msg: "target.agentId, target.agentName or target.poolName required"}
 	}
 	if req.TimeoutSeconds < 0 {
 		return &requestError{status: http.StatusBadRequest, msg: "timeoutSeconds must be >= 0"}
@@ -561,6 +643,43 @@ func validateRemoteAgentTaskCreateRequest(req remoteAgentTaskCreateRequest) erro
 		if artifact.Kind == "" {
 			return &requestError{status: http.StatusBadRequest, msg: "inputArtifacts[].kind required"}
 		}
+		if strings.TrimSpace(artifact.Digest) != "" {
+			return &requestError{status: http.StatusBadRequest, msg: "inputArtifacts[].digest is set by artifact upload"}
+		}
+	}
+	return nil
+}
+
+func validateRemoteAgentTranscriptAppendRequest(req remoteAgentTranscriptAppendRequest) error {
+	switch req.Role {
+	case remoteAgentTranscriptRoleSystem, remoteAgentTranscriptRoleUser, remoteAgentTranscriptRoleAgent, remoteAgentTranscriptRoleTool:
+	case "":
+		return &requestError{status: http.StatusBadRequest, msg: "role required"}
+	default:
+		return &requestError{status: http.StatusBadRequest, msg: "role must be one of system, user, agent, tool"}
+	}
+	if strings.TrimSpace(req.Text) == "" && strings.TrimSpace(req.EventRef) == "" {
+		return &requestError{status: http.StatusBadRequest, msg: "text or eventRef required"}
+	}
+	return nil
+}
+
+func validateRemoteAgentArtifactCreateRequest(req remoteAgentArtifactCreateRequest) error {
+	if strings.TrimSpace(req.Name) == "" {
+		return &requestError{status: http.StatusBadRequest, msg: "name required"}
+	}
+	switch req.Kind {
+	case remoteAgentArtifactKindFile, remoteAgentArtifactKindPatch, remoteAgentArtifactKindBundle, remoteAgentArtifactKindReport:
+	case "":
+		return &requestError{status: http.StatusBadRequest, msg: "kind required"}
+	default:
+		return &requestError{status: http.StatusBadRequest, msg: "kind must be one of file, patch, bundle, report"}
+	}
+	if strings.TrimSpace(req.Path) == "" && strings.TrimSpace(req.URI) == "" {
+		return &requestError{status: http.StatusBadRequest, msg: "path or uri required"}
+	}
+	if req.SizeBytes < 0 {
+		return &requestError{status: http.StatusBadRequest, msg: "sizeBytes must be >= 0"}
 	}
 	return nil
 }
@@ -592,8 +711,10 @@ func (s *RemoteAgentOrchestrationService) ListTasks() []remoteAgentTask {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	out := make([]remoteAgentTask, 0, len(s.tasks))
+	positions := s.queuePositionsLocked()
+	now := time.Now().UTC()
 	for _, task := range s.tasks {
-		out = append(out, task)
+		out = append(out, s.withQueueStatusLocked(task, positions, now))
 	}
 	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
 	return out
@@ -618,6 +739,7 @@ func (s *RemoteAgentOrchestrationService) CreatePool(req remoteAgentPoolCreateRe
 		DisplayName:        strings.TrimSpace(req.DisplayName),
 		Description:        strings.TrimSpace(req.Description),
 		WorkspaceSessionID: strings.TrimSpace(req.WorkspaceSessionID),
+		MaxConcurrency:     req.MaxConcurrency,
 		CreatedAt:          now,
 		UpdatedAt:          now,
 	}
@@ -647,7 +769,9 @@ func (s *RemoteAgentOrchestrationService) resolvePoolLocked(poolID, poolName str
 	return remoteAgentPool{}, false, &requestError{status: http.StatusBadRequest, msg: "pool not found"}
 }
 
-func (s *RemoteAgentOrchestrationService) CreateAgent(req remoteAgentCreateRequest) (remoteAgent, error) {
+// CreateAgent registers an agent. registeredBy and req.Team decide which
+// principals may report for it on the worker routes.
+func (s *RemoteAgentOrchestrationService) CreateAgent(registeredBy string, req remoteAgentCreateRequest) (remoteAgent, error) {
 	if err := validateRemoteAgentRequest(req); err != nil {
 		return remoteAgent{}, err
 	}
@@ -675,19 +799,22 @@ func (s *RemoteAgentOrchestrationService) CreateAgent(req remoteAgentCreateReque
 		return remoteAgent{}, err
 	}
 	agent := remoteAgent{
-		ID:                 newID(),
-		Name:               strings.TrimSpace(req.Name),
-		DisplayName:        strings.TrimSpace(req.DisplayName),
-		Description:        strings.TrimSpace(req.Description),
-		PoolID:             pool.ID,
-		PoolName:           pool.Name,
-		WorkspaceSessionID: strings.TrimSpace(req.WorkspaceSessionID),
-		Runtime:            req.Runtime,
-		Agent:              req.Agent,
-		Availability:       remoteAgentAvailabilityIdle,
-		CurrentSession:     currentSession,
-		CreatedAt:          now,
-		UpdatedAt:          now,
+		ID:                  newID(),
+		Name:                strings.TrimSpace(req.Name),
+		DisplayName:         strings.TrimSpace(req.DisplayName),
+		Description:         strings.TrimSpace(req.Description),
+		PoolID:              pool.ID,
+		PoolName:            pool.Name,
+		WorkspaceSessionID:  strings.TrimSpace(req.WorkspaceSessionID),
+		Runtime:             req.Runtime,
+		Agent:               req.Agent,
+		Availability:        remoteAgentAvailabilityIdle,
+		CurrentSession:      currentSession,
+		AvailabilityHistory: []remoteAgentAvailabilityEvent{{At: now, Availability: remoteAgentAvailabilityIdle, Reason: "registered"}},
+		RegisteredBy:        strings.TrimSpace(registeredBy),
+		Team:                strings.TrimSpace(req.Team),
+		CreatedAt:           now,
+		UpdatedAt:           now,
 	}
 	if agent.CurrentSession != nil {
 		agent.LastActivityAt = now
@@ -696,7 +823,8 @@ func (s *RemoteAgentOrchestrationService) CreateAgent(req remoteAgentCreateReque
 	if s.store != nil {
 		s.store.SaveAgent(agent)
 	}
-	return agent, nil
+	s.scheduleLocked()
+	return s.agents[agent.ID], nil
 }
 
 func (s *RemoteAgentOrchestrationService) GetAgent(id string) (remoteAgent, bool) {
@@ -739,6 +867,9 @@ func (s *RemoteAgentOrchestrationService) resolveAgentLocked(target remoteAgentT
 	return matches[0], nil
 }
 
+// makeRemoteAgentArtifact builds an artifact ref from client metadata. The
+// digest is left empty: it names stored content, so only the upload path and
+// references to that upload may set it.
 func makeRemoteAgentArtifact(req remoteAgentArtifactCreateRequest) remoteAgentArtifactRef {
 	return remoteAgentArtifactRef{
 		ID:        newID(),
@@ -747,7 +878,6 @@ func makeRemoteAgentArtifact(req remoteAgentArtifactCreateRequest) remoteAgentAr
 		MediaType: strings.TrimSpace(req.MediaType),
 		Path:      strings.TrimSpace(req.Path),
 		URI:       strings.TrimSpace(req.URI),
-		Digest:    strings.TrimSpace(req.Digest),
 		SizeBytes: req.SizeBytes,
 		CreatedAt: nowRFC3339(),
 	}
@@ -760,37 +890,59 @@ func (s *RemoteAgentOrchestrationService) DispatchTask(requestedBy string, req r
 	s.expireTimedOutTasks(time.Now().UTC())
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	agent, err := s.resolveAgentLocked(req.Target)
-	if err != nil {
-		return remoteAgentTask{}, err
-	}
-	if agent.Availability == remoteAgentAvailabilityBusy && strings.TrimSpace(agent.CurrentTaskID) != "" {
-		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "remote agent is busy; wait for the active task to finish or cancel it"}
-	}
-	now := nowRFC3339()
 	inputArtifacts := make([]remoteAgentArtifactRef, 0, len(req.InputArtifacts))
 	for _, artifact := range req.InputArtifacts {
 		inputArtifacts = append(inputArtifacts, makeRemoteAgentArtifact(artifact))
 	}
 	task := remoteAgentTask{
-		ID:                 newID(),
 		RequestedBy:        strings.TrimSpace(requestedBy),
-		AgentID:            agent.ID,
-		AgentName:          agent.Name,
-		PoolID:             agent.PoolID,
-		PoolName:           agent.PoolName,
-		WorkspaceSessionID: agent.WorkspaceSessionID,
+		Team:               strings.TrimSpace(req.Team),
+		WorkspaceSessionID: strings.TrimSpace(req.Target.WorkspaceSessionID),
 		Prompt:             strings.TrimSpace(req.Prompt),
-		State:              remoteAgentTaskStateAssigned,
+		Priority:           req.Priority,
 		TimeoutSeconds:     req.TimeoutSeconds,
-		Attempt:            1,
-		CurrentSession:     agent.CurrentSession,
-		CreatedAt:          now,
-		AssignedAt:         now,
-		LastTransitionAt:   now,
 		InputArtifacts:     inputArtifacts,
 	}
-	s.tasks[task.ID] = task
+	if isRemoteAgentPoolTarget(req.Target) {
+		task, err := s.newQueuedTaskLocked(task, req.Target.PoolName)
+		if err != nil {
+			return remoteAgentTask{}, err
+		}
+		s.scheduleLocked()
+		return s.withQueueStatusLocked(s.tasks[task.ID], s.queuePositionsLocked(), time.Now().UTC()), nil
+	}
+	return s.assignTaskLocked(task, req.Target)
+}
+
+// assignTaskLocked hands a new task straight to the agent named by target.
+func (s *RemoteAgentOrchestrationService) assignTaskLocked(task remoteAgentTask, target remoteAgentTaskTarget) (remoteAgentTask, error) {
+	agent, err := s.resolveAgentLocked(target)
+	if err != nil {
+		return remoteAgentTask{}, err
+	}
+	if agent.Availability == remoteAgentAvailabilityOffline {
+		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "remote agent is offline"}
+	}
+	if agent.Availability == remoteAgentAvailabilityBusy && strings.TrimSpace(agent.CurrentTaskID) != "" {
+		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "remote agent is busy; wait for the active task to finish or cancel it"}
+	}
+	if !s.poolHasCapacityLocked(agent.PoolID) {
+		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "pool concurrency limit reached; dispatch to the pool to queue the task"}
+	}
+	now := nowRFC3339()
+	task.ID = newID()
+	task.AgentID = agent.ID
+	task.AgentName = agent.Name
+	task.PoolID = agent.PoolID
+	task.PoolName = agent.PoolName
+	task.WorkspaceSessionID = agent.WorkspaceSessionID
+	task.State = remoteAgentTaskStateAssigned
+	task.Attempt = 1
+	task.CurrentSession = agent.CurrentSession
+	task.CreatedAt = now
+	task.AssignedAt = now
+	task.LastTransitionAt = now
+	s.setTaskLocked(task)
 	agent.Availability = remoteAgentAvailabilityBusy
 	agent.CurrentTaskID = task.ID
 	agent.LastActivityAt = now
@@ -802,6 +954,7 @@ func (s *RemoteAgentOrchestrationService) DispatchTask(requestedBy string, req r
 	if s.store != nil {
 		s.store.SaveTask(task)
 	}
+	s.executeTaskLocked(task)
 	return task, nil
 }
 
@@ -810,7 +963,10 @@ func (s *RemoteAgentOrchestrationService) GetTask(id string) (remoteAgentTask, b
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	task, ok := s.tasks[strings.TrimSpace(id)]
-	return task, ok
+	if !ok {
+		return remoteAgentTask{}, false
+	}
+	return s.withQueueStatusLocked(task, s.queuePositionsLocked(), time.Now().UTC()), true
 }
 
 func remoteAgentTaskDeadline(task remoteAgentTask) (time.Time, bool) {
@@ -842,6 +998,9 @@ type remoteAgentPersistenceBatch struct {
 func (s *RemoteAgentOrchestrationService) expireTimedOutTasks(now time.Time) {
 	s.mu.Lock()
 	batch := s.expireTimedOutTasksLocked(now)
+	if len(batch.tasks) != 0 {
+		s.scheduleLocked()
+	}
 	s.mu.Unlock()
 	s.persistBatch(batch)
 }
@@ -849,6 +1008,9 @@ func (s *RemoteAgentOrchestrationService) expireTimedOutTasks(now time.Time) {
 func (s *RemoteAgentOrchestrationService) expireTimedOutTask(taskID string, now time.Time) bool {
 	s.mu.Lock()
 	expired, batch := s.expireTimedOutTaskLocked(taskID, now)
+	if expired {
+		s.scheduleLocked()
+	}
 	s.mu.Unlock()
 	s.persistBatch(batch)
 	return expired
@@ -899,7 +1061,12 @@ func (s *RemoteAgentOrchestrationService) expireTimedOutTaskLocked(taskID string
 		TranscriptEntries:   len(task.Transcript),
 		OutputArtifactCount: len(task.OutputArtifacts),
 	}
-	s.tasks[task.ID] = task
+	s.setTaskLocked(task)
+	// The agent is freed below, so stop the prompt still running on it.
+	if cancel, ok := s.executions[task.ID]; ok {
+		cancel()
+		delete(s.executions, task.ID)
+	}
 	batch := remoteAgentPersistenceBatch{tasks: []remoteAgentTask{task}}
 	if agent, ok := s.agents[task.AgentID]; ok && agent.CurrentTaskID == task.ID {
 		agent.CurrentTaskID = ""
@@ -913,7 +1080,7 @@ func (s *RemoteAgentOrchestrationService) expireTimedOutTaskLocked(taskID string
 }
 
 func (s *RemoteAgentOrchestrationService) updateTaskLocked(task remoteAgentTask) {
-	s.tasks[task.ID] = task
+	s.setTaskLocked(task)
 	if s.store != nil {
 		s.store.SaveTask(task)
 	}
@@ -975,10 +1142,22 @@ func (s *RemoteAgentOrchestrationService) CancelTask(taskID string) (remoteAgent
 	s.expireTimedOutTask(strings.TrimSpace(taskID), time.Now().UTC())
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	task, err := s.transitionTaskLocked(taskID, []remoteAgentTaskState{remoteAgentTaskStateAssigned, remoteAgentTaskStateRunning}, remoteAgentTaskStateCancelled)
+	task, err := s.cancelTaskLocked(taskID)
 	if err != nil {
 		return remoteAgentTask{}, err
 	}
+	s.scheduleLocked()
+	return task, nil
+}
+
+func (s *RemoteAgentOrchestrationService) cancelTaskLocked(taskID string) (remoteAgentTask, error) {
+	task, err := s.transitionTaskLocked(taskID, []remoteAgentTaskState{remoteAgentTaskStateQueued, remoteAgentTaskStateAssigned, remoteAgentTaskStateRunning}, remoteAgentTaskStateCancelled)
+	if err != nil {
+		return remoteAgentTask{}, err
+	}
+	if cancel, ok := s.executions[task.ID]; ok {
+		cancel()
+	}
 	if agent, ok := s.agents[task.AgentID]; ok && agent.CurrentTaskID == task.ID {
 		agent.CurrentTaskID = ""
 		agent.Availability = remoteAgentAvailabilityIdle
@@ -990,10 +1169,24 @@ func (s *RemoteAgentOrchestrationService) CancelTask(taskID string) (remoteAgent
 }
 
 func (s *RemoteAgentOrchestrationService) CompleteTask(taskID string, result remoteAgentTaskCompleteRequest) (remoteAgentTask, error) {
+	return s.finishTask(taskID, remoteAgentTaskStateCompleted, result)
+}
+
+// FailTask records a worker's failed run, which keeps the task's workflow
+// dependants blocked until it is retried.
+func (s *RemoteAgentOrchestrationService) FailTask(taskID string, result remoteAgentTaskCompleteRequest) (remoteAgentTask, error) {
+	return s.finishTask(taskID, remoteAgentTaskStateFailed, result)
+}
+
+func (s *RemoteAgentOrchestrationService) finishTask(taskID string, next remoteAgentTaskState, result remoteAgentTaskCompleteRequest) (remoteAgentTask, error) {
 	s.expireTimedOutTask(strings.TrimSpace(taskID), time.Now().UTC())
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	task, err := s.transitionTaskLocked(taskID, []remoteAgentTaskState{remoteAgentTaskStateAssigned, remoteAgentTaskStateRunning}, remoteAgentTaskStateCompleted)
+	return s.finishTaskLocked(taskID, next, result)
+}
+
+func (s *RemoteAgentOrchestrationService) finishTaskLocked(taskID string, next remoteAgentTaskState, result remoteAgentTaskCompleteRequest) (remoteAgentTask, error) {
+	task, err := s.transitionTaskLocked(taskID, []remoteAgentTaskState{remoteAgentTaskStateAssigned, remoteAgentTaskStateRunning}, next)
 	if err != nil {
 		return remoteAgentTask{}, err
 	}
@@ -1004,7 +1197,7 @@ func (s *RemoteAgentOrchestrationService) CompleteTask(taskID string, result rem
 		OutputArtifactCount: len(task.OutputArtifacts),
 	}
 	if task.Result.Outcome == "" {
-		task.Result.Outcome = "completed"
+		task.Result.Outcome = string(next)
 	}
 	task.LastTransitionAt = task.CompletedAt
 	s.updateTaskLocked(task)
@@ -1015,6 +1208,7 @@ func (s *RemoteAgentOrchestrationService) CompleteTask(taskID string, result rem
 		agent.UpdatedAt = task.CompletedAt
 		s.updateAgentLocked(agent)
 	}
+	s.scheduleLocked()
 	return task, nil
 }
 
@@ -1030,13 +1224,24 @@ func (s *RemoteAgentOrchestrationService) RetryTask(taskID string) (remoteAgentT
 	if !task.isTerminal() || task.State == remoteAgentTaskStateCompleted {
 		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "task retry requires a failed, timed out, or cancelled task"}
 	}
+	if task.QueuedAt != "" {
+		if task.RetryCount >= remoteAgentMaxRequeues {
+			return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: fmt.Sprintf("task was already requeued %d times", task.RetryCount)}
+		}
+		s.reopenWorkflowLocked(task)
+		return s.requeueTaskLocked(task), nil
+	}
 	agent, ok := s.agents[task.AgentID]
 	if !ok {
 		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "task retry requires a registered remote agent"}
 	}
+	if agent.Availability == remoteAgentAvailabilityOffline {
+		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "remote agent is offline"}
+	}
 	if strings.TrimSpace(agent.CurrentTaskID) != "" && agent.CurrentTaskID != task.ID {
 		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "remote agent is busy; wait for the active task to finish or cancel it"}
 	}
+	s.reopenWorkflowLocked(task)
 	now := nowRFC3339()
 	task.State = remoteAgentTaskStateAssigned
 	task.Attempt++
@@ -1054,6 +1259,8 @@ func (s *RemoteAgentOrchestrationService) RetryTask(taskID string) (remoteAgentT
 	agent.LastActivityAt = now
 	agent.UpdatedAt = now
 	s.updateAgentLocked(agent)
+	s.executeTaskLocked(task)
+	s.scheduleLocked()
 	return task, nil
 }
 
@@ -1084,7 +1291,19 @@ func (s *RemoteAgentOrchestrationService) AppendTranscript(taskID string, entry
 	return task, nil
 }
 
+// AddOutputArtifact records client-declared artifact metadata. A digest is
+// only accepted when it names content already uploaded to the same task.
 func (s *RemoteAgentOrchestrationService) AddOutputArtifact(taskID string, req remoteAgentArtifactCreateRequest) (remoteAgentTask, error) {
+	return s.addOutputArtifact(taskID, req, "", false)
+}
+
+// addUploadedArtifact records an artifact whose content was stored under
+// digest by the upload handler.
+func (s *RemoteAgentOrchestrationService) addUploadedArtifact(taskID string, req remoteAgentArtifactCreateRequest, digest string) (remoteAgentTask, error) {
+	return s.addOutputArtifact(taskID, req, digest, true)
+}
+
+func (s *RemoteAgentOrchestrationService) addOutputArtifact(taskID string, req remoteAgentArtifactCreateRequest, digest string, uploaded bool) (remoteAgentTask, error) {
 	s.expireTimedOutTask(strings.TrimSpace(taskID), time.Now().UTC())
 	s.mu.Lock()
 	defer s.mu.Unlock()
@@ -1096,7 +1315,16 @@ func (s *RemoteAgentOrchestrationService) AddOutputArtifact(taskID string, req r
 	if task.State != remoteAgentTaskStateAssigned && task.State != remoteAgentTaskStateRunning {
 		return remoteAgentTask{}, &requestError{status: http.StatusConflict, msg: "task artifacts are immutable outside active states"}
 	}
-	task.OutputArtifacts = append(task.OutputArtifacts, makeRemoteAgentArtifact(req))
+	if !uploaded && strings.TrimSpace(req.Digest) != "" {
+		parsed, ok := parseArtifactDigest(req.Digest)
+		if !ok || !taskHasUploadedDigest(task, remoteAgentArtifactDigestPrefix+parsed) {
+			return remoteAgentTask{}, &requestError{status: http.StatusBadRequest, msg: "digest must reference content uploaded to this task"}
+		}
+		digest = remoteAgentArtifactDigestPrefix + parsed
+	}
+	artifact := makeRemoteAgentArtifact(req)
+	artifact.Digest = digest
+	task.OutputArtifacts = append(task.OutputArtifacts, artifact)
 	if task.Result != nil {
 		task.Result.OutputArtifactCount = len(task.OutputArtifacts)
 	}
@@ -1105,6 +1333,28 @@ func (s *RemoteAgentOrchestrationService) AddOutputArtifact(taskID string, req r
 	return task, nil
 }
 
+func taskHasUploadedDigest(task remoteAgentTask, digest string) bool {
+	for _, artifact := range task.OutputArtifacts {
+		if artifact.Digest == digest {
+			return true
+		}
+	}
+	return false
+}
+
+// referencesArtifactDigest reports whether any task output artifact is
+// stored under digest, so shared blobs outlive other references to them.
+func (s *RemoteAgentOrchestrationService) referencesArtifactDigest(digest string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, task := range s.tasks {
+		if taskHasUploadedDigest(task, digest) {
+			return true
+		}
+	}
+	return false
+}
+
 func (s *RemoteAgentOrchestrationService) TaskTranscript(taskID string) ([]remoteAgentTranscriptEntry, error) {
 	s.expireTimedOutTask(strings.TrimSpace(taskID), time.Now().UTC())
 	s.mu.Lock()
@@ -1179,7 +1429,13 @@ func (a *API) handleRemoteAgentsCreate(w http.ResponseWriter, r *http.Request) {
 		writeJSONError(w, err)
 		return
 	}
-	agent, err := a.RemoteAgentOrchestration.CreateAgent(req)
+	team, err := resolveRequestTeam(r.Context(), req.Team)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	req.Team = team
+	agent, err := a.RemoteAgentOrchestration.CreateAgent(principal(r.Context()), req)
 	if err != nil {
 		writeJSONError(w, err)
 		return
@@ -1200,12 +1456,19 @@ func (a *API) handleRemoteAgentGet(w http.ResponseWriter, _ *http.Request, id st
 	writeJSON(w, http.StatusOK, agent)
 }
 
-func (a *API) handleRemoteAgentTasksList(w http.ResponseWriter, _ *http.Request) {
+func (a *API) handleRemoteAgentTasksList(w http.ResponseWriter, r *http.Request) {
 	if a.RemoteAgentOrchestration == nil {
 		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
 		return
 	}
-	writeJSON(w, http.StatusOK, map[string]any{"remoteAgentTasks": a.RemoteAgentOrchestration.ListTasks()})
+	tasks := a.RemoteAgentOrchestration.ListTasks()
+	out := make([]remoteAgentTask, 0, len(tasks))
+	for _, task := range tasks {
+		if visibleTo(r.Context(), taskOwner(task)) {
+			out = append(out, task)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"remoteAgentTasks": out})
 }
 
 func (a *API) handleRemoteAgentTasksCreate(w http.ResponseWriter, r *http.Request) {
@@ -1218,6 +1481,12 @@ func (a *API) handleRemoteAgentTasksCreate(w http.ResponseWriter, r *http.Reques
 		writeJSONError(w, err)
 		return
 	}
+	team, err := resolveRequestTeam(r.Context(), req.Team)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	req.Team = team
 	task, err := a.RemoteAgentOrchestration.DispatchTask(principal(r.Context()), req)
 	if err != nil {
 		writeJSONError(w, err)
@@ -1290,3 +1559,95 @@ func (a *API) handleRemoteAgentTaskArtifactsGet(w http.ResponseWriter, _ *http.R
 	}
 	writeJSON(w, http.StatusOK, map[string]any{"taskId": id, "inputArtifacts": inputs, "outputArtifacts": outputs})
 }
+
+func (a *API) handleRemoteAgentTaskStart(w http.ResponseWriter, _ *http.Request, id string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	task, err := a.RemoteAgentOrchestration.StartTask(id)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+func (a *API) handleRemoteAgentTaskComplete(w http.ResponseWriter, r *http.Request, id string) {
+	a.handleRemoteAgentTaskFinish(w, r, id, remoteAgentTaskStateCompleted)
+}
+
+func (a *API) handleRemoteAgentTaskFail(w http.ResponseWriter, r *http.Request, id string) {
+	a.handleRemoteAgentTaskFinish(w, r, id, remoteAgentTaskStateFailed)
+}
+
+func (a *API) handleRemoteAgentTaskFinish(w http.ResponseWriter, r *http.Request, id string, next remoteAgentTaskState) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	var req remoteAgentTaskCompleteRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	finish := a.RemoteAgentOrchestration.CompleteTask
+	if next == remoteAgentTaskStateFailed {
+		finish = a.RemoteAgentOrchestration.FailTask
+	}
+	task, err := finish(id, req)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, task)
+}
+
+func (a *API) handleRemoteAgentTaskTranscriptAppend(w http.ResponseWriter, r *http.Request, id string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	var req remoteAgentTranscriptAppendRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	if err := validateRemoteAgentTranscriptAppendRequest(req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	task, err := a.RemoteAgentOrchestration.AppendTranscript(id, remoteAgentTranscriptEntry{
+		Role:     req.Role,
+		Kind:     strings.TrimSpace(req.Kind),
+		Text:     req.Text,
+		EventRef: req.EventRef,
+	})
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"taskId": task.ID, "entry": task.Transcript[len(task.Transcript)-1]})
+}
+
+func (a *API) handleRemoteAgentTaskArtifactCreate(w http.ResponseWriter, r *http.Request, id string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	var req remoteAgentArtifactCreateRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	if err := validateRemoteAgentArtifactCreateRequest(req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	task, err := a.RemoteAgentOrchestration.AddOutputArtifact(id, req)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, map[string]any{"taskId": task.ID, "artifact": task.OutputArtifacts[len(task.OutputArtifacts)-1]})
+}
@@ -28,7 +28,7 @@ func ageRemoteAgentTaskForTimeout(t *testing.T, service *RemoteAgentOrchestratio
 	assignedAt := time.Now().UTC().Add(-age).Format(time.RFC3339)
 	task.AssignedAt = assignedAt
 	task.LastTransitionAt = assignedAt
-	service.tasks[task.ID] = task
+	service.setTaskLocked(task)
 	if service.store != nil {
 		service.store.SaveTask(task)
 	}
@@ -203,6 +203,109 @@ func TestRemoteAgentOrchestrationAPIContract_TaskLifecycleTranscriptAndArtifacts
 	}
 }
 
+func TestRemoteAgentOrchestrationAPIContract_WorkerDrivesTaskLifecycle(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-worker", "worker", []string{ScopeRemoteAgentWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if err := api.Tokens.Create(context.Background(), "t-requester", "requester", []string{ScopeRemoteAgentTaskRead, ScopeRemoteAgentTaskWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	pool, err := api.RemoteAgentOrchestration.CreatePool(remoteAgentPoolCreateRequest{Name: "workers"})
+	if err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	agent, err := api.RemoteAgentOrchestration.CreateAgent("", remoteAgentCreateRequest{Name: "worker", PoolID: pool.ID})
+	if err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	task, err := api.RemoteAgentOrchestration.DispatchTask("t-requester", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentID: agent.ID}, Prompt: "Summarise the repo"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+	base := srv.URL + "/api/v1/remote-agent-tasks/" + task.ID
+
+	// The requester owns the task but is not its worker.
+	for _, route := range []string{"/start", "/complete", "/transcript", "/artifacts", "/artifacts/upload"} {
+		resp, _ := doJSON(t, srv.Client(), http.MethodPost, base+route, "requester", map[string]any{})
+		if resp.StatusCode != http.StatusForbidden {
+			t.Fatalf("requester %s status = %d, want 403", route, resp.StatusCode)
+		}
+	}
+	resp, body := doJSON(t, srv.Client(), http.MethodPost, base+"/start", "worker", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("start status = %d, want 200 (body=%s)", resp.StatusCode, string(body))
+	}
+	var started remoteAgentTask
+	if err := json.Unmarshal(body, &started); err != nil {
+		t.Fatalf("unmarshal task: %v", err)
+	}
+	if started.State != remoteAgentTaskStateRunning || started.StartedAt == "" {
+		t.Fatalf("unexpected started task: %+v", started)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, base+"/start", "worker", nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("second start status = %d, want 409", resp.StatusCode)
+	}
+
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, base+"/transcript", "worker", map[string]any{"role": "robot", "text": "hi"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("invalid role status = %d, want 400", resp.StatusCode)
+	}
+	resp, body = doJSON(t, srv.Client(), http.MethodPost, base+"/transcript", "worker", map[string]any{"role": "agent", "kind": "message", "text": "Reading files"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("append transcript status = %d, want 201 (body=%s)", resp.StatusCode, string(body))
+	}
+	var appended struct {
+		Entry remoteAgentTranscriptEntry `json:"entry"`
+	}
+	if err := json.Unmarshal(body, &appended); err != nil {
+		t.Fatalf("unmarshal entry: %v", err)
+	}
+	if appended.Entry.Sequence != 1 || appended.Entry.Text != "Reading files" || appended.Entry.At == "" {
+		t.Fatalf("unexpected transcript entry: %+v", appended.Entry)
+	}
+
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, base+"/artifacts", "worker", map[string]any{"name": "summary.md", "kind": "report"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("artifact without location status = %d, want 400", resp.StatusCode)
+	}
+	resp, body = doJSON(t, srv.Client(), http.MethodPost, base+"/artifacts", "worker", map[string]any{"name": "summary.md", "kind": "report", "path": "/workspace/summary.md", "mediaType": "text/markdown"})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("attach artifact status = %d, want 201 (body=%s)", resp.StatusCode, string(body))
+	}
+
+	resp, body = doJSON(t, srv.Client(), http.MethodPost, base+"/complete", "worker", map[string]any{"summary": "Summary written"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("complete status = %d, want 200 (body=%s)", resp.StatusCode, string(body))
+	}
+	var completed remoteAgentTask
+	if err := json.Unmarshal(body, &completed); err != nil {
+		t.Fatalf("unmarshal task: %v", err)
+	}
+	if completed.State != remoteAgentTaskStateCompleted || completed.Result == nil || completed.Result.TranscriptEntries != 1 || completed.Result.OutputArtifactCount != 1 {
+		t.Fatalf("unexpected completed task: %+v", completed)
+	}
+
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, base+"/transcript", "worker", map[string]any{"role": "agent", "text": "late"})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("append after completion status = %d, want 409", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, base+"/complete", "worker", map[string]any{})
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("second complete status = %d, want 409", resp.StatusCode)
+	}
+	resp, body = doJSON(t, srv.Client(), http.MethodGet, base, "requester", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("requester get status = %d, want 200 (body=%s)", resp.StatusCode, string(body))
+	}
+}
+
 func TestRemoteAgentOrchestrationAPIContract_RequiresUnambiguousNamedAgentAndSupportsCancel(t *testing.T) {
 	api, cleanup := newTestAPI(t)
 	defer cleanup()
@@ -310,7 +413,7 @@ func TestRemoteAgentOrchestrationStorePersistsTranscriptsAndArtifacts(t *testing
 	if err != nil {
 		t.Fatalf("create pool: %v", err)
 	}
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "researcher", PoolID: pool.ID})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "researcher", PoolID: pool.ID})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -351,6 +454,8 @@ func TestRemoteAgentOrchestrationStorePersistsTranscriptsAndArtifacts(t *testing
 		"/api/v1/remote-agents",
 		"/api/v1/remote-agent-tasks",
 		"/api/v1/remote-agent-tasks/{taskID}/retry",
+		"/api/v1/remote-agent-tasks/{taskID}/start",
+		"/api/v1/remote-agent-tasks/{taskID}/complete",
 		"/api/v1/remote-agent-tasks/{taskID}/transcript",
 		"/api/v1/remote-agent-tasks/{taskID}/artifacts",
 	} {
@@ -364,7 +469,7 @@ func TestRemoteAgentOrchestrationStoreLoadsLargeTranscriptRecords(t *testing.T)
 	store := newRemoteAgentOrchestrationStore(filepath.Join(t.TempDir(), "orchestration.jsonl"))
 	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "reviewer"})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer"})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -413,7 +518,7 @@ func TestRemoteAgentOrchestrationAPIContract_ValidatesCurrentSessionBinding(t *t
 		},
 	})
 
-	_, err := api.RemoteAgentOrchestration.CreateAgent(remoteAgentCreateRequest{
+	_, err := api.RemoteAgentOrchestration.CreateAgent("", remoteAgentCreateRequest{
 		Name:               "reviewer",
 		WorkspaceSessionID: "ws-123",
 		CurrentSession: &remoteAgentSessionBinding{
@@ -428,7 +533,7 @@ func TestRemoteAgentOrchestrationAPIContract_ValidatesCurrentSessionBinding(t *t
 		t.Fatalf("create agent: %v", err)
 	}
 
-	_, err = api.RemoteAgentOrchestration.CreateAgent(remoteAgentCreateRequest{
+	_, err = api.RemoteAgentOrchestration.CreateAgent("", remoteAgentCreateRequest{
 		Name:               "reviewer-2",
 		WorkspaceSessionID: "ws-123",
 		CurrentSession:     &remoteAgentSessionBinding{SessionID: "sas-123"},
@@ -447,7 +552,7 @@ func TestRemoteAgentOrchestrationAPIContract_ValidatesCurrentSessionBinding(t *t
 		},
 	})
 
-	_, err = api.RemoteAgentOrchestration.CreateAgent(remoteAgentCreateRequest{
+	_, err = api.RemoteAgentOrchestration.CreateAgent("", remoteAgentCreateRequest{
 		Name:               "reviewer-3",
 		WorkspaceSessionID: "ws-123",
 		CurrentSession: &remoteAgentSessionBinding{
@@ -462,7 +567,7 @@ func TestRemoteAgentOrchestrationAPIContract_ValidatesCurrentSessionBinding(t *t
 func TestRemoteAgentOrchestrationService_ArtifactAndTranscriptMutationRequiresActiveTask(t *testing.T) {
 	service := newRemoteAgentOrchestrationService(newRemoteAgentOrchestrationStore(""), "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "reviewer"})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer"})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -489,7 +594,7 @@ func TestRemoteAgentOrchestrationStoreRedactsSensitiveTaskPersistence(t *testing
 	store := newRemoteAgentOrchestrationStore(path)
 	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "researcher", CurrentSession: &remoteAgentSessionBinding{HarnessRunID: "run-456", SessionID: "spoofed"}})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "researcher", CurrentSession: &remoteAgentSessionBinding{HarnessRunID: "run-456", SessionID: "spoofed"}})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -531,7 +636,7 @@ func TestRemoteAgentOrchestrationService_TimesOutAndCanRetryTask(t *testing.T) {
 	store := newRemoteAgentOrchestrationStore(filepath.Join(t.TempDir(), "orchestration.jsonl"))
 	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "reviewer"})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer"})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -589,7 +694,7 @@ func TestRemoteAgentOrchestrationService_DispatchExpiresTimedOutTaskWithoutRead(
 	store := newRemoteAgentOrchestrationStore(filepath.Join(t.TempDir(), "orchestration.jsonl"))
 	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "reviewer"})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer"})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -636,7 +741,7 @@ func TestRemoteAgentOrchestrationService_DispatchExpiresTimedOutTaskAfterReload(
 	store := newRemoteAgentOrchestrationStore(filepath.Join(t.TempDir(), "orchestration.jsonl"))
 	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "reviewer"})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer"})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -684,7 +789,7 @@ func TestRemoteAgentOrchestrationService_RetryIgnoresTimedOutConflictingTask(t *
 	store := newRemoteAgentOrchestrationStore(filepath.Join(t.TempDir(), "orchestration.jsonl"))
 	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "reviewer"})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer"})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -730,7 +835,7 @@ func TestRemoteAgentOrchestrationService_RetryExpiresTimedOutConflictingTaskAfte
 	store := newRemoteAgentOrchestrationStore(filepath.Join(t.TempDir(), "orchestration.jsonl"))
 	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
 
-	agent, err := service.CreateAgent(remoteAgentCreateRequest{Name: "reviewer"})
+	agent, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer"})
 	if err != nil {
 		t.Fatalf("create agent: %v", err)
 	}
@@ -0,0 +1,262 @@
+package controlplaneapi
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tasks targeted at a pool rather than a named agent wait in a per-pool queue
+// until an idle agent in that pool can take them. The queue is ordered by
+// priority (higher first); within a priority tier the requester with the
+// fewest active tasks in the pool goes next, and ties fall back to FIFO.
+
+func isRemoteAgentPoolTarget(target remoteAgentTaskTarget) bool {
+	return strings.TrimSpace(target.AgentID) == "" && strings.TrimSpace(target.AgentName) == "" && strings.TrimSpace(target.PoolName) != ""
+}
+
+// newQueuedTaskLocked records a new task in its pool's queue. Callers run
+// scheduleLocked afterwards to hand it to an idle agent.
+func (s *RemoteAgentOrchestrationService) newQueuedTaskLocked(task remoteAgentTask, poolName string) (remoteAgentTask, error) {
+	pool, _, err := s.resolvePoolLocked("", poolName)
+	if err != nil {
+		return remoteAgentTask{}, err
+	}
+	now := nowRFC3339()
+	task.ID = newID()
+	task.PoolID = pool.ID
+	task.PoolName = pool.Name
+	task.State = remoteAgentTaskStateQueued
+	task.Attempt = 1
+	task.CreatedAt = now
+	task.QueuedAt = now
+	task.LastTransitionAt = now
+	s.queueSequence++
+	task.QueueSequence = s.queueSequence
+	s.updateTaskLocked(task)
+	return task, nil
+}
+
+// remoteAgentMaxRequeues caps how often a pool task goes back to its queue, so
+// a task that keeps losing its agent cannot cycle through the pool forever.
+// Callers check it before requeueTaskLocked.
+const remoteAgentMaxRequeues = 5
+
+// requeueTaskLocked puts a terminal pool task back in its pool's queue so the
+// retry can land on whichever agent frees up first.
+func (s *RemoteAgentOrchestrationService) requeueTaskLocked(task remoteAgentTask) remoteAgentTask {
+	now := nowRFC3339()
+	task.State = remoteAgentTaskStateQueued
+	task.Attempt++
+	task.RetryCount++
+	task.AgentID = ""
+	task.AgentName = ""
+	task.CurrentSession = nil
+	task.QueuedAt = now
+	s.queueSequence++
+	task.QueueSequence = s.queueSequence
+	task.AssignedAt = ""
+	task.StartedAt = ""
+	task.CompletedAt = ""
+	task.CancelledAt = ""
+	task.LastTransitionAt = now
+	task.Result = nil
+	s.updateTaskLocked(task)
+	s.scheduleLocked()
+	return s.withQueueStatusLocked(s.tasks[task.ID], s.queuePositionsLocked(), time.Now().UTC())
+}
+
+func remoteAgentTaskActive(task remoteAgentTask) bool {
+	return task.State == remoteAgentTaskStateAssigned || task.State == remoteAgentTaskStateRunning
+}
+
+// setTaskLocked stores task and moves it between the per-pool queued and
+// active indexes. Every write to s.tasks goes through it.
+func (s *RemoteAgentOrchestrationService) setTaskLocked(task remoteAgentTask) {
+	if prev, ok := s.tasks[task.ID]; ok {
+		removeFromPoolIndex(s.poolQueued, prev.PoolID, prev.ID)
+		removeFromPoolIndex(s.poolActive, prev.PoolID, prev.ID)
+	}
+	s.tasks[task.ID] = task
+	switch {
+	case task.State == remoteAgentTaskStateQueued:
+		addToPoolIndex(s.poolQueued, task.PoolID, task.ID)
+	case remoteAgentTaskActive(task):
+		addToPoolIndex(s.poolActive, task.PoolID, task.ID)
+	}
+}
+
+func addToPoolIndex(index map[string]map[string]struct{}, poolID, taskID string) {
+	ids, ok := index[poolID]
+	if !ok {
+		ids = map[string]struct{}{}
+		index[poolID] = ids
+	}
+	ids[taskID] = struct{}{}
+}
+
+func removeFromPoolIndex(index map[string]map[string]struct{}, poolID, taskID string) {
+	ids, ok := index[poolID]
+	if !ok {
+		return
+	}
+	delete(ids, taskID)
+	if len(ids) == 0 {
+		delete(index, poolID)
+	}
+}
+
+// poolHasCapacityLocked reports whether the pool is below its concurrency
+// limit. Agents outside any pool, and pools without a limit, always have room.
+func (s *RemoteAgentOrchestrationService) poolHasCapacityLocked(poolID string) bool {
+	pool, ok := s.pools[poolID]
+	if !ok || pool.MaxConcurrency <= 0 {
+		return true
+	}
+	return len(s.poolActive[poolID]) < pool.MaxConcurrency
+}
+
+// poolQueueLocked returns the queued tasks of a pool in the order they will
+// be assigned.
+func (s *RemoteAgentOrchestrationService) poolQueueLocked(poolID string) []remoteAgentTask {
+	active := map[string]int{}
+	for id := range s.poolActive[poolID] {
+		active[s.tasks[id].RequestedBy]++
+	}
+	queued := make([]remoteAgentTask, 0, len(s.poolQueued[poolID]))
+	for id := range s.poolQueued[poolID] {
+		queued = append(queued, s.tasks[id])
+	}
+	sort.Slice(queued, func(i, j int) bool {
+		if queued[i].Priority != queued[j].Priority {
+			return queued[i].Priority > queued[j].Priority
+		}
+		if queued[i].QueueSequence != queued[j].QueueSequence {
+			return queued[i].QueueSequence < queued[j].QueueSequence
+		}
+		return queued[i].ID < queued[j].ID
+	})
+	out := make([]remoteAgentTask, 0, len(queued))
+	for len(queued) > 0 {
+		next := 0
+		for i := 1; i < len(queued) && queued[i].Priority == queued[0].Priority; i++ {
+			if active[queued[i].RequestedBy] < active[queued[next].RequestedBy] {
+				next = i
+			}
+		}
+		out = append(out, queued[next])
+		active[queued[next].RequestedBy]++
+		queued = append(queued[:next], queued[next+1:]...)
+	}
+	return out
+}
+
+func (s *RemoteAgentOrchestrationService) queuePositionsLocked() map[string]int {
+	positions := map[string]int{}
+	for poolID := range s.poolQueued {
+		for i, queued := range s.poolQueueLocked(poolID) {
+			positions[queued.ID] = i + 1
+		}
+	}
+	return positions
+}
+
+// withQueueStatusLocked fills the derived queue fields of a task for API
+// responses. They are never persisted.
+func (s *RemoteAgentOrchestrationService) withQueueStatusLocked(task remoteAgentTask, positions map[string]int, now time.Time) remoteAgentTask {
+	task.QueuePosition = 0
+	task.WaitSeconds = 0
+	if task.QueuedAt == "" {
+		return task
+	}
+	queuedAt, err := time.Parse(time.RFC3339, task.QueuedAt)
+	if err != nil {
+		return task
+	}
+	until := now
+	if task.State == remoteAgentTaskStateQueued {
+		task.QueuePosition = positions[task.ID]
+	} else if assignedAt, err := time.Parse(time.RFC3339, task.AssignedAt); err == nil {
+		until = assignedAt
+	} else {
+		return task
+	}
+	if wait := until.Sub(queuedAt); wait > 0 {
+		task.WaitSeconds = int64(wait / time.Second)
+	}
+	return task
+}
+
+// idleAgentLocked picks the pool agent that has been idle the longest.
+func (s *RemoteAgentOrchestrationService) idleAgentLocked(poolID, workspaceSessionID string) (remoteAgent, bool) {
+	var candidates []remoteAgent
+	for _, agent := range s.agents {
+		if agent.PoolID != poolID || agent.Availability != remoteAgentAvailabilityIdle || strings.TrimSpace(agent.CurrentTaskID) != "" {
+			continue
+		}
+		if workspaceSessionID != "" && agent.WorkspaceSessionID != workspaceSessionID {
+			continue
+		}
+		candidates = append(candidates, agent)
+	}
+	if len(candidates) == 0 {
+		return remoteAgent{}, false
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].LastActivityAt != candidates[j].LastActivityAt {
+			return candidates[i].LastActivityAt < candidates[j].LastActivityAt
+		}
+		return candidates[i].Name < candidates[j].Name
+	})
+	return candidates[0], true
+}
+
+// scheduleLocked assigns queued tasks to idle agents while their pools have
+// capacity and dispatches workflow steps whose dependencies have completed.
+// It runs whenever a task is queued or an agent may have freed up.
+func (s *RemoteAgentOrchestrationService) scheduleLocked() {
+	for {
+		s.assignQueuedTasksLocked()
+		if !s.advanceWorkflowsLocked() {
+			return
+		}
+	}
+}
+
+func (s *RemoteAgentOrchestrationService) assignQueuedTasksLocked() {
+	poolIDs := make([]string, 0, len(s.poolQueued))
+	for poolID := range s.poolQueued {
+		poolIDs = append(poolIDs, poolID)
+	}
+	sort.Strings(poolIDs)
+	for _, poolID := range poolIDs {
+		for _, task := range s.poolQueueLocked(poolID) {
+			if !s.poolHasCapacityLocked(poolID) {
+				break
+			}
+			agent, ok := s.idleAgentLocked(poolID, task.WorkspaceSessionID)
+			if !ok {
+				continue
+			}
+			s.assignQueuedTaskLocked(task, agent)
+		}
+	}
+}
+
+func (s *RemoteAgentOrchestrationService) assignQueuedTaskLocked(task remoteAgentTask, agent remoteAgent) {
+	now := nowRFC3339()
+	task.State = remoteAgentTaskStateAssigned
+	task.AgentID = agent.ID
+	task.AgentName = agent.Name
+	task.WorkspaceSessionID = agent.WorkspaceSessionID
+	task.CurrentSession = agent.CurrentSession
+	task.AssignedAt = now
+	task.LastTransitionAt = now
+	s.updateTaskLocked(task)
+	agent.Availability = remoteAgentAvailabilityBusy
+	agent.CurrentTaskID = task.ID
+	agent.LastActivityAt = now
+	agent.UpdatedAt = now
+	s.updateAgentLocked(agent)
+	s.executeTaskLocked(task)
+}
@@ -0,0 +1,196 @@
+package controlplaneapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRemoteAgentQueue_PriorityAndFairShareOrdering(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	service := api.RemoteAgentOrchestration
+
+	if _, err := service.CreatePool(remoteAgentPoolCreateRequest{Name: "reviewers"}); err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	for _, name := range []string{"reviewer-a", "reviewer-b"} {
+		if _, err := service.CreateAgent("", remoteAgentCreateRequest{Name: name, PoolName: "reviewers"}); err != nil {
+			t.Fatalf("create agent: %v", err)
+		}
+	}
+	dispatch := func(requestedBy string, priority int) remoteAgentTask {
+		t.Helper()
+		task, err := service.DispatchTask(requestedBy, remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{PoolName: "reviewers"}, Prompt: "Review", Priority: priority})
+		if err != nil {
+			t.Fatalf("dispatch task: %v", err)
+		}
+		return task
+	}
+	assertState := func(task remoteAgentTask, want remoteAgentTaskState, position int) {
+		t.Helper()
+		got, _ := service.GetTask(task.ID)
+		if got.State != want || got.QueuePosition != position {
+			t.Fatalf("task %s (%s) = %s at position %d, want %s at %d", task.ID, task.RequestedBy, got.State, got.QueuePosition, want, position)
+		}
+	}
+
+	aliceFirst := dispatch("alice", 0)
+	aliceSecond := dispatch("alice", 0)
+	assertState(aliceFirst, remoteAgentTaskStateAssigned, 0)
+	assertState(aliceSecond, remoteAgentTaskStateAssigned, 0)
+
+	// Within a priority tier bob goes ahead of alice, who already holds both
+	// of the pool's agents; a higher priority task jumps the whole tier.
+	aliceThird := dispatch("alice", 0)
+	bob := dispatch("bob", 0)
+	assertState(bob, remoteAgentTaskStateQueued, 1)
+	assertState(aliceThird, remoteAgentTaskStateQueued, 2)
+	urgent := dispatch("alice", 5)
+	assertState(urgent, remoteAgentTaskStateQueued, 1)
+	assertState(bob, remoteAgentTaskStateQueued, 2)
+	assertState(aliceThird, remoteAgentTaskStateQueued, 3)
+
+	for _, step := range []struct{ done, next remoteAgentTask }{
+		{aliceFirst, urgent},
+		{aliceSecond, bob},
+		{urgent, aliceThird},
+	} {
+		if _, err := service.CompleteTask(step.done.ID, remoteAgentTaskCompleteRequest{}); err != nil {
+			t.Fatalf("complete task: %v", err)
+		}
+		assertState(step.next, remoteAgentTaskStateAssigned, 0)
+	}
+}
+
+func TestRemoteAgentQueue_PoolConcurrencyLimitCancelAndRetry(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	service := api.RemoteAgentOrchestration
+
+	if _, err := service.CreatePool(remoteAgentPoolCreateRequest{Name: "builders", MaxConcurrency: 1}); err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	for _, name := range []string{"builder-a", "builder-b"} {
+		if _, err := service.CreateAgent("", remoteAgentCreateRequest{Name: name, PoolName: "builders"}); err != nil {
+			t.Fatalf("create agent: %v", err)
+		}
+	}
+	running, err := service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{PoolName: "builders"}, Prompt: "Build"})
+	if err != nil || running.State != remoteAgentTaskStateAssigned {
+		t.Fatalf("first dispatch = %+v, %v", running, err)
+	}
+	waiting, err := service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{PoolName: "builders"}, Prompt: "Build again"})
+	if err != nil || waiting.State != remoteAgentTaskStateQueued || waiting.QueuePosition != 1 {
+		t.Fatalf("second dispatch = %+v, %v; want queued at position 1 despite an idle agent", waiting, err)
+	}
+	_, err = service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentName: "builder-b", PoolName: "builders"}, Prompt: "Direct"})
+	var reqErr *requestError
+	if !errors.As(err, &reqErr) || reqErr.status != http.StatusConflict {
+		t.Fatalf("direct dispatch over limit error = %v, want 409", err)
+	}
+
+	cancelled, err := service.CancelTask(waiting.ID)
+	if err != nil || cancelled.State != remoteAgentTaskStateCancelled {
+		t.Fatalf("cancel queued task = %+v, %v", cancelled, err)
+	}
+	retried, err := service.RetryTask(waiting.ID)
+	if err != nil || retried.State != remoteAgentTaskStateQueued || retried.Attempt != 2 {
+		t.Fatalf("retry queued task = %+v, %v; want requeued attempt 2", retried, err)
+	}
+	if _, err := service.CancelTask(running.ID); err != nil {
+		t.Fatalf("cancel running task: %v", err)
+	}
+	got, _ := service.GetTask(waiting.ID)
+	if got.State != remoteAgentTaskStateAssigned || got.AgentID == "" {
+		t.Fatalf("retried task = %s on %q, want assigned once the pool had capacity", got.State, got.AgentID)
+	}
+}
+
+func TestRemoteAgentQueue_RequeuesAreCappedAndIndexesTrackState(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	service := api.RemoteAgentOrchestration
+
+	if _, err := service.CreatePool(remoteAgentPoolCreateRequest{Name: "builders", MaxConcurrency: 1}); err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+	if _, err := service.CreateAgent("", remoteAgentCreateRequest{Name: "builder", PoolName: "builders"}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	task, err := service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{PoolName: "builders"}, Prompt: "Build"})
+	if err != nil {
+		t.Fatalf("dispatch task: %v", err)
+	}
+	for i := 0; i < remoteAgentMaxRequeues; i++ {
+		if _, err := service.CancelTask(task.ID); err != nil {
+			t.Fatalf("cancel attempt %d: %v", i+1, err)
+		}
+		if _, err := service.RetryTask(task.ID); err != nil {
+			t.Fatalf("retry %d: %v", i+1, err)
+		}
+	}
+	if _, err := service.CancelTask(task.ID); err != nil {
+		t.Fatalf("cancel last attempt: %v", err)
+	}
+	var reqErr *requestError
+	if _, err := service.RetryTask(task.ID); !errors.As(err, &reqErr) || reqErr.status != http.StatusConflict {
+		t.Fatalf("retry past the cap error = %v, want 409", err)
+	}
+
+	service.mu.Lock()
+	defer service.mu.Unlock()
+	pool := service.tasks[task.ID].PoolID
+	if len(service.poolQueued[pool]) != 0 || len(service.poolActive[pool]) != 0 {
+		t.Fatalf("pool indexes after cancel = queued %v active %v, want empty", service.poolQueued[pool], service.poolActive[pool])
+	}
+}
+
+func TestRemoteAgentQueue_APIDispatchToPoolReportsQueueStatus(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-alice", "alice", []string{ScopeRemoteAgentTaskRead, ScopeRemoteAgentTaskWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if _, err := api.RemoteAgentOrchestration.CreatePool(remoteAgentPoolCreateRequest{Name: "reviewers"}); err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agent-tasks", "alice", map[string]any{
+		"target":   map[string]any{"poolName": "reviewers"},
+		"prompt":   "Review",
+		"priority": 3,
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("dispatch status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var task remoteAgentTask
+	if err := json.Unmarshal(b, &task); err != nil {
+		t.Fatalf("unmarshal task: %v", err)
+	}
+	if task.State != remoteAgentTaskStateQueued || task.QueuePosition != 1 || task.Priority != 3 || task.QueuedAt == "" {
+		t.Fatalf("unexpected queued task: %+v", task)
+	}
+
+	if _, err := api.RemoteAgentOrchestration.CreateAgent("", remoteAgentCreateRequest{Name: "reviewer", PoolName: "reviewers"}); err != nil {
+		t.Fatalf("create agent: %v", err)
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/remote-agent-tasks/"+task.ID, "alice", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var assigned remoteAgentTask
+	if err := json.Unmarshal(b, &assigned); err != nil {
+		t.Fatalf("unmarshal task: %v", err)
+	}
+	if assigned.State != remoteAgentTaskStateAssigned || assigned.AgentName != "reviewer" || assigned.QueuePosition != 0 {
+		t.Fatalf("task after agent registration = %+v, want assigned to reviewer", assigned)
+	}
+}
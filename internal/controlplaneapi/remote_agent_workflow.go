@@ -0,0 +1,503 @@
+package controlplaneapi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// A workflow is a DAG of remote-agent tasks. Each step becomes a task once
+// every step it depends on has completed, and receives the output artifacts
+// of those steps as input artifacts, so fan-out and fan-in need no client-side
+// glue. A failed step blocks its dependants until it is retried.
+
+type remoteAgentWorkflowState string
+
+const (
+	remoteAgentWorkflowStateRunning   remoteAgentWorkflowState = "running"
+	remoteAgentWorkflowStateCompleted remoteAgentWorkflowState = "completed"
+	remoteAgentWorkflowStateFailed    remoteAgentWorkflowState = "failed"
+	remoteAgentWorkflowStateCancelled remoteAgentWorkflowState = "cancelled"
+)
+
+// Steps that have not been dispatched yet report one of these states in place
+// of a task state.
+const (
+	remoteAgentWorkflowStepPending remoteAgentTaskState = "pending"
+	remoteAgentWorkflowStepBlocked remoteAgentTaskState = "blocked"
+)
+
+type remoteAgentWorkflowStep struct {
+	Name           string                             `json:"name"`
+	DependsOn      []string                           `json:"dependsOn,omitempty"`
+	Target         remoteAgentTaskTarget              `json:"target"`
+	Prompt         string                             `json:"prompt"`
+	Priority       int                                `json:"priority,omitempty"`
+	TimeoutSeconds int32                              `json:"timeoutSeconds,omitempty"`
+	InputArtifacts []remoteAgentArtifactCreateRequest `json:"inputArtifacts,omitempty"`
+	TaskID         string                             `json:"taskId,omitempty"`
+	State          remoteAgentTaskState               `json:"state,omitempty"`
+	WaitingReason  string                             `json:"waitingReason,omitempty"`
+}
+
+type remoteAgentWorkflow struct {
+	ID               string                    `json:"id"`
+	Name             string                    `json:"name,omitempty"`
+	RequestedBy      string                    `json:"requestedBy,omitempty"`
+	Team             string                    `json:"team,omitempty"`
+	State            remoteAgentWorkflowState  `json:"state"`
+	Steps            []remoteAgentWorkflowStep `json:"steps"`
+	CreatedAt        string                    `json:"createdAt,omitempty"`
+	CompletedAt      string                    `json:"completedAt,omitempty"`
+	CancelledAt      string                    `json:"cancelledAt,omitempty"`
+	LastTransitionAt string                    `json:"lastTransitionAt,omitempty"`
+}
+
+type remoteAgentWorkflowStepRequest struct {
+	Name           string                             `json:"name"`
+	DependsOn      []string                           `json:"dependsOn,omitempty"`
+	Target         remoteAgentTaskTarget              `json:"target"`
+	Prompt         string                             `json:"prompt"`
+	Priority       int                                `json:"priority,omitempty"`
+	TimeoutSeconds int32                              `json:"timeoutSeconds,omitempty"`
+	InputArtifacts []remoteAgentArtifactCreateRequest `json:"inputArtifacts,omitempty"`
+}
+
+type remoteAgentWorkflowCreateRequest struct {
+	Name  string                           `json:"name,omitempty"`
+	Team  string                           `json:"team,omitempty"`
+	Steps []remoteAgentWorkflowStepRequest `json:"steps"`
+}
+
+func (w remoteAgentWorkflow) isTerminal() bool {
+	return w.State == remoteAgentWorkflowStateCompleted || w.State == remoteAgentWorkflowStateCancelled
+}
+
+func validateRemoteAgentWorkflowCreateRequest(req remoteAgentWorkflowCreateRequest) error {
+	if len(req.Steps) == 0 {
+		return &requestError{status: http.StatusBadRequest, msg: "steps required"}
+	}
+	names := map[string]bool{}
+	for _, step := range req.Steps {
+		name := strings.TrimSpace(step.Name)
+		if name == "" {
+			return &requestError{status: http.StatusBadRequest, msg: "steps[].name required"}
+		}
+		if names[name] {
+			return &requestError{status: http.StatusBadRequest, msg: fmt.Sprintf("duplicate step name %q", name)}
+		}
+		names[name] = true
+		err := validateRemoteAgentTaskCreateRequest(remoteAgentTaskCreateRequest{
+			Target:         step.Target,
+			Prompt:         step.Prompt,
+			TimeoutSeconds: step.TimeoutSeconds,
+			InputArtifacts: step.InputArtifacts,
+		})
+		if err != nil {
+			return &requestError{status: http.StatusBadRequest, msg: fmt.Sprintf("step %q: %s", name, err.Error())}
+		}
+	}
+	edges := map[string][]string{}
+	for _, step := range req.Steps {
+		name := strings.TrimSpace(step.Name)
+		for _, dep := range step.DependsOn {
+			dep = strings.TrimSpace(dep)
+			if !names[dep] {
+				return &requestError{status: http.StatusBadRequest, msg: fmt.Sprintf("step %q depends on unknown step %q", name, dep)}
+			}
+			if dep == name {
+				return &requestError{status: http.StatusBadRequest, msg: fmt.Sprintf("step %q depends on itself", name)}
+			}
+			edges[name] = append(edges[name], dep)
+		}
+	}
+	// Depth-first search for a back edge.
+	const (
+		visiting = iota + 1
+		done
+	)
+	marks := map[string]int{}
+	var visit func(name string) bool
+	visit = func(name string) bool {
+		switch marks[name] {
+		case visiting:
+			return false
+		case done:
+			return true
+		}
+		marks[name] = visiting
+		for _, dep := range edges[name] {
+			if !visit(dep) {
+				return false
+			}
+		}
+		marks[name] = done
+		return true
+	}
+	for _, step := range req.Steps {
+		if !visit(strings.TrimSpace(step.Name)) {
+			return &requestError{status: http.StatusBadRequest, msg: "workflow steps contain a dependency cycle"}
+		}
+	}
+	return nil
+}
+
+func (s *RemoteAgentOrchestrationService) CreateWorkflow(requestedBy string, req remoteAgentWorkflowCreateRequest) (remoteAgentWorkflow, error) {
+	if err := validateRemoteAgentWorkflowCreateRequest(req); err != nil {
+		return remoteAgentWorkflow{}, err
+	}
+	s.expireTimedOutTasks(time.Now().UTC())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	steps := make([]remoteAgentWorkflowStep, 0, len(req.Steps))
+	for _, step := range req.Steps {
+		name := strings.TrimSpace(step.Name)
+		if isRemoteAgentPoolTarget(step.Target) {
+			if _, _, err := s.resolvePoolLocked("", step.Target.PoolName); err != nil {
+				return remoteAgentWorkflow{}, workflowStepError(name, err)
+			}
+		} else if _, err := s.resolveAgentLocked(step.Target); err != nil {
+			return remoteAgentWorkflow{}, workflowStepError(name, err)
+		}
+		dependsOn := make([]string, 0, len(step.DependsOn))
+		for _, dep := range step.DependsOn {
+			dependsOn = append(dependsOn, strings.TrimSpace(dep))
+		}
+		steps = append(steps, remoteAgentWorkflowStep{
+			Name:           name,
+			DependsOn:      dependsOn,
+			Target:         step.Target,
+			Prompt:         strings.TrimSpace(step.Prompt),
+			Priority:       step.Priority,
+			TimeoutSeconds: step.TimeoutSeconds,
+			InputArtifacts: step.InputArtifacts,
+		})
+	}
+	now := nowRFC3339()
+	workflow := remoteAgentWorkflow{
+		ID:               newID(),
+		Name:             strings.TrimSpace(req.Name),
+		RequestedBy:      strings.TrimSpace(requestedBy),
+		Team:             strings.TrimSpace(req.Team),
+		State:            remoteAgentWorkflowStateRunning,
+		Steps:            steps,
+		CreatedAt:        now,
+		LastTransitionAt: now,
+	}
+	s.updateWorkflowLocked(workflow)
+	s.scheduleLocked()
+	return s.withWorkflowStatusLocked(s.workflows[workflow.ID]), nil
+}
+
+func workflowStepError(step string, err error) error {
+	status := http.StatusBadRequest
+	var reqErr *requestError
+	if errors.As(err, &reqErr) {
+		status = reqErr.status
+	}
+	return &requestError{status: status, msg: fmt.Sprintf("step %q: %s", step, err.Error())}
+}
+
+func (s *RemoteAgentOrchestrationService) GetWorkflow(id string) (remoteAgentWorkflow, bool) {
+	s.expireTimedOutTasks(time.Now().UTC())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workflow, ok := s.workflows[strings.TrimSpace(id)]
+	if !ok {
+		return remoteAgentWorkflow{}, false
+	}
+	return s.withWorkflowStatusLocked(workflow), true
+}
+
+func (s *RemoteAgentOrchestrationService) ListWorkflows() []remoteAgentWorkflow {
+	s.expireTimedOutTasks(time.Now().UTC())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]remoteAgentWorkflow, 0, len(s.workflows))
+	for _, workflow := range s.workflows {
+		out = append(out, s.withWorkflowStatusLocked(workflow))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].CreatedAt < out[j].CreatedAt })
+	return out
+}
+
+// CancelWorkflow cancels every queued or active step task and stops further
+// steps from being dispatched.
+func (s *RemoteAgentOrchestrationService) CancelWorkflow(id string) (remoteAgentWorkflow, error) {
+	s.expireTimedOutTasks(time.Now().UTC())
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	workflow, ok := s.workflows[strings.TrimSpace(id)]
+	if !ok {
+		return remoteAgentWorkflow{}, &requestError{status: http.StatusNotFound, msg: "remote agent workflow not found"}
+	}
+	if workflow.isTerminal() {
+		return remoteAgentWorkflow{}, &requestError{status: http.StatusConflict, msg: fmt.Sprintf("workflow is already %s", workflow.State)}
+	}
+	for _, step := range workflow.Steps {
+		task, ok := s.tasks[step.TaskID]
+		if !ok || task.isTerminal() {
+			continue
+		}
+		if _, err := s.cancelTaskLocked(task.ID); err != nil {
+			return remoteAgentWorkflow{}, err
+		}
+	}
+	now := nowRFC3339()
+	workflow.State = remoteAgentWorkflowStateCancelled
+	workflow.CancelledAt = now
+	workflow.LastTransitionAt = now
+	s.updateWorkflowLocked(workflow)
+	s.scheduleLocked()
+	return s.withWorkflowStatusLocked(workflow), nil
+}
+
+func (s *RemoteAgentOrchestrationService) updateWorkflowLocked(workflow remoteAgentWorkflow) {
+	s.workflows[workflow.ID] = workflow
+	if s.store != nil {
+		s.store.SaveWorkflow(workflow)
+	}
+}
+
+// workflowStepStatesLocked derives the state of every step from its task, or
+// from its dependencies when it has not been dispatched yet.
+func (s *RemoteAgentOrchestrationService) workflowStepStatesLocked(workflow remoteAgentWorkflow) map[string]remoteAgentTaskState {
+	steps := make(map[string]remoteAgentWorkflowStep, len(workflow.Steps))
+	for _, step := range workflow.Steps {
+		steps[step.Name] = step
+	}
+	states := make(map[string]remoteAgentTaskState, len(workflow.Steps))
+	var resolve func(name string) remoteAgentTaskState
+	resolve = func(name string) remoteAgentTaskState {
+		if state, ok := states[name]; ok {
+			return state
+		}
+		step := steps[name]
+		state := remoteAgentWorkflowStepPending
+		if task, ok := s.tasks[step.TaskID]; ok {
+			state = task.State
+		} else if workflow.State == remoteAgentWorkflowStateCancelled {
+			state = remoteAgentTaskStateCancelled
+		} else {
+			for _, dep := range step.DependsOn {
+				switch resolve(dep) {
+				case remoteAgentTaskStateFailed, remoteAgentTaskStateTimedOut, remoteAgentTaskStateCancelled, remoteAgentWorkflowStepBlocked:
+					state = remoteAgentWorkflowStepBlocked
+				}
+			}
+		}
+		states[name] = state
+		return state
+	}
+	for _, step := range workflow.Steps {
+		resolve(step.Name)
+	}
+	return states
+}
+
+func workflowStateFromSteps(states map[string]remoteAgentTaskState) remoteAgentWorkflowState {
+	completed := 0
+	for _, state := range states {
+		switch state {
+		case remoteAgentTaskStateCompleted:
+			completed++
+		case remoteAgentWorkflowStepPending, remoteAgentTaskStateQueued, remoteAgentTaskStateAssigned, remoteAgentTaskStateRunning:
+			return remoteAgentWorkflowStateRunning
+		}
+	}
+	if completed == len(states) {
+		return remoteAgentWorkflowStateCompleted
+	}
+	return remoteAgentWorkflowStateFailed
+}
+
+// advanceWorkflowsLocked dispatches every step whose dependencies have all
+// completed and refreshes the states of running workflows. Finished workflows
+// are skipped; retrying a step reopens a failed one. It reports whether any
+// task was created so scheduleLocked can hand newly queued steps to idle
+// agents.
+func (s *RemoteAgentOrchestrationService) advanceWorkflowsLocked() bool {
+	ids := make([]string, 0, len(s.workflows))
+	for id, workflow := range s.workflows {
+		if workflow.State == remoteAgentWorkflowStateRunning {
+			ids = append(ids, id)
+		}
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		left, right := s.workflows[ids[i]], s.workflows[ids[j]]
+		if left.CreatedAt != right.CreatedAt {
+			return left.CreatedAt < right.CreatedAt
+		}
+		return left.ID < right.ID
+	})
+	dispatched := false
+	for _, id := range ids {
+		workflow := s.workflows[id]
+		changed := false
+		states := s.workflowStepStatesLocked(workflow)
+		for i, step := range workflow.Steps {
+			if step.TaskID != "" || !workflowDependenciesCompleted(step, states) {
+				continue
+			}
+			task, err := s.dispatchWorkflowStepLocked(workflow, step)
+			if err != nil {
+				if step.WaitingReason != err.Error() {
+					workflow.Steps[i].WaitingReason = err.Error()
+					changed = true
+				}
+				continue
+			}
+			workflow.Steps[i].TaskID = task.ID
+			workflow.Steps[i].WaitingReason = ""
+			states[step.Name] = task.State
+			dispatched, changed = true, true
+		}
+		if next := workflowStateFromSteps(states); next != workflow.State {
+			now := nowRFC3339()
+			workflow.State = next
+			workflow.LastTransitionAt = now
+			workflow.CompletedAt = ""
+			if next == remoteAgentWorkflowStateCompleted || next == remoteAgentWorkflowStateFailed {
+				workflow.CompletedAt = now
+			}
+			changed = true
+		}
+		if changed {
+			s.updateWorkflowLocked(workflow)
+		}
+	}
+	return dispatched
+}
+
+// reopenWorkflowLocked puts the failed workflow of a retried step task back to
+// running so advanceWorkflowsLocked tracks it again.
+func (s *RemoteAgentOrchestrationService) reopenWorkflowLocked(task remoteAgentTask) {
+	workflow, ok := s.workflows[task.WorkflowID]
+	if !ok || workflow.State != remoteAgentWorkflowStateFailed {
+		return
+	}
+	workflow.State = remoteAgentWorkflowStateRunning
+	workflow.LastTransitionAt = nowRFC3339()
+	workflow.CompletedAt = ""
+	s.updateWorkflowLocked(workflow)
+}
+
+func workflowDependenciesCompleted(step remoteAgentWorkflowStep, states map[string]remoteAgentTaskState) bool {
+	for _, dep := range step.DependsOn {
+		if states[dep] != remoteAgentTaskStateCompleted {
+			return false
+		}
+	}
+	return true
+}
+
+// dispatchWorkflowStepLocked creates the task for a step, passing the output
+// artifacts of its dependencies along as inputs.
+func (s *RemoteAgentOrchestrationService) dispatchWorkflowStepLocked(workflow remoteAgentWorkflow, step remoteAgentWorkflowStep) (remoteAgentTask, error) {
+	inputArtifacts := make([]remoteAgentArtifactRef, 0, len(step.InputArtifacts))
+	for _, artifact := range step.InputArtifacts {
+		inputArtifacts = append(inputArtifacts, makeRemoteAgentArtifact(artifact))
+	}
+	for _, dep := range step.DependsOn {
+		for _, upstream := range workflow.Steps {
+			if upstream.Name == dep {
+				inputArtifacts = append(inputArtifacts, s.tasks[upstream.TaskID].OutputArtifacts...)
+			}
+		}
+	}
+	task := remoteAgentTask{
+		RequestedBy:        workflow.RequestedBy,
+		Team:               workflow.Team,
+		WorkspaceSessionID: strings.TrimSpace(step.Target.WorkspaceSessionID),
+		Prompt:             step.Prompt,
+		Priority:           step.Priority,
+		TimeoutSeconds:     step.TimeoutSeconds,
+		InputArtifacts:     inputArtifacts,
+		WorkflowID:         workflow.ID,
+		WorkflowStep:       step.Name,
+	}
+	if isRemoteAgentPoolTarget(step.Target) {
+		return s.newQueuedTaskLocked(task, step.Target.PoolName)
+	}
+	return s.assignTaskLocked(task, step.Target)
+}
+
+// withWorkflowStatusLocked fills the derived step states for API responses.
+func (s *RemoteAgentOrchestrationService) withWorkflowStatusLocked(workflow remoteAgentWorkflow) remoteAgentWorkflow {
+	states := s.workflowStepStatesLocked(workflow)
+	steps := make([]remoteAgentWorkflowStep, len(workflow.Steps))
+	for i, step := range workflow.Steps {
+		step.State = states[step.Name]
+		steps[i] = step
+	}
+	workflow.Steps = steps
+	return workflow
+}
+
+func (a *API) handleRemoteAgentWorkflowsList(w http.ResponseWriter, r *http.Request) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	workflows := a.RemoteAgentOrchestration.ListWorkflows()
+	out := make([]remoteAgentWorkflow, 0, len(workflows))
+	for _, workflow := range workflows {
+		if visibleTo(r.Context(), workflowOwner(workflow)) {
+			out = append(out, workflow)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"remoteAgentWorkflows": out})
+}
+
+func (a *API) handleRemoteAgentWorkflowsCreate(w http.ResponseWriter, r *http.Request) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	var req remoteAgentWorkflowCreateRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	team, err := resolveRequestTeam(r.Context(), req.Team)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	req.Team = team
+	workflow, err := a.RemoteAgentOrchestration.CreateWorkflow(principal(r.Context()), req)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusCreated, workflow)
+}
+
+func (a *API) handleRemoteAgentWorkflowGet(w http.ResponseWriter, _ *http.Request, id string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	workflow, ok := a.RemoteAgentOrchestration.GetWorkflow(id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "remote agent workflow not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, workflow)
+}
+
+func (a *API) handleRemoteAgentWorkflowCancel(w http.ResponseWriter, _ *http.Request, id string) {
+	if a.RemoteAgentOrchestration == nil {
+		writeError(w, http.StatusNotImplemented, "remote agent orchestration service not configured")
+		return
+	}
+	workflow, err := a.RemoteAgentOrchestration.CancelWorkflow(id)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, workflow)
+}
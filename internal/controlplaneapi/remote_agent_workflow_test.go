@@ -0,0 +1,333 @@
+package controlplaneapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+)
+
+func newRemoteAgentWorkflowFixture(t *testing.T, store *RemoteAgentOrchestrationStore, agents ...string) *RemoteAgentOrchestrationService {
+	t.Helper()
+	service := newRemoteAgentOrchestrationService(store, "", nil, nil)
+	for _, name := range agents {
+		if _, err := service.CreateAgent("", remoteAgentCreateRequest{Name: name}); err != nil {
+			t.Fatalf("create agent %s: %v", name, err)
+		}
+	}
+	return service
+}
+
+func findWorkflowStep(t *testing.T, workflow remoteAgentWorkflow, name string) remoteAgentWorkflowStep {
+	t.Helper()
+	for _, step := range workflow.Steps {
+		if step.Name == name {
+			return step
+		}
+	}
+	t.Fatalf("workflow has no step %q", name)
+	return remoteAgentWorkflowStep{}
+}
+
+func finishWorkflowStep(t *testing.T, service *RemoteAgentOrchestrationService, workflowID, name, artifact string) {
+	t.Helper()
+	workflow, _ := service.GetWorkflow(workflowID)
+	step := findWorkflowStep(t, workflow, name)
+	if step.State != remoteAgentTaskStateAssigned {
+		t.Fatalf("step %s state = %s, want assigned", name, step.State)
+	}
+	if artifact != "" {
+		if _, err := service.AddOutputArtifact(step.TaskID, remoteAgentArtifactCreateRequest{Name: artifact, Kind: remoteAgentArtifactKindPatch, Path: "/workspace/" + artifact}); err != nil {
+			t.Fatalf("add artifact to %s: %v", name, err)
+		}
+	}
+	if _, err := service.CompleteTask(step.TaskID, remoteAgentTaskCompleteRequest{Summary: name + " done"}); err != nil {
+		t.Fatalf("complete %s: %v", name, err)
+	}
+}
+
+func inputArtifactNames(task remoteAgentTask) map[string]bool {
+	names := map[string]bool{}
+	for _, artifact := range task.InputArtifacts {
+		names[artifact.Name] = true
+	}
+	return names
+}
+
+func TestRemoteAgentWorkflow_FanOutFanInPassesArtifacts(t *testing.T) {
+	service := newRemoteAgentWorkflowFixture(t, newRemoteAgentOrchestrationStore(""), "planner", "impl-a", "impl-b", "reviewer")
+
+	workflow, err := service.CreateWorkflow("alice", remoteAgentWorkflowCreateRequest{
+		Name: "plan-implement-review",
+		Steps: []remoteAgentWorkflowStepRequest{
+			{Name: "plan", Target: remoteAgentTaskTarget{AgentName: "planner"}, Prompt: "Plan the change"},
+			{Name: "implement-a", DependsOn: []string{"plan"}, Target: remoteAgentTaskTarget{AgentName: "impl-a"}, Prompt: "Implement part A"},
+			{Name: "implement-b", DependsOn: []string{"plan"}, Target: remoteAgentTaskTarget{AgentName: "impl-b"}, Prompt: "Implement part B"},
+			{Name: "review", DependsOn: []string{"implement-a", "implement-b"}, Target: remoteAgentTaskTarget{AgentName: "reviewer"}, Prompt: "Review both parts"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	if workflow.State != remoteAgentWorkflowStateRunning {
+		t.Fatalf("workflow state = %s, want running", workflow.State)
+	}
+	if got := findWorkflowStep(t, workflow, "implement-a").State; got != remoteAgentWorkflowStepPending {
+		t.Fatalf("implement-a state before plan completes = %s, want pending", got)
+	}
+
+	finishWorkflowStep(t, service, workflow.ID, "plan", "plan.md")
+	workflow, _ = service.GetWorkflow(workflow.ID)
+	for _, name := range []string{"implement-a", "implement-b"} {
+		step := findWorkflowStep(t, workflow, name)
+		task, _ := service.GetTask(step.TaskID)
+		if task.WorkflowID != workflow.ID || task.WorkflowStep != name || !inputArtifactNames(task)["plan.md"] {
+			t.Fatalf("%s task = %+v, want plan.md passed in", name, task)
+		}
+	}
+	if got := findWorkflowStep(t, workflow, "review").State; got != remoteAgentWorkflowStepPending {
+		t.Fatalf("review state before fan-in = %s, want pending", got)
+	}
+
+	finishWorkflowStep(t, service, workflow.ID, "implement-a", "a.patch")
+	if workflow, _ = service.GetWorkflow(workflow.ID); findWorkflowStep(t, workflow, "review").TaskID != "" {
+		t.Fatalf("review dispatched before implement-b completed")
+	}
+	finishWorkflowStep(t, service, workflow.ID, "implement-b", "b.patch")
+
+	workflow, _ = service.GetWorkflow(workflow.ID)
+	review, _ := service.GetTask(findWorkflowStep(t, workflow, "review").TaskID)
+	if names := inputArtifactNames(review); !names["a.patch"] || !names["b.patch"] || names["plan.md"] {
+		t.Fatalf("review inputs = %+v, want only the patches from its direct dependencies", review.InputArtifacts)
+	}
+	finishWorkflowStep(t, service, workflow.ID, "review", "")
+	if workflow, _ = service.GetWorkflow(workflow.ID); workflow.State != remoteAgentWorkflowStateCompleted || workflow.CompletedAt == "" {
+		t.Fatalf("workflow = %s (completedAt=%q), want completed", workflow.State, workflow.CompletedAt)
+	}
+}
+
+func TestRemoteAgentWorkflow_FailureBlocksDependantsUntilRetryAndCancelStopsAll(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "orchestration.jsonl")
+	service := newRemoteAgentWorkflowFixture(t, newRemoteAgentOrchestrationStore(path), "builder", "tester")
+
+	workflow, err := service.CreateWorkflow("alice", remoteAgentWorkflowCreateRequest{Steps: []remoteAgentWorkflowStepRequest{
+		{Name: "build", Target: remoteAgentTaskTarget{AgentName: "builder"}, Prompt: "Build"},
+		{Name: "test", DependsOn: []string{"build"}, Target: remoteAgentTaskTarget{AgentName: "tester"}, Prompt: "Test"},
+	}})
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	build := findWorkflowStep(t, workflow, "build")
+	if _, err := service.finishTask(build.TaskID, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{Summary: "compile error"}); err != nil {
+		t.Fatalf("fail build: %v", err)
+	}
+	workflow, _ = service.GetWorkflow(workflow.ID)
+	if workflow.State != remoteAgentWorkflowStateFailed || findWorkflowStep(t, workflow, "test").State != remoteAgentWorkflowStepBlocked {
+		t.Fatalf("workflow after failure = %+v, want failed with test blocked", workflow)
+	}
+
+	if _, err := service.RetryTask(build.TaskID); err != nil {
+		t.Fatalf("retry build: %v", err)
+	}
+	if workflow, _ = service.GetWorkflow(workflow.ID); workflow.State != remoteAgentWorkflowStateRunning || workflow.CompletedAt != "" {
+		t.Fatalf("workflow after retry = %s (completedAt=%q), want running", workflow.State, workflow.CompletedAt)
+	}
+
+	cancelled, err := service.CancelWorkflow(workflow.ID)
+	if err != nil {
+		t.Fatalf("cancel workflow: %v", err)
+	}
+	if cancelled.State != remoteAgentWorkflowStateCancelled {
+		t.Fatalf("workflow state = %s, want cancelled", cancelled.State)
+	}
+	for _, name := range []string{"build", "test"} {
+		if got := findWorkflowStep(t, cancelled, name).State; got != remoteAgentTaskStateCancelled {
+			t.Fatalf("step %s state = %s, want cancelled", name, got)
+		}
+	}
+	if agents := service.ListAgents(); agents[0].CurrentTaskID != "" || agents[1].CurrentTaskID != "" {
+		t.Fatalf("agents still hold tasks after cancel: %+v", agents)
+	}
+	var reqErr *requestError
+	if _, err := service.CancelWorkflow(workflow.ID); !errors.As(err, &reqErr) || reqErr.status != http.StatusConflict {
+		t.Fatalf("second cancel error = %v, want 409", err)
+	}
+
+	reloaded := newRemoteAgentOrchestrationService(newRemoteAgentOrchestrationStore(path), "", nil, nil)
+	restored, ok := reloaded.GetWorkflow(workflow.ID)
+	if !ok || restored.State != remoteAgentWorkflowStateCancelled || findWorkflowStep(t, restored, "build").TaskID != build.TaskID {
+		t.Fatalf("restored workflow = %+v, %v", restored, ok)
+	}
+}
+
+func TestRemoteAgentWorkflow_FailedWorkflowIsFrozenUntilAStepIsRetried(t *testing.T) {
+	service := newRemoteAgentWorkflowFixture(t, newRemoteAgentOrchestrationStore(""), "builder", "tester", "other")
+
+	workflow, err := service.CreateWorkflow("alice", remoteAgentWorkflowCreateRequest{Steps: []remoteAgentWorkflowStepRequest{
+		{Name: "build", Target: remoteAgentTaskTarget{AgentName: "builder"}, Prompt: "Build"},
+		{Name: "test", DependsOn: []string{"build"}, Target: remoteAgentTaskTarget{AgentName: "tester"}, Prompt: "Test"},
+	}})
+	if err != nil {
+		t.Fatalf("create workflow: %v", err)
+	}
+	build := findWorkflowStep(t, workflow, "build")
+	if _, err := service.finishTask(build.TaskID, remoteAgentTaskStateFailed, remoteAgentTaskCompleteRequest{Summary: "compile error"}); err != nil {
+		t.Fatalf("fail build: %v", err)
+	}
+	failed, _ := service.GetWorkflow(workflow.ID)
+
+	// Unrelated scheduling passes leave the failed workflow alone.
+	if _, err := service.DispatchTask("alice", remoteAgentTaskCreateRequest{Target: remoteAgentTaskTarget{AgentName: "other"}, Prompt: "Unrelated"}); err != nil {
+		t.Fatalf("dispatch unrelated task: %v", err)
+	}
+	if got, _ := service.GetWorkflow(workflow.ID); got.State != remoteAgentWorkflowStateFailed || got.LastTransitionAt != failed.LastTransitionAt || got.CompletedAt != failed.CompletedAt {
+		t.Fatalf("failed workflow changed on an unrelated pass: %+v", got)
+	}
+
+	if _, err := service.RetryTask(build.TaskID); err != nil {
+		t.Fatalf("retry build: %v", err)
+	}
+	finishWorkflowStep(t, service, workflow.ID, "build", "")
+	finishWorkflowStep(t, service, workflow.ID, "test", "")
+	if got, _ := service.GetWorkflow(workflow.ID); got.State != remoteAgentWorkflowStateCompleted {
+		t.Fatalf("workflow after retried build = %s, want completed", got.State)
+	}
+}
+
+func TestRemoteAgentWorkflow_RejectsInvalidGraphs(t *testing.T) {
+	service := newRemoteAgentWorkflowFixture(t, newRemoteAgentOrchestrationStore(""), "worker")
+	target := remoteAgentTaskTarget{AgentName: "worker"}
+	for name, steps := range map[string][]remoteAgentWorkflowStepRequest{
+		"cycle": {
+			{Name: "a", DependsOn: []string{"b"}, Target: target, Prompt: "A"},
+			{Name: "b", DependsOn: []string{"a"}, Target: target, Prompt: "B"},
+		},
+		"unknown dependency": {{Name: "a", DependsOn: []string{"missing"}, Target: target, Prompt: "A"}},
+		"duplicate name":     {{Name: "a", Target: target, Prompt: "A"}, {Name: "a", Target: target, Prompt: "A again"}},
+		"missing prompt":     {{Name: "a", Target: target}},
+		"unknown agent":      {{Name: "a", Target: remoteAgentTaskTarget{AgentName: "nobody"}, Prompt: "A"}},
+	} {
+		if _, err := service.CreateWorkflow("alice", remoteAgentWorkflowCreateRequest{Steps: steps}); err == nil {
+			t.Fatalf("%s: expected workflow to be rejected", name)
+		}
+	}
+	if got := service.ListWorkflows(); len(got) != 0 {
+		t.Fatalf("rejected workflows were stored: %+v", got)
+	}
+}
+
+func TestRemoteAgentWorkflow_APICreateGetAndCancel(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-alice", "alice", []string{ScopeRemoteAgentTaskRead, ScopeRemoteAgentTaskWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if _, err := api.RemoteAgentOrchestration.CreatePool(remoteAgentPoolCreateRequest{Name: "reviewers"}); err != nil {
+		t.Fatalf("create pool: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agent-workflows", "alice", map[string]any{
+		"name": "review",
+		"steps": []map[string]any{
+			{"name": "first", "target": map[string]any{"poolName": "reviewers"}, "prompt": "Review"},
+			{"name": "second", "dependsOn": []string{"first"}, "target": map[string]any{"poolName": "reviewers"}, "prompt": "Review again"},
+		},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var workflow remoteAgentWorkflow
+	if err := json.Unmarshal(b, &workflow); err != nil {
+		t.Fatalf("unmarshal workflow: %v", err)
+	}
+	if workflow.RequestedBy == "" || findWorkflowStep(t, workflow, "first").State != remoteAgentTaskStateQueued {
+		t.Fatalf("unexpected workflow: %+v", workflow)
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/remote-agent-workflows", "alice", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var listed struct {
+		RemoteAgentWorkflows []remoteAgentWorkflow `json:"remoteAgentWorkflows"`
+	}
+	if err := json.Unmarshal(b, &listed); err != nil || len(listed.RemoteAgentWorkflows) != 1 {
+		t.Fatalf("list = %s (%v)", string(b), err)
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agent-workflows/"+workflow.ID+"/cancel", "alice", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("cancel status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/remote-agent-workflows/"+workflow.ID, "alice", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var cancelled remoteAgentWorkflow
+	if err := json.Unmarshal(b, &cancelled); err != nil {
+		t.Fatalf("unmarshal workflow: %v", err)
+	}
+	if cancelled.State != remoteAgentWorkflowStateCancelled || findWorkflowStep(t, cancelled, "first").State != remoteAgentTaskStateCancelled {
+		t.Fatalf("workflow after cancel = %+v", cancelled)
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agent-workflows", "alice", map[string]any{
+		"steps": []map[string]any{{"name": "a", "dependsOn": []string{"a"}, "target": map[string]any{"poolName": "reviewers"}, "prompt": "A"}},
+	})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("self-dependency status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+}
+
+func TestRemoteAgentWorkflow_ExternalWorkerFailureBlocksDependants(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-alice", "alice", []string{ScopeRemoteAgentTaskRead, ScopeRemoteAgentTaskWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	if err := api.Tokens.Create(context.Background(), "t-worker", "worker", []string{ScopeRemoteAgentRead, ScopeRemoteAgentWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	for _, name := range []string{"builder", "tester"} {
+		if resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agents", "worker", map[string]any{"name": name}); resp.StatusCode != http.StatusCreated {
+			t.Fatalf("register %s status = %d (body=%s)", name, resp.StatusCode, string(b))
+		}
+	}
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agent-workflows", "alice", map[string]any{
+		"steps": []map[string]any{
+			{"name": "build", "target": map[string]any{"agentName": "builder"}, "prompt": "Build"},
+			{"name": "test", "dependsOn": []string{"build"}, "target": map[string]any{"agentName": "tester"}, "prompt": "Test"},
+		},
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create workflow status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var workflow remoteAgentWorkflow
+	_ = json.Unmarshal(b, &workflow)
+	build := findWorkflowStep(t, workflow, "build")
+
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/remote-agent-tasks/"+build.TaskID+"/fail", "worker", map[string]any{"summary": "compile error"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("fail status = %d (body=%s)", resp.StatusCode, string(b))
+	}
+	var task remoteAgentTask
+	_ = json.Unmarshal(b, &task)
+	if task.State != remoteAgentTaskStateFailed || task.Result == nil || task.Result.Outcome != string(remoteAgentTaskStateFailed) {
+		t.Fatalf("failed task = %+v", task)
+	}
+	workflow, _ = api.RemoteAgentOrchestration.GetWorkflow(workflow.ID)
+	if workflow.State != remoteAgentWorkflowStateFailed || findWorkflowStep(t, workflow, "test").TaskID != "" {
+		t.Fatalf("workflow after external failure = %+v, want failed with test never dispatched", workflow)
+	}
+}
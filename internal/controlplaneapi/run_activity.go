@@ -0,0 +1,94 @@
+package controlplaneapi
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/operator/controllers"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// runActivityInterval throttles last-activity writes per run. It only needs
+// to be small next to idle timeouts.
+const runActivityInterval = 30 * time.Second
+
+// runActivityRecorder stamps status.lastActivityTime on harness runs when
+// their agent session emits events or someone works in an attached
+// terminal, so the operator can stop idle runs.
+type runActivityRecorder struct {
+	namespace string
+	k8s       client.Client
+	now       func() time.Time
+
+	mu   sync.Mutex
+	last map[string]time.Time
+}
+
+func newRunActivityRecorder(namespace string, k8s client.Client) *runActivityRecorder {
+	return &runActivityRecorder{namespace: namespace, k8s: k8s, now: time.Now, last: map[string]time.Time{}}
+}
+
+// due reports whether key has not been recorded within runActivityInterval,
+// and marks it recorded.
+func (r *runActivityRecorder) due(key string, now time.Time) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if last, ok := r.last[key]; ok && now.Sub(last) < runActivityInterval {
+		return false
+	}
+	r.last[key] = now
+	return true
+}
+
+// TouchRun records activity on the run named runID.
+func (r *runActivityRecorder) TouchRun(ctx context.Context, runID string) {
+	if r == nil || r.k8s == nil || runID == "" {
+		return
+	}
+	now := r.now()
+	if !r.due("run/"+runID, now) {
+		return
+	}
+	var run operatorv1alpha1.HarnessRun
+	if err := r.k8s.Get(ctx, client.ObjectKey{Namespace: r.namespace, Name: runID}, &run); err != nil {
+		return
+	}
+	r.touch(ctx, &run, now)
+}
+
+// TouchWorkspaceSession records activity on the active runs of a workspace
+// session, which is what terminal attach is scoped to.
+func (r *runActivityRecorder) TouchWorkspaceSession(ctx context.Context, workspaceSessionID string) {
+	if r == nil || r.k8s == nil || workspaceSessionID == "" {
+		return
+	}
+	now := r.now()
+	if !r.due("workspace-session/"+workspaceSessionID, now) {
+		return
+	}
+	var runs operatorv1alpha1.HarnessRunList
+	if err := r.k8s.List(ctx, &runs, client.InNamespace(r.namespace), client.MatchingLabels{controllers.LabelWorkspaceSessionName: workspaceSessionID}); err != nil {
+		return
+	}
+	for i := range runs.Items {
+		switch runs.Items[i].Status.Phase {
+		case operatorv1alpha1.HarnessRunPhaseStarting, operatorv1alpha1.HarnessRunPhaseRunning:
+			r.touch(ctx, &runs.Items[i], now)
+		}
+	}
+}
+
+func (r *runActivityRecorder) touch(ctx context.Context, run *operatorv1alpha1.HarnessRun, now time.Time) {
+	if last := run.Status.LastActivityTime; last != nil && !now.After(last.Time) {
+		return
+	}
+	updated := run.DeepCopy()
+	updated.Status.LastActivityTime = &metav1.Time{Time: now.UTC()}
+	if err := r.k8s.Status().Patch(ctx, updated, client.MergeFrom(run)); err != nil {
+		slog.Warn("failed to record harness run activity", "run", run.Name, "error", err)
+	}
+}
@@ -0,0 +1,97 @@
+package controlplaneapi
+
+import (
+	"fmt"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+func parseRunResourceMaximums(raw map[string]string) (corev1.ResourceList, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	out := corev1.ResourceList{}
+	for name, value := range raw {
+		q, err := resource.ParseQuantity(value)
+		if err != nil || q.Sign() <= 0 {
+			return nil, fmt.Errorf("run resource maximum %s must be a positive quantity (got %q)", name, value)
+		}
+		out[corev1.ResourceName(name)] = q
+	}
+	if err := checkRunResourceDefaults(out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// checkRunResourceDefaults rejects a maximum below an image profile's default
+// request, which would fail every run of that profile that does not set its
+// own resources.
+func checkRunResourceDefaults(maximums corev1.ResourceList) error {
+	profiles := []operatorv1alpha1.HarnessImageProfile{
+		operatorv1alpha1.HarnessImageProfileBase,
+		operatorv1alpha1.HarnessImageProfileWeb,
+		operatorv1alpha1.HarnessImageProfileGo,
+		operatorv1alpha1.HarnessImageProfileFull,
+	}
+	for _, profile := range profiles {
+		defaults := operatorv1alpha1.DefaultHarnessResources(profile)
+		for _, name := range operatorv1alpha1.HarnessResourceNames {
+			maximum, ok := maximums[name]
+			if !ok {
+				continue
+			}
+			if request, ok := defaults.Requests[name]; ok && request.Cmp(maximum) > 0 {
+				return fmt.Errorf("run resource maximum %s %s is below the %s image profile's default request %s", name, maximum.String(), profile, request.String())
+			}
+		}
+	}
+	return nil
+}
+
+// resolveRunResources returns the harness container resources a new run gets:
+// the request's overrides on top of the selected image profile's defaults.
+// Limits the profile leaves open are capped at the namespace maximum, and
+// anything above a maximum is rejected.
+func (a *API) resolveRunResources(profile *operatorv1alpha1.HarnessImageProfileStatus, override *corev1.ResourceRequirements) (*corev1.ResourceRequirements, error) {
+	if err := operatorv1alpha1.ValidateHarnessResources("resources", override); err != nil {
+		return nil, err
+	}
+	selected := operatorv1alpha1.HarnessImageProfileFull
+	if profile != nil && profile.SelectedProfile != "" {
+		selected = profile.SelectedProfile
+	}
+	resolved := operatorv1alpha1.ResolveHarnessResources(selected, override)
+	for _, name := range operatorv1alpha1.HarnessResourceNames {
+		maximum, ok := a.RunResourceMaximums[name]
+		if !ok {
+			continue
+		}
+		if request, ok := resolved.Requests[name]; ok && request.Cmp(maximum) > 0 {
+			return nil, fmt.Errorf("resources.requests.%s %s exceeds the namespace maximum %s", name, request.String(), maximum.String())
+		}
+		limit, ok := resolved.Limits[name]
+		if !ok {
+			if resolved.Limits == nil {
+				resolved.Limits = corev1.ResourceList{}
+			}
+			resolved.Limits[name] = maximum.DeepCopy()
+			continue
+		}
+		if limit.Cmp(maximum) <= 0 {
+			continue
+		}
+		explicit := false
+		if override != nil {
+			_, explicit = override.Limits[name]
+		}
+		if explicit {
+			return nil, fmt.Errorf("resources.limits.%s %s exceeds the namespace maximum %s", name, limit.String(), maximum.String())
+		}
+		// A profile default above the maximum is lowered to it.
+		resolved.Limits[name] = maximum.DeepCopy()
+	}
+	return &resolved, nil
+}
@@ -0,0 +1,75 @@
+package controlplaneapi
+
+import (
+	"context"
+	"net/http"
+	"strings"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/operator/controllers"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// runClassScheduling returns the scheduling of a run that picked an
+// admin-defined run class, or nil when it picked none. Whether the caller may
+// use the class is checked by the operator against the run's ownership stamp.
+func runClassScheduling(raw string) (*operatorv1alpha1.HarnessRunSchedulingSpec, error) {
+	name := strings.TrimSpace(raw)
+	if name == "" {
+		return nil, nil
+	}
+	if errs := validation.IsConfigMapKey(name); len(errs) != 0 {
+		return nil, &requestError{status: http.StatusBadRequest, msg: "invalid runClass"}
+	}
+	return &operatorv1alpha1.HarnessRunSchedulingSpec{RunClass: name}, nil
+}
+
+// checkRequestScheduling lets only admins set raw node selectors,
+// tolerations, affinity and priority classes; everyone else picks a run class.
+func checkRequestScheduling(ctx context.Context, field string, scheduling *operatorv1alpha1.HarnessRunSchedulingSpec) error {
+	if scheduling == nil {
+		return nil
+	}
+	if name := strings.TrimSpace(scheduling.RunClass); name != "" && len(validation.IsConfigMapKey(name)) != 0 {
+		return &requestError{status: http.StatusBadRequest, msg: "invalid " + field + ".runClass"}
+	}
+	raw := len(scheduling.NodeSelector) != 0 || len(scheduling.Tolerations) != 0 || scheduling.Affinity != nil || strings.TrimSpace(scheduling.PriorityClassName) != ""
+	if p, _ := principalFrom(ctx); raw && !isAdmin(p) {
+		return &requestError{status: http.StatusForbidden, msg: field + " accepts only runClass unless the caller is an admin"}
+	}
+	return nil
+}
+
+// checkRunClassChange requires the caller to be allowed the run class an
+// existing resource is switched to. Runs inherit the resource's original
+// owner stamp, so the operator alone would check the wrong principal.
+func (a *API) checkRunClassChange(ctx context.Context, field string, before, after *operatorv1alpha1.HarnessRunSchedulingSpec) error {
+	name := schedulingRunClass(after)
+	if name == "" || name == schedulingRunClass(before) {
+		return nil
+	}
+	p, _ := principalFrom(ctx)
+	if isAdmin(p) {
+		return nil
+	}
+	owner, teams := "", []string(nil)
+	if p != nil {
+		owner, teams = p.Actor(), p.Teams
+	}
+	err := controllers.CheckRunClassAccess(ctx, a.K8s, a.Namespace, name, owner, teams)
+	switch {
+	case err == nil:
+		return nil
+	case controllers.IsRunClassDenied(err):
+		return &requestError{status: http.StatusForbidden, msg: field + ".runClass: " + err.Error()}
+	default:
+		return &requestError{status: http.StatusInternalServerError, msg: "check run class failed", err: err}
+	}
+}
+
+func schedulingRunClass(scheduling *operatorv1alpha1.HarnessRunSchedulingSpec) string {
+	if scheduling == nil {
+		return ""
+	}
+	return strings.TrimSpace(scheduling.RunClass)
+}
@@ -10,7 +10,9 @@ import (
 	"time"
 
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/operator/controllers"
 	corev1 "k8s.io/api/core/v1"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -19,13 +21,15 @@ import (
 var symphonySecretNameCleaner = regexp.MustCompile(`[^a-z0-9-]+`)
 
 type symphonyProjectSourceRequest struct {
-	Project         operatorv1alpha1.GitHubProjectRef `json:"project"`
-	TokenSecretRef  operatorv1alpha1.SecretKeyRef     `json:"tokenSecretRef"`
-	GitHubToken     string                            `json:"githubToken,omitempty"`
-	ActiveStates    []string                          `json:"activeStates,omitempty"`
-	TerminalStates  []string                          `json:"terminalStates,omitempty"`
-	FieldName       string                            `json:"fieldName,omitempty"`
-	PollIntervalSec int32                             `json:"pollIntervalSeconds,omitempty"`
+	Kind            operatorv1alpha1.SymphonySourceKind `json:"kind,omitempty"`
+	Project         operatorv1alpha1.GitHubProjectRef   `json:"project"`
+	Linear          *operatorv1alpha1.LinearProjectRef  `json:"linear,omitempty"`
+	TokenSecretRef  operatorv1alpha1.SecretKeyRef       `json:"tokenSecretRef"`
+	GitHubToken     string                              `json:"githubToken,omitempty"`
+	ActiveStates    []string                            `json:"activeStates,omitempty"`
+	TerminalStates  []string                            `json:"terminalStates,omitempty"`
+	FieldName       string                              `json:"fieldName,omitempty"`
+	PollIntervalSec int32                               `json:"pollIntervalSeconds,omitempty"`
 }
 
 type symphonyProjectSpecRequest struct {
@@ -33,6 +37,9 @@ type symphonyProjectSpecRequest struct {
 	Source       symphonyProjectSourceRequest                     `json:"source"`
 	Repositories []operatorv1alpha1.SymphonyProjectRepositorySpec `json:"repositories"`
 	Runtime      operatorv1alpha1.SymphonyProjectRuntimeSpec      `json:"runtime"`
+	WriteBack    *operatorv1alpha1.SymphonyProjectWriteBackSpec   `json:"writeBack,omitempty"`
+	Priority     *operatorv1alpha1.SymphonyProjectPrioritySpec    `json:"priority,omitempty"`
+	PullRequests *operatorv1alpha1.SymphonyProjectPullRequestSpec `json:"pullRequests,omitempty"`
 }
 
 type symphonyProjectRequest struct {
@@ -94,15 +101,21 @@ func (a *API) handleSymphonyProjectsCreate(w http.ResponseWriter, r *http.Reques
 		return
 	}
 	spec := req.Spec.toSpec()
+	if err := checkRequestScheduling(r.Context(), "runtime.scheduling", spec.Runtime.Scheduling); err != nil {
+		writeJSONError(w, err)
+		return
+	}
 	if err := a.prepareSymphonySourceSecret(r.Context(), name, &req.Spec.Source, &spec.Source); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
 	}
 	project := &operatorv1alpha1.SymphonyProject{
 		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "SymphonyProject"},
-		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: a.Namespace},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: a.Namespace, Labels: map[string]string{}, Annotations: map[string]string{}},
 		Spec:       spec,
 	}
+	// The project's runs inherit this stamp, which run classes are checked against.
+	stampOwner(r.Context(), project.Labels, project.Annotations, "")
 	project.ApplyDefaults()
 	if err := project.Validate(); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
@@ -116,10 +129,20 @@ func (a *API) handleSymphonyProjectsCreate(w http.ResponseWriter, r *http.Reques
 		writeError(w, http.StatusInternalServerError, "create symphony project failed")
 		return
 	}
-	appendSymphonyAudit(r.Context(), a.Audit, "api", "symphony.create", project.Name, "allowed", map[string]any{"repositoryCount": len(project.Spec.Repositories), "project": map[string]any{"owner": project.Spec.Source.Project.Owner, "number": project.Spec.Source.Project.Number}})
+	appendSymphonyAudit(r.Context(), a.Audit, "api", "symphony.create", project.Name, "allowed", symphonyCreateAuditDetails(project))
 	writeJSON(w, http.StatusCreated, symphonyProjectToResponse(project))
 }
 
+func symphonyCreateAuditDetails(project *operatorv1alpha1.SymphonyProject) map[string]any {
+	details := map[string]any{"repositoryCount": len(project.Spec.Repositories), "sourceKind": string(project.Spec.Source.Kind)}
+	if project.Spec.Source.Kind == operatorv1alpha1.SymphonySourceKindLinear && project.Spec.Source.Linear != nil {
+		details["linear"] = map[string]any{"teamKey": project.Spec.Source.Linear.TeamKey, "projectName": project.Spec.Source.Linear.ProjectName}
+		return details
+	}
+	details["project"] = map[string]any{"owner": project.Spec.Source.Project.Owner, "number": project.Spec.Source.Project.Number}
+	return details
+}
+
 func (a *API) handleSymphonyProjectGet(w http.ResponseWriter, r *http.Request, name string) {
 	project, err := a.getSymphonyProject(r.Context(), name)
 	if err != nil {
@@ -142,6 +165,17 @@ func (a *API) handleSymphonyProjectPatch(w http.ResponseWriter, r *http.Request,
 	}
 	updated := project.DeepCopy()
 	updated.Spec = req.Spec.toSpec()
+	// Scheduling an admin already set may be sent back unchanged.
+	if !apiequality.Semantic.DeepEqual(project.Spec.Runtime.Scheduling, updated.Spec.Runtime.Scheduling) {
+		if err := checkRequestScheduling(r.Context(), "runtime.scheduling", updated.Spec.Runtime.Scheduling); err != nil {
+			writeJSONError(w, err)
+			return
+		}
+	}
+	if err := a.checkRunClassChange(r.Context(), "runtime.scheduling", project.Spec.Runtime.Scheduling, updated.Spec.Runtime.Scheduling); err != nil {
+		writeJSONError(w, err)
+		return
+	}
 	if err := a.prepareSymphonySourceSecret(r.Context(), updated.Name, &req.Spec.Source, &updated.Spec.Source); err != nil {
 		writeError(w, http.StatusBadRequest, err.Error())
 		return
@@ -160,10 +194,17 @@ func (a *API) handleSymphonyProjectPatch(w http.ResponseWriter, r *http.Request,
 }
 
 func (req symphonyProjectSpecRequest) toSpec() operatorv1alpha1.SymphonyProjectSpec {
+	var linear *operatorv1alpha1.LinearProjectRef
+	if req.Source.Linear != nil {
+		ref := *req.Source.Linear
+		linear = &ref
+	}
 	return operatorv1alpha1.SymphonyProjectSpec{
 		Paused: req.Paused,
 		Source: operatorv1alpha1.SymphonyProjectSourceSpec{
+			Kind:            req.Source.Kind,
 			Project:         req.Source.Project,
+			Linear:          linear,
 			TokenSecretRef:  req.Source.TokenSecretRef,
 			ActiveStates:    append([]string(nil), req.Source.ActiveStates...),
 			TerminalStates:  append([]string(nil), req.Source.TerminalStates...),
@@ -172,6 +213,9 @@ func (req symphonyProjectSpecRequest) toSpec() operatorv1alpha1.SymphonyProjectS
 		},
 		Repositories: append([]operatorv1alpha1.SymphonyProjectRepositorySpec(nil), req.Repositories...),
 		Runtime:      req.Runtime,
+		WriteBack:    req.WriteBack,
+		Priority:     req.Priority,
+		PullRequests: req.PullRequests,
 	}
 }
 
@@ -184,6 +228,9 @@ func (a *API) prepareSymphonySourceSecret(ctx context.Context, projectName strin
 		secretKey = "token"
 	}
 	githubToken := strings.TrimSpace(req.GitHubToken)
+	if githubToken != "" && req.Kind == operatorv1alpha1.SymphonySourceKindLinear {
+		return fmt.Errorf("spec.source.githubToken is not supported for linear sources; store the Linear API key in a Secret and set spec.source.tokenSecretRef")
+	}
 	if githubToken != "" {
 		secretName := deriveSymphonySecretName(projectName, req.Project.Owner)
 		if err := a.upsertSymphonyTokenSecret(ctx, secretName, secretKey, githubToken, projectName, req.Project.Owner); err != nil {
@@ -308,6 +355,57 @@ func (a *API) handleSymphonyProjectRefresh(w http.ResponseWriter, r *http.Reques
 	writeJSON(w, http.StatusOK, symphonyProjectToResponse(updated))
 }
 
+// symphonyPlanResponse pairs the latest plan request with the operator's
+// answer. Ready is false until the plan answers the current request.
+type symphonyPlanResponse struct {
+	Name        string                                      `json:"name"`
+	RequestedAt string                                      `json:"requestedAt,omitempty"`
+	Ready       bool                                        `json:"ready"`
+	Plan        *operatorv1alpha1.SymphonyProjectPlanStatus `json:"plan,omitempty"`
+}
+
+func symphonyProjectToPlanResponse(project *operatorv1alpha1.SymphonyProject) symphonyPlanResponse {
+	requestedAt := strings.TrimSpace(project.Annotations[controllers.AnnotationSymphonyPlanRequestedAt])
+	plan := project.Status.Plan
+	return symphonyPlanResponse{
+		Name:        project.Name,
+		RequestedAt: requestedAt,
+		Ready:       plan != nil && requestedAt != "" && plan.RequestedAt == requestedAt,
+		Plan:        plan,
+	}
+}
+
+// handleSymphonyProjectPlanRequest asks the operator for a dry-run sync. The
+// operator holds the source token and WORKFLOW.md checkouts, so the plan is
+// answered asynchronously; poll GET .../plan until it is ready.
+func (a *API) handleSymphonyProjectPlanRequest(w http.ResponseWriter, r *http.Request, name string) {
+	project, err := a.getSymphonyProject(r.Context(), name)
+	if err != nil {
+		a.writeSymphonyProjectError(w, err, "get symphony project failed")
+		return
+	}
+	updated := project.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[controllers.AnnotationSymphonyPlanRequestedAt] = time.Now().UTC().Format(time.RFC3339Nano)
+	if err := a.K8s.Patch(r.Context(), updated, client.MergeFrom(project)); err != nil {
+		writeError(w, http.StatusInternalServerError, "plan symphony project failed")
+		return
+	}
+	appendSymphonyAudit(r.Context(), a.Audit, "api", "symphony.plan", updated.Name, "allowed", map[string]any{"requestedAt": updated.Annotations[controllers.AnnotationSymphonyPlanRequestedAt]})
+	writeJSON(w, http.StatusAccepted, symphonyProjectToPlanResponse(updated))
+}
+
+func (a *API) handleSymphonyProjectPlanGet(w http.ResponseWriter, r *http.Request, name string) {
+	project, err := a.getSymphonyProject(r.Context(), name)
+	if err != nil {
+		a.writeSymphonyProjectError(w, err, "get symphony project failed")
+		return
+	}
+	writeJSON(w, http.StatusOK, symphonyProjectToPlanResponse(project))
+}
+
 func (a *API) getSymphonyProject(ctx context.Context, name string) (*operatorv1alpha1.SymphonyProject, error) {
 	project := &operatorv1alpha1.SymphonyProject{}
 	err := a.K8s.Get(ctx, client.ObjectKey{Namespace: a.Namespace, Name: strings.TrimSpace(name)}, project)
@@ -0,0 +1,394 @@
+package controlplaneapi
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// githubWebhookMaxBodyBytes matches GitHub's own payload cap.
+	githubWebhookMaxBodyBytes = 25 << 20
+	// githubWebhookMaxDeliveries bounds the delivery log used for dedupe.
+	githubWebhookMaxDeliveries = 1000
+
+	githubWebhookOutcomeTriggered = "triggered"
+	githubWebhookOutcomeIgnored   = "ignored"
+	githubWebhookOutcomeError     = "error"
+)
+
+// githubWebhookDelivery is one entry of the delivery log. GitHub redelivers
+// with the same delivery ID, so an ID that already triggered or was ignored
+// is acknowledged without another refresh; errored deliveries may be retried.
+type githubWebhookDelivery struct {
+	DeliveryID string    `json:"deliveryId"`
+	Event      string    `json:"event"`
+	Action     string    `json:"action,omitempty"`
+	ReceivedAt time.Time `json:"receivedAt"`
+	Outcome    string    `json:"outcome"`
+	Reason     string    `json:"reason,omitempty"`
+	Projects   []string  `json:"projects,omitempty"`
+	Duplicates int       `json:"duplicates,omitempty"`
+}
+
+// GitHubWebhookService verifies GitHub webhook deliveries and turns the ones
+// that concern a SymphonyProject into an immediate refresh.
+type GitHubWebhookService struct {
+	secret []byte
+
+	// mu guards the delivery log and inFlight. It is never held across
+	// Kubernetes calls; inFlight keeps a concurrent redelivery from
+	// refreshing twice instead.
+	mu         sync.Mutex
+	path       string
+	deliveries []githubWebhookDelivery
+	inFlight   map[string]struct{}
+	// logged counts the records in the file at path, which is rewritten
+	// with just the retained deliveries once it holds twice as many.
+	logged int
+}
+
+func newGitHubWebhookService(secret, path string) *GitHubWebhookService {
+	s := &GitHubWebhookService{secret: []byte(secret), path: path, inFlight: map[string]struct{}{}}
+	s.load()
+	return s
+}
+
+func githubWebhookDeliveryLogPath(auditPath string) string {
+	if auditPath == "" {
+		return ""
+	}
+	dir := filepath.Dir(auditPath)
+	return filepath.Join(dir, "kocao.github_webhook_deliveries.jsonl")
+}
+
+// load replays the persisted log; later records for a delivery ID win.
+func (s *GitHubWebhookService) load() {
+	if s.path == "" {
+		return
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return
+	}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec githubWebhookDelivery
+		s.logged++
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.DeliveryID == "" {
+			continue
+		}
+		s.rememberLocked(rec)
+	}
+	_ = f.Close()
+	if s.logged > 2*githubWebhookMaxDeliveries {
+		s.compactLocked()
+	}
+}
+
+func (s *GitHubWebhookService) lookupLocked(deliveryID string) (int, bool) {
+	for i := len(s.deliveries) - 1; i >= 0; i-- {
+		if s.deliveries[i].DeliveryID == deliveryID {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *GitHubWebhookService) rememberLocked(rec githubWebhookDelivery) {
+	if i, ok := s.lookupLocked(rec.DeliveryID); ok {
+		s.deliveries = append(s.deliveries[:i], s.deliveries[i+1:]...)
+	}
+	s.deliveries = append(s.deliveries, rec)
+	if len(s.deliveries) > githubWebhookMaxDeliveries {
+		s.deliveries = s.deliveries[len(s.deliveries)-githubWebhookMaxDeliveries:]
+	}
+}
+
+func (s *GitHubWebhookService) recordLocked(rec githubWebhookDelivery) {
+	s.rememberLocked(rec)
+	if s.path == "" {
+		return
+	}
+	_ = os.MkdirAll(filepath.Dir(s.path), 0o755)
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if err := json.NewEncoder(f).Encode(rec); err != nil {
+		return
+	}
+	s.logged++
+	if s.logged > 2*githubWebhookMaxDeliveries {
+		s.compactLocked()
+	}
+}
+
+// compactLocked replaces the log file with the retained deliveries, writing
+// a temporary file first so a crash leaves either the old or the new log.
+func (s *GitHubWebhookService) compactLocked() {
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), filepath.Base(s.path)+".tmp-*")
+	if err != nil {
+		return
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	w := bufio.NewWriter(tmp)
+	enc := json.NewEncoder(w)
+	for _, rec := range s.deliveries {
+		if err := enc.Encode(rec); err != nil {
+			_ = tmp.Close()
+			return
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = tmp.Close()
+		return
+	}
+	if err := tmp.Close(); err != nil {
+		return
+	}
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return
+	}
+	if err := os.Rename(tmp.Name(), s.path); err != nil {
+		return
+	}
+	s.logged = len(s.deliveries)
+}
+
+// begin claims a delivery for processing. It returns "duplicate" for a
+// delivery that already triggered or was ignored, "in_progress" while another
+// request handles the same ID, and "" once the caller owns the delivery and
+// must hand it to finish.
+func (s *GitHubWebhookService) begin(deliveryID string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, busy := s.inFlight[deliveryID]; busy {
+		return "in_progress"
+	}
+	if i, ok := s.lookupLocked(deliveryID); ok && s.deliveries[i].Outcome != githubWebhookOutcomeError {
+		prior := s.deliveries[i]
+		prior.Duplicates++
+		s.recordLocked(prior)
+		return "duplicate"
+	}
+	s.inFlight[deliveryID] = struct{}{}
+	return ""
+}
+
+// finish records the outcome of a delivery claimed by begin.
+func (s *GitHubWebhookService) finish(rec githubWebhookDelivery) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.inFlight, rec.DeliveryID)
+	s.recordLocked(rec)
+}
+
+// Deliveries returns the delivery log, newest first.
+func (s *GitHubWebhookService) Deliveries() []githubWebhookDelivery {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]githubWebhookDelivery, len(s.deliveries))
+	for i := range s.deliveries {
+		out[len(out)-1-i] = s.deliveries[i]
+	}
+	return out
+}
+
+// verify checks the X-Hub-Signature-256 header against the body.
+func (s *GitHubWebhookService) verify(body []byte, header string) bool {
+	got, ok := strings.CutPrefix(strings.TrimSpace(header), "sha256=")
+	if !ok {
+		return false
+	}
+	sig, err := hex.DecodeString(got)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, s.secret)
+	_, _ = mac.Write(body)
+	return hmac.Equal(sig, mac.Sum(nil))
+}
+
+// githubWebhookPayload holds the fields used to route an event to projects.
+type githubWebhookPayload struct {
+	Action         string `json:"action"`
+	ProjectsV2Item *struct {
+		ProjectNodeID string `json:"project_node_id"`
+	} `json:"projects_v2_item"`
+	Organization *struct {
+		Login string `json:"login"`
+	} `json:"organization"`
+	Repository *struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+// matchSymphonyProjects returns the unpaused GitHub-sourced projects an event
+// concerns. Project item events match on the project node ID recorded by the
+// operator, falling back to the owning organization until the first sync has
+// recorded it; issue events match on the configured repositories.
+func matchSymphonyProjects(event string, payload githubWebhookPayload, projects []operatorv1alpha1.SymphonyProject) []*operatorv1alpha1.SymphonyProject {
+	var out []*operatorv1alpha1.SymphonyProject
+	for i := range projects {
+		project := &projects[i]
+		if project.Spec.Paused {
+			continue
+		}
+		if kind := project.Spec.Source.Kind; kind != "" && kind != operatorv1alpha1.SymphonySourceKindGitHub {
+			continue
+		}
+		switch event {
+		case "projects_v2_item":
+			if payload.ProjectsV2Item == nil {
+				continue
+			}
+			nodeID := strings.TrimSpace(payload.ProjectsV2Item.ProjectNodeID)
+			if project.Status.ProjectNodeID != "" {
+				if nodeID != "" && project.Status.ProjectNodeID == nodeID {
+					out = append(out, project)
+				}
+				continue
+			}
+			if payload.Organization != nil && strings.EqualFold(strings.TrimSpace(payload.Organization.Login), strings.TrimSpace(project.Spec.Source.Project.Owner)) {
+				out = append(out, project)
+			}
+		case "issues", "issue_comment":
+			if payload.Repository == nil {
+				continue
+			}
+			repo := strings.ToLower(strings.TrimSpace(payload.Repository.FullName))
+			for _, candidate := range project.Spec.Repositories {
+				if repo != "" && candidate.RepositoryKey() == repo {
+					out = append(out, project)
+					break
+				}
+			}
+		}
+	}
+	return out
+}
+
+func (a *API) handleGitHubWebhook(w http.ResponseWriter, r *http.Request) {
+	svc := a.GitHubWebhooks
+	if svc == nil {
+		writeError(w, http.StatusNotImplemented, "github webhook secret not configured")
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, githubWebhookMaxBodyBytes))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			writeError(w, http.StatusRequestEntityTooLarge, "webhook payload too large")
+			return
+		}
+		writeError(w, http.StatusBadRequest, "read webhook payload failed")
+		return
+	}
+	event := strings.TrimSpace(r.Header.Get("X-GitHub-Event"))
+	deliveryID := strings.TrimSpace(r.Header.Get("X-GitHub-Delivery"))
+	if !svc.verify(body, r.Header.Get("X-Hub-Signature-256")) {
+		appendSymphonyAudit(r.Context(), a.Audit, "github", "symphony.webhook", deliveryID, "denied", map[string]any{"event": event, "reason": "invalid signature"})
+		writeError(w, http.StatusUnauthorized, "invalid webhook signature")
+		return
+	}
+	if deliveryID == "" || event == "" {
+		writeError(w, http.StatusBadRequest, "X-GitHub-Event and X-GitHub-Delivery headers required")
+		return
+	}
+
+	var payload githubWebhookPayload
+	supported := false
+	switch event {
+	case "projects_v2_item", "issues", "issue_comment":
+		if err := json.Unmarshal(body, &payload); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid webhook payload")
+			return
+		}
+		supported = true
+	}
+
+	switch svc.begin(deliveryID) {
+	case "duplicate":
+		writeJSON(w, http.StatusOK, map[string]any{"deliveryId": deliveryID, "outcome": "duplicate"})
+		return
+	case "in_progress":
+		writeError(w, http.StatusConflict, "webhook delivery already in progress")
+		return
+	}
+	delivery := githubWebhookDelivery{DeliveryID: deliveryID, Event: event, Action: payload.Action, ReceivedAt: time.Now().UTC()}
+	if !supported {
+		delivery.Outcome = githubWebhookOutcomeIgnored
+		delivery.Reason = "unsupported event"
+		svc.finish(delivery)
+		writeJSON(w, http.StatusOK, delivery)
+		return
+	}
+
+	var list operatorv1alpha1.SymphonyProjectList
+	if err := a.K8s.List(r.Context(), &list, client.InNamespace(a.Namespace)); err != nil {
+		delivery.Outcome = githubWebhookOutcomeError
+		delivery.Reason = "list symphony projects failed"
+		svc.finish(delivery)
+		writeError(w, http.StatusInternalServerError, "list symphony projects failed")
+		return
+	}
+	matched := matchSymphonyProjects(event, payload, list.Items)
+	if len(matched) == 0 {
+		delivery.Outcome = githubWebhookOutcomeIgnored
+		delivery.Reason = "no matching symphony project"
+		svc.finish(delivery)
+		writeJSON(w, http.StatusOK, delivery)
+		return
+	}
+
+	requestedAt := delivery.ReceivedAt.Format(time.RFC3339Nano)
+	var failed []string
+	for _, project := range matched {
+		updated := project.DeepCopy()
+		if updated.Annotations == nil {
+			updated.Annotations = map[string]string{}
+		}
+		updated.Annotations[annotationSymphonyRefreshRequestedAt] = requestedAt
+		if err := a.K8s.Patch(r.Context(), updated, client.MergeFrom(project)); err != nil {
+			failed = append(failed, project.Name)
+			continue
+		}
+		delivery.Projects = append(delivery.Projects, project.Name)
+		appendSymphonyAudit(r.Context(), a.Audit, "github", "symphony.webhook", project.Name, "allowed", map[string]any{"event": event, "action": payload.Action, "deliveryID": deliveryID, "requestedAt": requestedAt})
+	}
+	sort.Strings(delivery.Projects)
+	if len(failed) != 0 {
+		delivery.Outcome = githubWebhookOutcomeError
+		delivery.Reason = "refresh failed for " + strings.Join(failed, ", ")
+		svc.finish(delivery)
+		writeError(w, http.StatusInternalServerError, "refresh symphony project failed")
+		return
+	}
+	delivery.Outcome = githubWebhookOutcomeTriggered
+	svc.finish(delivery)
+	writeJSON(w, http.StatusAccepted, delivery)
+}
+
+func (a *API) handleGitHubWebhookDeliveries(w http.ResponseWriter, _ *http.Request) {
+	if a.GitHubWebhooks == nil {
+		writeJSON(w, http.StatusOK, map[string]any{"deliveries": []githubWebhookDelivery{}})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deliveries": a.GitHubWebhooks.Deliveries()})
+}
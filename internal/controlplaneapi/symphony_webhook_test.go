@@ -0,0 +1,205 @@
+package controlplaneapi
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const testGitHubWebhookSecret = "webhook-secret"
+
+func postGitHubWebhook(t *testing.T, c *http.Client, url, event, deliveryID, secret string, payload any) (*http.Response, []byte) {
+	t.Helper()
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatalf("encode payload: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("new request: %v", err)
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	_, _ = mac.Write(body)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-GitHub-Event", event)
+	req.Header.Set("X-GitHub-Delivery", deliveryID)
+	req.Header.Set("X-Hub-Signature-256", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("do request: %v", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, b
+}
+
+func newWebhookTestProject(name, owner, nodeID string, repos ...string) *operatorv1alpha1.SymphonyProject {
+	project := &operatorv1alpha1.SymphonyProject{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "test-ns"},
+		Spec: operatorv1alpha1.SymphonyProjectSpec{
+			Source: operatorv1alpha1.SymphonyProjectSourceSpec{Project: operatorv1alpha1.GitHubProjectRef{Owner: owner, Number: 1}},
+		},
+		Status: operatorv1alpha1.SymphonyProjectStatus{ProjectNodeID: nodeID},
+	}
+	for _, repo := range repos {
+		project.Spec.Repositories = append(project.Spec.Repositories, operatorv1alpha1.SymphonyProjectRepositorySpec{Owner: owner, Name: repo})
+	}
+	return project
+}
+
+func refreshRequestedAt(t *testing.T, api *API, name string) string {
+	t.Helper()
+	var stored operatorv1alpha1.SymphonyProject
+	if err := api.K8s.Get(context.Background(), client.ObjectKey{Namespace: api.Namespace, Name: name}, &stored); err != nil {
+		t.Fatalf("get symphony project %s: %v", name, err)
+	}
+	return stored.Annotations[annotationSymphonyRefreshRequestedAt]
+}
+
+func TestGitHubWebhook_RoutesEventsAndDedupesDeliveries(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	api.GitHubWebhooks = newGitHubWebhookService(testGitHubWebhookSecret, "")
+	for _, project := range []*operatorv1alpha1.SymphonyProject{
+		newWebhookTestProject("board", "withakay", "PVT_board", "kocao"),
+		newWebhookTestProject("other", "withakay", "PVT_other", "other"),
+	} {
+		if err := api.K8s.Create(context.Background(), project); err != nil {
+			t.Fatalf("create project: %v", err)
+		}
+	}
+	if err := api.Tokens.Create(context.Background(), "t-symphony", "symphony", []string{ScopeSymphonyProjectRead}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+	url := srv.URL + "/api/v1/webhooks/github"
+
+	item := map[string]any{"action": "edited", "projects_v2_item": map[string]any{"project_node_id": "PVT_board"}, "organization": map[string]any{"login": "withakay"}}
+	resp, b := postGitHubWebhook(t, srv.Client(), url, "projects_v2_item", "d-1", testGitHubWebhookSecret, item)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("projects_v2_item status = %d, want 202 (body=%s)", resp.StatusCode, string(b))
+	}
+	first := refreshRequestedAt(t, api, "board")
+	if first == "" {
+		t.Fatalf("expected board to be refreshed")
+	}
+	if refreshRequestedAt(t, api, "other") != "" {
+		t.Fatalf("expected other project to be left alone")
+	}
+
+	resp, b = postGitHubWebhook(t, srv.Client(), url, "projects_v2_item", "d-1", testGitHubWebhookSecret, item)
+	if resp.StatusCode != http.StatusOK || !bytes.Contains(b, []byte(`"duplicate"`)) {
+		t.Fatalf("redelivery status = %d body=%s, want 200 duplicate", resp.StatusCode, string(b))
+	}
+	if refreshRequestedAt(t, api, "board") != first {
+		t.Fatalf("expected redelivery not to refresh again")
+	}
+
+	comment := map[string]any{"action": "created", "repository": map[string]any{"full_name": "WithAkay/Other"}}
+	resp, b = postGitHubWebhook(t, srv.Client(), url, "issue_comment", "d-2", testGitHubWebhookSecret, comment)
+	if resp.StatusCode != http.StatusAccepted {
+		t.Fatalf("issue_comment status = %d, want 202 (body=%s)", resp.StatusCode, string(b))
+	}
+	if refreshRequestedAt(t, api, "other") == "" {
+		t.Fatalf("expected repository match to refresh other project")
+	}
+
+	resp, b = postGitHubWebhook(t, srv.Client(), url, "push", "d-3", testGitHubWebhookSecret, map[string]any{})
+	if resp.StatusCode != http.StatusOK || !bytes.Contains(b, []byte(`"ignored"`)) {
+		t.Fatalf("push status = %d body=%s, want 200 ignored", resp.StatusCode, string(b))
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, url+"/deliveries", "symphony", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("deliveries status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	var out struct {
+		Deliveries []githubWebhookDelivery `json:"deliveries"`
+	}
+	if err := json.Unmarshal(b, &out); err != nil {
+		t.Fatalf("decode deliveries: %v", err)
+	}
+	if len(out.Deliveries) != 3 || out.Deliveries[0].DeliveryID != "d-3" {
+		t.Fatalf("deliveries = %+v, want 3 newest first", out.Deliveries)
+	}
+	for _, d := range out.Deliveries {
+		if d.DeliveryID == "d-1" && (d.Duplicates != 1 || d.Outcome != githubWebhookOutcomeTriggered || len(d.Projects) != 1 || d.Projects[0] != "board") {
+			t.Fatalf("d-1 delivery = %+v", d)
+		}
+	}
+}
+
+func TestGitHubWebhook_RejectsBadSignatureAndPausedProjects(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+	url := srv.URL + "/api/v1/webhooks/github"
+	payload := map[string]any{"action": "opened", "repository": map[string]any{"full_name": "withakay/kocao"}}
+
+	resp, _ := postGitHubWebhook(t, srv.Client(), url, "issues", "d-1", testGitHubWebhookSecret, payload)
+	if resp.StatusCode != http.StatusNotImplemented {
+		t.Fatalf("unconfigured webhook status = %d, want 501", resp.StatusCode)
+	}
+
+	api.GitHubWebhooks = newGitHubWebhookService(testGitHubWebhookSecret, "")
+	project := newWebhookTestProject("paused", "withakay", "", "kocao")
+	project.Spec.Paused = true
+	if err := api.K8s.Create(context.Background(), project); err != nil {
+		t.Fatalf("create project: %v", err)
+	}
+
+	resp, _ = postGitHubWebhook(t, srv.Client(), url, "issues", "d-1", "wrong-secret", payload)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("bad signature status = %d, want 401", resp.StatusCode)
+	}
+	resp, b := postGitHubWebhook(t, srv.Client(), url, "issues", "d-1", testGitHubWebhookSecret, payload)
+	if resp.StatusCode != http.StatusOK || !bytes.Contains(b, []byte("no matching symphony project")) {
+		t.Fatalf("paused project status = %d body=%s, want 200 ignored", resp.StatusCode, string(b))
+	}
+	if refreshRequestedAt(t, api, "paused") != "" {
+		t.Fatalf("expected paused project not to be refreshed")
+	}
+}
+
+func TestGitHubWebhook_DeliveryLogIsCompacted(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "deliveries.jsonl")
+	svc := newGitHubWebhookService("secret", path)
+	total := 2*githubWebhookMaxDeliveries + 10
+	for i := 0; i < total; i++ {
+		svc.finish(githubWebhookDelivery{DeliveryID: fmt.Sprintf("d-%d", i), Event: "issues", ReceivedAt: time.Now().UTC(), Outcome: githubWebhookOutcomeIgnored})
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("read log: %v", err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines > 2*githubWebhookMaxDeliveries {
+		t.Fatalf("delivery log has %d lines, want at most %d", lines, 2*githubWebhookMaxDeliveries)
+	}
+
+	reloaded := newGitHubWebhookService("secret", path)
+	deliveries := reloaded.Deliveries()
+	if len(deliveries) != githubWebhookMaxDeliveries || deliveries[0].DeliveryID != fmt.Sprintf("d-%d", total-1) {
+		t.Fatalf("reloaded %d deliveries (newest %q), want %d ending at d-%d", len(deliveries), deliveries[0].DeliveryID, githubWebhookMaxDeliveries, total-1)
+	}
+	if reloaded.begin(fmt.Sprintf("d-%d", total-1)) != "duplicate" {
+		t.Fatal("retained delivery was not deduplicated after compaction")
+	}
+}
@@ -1,30 +1,91 @@
 package controlplaneapi
 
 import (
+	"bufio"
 	"context"
+	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"time"
 )
 
+// tokenLastUsedPersistInterval bounds how often a managed token's last-used
+// timestamp is flushed to the durable store; lookups always update memory.
+const tokenLastUsedPersistInterval = time.Minute
+
 type TokenRecord struct {
-	ID        string
-	Hash      string
-	Scopes    string
+	ID         string            `json:"id"`
+	Name       string            `json:"name,omitempty"`
+	Hash       string            `json:"hash"`
+	Scopes     string            `json:"scopes"`
+	CreatedAt  time.Time         `json:"createdAt,omitempty"`
+	CreatedBy  string            `json:"createdBy,omitempty"`
+	ExpiresAt  time.Time         `json:"expiresAt,omitempty"`
+	LastUsedAt time.Time         `json:"lastUsedAt,omitempty"`
+	RevokedAt  time.Time         `json:"revokedAt,omitempty"`
+	Team       string            `json:"team,omitempty"`
+	Claims     map[string]string `json:"claims,omitempty"`
+
+	// Managed records were minted through the token API and are persisted.
+	Managed bool `json:"managed,omitempty"`
+
+	lastPersistedUse time.Time
+}
+
+func (r TokenRecord) expired(now time.Time) bool {
+	return !r.ExpiresAt.IsZero() && now.After(r.ExpiresAt)
+}
+
+func (r TokenRecord) revoked() bool {
+	return !r.RevokedAt.IsZero()
+}
+
+// TokenMintRequest describes a managed API token to mint.
+type TokenMintRequest struct {
+	Name      string
+	Scopes    []string
 	ExpiresAt time.Time
-	Claims    map[string]string
+	CreatedBy string
+	Team      string
+}
+
+type tokenStoreRecord struct {
+	Type  string      `json:"type"`
+	At    time.Time   `json:"at"`
+	Token TokenRecord `json:"token"`
 }
 
 type TokenStore struct {
 	mu     sync.RWMutex
+	path   string
 	byHash map[string]TokenRecord
+	byID   map[string]string
 }
 
-func newTokenStore() *TokenStore {
-	return &TokenStore{byHash: map[string]TokenRecord{}}
+func newTokenStore(path string) *TokenStore {
+	t := &TokenStore{path: path, byHash: map[string]TokenRecord{}, byID: map[string]string{}}
+	if err := t.load(); err != nil {
+		slog.Error("token store: load failed", "path", path, "error", err)
+	}
+	return t
+}
+
+func tokenStorePath(auditPath string) string {
+	if auditPath == "" {
+		return ""
+	}
+	dir := filepath.Dir(auditPath)
+	return filepath.Join(dir, "kocao.tokens.jsonl")
 }
 
 func tokenHash(raw string) string {
@@ -32,6 +93,12 @@ func tokenHash(raw string) string {
 	return hex.EncodeToString(h[:])
 }
 
+func newRawToken() string {
+	var b [32]byte
+	_, _ = rand.Read(b[:])
+	return "kocao_" + hex.EncodeToString(b[:])
+}
+
 func (t *TokenStore) EnsureBootstrapToken(_ context.Context, raw string) error {
 	if strings.TrimSpace(raw) == "" {
 		return nil
@@ -39,6 +106,25 @@ func (t *TokenStore) EnsureBootstrapToken(_ context.Context, raw string) error {
 	return t.Create(context.Background(), "bootstrap", raw, []string{"*"})
 }
 
+// EnsureBootstrapTokenHash registers a wildcard bootstrap token by its SHA-256
+// digest so production deployments never need the raw secret in their env.
+func (t *TokenStore) EnsureBootstrapTokenHash(_ context.Context, digest string) error {
+	digest = strings.ToLower(strings.TrimSpace(digest))
+	if digest == "" {
+		return nil
+	}
+	if b, err := hex.DecodeString(digest); err != nil || len(b) != sha256.Size {
+		return errors.New("bootstrap token digest must be a hex-encoded sha256")
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, exists := t.byHash[digest]; exists {
+		return nil
+	}
+	t.byHash[digest] = TokenRecord{ID: "bootstrap", Hash: digest, Scopes: "*"}
+	return nil
+}
+
 func (t *TokenStore) Create(ctx context.Context, id, raw string, scopes []string) error {
 	return t.CreateWithClaims(ctx, id, raw, scopes, time.Time{}, nil)
 }
@@ -72,22 +158,470 @@ func (t *TokenStore) CreateWithClaims(_ context.Context, id, raw string, scopes
 	return nil
 }
 
+// Mint creates a managed, persisted token and returns its record together with
+// the raw secret. The raw secret is never stored and cannot be recovered later.
+func (t *TokenStore) Mint(_ context.Context, req TokenMintRequest) (TokenRecord, string, error) {
+	if len(req.Scopes) == 0 {
+		return TokenRecord{}, "", errors.New("scopes required")
+	}
+	raw := newRawToken()
+	rec := TokenRecord{
+		ID:        newID(),
+		Name:      strings.TrimSpace(req.Name),
+		Hash:      tokenHash(raw),
+		Scopes:    strings.Join(req.Scopes, ","),
+		CreatedAt: time.Now().UTC(),
+		CreatedBy: strings.TrimSpace(req.CreatedBy),
+		ExpiresAt: req.ExpiresAt.UTC(),
+		Team:      strings.TrimSpace(req.Team),
+		Managed:   true,
+	}
+	if req.ExpiresAt.IsZero() {
+		rec.ExpiresAt = time.Time{}
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if err := t.persistLocked("token", rec); err != nil {
+		return TokenRecord{}, "", err
+	}
+	t.byHash[rec.Hash] = rec
+	t.byID[rec.ID] = rec.Hash
+	return rec, raw, nil
+}
+
+// Get returns a managed token by ID.
+func (t *TokenStore) Get(_ context.Context, id string) (TokenRecord, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	h, ok := t.byID[strings.TrimSpace(id)]
+	if !ok {
+		return TokenRecord{}, false
+	}
+	rec, ok := t.byHash[h]
+	return rec, ok
+}
+
+// List returns managed tokens ordered by creation time.
+func (t *TokenStore) List(_ context.Context) []TokenRecord {
+	t.mu.RLock()
+	out := make([]TokenRecord, 0, len(t.byID))
+	for _, h := range t.byID {
+		if rec, ok := t.byHash[h]; ok {
+			out = append(out, rec)
+		}
+	}
+	t.mu.RUnlock()
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].CreatedAt.Equal(out[j].CreatedAt) {
+			return out[i].ID < out[j].ID
+		}
+		return out[i].CreatedAt.Before(out[j].CreatedAt)
+	})
+	return out
+}
+
+// Revoke marks a managed token as revoked. Revoking twice is a no-op. The
+// token stops authenticating even when the revocation cannot be persisted;
+// the error tells the caller it would come back after a restart.
+func (t *TokenStore) Revoke(_ context.Context, id string) (TokenRecord, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.byID[strings.TrimSpace(id)]
+	if !ok {
+		return TokenRecord{}, errTokenNotFound
+	}
+	rec := t.byHash[h]
+	if rec.revoked() {
+		return rec, nil
+	}
+	rec.RevokedAt = time.Now().UTC()
+	t.byHash[h] = rec
+	if err := t.persistLocked("token", rec); err != nil {
+		return TokenRecord{}, err
+	}
+	return rec, nil
+}
+
+// Rotate replaces the secret of an active managed token, keeping its ID,
+// scopes and expiry. The previous secret stops working immediately.
+func (t *TokenStore) Rotate(_ context.Context, id string) (TokenRecord, string, error) {
+	raw := newRawToken()
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	h, ok := t.byID[strings.TrimSpace(id)]
+	if !ok {
+		return TokenRecord{}, "", errTokenNotFound
+	}
+	rec := t.byHash[h]
+	if rec.revoked() {
+		return TokenRecord{}, "", errTokenRevoked
+	}
+	rec.Hash = tokenHash(raw)
+	rec.LastUsedAt = time.Time{}
+	rec.lastPersistedUse = time.Time{}
+	if err := t.persistLocked("token", rec); err != nil {
+		return TokenRecord{}, "", err
+	}
+	delete(t.byHash, h)
+	t.byHash[rec.Hash] = rec
+	t.byID[rec.ID] = rec.Hash
+	return rec, raw, nil
+}
+
+var (
+	errTokenNotFound = errors.New("token not found")
+	errTokenRevoked  = errors.New("token revoked")
+)
+
 func (t *TokenStore) Lookup(_ context.Context, raw string) (*TokenRecord, error) {
 	if strings.TrimSpace(raw) == "" {
 		return nil, nil
 	}
 	h := tokenHash(raw)
-	t.mu.RLock()
+	now := time.Now().UTC()
+	t.mu.Lock()
 	rec, ok := t.byHash[h]
-	t.mu.RUnlock()
 	if !ok {
+		t.mu.Unlock()
 		return nil, nil
 	}
-	if !rec.ExpiresAt.IsZero() && time.Now().After(rec.ExpiresAt) {
-		t.mu.Lock()
-		delete(t.byHash, h)
+	if rec.revoked() {
+		t.mu.Unlock()
+		return nil, nil
+	}
+	if rec.expired(now) {
+		if !rec.Managed {
+			delete(t.byHash, h)
+		}
 		t.mu.Unlock()
 		return nil, nil
 	}
+	rec.LastUsedAt = now
+	if rec.Managed && now.Sub(rec.lastPersistedUse) >= tokenLastUsedPersistInterval {
+		// Only the timestamp is written, so replay can never resurrect a
+		// secret or revocation state that changed after this lookup.
+		used := TokenRecord{ID: rec.ID, LastUsedAt: now}
+		if err := t.persistLocked("used", used); err != nil {
+			slog.Error("token store: persist last use failed", "id", rec.ID, "error", err)
+		} else {
+			rec.lastPersistedUse = now
+		}
+	}
+	t.byHash[h] = rec
+	t.mu.Unlock()
 	return &rec, nil
 }
+
+// persistLocked appends one record to the store file. Callers hold t.mu so
+// records land in the same order as the in-memory changes they describe.
+func (t *TokenStore) persistLocked(kind string, rec TokenRecord) error {
+	if t.path == "" {
+		return nil
+	}
+	record := tokenStoreRecord{Type: kind, At: time.Now().UTC(), Token: rec}
+	if err := os.MkdirAll(filepath.Dir(t.path), 0o755); err != nil {
+		return fmt.Errorf("token store: mkdir: %w", err)
+	}
+	f, err := os.OpenFile(t.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("token store: open: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	if err := json.NewEncoder(f).Encode(record); err != nil {
+		return fmt.Errorf("token store: encode: %w", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("token store: sync: %w", err)
+	}
+	return nil
+}
+
+func (t *TokenStore) load() error {
+	if t.path == "" {
+		return nil
+	}
+	f, err := os.Open(t.path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	latest := map[string]TokenRecord{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec tokenStoreRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			continue
+		}
+		if rec.Type == "used" {
+			// Last-use records only advance the timestamp of a known token.
+			if cur, ok := latest[rec.Token.ID]; ok && rec.Token.LastUsedAt.After(cur.LastUsedAt) {
+				cur.LastUsedAt = rec.Token.LastUsedAt
+				cur.lastPersistedUse = cur.LastUsedAt
+				latest[rec.Token.ID] = cur
+			}
+			continue
+		}
+		if rec.Token.ID == "" || rec.Token.Hash == "" {
+			continue
+		}
+		rec.Token.Managed = true
+		if prev, ok := latest[rec.Token.ID]; ok && prev.Hash == rec.Token.Hash && prev.LastUsedAt.After(rec.Token.LastUsedAt) {
+			rec.Token.LastUsedAt = prev.LastUsedAt
+		}
+		rec.Token.lastPersistedUse = rec.Token.LastUsedAt
+		latest[rec.Token.ID] = rec.Token
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for id, rec := range latest {
+		t.byHash[rec.Hash] = rec
+		t.byID[id] = rec.Hash
+	}
+	return nil
+}
+
+type tokenCreateRequest struct {
+	Name       string   `json:"name,omitempty"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"`
+	TTLSeconds int64    `json:"ttlSeconds,omitempty"`
+	Team       string   `json:"team,omitempty"`
+}
+
+type tokenResponse struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Scopes     []string `json:"scopes"`
+	Status     string   `json:"status"`
+	CreatedAt  string   `json:"createdAt,omitempty"`
+	CreatedBy  string   `json:"createdBy,omitempty"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"`
+	LastUsedAt string   `json:"lastUsedAt,omitempty"`
+	RevokedAt  string   `json:"revokedAt,omitempty"`
+	Team       string   `json:"team,omitempty"`
+	// Token carries the raw bearer secret and is only populated on create and rotate.
+	Token string `json:"token,omitempty"`
+}
+
+func formatTokenTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func tokenToResponse(rec TokenRecord, now time.Time) tokenResponse {
+	status := "active"
+	switch {
+	case rec.revoked():
+		status = "revoked"
+	case rec.expired(now):
+		status = "expired"
+	}
+	scopes := make([]string, 0, 4)
+	for s := range parseScopes(rec.Scopes) {
+		scopes = append(scopes, s)
+	}
+	sort.Strings(scopes)
+	return tokenResponse{
+		ID:         rec.ID,
+		Name:       rec.Name,
+		Scopes:     scopes,
+		Status:     status,
+		CreatedAt:  formatTokenTime(rec.CreatedAt),
+		CreatedBy:  rec.CreatedBy,
+		ExpiresAt:  formatTokenTime(rec.ExpiresAt),
+		LastUsedAt: formatTokenTime(rec.LastUsedAt),
+		RevokedAt:  formatTokenTime(rec.RevokedAt),
+		Team:       rec.Team,
+	}
+}
+
+func normalizeTokenCreateRequest(req tokenCreateRequest, now time.Time) (TokenMintRequest, error) {
+	out := TokenMintRequest{Name: strings.TrimSpace(req.Name)}
+	if len(out.Name) > 128 {
+		return TokenMintRequest{}, errors.New("name must be at most 128 characters")
+	}
+	seen := map[string]struct{}{}
+	for _, raw := range req.Scopes {
+		scope := strings.TrimSpace(raw)
+		if scope == "" {
+			continue
+		}
+		if !isKnownScope(scope) {
+			return TokenMintRequest{}, fmt.Errorf("unknown scope %q", scope)
+		}
+		if _, dup := seen[scope]; dup {
+			continue
+		}
+		seen[scope] = struct{}{}
+		out.Scopes = append(out.Scopes, scope)
+	}
+	if len(out.Scopes) == 0 {
+		return TokenMintRequest{}, errors.New("at least one scope is required")
+	}
+	sort.Strings(out.Scopes)
+	expiresAt := strings.TrimSpace(req.ExpiresAt)
+	switch {
+	case expiresAt != "" && req.TTLSeconds != 0:
+		return TokenMintRequest{}, errors.New("expiresAt and ttlSeconds are mutually exclusive")
+	case expiresAt != "":
+		t, err := time.Parse(time.RFC3339, expiresAt)
+		if err != nil {
+			return TokenMintRequest{}, errors.New("expiresAt must be RFC3339")
+		}
+		if !t.After(now) {
+			return TokenMintRequest{}, errors.New("expiresAt must be in the future")
+		}
+		out.ExpiresAt = t.UTC()
+	case req.TTLSeconds < 0:
+		return TokenMintRequest{}, errors.New("ttlSeconds must be positive")
+	case req.TTLSeconds > 0:
+		out.ExpiresAt = now.Add(time.Duration(req.TTLSeconds) * time.Second).UTC()
+	}
+	return out, nil
+}
+
+func (a *API) handleTokensList(w http.ResponseWriter, r *http.Request) {
+	now := time.Now().UTC()
+	records := a.Tokens.List(r.Context())
+	out := make([]tokenResponse, 0, len(records))
+	for _, rec := range records {
+		out = append(out, tokenToResponse(rec, now))
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"tokens": out})
+}
+
+func (a *API) handleTokensCreate(w http.ResponseWriter, r *http.Request) {
+	var req tokenCreateRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	now := time.Now().UTC()
+	mint, err := normalizeTokenCreateRequest(req, now)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+	// A caller can never mint a token broader than its own grant.
+	if !callerHoldsScopes(r.Context(), mint.Scopes) {
+		writeError(w, http.StatusForbidden, "cannot grant scope not held by caller")
+		return
+	}
+	team, err := resolveRequestTeam(r.Context(), req.Team)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	mint.Team = team
+	mint.CreatedBy = principal(r.Context())
+	rec, raw, err := a.Tokens.Mint(r.Context(), mint)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, "create token failed")
+		return
+	}
+	a.Audit.Append(r.Context(), principal(r.Context()), "token.minted", "token", rec.ID, "allowed", map[string]any{
+		"name":      rec.Name,
+		"scopes":    rec.Scopes,
+		"expiresAt": formatTokenTime(rec.ExpiresAt),
+	})
+	resp := tokenToResponse(rec, now)
+	resp.Token = raw
+	writeJSON(w, http.StatusCreated, resp)
+}
+
+func (a *API) handleTokenGet(w http.ResponseWriter, r *http.Request, id string) {
+	rec, ok := a.Tokens.Get(r.Context(), id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, tokenToResponse(rec, time.Now().UTC()))
+}
+
+// callerHoldsScopes reports whether the request principal holds every scope.
+// The wildcard is only covered by another wildcard grant.
+func callerHoldsScopes(ctx context.Context, scopes []string) bool {
+	p, _ := principalFrom(ctx)
+	if p == nil {
+		return false
+	}
+	for _, scope := range scopes {
+		if scope == "*" {
+			if _, ok := p.Scopes["*"]; !ok {
+				return false
+			}
+			continue
+		}
+		if !hasScope(p.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// authorizeTokenManagement loads a managed token and checks that the caller
+// holds all of its scopes, so token:write cannot take over broader tokens.
+func (a *API) authorizeTokenManagement(w http.ResponseWriter, r *http.Request, id string) bool {
+	rec, ok := a.Tokens.Get(r.Context(), id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "not found")
+		return false
+	}
+	scopes := make([]string, 0, 4)
+	for s := range parseScopes(rec.Scopes) {
+		scopes = append(scopes, s)
+	}
+	if !callerHoldsScopes(r.Context(), scopes) {
+		writeError(w, http.StatusForbidden, "cannot manage token with scope not held by caller")
+		return false
+	}
+	return true
+}
+
+func (a *API) handleTokenRevoke(w http.ResponseWriter, r *http.Request, id string) {
+	if !a.authorizeTokenManagement(w, r, id) {
+		return
+	}
+	rec, err := a.Tokens.Revoke(r.Context(), id)
+	switch {
+	case errors.Is(err, errTokenNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "revoke token failed")
+		return
+	}
+	a.Audit.Append(r.Context(), principal(r.Context()), "token.revoked", "token", rec.ID, "allowed", map[string]any{"name": rec.Name})
+	writeJSON(w, http.StatusOK, tokenToResponse(rec, time.Now().UTC()))
+}
+
+func (a *API) handleTokenRotate(w http.ResponseWriter, r *http.Request, id string) {
+	if !a.authorizeTokenManagement(w, r, id) {
+		return
+	}
+	rec, raw, err := a.Tokens.Rotate(r.Context(), id)
+	switch {
+	case errors.Is(err, errTokenNotFound):
+		writeError(w, http.StatusNotFound, "not found")
+		return
+	case errors.Is(err, errTokenRevoked):
+		writeError(w, http.StatusConflict, "token is revoked")
+		return
+	case err != nil:
+		writeError(w, http.StatusInternalServerError, "rotate token failed")
+		return
+	}
+	a.Audit.Append(r.Context(), principal(r.Context()), "token.rotated", "token", rec.ID, "allowed", map[string]any{"name": rec.Name})
+	resp := tokenToResponse(rec, time.Now().UTC())
+	resp.Token = raw
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,294 @@
+package controlplaneapi
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTokenLifecycle_API(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-admin", "admin", []string{ScopeTokenRead, ScopeTokenWrite, ScopeHarnessRunRead}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens", "admin", map[string]any{
+		"name":       "ci",
+		"scopes":     []string{ScopeHarnessRunRead},
+		"ttlSeconds": 3600,
+	})
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create status = %d, want 201 (body=%s)", resp.StatusCode, string(b))
+	}
+	var created tokenResponse
+	if err := json.Unmarshal(b, &created); err != nil {
+		t.Fatalf("decode create: %v", err)
+	}
+	if created.ID == "" || created.Token == "" {
+		t.Fatalf("expected id and raw token, got %+v", created)
+	}
+	if created.Status != "active" || created.ExpiresAt == "" || created.CreatedBy != "t-admin" {
+		t.Fatalf("unexpected created token: %+v", created)
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/harness-runs", created.Token, nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("minted token list runs status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/tokens", created.Token, nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("minted token list tokens status = %d, want 403", resp.StatusCode)
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/tokens", "admin", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("list status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	var list struct {
+		Tokens []tokenResponse `json:"tokens"`
+	}
+	if err := json.Unmarshal(b, &list); err != nil {
+		t.Fatalf("decode list: %v", err)
+	}
+	if len(list.Tokens) != 1 || list.Tokens[0].ID != created.ID {
+		t.Fatalf("unexpected token list: %+v", list.Tokens)
+	}
+	if list.Tokens[0].Token != "" {
+		t.Fatalf("list must not expose raw token")
+	}
+	if list.Tokens[0].LastUsedAt == "" {
+		t.Fatalf("expected lastUsedAt after use")
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens/"+created.ID+"/rotate", "admin", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("rotate status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	var rotated tokenResponse
+	if err := json.Unmarshal(b, &rotated); err != nil {
+		t.Fatalf("decode rotate: %v", err)
+	}
+	if rotated.ID != created.ID || rotated.Token == "" || rotated.Token == created.Token {
+		t.Fatalf("unexpected rotated token: %+v", rotated)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/harness-runs", created.Token, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("old secret status = %d, want 401", resp.StatusCode)
+	}
+
+	resp, b = doJSON(t, srv.Client(), http.MethodDelete, srv.URL+"/api/v1/tokens/"+created.ID, "admin", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("revoke status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/harness-runs", rotated.Token, nil)
+	if resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("revoked token status = %d, want 401", resp.StatusCode)
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodGet, srv.URL+"/api/v1/tokens/"+created.ID, "admin", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("get status = %d, want 200 (body=%s)", resp.StatusCode, string(b))
+	}
+	var got tokenResponse
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatalf("decode get: %v", err)
+	}
+	if got.Status != "revoked" || got.RevokedAt == "" {
+		t.Fatalf("expected revoked token, got %+v", got)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens/"+created.ID+"/rotate", "admin", nil)
+	if resp.StatusCode != http.StatusConflict {
+		t.Fatalf("rotate revoked status = %d, want 409", resp.StatusCode)
+	}
+
+	evs, err := api.Audit.List(context.Background(), 100)
+	if err != nil {
+		t.Fatalf("audit list: %v", err)
+	}
+	seen := map[string]bool{}
+	for _, ev := range evs {
+		if ev.ResourceType == "token" && ev.ResourceID == created.ID {
+			seen[ev.Action] = true
+		}
+	}
+	for _, want := range []string{"token.minted", "token.rotated", "token.revoked"} {
+		if !seen[want] {
+			t.Fatalf("expected audit action %q, got %v", want, seen)
+		}
+	}
+}
+
+func TestTokenCreate_RejectsScopeEscalationAndUnknownScopes(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-limited", "limited", []string{ScopeTokenWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, _ := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens", "limited", map[string]any{"scopes": []string{ScopeAuditRead}})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("escalation status = %d, want 403", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens", "limited", map[string]any{"scopes": []string{"*"}})
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("wildcard escalation status = %d, want 403", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens", "limited", map[string]any{"scopes": []string{"bogus:read"}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("unknown scope status = %d, want 400", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens", "limited", map[string]any{"scopes": []string{ScopeTokenWrite}, "expiresAt": "2001-01-01T00:00:00Z"})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("past expiry status = %d, want 400", resp.StatusCode)
+	}
+	resp, _ = doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens", "limited", map[string]any{"scopes": []string{}})
+	if resp.StatusCode != http.StatusBadRequest {
+		t.Fatalf("empty scopes status = %d, want 400", resp.StatusCode)
+	}
+}
+
+func TestTokenStore_PersistsManagedTokensAcrossRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kocao.tokens.jsonl")
+
+	store := newTokenStore(path)
+	keep, keepRaw, err := store.Mint(context.Background(), TokenMintRequest{Name: "keep", Scopes: []string{ScopeAuditRead}})
+	if err != nil {
+		t.Fatalf("mint keep: %v", err)
+	}
+	drop, dropRaw, err := store.Mint(context.Background(), TokenMintRequest{Name: "drop", Scopes: []string{ScopeAuditRead}})
+	if err != nil {
+		t.Fatalf("mint drop: %v", err)
+	}
+	if _, err := store.Revoke(context.Background(), drop.ID); err != nil {
+		t.Fatalf("revoke drop: %v", err)
+	}
+	if rec, err := store.Lookup(context.Background(), keepRaw); err != nil || rec == nil {
+		t.Fatalf("lookup keep: rec=%v err=%v", rec, err)
+	}
+	if err := store.Create(context.Background(), "ephemeral", "ephemeral-raw", []string{"*"}); err != nil {
+		t.Fatalf("create ephemeral: %v", err)
+	}
+
+	reloaded := newTokenStore(path)
+	got, ok := reloaded.Get(context.Background(), keep.ID)
+	if !ok {
+		t.Fatalf("expected kept token after reload")
+	}
+	if got.Name != "keep" || got.LastUsedAt.IsZero() {
+		t.Fatalf("unexpected reloaded token: %+v", got)
+	}
+	if rec, _ := reloaded.Lookup(context.Background(), keepRaw); rec == nil || rec.ID != keep.ID {
+		t.Fatalf("expected kept token to authenticate after reload, got %+v", rec)
+	}
+	if rec, _ := reloaded.Lookup(context.Background(), dropRaw); rec != nil {
+		t.Fatalf("revoked token must not authenticate after reload")
+	}
+	if rec, _ := reloaded.Lookup(context.Background(), "ephemeral-raw"); rec != nil {
+		t.Fatalf("unmanaged tokens must not be persisted")
+	}
+	if len(reloaded.List(context.Background())) != 2 {
+		t.Fatalf("expected 2 managed tokens after reload")
+	}
+}
+
+func TestTokenStore_LastUseRecordCannotReviveRevokedToken(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "kocao.tokens.jsonl")
+
+	store := newTokenStore(path)
+	rec, raw, err := store.Mint(context.Background(), TokenMintRequest{Name: "ci", Scopes: []string{ScopeAuditRead}})
+	if err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+	if _, err := store.Revoke(context.Background(), rec.ID); err != nil {
+		t.Fatalf("revoke: %v", err)
+	}
+	// A lookup that raced the revoke lands its last-use record afterwards.
+	stale := rec
+	stale.LastUsedAt = time.Now().UTC()
+	store.mu.Lock()
+	err = store.persistLocked("used", stale)
+	store.mu.Unlock()
+	if err != nil {
+		t.Fatalf("persist stale use: %v", err)
+	}
+
+	reloaded := newTokenStore(path)
+	if got, _ := reloaded.Lookup(context.Background(), raw); got != nil {
+		t.Fatalf("revoked token must not authenticate after reload")
+	}
+	got, ok := reloaded.Get(context.Background(), rec.ID)
+	if !ok || !got.revoked() || got.LastUsedAt.IsZero() {
+		t.Fatalf("expected revoked token with last use, got %+v", got)
+	}
+}
+
+func TestTokenManagement_RequiresCallerToHoldTargetScopes(t *testing.T) {
+	api, cleanup := newTestAPI(t)
+	defer cleanup()
+
+	if err := api.Tokens.Create(context.Background(), "t-writer", "writer", []string{ScopeTokenWrite}); err != nil {
+		t.Fatalf("create token: %v", err)
+	}
+	admin, adminRaw, err := api.Tokens.Mint(context.Background(), TokenMintRequest{Name: "admin", Scopes: []string{"*"}})
+	if err != nil {
+		t.Fatalf("mint admin: %v", err)
+	}
+
+	srv := httptest.NewServer(api.Handler())
+	defer srv.Close()
+
+	resp, b := doJSON(t, srv.Client(), http.MethodPost, srv.URL+"/api/v1/tokens/"+admin.ID+"/rotate", "writer", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("rotate admin status = %d, want 403 (body=%s)", resp.StatusCode, string(b))
+	}
+	resp, b = doJSON(t, srv.Client(), http.MethodDelete, srv.URL+"/api/v1/tokens/"+admin.ID, "writer", nil)
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("revoke admin status = %d, want 403 (body=%s)", resp.StatusCode, string(b))
+	}
+	if rec, _ := api.Tokens.Lookup(context.Background(), adminRaw); rec == nil {
+		t.Fatalf("admin token must keep working")
+	}
+}
+
+func TestTokenStore_ExpiredManagedTokenRejected(t *testing.T) {
+	store := newTokenStore("")
+	rec, raw, err := store.Mint(context.Background(), TokenMintRequest{Scopes: []string{ScopeAuditRead}, ExpiresAt: time.Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatalf("mint: %v", err)
+	}
+	if got, _ := store.Lookup(context.Background(), raw); got != nil {
+		t.Fatalf("expired token must not authenticate")
+	}
+	if resp := tokenToResponse(rec, time.Now()); resp.Status != "expired" {
+		t.Fatalf("status = %q, want expired", resp.Status)
+	}
+}
+
+func TestTokenStore_BootstrapTokenHash(t *testing.T) {
+	store := newTokenStore("")
+	if err := store.EnsureBootstrapTokenHash(context.Background(), "nope"); err == nil {
+		t.Fatalf("expected malformed digest error")
+	}
+	if err := store.EnsureBootstrapTokenHash(context.Background(), tokenHash("break-glass")); err != nil {
+		t.Fatalf("ensure digest: %v", err)
+	}
+	rec, err := store.Lookup(context.Background(), "break-glass")
+	if err != nil || rec == nil {
+		t.Fatalf("expected bootstrap digest to authenticate: rec=%v err=%v", rec, err)
+	}
+	if rec.ID != "bootstrap" || !hasScope(parseScopes(rec.Scopes), ScopeAuditRead) {
+		t.Fatalf("unexpected bootstrap record: %+v", rec)
+	}
+}
@@ -0,0 +1,211 @@
+package controlplaneapi
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/withakay/kocao/internal/operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/remotecommand"
+)
+
+const (
+	workspaceArchiveContainer    = "archive"
+	workspaceArchiveMountPath    = "/workspace"
+	workspaceArchiveStartTimeout = 5 * time.Minute
+)
+
+// workspaceArchiver copies a workspace PVC to and from a gzipped tar stream.
+// It backs workspace snapshots on clusters without the CSI snapshot API.
+type workspaceArchiver interface {
+	Archive(ctx context.Context, pvc string, w io.Writer) error
+	Extract(ctx context.Context, pvc string, r io.Reader) error
+}
+
+// podWorkspaceArchiver mounts the PVC in a short-lived helper pod and streams
+// tar through pod exec, the same way terminal attach reaches harness pods.
+type podWorkspaceArchiver struct {
+	namespace string
+	clientset kubernetes.Interface
+	restCfg   *rest.Config
+	image     string
+}
+
+func newPodWorkspaceArchiver(namespace string, clientset kubernetes.Interface, restCfg *rest.Config, image string) *podWorkspaceArchiver {
+	image = strings.TrimSpace(image)
+	if image == "" {
+		image = controllers.DefaultInitContainerImage
+	}
+	return &podWorkspaceArchiver{namespace: namespace, clientset: clientset, restCfg: restCfg, image: image}
+}
+
+func (a *podWorkspaceArchiver) Archive(ctx context.Context, pvc string, w io.Writer) error {
+	return a.withPod(ctx, pvc, func(pod string) error {
+		return a.exec(ctx, pod, []string{"tar", "czf", "-", "-C", workspaceArchiveMountPath, "."}, nil, w)
+	})
+}
+
+func (a *podWorkspaceArchiver) Extract(ctx context.Context, pvc string, r io.Reader) error {
+	return a.withPod(ctx, pvc, func(pod string) error {
+		return a.exec(ctx, pod, []string{"tar", "xzf", "-", "-C", workspaceArchiveMountPath}, r, io.Discard)
+	})
+}
+
+// withPod runs fn against a helper pod that mounts pvc, deleting the pod
+// afterwards.
+func (a *podWorkspaceArchiver) withPod(ctx context.Context, pvc string, fn func(pod string) error) error {
+	pods := a.clientset.CoreV1().Pods(a.namespace)
+	node, err := a.nodeMounting(ctx, pvc)
+	if err != nil {
+		return err
+	}
+	pod, err := pods.Create(ctx, a.desiredPod(pvc, node), metav1.CreateOptions{})
+	if err != nil {
+		return fmt.Errorf("create archive pod: %w", err)
+	}
+	defer func() {
+		_ = pods.Delete(context.Background(), pod.Name, metav1.DeleteOptions{})
+	}()
+
+	err = wait.PollUntilContextTimeout(ctx, 2*time.Second, workspaceArchiveStartTimeout, true, func(ctx context.Context) (bool, error) {
+		current, err := pods.Get(ctx, pod.Name, metav1.GetOptions{})
+		if err != nil {
+			return false, nil
+		}
+		switch current.Status.Phase {
+		case corev1.PodRunning:
+			return true, nil
+		case corev1.PodSucceeded, corev1.PodFailed:
+			return false, fmt.Errorf("archive pod %s", strings.ToLower(string(current.Status.Phase)))
+		}
+		return false, nil
+	})
+	if err != nil {
+		return fmt.Errorf("wait for archive pod: %w", err)
+	}
+	return fn(pod.Name)
+}
+
+// nodeMounting returns the node of a live pod that mounts pvc. The workspace
+// is ReadWriteOnce, so the helper pod must share that node.
+func (a *podWorkspaceArchiver) nodeMounting(ctx context.Context, pvc string) (string, error) {
+	list, err := a.clientset.CoreV1().Pods(a.namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return "", fmt.Errorf("list pods: %w", err)
+	}
+	for _, pod := range list.Items {
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		for _, vol := range pod.Spec.Volumes {
+			if vol.PersistentVolumeClaim != nil && vol.PersistentVolumeClaim.ClaimName == pvc {
+				return pod.Spec.NodeName, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// workspaceArchiveCapabilities are the only capabilities the archive pod
+// keeps: enough for root to read any workspace file and to restore its
+// ownership and modes, nothing more.
+var workspaceArchiveCapabilities = []corev1.Capability{"CHOWN", "FOWNER", "DAC_OVERRIDE"}
+
+func (a *podWorkspaceArchiver) desiredPod(pvc, node string) *corev1.Pod {
+	// The pod runs as root because workspaces hold files owned by whatever
+	// UIDs the agent used: tar needs root to read all of them and to restore
+	// their ownership into a fresh, root-owned volume. Everything else a root
+	// container could do is taken away below.
+	rootUID := int64(0)
+	runAsNonRoot := false
+	allowPrivilegeEscalation := false
+	automountToken := false
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "kocao-archive-" + newID()[:12],
+			Namespace: a.namespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "kocao-control-plane-api",
+				"app.kubernetes.io/name":       "kocao-workspace-archive",
+			},
+		},
+		Spec: corev1.PodSpec{
+			RestartPolicy: corev1.RestartPolicyNever,
+			NodeName:      node,
+			Containers: []corev1.Container{{
+				Name:    workspaceArchiveContainer,
+				Image:   a.image,
+				Command: []string{"sh", "-c", "sleep 3600"},
+				VolumeMounts: []corev1.VolumeMount{
+					{Name: "workspace", MountPath: workspaceArchiveMountPath},
+				},
+				SecurityContext: &corev1.SecurityContext{
+					RunAsUser:                &rootUID,
+					RunAsGroup:               &rootUID,
+					RunAsNonRoot:             &runAsNonRoot,
+					AllowPrivilegeEscalation: &allowPrivilegeEscalation,
+					Capabilities: &corev1.Capabilities{
+						Drop: []corev1.Capability{"ALL"},
+						Add:  workspaceArchiveCapabilities,
+					},
+					SeccompProfile: &corev1.SeccompProfile{Type: corev1.SeccompProfileTypeRuntimeDefault},
+				},
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{
+						corev1.ResourceCPU:    resource.MustParse("50m"),
+						corev1.ResourceMemory: resource.MustParse("64Mi"),
+					},
+					Limits: corev1.ResourceList{
+						corev1.ResourceCPU:              resource.MustParse("1"),
+						corev1.ResourceMemory:           resource.MustParse("256Mi"),
+						corev1.ResourceEphemeralStorage: resource.MustParse("64Mi"),
+					},
+				},
+			}},
+			AutomountServiceAccountToken: &automountToken,
+			Volumes: []corev1.Volume{{
+				Name:         "workspace",
+				VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: pvc}},
+			}},
+		},
+	}
+}
+
+func (a *podWorkspaceArchiver) exec(ctx context.Context, pod string, command []string, stdin io.Reader, stdout io.Writer) error {
+	req := a.clientset.CoreV1().RESTClient().Post().
+		Namespace(a.namespace).
+		Resource("pods").
+		Name(pod).
+		SubResource("exec")
+	req.VersionedParams(&corev1.PodExecOptions{
+		Container: workspaceArchiveContainer,
+		Command:   command,
+		Stdin:     stdin != nil,
+		Stdout:    true,
+		Stderr:    true,
+	}, scheme.ParameterCodec)
+
+	exec, err := remotecommand.NewSPDYExecutor(a.restCfg, http.MethodPost, req.URL())
+	if err != nil {
+		return fmt.Errorf("init exec: %w", err)
+	}
+	var stderr bytes.Buffer
+	if err := exec.StreamWithContext(ctx, remotecommand.StreamOptions{Stdin: stdin, Stdout: stdout, Stderr: &stderr}); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package controlplaneapi
+
+import (
+	"slices"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodWorkspaceArchiver_DesiredPodKeepsOnlyArchiveCapabilities(t *testing.T) {
+	pod := newPodWorkspaceArchiver("test-ns", nil, nil, "").desiredPod("ws-1-workspace", "node-a")
+
+	if pod.Spec.NodeName != "node-a" || pod.Spec.Volumes[0].PersistentVolumeClaim.ClaimName != "ws-1-workspace" {
+		t.Fatalf("pod spec = %+v", pod.Spec)
+	}
+	if pod.Spec.AutomountServiceAccountToken == nil || *pod.Spec.AutomountServiceAccountToken {
+		t.Fatalf("service account token mounted")
+	}
+	container := pod.Spec.Containers[0]
+	sc := container.SecurityContext
+	if sc == nil || sc.Capabilities == nil || !slices.Equal(sc.Capabilities.Drop, []corev1.Capability{"ALL"}) {
+		t.Fatalf("security context = %+v", sc)
+	}
+	if !slices.Equal(sc.Capabilities.Add, []corev1.Capability{"CHOWN", "FOWNER", "DAC_OVERRIDE"}) {
+		t.Fatalf("added capabilities = %v", sc.Capabilities.Add)
+	}
+	if sc.AllowPrivilegeEscalation == nil || *sc.AllowPrivilegeEscalation {
+		t.Fatalf("privilege escalation allowed")
+	}
+	for _, name := range []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory} {
+		if _, ok := container.Resources.Requests[name]; !ok {
+			t.Fatalf("missing %s request: %+v", name, container.Resources)
+		}
+		if _, ok := container.Resources.Limits[name]; !ok {
+			t.Fatalf("missing %s limit: %+v", name, container.Resources)
+		}
+	}
+}
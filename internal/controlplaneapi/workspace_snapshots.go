@@ -0,0 +1,666 @@
+package controlplaneapi
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/operator/controllers"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Workspace snapshots capture a session's workspace PVC so it can be restored
+// into a new session. Where the cluster serves the CSI snapshot API they are
+// VolumeSnapshots; elsewhere the workspace is archived with tar into the
+// artifact blob store. Records outlive the session they were taken from.
+
+const (
+	workspaceSnapshotMethodVolumeSnapshot = "volumeSnapshot"
+	workspaceSnapshotMethodArchive        = "archive"
+
+	workspaceSnapshotPhasePending = "Pending"
+	workspaceSnapshotPhaseReady   = "Ready"
+	workspaceSnapshotPhaseFailed  = "Failed"
+
+	// annotationWorkspaceRestorePhase tracks an archive restore into a new
+	// session; runs are refused until it is Ready.
+	annotationWorkspaceRestorePhase = "kocao.withakay.github.com/workspace-restore-phase"
+	annotationWorkspaceRestoredFrom = "kocao.withakay.github.com/workspace-restored-from"
+
+	workspaceSnapshotArchiveTimeout = 30 * time.Minute
+	// workspaceSnapshotReconcileInterval is how often archives and restores
+	// abandoned by a previous API process are failed.
+	workspaceSnapshotReconcileInterval = 5 * time.Minute
+	workspaceSnapshotMaxNameLen        = 128
+)
+
+var volumeSnapshotGVK = schema.GroupVersionKind{Group: controllers.VolumeSnapshotGroup, Version: "v1", Kind: "VolumeSnapshot"}
+
+type workspaceSnapshot struct {
+	ID                 string     `json:"id"`
+	WorkspaceSessionID string     `json:"workspaceSessionId"`
+	Name               string     `json:"name,omitempty"`
+	RepoURL            string     `json:"repoURL,omitempty"`
+	Method             string     `json:"method"`
+	Phase              string     `json:"phase"`
+	VolumeSnapshotName string     `json:"volumeSnapshotName,omitempty"`
+	ArtifactDigest     string     `json:"artifactDigest,omitempty"`
+	SizeBytes          int64      `json:"sizeBytes,omitempty"`
+	Error              string     `json:"error,omitempty"`
+	Owner              string     `json:"owner,omitempty"`
+	Team               string     `json:"team,omitempty"`
+	CreatedBy          string     `json:"createdBy,omitempty"`
+	CreatedAt          time.Time  `json:"createdAt"`
+	ReadyAt            *time.Time `json:"readyAt,omitempty"`
+	// Deleted marks a tombstone in the persisted log.
+	Deleted bool `json:"deleted,omitempty"`
+}
+
+// WorkspaceSnapshotService takes and restores workspace snapshots and keeps
+// their records in a JSONL log next to the audit log.
+type WorkspaceSnapshotService struct {
+	namespace string
+	k8s       client.Client
+	blobs     ArtifactBlobStore
+	archiver  workspaceArchiver
+	// volumeSnapshots reports whether the cluster serves the CSI snapshot API.
+	volumeSnapshots     func(context.Context) bool
+	volumeSnapshotClass string
+	now                 func() time.Time
+	// blobInUse reports whether something other than a snapshot stores
+	// content under an artifact digest.
+	blobInUse func(digest string) bool
+
+	mu        sync.Mutex
+	path      string
+	snapshots []workspaceSnapshot
+}
+
+func newWorkspaceSnapshotService(namespace string, k8s client.Client, blobs ArtifactBlobStore, archiver workspaceArchiver, volumeSnapshots func(context.Context) bool, volumeSnapshotClass, path string) *WorkspaceSnapshotService {
+	if volumeSnapshots == nil {
+		volumeSnapshots = func(context.Context) bool { return false }
+	}
+	s := &WorkspaceSnapshotService{
+		namespace:           namespace,
+		k8s:                 k8s,
+		blobs:               blobs,
+		archiver:            archiver,
+		volumeSnapshots:     volumeSnapshots,
+		volumeSnapshotClass: strings.TrimSpace(volumeSnapshotClass),
+		now:                 time.Now,
+		path:                path,
+	}
+	s.load()
+	return s
+}
+
+func workspaceSnapshotStorePath(auditPath string) string {
+	if auditPath == "" {
+		return ""
+	}
+	dir := filepath.Dir(auditPath)
+	return filepath.Join(dir, "kocao.workspace_snapshots.jsonl")
+}
+
+// clusterServesVolumeSnapshots asks discovery for the snapshot.storage.k8s.io
+// API, which is only present when the CSI snapshot CRDs are installed.
+func clusterServesVolumeSnapshots(cs kubernetes.Interface) func(context.Context) bool {
+	return func(context.Context) bool {
+		resources, err := cs.Discovery().ServerResourcesForGroupVersion(volumeSnapshotGVK.GroupVersion().String())
+		if err != nil {
+			return false
+		}
+		for _, r := range resources.APIResources {
+			if r.Name == "volumesnapshots" {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// load replays the persisted log; later records for a snapshot ID win.
+func (s *WorkspaceSnapshotService) load() {
+	if s.path == "" {
+		return
+	}
+	f, err := os.Open(s.path)
+	if err != nil {
+		return
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var rec workspaceSnapshot
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil || rec.ID == "" {
+			continue
+		}
+		if rec.Deleted {
+			s.forgetLocked(rec.ID)
+			continue
+		}
+		s.rememberLocked(rec)
+	}
+}
+
+func (s *WorkspaceSnapshotService) lookupLocked(id string) (int, bool) {
+	for i := range s.snapshots {
+		if s.snapshots[i].ID == id {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (s *WorkspaceSnapshotService) rememberLocked(rec workspaceSnapshot) {
+	if i, ok := s.lookupLocked(rec.ID); ok {
+		s.snapshots[i] = rec
+		return
+	}
+	s.snapshots = append(s.snapshots, rec)
+}
+
+func (s *WorkspaceSnapshotService) forgetLocked(id string) {
+	if i, ok := s.lookupLocked(id); ok {
+		s.snapshots = append(s.snapshots[:i], s.snapshots[i+1:]...)
+	}
+}
+
+func (s *WorkspaceSnapshotService) record(rec workspaceSnapshot) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.recordLocked(rec)
+}
+
+func (s *WorkspaceSnapshotService) recordLocked(rec workspaceSnapshot) {
+	if rec.Deleted {
+		s.forgetLocked(rec.ID)
+	} else {
+		s.rememberLocked(rec)
+	}
+	if s.path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		slog.Error("workspace snapshot store: mkdir failed", "path", s.path, "error", err)
+		return
+	}
+	f, err := os.OpenFile(s.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		slog.Error("workspace snapshot store: open failed", "path", s.path, "error", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	_ = json.NewEncoder(f).Encode(rec)
+}
+
+// Get returns a snapshot, refreshing the phase of pending VolumeSnapshots.
+func (s *WorkspaceSnapshotService) Get(ctx context.Context, id string) (workspaceSnapshot, bool) {
+	s.mu.Lock()
+	i, ok := s.lookupLocked(id)
+	var snap workspaceSnapshot
+	if ok {
+		snap = s.snapshots[i]
+	}
+	s.mu.Unlock()
+	if !ok {
+		return workspaceSnapshot{}, false
+	}
+	return s.refresh(ctx, snap), true
+}
+
+// List returns the snapshots of a workspace session, newest first.
+func (s *WorkspaceSnapshotService) List(ctx context.Context, workspaceSessionID string) []workspaceSnapshot {
+	s.mu.Lock()
+	var out []workspaceSnapshot
+	for i := len(s.snapshots) - 1; i >= 0; i-- {
+		if s.snapshots[i].WorkspaceSessionID == workspaceSessionID {
+			out = append(out, s.snapshots[i])
+		}
+	}
+	s.mu.Unlock()
+	for i := range out {
+		out[i] = s.refresh(ctx, out[i])
+	}
+	return out
+}
+
+func (s *WorkspaceSnapshotService) refresh(ctx context.Context, snap workspaceSnapshot) workspaceSnapshot {
+	if snap.Method != workspaceSnapshotMethodVolumeSnapshot || snap.Phase != workspaceSnapshotPhasePending {
+		return snap
+	}
+	vs := &unstructured.Unstructured{}
+	vs.SetGroupVersionKind(volumeSnapshotGVK)
+	if err := s.k8s.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: snap.VolumeSnapshotName}, vs); err != nil {
+		if !apierrors.IsNotFound(err) {
+			return snap
+		}
+		snap.Phase = workspaceSnapshotPhaseFailed
+		snap.Error = "volume snapshot not found"
+		s.record(snap)
+		return snap
+	}
+	if msg, _, _ := unstructured.NestedString(vs.Object, "status", "error", "message"); msg != "" {
+		snap.Phase = workspaceSnapshotPhaseFailed
+		snap.Error = msg
+		s.record(snap)
+		return snap
+	}
+	if ready, _, _ := unstructured.NestedBool(vs.Object, "status", "readyToUse"); !ready {
+		return snap
+	}
+	if raw, _, _ := unstructured.NestedString(vs.Object, "status", "restoreSize"); raw != "" {
+		if q, err := resource.ParseQuantity(raw); err == nil {
+			snap.SizeBytes = q.Value()
+		}
+	}
+	readyAt := s.now().UTC()
+	snap.Phase = workspaceSnapshotPhaseReady
+	snap.ReadyAt = &readyAt
+	s.record(snap)
+	return snap
+}
+
+// methodFor picks the snapshot method, preferring VolumeSnapshots.
+func (s *WorkspaceSnapshotService) methodFor(ctx context.Context, requested string) (string, error) {
+	archiveOK := s.archiver != nil && s.blobs != nil
+	switch strings.TrimSpace(requested) {
+	case "":
+		if s.volumeSnapshots(ctx) {
+			return workspaceSnapshotMethodVolumeSnapshot, nil
+		}
+		if archiveOK {
+			return workspaceSnapshotMethodArchive, nil
+		}
+		return "", &requestError{status: http.StatusNotImplemented, msg: "workspace snapshots not available"}
+	case workspaceSnapshotMethodVolumeSnapshot:
+		if !s.volumeSnapshots(ctx) {
+			return "", &requestError{status: http.StatusBadRequest, msg: "volume snapshots are not available in this cluster"}
+		}
+		return workspaceSnapshotMethodVolumeSnapshot, nil
+	case workspaceSnapshotMethodArchive:
+		if !archiveOK {
+			return "", &requestError{status: http.StatusBadRequest, msg: "workspace archives are not available"}
+		}
+		return workspaceSnapshotMethodArchive, nil
+	default:
+		return "", &requestError{status: http.StatusBadRequest, msg: "method must be volumeSnapshot or archive"}
+	}
+}
+
+// Create snapshots the workspace PVC of sess. VolumeSnapshots become ready on
+// the CSI driver's schedule; archives are taken in the background.
+func (s *WorkspaceSnapshotService) Create(ctx context.Context, sess *operatorv1alpha1.Session, name, method, createdBy string) (workspaceSnapshot, error) {
+	method, err := s.methodFor(ctx, method)
+	if err != nil {
+		return workspaceSnapshot{}, err
+	}
+	pvcName := controllers.SessionWorkspacePVCName(sess.Name)
+	var pvc corev1.PersistentVolumeClaim
+	if err := s.k8s.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: pvcName}, &pvc); err != nil {
+		if apierrors.IsNotFound(err) {
+			return workspaceSnapshot{}, &requestError{status: http.StatusConflict, msg: "workspace volume not provisioned yet"}
+		}
+		return workspaceSnapshot{}, &requestError{status: http.StatusInternalServerError, msg: "get workspace volume failed"}
+	}
+
+	owner := sessionOwner(sess)
+	snap := workspaceSnapshot{
+		ID:                 newID(),
+		WorkspaceSessionID: sess.Name,
+		Name:               name,
+		RepoURL:            sess.Spec.RepoURL,
+		Method:             method,
+		Phase:              workspaceSnapshotPhasePending,
+		Owner:              owner.Owner,
+		Team:               owner.Team,
+		CreatedBy:          createdBy,
+		CreatedAt:          s.now().UTC(),
+	}
+	if method == workspaceSnapshotMethodArchive {
+		s.record(snap)
+		go s.archive(snap, pvcName)
+		return snap, nil
+	}
+
+	// The VolumeSnapshot has no owner reference so it survives the session.
+	snap.VolumeSnapshotName = "kocao-snapshot-" + snap.ID
+	vs := &unstructured.Unstructured{}
+	vs.SetGroupVersionKind(volumeSnapshotGVK)
+	vs.SetNamespace(s.namespace)
+	vs.SetName(snap.VolumeSnapshotName)
+	vs.SetLabels(map[string]string{
+		controllers.LabelWorkspaceSessionName: sess.Name,
+		"app.kubernetes.io/managed-by":        "kocao-control-plane-api",
+	})
+	spec := map[string]any{"source": map[string]any{"persistentVolumeClaimName": pvcName}}
+	if s.volumeSnapshotClass != "" {
+		spec["volumeSnapshotClassName"] = s.volumeSnapshotClass
+	}
+	vs.Object["spec"] = spec
+	if err := s.k8s.Create(ctx, vs); err != nil {
+		return workspaceSnapshot{}, &requestError{status: http.StatusInternalServerError, msg: "create volume snapshot failed"}
+	}
+	s.record(snap)
+	return snap, nil
+}
+
+func (s *WorkspaceSnapshotService) archive(snap workspaceSnapshot, pvcName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), workspaceSnapshotArchiveTimeout)
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	defer func() { _ = pr.Close() }()
+	go func() { _ = pw.CloseWithError(s.archiver.Archive(ctx, pvcName, pw)) }()
+
+	f, digest, size, err := spoolArtifactUpload(pr)
+	if err == nil {
+		err = s.blobs.Put(ctx, digest, f, size)
+		_ = f.Close()
+		_ = os.Remove(f.Name())
+	}
+	if err != nil {
+		slog.Warn("workspace snapshot archive failed", "snapshot", snap.ID, "workspaceSession", snap.WorkspaceSessionID, "error", err)
+		snap.Phase = workspaceSnapshotPhaseFailed
+		snap.Error = "archive workspace: " + err.Error()
+		s.record(snap)
+		return
+	}
+	readyAt := s.now().UTC()
+	snap.Phase = workspaceSnapshotPhaseReady
+	snap.ArtifactDigest = remoteAgentArtifactDigestPrefix + digest
+	snap.SizeBytes = size
+	snap.ReadyAt = &readyAt
+	s.record(snap)
+}
+
+// restoreAnnotations are the annotations a session restored from snap is
+// created with.
+func (s *WorkspaceSnapshotService) restoreAnnotations(snap workspaceSnapshot) map[string]string {
+	annotations := map[string]string{annotationWorkspaceRestoredFrom: snap.ID}
+	switch snap.Method {
+	case workspaceSnapshotMethodVolumeSnapshot:
+		annotations[controllers.AnnotationRestoreVolumeSnapshot] = snap.VolumeSnapshotName
+	case workspaceSnapshotMethodArchive:
+		annotations[annotationWorkspaceRestorePhase] = workspaceSnapshotPhasePending
+	}
+	return annotations
+}
+
+// startRestore fills the new session's workspace. VolumeSnapshot restores are
+// done by the operator when it provisions the PVC; archives are extracted into
+// the PVC in the background.
+func (s *WorkspaceSnapshotService) startRestore(snap workspaceSnapshot, sessionName string) {
+	if snap.Method != workspaceSnapshotMethodArchive {
+		return
+	}
+	go s.extract(snap, sessionName)
+}
+
+func (s *WorkspaceSnapshotService) extract(snap workspaceSnapshot, sessionName string) {
+	ctx, cancel := context.WithTimeout(context.Background(), workspaceSnapshotArchiveTimeout)
+	defer cancel()
+
+	err := errors.New("snapshot has no archive")
+	if digest, ok := parseArtifactDigest(snap.ArtifactDigest); ok && s.blobs != nil && s.archiver != nil {
+		var body io.ReadCloser
+		body, _, err = s.blobs.Open(ctx, digest)
+		if err == nil {
+			err = s.archiver.Extract(ctx, controllers.SessionWorkspacePVCName(sessionName), body)
+			_ = body.Close()
+		}
+	}
+	phase := workspaceSnapshotPhaseReady
+	if err != nil {
+		slog.Warn("workspace snapshot restore failed", "snapshot", snap.ID, "workspaceSession", sessionName, "error", err)
+		phase = workspaceSnapshotPhaseFailed
+	}
+	// The extract context may have run out; record the outcome regardless.
+	recordCtx, recordCancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer recordCancel()
+	s.setRestorePhase(recordCtx, sessionName, phase)
+}
+
+// setRestorePhase records the archive restore phase on a session. A restore
+// left Pending is failed by ExpireStale once it exceeds the archive timeout.
+func (s *WorkspaceSnapshotService) setRestorePhase(ctx context.Context, sessionName, phase string) {
+	var sess operatorv1alpha1.Session
+	if err := s.k8s.Get(ctx, client.ObjectKey{Namespace: s.namespace, Name: sessionName}, &sess); err != nil {
+		return
+	}
+	updated := sess.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[annotationWorkspaceRestorePhase] = phase
+	if err := s.k8s.Patch(ctx, updated, client.MergeFrom(&sess)); err != nil {
+		slog.Warn("failed to record workspace restore phase", "workspaceSession", sessionName, "error", err)
+	}
+}
+
+// RunReconciler fails abandoned archives and restores at startup and then
+// periodically until ctx is done.
+func (s *WorkspaceSnapshotService) RunReconciler(ctx context.Context) {
+	ticker := time.NewTicker(workspaceSnapshotReconcileInterval)
+	defer ticker.Stop()
+	for {
+		s.ExpireStale(ctx, s.now().UTC())
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ExpireStale fails archive snapshots and archive restores still Pending after
+// workspaceSnapshotArchiveTimeout. Their background goroutine has either
+// timed out or died with a previous API process, so nothing else will finish
+// them.
+func (s *WorkspaceSnapshotService) ExpireStale(ctx context.Context, now time.Time) {
+	cutoff := now.Add(-workspaceSnapshotArchiveTimeout)
+	s.mu.Lock()
+	for _, snap := range s.snapshots {
+		if snap.Method != workspaceSnapshotMethodArchive || snap.Phase != workspaceSnapshotPhasePending || snap.CreatedAt.After(cutoff) {
+			continue
+		}
+		snap.Phase = workspaceSnapshotPhaseFailed
+		snap.Error = "archive did not finish"
+		s.recordLocked(snap)
+	}
+	s.mu.Unlock()
+
+	var sessions operatorv1alpha1.SessionList
+	if err := s.k8s.List(ctx, &sessions, client.InNamespace(s.namespace)); err != nil {
+		slog.Warn("list workspace sessions for restore expiry failed", "error", err)
+		return
+	}
+	for _, sess := range sessions.Items {
+		if sess.Annotations[annotationWorkspaceRestorePhase] != workspaceSnapshotPhasePending || sess.CreationTimestamp.After(cutoff) {
+			continue
+		}
+		slog.Warn("workspace snapshot restore did not finish", "workspaceSession", sess.Name, "snapshot", sess.Annotations[annotationWorkspaceRestoredFrom])
+		s.setRestorePhase(ctx, sess.Name, workspaceSnapshotPhaseFailed)
+	}
+}
+
+// Delete removes a snapshot and what backs it: the VolumeSnapshot, or the
+// archive blob unless another snapshot or task artifact shares its digest.
+func (s *WorkspaceSnapshotService) Delete(ctx context.Context, id string) error {
+	s.mu.Lock()
+	i, ok := s.lookupLocked(id)
+	if !ok {
+		s.mu.Unlock()
+		return &requestError{status: http.StatusNotFound, msg: "workspace snapshot not found"}
+	}
+	snap := s.snapshots[i]
+	shared := false
+	for _, other := range s.snapshots {
+		if other.ID != snap.ID && snap.ArtifactDigest != "" && other.ArtifactDigest == snap.ArtifactDigest {
+			shared = true
+		}
+	}
+	s.mu.Unlock()
+
+	switch snap.Method {
+	case workspaceSnapshotMethodVolumeSnapshot:
+		if snap.VolumeSnapshotName != "" {
+			vs := &unstructured.Unstructured{}
+			vs.SetGroupVersionKind(volumeSnapshotGVK)
+			vs.SetNamespace(s.namespace)
+			vs.SetName(snap.VolumeSnapshotName)
+			if err := s.k8s.Delete(ctx, vs); err != nil && !apierrors.IsNotFound(err) {
+				return &requestError{status: http.StatusInternalServerError, msg: "delete volume snapshot failed"}
+			}
+		}
+	case workspaceSnapshotMethodArchive:
+		if snap.Phase == workspaceSnapshotPhasePending {
+			return &requestError{status: http.StatusConflict, msg: "workspace snapshot archive in progress"}
+		}
+		digest, ok := parseArtifactDigest(snap.ArtifactDigest)
+		if ok && !shared && s.blobs != nil && (s.blobInUse == nil || !s.blobInUse(snap.ArtifactDigest)) {
+			if err := s.blobs.Delete(ctx, digest); err != nil {
+				return &requestError{status: http.StatusBadGateway, msg: "delete workspace archive failed"}
+			}
+		}
+	}
+	s.record(workspaceSnapshot{ID: snap.ID, Deleted: true})
+	return nil
+}
+
+type workspaceSnapshotCreateRequest struct {
+	Name   string `json:"name,omitempty"`
+	Method string `json:"method,omitempty"`
+}
+
+type workspaceSnapshotRestoreRequest struct {
+	DisplayName string `json:"displayName,omitempty"`
+	Team        string `json:"team,omitempty"`
+}
+
+func (a *API) handleWorkspaceSnapshotsCreate(w http.ResponseWriter, r *http.Request, workspaceSessionID string) {
+	if a.WorkspaceSnapshots == nil {
+		writeError(w, http.StatusNotImplemented, "workspace snapshots not configured")
+		return
+	}
+	var req workspaceSnapshotCreateRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+	if len(name) > workspaceSnapshotMaxNameLen {
+		writeError(w, http.StatusBadRequest, "name too long (max 128)")
+		return
+	}
+	var sess operatorv1alpha1.Session
+	if err := a.K8s.Get(r.Context(), client.ObjectKey{Namespace: a.Namespace, Name: workspaceSessionID}, &sess); err != nil {
+		if apierrors.IsNotFound(err) {
+			writeError(w, http.StatusNotFound, "workspace session not found")
+			return
+		}
+		writeError(w, http.StatusInternalServerError, "get workspace session failed")
+		return
+	}
+	snap, err := a.WorkspaceSnapshots.Create(r.Context(), &sess, name, req.Method, principal(r.Context()))
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusAccepted, snap)
+}
+
+func (a *API) handleWorkspaceSnapshotsList(w http.ResponseWriter, r *http.Request, workspaceSessionID string) {
+	if a.WorkspaceSnapshots == nil {
+		writeError(w, http.StatusNotImplemented, "workspace snapshots not configured")
+		return
+	}
+	// Snapshots outlive their session, whose ownership the route checked, so
+	// each record is checked against its own stamp too.
+	snaps := a.WorkspaceSnapshots.List(r.Context(), workspaceSessionID)
+	out := make([]workspaceSnapshot, 0, len(snaps))
+	for _, snap := range snaps {
+		if visibleTo(r.Context(), snapshotOwner(snap)) {
+			out = append(out, snap)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"snapshots": out})
+}
+
+func (a *API) handleWorkspaceSnapshotGet(w http.ResponseWriter, r *http.Request, id string) {
+	if a.WorkspaceSnapshots == nil {
+		writeError(w, http.StatusNotImplemented, "workspace snapshots not configured")
+		return
+	}
+	snap, ok := a.WorkspaceSnapshots.Get(r.Context(), id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace snapshot not found")
+		return
+	}
+	writeJSON(w, http.StatusOK, snap)
+}
+
+func (a *API) handleWorkspaceSnapshotDelete(w http.ResponseWriter, r *http.Request, id string) {
+	if a.WorkspaceSnapshots == nil {
+		writeError(w, http.StatusNotImplemented, "workspace snapshots not configured")
+		return
+	}
+	if err := a.WorkspaceSnapshots.Delete(r.Context(), id); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"deleted": true})
+}
+
+func (a *API) handleWorkspaceSnapshotRestore(w http.ResponseWriter, r *http.Request, id string) {
+	if a.WorkspaceSnapshots == nil {
+		writeError(w, http.StatusNotImplemented, "workspace snapshots not configured")
+		return
+	}
+	var req workspaceSnapshotRestoreRequest
+	if err := readJSON(w, r, &req); err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	team, err := resolveRequestTeam(r.Context(), req.Team)
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	snap, ok := a.WorkspaceSnapshots.Get(r.Context(), id)
+	if !ok {
+		writeError(w, http.StatusNotFound, "workspace snapshot not found")
+		return
+	}
+	if snap.Phase != workspaceSnapshotPhaseReady {
+		writeError(w, http.StatusConflict, "workspace snapshot is not ready")
+		return
+	}
+	if team == "" {
+		team = snap.Team
+	}
+	sess, err := a.createSession(r.Context(), req.DisplayName, snap.RepoURL, team, a.WorkspaceSnapshots.restoreAnnotations(snap))
+	if err != nil {
+		writeJSONError(w, err)
+		return
+	}
+	a.WorkspaceSnapshots.startRestore(snap, sess.Name)
+	a.Audit.Append(r.Context(), principal(r.Context()), "workspace-snapshot.restored", "workspace-session", sess.Name, "allowed", map[string]any{"snapshot": snap.ID, "method": snap.Method, "source": snap.WorkspaceSessionID})
+	writeJSON(w, http.StatusCreated, sessionToResponse(sess))
+}
@@ -18,8 +18,28 @@ type WorkspaceSession struct {
 	ID          string `json:"id"`
 	DisplayName string `json:"displayName,omitempty"`
 	RepoURL     string `json:"repoURL,omitempty"`
+	Owner       string `json:"owner,omitempty"`
+	Team        string `json:"team,omitempty"`
 	Phase       string `json:"phase,omitempty"`
 	CreatedAt   string `json:"createdAt,omitempty"`
+
+	RestoredFrom string `json:"restoredFrom,omitempty"`
+	RestorePhase string `json:"restorePhase,omitempty"`
+}
+
+// WorkspaceSnapshot is a point-in-time copy of a workspace session's volume.
+type WorkspaceSnapshot struct {
+	ID                 string `json:"id"`
+	WorkspaceSessionID string `json:"workspaceSessionId"`
+	Name               string `json:"name,omitempty"`
+	Method             string `json:"method"`
+	Phase              string `json:"phase"`
+	VolumeSnapshotName string `json:"volumeSnapshotName,omitempty"`
+	ArtifactDigest     string `json:"artifactDigest,omitempty"`
+	SizeBytes          int64  `json:"sizeBytes,omitempty"`
+	Error              string `json:"error,omitempty"`
+	CreatedAt          string `json:"createdAt,omitempty"`
+	ReadyAt            string `json:"readyAt,omitempty"`
 }
 
 type AgentSessionInfo struct {
@@ -41,9 +61,14 @@ type HarnessRun struct {
 	Phase              string                                           `json:"phase,omitempty"`
 	PodName            string                                           `json:"podName,omitempty"`
 	AgentSession       *AgentSessionInfo                                `json:"agentSession,omitempty"`
+	Owner              string                                           `json:"owner,omitempty"`
+	Team               string                                           `json:"team,omitempty"`
 	GitHubBranch       string                                           `json:"gitHubBranch,omitempty"`
 	PullRequestURL     string                                           `json:"pullRequestURL,omitempty"`
 	PullRequestStatus  string                                           `json:"pullRequestStatus,omitempty"`
+	Reason             string                                           `json:"reason,omitempty"`
+	Message            string                                           `json:"message,omitempty"`
+	LastActivityAt     string                                           `json:"lastActivityAt,omitempty"`
 }
 
 type PodLogs struct {
@@ -72,6 +97,15 @@ type SymphonyProject struct {
 	Status     operatorv1alpha1.SymphonyProjectStatus `json:"status"`
 }
 
+// SymphonyPlan is the latest dry-run sync of a project. Ready is false until
+// the operator has answered RequestedAt.
+type SymphonyPlan struct {
+	Name        string                                      `json:"name"`
+	RequestedAt string                                      `json:"requestedAt,omitempty"`
+	Ready       bool                                        `json:"ready"`
+	Plan        *operatorv1alpha1.SymphonyProjectPlanStatus `json:"plan,omitempty"`
+}
+
 type SymphonyProjectRequest struct {
 	Name string                               `json:"name,omitempty"`
 	Spec operatorv1alpha1.SymphonyProjectSpec `json:"spec"`
@@ -91,6 +125,7 @@ type RemoteAgentPool struct {
 	DisplayName        string `json:"displayName,omitempty"`
 	Description        string `json:"description,omitempty"`
 	WorkspaceSessionID string `json:"workspaceSessionId,omitempty"`
+	MaxConcurrency     int    `json:"maxConcurrency,omitempty"`
 	CreatedAt          string `json:"createdAt,omitempty"`
 	UpdatedAt          string `json:"updatedAt,omitempty"`
 }
@@ -100,24 +135,33 @@ type RemoteAgentPoolCreateRequest struct {
 	DisplayName        string `json:"displayName,omitempty"`
 	Description        string `json:"description,omitempty"`
 	WorkspaceSessionID string `json:"workspaceSessionId,omitempty"`
+	MaxConcurrency     int    `json:"maxConcurrency,omitempty"`
 }
 
 type RemoteAgent struct {
-	ID                 string                     `json:"id"`
-	Name               string                     `json:"name"`
-	DisplayName        string                     `json:"displayName,omitempty"`
-	Description        string                     `json:"description,omitempty"`
-	PoolID             string                     `json:"poolId,omitempty"`
-	PoolName           string                     `json:"poolName,omitempty"`
-	WorkspaceSessionID string                     `json:"workspaceSessionId,omitempty"`
-	Runtime            string                     `json:"runtime,omitempty"`
-	Agent              string                     `json:"agent,omitempty"`
-	Availability       string                     `json:"availability,omitempty"`
-	CurrentTaskID      string                     `json:"currentTaskId,omitempty"`
-	LastActivityAt     string                     `json:"lastActivityAt,omitempty"`
-	CurrentSession     *RemoteAgentSessionBinding `json:"currentSession,omitempty"`
-	CreatedAt          string                     `json:"createdAt,omitempty"`
-	UpdatedAt          string                     `json:"updatedAt,omitempty"`
+	ID                  string                         `json:"id"`
+	Name                string                         `json:"name"`
+	DisplayName         string                         `json:"displayName,omitempty"`
+	Description         string                         `json:"description,omitempty"`
+	PoolID              string                         `json:"poolId,omitempty"`
+	PoolName            string                         `json:"poolName,omitempty"`
+	WorkspaceSessionID  string                         `json:"workspaceSessionId,omitempty"`
+	Runtime             string                         `json:"runtime,omitempty"`
+	Agent               string                         `json:"agent,omitempty"`
+	Availability        string                         `json:"availability,omitempty"`
+	CurrentTaskID       string                         `json:"currentTaskId,omitempty"`
+	LastActivityAt      string                         `json:"lastActivityAt,omitempty"`
+	LastHeartbeatAt     string                         `json:"lastHeartbeatAt,omitempty"`
+	CurrentSession      *RemoteAgentSessionBinding     `json:"currentSession,omitempty"`
+	AvailabilityHistory []RemoteAgentAvailabilityEvent `json:"availabilityHistory,omitempty"`
+	CreatedAt           string                         `json:"createdAt,omitempty"`
+	UpdatedAt           string                         `json:"updatedAt,omitempty"`
+}
+
+type RemoteAgentAvailabilityEvent struct {
+	At           string `json:"at"`
+	Availability string `json:"availability"`
+	Reason       string `json:"reason,omitempty"`
 }
 
 type RemoteAgentCreateRequest struct {
@@ -187,16 +231,22 @@ type RemoteAgentTask struct {
 	WorkspaceSessionID string                       `json:"workspaceSessionId,omitempty"`
 	Prompt             string                       `json:"prompt,omitempty"`
 	State              string                       `json:"state"`
+	Priority           int                          `json:"priority,omitempty"`
+	QueuePosition      int                          `json:"queuePosition,omitempty"`
+	WaitSeconds        int64                        `json:"waitSeconds,omitempty"`
 	TimeoutSeconds     int32                        `json:"timeoutSeconds,omitempty"`
 	Attempt            int                          `json:"attempt,omitempty"`
 	RetryCount         int                          `json:"retryCount,omitempty"`
 	CurrentSession     *RemoteAgentSessionBinding   `json:"currentSession,omitempty"`
 	CreatedAt          string                       `json:"createdAt,omitempty"`
+	QueuedAt           string                       `json:"queuedAt,omitempty"`
 	AssignedAt         string                       `json:"assignedAt,omitempty"`
 	StartedAt          string                       `json:"startedAt,omitempty"`
 	CompletedAt        string                       `json:"completedAt,omitempty"`
 	CancelledAt        string                       `json:"cancelledAt,omitempty"`
 	LastTransitionAt   string                       `json:"lastTransitionAt,omitempty"`
+	WorkflowID         string                       `json:"workflowId,omitempty"`
+	WorkflowStep       string                       `json:"workflowStep,omitempty"`
 	Result             *RemoteAgentTaskResult       `json:"result,omitempty"`
 	InputArtifacts     []RemoteAgentArtifactRef     `json:"inputArtifacts,omitempty"`
 	OutputArtifacts    []RemoteAgentArtifactRef     `json:"outputArtifacts,omitempty"`
@@ -206,10 +256,65 @@ type RemoteAgentTask struct {
 type RemoteAgentTaskCreateRequest struct {
 	Target         RemoteAgentTaskTarget              `json:"target"`
 	Prompt         string                             `json:"prompt"`
+	Priority       int                                `json:"priority,omitempty"`
+	TimeoutSeconds int32                              `json:"timeoutSeconds,omitempty"`
+	InputArtifacts []RemoteAgentArtifactCreateRequest `json:"inputArtifacts,omitempty"`
+}
+
+type RemoteAgentWorkflowStep struct {
+	Name           string                             `json:"name"`
+	DependsOn      []string                           `json:"dependsOn,omitempty"`
+	Target         RemoteAgentTaskTarget              `json:"target"`
+	Prompt         string                             `json:"prompt"`
+	Priority       int                                `json:"priority,omitempty"`
+	TimeoutSeconds int32                              `json:"timeoutSeconds,omitempty"`
+	InputArtifacts []RemoteAgentArtifactCreateRequest `json:"inputArtifacts,omitempty"`
+	TaskID         string                             `json:"taskId,omitempty"`
+	State          string                             `json:"state,omitempty"`
+	WaitingReason  string                             `json:"waitingReason,omitempty"`
+}
+
+type RemoteAgentWorkflow struct {
+	ID               string                    `json:"id"`
+	Name             string                    `json:"name,omitempty"`
+	RequestedBy      string                    `json:"requestedBy,omitempty"`
+	Team             string                    `json:"team,omitempty"`
+	State            string                    `json:"state"`
+	Steps            []RemoteAgentWorkflowStep `json:"steps"`
+	CreatedAt        string                    `json:"createdAt,omitempty"`
+	CompletedAt      string                    `json:"completedAt,omitempty"`
+	CancelledAt      string                    `json:"cancelledAt,omitempty"`
+	LastTransitionAt string                    `json:"lastTransitionAt,omitempty"`
+}
+
+type RemoteAgentWorkflowStepRequest struct {
+	Name           string                             `json:"name"`
+	DependsOn      []string                           `json:"dependsOn,omitempty"`
+	Target         RemoteAgentTaskTarget              `json:"target"`
+	Prompt         string                             `json:"prompt"`
+	Priority       int                                `json:"priority,omitempty"`
 	TimeoutSeconds int32                              `json:"timeoutSeconds,omitempty"`
 	InputArtifacts []RemoteAgentArtifactCreateRequest `json:"inputArtifacts,omitempty"`
 }
 
+type RemoteAgentWorkflowCreateRequest struct {
+	Name  string                           `json:"name,omitempty"`
+	Team  string                           `json:"team,omitempty"`
+	Steps []RemoteAgentWorkflowStepRequest `json:"steps"`
+}
+
+type RemoteAgentTaskCompleteRequest struct {
+	Summary string `json:"summary,omitempty"`
+	Outcome string `json:"outcome,omitempty"`
+}
+
+type RemoteAgentTranscriptAppendRequest struct {
+	Role     string `json:"role"`
+	Kind     string `json:"kind,omitempty"`
+	Text     string `json:"text,omitempty"`
+	EventRef string `json:"eventRef,omitempty"`
+}
+
 type RemoteAgentTaskTranscript struct {
 	TaskID     string                       `json:"taskId"`
 	Transcript []RemoteAgentTranscriptEntry `json:"transcript"`
@@ -221,6 +326,27 @@ type RemoteAgentTaskArtifacts struct {
 	OutputArtifacts []RemoteAgentArtifactRef `json:"outputArtifacts"`
 }
 
+type APIToken struct {
+	ID         string   `json:"id"`
+	Name       string   `json:"name,omitempty"`
+	Scopes     []string `json:"scopes"`
+	Status     string   `json:"status"`
+	CreatedAt  string   `json:"createdAt,omitempty"`
+	CreatedBy  string   `json:"createdBy,omitempty"`
+	ExpiresAt  string   `json:"expiresAt,omitempty"`
+	LastUsedAt string   `json:"lastUsedAt,omitempty"`
+	RevokedAt  string   `json:"revokedAt,omitempty"`
+	Team       string   `json:"team,omitempty"`
+	Token      string   `json:"token,omitempty"`
+}
+
+type APITokenCreateRequest struct {
+	Name       string   `json:"name,omitempty"`
+	Scopes     []string `json:"scopes"`
+	TTLSeconds int64    `json:"ttlSeconds,omitempty"`
+	Team       string   `json:"team,omitempty"`
+}
+
 type Client struct {
 	baseURL    *url.URL
 	token      string
@@ -364,6 +490,40 @@ func (c *Client) GetWorkspaceSession(ctx context.Context, sessionID string) (Wor
 	return out, nil
 }
 
+func (c *Client) CreateWorkspaceSnapshot(ctx context.Context, sessionID, name string) (WorkspaceSnapshot, error) {
+	var out WorkspaceSnapshot
+	route := "/api/v1/workspace-sessions/" + url.PathEscape(strings.TrimSpace(sessionID)) + "/snapshots"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, map[string]string{"name": name}, &out); err != nil {
+		return WorkspaceSnapshot{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) ListWorkspaceSnapshots(ctx context.Context, sessionID string) ([]WorkspaceSnapshot, error) {
+	var payload struct {
+		Snapshots []WorkspaceSnapshot `json:"snapshots"`
+	}
+	route := "/api/v1/workspace-sessions/" + url.PathEscape(strings.TrimSpace(sessionID)) + "/snapshots"
+	if err := c.doJSON(ctx, http.MethodGet, route, nil, nil, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Snapshots, nil
+}
+
+func (c *Client) RestoreWorkspaceSnapshot(ctx context.Context, snapshotID, displayName string) (WorkspaceSession, error) {
+	var out WorkspaceSession
+	route := "/api/v1/workspace-snapshots/" + url.PathEscape(strings.TrimSpace(snapshotID)) + "/restore"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, map[string]string{"displayName": displayName}, &out); err != nil {
+		return WorkspaceSession{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) DeleteWorkspaceSnapshot(ctx context.Context, snapshotID string) error {
+	route := "/api/v1/workspace-snapshots/" + url.PathEscape(strings.TrimSpace(snapshotID))
+	return c.doJSON(ctx, http.MethodDelete, route, nil, nil, nil)
+}
+
 func (c *Client) ListHarnessRuns(ctx context.Context, workspaceSessionID string) ([]HarnessRun, error) {
 	query := url.Values{}
 	if strings.TrimSpace(workspaceSessionID) != "" {
@@ -439,6 +599,15 @@ func (c *Client) GetRemoteAgent(ctx context.Context, agentID string) (RemoteAgen
 	return out, nil
 }
 
+func (c *Client) HeartbeatRemoteAgent(ctx context.Context, agentID string) (RemoteAgent, error) {
+	var out RemoteAgent
+	route := "/api/v1/remote-agents/" + url.PathEscape(strings.TrimSpace(agentID)) + "/heartbeat"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, nil, &out); err != nil {
+		return RemoteAgent{}, err
+	}
+	return out, nil
+}
+
 func (c *Client) ListRemoteAgentTasks(ctx context.Context) ([]RemoteAgentTask, error) {
 	var payload struct {
 		RemoteAgentTasks []RemoteAgentTask `json:"remoteAgentTasks"`
@@ -474,6 +643,49 @@ func (c *Client) RetryRemoteAgentTask(ctx context.Context, taskID string) (Remot
 	return c.controlRemoteAgentTask(ctx, taskID, "retry")
 }
 
+func (c *Client) StartRemoteAgentTask(ctx context.Context, taskID string) (RemoteAgentTask, error) {
+	return c.controlRemoteAgentTask(ctx, taskID, "start")
+}
+
+func (c *Client) CompleteRemoteAgentTask(ctx context.Context, taskID string, req RemoteAgentTaskCompleteRequest) (RemoteAgentTask, error) {
+	return c.finishRemoteAgentTask(ctx, taskID, "complete", req)
+}
+
+func (c *Client) FailRemoteAgentTask(ctx context.Context, taskID string, req RemoteAgentTaskCompleteRequest) (RemoteAgentTask, error) {
+	return c.finishRemoteAgentTask(ctx, taskID, "fail", req)
+}
+
+func (c *Client) finishRemoteAgentTask(ctx context.Context, taskID, action string, req RemoteAgentTaskCompleteRequest) (RemoteAgentTask, error) {
+	var out RemoteAgentTask
+	route := "/api/v1/remote-agent-tasks/" + url.PathEscape(strings.TrimSpace(taskID)) + "/" + action
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, req, &out); err != nil {
+		return RemoteAgentTask{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) AppendRemoteAgentTaskTranscript(ctx context.Context, taskID string, req RemoteAgentTranscriptAppendRequest) (RemoteAgentTranscriptEntry, error) {
+	var payload struct {
+		Entry RemoteAgentTranscriptEntry `json:"entry"`
+	}
+	route := "/api/v1/remote-agent-tasks/" + url.PathEscape(strings.TrimSpace(taskID)) + "/transcript"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, req, &payload); err != nil {
+		return RemoteAgentTranscriptEntry{}, err
+	}
+	return payload.Entry, nil
+}
+
+func (c *Client) AttachRemoteAgentTaskArtifact(ctx context.Context, taskID string, req RemoteAgentArtifactCreateRequest) (RemoteAgentArtifactRef, error) {
+	var payload struct {
+		Artifact RemoteAgentArtifactRef `json:"artifact"`
+	}
+	route := "/api/v1/remote-agent-tasks/" + url.PathEscape(strings.TrimSpace(taskID)) + "/artifacts"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, req, &payload); err != nil {
+		return RemoteAgentArtifactRef{}, err
+	}
+	return payload.Artifact, nil
+}
+
 func (c *Client) GetRemoteAgentTaskTranscript(ctx context.Context, taskID string) (RemoteAgentTaskTranscript, error) {
 	var out RemoteAgentTaskTranscript
 	route := "/api/v1/remote-agent-tasks/" + url.PathEscape(strings.TrimSpace(taskID)) + "/transcript"
@@ -492,6 +704,46 @@ func (c *Client) GetRemoteAgentTaskArtifacts(ctx context.Context, taskID string)
 	return out, nil
 }
 
+// DownloadRemoteAgentTaskArtifact streams stored artifact content into w and
+// returns the digest the control plane reported for it.
+func (c *Client) DownloadRemoteAgentTaskArtifact(ctx context.Context, taskID string, artifactID string, w io.Writer) (string, int64, error) {
+	route := "/api/v1/remote-agent-tasks/" + url.PathEscape(strings.TrimSpace(taskID)) + "/artifacts/" + url.PathEscape(strings.TrimSpace(artifactID)) + "/content"
+	requestURL := c.apiURL(route, nil)
+	c.debugf("-> %s %s", http.MethodGet, requestURL)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return "", 0, fmt.Errorf("build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", 0, fmt.Errorf("execute request: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	contentType := strings.TrimSpace(resp.Header.Get("Content-Type"))
+	c.debugf("<- %s %s status=%d content-type=%q", http.MethodGet, requestURL, resp.StatusCode, contentType)
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		b, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		apiErr := &APIError{StatusCode: resp.StatusCode, Body: string(b), Method: http.MethodGet, URL: requestURL, ContentType: contentType}
+		var payload struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(b, &payload) == nil {
+			apiErr.Message = strings.TrimSpace(payload.Error)
+		}
+		if apiErr.Message == "" {
+			apiErr.Message = strings.TrimSpace(string(b))
+		}
+		return "", 0, apiErr
+	}
+	n, err := io.Copy(w, resp.Body)
+	if err != nil {
+		return "", n, fmt.Errorf("read artifact content: %w", err)
+	}
+	return strings.TrimSpace(resp.Header.Get("X-Kocao-Artifact-Digest")), n, nil
+}
+
 func (c *Client) controlRemoteAgentTask(ctx context.Context, taskID string, action string) (RemoteAgentTask, error) {
 	var out RemoteAgentTask
 	route := "/api/v1/remote-agent-tasks/" + url.PathEscape(strings.TrimSpace(taskID)) + "/" + action
@@ -501,6 +753,87 @@ func (c *Client) controlRemoteAgentTask(ctx context.Context, taskID string, acti
 	return out, nil
 }
 
+func (c *Client) ListRemoteAgentWorkflows(ctx context.Context) ([]RemoteAgentWorkflow, error) {
+	var payload struct {
+		RemoteAgentWorkflows []RemoteAgentWorkflow `json:"remoteAgentWorkflows"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/api/v1/remote-agent-workflows", nil, nil, &payload); err != nil {
+		return nil, err
+	}
+	return payload.RemoteAgentWorkflows, nil
+}
+
+func (c *Client) CreateRemoteAgentWorkflow(ctx context.Context, req RemoteAgentWorkflowCreateRequest) (RemoteAgentWorkflow, error) {
+	var out RemoteAgentWorkflow
+	if err := c.doJSON(ctx, http.MethodPost, "/api/v1/remote-agent-workflows", nil, req, &out); err != nil {
+		return RemoteAgentWorkflow{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetRemoteAgentWorkflow(ctx context.Context, workflowID string) (RemoteAgentWorkflow, error) {
+	var out RemoteAgentWorkflow
+	route := "/api/v1/remote-agent-workflows/" + url.PathEscape(strings.TrimSpace(workflowID))
+	if err := c.doJSON(ctx, http.MethodGet, route, nil, nil, &out); err != nil {
+		return RemoteAgentWorkflow{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) CancelRemoteAgentWorkflow(ctx context.Context, workflowID string) (RemoteAgentWorkflow, error) {
+	var out RemoteAgentWorkflow
+	route := "/api/v1/remote-agent-workflows/" + url.PathEscape(strings.TrimSpace(workflowID)) + "/cancel"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, nil, &out); err != nil {
+		return RemoteAgentWorkflow{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) ListAPITokens(ctx context.Context) ([]APIToken, error) {
+	var payload struct {
+		Tokens []APIToken `json:"tokens"`
+	}
+	if err := c.doJSON(ctx, http.MethodGet, "/api/v1/tokens", nil, nil, &payload); err != nil {
+		return nil, err
+	}
+	return payload.Tokens, nil
+}
+
+func (c *Client) GetAPIToken(ctx context.Context, tokenID string) (APIToken, error) {
+	var out APIToken
+	route := "/api/v1/tokens/" + url.PathEscape(strings.TrimSpace(tokenID))
+	if err := c.doJSON(ctx, http.MethodGet, route, nil, nil, &out); err != nil {
+		return APIToken{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) CreateAPIToken(ctx context.Context, req APITokenCreateRequest) (APIToken, error) {
+	var out APIToken
+	if err := c.doJSON(ctx, http.MethodPost, "/api/v1/tokens", nil, req, &out); err != nil {
+		return APIToken{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) RevokeAPIToken(ctx context.Context, tokenID string) (APIToken, error) {
+	var out APIToken
+	route := "/api/v1/tokens/" + url.PathEscape(strings.TrimSpace(tokenID))
+	if err := c.doJSON(ctx, http.MethodDelete, route, nil, nil, &out); err != nil {
+		return APIToken{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) RotateAPIToken(ctx context.Context, tokenID string) (APIToken, error) {
+	var out APIToken
+	route := "/api/v1/tokens/" + url.PathEscape(strings.TrimSpace(tokenID)) + "/rotate"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, nil, &out); err != nil {
+		return APIToken{}, err
+	}
+	return out, nil
+}
+
 func (c *Client) CreateAttachToken(ctx context.Context, workspaceSessionID string, role string, mode string) (AttachTokenResponse, error) {
 	var out AttachTokenResponse
 	body := map[string]string{"role": role, "mode": mode}
@@ -559,6 +892,24 @@ func (c *Client) RefreshSymphonyProject(ctx context.Context, name string) (Symph
 	return c.controlSymphonyProject(ctx, name, "refresh")
 }
 
+func (c *Client) RequestSymphonyPlan(ctx context.Context, name string) (SymphonyPlan, error) {
+	var out SymphonyPlan
+	route := "/api/v1/symphony-projects/" + url.PathEscape(strings.TrimSpace(name)) + "/plan"
+	if err := c.doJSON(ctx, http.MethodPost, route, nil, nil, &out); err != nil {
+		return SymphonyPlan{}, err
+	}
+	return out, nil
+}
+
+func (c *Client) GetSymphonyPlan(ctx context.Context, name string) (SymphonyPlan, error) {
+	var out SymphonyPlan
+	route := "/api/v1/symphony-projects/" + url.PathEscape(strings.TrimSpace(name)) + "/plan"
+	if err := c.doJSON(ctx, http.MethodGet, route, nil, nil, &out); err != nil {
+		return SymphonyPlan{}, err
+	}
+	return out, nil
+}
+
 func (c *Client) controlSymphonyProject(ctx context.Context, name string, action string) (SymphonyProject, error) {
 	var out SymphonyProject
 	route := "/api/v1/symphony-projects/" + url.PathEscape(strings.TrimSpace(name)) + "/" + action
@@ -0,0 +1,295 @@
+package controlplanecli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"sigs.k8s.io/yaml"
+)
+
+func runRemoteAgentWorkflowsCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		writeRemoteAgentWorkflowsUsage(stdout)
+		return nil
+	}
+
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	switch sub {
+	case "ls", "list":
+		return runRemoteAgentWorkflowListCommand(args[1:], cfg, stdout, stderr)
+	case "get", "inspect":
+		return runRemoteAgentWorkflowGetCommand(args[1:], cfg, stdout, stderr)
+	case "create", "run":
+		return runRemoteAgentWorkflowCreateCommand(args[1:], cfg, stdout, stderr)
+	case "cancel":
+		return runRemoteAgentWorkflowCancelCommand(args[1:], cfg, stdout, stderr)
+	case "help", "-h", "--help":
+		writeRemoteAgentWorkflowsUsage(stdout)
+		return nil
+	default:
+		return fmt.Errorf("unknown remote-agents workflows subcommand %q", sub)
+	}
+}
+
+func runRemoteAgentWorkflowListCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	fs := newFlagSet("kocao remote-agents workflows list", stderr)
+	state := fs.String("state", "", "filter by state (comma-separated)")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	workflows, err := client.ListRemoteAgentWorkflows(context.Background())
+	if err != nil {
+		return err
+	}
+	if states := parseCSVSet(*state); len(states) != 0 {
+		filtered := workflows[:0]
+		for _, workflow := range workflows {
+			if _, ok := states[strings.ToLower(workflow.State)]; ok {
+				filtered = append(filtered, workflow)
+			}
+		}
+		workflows = filtered
+	}
+	sort.SliceStable(workflows, func(i, j int) bool { return workflows[i].CreatedAt > workflows[j].CreatedAt })
+
+	if format == "json" {
+		return writeJSON(stdout, workflows)
+	}
+	if len(workflows) == 0 {
+		_, _ = fmt.Fprintln(stdout, "no remote agent workflows found")
+		return nil
+	}
+	return writeRemoteAgentWorkflowsTable(stdout, workflows)
+}
+
+func runRemoteAgentWorkflowGetCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	workflowID, flagArgs, err := parseRequiredRemoteAgentWorkflowID("get", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao remote-agents workflows get", stderr)
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	workflow, err := client.GetRemoteAgentWorkflow(context.Background(), workflowID)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, workflow)
+	}
+	return writeRemoteAgentWorkflowSummary(stdout, workflow)
+}
+
+func runRemoteAgentWorkflowCreateCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	fs := newFlagSet("kocao remote-agents workflows create", stderr)
+	file := fs.String("file", "", "workflow definition (YAML or JSON)")
+	name := fs.String("name", "", "override the workflow name")
+	team := fs.String("team", "", "share the workflow with a team")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if strings.TrimSpace(*file) == "" {
+		return fmt.Errorf("usage: kocao remote-agents workflows create --file <path> [--name NAME] [--team TEAM] [--output table|json]")
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+
+	raw, err := os.ReadFile(*file)
+	if err != nil {
+		return fmt.Errorf("read workflow file: %w", err)
+	}
+	var req RemoteAgentWorkflowCreateRequest
+	if err := yaml.UnmarshalStrict(raw, &req); err != nil {
+		return fmt.Errorf("parse workflow file: %w", err)
+	}
+	if strings.TrimSpace(*name) != "" {
+		req.Name = strings.TrimSpace(*name)
+	}
+	if strings.TrimSpace(*team) != "" {
+		req.Team = strings.TrimSpace(*team)
+	}
+	if len(req.Steps) == 0 {
+		return fmt.Errorf("workflow file %s defines no steps", *file)
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	workflow, err := client.CreateRemoteAgentWorkflow(context.Background(), req)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, workflow)
+	}
+	_, _ = fmt.Fprintf(stdout, "created workflow %s with %d steps\n", workflow.ID, len(workflow.Steps))
+	return writeRemoteAgentWorkflowSummary(stdout, workflow)
+}
+
+func runRemoteAgentWorkflowCancelCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	workflowID, flagArgs, err := parseRequiredRemoteAgentWorkflowID("cancel", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao remote-agents workflows cancel", stderr)
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	workflow, err := client.CancelRemoteAgentWorkflow(context.Background(), workflowID)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, workflow)
+	}
+	_, _ = fmt.Fprintf(stdout, "cancelled workflow %s\n", workflow.ID)
+	return writeRemoteAgentWorkflowSummary(stdout, workflow)
+}
+
+func writeRemoteAgentWorkflowsUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "kocao remote-agents workflows")
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "Usage:")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents workflows list [--state running,failed] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents workflows get <workflow-id> [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents workflows create --file <path> [--name NAME] [--team TEAM] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents workflows cancel <workflow-id> [--output table|json]")
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "The workflow file lists steps; each step names a target agent or pool, a prompt,")
+	_, _ = fmt.Fprintln(w, "and the steps it dependsOn. Output artifacts of a step are passed to its dependants.")
+}
+
+func parseRequiredRemoteAgentWorkflowID(command string, args []string) (string, []string, error) {
+	usage := fmt.Sprintf("usage: kocao remote-agents workflows %s <workflow-id> [--output table|json]", command)
+	if len(args) == 0 || strings.HasPrefix(strings.TrimSpace(args[0]), "-") {
+		return "", nil, fmt.Errorf("%s", usage)
+	}
+	workflowID := strings.TrimSpace(args[0])
+	if workflowID == "" {
+		return "", nil, fmt.Errorf("%s", usage)
+	}
+	return workflowID, args[1:], nil
+}
+
+func writeRemoteAgentWorkflowsTable(w io.Writer, workflows []RemoteAgentWorkflow) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "WORKFLOW ID\tNAME\tSTATE\tSTEPS\tCREATED"); err != nil {
+		return err
+	}
+	for _, workflow := range workflows {
+		completed := 0
+		for _, step := range workflow.Steps {
+			if step.State == "completed" {
+				completed++
+			}
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%d/%d\t%s\n",
+			valueOrDash(workflow.ID),
+			valueOrDash(workflow.Name),
+			valueOrDash(workflow.State),
+			completed,
+			len(workflow.Steps),
+			valueOrDash(workflow.CreatedAt),
+		); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func writeRemoteAgentWorkflowSummary(w io.Writer, workflow RemoteAgentWorkflow) error {
+	lines := []struct {
+		label string
+		value string
+	}{
+		{"Workflow ID", valueOrDash(workflow.ID)},
+		{"Name", valueOrDash(workflow.Name)},
+		{"State", valueOrDash(workflow.State)},
+		{"Requested By", valueOrDash(workflow.RequestedBy)},
+		{"Created", valueOrDash(workflow.CreatedAt)},
+		{"Completed", valueOrDash(workflow.CompletedAt)},
+		{"Cancelled", valueOrDash(workflow.CancelledAt)},
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "%-14s %s\n", line.label+":", line.value); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintln(w, "\nSteps:"); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "STEP\tSTATE\tTARGET\tDEPENDS ON\tTASK ID\tWAITING"); err != nil {
+		return err
+	}
+	for _, step := range workflow.Steps {
+		target := step.Target.AgentName
+		if target == "" {
+			target = step.Target.AgentID
+		}
+		if target == "" && step.Target.PoolName != "" {
+			target = "pool/" + step.Target.PoolName
+		}
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			valueOrDash(step.Name),
+			valueOrDash(step.State),
+			valueOrDash(target),
+			valueOrDash(strings.Join(step.DependsOn, ",")),
+			valueOrDash(step.TaskID),
+			valueOrDash(step.WaitingReason),
+		); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
@@ -2,10 +2,13 @@ package controlplanecli
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"sort"
 	"strings"
 	"syscall"
@@ -24,6 +27,8 @@ func runRemoteAgentsCommand(args []string, cfg Config, stdout io.Writer, stderr
 		return runRemoteAgentAgentsCommand(args[1:], cfg, stdout, stderr)
 	case "tasks", "task":
 		return runRemoteAgentTasksCommand(args[1:], cfg, stdout, stderr)
+	case "workflows", "workflow":
+		return runRemoteAgentWorkflowsCommand(args[1:], cfg, stdout, stderr)
 	case "help", "-h", "--help":
 		writeRemoteAgentsUsage(stdout)
 		return nil
@@ -44,6 +49,8 @@ func runRemoteAgentAgentsCommand(args []string, cfg Config, stdout io.Writer, st
 		return runRemoteAgentListCommand(args[1:], cfg, stdout, stderr)
 	case "get", "inspect":
 		return runRemoteAgentGetCommand(args[1:], cfg, stdout, stderr)
+	case "heartbeat":
+		return runRemoteAgentHeartbeatCommand(args[1:], cfg, stdout, stderr)
 	case "help", "-h", "--help":
 		writeRemoteAgentAgentsUsage(stdout)
 		return nil
@@ -72,6 +79,16 @@ func runRemoteAgentTasksCommand(args []string, cfg Config, stdout io.Writer, std
 		return runRemoteAgentTaskTranscriptCommand(args[1:], cfg, stdout, stderr)
 	case "artifacts":
 		return runRemoteAgentTaskArtifactsCommand(args[1:], cfg, stdout, stderr)
+	case "start":
+		return runRemoteAgentTaskStartCommand(args[1:], cfg, stdout, stderr)
+	case "complete":
+		return runRemoteAgentTaskCompleteCommand(args[1:], cfg, stdout, stderr)
+	case "fail":
+		return runRemoteAgentTaskFailCommand(args[1:], cfg, stdout, stderr)
+	case "log":
+		return runRemoteAgentTaskLogCommand(args[1:], cfg, stdout, stderr)
+	case "attach-artifact":
+		return runRemoteAgentTaskAttachArtifactCommand(args[1:], cfg, stdout, stderr)
 	case "help", "-h", "--help":
 		writeRemoteAgentTasksUsage(stdout)
 		return nil
@@ -165,6 +182,50 @@ func runRemoteAgentGetCommand(args []string, cfg Config, stdout io.Writer, stder
 	return writeRemoteAgentSummary(stdout, agent)
 }
 
+func runRemoteAgentHeartbeatCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	usage := "usage: kocao remote-agents agents heartbeat <agent-id-or-name> [--pool NAME] [--workspace ID] [--output table|json]"
+	if len(args) == 0 {
+		return fmt.Errorf("%s", usage)
+	}
+	ref := strings.TrimSpace(args[0])
+	if ref == "" || strings.HasPrefix(ref, "-") {
+		return fmt.Errorf("%s", usage)
+	}
+
+	fs := newFlagSet("kocao remote-agents agents heartbeat", stderr)
+	pool := fs.String("pool", "", "disambiguate by pool name")
+	workspace := fs.String("workspace", "", "disambiguate by workspace session ID")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	agent, err := resolveRemoteAgent(context.Background(), client, ref, *pool, *workspace)
+	if err != nil {
+		return err
+	}
+	agent, err = client.HeartbeatRemoteAgent(context.Background(), agent.ID)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, agent)
+	}
+	_, _ = fmt.Fprintf(stdout, "heartbeat recorded for %s (%s)\n", agent.Name, agent.Availability)
+	return nil
+}
+
 func runRemoteAgentTaskListCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
 	fs := newFlagSet("kocao remote-agents tasks list", stderr)
 	agent := fs.String("agent", "", "filter by agent name")
@@ -245,10 +306,11 @@ func runRemoteAgentTaskDispatchCommand(args []string, cfg Config, stdout io.Writ
 	fs := newFlagSet("kocao remote-agents tasks dispatch", stderr)
 	agentID := fs.String("agent-id", "", "dispatch directly to a durable agent ID")
 	agentName := fs.String("agent", "", "dispatch to a named agent (preferred)")
-	pool := fs.String("pool", "", "disambiguate named agent by pool name")
+	pool := fs.String("pool", "", "queue for any agent in the pool, or disambiguate a named agent")
 	workspace := fs.String("workspace", "", "disambiguate named agent by workspace session ID")
 	prompt := fs.String("prompt", "", "task prompt text")
 	promptFile := fs.String("prompt-file", "", "read task prompt from file")
+	priority := fs.Int("priority", 0, "queue priority; higher runs first")
 	timeoutSeconds := fs.Int("timeout-seconds", 0, "task timeout in seconds")
 	output := fs.String("output", "table", "output format: table or json")
 	if err := fs.Parse(args); err != nil {
@@ -257,8 +319,8 @@ func runRemoteAgentTaskDispatchCommand(args []string, cfg Config, stdout io.Writ
 	if fs.NArg() != 0 {
 		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
 	}
-	if strings.TrimSpace(*agentID) == "" && strings.TrimSpace(*agentName) == "" {
-		return fmt.Errorf("must specify --agent <name> or --agent-id <id>")
+	if strings.TrimSpace(*agentID) == "" && strings.TrimSpace(*agentName) == "" && strings.TrimSpace(*pool) == "" {
+		return fmt.Errorf("must specify --agent <name>, --agent-id <id> or --pool <name>")
 	}
 	if strings.TrimSpace(*agentID) != "" && strings.TrimSpace(*agentName) != "" {
 		return fmt.Errorf("--agent and --agent-id are mutually exclusive")
@@ -301,6 +363,7 @@ func runRemoteAgentTaskDispatchCommand(args []string, cfg Config, stdout io.Writ
 	task, err := client.CreateRemoteAgentTask(context.Background(), RemoteAgentTaskCreateRequest{
 		Target:         target,
 		Prompt:         promptText,
+		Priority:       *priority,
 		TimeoutSeconds: int32(*timeoutSeconds),
 	})
 	if err != nil {
@@ -309,7 +372,11 @@ func runRemoteAgentTaskDispatchCommand(args []string, cfg Config, stdout io.Writ
 	if format == "json" {
 		return writeJSON(stdout, task)
 	}
-	_, _ = fmt.Fprintf(stdout, "dispatched task %s to %s\n", task.ID, remoteAgentTaskDisplayAgent(task))
+	if task.State == "queued" {
+		_, _ = fmt.Fprintf(stdout, "queued task %s in pool %s at position %d\n", task.ID, task.PoolName, task.QueuePosition)
+	} else {
+		_, _ = fmt.Fprintf(stdout, "dispatched task %s to %s\n", task.ID, remoteAgentTaskDisplayAgent(task))
+	}
 	return writeRemoteAgentTaskSummary(stdout, task)
 }
 
@@ -381,6 +448,9 @@ func runRemoteAgentTaskTranscriptCommand(args []string, cfg Config, stdout io.Wr
 }
 
 func runRemoteAgentTaskArtifactsCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	if len(args) != 0 && strings.EqualFold(strings.TrimSpace(args[0]), "download") {
+		return runRemoteAgentTaskArtifactDownloadCommand(args[1:], cfg, stdout, stderr)
+	}
 	taskID, flagArgs, err := parseRequiredRemoteAgentTaskID("artifacts", args)
 	if err != nil {
 		return err
@@ -415,16 +485,332 @@ func runRemoteAgentTaskArtifactsCommand(args []string, cfg Config, stdout io.Wri
 	return writeRemoteAgentArtifactsTable(stdout, artifacts.InputArtifacts, artifacts.OutputArtifacts)
 }
 
+func runRemoteAgentTaskArtifactDownloadCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	usage := "usage: kocao remote-agents tasks artifacts download <task-id> <artifact-id-or-name> [--out PATH|-]"
+	if len(args) < 2 || strings.HasPrefix(strings.TrimSpace(args[0]), "-") || strings.HasPrefix(strings.TrimSpace(args[1]), "-") {
+		return fmt.Errorf("%s", usage)
+	}
+	taskID, ref := strings.TrimSpace(args[0]), strings.TrimSpace(args[1])
+	fs := newFlagSet("kocao remote-agents tasks artifacts download", stderr)
+	out := fs.String("out", "", "destination file (default: artifact name in the current directory; - for stdout)")
+	if err := fs.Parse(args[2:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	artifacts, err := client.GetRemoteAgentTaskArtifacts(ctx, taskID)
+	if err != nil {
+		return err
+	}
+	artifact, err := resolveRemoteAgentArtifact(append(artifacts.OutputArtifacts, artifacts.InputArtifacts...), ref)
+	if err != nil {
+		return err
+	}
+
+	dest := strings.TrimSpace(*out)
+	if dest == "" {
+		dest = filepath.Base(artifact.Name)
+	}
+	if dest == "-" {
+		hasher := sha256.New()
+		digest, _, err := client.DownloadRemoteAgentTaskArtifact(ctx, taskID, artifact.ID, io.MultiWriter(stdout, hasher))
+		if err != nil {
+			return err
+		}
+		return verifyRemoteAgentArtifactDigest(artifact, digest, hex.EncodeToString(hasher.Sum(nil)))
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(dest), "."+filepath.Base(dest)+".download-*")
+	if err != nil {
+		return fmt.Errorf("create %s: %w", dest, err)
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }()
+	hasher := sha256.New()
+	digest, size, err := client.DownloadRemoteAgentTaskArtifact(ctx, taskID, artifact.ID, io.MultiWriter(tmp, hasher))
+	if closeErr := tmp.Close(); err == nil && closeErr != nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+	if err := verifyRemoteAgentArtifactDigest(artifact, digest, hex.EncodeToString(hasher.Sum(nil))); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		return fmt.Errorf("write %s: %w", dest, err)
+	}
+	_, _ = fmt.Fprintf(stderr, "downloaded %s (%d bytes, %s) to %s\n", artifact.Name, size, valueOrDash(artifact.Digest), dest)
+	return nil
+}
+
+func resolveRemoteAgentArtifact(artifacts []RemoteAgentArtifactRef, ref string) (RemoteAgentArtifactRef, error) {
+	for _, artifact := range artifacts {
+		if artifact.ID == ref {
+			return artifact, nil
+		}
+	}
+	var matches []RemoteAgentArtifactRef
+	for _, artifact := range artifacts {
+		if artifact.Name == ref {
+			matches = append(matches, artifact)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return RemoteAgentArtifactRef{}, fmt.Errorf("artifact %q not found", ref)
+	case 1:
+		return matches[0], nil
+	default:
+		return RemoteAgentArtifactRef{}, fmt.Errorf("artifact name %q is ambiguous; use the artifact id", ref)
+	}
+}
+
+// verifyRemoteAgentArtifactDigest checks downloaded content against both the
+// recorded artifact digest and the digest the server sent with the content.
+func verifyRemoteAgentArtifactDigest(artifact RemoteAgentArtifactRef, served string, computed string) error {
+	for _, expected := range []string{artifact.Digest, served} {
+		expected = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(expected)), "sha256:")
+		if expected != "" && expected != computed {
+			return fmt.Errorf("artifact %s digest mismatch: expected sha256:%s, got sha256:%s", artifact.Name, expected, computed)
+		}
+	}
+	return nil
+}
+
+func runRemoteAgentTaskStartCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	taskID, flagArgs, err := parseRequiredRemoteAgentTaskID("start", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao remote-agents tasks start", stderr)
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	task, err := client.StartRemoteAgentTask(context.Background(), taskID)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, task)
+	}
+	_, _ = fmt.Fprintf(stdout, "started task %s\n", task.ID)
+	return writeRemoteAgentTaskSummary(stdout, task)
+}
+
+func runRemoteAgentTaskCompleteCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	taskID, flagArgs, err := parseRequiredRemoteAgentTaskID("complete", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao remote-agents tasks complete", stderr)
+	summary := fs.String("summary", "", "result summary")
+	outcome := fs.String("outcome", "", "result outcome (default: completed)")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	task, err := client.CompleteRemoteAgentTask(context.Background(), taskID, RemoteAgentTaskCompleteRequest{
+		Summary: strings.TrimSpace(*summary),
+		Outcome: strings.TrimSpace(*outcome),
+	})
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, task)
+	}
+	_, _ = fmt.Fprintf(stdout, "completed task %s\n", task.ID)
+	return writeRemoteAgentTaskSummary(stdout, task)
+}
+
+func runRemoteAgentTaskFailCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	taskID, flagArgs, err := parseRequiredRemoteAgentTaskID("fail", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao remote-agents tasks fail", stderr)
+	summary := fs.String("summary", "", "failure summary")
+	outcome := fs.String("outcome", "", "result outcome (default: failed)")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	task, err := client.FailRemoteAgentTask(context.Background(), taskID, RemoteAgentTaskCompleteRequest{
+		Summary: strings.TrimSpace(*summary),
+		Outcome: strings.TrimSpace(*outcome),
+	})
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, task)
+	}
+	_, _ = fmt.Fprintf(stdout, "failed task %s\n", task.ID)
+	return writeRemoteAgentTaskSummary(stdout, task)
+}
+
+func runRemoteAgentTaskLogCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	taskID, flagArgs, err := parseRequiredRemoteAgentTaskID("log", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao remote-agents tasks log", stderr)
+	role := fs.String("role", "agent", "transcript role: system, user, agent, or tool")
+	kind := fs.String("kind", "message", "transcript entry kind")
+	text := fs.String("text", "", "transcript text")
+	textFile := fs.String("text-file", "", "read transcript text from file")
+	eventRef := fs.String("event-ref", "", "reference to the originating agent event")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if strings.TrimSpace(*text) != "" && strings.TrimSpace(*textFile) != "" {
+		return fmt.Errorf("--text and --text-file are mutually exclusive")
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	entryText := strings.TrimSpace(*text)
+	if strings.TrimSpace(*textFile) != "" {
+		b, err := os.ReadFile(strings.TrimSpace(*textFile))
+		if err != nil {
+			return fmt.Errorf("read text file: %w", err)
+		}
+		entryText = strings.TrimSpace(string(b))
+	}
+	if entryText == "" && strings.TrimSpace(*eventRef) == "" {
+		return fmt.Errorf("usage: kocao remote-agents tasks log <task-id> --text <text>|--text-file <path>|--event-ref <ref> [--role agent] [--kind message] [--output table|json]")
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	entry, err := client.AppendRemoteAgentTaskTranscript(context.Background(), taskID, RemoteAgentTranscriptAppendRequest{
+		Role:     strings.ToLower(strings.TrimSpace(*role)),
+		Kind:     strings.TrimSpace(*kind),
+		Text:     entryText,
+		EventRef: strings.TrimSpace(*eventRef),
+	})
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, entry)
+	}
+	_, _ = fmt.Fprintf(stdout, "appended transcript entry %d to task %s\n", entry.Sequence, taskID)
+	return nil
+}
+
+func runRemoteAgentTaskAttachArtifactCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	taskID, flagArgs, err := parseRequiredRemoteAgentTaskID("attach-artifact", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao remote-agents tasks attach-artifact", stderr)
+	name := fs.String("name", "", "artifact name")
+	kind := fs.String("kind", "file", "artifact kind: file, patch, bundle, or report")
+	path := fs.String("path", "", "artifact path inside the workspace")
+	uri := fs.String("uri", "", "artifact URI")
+	mediaType := fs.String("media-type", "", "artifact media type")
+	digest := fs.String("digest", "", "artifact content digest")
+	sizeBytes := fs.Int64("size-bytes", 0, "artifact size in bytes")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	if strings.TrimSpace(*name) == "" || (strings.TrimSpace(*path) == "" && strings.TrimSpace(*uri) == "") {
+		return fmt.Errorf("usage: kocao remote-agents tasks attach-artifact <task-id> --name <name> --path <path>|--uri <uri> [--kind file|patch|bundle|report] [--media-type TYPE] [--digest DIGEST] [--size-bytes N] [--output table|json]")
+	}
+	if *sizeBytes < 0 {
+		return fmt.Errorf("--size-bytes must be >= 0")
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	artifact, err := client.AttachRemoteAgentTaskArtifact(context.Background(), taskID, RemoteAgentArtifactCreateRequest{
+		Name:      strings.TrimSpace(*name),
+		Kind:      strings.ToLower(strings.TrimSpace(*kind)),
+		MediaType: strings.TrimSpace(*mediaType),
+		Path:      strings.TrimSpace(*path),
+		URI:       strings.TrimSpace(*uri),
+		Digest:    strings.TrimSpace(*digest),
+		SizeBytes: *sizeBytes,
+	})
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, artifact)
+	}
+	_, _ = fmt.Fprintf(stdout, "attached artifact %s to task %s\n", artifact.ID, taskID)
+	return writeRemoteAgentArtifactsTable(stdout, nil, []RemoteAgentArtifactRef{artifact})
+}
+
 func writeRemoteAgentsUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "kocao remote-agents")
 	_, _ = fmt.Fprintln(w, "")
 	_, _ = fmt.Fprintln(w, "Usage:")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents agents <subcommand>")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks <subcommand>")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents workflows <subcommand>")
 	_, _ = fmt.Fprintln(w, "")
 	_, _ = fmt.Fprintln(w, "Subcommands:")
-	_, _ = fmt.Fprintln(w, "  agents   Inspect named remote agents")
-	_, _ = fmt.Fprintln(w, "  tasks    Dispatch and inspect remote-agent tasks")
+	_, _ = fmt.Fprintln(w, "  agents     Inspect named remote agents")
+	_, _ = fmt.Fprintln(w, "  tasks      Dispatch and inspect remote-agent tasks")
+	_, _ = fmt.Fprintln(w, "  workflows  Run task dependency graphs across remote agents")
 }
 
 func writeRemoteAgentAgentsUsage(w io.Writer) {
@@ -433,18 +819,27 @@ func writeRemoteAgentAgentsUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "Usage:")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents agents list [--pool NAME] [--workspace ID] [--availability idle|busy|offline] [--output table|json]")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents agents get <agent-id-or-name> [--pool NAME] [--workspace ID] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents agents heartbeat <agent-id-or-name> [--pool NAME] [--workspace ID] [--output table|json]")
 }
 
 func writeRemoteAgentTasksUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "kocao remote-agents tasks")
 	_, _ = fmt.Fprintln(w, "")
 	_, _ = fmt.Fprintln(w, "Usage:")
-	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks list [--agent NAME] [--pool NAME] [--state assigned,running] [--active] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks list [--agent NAME] [--pool NAME] [--state queued,assigned,running] [--active] [--output table|json]")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks get <task-id> [--output table|json]")
-	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks dispatch --agent <name>|--agent-id <id> --prompt <text>|--prompt-file <path> [--pool NAME] [--workspace ID] [--timeout-seconds N] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks dispatch --agent <name>|--agent-id <id>|--pool <name> --prompt <text>|--prompt-file <path> [--workspace ID] [--priority N] [--timeout-seconds N] [--output table|json]")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks cancel <task-id> [--output table|json]")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks transcript <task-id> [--output table|json]")
 	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks artifacts <task-id> [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks artifacts download <task-id> <artifact-id-or-name> [--out PATH|-]")
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "Worker commands:")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks start <task-id> [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks log <task-id> --text <text>|--text-file <path> [--role agent] [--kind message] [--event-ref REF] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks attach-artifact <task-id> --name <name> --path <path>|--uri <uri> [--kind file|patch|bundle|report] [--media-type TYPE] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks complete <task-id> [--summary TEXT] [--outcome TEXT] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao remote-agents tasks fail <task-id> [--summary TEXT] [--outcome TEXT] [--output table|json]")
 }
 
 type remoteAgentFilter struct {
@@ -589,6 +984,9 @@ func resolveRemoteAgentTaskTarget(ctx context.Context, client *Client, agentName
 	if agentID != "" {
 		return RemoteAgentTaskTarget{AgentID: agentID}, nil
 	}
+	if agentName == "" {
+		return RemoteAgentTaskTarget{PoolName: pool, WorkspaceSessionID: workspace}, nil
+	}
 	agent, err := resolveRemoteAgent(ctx, client, agentName, pool, workspace)
 	if err != nil {
 		return RemoteAgentTaskTarget{}, err
@@ -643,6 +1041,7 @@ func writeRemoteAgentSummary(w io.Writer, agent RemoteAgent) error {
 		{"Runtime", valueOrDash(string(agent.Runtime))},
 		{"Kind", valueOrDash(string(agent.Agent))},
 		{"Last Activity", valueOrDash(agent.LastActivityAt)},
+		{"Heartbeat", valueOrDash(agent.LastHeartbeatAt)},
 	}
 	for _, line := range lines {
 		if _, err := fmt.Fprintf(w, "%-14s %s\n", line.label+":", line.value); err != nil {
@@ -657,7 +1056,22 @@ func writeRemoteAgentSummary(w io.Writer, agent RemoteAgent) error {
 			return err
 		}
 	}
-	return nil
+	if len(agent.AvailabilityHistory) == 0 {
+		return nil
+	}
+	if _, err := fmt.Fprintln(w, "\nAvailability history:"); err != nil {
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "AT\tAVAILABILITY\tREASON"); err != nil {
+		return err
+	}
+	for _, ev := range agent.AvailabilityHistory {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\n", valueOrDash(ev.At), valueOrDash(ev.Availability), valueOrDash(ev.Reason)); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
 }
 
 func writeRemoteAgentTasksTable(w io.Writer, tasks []RemoteAgentTask) error {
@@ -687,6 +1101,7 @@ func writeRemoteAgentTaskSummary(w io.Writer, task RemoteAgentTask) error {
 	}{
 		{"Task ID", valueOrDash(task.ID)},
 		{"State", valueOrDash(task.State)},
+		{"Priority", fmt.Sprintf("%d", task.Priority)},
 		{"Agent", valueOrDash(task.AgentName)},
 		{"Agent ID", valueOrDash(task.AgentID)},
 		{"Pool", valueOrDash(task.PoolName)},
@@ -694,16 +1109,33 @@ func writeRemoteAgentTaskSummary(w io.Writer, task RemoteAgentTask) error {
 		{"Attempt", fmt.Sprintf("%d", task.Attempt)},
 		{"Retries", fmt.Sprintf("%d", task.RetryCount)},
 		{"Timeout", fmt.Sprintf("%ds", task.TimeoutSeconds)},
+		{"Queued", valueOrDash(task.QueuedAt)},
 		{"Assigned", valueOrDash(task.AssignedAt)},
 		{"Started", valueOrDash(task.StartedAt)},
 		{"Completed", valueOrDash(task.CompletedAt)},
 		{"Cancelled", valueOrDash(task.CancelledAt)},
 	}
+
 	for _, line := range lines {
 		if _, err := fmt.Fprintf(w, "%-14s %s\n", line.label+":", line.value); err != nil {
 			return err
 		}
 	}
+	if task.WorkflowID != "" {
+		if _, err := fmt.Fprintf(w, "%-14s %s (step %s)\n", "Workflow:", task.WorkflowID, valueOrDash(task.WorkflowStep)); err != nil {
+			return err
+		}
+	}
+	if task.QueuePosition > 0 {
+		if _, err := fmt.Fprintf(w, "%-14s %d\n", "Queue Pos:", task.QueuePosition); err != nil {
+			return err
+		}
+	}
+	if task.QueuedAt != "" {
+		if _, err := fmt.Fprintf(w, "%-14s %ds\n", "Waited:", task.WaitSeconds); err != nil {
+			return err
+		}
+	}
 	if strings.TrimSpace(task.Prompt) != "" {
 		if _, err := fmt.Fprintf(w, "%-14s %s\n", "Prompt:", task.Prompt); err != nil {
 			return err
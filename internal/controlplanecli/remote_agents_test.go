@@ -2,6 +2,8 @@ package controlplanecli
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
@@ -67,6 +69,53 @@ func TestRemoteAgentGetByName(t *testing.T) {
 	}
 }
 
+func TestRemoteAgentHeartbeatShowsAvailabilityHistory(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	agent := map[string]any{
+		"id": "agent-1", "name": "reviewer", "availability": "idle", "lastHeartbeatAt": "2026-04-14T12:05:00Z",
+		"availabilityHistory": []map[string]any{
+			{"at": "2026-04-14T12:00:00Z", "availability": "idle", "reason": "registered"},
+			{"at": "2026-04-14T12:02:00Z", "availability": "offline", "reason": "pod pod-1 not found"},
+			{"at": "2026-04-14T12:05:00Z", "availability": "idle", "reason": "heartbeat received"},
+		},
+	}
+	var heartbeats int
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/remote-agents" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(map[string]any{"remoteAgents": []map[string]any{agent}})
+		case r.URL.Path == "/api/v1/remote-agents/agent-1/heartbeat" && r.Method == http.MethodPost:
+			heartbeats++
+			_ = json.NewEncoder(w).Encode(agent)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "agents", "heartbeat", "reviewer"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("heartbeat exit code = %d stderr=%s", code, stderr.String())
+	}
+	if heartbeats != 1 || !strings.Contains(stdout.String(), "heartbeat recorded for reviewer (idle)") {
+		t.Fatalf("heartbeats=%d output=%s", heartbeats, stdout.String())
+	}
+
+	stdout.Reset()
+	code = Main([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "agents", "get", "reviewer"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("get exit code = %d stderr=%s", code, stderr.String())
+	}
+	for _, want := range []string{"Heartbeat:", "2026-04-14T12:05:00Z", "Availability history:", "AVAILABILITY", "pod pod-1 not found", "heartbeat received"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
 func TestRemoteAgentTaskDispatchWithPromptFile(t *testing.T) {
 	t.Setenv(EnvToken, "")
 	tempDir := t.TempDir()
@@ -116,6 +165,44 @@ func TestRemoteAgentTaskDispatchWithPromptFile(t *testing.T) {
 	}
 }
 
+func TestRemoteAgentTaskDispatchToPoolQueuesTask(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/remote-agent-tasks" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		var payload map[string]any
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Fatalf("decode payload: %v", err)
+		}
+		target := payload["target"].(map[string]any)
+		if target["poolName"] != "backend" || target["agentId"] != nil {
+			t.Fatalf("target = %#v, want pool-only target", target)
+		}
+		if payload["priority"] != float64(5) {
+			t.Fatalf("priority = %#v", payload["priority"])
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"id": "task-2", "state": "queued", "poolName": "backend", "priority": 5, "queuePosition": 2, "queuedAt": "2026-01-01T00:00:00Z", "waitSeconds": 4, "attempt": 1,
+		})
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "tasks", "dispatch", "--pool", "backend", "--priority", "5", "--prompt", "Review the patch"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d stderr=%s", code, stderr.String())
+	}
+	for _, want := range []string{"queued task task-2 in pool backend at position 2", "Queue Pos:     2", "Waited:        4s"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("expected %q in output, got: %s", want, stdout.String())
+		}
+	}
+}
+
 func TestRemoteAgentTaskDispatchRejectsAmbiguousNamedAgent(t *testing.T) {
 	t.Setenv(EnvToken, "")
 
@@ -165,7 +252,7 @@ func TestRemoteAgentTaskDispatchRejectsMissingSelector(t *testing.T) {
 	if code != 1 {
 		t.Fatalf("exit code = %d, want 1; stderr=%s", code, stderr.String())
 	}
-	if !strings.Contains(stderr.String(), "must specify --agent <name> or --agent-id <id>") {
+	if !strings.Contains(stderr.String(), "must specify --agent <name>, --agent-id <id> or --pool <name>") {
 		t.Fatalf("expected missing selector error, got: %s", stderr.String())
 	}
 	if stdout.Len() != 0 {
@@ -468,3 +555,220 @@ func TestRemoteAgentTaskArtifactsEmptyTable(t *testing.T) {
 		t.Fatalf("expected empty artifacts message, got: %s", stdout.String())
 	}
 }
+
+func TestRemoteAgentTaskArtifactDownloadVerifiesDigest(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	content := []byte("diff --git a/x b/x\n")
+	sum := sha256.Sum256(content)
+	digest := "sha256:" + hex.EncodeToString(sum[:])
+	served := content
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/remote-agent-tasks/task-1/artifacts":
+			_ = json.NewEncoder(w).Encode(map[string]any{
+				"taskId":          "task-1",
+				"outputArtifacts": []map[string]any{{"id": "art-1", "name": "fix.patch", "kind": "patch", "digest": digest}},
+			})
+		case "/api/v1/remote-agent-tasks/task-1/artifacts/art-1/content":
+			w.Header().Set("X-Kocao-Artifact-Digest", digest)
+			_, _ = w.Write(served)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	dest := filepath.Join(t.TempDir(), "out.patch")
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "tasks", "artifacts", "download", "task-1", "fix.patch", "--out", dest}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d stderr=%s", code, stderr.String())
+	}
+	if got, err := os.ReadFile(dest); err != nil || !bytes.Equal(got, content) {
+		t.Fatalf("downloaded content = %q, %v", got, err)
+	}
+
+	served = []byte("tampered")
+	tampered := filepath.Join(t.TempDir(), "tampered.patch")
+	stderr.Reset()
+	code = Main([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "tasks", "artifacts", "download", "task-1", "art-1", "--out", tampered}, &stdout, &stderr)
+	if code == 0 || !strings.Contains(stderr.String(), "digest mismatch") {
+		t.Fatalf("expected digest mismatch, code=%d stderr=%s", code, stderr.String())
+	}
+	if _, err := os.Stat(tampered); !os.IsNotExist(err) {
+		t.Fatalf("tampered download was written: %v", err)
+	}
+}
+
+func TestRemoteAgentTaskWorkerCommands(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	var logged RemoteAgentTranscriptAppendRequest
+	var attached RemoteAgentArtifactCreateRequest
+	var completed, failed RemoteAgentTaskCompleteRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/v1/remote-agent-tasks/task-1/start":
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "task-1", "state": "running", "agentName": "reviewer"})
+		case "/api/v1/remote-agent-tasks/task-1/transcript":
+			_ = json.NewDecoder(r.Body).Decode(&logged)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"taskId": "task-1", "entry": map[string]any{"sequence": 3, "role": logged.Role, "text": logged.Text}})
+		case "/api/v1/remote-agent-tasks/task-1/artifacts":
+			_ = json.NewDecoder(r.Body).Decode(&attached)
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"taskId": "task-1", "artifact": map[string]any{"id": "art-1", "name": attached.Name, "kind": attached.Kind, "path": attached.Path}})
+		case "/api/v1/remote-agent-tasks/task-1/complete":
+			_ = json.NewDecoder(r.Body).Decode(&completed)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "task-1", "state": "completed", "agentName": "reviewer", "result": map[string]any{"summary": completed.Summary, "outcome": "completed"}})
+		case "/api/v1/remote-agent-tasks/task-2/fail":
+			_ = json.NewDecoder(r.Body).Decode(&failed)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "task-2", "state": "failed", "agentName": "reviewer", "result": map[string]any{"summary": failed.Summary, "outcome": "failed"}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	run := func(args ...string) string {
+		t.Helper()
+		var stdout bytes.Buffer
+		var stderr bytes.Buffer
+		code := Main(append([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "tasks"}, args...), &stdout, &stderr)
+		if code != 0 {
+			t.Fatalf("%v exit code = %d stderr=%s", args, code, stderr.String())
+		}
+		return stdout.String()
+	}
+
+	if out := run("start", "task-1"); !strings.Contains(out, "started task task-1") || !strings.Contains(out, "running") {
+		t.Fatalf("unexpected start output: %s", out)
+	}
+	if out := run("log", "task-1", "--text", "Reading files"); !strings.Contains(out, "appended transcript entry 3") {
+		t.Fatalf("unexpected log output: %s", out)
+	}
+	if logged.Role != "agent" || logged.Kind != "message" || logged.Text != "Reading files" {
+		t.Fatalf("unexpected transcript request: %+v", logged)
+	}
+	if out := run("attach-artifact", "task-1", "--name", "review.md", "--kind", "report", "--path", "/workspace/review.md"); !strings.Contains(out, "attached artifact art-1") || !strings.Contains(out, "/workspace/review.md") {
+		t.Fatalf("unexpected attach output: %s", out)
+	}
+	if attached.Kind != "report" || attached.Name != "review.md" {
+		t.Fatalf("unexpected artifact request: %+v", attached)
+	}
+	if out := run("complete", "task-1", "--summary", "Looks good"); !strings.Contains(out, "completed task task-1") {
+		t.Fatalf("unexpected complete output: %s", out)
+	}
+	if completed.Summary != "Looks good" {
+		t.Fatalf("unexpected complete request: %+v", completed)
+	}
+	if out := run("fail", "task-2", "--summary", "tests failed"); !strings.Contains(out, "failed task task-2") {
+		t.Fatalf("unexpected fail output: %s", out)
+	}
+	if failed.Summary != "tests failed" {
+		t.Fatalf("unexpected fail request: %+v", failed)
+	}
+}
+
+func TestRemoteAgentTaskLogRequiresText(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", "http://127.0.0.1:1", "--token", "test-token", "remote-agents", "tasks", "log", "task-1"}, &stdout, &stderr)
+	if code != 2 {
+		t.Fatalf("exit code = %d, want 2 (stderr=%s)", code, stderr.String())
+	}
+}
+
+func TestRemoteAgentWorkflowCreateFromYAMLAndGet(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	workflow := map[string]any{
+		"id": "wf-1", "name": "plan-review", "state": "running",
+		"steps": []map[string]any{
+			{"name": "plan", "target": map[string]any{"agentName": "planner"}, "prompt": "Plan", "taskId": "task-1", "state": "assigned"},
+			{"name": "review", "dependsOn": []string{"plan"}, "target": map[string]any{"poolName": "reviewers"}, "prompt": "Review", "state": "pending"},
+		},
+	}
+	var created map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/remote-agent-workflows" && r.Method == http.MethodPost:
+			if err := json.NewDecoder(r.Body).Decode(&created); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(workflow)
+		case r.URL.Path == "/api/v1/remote-agent-workflows/wf-1" && r.Method == http.MethodGet:
+			_ = json.NewEncoder(w).Encode(workflow)
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "workflow.yaml")
+	definition := `name: plan-review
+steps:
+  - name: plan
+    target: {agentName: planner}
+    prompt: Plan
+  - name: review
+    dependsOn: [plan]
+    target: {poolName: reviewers}
+    prompt: Review
+`
+	if err := os.WriteFile(path, []byte(definition), 0o600); err != nil {
+		t.Fatalf("write workflow file: %v", err)
+	}
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "workflows", "create", "--file", path, "--team", "platform"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("create exit code = %d stderr=%s", code, stderr.String())
+	}
+	steps, _ := created["steps"].([]any)
+	if created["team"] != "platform" || len(steps) != 2 {
+		t.Fatalf("unexpected create request: %+v", created)
+	}
+	if review, _ := steps[1].(map[string]any); review["dependsOn"].([]any)[0] != "plan" {
+		t.Fatalf("dependsOn not sent: %+v", review)
+	}
+	if !strings.Contains(stdout.String(), "created workflow wf-1 with 2 steps") {
+		t.Fatalf("unexpected create output:\n%s", stdout.String())
+	}
+
+	stdout.Reset()
+	code = Main([]string{"--api-url", srv.URL, "--token", "test-token", "remote-agents", "workflows", "get", "wf-1"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("get exit code = %d stderr=%s", code, stderr.String())
+	}
+	for _, want := range []string{"plan-review", "STEP", "planner", "pool/reviewers", "pending"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestRemoteAgentWorkflowCreateRejectsUnknownFields(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	path := filepath.Join(t.TempDir(), "workflow.yaml")
+	if err := os.WriteFile(path, []byte("steps:\n  - name: plan\n    depends_on: [x]\n"), 0o600); err != nil {
+		t.Fatalf("write workflow file: %v", err)
+	}
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", "http://127.0.0.1:1", "--token", "test-token", "remote-agents", "workflows", "create", "--file", path}, &stdout, &stderr)
+	if code == 0 || !strings.Contains(stderr.String(), "parse workflow file") {
+		t.Fatalf("exit code = %d stderr=%s", code, stderr.String())
+	}
+}
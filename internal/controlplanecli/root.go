@@ -81,6 +81,8 @@ func Main(args []string, stdout io.Writer, stderr io.Writer) int {
 		cmdErr = runAgentCommand(rest[1:], cfg, stdout, stderr)
 	case "remote-agents", "remote-agent", "orchestration":
 		cmdErr = runRemoteAgentsCommand(rest[1:], cfg, stdout, stderr)
+	case "tokens", "token":
+		cmdErr = runTokensCommand(rest[1:], cfg, stdout, stderr)
 	default:
 		cmdErr = fmt.Errorf("unknown command %q", cmd)
 	}
@@ -179,6 +179,89 @@ func TestMainSymphonyListAndPause(t *testing.T) {
 	}
 }
 
+func TestMainSymphonyGetShowsRanks(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/symphony-projects/demo" || r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"name": "demo",
+			"spec": map[string]any{"source": map[string]any{"project": map[string]any{"owner": "withakay", "number": 42}}},
+			"status": map[string]any{
+				"phase":        "Ready",
+				"activeClaims": []map[string]any{{"itemId": "item-1", "rank": 1, "attempt": 1, "phase": "Running", "issue": map[string]any{"repository": "withakay/kocao", "number": 7}}},
+				"recentSkips": []map[string]any{
+					{"itemId": "item-2", "rank": 2, "reason": "concurrency_limit", "message": "held back: 1 of 1 project slots in use", "issue": map[string]any{"repository": "withakay/kocao", "number": 9}},
+					{"itemId": "item-3", "reason": "inactive_state", "issue": map[string]any{"repository": "withakay/kocao", "number": 3}},
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "symphony", "get", "demo"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d stderr=%s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Claims:", "1     withakay/kocao#7", "2     withakay/kocao#9  concurrency_limit", "-     withakay/kocao#3  inactive_state"} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
+func TestMainSymphonyPlanWaitsForOperator(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	requested := false
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/symphony-projects/demo/plan" {
+			http.NotFound(w, r)
+			return
+		}
+		switch r.Method {
+		case http.MethodPost:
+			requested = true
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "demo", "requestedAt": "t2", "ready": false, "plan": map[string]any{"requestedAt": "t1"}})
+		case http.MethodGet:
+			if !requested {
+				t.Errorf("plan read before it was requested")
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"name": "demo", "requestedAt": "t2", "ready": true, "plan": map[string]any{
+				"requestedAt":   "t2",
+				"eligibleItems": 2,
+				"items": []map[string]any{
+					{"action": "claim", "itemId": "item-1", "rank": 1, "attempt": 1, "issue": map[string]any{"repository": "withakay/kocao", "number": 7}, "prompt": "Fix issue 7."},
+					{"action": "skip", "itemId": "item-2", "rank": 2, "reason": "concurrency_limit", "message": "held back", "issue": map[string]any{"repository": "withakay/kocao", "number": 9}},
+				},
+			}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "symphony", "plan", "demo"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d stderr=%s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Eligible: 2", "claim   1     withakay/kocao#7  1        -", "skip    2     withakay/kocao#9  -        concurrency_limit held back", "--- claim withakay/kocao#7 (attempt 1) ---\nFix issue 7."} {
+		if !strings.Contains(out, want) {
+			t.Fatalf("expected %q in output, got:\n%s", want, out)
+		}
+	}
+}
+
 func TestMainSymphonyCreateJSON(t *testing.T) {
 	t.Setenv(EnvToken, "")
 	tempDir := t.TempDir()
@@ -213,3 +296,54 @@ func TestMainSymphonyCreateJSON(t *testing.T) {
 		t.Fatalf("expected created project JSON, got: %s", stdout.String())
 	}
 }
+
+func TestMainSessionSnapshotAndRestore(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]string
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/workspace-sessions/sess-1/snapshots":
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["name"] != "checkpoint" {
+				t.Fatalf("snapshot body = %v", body)
+			}
+			w.WriteHeader(http.StatusAccepted)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "snap-1", "workspaceSessionId": "sess-1", "method": "volumeSnapshot", "phase": "Pending"})
+		case r.Method == http.MethodPost && r.URL.Path == "/api/v1/workspace-snapshots/snap-1/restore":
+			_ = json.NewDecoder(r.Body).Decode(&body)
+			if body["displayName"] != "fork" {
+				t.Fatalf("restore body = %v", body)
+			}
+			w.WriteHeader(http.StatusCreated)
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "sess-2", "displayName": "fork", "restoredFrom": "snap-1"})
+		case r.Method == http.MethodDelete && r.URL.Path == "/api/v1/workspace-snapshots/snap-1":
+			_ = json.NewEncoder(w).Encode(map[string]any{"deleted": true})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var stdout, stderr bytes.Buffer
+	if code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "sessions", "snapshot", "sess-1", "--name", "checkpoint"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("snapshot exit code = %d stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Snapshot snap-1 (volumeSnapshot) is Pending") {
+		t.Fatalf("snapshot output = %s", stdout.String())
+	}
+	stdout.Reset()
+	if code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "sessions", "restore", "snap-1", "--display-name", "fork"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("restore exit code = %d stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "workspace session sess-2 (fork)") {
+		t.Fatalf("restore output = %s", stdout.String())
+	}
+	stdout.Reset()
+	if code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "sessions", "delete-snapshot", "snap-1"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("delete-snapshot exit code = %d stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "Deleted snapshot snap-1") {
+		t.Fatalf("delete-snapshot output = %s", stdout.String())
+	}
+}
@@ -29,6 +29,14 @@ func runSessionsCommand(args []string, cfg Config, stdout io.Writer, stderr io.W
 		return runSessionLogsCommand(cfg, args[1:], stdout, stderr)
 	case "attach":
 		return runSessionAttachCommand(cfg, args[1:], stdout, stderr)
+	case "snapshot":
+		return runSessionSnapshotCommand(ctx, cfg, args[1:], stdout, stderr)
+	case "snapshots":
+		return runSessionSnapshotsCommand(ctx, cfg, args[1:], stdout, stderr)
+	case "restore":
+		return runSessionRestoreCommand(ctx, cfg, args[1:], stdout, stderr)
+	case "delete-snapshot":
+		return runSessionDeleteSnapshotCommand(ctx, cfg, args[1:], stdout, stderr)
 	case "help", "-h", "--help":
 		writeSessionsUsage(stdout)
 		return nil
@@ -103,7 +111,157 @@ func runSessionGetCommand(ctx context.Context, cfg Config, args []string, stdout
 	_, _ = fmt.Fprintf(stdout, "Name:       %s\n", name)
 	_, _ = fmt.Fprintf(stdout, "Phase:      %s\n", valueOrDash(session.Phase))
 	_, _ = fmt.Fprintf(stdout, "Repo URL:   %s\n", valueOrDash(session.RepoURL))
+	_, _ = fmt.Fprintf(stdout, "Owner:      %s\n", valueOrDash(session.Owner))
+	_, _ = fmt.Fprintf(stdout, "Team:       %s\n", valueOrDash(session.Team))
 	_, _ = fmt.Fprintf(stdout, "Created At: %s\n", valueOrDash(session.CreatedAt))
+	if session.RestoredFrom != "" {
+		_, _ = fmt.Fprintf(stdout, "Restored:   %s (%s)\n", session.RestoredFrom, valueOrDash(session.RestorePhase))
+	}
+	return nil
+}
+
+func runSessionSnapshotCommand(ctx context.Context, cfg Config, args []string, stdout io.Writer, stderr io.Writer) error {
+	const usage = "usage: kocao sessions snapshot <workspace-session-id> [--name NAME] [--json]"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	sessionID := strings.TrimSpace(args[0])
+	if sessionID == "" || strings.HasPrefix(sessionID, "-") {
+		return fmt.Errorf(usage)
+	}
+
+	fs := flag.NewFlagSet("kocao sessions snapshot", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	name := fs.String("name", "", "snapshot name")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	snapshot, err := client.CreateWorkspaceSnapshot(ctx, sessionID, strings.TrimSpace(*name))
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return writeJSON(stdout, snapshot)
+	}
+	_, _ = fmt.Fprintf(stdout, "Snapshot %s (%s) is %s\n", snapshot.ID, snapshot.Method, snapshot.Phase)
+	return nil
+}
+
+func runSessionSnapshotsCommand(ctx context.Context, cfg Config, args []string, stdout io.Writer, stderr io.Writer) error {
+	const usage = "usage: kocao sessions snapshots <workspace-session-id> [--json]"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	sessionID := strings.TrimSpace(args[0])
+	if sessionID == "" || strings.HasPrefix(sessionID, "-") {
+		return fmt.Errorf(usage)
+	}
+
+	fs := flag.NewFlagSet("kocao sessions snapshots", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	jsonOut := fs.Bool("json", false, "output JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	snapshots, err := client.ListWorkspaceSnapshots(ctx, sessionID)
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return writeJSON(stdout, map[string]any{"snapshots": snapshots})
+	}
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "ID\tNAME\tMETHOD\tPHASE\tCREATED"); err != nil {
+		return err
+	}
+	for _, s := range snapshots {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", s.ID, valueOrDash(s.Name), s.Method, s.Phase, valueOrDash(s.CreatedAt)); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func runSessionRestoreCommand(ctx context.Context, cfg Config, args []string, stdout io.Writer, stderr io.Writer) error {
+	const usage = "usage: kocao sessions restore <snapshot-id> [--display-name NAME] [--json]"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	snapshotID := strings.TrimSpace(args[0])
+	if snapshotID == "" || strings.HasPrefix(snapshotID, "-") {
+		return fmt.Errorf(usage)
+	}
+
+	fs := flag.NewFlagSet("kocao sessions restore", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	displayName := fs.String("display-name", "", "display name of the new workspace session")
+	jsonOut := fs.Bool("json", false, "output JSON")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	session, err := client.RestoreWorkspaceSnapshot(ctx, snapshotID, strings.TrimSpace(*displayName))
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return writeJSON(stdout, session)
+	}
+	_, _ = fmt.Fprintf(stdout, "Restored snapshot %s into workspace session %s (%s)\n", snapshotID, session.ID, session.DisplayName)
+	return nil
+}
+
+func runSessionDeleteSnapshotCommand(ctx context.Context, cfg Config, args []string, stdout io.Writer, stderr io.Writer) error {
+	const usage = "usage: kocao sessions delete-snapshot <snapshot-id>"
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+	snapshotID := strings.TrimSpace(args[0])
+	if snapshotID == "" || strings.HasPrefix(snapshotID, "-") {
+		return fmt.Errorf(usage)
+	}
+
+	fs := flag.NewFlagSet("kocao sessions delete-snapshot", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	if err := client.DeleteWorkspaceSnapshot(ctx, snapshotID); err != nil {
+		return err
+	}
+	_, _ = fmt.Fprintf(stdout, "Deleted snapshot %s\n", snapshotID)
 	return nil
 }
 
@@ -153,6 +311,9 @@ func runSessionStatusCommand(ctx context.Context, cfg Config, args []string, std
 	_, _ = fmt.Fprintf(stdout, "Run:        %s\n", status.Run.ID)
 	_, _ = fmt.Fprintf(stdout, "Profile:    %s\n", formatHarnessImageProfile(status.Run.ImageProfile))
 	_, _ = fmt.Fprintf(stdout, "Run Phase:  %s\n", valueOrDash(status.Run.Phase))
+	if status.Run.Reason != "" {
+		_, _ = fmt.Fprintf(stdout, "Reason:     %s: %s\n", status.Run.Reason, valueOrDash(status.Run.Message))
+	}
 	_, _ = fmt.Fprintf(stdout, "Pod Name:   %s\n", valueOrDash(status.Run.PodName))
 	return nil
 }
@@ -173,6 +334,7 @@ func writeRootUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  symphony   Manage Symphony projects")
 	_, _ = fmt.Fprintln(w, "  agent      Manage sandbox agents")
 	_, _ = fmt.Fprintln(w, "  remote-agents  Manage orchestrated remote agents and tasks")
+	_, _ = fmt.Fprintln(w, "  tokens     Manage control-plane API tokens")
 	_, _ = fmt.Fprintln(w, "")
 	_, _ = fmt.Fprintf(w, "Environment:\n  %s (default: http://127.0.0.1:8080)\n  %s\n  %s (example: 15s)\n  %s (true|false)\n", EnvAPIURL, EnvToken, EnvTimeout, EnvVerbose)
 }
@@ -186,6 +348,10 @@ func writeSessionsUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  kocao sessions status <workspace-session-id> [--json]")
 	_, _ = fmt.Fprintln(w, "  kocao sessions logs <workspace-session-id> [--tail N] [--container NAME] [--follow] [--json]")
 	_, _ = fmt.Fprintln(w, "  kocao sessions attach <workspace-session-id> [--driver] [--collab]")
+	_, _ = fmt.Fprintln(w, "  kocao sessions snapshot <workspace-session-id> [--name NAME] [--json]")
+	_, _ = fmt.Fprintln(w, "  kocao sessions snapshots <workspace-session-id> [--json]")
+	_, _ = fmt.Fprintln(w, "  kocao sessions restore <snapshot-id> [--display-name NAME] [--json]")
+	_, _ = fmt.Fprintln(w, "  kocao sessions delete-snapshot <snapshot-id>")
 }
 
 func writeSessionsTable(w io.Writer, sessions []WorkspaceSession) error {
@@ -9,6 +9,9 @@ import (
 	"os"
 	"strings"
 	"text/tabwriter"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
 )
 
 func runSymphonyCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
@@ -32,6 +35,8 @@ func runSymphonyCommand(args []string, cfg Config, stdout io.Writer, stderr io.W
 		return runSymphonyControlCommand(ctx, cfg, args[1:], stdout, stderr, "resume")
 	case "refresh":
 		return runSymphonyControlCommand(ctx, cfg, args[1:], stdout, stderr, "refresh")
+	case "plan":
+		return runSymphonyPlanCommand(ctx, cfg, args[1:], stdout, stderr)
 	case "help", "-h", "--help":
 		writeSymphonyUsage(stdout)
 		return nil
@@ -100,9 +105,53 @@ func runSymphonyGetCommand(ctx context.Context, cfg Config, args []string, stdou
 	_, _ = fmt.Fprintf(stdout, "Active:       %d\n", project.Status.RunningItems)
 	_, _ = fmt.Fprintf(stdout, "Retrying:     %d\n", project.Status.RetryingItems)
 	_, _ = fmt.Fprintf(stdout, "Created At:   %s\n", valueOrDash(project.CreatedAt))
+	return writeSymphonyQueue(stdout, project.Status)
+}
+
+// writeSymphonyQueue lists active claims and recent skips with their priority
+// rank; skips outside the candidate set have no rank.
+func writeSymphonyQueue(w io.Writer, status operatorv1alpha1.SymphonyProjectStatus) error {
+	if len(status.ActiveClaims) != 0 {
+		_, _ = fmt.Fprintln(w, "")
+		_, _ = fmt.Fprintln(w, "Claims:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "  RANK\tISSUE\tATTEMPT\tPHASE")
+		for _, claim := range status.ActiveClaims {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%d\t%s\n", symphonyRank(claim.Rank), symphonyIssueRef(claim.Issue), claim.Attempt, valueOrDash(claim.Phase))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	if len(status.RecentSkips) != 0 {
+		_, _ = fmt.Fprintln(w, "")
+		_, _ = fmt.Fprintln(w, "Skipped:")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		_, _ = fmt.Fprintln(tw, "  RANK\tISSUE\tREASON\tMESSAGE")
+		for _, skip := range status.RecentSkips {
+			_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\n", symphonyRank(skip.Rank), symphonyIssueRef(skip.Issue), valueOrDash(skip.Reason), valueOrDash(skip.Message))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func symphonyRank(rank int32) string {
+	if rank <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", rank)
+}
+
+func symphonyIssueRef(issue operatorv1alpha1.SymphonyProjectIssueRefStatus) string {
+	if issue.Repository == "" {
+		return "-"
+	}
+	return fmt.Sprintf("%s#%d", issue.Repository, issue.Number)
+}
+
 func runSymphonyCreateCommand(ctx context.Context, cfg Config, args []string, stdout io.Writer, stderr io.Writer) error {
 	fs := flag.NewFlagSet("kocao symphony create", flag.ContinueOnError)
 	fs.SetOutput(stderr)
@@ -180,6 +229,112 @@ func runSymphonyControlCommand(ctx context.Context, cfg Config, args []string, s
 	return nil
 }
 
+// runSymphonyPlanCommand asks the operator for a dry-run sync and waits for it.
+// Nothing in the plan is acted on, so it is safe against a paused project.
+func runSymphonyPlanCommand(ctx context.Context, cfg Config, args []string, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("usage: kocao symphony plan <project-name> [--timeout 60s] [--no-prompts] [--json]")
+	}
+	name := strings.TrimSpace(args[0])
+	if name == "" || strings.HasPrefix(name, "-") {
+		return fmt.Errorf("usage: kocao symphony plan <project-name> [--timeout 60s] [--no-prompts] [--json]")
+	}
+	fs := flag.NewFlagSet("kocao symphony plan", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	jsonOut := fs.Bool("json", false, "output JSON")
+	noPrompts := fs.Bool("no-prompts", false, "omit rendered WORKFLOW.md prompts")
+	timeout := fs.Duration("timeout", 60*time.Second, "how long to wait for the operator")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, *timeout)
+	defer cancel()
+	requested, err := client.RequestSymphonyPlan(ctx, name)
+	if err != nil {
+		return err
+	}
+	plan, err := pollSymphonyPlan(ctx, client, name, requested.RequestedAt, 2*time.Second)
+	if err != nil {
+		return err
+	}
+	if *jsonOut {
+		return writeJSON(stdout, plan)
+	}
+	return writeSymphonyPlan(stdout, *plan.Plan, !*noPrompts)
+}
+
+// pollSymphonyPlan waits until the operator answers the plan requested at
+// requestedAt or ctx ends.
+func pollSymphonyPlan(ctx context.Context, client *Client, name, requestedAt string, interval time.Duration) (SymphonyPlan, error) {
+	for {
+		plan, err := client.GetSymphonyPlan(ctx, name)
+		if err == nil && plan.Ready && plan.Plan != nil && plan.RequestedAt == requestedAt {
+			return plan, nil
+		}
+		if err != nil && ctx.Err() == nil {
+			return SymphonyPlan{}, err
+		}
+		timer := time.NewTimer(interval)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return SymphonyPlan{}, fmt.Errorf("timed out waiting for the operator to plan symphony project %s", name)
+		case <-timer.C:
+		}
+	}
+}
+
+func writeSymphonyPlan(w io.Writer, plan operatorv1alpha1.SymphonyProjectPlanStatus, prompts bool) error {
+	if plan.Error != "" {
+		return fmt.Errorf("plan failed: %s", plan.Error)
+	}
+	_, _ = fmt.Fprintf(w, "Eligible: %d\n", plan.EligibleItems)
+	if len(plan.Items) == 0 {
+		_, _ = fmt.Fprintln(w, "No items.")
+		return nil
+	}
+	_, _ = fmt.Fprintln(w, "")
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "ACTION\tRANK\tISSUE\tATTEMPT\tDETAIL")
+	for _, item := range plan.Items {
+		detail := strings.TrimSpace(strings.Join([]string{item.Reason, item.Message}, " "))
+		if item.ReadyAt != nil {
+			detail = strings.TrimSpace(detail + " ready at " + item.ReadyAt.UTC().Format(time.RFC3339))
+		}
+		if item.PromptError != "" {
+			detail = strings.TrimSpace(detail + " prompt error: " + item.PromptError)
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", item.Action, symphonyRank(item.Rank), symphonyIssueRef(item.Issue), symphonyAttempt(item.Attempt), valueOrDash(detail))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if !prompts {
+		return nil
+	}
+	for _, item := range plan.Items {
+		if item.Prompt == "" {
+			continue
+		}
+		_, _ = fmt.Fprintf(w, "\n--- %s %s (attempt %d) ---\n%s\n", item.Action, symphonyIssueRef(item.Issue), item.Attempt, strings.TrimRight(item.Prompt, "\n"))
+	}
+	return nil
+}
+
+func symphonyAttempt(attempt int32) string {
+	if attempt <= 0 {
+		return "-"
+	}
+	return fmt.Sprintf("%d", attempt)
+}
+
 func writeSymphonyUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "kocao symphony")
 	_, _ = fmt.Fprintln(w, "")
@@ -190,6 +345,7 @@ func writeSymphonyUsage(w io.Writer) {
 	_, _ = fmt.Fprintln(w, "  kocao symphony pause <project-name> [--json]")
 	_, _ = fmt.Fprintln(w, "  kocao symphony resume <project-name> [--json]")
 	_, _ = fmt.Fprintln(w, "  kocao symphony refresh <project-name> [--json]")
+	_, _ = fmt.Fprintln(w, "  kocao symphony plan <project-name> [--timeout 60s] [--no-prompts] [--json]")
 }
 
 func writeSymphonyTable(w io.Writer, projects []SymphonyProject) error {
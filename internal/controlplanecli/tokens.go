@@ -0,0 +1,270 @@
+package controlplanecli
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+func runTokensCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	if len(args) == 0 {
+		writeTokensUsage(stdout)
+		return nil
+	}
+
+	sub := strings.ToLower(strings.TrimSpace(args[0]))
+	switch sub {
+	case "ls", "list":
+		return runTokenListCommand(args[1:], cfg, stdout, stderr)
+	case "get", "inspect":
+		return runTokenGetCommand(args[1:], cfg, stdout, stderr)
+	case "create", "mint":
+		return runTokenCreateCommand(args[1:], cfg, stdout, stderr)
+	case "revoke":
+		return runTokenControlCommand("revoke", args[1:], cfg, stdout, stderr)
+	case "rotate":
+		return runTokenControlCommand("rotate", args[1:], cfg, stdout, stderr)
+	case "help", "-h", "--help":
+		writeTokensUsage(stdout)
+		return nil
+	default:
+		return fmt.Errorf("unknown tokens subcommand %q", sub)
+	}
+}
+
+func runTokenListCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	fs := newFlagSet("kocao tokens list", stderr)
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	tokens, err := client.ListAPITokens(context.Background())
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, tokens)
+	}
+	if len(tokens) == 0 {
+		_, _ = fmt.Fprintln(stdout, "no tokens found")
+		return nil
+	}
+	return writeTokensTable(stdout, tokens)
+}
+
+func runTokenGetCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	tokenID, flagArgs, err := parseRequiredTokenID("get", args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao tokens get", stderr)
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	token, err := client.GetAPIToken(context.Background(), tokenID)
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, token)
+	}
+	return writeTokenSummary(stdout, token)
+}
+
+func runTokenCreateCommand(args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	fs := newFlagSet("kocao tokens create", stderr)
+	name := fs.String("name", "", "human-readable token name")
+	scopes := fs.String("scope", "", "comma-separated scopes to grant")
+	expiresIn := fs.Duration("expires-in", 0, "token lifetime (e.g. 720h); omit for no expiry")
+	team := fs.String("team", "", "team whose resources the token may share")
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	var scopeList []string
+	for _, scope := range strings.Split(*scopes, ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopeList = append(scopeList, scope)
+		}
+	}
+	if len(scopeList) == 0 {
+		return fmt.Errorf("usage: kocao tokens create --scope <scope>[,<scope>...] [--name NAME] [--team TEAM] [--expires-in DURATION] [--output table|json]")
+	}
+	if *expiresIn < 0 {
+		return fmt.Errorf("--expires-in must be positive")
+	}
+	if *expiresIn > 0 && *expiresIn < time.Second {
+		return fmt.Errorf("--expires-in must be at least 1s")
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	token, err := client.CreateAPIToken(context.Background(), APITokenCreateRequest{
+		Name:       strings.TrimSpace(*name),
+		Scopes:     scopeList,
+		TTLSeconds: int64(*expiresIn / time.Second),
+		Team:       strings.TrimSpace(*team),
+	})
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, token)
+	}
+	_, _ = fmt.Fprintf(stdout, "created token %s\n", token.ID)
+	return writeTokenSecret(stdout, token)
+}
+
+func runTokenControlCommand(action string, args []string, cfg Config, stdout io.Writer, stderr io.Writer) error {
+	tokenID, flagArgs, err := parseRequiredTokenID(action, args)
+	if err != nil {
+		return err
+	}
+	fs := newFlagSet("kocao tokens "+action, stderr)
+	output := fs.String("output", "table", "output format: table or json")
+	if err := fs.Parse(flagArgs); err != nil {
+		return err
+	}
+	if fs.NArg() != 0 {
+		return fmt.Errorf("unexpected argument: %s", fs.Arg(0))
+	}
+	format, err := parseAgentOutputFormat(*output, "table", "json")
+	if err != nil {
+		return err
+	}
+	client, err := NewClient(cfg)
+	if err != nil {
+		return err
+	}
+	var token APIToken
+	switch action {
+	case "revoke":
+		token, err = client.RevokeAPIToken(context.Background(), tokenID)
+	case "rotate":
+		token, err = client.RotateAPIToken(context.Background(), tokenID)
+	}
+	if err != nil {
+		return err
+	}
+	if format == "json" {
+		return writeJSON(stdout, token)
+	}
+	if action == "rotate" {
+		_, _ = fmt.Fprintf(stdout, "rotated token %s\n", token.ID)
+		return writeTokenSecret(stdout, token)
+	}
+	_, _ = fmt.Fprintf(stdout, "revoked token %s\n", token.ID)
+	return nil
+}
+
+func writeTokensUsage(w io.Writer) {
+	_, _ = fmt.Fprintln(w, "kocao tokens")
+	_, _ = fmt.Fprintln(w, "")
+	_, _ = fmt.Fprintln(w, "Usage:")
+	_, _ = fmt.Fprintln(w, "  kocao tokens list [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao tokens get <token-id> [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao tokens create --scope <scope>[,<scope>...] [--name NAME] [--team TEAM] [--expires-in DURATION] [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao tokens revoke <token-id> [--output table|json]")
+	_, _ = fmt.Fprintln(w, "  kocao tokens rotate <token-id> [--output table|json]")
+}
+
+func parseRequiredTokenID(command string, args []string) (string, []string, error) {
+	usage := fmt.Sprintf("usage: kocao tokens %s <token-id> [--output table|json]", command)
+	if len(args) == 0 || strings.HasPrefix(strings.TrimSpace(args[0]), "-") {
+		return "", nil, fmt.Errorf("%s", usage)
+	}
+	tokenID := strings.TrimSpace(args[0])
+	if tokenID == "" {
+		return "", nil, fmt.Errorf("%s", usage)
+	}
+	return tokenID, args[1:], nil
+}
+
+func writeTokensTable(w io.Writer, tokens []APIToken) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if _, err := fmt.Fprintln(tw, "TOKEN ID\tNAME\tSTATUS\tSCOPES\tEXPIRES\tLAST USED"); err != nil {
+		return err
+	}
+	for _, token := range tokens {
+		if _, err := fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n",
+			valueOrDash(token.ID),
+			valueOrDash(token.Name),
+			valueOrDash(token.Status),
+			valueOrDash(strings.Join(token.Scopes, ",")),
+			valueOrDash(token.ExpiresAt),
+			valueOrDash(token.LastUsedAt),
+		); err != nil {
+			return err
+		}
+	}
+	return tw.Flush()
+}
+
+func writeTokenSummary(w io.Writer, token APIToken) error {
+	lines := []struct {
+		label string
+		value string
+	}{
+		{"Token ID", valueOrDash(token.ID)},
+		{"Name", valueOrDash(token.Name)},
+		{"Status", valueOrDash(token.Status)},
+		{"Scopes", valueOrDash(strings.Join(token.Scopes, ","))},
+		{"Team", valueOrDash(token.Team)},
+		{"Created", valueOrDash(token.CreatedAt)},
+		{"Created By", valueOrDash(token.CreatedBy)},
+		{"Expires", valueOrDash(token.ExpiresAt)},
+		{"Last Used", valueOrDash(token.LastUsedAt)},
+		{"Revoked", valueOrDash(token.RevokedAt)},
+	}
+	for _, line := range lines {
+		if _, err := fmt.Fprintf(w, "%-14s %s\n", line.label+":", line.value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTokenSecret(w io.Writer, token APIToken) error {
+	if err := writeTokenSummary(w, token); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(w, "%-14s %s\n", "Secret:", token.Token); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintln(w, "Store this secret now; it cannot be retrieved again.")
+	return err
+}
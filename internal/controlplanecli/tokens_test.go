@@ -0,0 +1,116 @@
+package controlplanecli
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestTokensListTable(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/tokens" || r.Method != http.MethodGet {
+			http.NotFound(w, r)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"tokens": []map[string]any{{
+			"id": "tok-1", "name": "ci", "status": "active", "scopes": []string{"audit:read", "harness-run:read"}, "lastUsedAt": "2026-04-14T12:00:00Z",
+		}}})
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "tokens", "list"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d stderr=%s", code, stderr.String())
+	}
+	for _, want := range []string{"TOKEN ID", "tok-1", "ci", "audit:read,harness-run:read", "2026-04-14T12:00:00Z"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestTokensCreateSendsScopesAndTTL(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	var got APITokenCreateRequest
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/tokens" || r.Method != http.MethodPost {
+			http.NotFound(w, r)
+			return
+		}
+		if err := json.NewDecoder(r.Body).Decode(&got); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(map[string]any{"id": "tok-2", "name": got.Name, "status": "active", "scopes": got.Scopes, "token": "kocao_secret"})
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "tokens", "create", "--name", "ci", "--scope", "audit:read, harness-run:read", "--expires-in", "2h"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("exit code = %d stderr=%s", code, stderr.String())
+	}
+	if got.Name != "ci" || len(got.Scopes) != 2 || got.Scopes[1] != "harness-run:read" || got.TTLSeconds != 7200 {
+		t.Fatalf("unexpected create request: %+v", got)
+	}
+	for _, want := range []string{"created token tok-2", "kocao_secret", "cannot be retrieved again"} {
+		if !strings.Contains(stdout.String(), want) {
+			t.Fatalf("output missing %q:\n%s", want, stdout.String())
+		}
+	}
+}
+
+func TestTokensCreateRequiresScope(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", "http://127.0.0.1:1", "--token", "test-token", "tokens", "create", "--name", "ci"}, &stdout, &stderr)
+	if code != 2 {
+		t.Fatalf("exit code = %d, want 2 (stderr=%s)", code, stderr.String())
+	}
+}
+
+func TestTokensRevokeAndRotate(t *testing.T) {
+	t.Setenv(EnvToken, "")
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/v1/tokens/tok-1" && r.Method == http.MethodDelete:
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "tok-1", "status": "revoked", "scopes": []string{"audit:read"}})
+		case r.URL.Path == "/api/v1/tokens/tok-1/rotate" && r.Method == http.MethodPost:
+			_ = json.NewEncoder(w).Encode(map[string]any{"id": "tok-1", "status": "active", "scopes": []string{"audit:read"}, "token": "kocao_rotated"})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	var stdout bytes.Buffer
+	var stderr bytes.Buffer
+	code := Main([]string{"--api-url", srv.URL, "--token", "test-token", "tokens", "rotate", "tok-1"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("rotate exit code = %d stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "kocao_rotated") {
+		t.Fatalf("expected rotated secret, got: %s", stdout.String())
+	}
+
+	stdout.Reset()
+	code = Main([]string{"--api-url", srv.URL, "--token", "test-token", "tokens", "revoke", "tok-1", "--output", "json"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("revoke exit code = %d stderr=%s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), `"status": "revoked"`) {
+		t.Fatalf("expected revoked JSON, got: %s", stdout.String())
+	}
+}
@@ -0,0 +1,101 @@
+package egressproxy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// auditForwarderQueue bounds the denials waiting to be sent; a full queue
+// drops new ones rather than stalling the proxied connection.
+const auditForwarderQueue = 256
+
+type forwardedAuditEvent struct {
+	Action       string `json:"action"`
+	ResourceType string `json:"resourceType"`
+	ResourceID   string `json:"resourceID"`
+	Outcome      string `json:"outcome"`
+	Metadata     any    `json:"metadata,omitempty"`
+}
+
+// AuditForwarder is an Auditor that posts events to the control-plane audit
+// ingest endpoint in the background. The control plane records them under the
+// forwarder token's own actor, so the actor passed to Append is not sent.
+type AuditForwarder struct {
+	endpoint string
+	token    string
+	client   *http.Client
+	logger   *slog.Logger
+	queue    chan forwardedAuditEvent
+}
+
+// NewAuditForwarder returns a forwarder for the control plane at apiURL,
+// authenticating with a bearer token granted the audit:write scope. Call Run
+// to start sending.
+func NewAuditForwarder(apiURL, token string, logger *slog.Logger) *AuditForwarder {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return &AuditForwarder{
+		endpoint: strings.TrimRight(apiURL, "/") + "/api/v1/audit",
+		token:    token,
+		// The control plane is cluster-internal, so never route it through
+		// an environment proxy.
+		client: &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{Proxy: nil}},
+		logger: logger,
+		queue:  make(chan forwardedAuditEvent, auditForwarderQueue),
+	}
+}
+
+func (f *AuditForwarder) Append(_ context.Context, _, action, resourceType, resourceID, outcome string, metadata any) {
+	ev := forwardedAuditEvent{Action: action, ResourceType: resourceType, ResourceID: resourceID, Outcome: outcome, Metadata: metadata}
+	select {
+	case f.queue <- ev:
+	default:
+		f.logger.Warn("audit forward queue full; dropping event", "action", action, "resourceID", resourceID)
+	}
+}
+
+// Run sends queued events until ctx ends. Failed sends are logged and
+// dropped; the denial is still on stdout.
+func (f *AuditForwarder) Run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev := <-f.queue:
+			if err := f.send(ctx, ev); err != nil {
+				f.logger.Warn("audit forward failed", "action", ev.Action, "resourceID", ev.ResourceID, "error", err)
+			}
+		}
+	}
+}
+
+func (f *AuditForwarder) send(ctx context.Context, ev forwardedAuditEvent) error {
+	body, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, f.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", "Bearer "+f.token)
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 4096))
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("audit ingest returned %s", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package egressproxy
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestAuditForwarderPostsEventsToTheControlPlane(t *testing.T) {
+	received := make(chan map[string]any, 1)
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost || r.URL.Path != "/api/v1/audit" || r.Header.Get("Authorization") != "Bearer proxy-token" {
+			http.Error(w, "unexpected request", http.StatusBadRequest)
+			return
+		}
+		var body map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		received <- body
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer api.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	forwarder := NewAuditForwarder(api.URL+"/", "proxy-token", nil)
+	go forwarder.Run(ctx)
+	forwarder.Append(ctx, "egress-proxy", "egress.denied", "harness-run", "run-1", "denied", map[string]any{"host": "evil.example.net"})
+
+	select {
+	case body := <-received:
+		meta, _ := body["metadata"].(map[string]any)
+		if body["action"] != "egress.denied" || body["resourceID"] != "run-1" || body["outcome"] != "denied" || meta["host"] != "evil.example.net" {
+			t.Fatalf("forwarded event = %+v", body)
+		}
+		if _, ok := body["actor"]; ok {
+			t.Fatalf("forwarded event carries an actor: %+v", body)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("audit event was not forwarded")
+	}
+}
+
+func TestAuditForwarderDropsEventsWhenTheQueueIsFull(t *testing.T) {
+	forwarder := NewAuditForwarder("http://127.0.0.1:0", "token", nil)
+	for i := 0; i < auditForwarderQueue+10; i++ {
+		forwarder.Append(context.Background(), "", "egress.denied", "harness-run", "run-1", "denied", nil)
+	}
+	if got := len(forwarder.queue); got != auditForwarderQueue {
+		t.Fatalf("queued events = %d, want %d", got, auditForwarderQueue)
+	}
+}
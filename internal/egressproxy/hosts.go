@@ -0,0 +1,54 @@
+package egressproxy
+
+import "strings"
+
+// AnnotationAllowedHosts is the harness pod annotation listing the hostnames
+// the run may reach through the proxy, comma-separated. It matches the
+// operator's egress-allowed-hosts annotation.
+const AnnotationAllowedHosts = "kocao.withakay.github.com/egress-allowed-hosts"
+
+// LabelRun names the HarnessRun a harness pod belongs to.
+const LabelRun = "kocao.withakay.github.com/run"
+
+// ParseHosts splits a comma- or whitespace-separated allowlist into
+// lower-cased hostnames, dropping duplicates and empty entries.
+func ParseHosts(raw string) []string {
+	return NormalizeHosts(strings.FieldsFunc(raw, func(r rune) bool { return r == ',' || r == ' ' || r == '\n' || r == '\t' }))
+}
+
+// NormalizeHosts lower-cases and trims hosts, dropping duplicates and empty
+// entries while keeping their order.
+func NormalizeHosts(hosts []string) []string {
+	var out []string
+	seen := map[string]bool{}
+	for _, host := range hosts {
+		host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+		if host == "" || seen[host] {
+			continue
+		}
+		seen[host] = true
+		out = append(out, host)
+	}
+	return out
+}
+
+// HostAllowed reports whether host matches an entry of allowed. A wildcard
+// entry matches subdomains only, not the bare domain.
+func HostAllowed(allowed []string, host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(strings.TrimSpace(host)), ".")
+	if host == "" {
+		return false
+	}
+	for _, entry := range allowed {
+		if suffix, ok := strings.CutPrefix(entry, "*"); ok {
+			if strings.HasSuffix(host, suffix) && len(host) > len(suffix) {
+				return true
+			}
+			continue
+		}
+		if host == entry {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,23 @@
+package egressproxy
+
+import "testing"
+
+func TestHostAllowedMatchesExactHostsAndWildcardSubdomains(t *testing.T) {
+	allowed := ParseHosts("Registry.NPMjs.org, *.githubusercontent.com\napi.openai.com.")
+	for host, want := range map[string]bool{
+		"registry.npmjs.org":          true,
+		"REGISTRY.npmjs.org.":         true,
+		"api.openai.com":              true,
+		"raw.githubusercontent.com":   true,
+		"a.b.githubusercontent.com":   true,
+		"githubusercontent.com":       false,
+		"evilgithubusercontent.com":   false,
+		"registry.npmjs.org.evil.com": false,
+		"npmjs.org":                   false,
+		"":                            false,
+	} {
+		if got := HostAllowed(allowed, host); got != want {
+			t.Errorf("HostAllowed(%q) = %v, want %v", host, got, want)
+		}
+	}
+}
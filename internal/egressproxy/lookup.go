@@ -0,0 +1,61 @@
+package egressproxy
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+)
+
+const podIPIndex = "podIP"
+
+// PodLookup resolves connections to harness runs by pod IP, from a watch of
+// the namespace's harness pods.
+type PodLookup struct {
+	indexer cache.Indexer
+}
+
+// NewPodLookup watches the harness pods of namespace and blocks until the
+// first list is cached or ctx ends.
+func NewPodLookup(ctx context.Context, clientset kubernetes.Interface, namespace string) (*PodLookup, error) {
+	factory := informers.NewSharedInformerFactoryWithOptions(clientset, 0,
+		informers.WithNamespace(namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) { opts.LabelSelector = LabelRun }),
+	)
+	informer := factory.Core().V1().Pods().Informer()
+	if err := informer.AddIndexers(cache.Indexers{podIPIndex: func(obj any) ([]string, error) {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Status.PodIP == "" {
+			return nil, nil
+		}
+		return []string{pod.Status.PodIP}, nil
+	}}); err != nil {
+		return nil, err
+	}
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.HasSynced) {
+		return nil, fmt.Errorf("harness pod cache did not sync")
+	}
+	return &PodLookup{indexer: informer.GetIndexer()}, nil
+}
+
+// Lookup returns the run of the running harness pod with ip. Finished pods
+// are skipped because their IP may already belong to another pod.
+func (l *PodLookup) Lookup(_ context.Context, ip string) (Client, error) {
+	objs, err := l.indexer.ByIndex(podIPIndex, ip)
+	if err != nil {
+		return Client{}, err
+	}
+	for _, obj := range objs {
+		pod, ok := obj.(*corev1.Pod)
+		if !ok || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed || pod.DeletionTimestamp != nil {
+			continue
+		}
+		return Client{Run: pod.Labels[LabelRun], AllowedHosts: ParseHosts(pod.Annotations[AnnotationAllowedHosts])}, nil
+	}
+	return Client{}, fmt.Errorf("%w: %s", ErrUnknownClient, ip)
+}
@@ -0,0 +1,267 @@
+package egressproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httputil"
+	"net/netip"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// Client is the harness run behind a proxied connection.
+type Client struct {
+	Run          string
+	AllowedHosts []string
+}
+
+// ErrUnknownClient is returned by a Lookup for addresses that belong to no
+// harness pod.
+var ErrUnknownClient = errors.New("unknown egress proxy client")
+
+// ErrBlockedAddress is returned when an allowed hostname resolves to a
+// loopback, private, link-local or otherwise non-public address, such as a
+// cluster service or the cloud metadata endpoint.
+var ErrBlockedAddress = errors.New("egress to non-public address blocked")
+
+// Lookup resolves the source IP of a connection to its harness run.
+type Lookup interface {
+	Lookup(ctx context.Context, ip string) (Client, error)
+}
+
+// Auditor records denied connections; *auditlog.Store satisfies it.
+type Auditor interface {
+	Append(ctx context.Context, actor, action, resourceType, resourceID, outcome string, metadata any)
+}
+
+// Server is an HTTP forward proxy. It tunnels CONNECT requests and forwards
+// absolute-URI HTTP requests, but only to hosts on the allowlist of the
+// harness run the connection comes from.
+type Server struct {
+	Lookup Lookup
+	Audit  Auditor
+	Logger *slog.Logger
+	// Dial opens upstream connections; nil uses a net.Dialer that refuses
+	// non-public addresses. A custom Dial is trusted to do its own checks.
+	Dial func(ctx context.Context, network, address string) (net.Conn, error)
+
+	once      sync.Once
+	forwarder *httputil.ReverseProxy
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodConnect && !r.URL.IsAbs() {
+		if r.URL.Path == "/healthz" {
+			w.WriteHeader(http.StatusOK)
+			return
+		}
+		http.Error(w, "not a proxy request", http.StatusBadRequest)
+		return
+	}
+	host, port := r.URL.Hostname(), r.URL.Port()
+	if r.Method == http.MethodConnect {
+		h, p, err := net.SplitHostPort(r.Host)
+		if err != nil {
+			http.Error(w, "CONNECT target must be host:port", http.StatusBadRequest)
+			return
+		}
+		host, port = h, p
+	}
+	if port == "" {
+		port = "80"
+		if r.URL.Scheme == "https" {
+			port = "443"
+		}
+	}
+	client, allowed := s.authorize(r, host, port)
+	if !allowed {
+		http.Error(w, "egress to "+host+" is not allowed for this run", http.StatusForbidden)
+		return
+	}
+	if r.Method == http.MethodConnect {
+		s.tunnel(w, r, net.JoinHostPort(host, port), client)
+		return
+	}
+	s.once.Do(s.initForwarder)
+	s.forwarder.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), clientKey{}, client)))
+}
+
+type clientKey struct{}
+
+// authorize looks up the run behind r and checks host against its
+// allowlist, auditing denials.
+func (s *Server) authorize(r *http.Request, host, port string) (Client, bool) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	client, err := s.Lookup.Lookup(r.Context(), ip)
+	reason := ""
+	switch {
+	case errors.Is(err, ErrUnknownClient):
+		reason = "unknown_client"
+	case err != nil:
+		reason = "lookup_failed"
+	case !HostAllowed(client.AllowedHosts, host):
+		reason = "host_not_allowed"
+	default:
+		return client, true
+	}
+	s.deny(r, client, host, port, reason, err)
+	return client, false
+}
+
+// deny logs and audits a refused connection.
+func (s *Server) deny(r *http.Request, client Client, host, port, reason string, err error) {
+	ip, _, splitErr := net.SplitHostPort(r.RemoteAddr)
+	if splitErr != nil {
+		ip = r.RemoteAddr
+	}
+	s.logger().Warn("egress denied", "run", client.Run, "sourceIP", ip, "host", host, "port", port, "reason", reason, "error", err)
+	if s.Audit != nil {
+		s.Audit.Append(r.Context(), "egress-proxy", "egress.denied", "harness-run", client.Run, "denied", map[string]any{
+			"host":     host,
+			"port":     port,
+			"method":   r.Method,
+			"sourceIP": ip,
+			"reason":   reason,
+		})
+	}
+}
+
+func (s *Server) tunnel(w http.ResponseWriter, r *http.Request, address string, client Client) {
+	upstream, err := s.dial(r.Context(), "tcp", address)
+	if errors.Is(err, ErrBlockedAddress) {
+		host, port, _ := net.SplitHostPort(address)
+		s.deny(r, client, host, port, "blocked_address", err)
+		http.Error(w, "egress to "+host+" is not allowed for this run", http.StatusForbidden)
+		return
+	}
+	if err != nil {
+		s.logger().Warn("egress upstream dial failed", "run", client.Run, "address", address, "error", err)
+		http.Error(w, "upstream unreachable", http.StatusBadGateway)
+		return
+	}
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		_ = upstream.Close()
+		http.Error(w, "connection cannot be tunneled", http.StatusInternalServerError)
+		return
+	}
+	conn, buffered, err := hijacker.Hijack()
+	if err != nil {
+		_ = upstream.Close()
+		return
+	}
+	if _, err := conn.Write([]byte("HTTP/1.1 200 Connection Established\r\n\r\n")); err != nil {
+		_ = conn.Close()
+		_ = upstream.Close()
+		return
+	}
+	done := make(chan struct{}, 2)
+	go func() {
+		// Bytes the client sent after the CONNECT line are still buffered.
+		_, _ = io.Copy(upstream, buffered)
+		closeWrite(upstream)
+		done <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(conn, upstream)
+		closeWrite(conn)
+		done <- struct{}{}
+	}()
+	<-done
+	<-done
+	_ = conn.Close()
+	_ = upstream.Close()
+}
+
+func (s *Server) initForwarder() {
+	s.forwarder = &httputil.ReverseProxy{
+		// Absolute-URI requests already name their upstream.
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			pr.Out.Header.Del("Proxy-Authorization")
+		},
+		Transport: &http.Transport{
+			Proxy:                 nil,
+			DialContext:           s.dial,
+			MaxIdleConns:          100,
+			IdleConnTimeout:       90 * time.Second,
+			ResponseHeaderTimeout: 60 * time.Second,
+		},
+		ErrorHandler: func(w http.ResponseWriter, r *http.Request, err error) {
+			if errors.Is(err, ErrBlockedAddress) {
+				client, _ := r.Context().Value(clientKey{}).(Client)
+				s.deny(r, client, r.URL.Hostname(), r.URL.Port(), "blocked_address", err)
+				http.Error(w, "egress to "+r.URL.Hostname()+" is not allowed for this run", http.StatusForbidden)
+				return
+			}
+			s.logger().Warn("egress upstream request failed", "host", r.URL.Host, "error", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+	}
+}
+
+func (s *Server) dial(ctx context.Context, network, address string) (net.Conn, error) {
+	if s.Dial != nil {
+		return s.Dial(ctx, network, address)
+	}
+	d := net.Dialer{
+		Timeout: 30 * time.Second,
+		// Check the address actually dialled rather than an earlier DNS
+		// answer, so a rebinding resolver cannot swap in an internal IP.
+		ControlContext: func(_ context.Context, _, address string, _ syscall.RawConn) error {
+			return checkPublicAddress(address)
+		},
+	}
+	return d.DialContext(ctx, network, address)
+}
+
+// sharedAddressSpace is the carrier-grade NAT range, which some CNIs use for
+// pod and service networks.
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+func checkPublicAddress(address string) error {
+	addrPort, err := netip.ParseAddrPort(address)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, address)
+	}
+	if !publicAddr(addrPort.Addr()) {
+		return fmt.Errorf("%w: %s", ErrBlockedAddress, addrPort.Addr())
+	}
+	return nil
+}
+
+// publicAddr reports whether ip is a globally routable unicast address.
+func publicAddr(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	switch {
+	case !ip.IsValid(), ip.IsUnspecified(), ip.IsLoopback(), ip.IsPrivate(),
+		ip.IsLinkLocalUnicast(), ip.IsLinkLocalMulticast(), ip.IsInterfaceLocalMulticast(), ip.IsMulticast():
+		return false
+	case ip.Is4() && (ip.As4()[0] == 0 || sharedAddressSpace.Contains(ip)):
+		return false
+	}
+	return true
+}
+
+func (s *Server) logger() *slog.Logger {
+	if s.Logger != nil {
+		return s.Logger
+	}
+	return slog.Default()
+}
+
+func closeWrite(conn net.Conn) {
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok {
+		_ = cw.CloseWrite()
+		return
+	}
+	_ = conn.Close()
+}
@@ -0,0 +1,192 @@
+package egressproxy
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+)
+
+type stubLookup struct {
+	clients map[string]Client
+}
+
+func (l stubLookup) Lookup(_ context.Context, ip string) (Client, error) {
+	client, ok := l.clients[ip]
+	if !ok {
+		return Client{}, ErrUnknownClient
+	}
+	return client, nil
+}
+
+type recordedAudit struct {
+	action, resourceID, outcome string
+	metadata                    map[string]any
+}
+
+type stubAuditor struct {
+	mu     sync.Mutex
+	events []recordedAudit
+}
+
+func (a *stubAuditor) Append(_ context.Context, _, action, _, resourceID, outcome string, metadata any) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	meta, _ := metadata.(map[string]any)
+	a.events = append(a.events, recordedAudit{action: action, resourceID: resourceID, outcome: outcome, metadata: meta})
+}
+
+// newTestProxy starts the proxy in front of stub upstreams: every dialled
+// address is routed to the TLS upstream for port 443 and to the plain HTTP
+// upstream otherwise.
+func newTestProxy(t *testing.T, allowed ...string) (*http.Client, *stubAuditor) {
+	t.Helper()
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = io.WriteString(w, "hello from "+r.Host)
+	})
+	plain := httptest.NewServer(handler)
+	t.Cleanup(plain.Close)
+	secure := httptest.NewTLSServer(handler)
+	t.Cleanup(secure.Close)
+
+	audit := &stubAuditor{}
+	proxy := httptest.NewServer(&Server{
+		Lookup: stubLookup{clients: map[string]Client{"127.0.0.1": {Run: "run-1", AllowedHosts: allowed}}},
+		Audit:  audit,
+		Dial: func(ctx context.Context, network, address string) (net.Conn, error) {
+			target := plain.Listener.Addr().String()
+			if strings.HasSuffix(address, ":443") {
+				target = secure.Listener.Addr().String()
+			}
+			var d net.Dialer
+			return d.DialContext(ctx, network, target)
+		},
+	})
+	t.Cleanup(proxy.Close)
+
+	proxyURL, _ := url.Parse(proxy.URL)
+	tlsConfig := secure.Client().Transport.(*http.Transport).TLSClientConfig.Clone()
+	tlsConfig.ServerName = "example.com"
+	return &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL), TLSClientConfig: tlsConfig}}, audit
+}
+
+func TestServerTunnelsAndForwardsAllowedHosts(t *testing.T) {
+	client, audit := newTestProxy(t, "registry.npmjs.org", "*.githubusercontent.com")
+
+	for _, target := range []string{"https://registry.npmjs.org/react", "http://raw.githubusercontent.com/withakay/kocao/main/README.md"} {
+		resp, err := client.Get(target)
+		if err != nil {
+			t.Fatalf("GET %s: %v", target, err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(string(body), "hello from") {
+			t.Fatalf("GET %s = %d %q", target, resp.StatusCode, body)
+		}
+	}
+	if len(audit.events) != 0 {
+		t.Fatalf("audit events = %+v, want none", audit.events)
+	}
+}
+
+func TestServerDeniesAndAuditsHostsOffTheAllowlist(t *testing.T) {
+	client, audit := newTestProxy(t, "*.githubusercontent.com")
+
+	if _, err := client.Get("https://evil.example.net/"); err == nil || !strings.Contains(err.Error(), "Forbidden") {
+		t.Fatalf("CONNECT error = %v, want Forbidden", err)
+	}
+	resp, err := client.Get("http://githubusercontent.com/")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("bare wildcard domain status = %d, want 403", resp.StatusCode)
+	}
+
+	if len(audit.events) != 2 {
+		t.Fatalf("audit events = %+v, want 2", audit.events)
+	}
+	denied := audit.events[0]
+	if denied.action != "egress.denied" || denied.resourceID != "run-1" || denied.outcome != "denied" {
+		t.Fatalf("audit event = %+v", denied)
+	}
+	if denied.metadata["host"] != "evil.example.net" || denied.metadata["port"] != "443" || denied.metadata["reason"] != "host_not_allowed" {
+		t.Fatalf("audit metadata = %+v", denied.metadata)
+	}
+}
+
+func TestServerDeniesUnknownClients(t *testing.T) {
+	audit := &stubAuditor{}
+	srv := httptest.NewServer(&Server{Lookup: stubLookup{}, Audit: audit})
+	defer srv.Close()
+	proxyURL, _ := url.Parse(srv.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL), TLSClientConfig: &tls.Config{}}}
+
+	resp, err := client.Get("http://registry.npmjs.org/")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden || len(audit.events) != 1 || audit.events[0].metadata["reason"] != "unknown_client" {
+		t.Fatalf("status = %d, audit = %+v", resp.StatusCode, audit.events)
+	}
+}
+
+func TestServerRefusesAllowedHostsResolvingToInternalAddresses(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = io.WriteString(w, "internal")
+	}))
+	defer upstream.Close()
+	_, port, _ := net.SplitHostPort(upstream.Listener.Addr().String())
+
+	audit := &stubAuditor{}
+	srv := httptest.NewServer(&Server{
+		Lookup: stubLookup{clients: map[string]Client{"127.0.0.1": {Run: "run-1", AllowedHosts: []string{"localhost"}}}},
+		Audit:  audit,
+	})
+	defer srv.Close()
+	proxyURL, _ := url.Parse(srv.URL)
+	client := &http.Client{Transport: &http.Transport{Proxy: http.ProxyURL(proxyURL)}}
+
+	resp, err := client.Get("http://localhost:" + port + "/")
+	if err != nil {
+		t.Fatalf("GET: %v", err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusForbidden {
+		t.Fatalf("status = %d, want 403", resp.StatusCode)
+	}
+	if len(audit.events) != 1 || audit.events[0].metadata["reason"] != "blocked_address" {
+		t.Fatalf("audit = %+v", audit.events)
+	}
+}
+
+func TestPublicAddr(t *testing.T) {
+	for addr, want := range map[string]bool{
+		"140.82.112.3":     true,
+		"2606:4700::1111":  true,
+		"127.0.0.1":        false,
+		"10.96.0.1":        false,
+		"172.16.5.4":       false,
+		"192.168.1.1":      false,
+		"169.254.169.254":  false,
+		"100.64.0.10":      false,
+		"0.0.0.0":          false,
+		"::1":              false,
+		"fd00::1":          false,
+		"fe80::1":          false,
+		"::ffff:127.0.0.1": false,
+	} {
+		if got := publicAddr(netip.MustParseAddr(addr)); got != want {
+			t.Errorf("publicAddr(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
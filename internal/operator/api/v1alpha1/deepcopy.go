@@ -1,6 +1,9 @@
 package v1alpha1
 
-import metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
 
 func (in *Session) DeepCopyInto(out *Session) {
 	*out = *in
@@ -94,6 +97,25 @@ func (in *HarnessRun) DeepCopyInto(out *HarnessRun) {
 		v := *in.Spec.TTLSecondsAfterFinished
 		out.Spec.TTLSecondsAfterFinished = &v
 	}
+	if in.Spec.PullRequest != nil {
+		pullRequest := *in.Spec.PullRequest
+		out.Spec.PullRequest = &pullRequest
+	}
+	if in.Spec.Resources != nil {
+		out.Spec.Resources = in.Spec.Resources.DeepCopy()
+	}
+	out.Spec.Scheduling = in.Spec.Scheduling.DeepCopy()
+	if in.Spec.EgressAllowedHosts != nil {
+		out.Spec.EgressAllowedHosts = append([]string(nil), in.Spec.EgressAllowedHosts...)
+	}
+	if in.Spec.ActiveDeadlineSeconds != nil {
+		v := *in.Spec.ActiveDeadlineSeconds
+		out.Spec.ActiveDeadlineSeconds = &v
+	}
+	if in.Spec.IdleTimeoutSeconds != nil {
+		v := *in.Spec.IdleTimeoutSeconds
+		out.Spec.IdleTimeoutSeconds = &v
+	}
 
 	out.Status.ObservedGeneration = in.Status.ObservedGeneration
 	out.Status.Phase = in.Status.Phase
@@ -145,6 +167,11 @@ func (in *HarnessRun) DeepCopyInto(out *HarnessRun) {
 			Phase:     in.Status.AgentSession.Phase,
 		}
 	}
+	if in.Status.LastActivityTime != nil {
+		out.Status.LastActivityTime = &metav1.Time{Time: in.Status.LastActivityTime.Time}
+	}
+	out.Status.Reason = in.Status.Reason
+	out.Status.Message = in.Status.Message
 }
 
 func (in *HarnessRun) DeepCopy() *HarnessRun {
@@ -183,6 +210,10 @@ func (in *SymphonyProject) DeepCopyInto(out *SymphonyProject) {
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
 	out.Spec.Paused = in.Spec.Paused
 	out.Spec.Source = in.Spec.Source
+	if in.Spec.Source.Linear != nil {
+		linear := *in.Spec.Source.Linear
+		out.Spec.Source.Linear = &linear
+	}
 	if in.Spec.Source.ActiveStates != nil {
 		out.Spec.Source.ActiveStates = append([]string(nil), in.Spec.Source.ActiveStates...)
 	}
@@ -219,10 +250,53 @@ func (in *SymphonyProject) DeepCopyInto(out *SymphonyProject) {
 		out.Spec.Runtime.Env = make([]EnvVar, len(in.Spec.Runtime.Env))
 		copy(out.Spec.Runtime.Env, in.Spec.Runtime.Env)
 	}
+	if in.Spec.Runtime.MaxConcurrentItemsByStatus != nil {
+		out.Spec.Runtime.MaxConcurrentItemsByStatus = make(map[string]int32, len(in.Spec.Runtime.MaxConcurrentItemsByStatus))
+		for key, value := range in.Spec.Runtime.MaxConcurrentItemsByStatus {
+			out.Spec.Runtime.MaxConcurrentItemsByStatus[key] = value
+		}
+	}
 	if in.Spec.Runtime.TTLSecondsAfterFinished != nil {
 		v := *in.Spec.Runtime.TTLSecondsAfterFinished
 		out.Spec.Runtime.TTLSecondsAfterFinished = &v
 	}
+	out.Spec.Runtime.Scheduling = in.Spec.Runtime.Scheduling.DeepCopy()
+	if in.Spec.Runtime.EgressAllowedHosts != nil {
+		out.Spec.Runtime.EgressAllowedHosts = append([]string(nil), in.Spec.Runtime.EgressAllowedHosts...)
+	}
+	if in.Spec.WriteBack != nil {
+		writeBack := *in.Spec.WriteBack
+		for _, action := range []*SymphonyProjectWriteBackActionSpec{&writeBack.OnClaim, &writeBack.OnFailure, &writeBack.OnSuccess} {
+			if action.AddLabels != nil {
+				action.AddLabels = append([]string(nil), action.AddLabels...)
+			}
+			if action.RemoveLabels != nil {
+				action.RemoveLabels = append([]string(nil), action.RemoveLabels...)
+			}
+		}
+		out.Spec.WriteBack = &writeBack
+	}
+	if in.Spec.PullRequests != nil {
+		pullRequests := *in.Spec.PullRequests
+		out.Spec.PullRequests = &pullRequests
+	}
+	if in.Spec.Priority != nil {
+		priority := *in.Spec.Priority
+		if priority.Pinned != nil {
+			priority.Pinned = append([]string(nil), priority.Pinned...)
+		}
+		if priority.FieldOrder != nil {
+			priority.FieldOrder = append([]string(nil), priority.FieldOrder...)
+		}
+		if priority.LabelWeights != nil {
+			weights := make(map[string]int32, len(priority.LabelWeights))
+			for label, weight := range priority.LabelWeights {
+				weights[label] = weight
+			}
+			priority.LabelWeights = weights
+		}
+		out.Spec.Priority = &priority
+	}
 
 	out.Status = in.Status
 	if in.Status.Conditions != nil {
@@ -292,6 +366,43 @@ func (in *SymphonyProject) DeepCopyInto(out *SymphonyProject) {
 	if in.Status.UnsupportedRepos != nil {
 		out.Status.UnsupportedRepos = append([]string(nil), in.Status.UnsupportedRepos...)
 	}
+	if in.Status.Plan != nil {
+		plan := *in.Status.Plan
+		if plan.GeneratedTime != nil {
+			plan.GeneratedTime = &metav1.Time{Time: plan.GeneratedTime.Time}
+		}
+		if plan.Items != nil {
+			plan.Items = make([]SymphonyProjectPlanItemStatus, len(in.Status.Plan.Items))
+			for i := range in.Status.Plan.Items {
+				plan.Items[i] = in.Status.Plan.Items[i]
+				if in.Status.Plan.Items[i].ReadyAt != nil {
+					plan.Items[i].ReadyAt = &metav1.Time{Time: in.Status.Plan.Items[i].ReadyAt.Time}
+				}
+			}
+		}
+		out.Status.Plan = &plan
+	}
+	if in.Status.Budget != nil {
+		budget := *in.Status.Budget
+		if budget.RemainingTokensToday != nil {
+			v := *budget.RemainingTokensToday
+			budget.RemainingTokensToday = &v
+		}
+		if budget.RemainingSecondsToday != nil {
+			v := *budget.RemainingSecondsToday
+			budget.RemainingSecondsToday = &v
+		}
+		if budget.ExceededItems != nil {
+			budget.ExceededItems = make([]SymphonyProjectSkipStatus, len(in.Status.Budget.ExceededItems))
+			for i := range in.Status.Budget.ExceededItems {
+				budget.ExceededItems[i] = in.Status.Budget.ExceededItems[i]
+				if in.Status.Budget.ExceededItems[i].ObservedTime != nil {
+					budget.ExceededItems[i].ObservedTime = &metav1.Time{Time: in.Status.Budget.ExceededItems[i].ObservedTime.Time}
+				}
+			}
+		}
+		out.Status.Budget = &budget
+	}
 }
 
 func (in *SymphonyProject) DeepCopy() *SymphonyProject {
@@ -323,3 +434,24 @@ func (in *SymphonyProjectList) DeepCopy() *SymphonyProjectList {
 	in.DeepCopyInto(out)
 	return out
 }
+
+func (in *HarnessRunSchedulingSpec) DeepCopy() *HarnessRunSchedulingSpec {
+	if in == nil {
+		return nil
+	}
+	out := *in
+	if in.NodeSelector != nil {
+		out.NodeSelector = make(map[string]string, len(in.NodeSelector))
+		for key, value := range in.NodeSelector {
+			out.NodeSelector[key] = value
+		}
+	}
+	if in.Tolerations != nil {
+		out.Tolerations = make([]corev1.Toleration, len(in.Tolerations))
+		for i := range in.Tolerations {
+			in.Tolerations[i].DeepCopyInto(&out.Tolerations[i])
+		}
+	}
+	out.Affinity = in.Affinity.DeepCopy()
+	return &out
+}
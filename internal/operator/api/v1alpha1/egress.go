@@ -0,0 +1,35 @@
+package v1alpha1
+
+import (
+	"fmt"
+	"net"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// internalEgressSuffixes are DNS zones that resolve to cluster services,
+// node-local names or cloud metadata endpoints rather than the internet.
+var internalEgressSuffixes = []string{"local", "internal", "localhost", "localdomain", "svc", "cluster.local", "default", "arpa"}
+
+// ValidateEgressAllowedHosts accepts DNS hostnames such as
+// registry.npmjs.org and wildcards such as *.githubusercontent.com, which
+// match subdomains. Schemes, ports, paths, IP addresses and cluster-internal
+// or metadata names such as kubernetes.default are rejected.
+func ValidateEgressAllowedHosts(field string, hosts []string) error {
+	for _, host := range hosts {
+		name := strings.TrimSuffix(strings.TrimPrefix(strings.ToLower(strings.TrimSpace(host)), "*."), ".")
+		if net.ParseIP(name) != nil {
+			return fmt.Errorf("%s entry %q must be a hostname, not an IP address", field, host)
+		}
+		if errs := validation.IsDNS1123Subdomain(name); len(errs) != 0 || !strings.Contains(name, ".") {
+			return fmt.Errorf("%s entry %q must be a hostname such as registry.npmjs.org or *.example.com", field, host)
+		}
+		for _, suffix := range internalEgressSuffixes {
+			if name == suffix || strings.HasSuffix(name, "."+suffix) {
+				return fmt.Errorf("%s entry %q names a cluster-internal or metadata host", field, host)
+			}
+		}
+	}
+	return nil
+}
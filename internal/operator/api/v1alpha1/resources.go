@@ -0,0 +1,103 @@
+package v1alpha1
+
+import (
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+// HarnessResourceNames are the resources a HarnessRun may request and limit.
+var HarnessResourceNames = []corev1.ResourceName{corev1.ResourceCPU, corev1.ResourceMemory, corev1.ResourceEphemeralStorage}
+
+// harnessProfileResources holds the requests and limits of each image
+// profile. Toolchain profiles build and test code, so they get more than base.
+var harnessProfileResources = map[HarnessImageProfile][2][3]string{
+	HarnessImageProfileBase: {{"250m", "512Mi", "1Gi"}, {"1", "2Gi", "4Gi"}},
+	HarnessImageProfileWeb:  {{"500m", "1Gi", "2Gi"}, {"2", "4Gi", "8Gi"}},
+	HarnessImageProfileGo:   {{"1", "2Gi", "4Gi"}, {"4", "8Gi", "16Gi"}},
+	HarnessImageProfileFull: {{"1", "2Gi", "4Gi"}, {"4", "8Gi", "20Gi"}},
+}
+
+// DefaultHarnessResources returns the harness container resources for an
+// image profile, or empty requirements for an unknown profile.
+func DefaultHarnessResources(profile HarnessImageProfile) corev1.ResourceRequirements {
+	values, ok := harnessProfileResources[profile]
+	if !ok {
+		return corev1.ResourceRequirements{}
+	}
+	out := corev1.ResourceRequirements{Requests: corev1.ResourceList{}, Limits: corev1.ResourceList{}}
+	for i, name := range HarnessResourceNames {
+		out.Requests[name] = resource.MustParse(values[0][i])
+		out.Limits[name] = resource.MustParse(values[1][i])
+	}
+	return out
+}
+
+// ResolveHarnessResources overlays override onto the defaults of profile, one
+// resource at a time. A request raised above the profile's limit raises that
+// limit with it, unless the override also sets the limit.
+func ResolveHarnessResources(profile HarnessImageProfile, override *corev1.ResourceRequirements) corev1.ResourceRequirements {
+	out := DefaultHarnessResources(profile)
+	if override == nil {
+		return out
+	}
+	if out.Requests == nil {
+		out.Requests = corev1.ResourceList{}
+	}
+	if out.Limits == nil {
+		out.Limits = corev1.ResourceList{}
+	}
+	for name, value := range override.Limits {
+		out.Limits[name] = value.DeepCopy()
+	}
+	for name, value := range override.Requests {
+		out.Requests[name] = value.DeepCopy()
+		if _, set := override.Limits[name]; set {
+			continue
+		}
+		if limit, ok := out.Limits[name]; ok && value.Cmp(limit) > 0 {
+			out.Limits[name] = value.DeepCopy()
+		}
+	}
+	if len(out.Requests) == 0 {
+		out.Requests = nil
+	}
+	if len(out.Limits) == 0 {
+		out.Limits = nil
+	}
+	return out
+}
+
+// ValidateHarnessResources rejects resources other than cpu, memory and
+// ephemeral-storage, negative quantities and requests above their limit.
+func ValidateHarnessResources(field string, resources *corev1.ResourceRequirements) error {
+	if resources == nil {
+		return nil
+	}
+	for kind, list := range map[string]corev1.ResourceList{"requests": resources.Requests, "limits": resources.Limits} {
+		for name, value := range list {
+			if !isHarnessResourceName(name) {
+				return fmt.Errorf("%s.%s.%s is not supported; use cpu, memory or ephemeral-storage", field, kind, name)
+			}
+			if value.Sign() < 0 {
+				return fmt.Errorf("%s.%s.%s must not be negative", field, kind, name)
+			}
+		}
+	}
+	for name, request := range resources.Requests {
+		if limit, ok := resources.Limits[name]; ok && request.Cmp(limit) > 0 {
+			return fmt.Errorf("%s.requests.%s (%s) must not exceed %s.limits.%s (%s)", field, name, request.String(), field, name, limit.String())
+		}
+	}
+	return nil
+}
+
+func isHarnessResourceName(name corev1.ResourceName) bool {
+	for _, allowed := range HarnessResourceNames {
+		if name == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 )
@@ -157,13 +158,38 @@ type GitHubProjectRef struct {
 	Number int64  `json:"number"`
 }
 
+// SymphonySourceKind selects the issue tracker a Symphony project polls.
+type SymphonySourceKind string
+
+const (
+	SymphonySourceKindGitHub SymphonySourceKind = "github"
+	SymphonySourceKindLinear SymphonySourceKind = "linear"
+)
+
+// DefaultLinearRepositoryLabelPrefix marks the Linear label that routes an
+// issue to a repository, e.g. "repo:withakay/kocao".
+const DefaultLinearRepositoryLabelPrefix = "repo:"
+
+// LinearProjectRef selects Linear issues by team and, optionally, project.
+// Issues are routed to a repository by a label carrying RepositoryLabelPrefix,
+// or to the only configured repository when there is exactly one.
+type LinearProjectRef struct {
+	TeamKey               string `json:"teamKey"`
+	ProjectName           string `json:"projectName,omitempty"`
+	RepositoryLabelPrefix string `json:"repositoryLabelPrefix,omitempty"`
+}
+
 type SymphonyProjectSourceSpec struct {
-	Project         GitHubProjectRef `json:"project"`
-	TokenSecretRef  SecretKeyRef     `json:"tokenSecretRef"`
-	ActiveStates    []string         `json:"activeStates,omitempty"`
-	TerminalStates  []string         `json:"terminalStates,omitempty"`
-	FieldName       string           `json:"fieldName,omitempty"`
-	PollIntervalSec int32            `json:"pollIntervalSeconds,omitempty"`
+	// Kind defaults to github; linear reads issues from Linear instead of a
+	// GitHub Project and requires Linear to be set.
+	Kind            SymphonySourceKind `json:"kind,omitempty"`
+	Project         GitHubProjectRef   `json:"project,omitempty"`
+	Linear          *LinearProjectRef  `json:"linear,omitempty"`
+	TokenSecretRef  SecretKeyRef       `json:"tokenSecretRef"`
+	ActiveStates    []string           `json:"activeStates,omitempty"`
+	TerminalStates  []string           `json:"terminalStates,omitempty"`
+	FieldName       string             `json:"fieldName,omitempty"`
+	PollIntervalSec int32              `json:"pollIntervalSeconds,omitempty"`
 }
 
 type SymphonyProjectRepositorySpec struct {
@@ -176,6 +202,9 @@ type SymphonyProjectRepositorySpec struct {
 	GitAuth      *GitAuthSpec   `json:"gitAuth,omitempty"`
 	AgentAuth    *AgentAuthSpec `json:"agentAuth,omitempty"`
 	EgressMode   string         `json:"egressMode,omitempty"`
+	// MaxConcurrentItems caps the claims this repository may hold at once,
+	// within the project-wide runtime.maxConcurrentItems. Zero means no cap.
+	MaxConcurrentItems int32 `json:"maxConcurrentItems,omitempty"`
 }
 
 func (in SymphonyProjectRepositorySpec) RepositoryKey() string {
@@ -187,21 +216,87 @@ func (in SymphonyProjectRepositorySpec) RepositoryKey() string {
 	return owner + "/" + name
 }
 
+// SymphonyWorkerMode selects where Symphony runs the Codex turns for a claim.
+type SymphonyWorkerMode string
+
+const (
+	// SymphonyWorkerModeOperator runs the turns inside the operator process.
+	SymphonyWorkerModeOperator SymphonyWorkerMode = "operator"
+	// SymphonyWorkerModePod hands the rendered prompt to the claim's harness
+	// pod, which runs the turns against its cloned repository and reports
+	// usage back through the run's annotations.
+	SymphonyWorkerModePod SymphonyWorkerMode = "pod"
+)
+
 type SymphonyProjectRuntimeSpec struct {
-	Image                   string   `json:"image"`
-	Command                 []string `json:"command,omitempty"`
-	Args                    []string `json:"args,omitempty"`
-	WorkingDir              string   `json:"workingDir,omitempty"`
-	Env                     []EnvVar `json:"env,omitempty"`
-	MaxConcurrentItems      int32    `json:"maxConcurrentItems,omitempty"`
-	RetryBaseDelaySeconds   int32    `json:"retryBaseDelaySeconds,omitempty"`
-	RetryMaxDelaySeconds    int32    `json:"retryMaxDelaySeconds,omitempty"`
-	TTLSecondsAfterFinished *int32   `json:"ttlSecondsAfterFinished,omitempty"`
-	RecentSkipLimit         int32    `json:"recentSkipLimit,omitempty"`
-	RecentErrorLimit        int32    `json:"recentErrorLimit,omitempty"`
-	ActiveStatusItemLimit   int32    `json:"activeStatusItemLimit,omitempty"`
-	DefaultRepoRevision     string   `json:"defaultRepoRevision,omitempty"`
-	DefaultEgressMode       string   `json:"defaultEgressMode,omitempty"`
+	// WorkerMode defaults to operator.
+	WorkerMode              SymphonyWorkerMode `json:"workerMode,omitempty"`
+	Image                   string             `json:"image"`
+	Command                 []string           `json:"command,omitempty"`
+	Args                    []string           `json:"args,omitempty"`
+	WorkingDir              string             `json:"workingDir,omitempty"`
+	Env                     []EnvVar           `json:"env,omitempty"`
+	MaxConcurrentItems      int32              `json:"maxConcurrentItems,omitempty"`
+	RetryBaseDelaySeconds   int32              `json:"retryBaseDelaySeconds,omitempty"`
+	RetryMaxDelaySeconds    int32              `json:"retryMaxDelaySeconds,omitempty"`
+	TTLSecondsAfterFinished *int32             `json:"ttlSecondsAfterFinished,omitempty"`
+	RecentSkipLimit         int32              `json:"recentSkipLimit,omitempty"`
+	RecentErrorLimit        int32              `json:"recentErrorLimit,omitempty"`
+	ActiveStatusItemLimit   int32              `json:"activeStatusItemLimit,omitempty"`
+	DefaultRepoRevision     string             `json:"defaultRepoRevision,omitempty"`
+	DefaultEgressMode       string             `json:"defaultEgressMode,omitempty"`
+	// MaxConcurrentItemsByStatus caps the claims held by items in a given
+	// board status, keyed by status name (matched case-insensitively), within
+	// maxConcurrentItems.
+	MaxConcurrentItemsByStatus map[string]int32 `json:"maxConcurrentItemsByStatus,omitempty"`
+	// Budgets cap the tokens and runtime the project may spend.
+	Budgets SymphonyProjectBudgetSpec `json:"budgets,omitempty"`
+	// Scheduling is copied onto every HarnessRun the project starts.
+	Scheduling *HarnessRunSchedulingSpec `json:"scheduling,omitempty"`
+	// EgressAllowedHosts is copied onto every HarnessRun the project starts;
+	// it applies to runs in "proxy" egress mode.
+	EgressAllowedHosts []string `json:"egressAllowedHosts,omitempty"`
+}
+
+// SymphonyProjectBudgetSpec limits spending per attempt, per item across its
+// attempts and per project per UTC day. Zero leaves a budget unlimited. An
+// item that reaches an attempt or item budget is failed with reason
+// budget_exceeded; a project that reaches a daily budget claims nothing more
+// until the next day.
+type SymphonyProjectBudgetSpec struct {
+	MaxTokensPerAttempt  int64 `json:"maxTokensPerAttempt,omitempty"`
+	MaxSecondsPerAttempt int64 `json:"maxSecondsPerAttempt,omitempty"`
+	MaxTokensPerItem     int64 `json:"maxTokensPerItem,omitempty"`
+	MaxSecondsPerItem    int64 `json:"maxSecondsPerItem,omitempty"`
+	MaxTokensPerDay      int64 `json:"maxTokensPerDay,omitempty"`
+	MaxSecondsPerDay     int64 `json:"maxSecondsPerDay,omitempty"`
+}
+
+// Enabled reports whether any budget is set.
+func (b SymphonyProjectBudgetSpec) Enabled() bool {
+	return b.MaxTokensPerAttempt > 0 || b.MaxSecondsPerAttempt > 0 || b.MaxTokensPerItem > 0 || b.MaxSecondsPerItem > 0 || b.MaxTokensPerDay > 0 || b.MaxSecondsPerDay > 0
+}
+
+// SymphonyProjectWriteBackSpec reports progress back to the GitHub Project
+// board and issue when an item is claimed, fails or succeeds. An empty action
+// leaves the board untouched for that transition.
+type SymphonyProjectWriteBackSpec struct {
+	// Comment posts a single progress comment per issue and edits it in place
+	// on every transition.
+	Comment bool `json:"comment,omitempty"`
+	// RunURLBase is the Kocao UI base URL used to link runs from the comment,
+	// e.g. https://kocao.example.com.
+	RunURLBase string                             `json:"runURLBase,omitempty"`
+	OnClaim    SymphonyProjectWriteBackActionSpec `json:"onClaim,omitempty"`
+	OnFailure  SymphonyProjectWriteBackActionSpec `json:"onFailure,omitempty"`
+	OnSuccess  SymphonyProjectWriteBackActionSpec `json:"onSuccess,omitempty"`
+}
+
+type SymphonyProjectWriteBackActionSpec struct {
+	// Status is the single-select option to move the item to.
+	Status       string   `json:"status,omitempty"`
+	AddLabels    []string `json:"addLabels,omitempty"`
+	RemoveLabels []string `json:"removeLabels,omitempty"`
 }
 
 type SymphonyProjectSpec struct {
@@ -209,6 +304,53 @@ type SymphonyProjectSpec struct {
 	Source       SymphonyProjectSourceSpec       `json:"source"`
 	Repositories []SymphonyProjectRepositorySpec `json:"repositories"`
 	Runtime      SymphonyProjectRuntimeSpec      `json:"runtime"`
+	WriteBack    *SymphonyProjectWriteBackSpec   `json:"writeBack,omitempty"`
+	Priority     *SymphonyProjectPrioritySpec    `json:"priority,omitempty"`
+	PullRequests *SymphonyProjectPullRequestSpec `json:"pullRequests,omitempty"`
+}
+
+// DefaultSymphonyPullRequestBranchPrefix prefixes per-item pull request
+// branches when spec.pullRequests.branchPrefix is unset.
+const DefaultSymphonyPullRequestBranchPrefix = "symphony/"
+
+// SymphonyProjectPullRequestSpec publishes each succeeded run as a pull
+// request linked to its issue. It requires the pod worker mode and gitAuth on
+// every repository, because the harness pod pushes the branch.
+type SymphonyProjectPullRequestSpec struct {
+	// BranchPrefix prefixes the per-item branch, <prefix><repo>-<number>.
+	BranchPrefix string `json:"branchPrefix,omitempty"`
+	// Draft opens the pull requests as drafts.
+	Draft bool `json:"draft,omitempty"`
+}
+
+// SymphonyPriorityAge orders otherwise equal candidates by issue age.
+type SymphonyPriorityAge string
+
+const (
+	SymphonyPriorityAgeOldestFirst SymphonyPriorityAge = "oldestFirst"
+	SymphonyPriorityAgeNewestFirst SymphonyPriorityAge = "newestFirst"
+)
+
+// SymphonyProjectPrioritySpec orders eligible items before they are admitted.
+// Items are compared by pin, then field value, then label weight, then age,
+// and finally by board order.
+type SymphonyProjectPrioritySpec struct {
+	// Pinned lists items admitted ahead of everything else, in order, as
+	// "owner/repo#number" or project item IDs.
+	Pinned []string `json:"pinned,omitempty"`
+	// FieldName is a single-select or number field of the GitHub Project,
+	// e.g. "Priority". Linear sources use the issue's built-in priority and
+	// only accept "priority" here.
+	FieldName string `json:"fieldName,omitempty"`
+	// FieldOrder lists single-select options, most urgent first. Options not
+	// listed rank after listed ones, alphabetically.
+	FieldOrder []string `json:"fieldOrder,omitempty"`
+	// HighestNumberFirst ranks number fields descending; by default the
+	// lowest number is the most urgent.
+	HighestNumberFirst bool `json:"highestNumberFirst,omitempty"`
+	// LabelWeights adds each matching label's weight; heavier items first.
+	LabelWeights map[string]int32    `json:"labelWeights,omitempty"`
+	Age          SymphonyPriorityAge `json:"age,omitempty"`
 }
 
 type SymphonyProjectIssueRefStatus struct {
@@ -232,6 +374,12 @@ type SymphonyProjectClaimStatus struct {
 	ClaimedAt       *metav1.Time                  `json:"claimedAt,omitempty"`
 	LastUpdatedTime *metav1.Time                  `json:"lastUpdatedTime,omitempty"`
 	RunRef          SymphonyProjectRunRefStatus   `json:"runRef,omitempty"`
+	// Rank is the item's 1-based position in priority order this sync.
+	Rank int32 `json:"rank,omitempty"`
+	// UsedTokens and UsedSeconds are what the item's finished attempts spent,
+	// counted against runtime.budgets.maxTokensPerItem and maxSecondsPerItem.
+	UsedTokens  int64   `json:"usedTokens,omitempty"`
+	UsedSeconds float64 `json:"usedSeconds,omitempty"`
 }
 
 type SymphonyProjectRetryStatus struct {
@@ -241,6 +389,8 @@ type SymphonyProjectRetryStatus struct {
 	Reason        string                        `json:"reason,omitempty"`
 	ReadyAt       *metav1.Time                  `json:"readyAt,omitempty"`
 	LastErrorTime *metav1.Time                  `json:"lastErrorTime,omitempty"`
+	UsedTokens    int64                         `json:"usedTokens,omitempty"`
+	UsedSeconds   float64                       `json:"usedSeconds,omitempty"`
 }
 
 type SymphonyProjectSkipStatus struct {
@@ -250,6 +400,8 @@ type SymphonyProjectSkipStatus struct {
 	Reason       string                        `json:"reason,omitempty"`
 	Message      string                        `json:"message,omitempty"`
 	ObservedTime *metav1.Time                  `json:"observedTime,omitempty"`
+	// Rank is set for eligible items held back by a concurrency cap.
+	Rank int32 `json:"rank,omitempty"`
 }
 
 type SymphonyProjectErrorStatus struct {
@@ -271,6 +423,11 @@ type SymphonyProjectEventStatus struct {
 	Message        string                        `json:"message,omitempty"`
 	ObservedTime   *metav1.Time                  `json:"observedTime,omitempty"`
 	HarnessRunName string                        `json:"harnessRunName,omitempty"`
+	// Turn is set on per-turn events (1-based); the run's summary event
+	// leaves it empty and carries StopReason instead.
+	Turn        int32  `json:"turn,omitempty"`
+	TotalTokens int64  `json:"totalTokens,omitempty"`
+	StopReason  string `json:"stopReason,omitempty"`
 }
 
 type SymphonyProjectTokenTotalsStatus struct {
@@ -280,11 +437,70 @@ type SymphonyProjectTokenTotalsStatus struct {
 	SecondsRunning float64 `json:"secondsRunning,omitempty"`
 }
 
+// SymphonyProjectBudgetStatus reports spending against runtime.budgets.
+type SymphonyProjectBudgetStatus struct {
+	// Day is the UTC date (YYYY-MM-DD) the daily usage is counted for.
+	Day          string  `json:"day,omitempty"`
+	TokensToday  int64   `json:"tokensToday,omitempty"`
+	SecondsToday float64 `json:"secondsToday,omitempty"`
+	// RemainingTokensToday and RemainingSecondsToday are only set for the
+	// daily budgets that are configured.
+	RemainingTokensToday  *int64 `json:"remainingTokensToday,omitempty"`
+	RemainingSecondsToday *int64 `json:"remainingSecondsToday,omitempty"`
+	// Exhausted is set while a daily budget is used up.
+	Exhausted bool `json:"exhausted,omitempty"`
+	// ExceededItems are items failed for reaching an attempt or item budget.
+	// They are not claimed again until they leave the active states.
+	ExceededItems []SymphonyProjectSkipStatus `json:"exceededItems,omitempty"`
+}
+
+// SymphonyPlanAction is what a sync would do with one item.
+type SymphonyPlanAction string
+
+const (
+	SymphonyPlanActionClaim   SymphonyPlanAction = "claim"
+	SymphonyPlanActionRetry   SymphonyPlanAction = "retry"
+	SymphonyPlanActionRunning SymphonyPlanAction = "running"
+	SymphonyPlanActionBackoff SymphonyPlanAction = "backoff"
+	SymphonyPlanActionSkip    SymphonyPlanAction = "skip"
+	SymphonyPlanActionRelease SymphonyPlanAction = "release"
+)
+
+type SymphonyProjectPlanItemStatus struct {
+	Action         SymphonyPlanAction            `json:"action"`
+	ItemID         string                        `json:"itemId,omitempty"`
+	Issue          SymphonyProjectIssueRefStatus `json:"issue,omitempty"`
+	Rank           int32                         `json:"rank,omitempty"`
+	Attempt        int32                         `json:"attempt,omitempty"`
+	Reason         string                        `json:"reason,omitempty"`
+	Message        string                        `json:"message,omitempty"`
+	ReadyAt        *metav1.Time                  `json:"readyAt,omitempty"`
+	HarnessRunName string                        `json:"harnessRunName,omitempty"`
+	// Prompt is the rendered WORKFLOW.md prompt for claim and retry items
+	// whose repository has a localPath.
+	Prompt      string `json:"prompt,omitempty"`
+	PromptError string `json:"promptError,omitempty"`
+}
+
+// SymphonyProjectPlanStatus is the outcome of the latest dry-run sync. Nothing
+// it lists has been acted on.
+type SymphonyProjectPlanStatus struct {
+	// RequestedAt echoes the plan-requested-at annotation it answers.
+	RequestedAt   string                          `json:"requestedAt,omitempty"`
+	GeneratedTime *metav1.Time                    `json:"generatedTime,omitempty"`
+	EligibleItems int32                           `json:"eligibleItems,omitempty"`
+	Items         []SymphonyProjectPlanItemStatus `json:"items,omitempty"`
+	Error         string                          `json:"error,omitempty"`
+}
+
 type SymphonyProjectStatus struct {
-	ObservedGeneration int64                            `json:"observedGeneration,omitempty"`
-	Phase              SymphonyProjectPhase             `json:"phase,omitempty"`
-	Conditions         []metav1.Condition               `json:"conditions,omitempty"`
-	ResolvedFieldName  string                           `json:"resolvedFieldName,omitempty"`
+	ObservedGeneration int64                `json:"observedGeneration,omitempty"`
+	Phase              SymphonyProjectPhase `json:"phase,omitempty"`
+	Conditions         []metav1.Condition   `json:"conditions,omitempty"`
+	ResolvedFieldName  string               `json:"resolvedFieldName,omitempty"`
+	// ProjectNodeID is the source project's node ID, used to route GitHub
+	// projects_v2_item webhooks to this project.
+	ProjectNodeID      string                           `json:"projectNodeId,omitempty"`
 	LastSyncTime       *metav1.Time                     `json:"lastSyncTime,omitempty"`
 	LastSuccessfulSync *metav1.Time                     `json:"lastSuccessfulSyncTime,omitempty"`
 	NextSyncTime       *metav1.Time                     `json:"nextSyncTime,omitempty"`
@@ -302,6 +518,10 @@ type SymphonyProjectStatus struct {
 	CompletedItems     int32                            `json:"completedItems,omitempty"`
 	FailedItems        int32                            `json:"failedItems,omitempty"`
 	SkippedItems       int32                            `json:"skippedItems,omitempty"`
+	// Plan is the latest dry-run sync, requested through the plan endpoint.
+	Plan *SymphonyProjectPlanStatus `json:"plan,omitempty"`
+	// Budget is set when runtime.budgets sets any budget.
+	Budget *SymphonyProjectBudgetStatus `json:"budget,omitempty"`
 }
 
 type SymphonyProject struct {
@@ -346,9 +566,18 @@ func (in *SymphonyProject) ApplyDefaults() {
 	if strings.TrimSpace(in.Spec.Runtime.DefaultEgressMode) == "" {
 		in.Spec.Runtime.DefaultEgressMode = "restricted"
 	}
-	if strings.TrimSpace(in.Spec.Source.FieldName) == "" {
+	if strings.TrimSpace(string(in.Spec.Runtime.WorkerMode)) == "" {
+		in.Spec.Runtime.WorkerMode = SymphonyWorkerModeOperator
+	}
+	if strings.TrimSpace(string(in.Spec.Source.Kind)) == "" {
+		in.Spec.Source.Kind = SymphonySourceKindGitHub
+	}
+	if in.Spec.Source.Kind == SymphonySourceKindGitHub && strings.TrimSpace(in.Spec.Source.FieldName) == "" {
 		in.Spec.Source.FieldName = "Status"
 	}
+	if in.Spec.Source.Linear != nil && strings.TrimSpace(in.Spec.Source.Linear.RepositoryLabelPrefix) == "" {
+		in.Spec.Source.Linear.RepositoryLabelPrefix = DefaultLinearRepositoryLabelPrefix
+	}
 	for i := range in.Spec.Source.ActiveStates {
 		in.Spec.Source.ActiveStates[i] = strings.TrimSpace(in.Spec.Source.ActiveStates[i])
 	}
@@ -361,11 +590,20 @@ func (in *SymphonyProject) Validate() error {
 	if in == nil {
 		return fmt.Errorf("symphony project is nil")
 	}
-	if strings.TrimSpace(in.Spec.Source.Project.Owner) == "" {
-		return fmt.Errorf("spec.source.project.owner is required")
-	}
-	if in.Spec.Source.Project.Number <= 0 {
-		return fmt.Errorf("spec.source.project.number must be greater than zero")
+	switch in.Spec.Source.Kind {
+	case "", SymphonySourceKindGitHub:
+		if strings.TrimSpace(in.Spec.Source.Project.Owner) == "" {
+			return fmt.Errorf("spec.source.project.owner is required")
+		}
+		if in.Spec.Source.Project.Number <= 0 {
+			return fmt.Errorf("spec.source.project.number must be greater than zero")
+		}
+	case SymphonySourceKindLinear:
+		if in.Spec.Source.Linear == nil || strings.TrimSpace(in.Spec.Source.Linear.TeamKey) == "" {
+			return fmt.Errorf("spec.source.linear.teamKey is required when spec.source.kind is linear")
+		}
+	default:
+		return fmt.Errorf("spec.source.kind must be one of github|linear (got %q)", in.Spec.Source.Kind)
 	}
 	if strings.TrimSpace(in.Spec.Source.TokenSecretRef.Name) == "" {
 		return fmt.Errorf("spec.source.tokenSecretRef.name is required")
@@ -394,6 +632,35 @@ func (in *SymphonyProject) Validate() error {
 	if in.Spec.Runtime.RetryMaxDelaySeconds < in.Spec.Runtime.RetryBaseDelaySeconds {
 		return fmt.Errorf("spec.runtime.retryMaxDelaySeconds must be greater than or equal to retryBaseDelaySeconds")
 	}
+	for status, limit := range in.Spec.Runtime.MaxConcurrentItemsByStatus {
+		if strings.TrimSpace(status) == "" {
+			return fmt.Errorf("spec.runtime.maxConcurrentItemsByStatus keys must not be empty")
+		}
+		if limit <= 0 {
+			return fmt.Errorf("spec.runtime.maxConcurrentItemsByStatus[%q] must be greater than zero", status)
+		}
+	}
+	budgets := in.Spec.Runtime.Budgets
+	for name, value := range map[string]int64{
+		"maxTokensPerAttempt":  budgets.MaxTokensPerAttempt,
+		"maxSecondsPerAttempt": budgets.MaxSecondsPerAttempt,
+		"maxTokensPerItem":     budgets.MaxTokensPerItem,
+		"maxSecondsPerItem":    budgets.MaxSecondsPerItem,
+		"maxTokensPerDay":      budgets.MaxTokensPerDay,
+		"maxSecondsPerDay":     budgets.MaxSecondsPerDay,
+	} {
+		if value < 0 {
+			return fmt.Errorf("spec.runtime.budgets.%s must not be negative", name)
+		}
+	}
+	if err := ValidateEgressAllowedHosts("spec.runtime.egressAllowedHosts", in.Spec.Runtime.EgressAllowedHosts); err != nil {
+		return err
+	}
+	switch in.Spec.Runtime.WorkerMode {
+	case "", SymphonyWorkerModeOperator, SymphonyWorkerModePod:
+	default:
+		return fmt.Errorf("spec.runtime.workerMode must be one of operator|pod (got %q)", in.Spec.Runtime.WorkerMode)
+	}
 	seenRepositories := map[string]struct{}{}
 	for _, repo := range in.Spec.Repositories {
 		if key := repo.RepositoryKey(); key == "" {
@@ -402,12 +669,68 @@ func (in *SymphonyProject) Validate() error {
 			if _, exists := seenRepositories[key]; exists {
 				return fmt.Errorf("spec.repositories contains duplicate repository %q", key)
 			}
+			if repo.MaxConcurrentItems < 0 {
+				return fmt.Errorf("spec.repositories[%q].maxConcurrentItems must not be negative", key)
+			}
 			seenRepositories[key] = struct{}{}
 		}
 	}
+	if priority := in.Spec.Priority; priority != nil {
+		switch priority.Age {
+		case "", SymphonyPriorityAgeOldestFirst, SymphonyPriorityAgeNewestFirst:
+		default:
+			return fmt.Errorf("spec.priority.age must be one of oldestFirst|newestFirst (got %q)", priority.Age)
+		}
+		if len(priority.FieldOrder) != 0 && strings.TrimSpace(priority.FieldName) == "" {
+			return fmt.Errorf("spec.priority.fieldOrder requires spec.priority.fieldName")
+		}
+		if name := strings.TrimSpace(priority.FieldName); name != "" && in.Spec.Source.Kind == SymphonySourceKindLinear && !strings.EqualFold(name, "priority") {
+			return fmt.Errorf("spec.priority.fieldName must be \"priority\" for linear sources")
+		}
+		for _, pin := range priority.Pinned {
+			if strings.TrimSpace(pin) == "" {
+				return fmt.Errorf("spec.priority.pinned entries must not be empty")
+			}
+		}
+	}
+	if in.Spec.PullRequests != nil {
+		if in.Spec.Runtime.WorkerMode != SymphonyWorkerModePod {
+			return fmt.Errorf("spec.pullRequests requires spec.runtime.workerMode pod")
+		}
+		for _, repo := range in.Spec.Repositories {
+			if repo.GitAuth == nil || strings.TrimSpace(repo.GitAuth.SecretName) == "" {
+				return fmt.Errorf("spec.pullRequests requires gitAuth on repository %q", repo.RepositoryKey())
+			}
+		}
+	}
+	if in.Spec.WriteBack != nil {
+		if in.Spec.Source.Kind == SymphonySourceKindLinear {
+			return fmt.Errorf("spec.writeBack is only supported for github sources")
+		}
+		// Claimed and retried items must stay eligible, so their status has to
+		// remain one of the active states.
+		if status := strings.TrimSpace(in.Spec.WriteBack.OnClaim.Status); status != "" && !containsStateFold(in.Spec.Source.ActiveStates, status) {
+			return fmt.Errorf("spec.writeBack.onClaim.status %q must be one of spec.source.activeStates", status)
+		}
+		if status := strings.TrimSpace(in.Spec.WriteBack.OnFailure.Status); status != "" && !containsStateFold(in.Spec.Source.ActiveStates, status) {
+			return fmt.Errorf("spec.writeBack.onFailure.status %q must be one of spec.source.activeStates", status)
+		}
+		if base := strings.TrimSpace(in.Spec.WriteBack.RunURLBase); base != "" && !strings.HasPrefix(base, "https://") && !strings.HasPrefix(base, "http://") {
+			return fmt.Errorf("spec.writeBack.runURLBase must be an http(s) URL")
+		}
+	}
 	return nil
 }
 
+func containsStateFold(states []string, want string) bool {
+	for _, state := range states {
+		if strings.EqualFold(strings.TrimSpace(state), want) {
+			return true
+		}
+	}
+	return false
+}
+
 // GitAuthSpec configures secure Git credential injection for HTTPS clones.
 // The referenced Secret MUST exist in the same namespace as the HarnessRun.
 //
@@ -662,11 +985,66 @@ type HarnessRunSpec struct {
 	//
 	// Supported values (MVP):
 	// - "restricted": default-deny with GitHub-only allowlist (plus DNS)
+	// - "proxy": restricted, plus the egress proxy, which allows
+	//   EgressAllowedHosts and the session's allowed hosts
 	// - "full": allow full internet egress
 	EgressMode string `json:"egressMode,omitempty"`
 
 	// TTLSecondsAfterFinished controls automatic HarnessRun deletion.
 	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// PullRequest publishes the run's changes as a pull request. Requires GitAuth.
+	PullRequest *HarnessRunPullRequestSpec `json:"pullRequest,omitempty"`
+
+	// Resources sets cpu, memory and ephemeral-storage for the harness
+	// container. Unset values default from the image profile.
+	Resources *corev1.ResourceRequirements `json:"resources,omitempty"`
+
+	// Scheduling places the run's pod, through a named run class and
+	// optional raw overrides.
+	Scheduling *HarnessRunSchedulingSpec `json:"scheduling,omitempty"`
+
+	// EgressAllowedHosts lists the hostnames the run may reach through the
+	// egress proxy in "proxy" egress mode. "*.example.com" matches subdomains.
+	EgressAllowedHosts []string `json:"egressAllowedHosts,omitempty"`
+
+	// ActiveDeadlineSeconds stops the run this long after it started. Unset or
+	// zero means no limit.
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// IdleTimeoutSeconds stops the run after this long without agent session
+	// events or attach activity. Unset or zero means no limit.
+	IdleTimeoutSeconds *int64 `json:"idleTimeoutSeconds,omitempty"`
+}
+
+// HarnessRunPullRequestSpec describes the pull request for a run. Once the run
+// command succeeds the harness commits the repository onto Branch and pushes
+// it with GitAuth; the operator then opens the pull request and tracks it in
+// the pull-request-status annotation until it is merged or closed.
+type HarnessRunPullRequestSpec struct {
+	// Branch receives the commit. It is force-pushed, so it should belong to
+	// the run or to its work item.
+	Branch string `json:"branch"`
+	// Base is the branch to merge into; defaults to the repository's default branch.
+	Base  string `json:"base,omitempty"`
+	Title string `json:"title"`
+	Body  string `json:"body,omitempty"`
+	Draft bool   `json:"draft,omitempty"`
+	// CommitMessage defaults to Title.
+	CommitMessage string `json:"commitMessage,omitempty"`
+}
+
+// HarnessRunSchedulingSpec places a run's pod. RunClass names an
+// admin-defined class in the kocao-run-classes ConfigMap; the class named
+// "default" applies when none is set. The raw fields are layered over the
+// class: node selector entries and the priority class override it, while
+// tolerations and affinity terms are added to it.
+type HarnessRunSchedulingSpec struct {
+	RunClass          string              `json:"runClass,omitempty"`
+	NodeSelector      map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations       []corev1.Toleration `json:"tolerations,omitempty"`
+	Affinity          *corev1.Affinity    `json:"affinity,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty"`
 }
 
 type HarnessRunStatus struct {
@@ -679,6 +1057,13 @@ type HarnessRunStatus struct {
 	CompletionTime     *metav1.Time                    `json:"completionTime,omitempty"`
 	Conditions         []metav1.Condition              `json:"conditions,omitempty"`
 	AgentSession       *AgentSessionStatus             `json:"agentSession,omitempty"`
+
+	// LastActivityTime is the latest agent session event or attach activity
+	// the control-plane API saw for the run. Idle timeouts count from it.
+	LastActivityTime *metav1.Time `json:"lastActivityTime,omitempty"`
+	// Reason and Message say why the run stopped early, e.g. TimedOut.
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
 }
 
 type HarnessRunList struct {
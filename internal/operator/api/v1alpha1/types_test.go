@@ -38,6 +38,9 @@ func TestSymphonyProjectApplyDefaults(t *testing.T) {
 	if got := project.Spec.Runtime.DefaultEgressMode; got != "restricted" {
 		t.Fatalf("expected default egress mode restricted, got %q", got)
 	}
+	if got := project.Spec.Runtime.WorkerMode; got != SymphonyWorkerModeOperator {
+		t.Fatalf("expected default worker mode operator, got %q", got)
+	}
 	if project.Spec.Source.ActiveStates[0] != "Todo" {
 		t.Fatalf("expected trimmed active state, got %q", project.Spec.Source.ActiveStates[0])
 	}
@@ -90,6 +93,124 @@ func TestSymphonyProjectValidateRejectsInvalidConfig(t *testing.T) {
 	if !strings.Contains(err.Error(), "duplicate repository") {
 		t.Fatalf("expected duplicate repository error, got %v", err)
 	}
+
+	project.Spec.Repositories = project.Spec.Repositories[:1]
+	project.Spec.Runtime.WorkerMode = "sidecar"
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "spec.runtime.workerMode") {
+		t.Fatalf("expected worker mode validation error, got %v", err)
+	}
+
+	project.Spec.Runtime.WorkerMode = ""
+	project.Spec.Runtime.Budgets.MaxTokensPerDay = -1
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "spec.runtime.budgets.maxTokensPerDay") {
+		t.Fatalf("expected budget validation error, got %v", err)
+	}
+
+	project.Spec.Runtime.Budgets.MaxTokensPerDay = 0
+	project.Spec.PullRequests = &SymphonyProjectPullRequestSpec{}
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "workerMode pod") {
+		t.Fatalf("expected pull request worker mode error, got %v", err)
+	}
+	project.Spec.Runtime.WorkerMode = SymphonyWorkerModePod
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "requires gitAuth") {
+		t.Fatalf("expected pull request gitAuth error, got %v", err)
+	}
+	project.Spec.Repositories[0].GitAuth = &GitAuthSpec{SecretName: "github-token"}
+	if err := project.Validate(); err != nil {
+		t.Fatalf("expected pull request spec to validate, got %v", err)
+	}
+
+	for _, host := range []string{"https://registry.npmjs.org", "github.com:443", "10.0.0.1", "*", "localhost", "github.com/path", "kubernetes.default", "*.svc.cluster.local", "metadata.google.internal"} {
+		project.Spec.Runtime.EgressAllowedHosts = []string{"*.githubusercontent.com", host}
+		if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "spec.runtime.egressAllowedHosts") {
+			t.Fatalf("expected egress host validation error for %q, got %v", host, err)
+		}
+	}
+	project.Spec.Runtime.EgressAllowedHosts = []string{"registry.npmjs.org", "*.githubusercontent.com"}
+	if err := project.Validate(); err != nil {
+		t.Fatalf("expected egress hosts to validate, got %v", err)
+	}
+}
+
+func TestSymphonyProjectLinearSourceKind(t *testing.T) {
+	project := &SymphonyProject{
+		Spec: SymphonyProjectSpec{
+			Source: SymphonyProjectSourceSpec{
+				Kind:           SymphonySourceKindLinear,
+				TokenSecretRef: SecretKeyRef{Name: "linear-api-key"},
+				ActiveStates:   []string{"Todo"},
+				TerminalStates: []string{"Done"},
+			},
+			Repositories: []SymphonyProjectRepositorySpec{{Owner: "withakay", Name: "kocao"}},
+			Runtime:      SymphonyProjectRuntimeSpec{Image: "ghcr.io/withakay/kocao-harness:latest"},
+		},
+	}
+	project.ApplyDefaults()
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "spec.source.linear.teamKey") {
+		t.Fatalf("expected linear team validation error, got %v", err)
+	}
+
+	project.Spec.Source.Linear = &LinearProjectRef{TeamKey: "ENG"}
+	project.ApplyDefaults()
+	if err := project.Validate(); err != nil {
+		t.Fatalf("expected linear source to validate without a GitHub project, got %v", err)
+	}
+	if got := project.Spec.Source.Linear.RepositoryLabelPrefix; got != DefaultLinearRepositoryLabelPrefix {
+		t.Fatalf("expected repository label prefix default, got %q", got)
+	}
+	if got := project.Spec.Source.FieldName; got != "" {
+		t.Fatalf("expected no GitHub field name default for linear, got %q", got)
+	}
+	copied := project.DeepCopy()
+	copied.Spec.Source.Linear.TeamKey = "OPS"
+	if project.Spec.Source.Linear.TeamKey != "ENG" {
+		t.Fatal("expected DeepCopy to copy the linear source reference")
+	}
+
+	project.Spec.Source.Kind = "jira"
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "spec.source.kind") {
+		t.Fatalf("expected source kind validation error, got %v", err)
+	}
+}
+
+func TestSymphonyProjectWriteBackValidation(t *testing.T) {
+	project := &SymphonyProject{
+		Spec: SymphonyProjectSpec{
+			Source: SymphonyProjectSourceSpec{
+				Project:        GitHubProjectRef{Owner: "withakay", Number: 12},
+				TokenSecretRef: SecretKeyRef{Name: "github-token"},
+				ActiveStates:   []string{"Todo", "In Progress"},
+				TerminalStates: []string{"Done"},
+			},
+			Repositories: []SymphonyProjectRepositorySpec{{Owner: "withakay", Name: "kocao"}},
+			Runtime:      SymphonyProjectRuntimeSpec{Image: "ghcr.io/withakay/kocao-harness:latest"},
+			WriteBack: &SymphonyProjectWriteBackSpec{
+				Comment:    true,
+				RunURLBase: "https://kocao.example.com",
+				OnClaim:    SymphonyProjectWriteBackActionSpec{Status: "in progress", AddLabels: []string{"symphony"}},
+				OnSuccess:  SymphonyProjectWriteBackActionSpec{Status: "In Review", RemoveLabels: []string{"symphony"}},
+			},
+		},
+	}
+	project.ApplyDefaults()
+	if err := project.Validate(); err != nil {
+		t.Fatalf("expected write-back config to validate, got %v", err)
+	}
+	copied := project.DeepCopy()
+	copied.Spec.WriteBack.OnClaim.AddLabels[0] = "changed"
+	if project.Spec.WriteBack.OnClaim.AddLabels[0] != "symphony" {
+		t.Fatal("expected DeepCopy to copy write-back labels")
+	}
+
+	project.Spec.WriteBack.OnClaim.Status = "In Review"
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "spec.writeBack.onClaim.status") {
+		t.Fatalf("expected inactive claim status to be rejected, got %v", err)
+	}
+	project.Spec.WriteBack.OnClaim.Status = ""
+	project.Spec.WriteBack.RunURLBase = "kocao.example.com"
+	if err := project.Validate(); err == nil || !strings.Contains(err.Error(), "runURLBase") {
+		t.Fatalf("expected run URL validation error, got %v", err)
+	}
 }
 
 func TestAddToSchemeRegistersSymphonyProject(t *testing.T) {
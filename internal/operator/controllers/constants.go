@@ -10,9 +10,15 @@ const (
 	LabelSymphonyItemID       = "kocao.withakay.github.com/symphony-item-id"
 	LabelGitHubRepository     = "kocao.withakay.github.com/github-repository"
 	LabelGitHubIssueNumber    = "kocao.withakay.github.com/github-issue-number"
+	// LabelTeam scopes a resource to a team so team members share access.
+	LabelTeam = "kocao.withakay.github.com/team"
 )
 
 const (
+	// AnnotationOwner records the audit actor that created a resource. It is an
+	// annotation because identities (e-mail addresses) are not valid label values.
+	AnnotationOwner = "kocao.withakay.github.com/owner"
+
 	AnnotationAttachEnabled          = "kocao.withakay.github.com/attach-enabled"
 	AnnotationEgressMode             = "kocao.withakay.github.com/egress-mode"
 	AnnotationEgressHosts            = "kocao.withakay.github.com/egress-allowed-hosts"
@@ -32,9 +38,26 @@ const (
 	AnnotationSymphonyApprovalPolicy = "kocao.withakay.github.com/symphony-approval-policy"
 	AnnotationSymphonyThreadSandbox  = "kocao.withakay.github.com/symphony-thread-sandbox"
 	AnnotationSymphonyTurnSandbox    = "kocao.withakay.github.com/symphony-turn-sandbox-policy"
+	AnnotationSymphonyStopReason     = "kocao.withakay.github.com/symphony-stop-reason"
+	// AnnotationSymphonyTurns holds the JSON list of per-turn results.
+	AnnotationSymphonyTurns = "kocao.withakay.github.com/symphony-turns"
+	// AnnotationSymphonyHooks holds the JSON list of lifecycle hook results,
+	// with redacted output.
+	AnnotationSymphonyHooks = "kocao.withakay.github.com/symphony-hooks"
+	// AnnotationSymphonyTaskConfigMap names the ConfigMap holding the rendered
+	// task for a pod-mode Symphony worker.
+	AnnotationSymphonyTaskConfigMap = "kocao.withakay.github.com/symphony-task-configmap"
+	// AnnotationSymphonyPlanRequestedAt asks the operator for a dry-run sync;
+	// the plan in status echoes its value once it is ready.
+	AnnotationSymphonyPlanRequestedAt = "kocao.withakay.github.com/symphony-plan-requested-at"
+
+	// AnnotationRestoreVolumeSnapshot names a VolumeSnapshot to provision the
+	// session's workspace PVC from. It only takes effect when the PVC is created.
+	AnnotationRestoreVolumeSnapshot = "kocao.withakay.github.com/restore-volume-snapshot"
 
-	// GitHub outcome metadata is reported by the harness (or external automation)
-	// and surfaced through the control-plane API for UI visibility.
+	// GitHub outcome metadata is surfaced through the control-plane API for UI
+	// visibility. The operator sets it for runs with spec.pullRequest; other
+	// runs may have it set by the harness or external automation.
 	AnnotationGitHubBranch      = "kocao.withakay.github.com/github-branch"
 	AnnotationPullRequestURL    = "kocao.withakay.github.com/pull-request-url"
 	AnnotationPullRequestStatus = "kocao.withakay.github.com/pull-request-status"
@@ -49,4 +72,12 @@ const (
 	ConditionConfig    = "ConfigReady"
 	ConditionSource    = "SourceSynced"
 	ConditionLifecycle = "OrchestrationReady"
+	ConditionWriteBack = "SourceWriteBack"
+	// ConditionPullRequest reports the pull request of a run with
+	// spec.pullRequest.
+	ConditionPullRequest = "PullRequestReady"
 )
+
+// ConditionBranchPush records what the harness entrypoint reported about
+// pushing the branch of a run with spec.pullRequest.
+const ConditionBranchPush = "BranchPushed"
@@ -4,9 +4,12 @@ import (
 	"context"
 	"errors"
 	"net/netip"
+	"net/url"
 	"os"
+	"strconv"
 	"strings"
 
+	"github.com/withakay/kocao/internal/egressproxy"
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
@@ -21,8 +24,18 @@ import (
 
 const envGitHubEgressCIDRs = "CP_GITHUB_EGRESS_CIDRS"
 
+// envEgressProxyURL overrides the address of the namespace's egress proxy,
+// which runs in proxy egress mode reach allowed hosts through.
+const envEgressProxyURL = "CP_EGRESS_PROXY_URL"
+
+const defaultEgressProxyURL = "http://kocao-egress-proxy:3128"
+
+// egressProxyPodLabels select the egress proxy pods.
+var egressProxyPodLabels = map[string]string{"app.kubernetes.io/name": "kocao-egress-proxy"}
+
 const (
 	egressModeRestricted = "restricted"
+	egressModeProxy      = "proxy"
 	egressModeFull       = "full"
 )
 
@@ -31,6 +44,8 @@ func normalizeEgressMode(mode string) string {
 	switch m {
 	case "", "github", "github-only", "restricted", "deny-by-default":
 		return egressModeRestricted
+	case "proxy", "allowlist":
+		return egressModeProxy
 	case "full", "full-internet", "internet":
 		return egressModeFull
 	default:
@@ -75,7 +90,49 @@ func githubEgressCIDRs() ([]string, []string) {
 	return parseGitHubEgressCIDRs(v)
 }
 
-func desiredRunEgressNetworkPolicy(run *operatorv1alpha1.HarnessRun, mode string, githubCIDRs []string) *networkingv1.NetworkPolicy {
+// egressProxyURL returns the egress proxy URL and its port, defaulting to
+// the kocao-egress-proxy Service.
+func egressProxyURL() (string, int) {
+	raw := strings.TrimSpace(os.Getenv(envEgressProxyURL))
+	if raw == "" {
+		raw = defaultEgressProxyURL
+	}
+	port := 3128
+	if u, err := url.Parse(raw); err == nil {
+		if p, err := strconv.Atoi(u.Port()); err == nil && p > 0 {
+			port = p
+		}
+	}
+	return raw, port
+}
+
+// runEgressAllowedHosts returns the hosts a run in proxy egress mode may
+// reach: its own and those allowed on its workspace session.
+func runEgressAllowedHosts(run *operatorv1alpha1.HarnessRun, sess *operatorv1alpha1.Session) []string {
+	hosts := append([]string(nil), run.Spec.EgressAllowedHosts...)
+	if sess != nil {
+		hosts = append(hosts, egressproxy.ParseHosts(sess.Annotations[AnnotationEgressHosts])...)
+	}
+	return egressproxy.NormalizeHosts(hosts)
+}
+
+// applyEgressProxy points the harness container at the egress proxy and
+// records the run's allowlist on the pod, where the proxy reads it.
+func applyEgressProxy(pod *corev1.Pod, proxyURL string, hosts []string) {
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[AnnotationEgressHosts] = strings.Join(hosts, ",")
+	noProxy := "localhost,127.0.0.1,.svc,.cluster.local"
+	for _, name := range []string{"HTTPS_PROXY", "HTTP_PROXY", "https_proxy", "http_proxy"} {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, corev1.EnvVar{Name: name, Value: proxyURL})
+	}
+	for _, name := range []string{"NO_PROXY", "no_proxy"} {
+		pod.Spec.Containers[0].Env = append(pod.Spec.Containers[0].Env, corev1.EnvVar{Name: name, Value: noProxy})
+	}
+}
+
+func desiredRunEgressNetworkPolicy(run *operatorv1alpha1.HarnessRun, mode string, githubCIDRs []string, proxyPort int) *networkingv1.NetworkPolicy {
 	labels := map[string]string{
 		"app.kubernetes.io/managed-by":  "kocao-control-plane-operator",
 		"app.kubernetes.io/name":        "kocao-harness-egress",
@@ -123,6 +180,14 @@ func desiredRunEgressNetworkPolicy(run *operatorv1alpha1.HarnessRun, mode string
 		egress = append(egress, networkingv1.NetworkPolicyEgressRule{To: peers, Ports: ports})
 	}
 
+	// Proxy mode adds the egress proxy, which enforces the hostname allowlist.
+	if mode == egressModeProxy {
+		egress = append(egress, networkingv1.NetworkPolicyEgressRule{
+			To:    []networkingv1.NetworkPolicyPeer{{PodSelector: &metav1.LabelSelector{MatchLabels: egressProxyPodLabels}}},
+			Ports: []networkingv1.NetworkPolicyPort{{Protocol: protoPtr(corev1.ProtocolTCP), Port: intstrPtr(proxyPort)}},
+		})
+	}
+
 	return &networkingv1.NetworkPolicy{
 		ObjectMeta: metav1.ObjectMeta{Name: runEgressNetworkPolicyName(run.Name), Namespace: run.Namespace, Labels: labels},
 		Spec:       networkingv1.NetworkPolicySpec{PodSelector: podSelector, PolicyTypes: policyTypes, Egress: egress},
@@ -141,7 +206,8 @@ func ensureRunEgressNetworkPolicy(ctx context.Context, c client.Client, scheme *
 		)
 	}
 
-	desired := desiredRunEgressNetworkPolicy(run, mode, githubCIDRs)
+	_, proxyPort := egressProxyURL()
+	desired := desiredRunEgressNetworkPolicy(run, mode, githubCIDRs, proxyPort)
 	if err := controllerutil.SetControllerReference(run, desired, scheme); err != nil {
 		return err
 	}
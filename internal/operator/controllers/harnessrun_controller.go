@@ -2,6 +2,8 @@ package controllers
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"strings"
 	"time"
 
@@ -11,6 +13,7 @@ import (
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/clock"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -19,9 +22,12 @@ import (
 
 type HarnessRunReconciler struct {
 	client.Client
-	Scheme    *runtime.Scheme
-	Clock     clock.Clock
-	PodImages PodImages
+	Scheme       *runtime.Scheme
+	Clock        clock.Clock
+	PodImages    PodImages
+	PullRequests pullRequestClientFactory
+	// Recorder, when set, receives timeout warning events.
+	Recorder record.EventRecorder
 }
 
 func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -176,6 +182,45 @@ func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
 		changedStatus = true
 	}
+	if pr := updated.Spec.PullRequest; pr != nil && (updated.Spec.GitAuth == nil || strings.TrimSpace(pr.Branch) == "" || strings.TrimSpace(pr.Title) == "") {
+		now := metav1.NewTime(r.Clock.Now())
+		setCondition(&updated.Status.Conditions, metav1.Condition{
+			Type:               ConditionFailed,
+			Status:             metav1.ConditionTrue,
+			Reason:             "SpecInvalid",
+			Message:            invalidSpecError("pullRequest requires gitAuth, branch and title").Error(),
+			LastTransitionTime: now,
+		})
+		updated.Status.ObservedGeneration = updated.Generation
+		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
+		changedStatus = true
+	}
+	if err := operatorv1alpha1.ValidateEgressAllowedHosts("egressAllowedHosts", updated.Spec.EgressAllowedHosts); err != nil {
+		now := metav1.NewTime(r.Clock.Now())
+		setCondition(&updated.Status.Conditions, metav1.Condition{
+			Type:               ConditionFailed,
+			Status:             metav1.ConditionTrue,
+			Reason:             "SpecInvalid",
+			Message:            invalidSpecError(err.Error()).Error(),
+			LastTransitionTime: now,
+		})
+		updated.Status.ObservedGeneration = updated.Generation
+		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
+		changedStatus = true
+	}
+	if err := operatorv1alpha1.ValidateHarnessResources("resources", updated.Spec.Resources); err != nil {
+		now := metav1.NewTime(r.Clock.Now())
+		setCondition(&updated.Status.Conditions, metav1.Condition{
+			Type:               ConditionFailed,
+			Status:             metav1.ConditionTrue,
+			Reason:             "SpecInvalid",
+			Message:            invalidSpecError(err.Error()).Error(),
+			LastTransitionTime: now,
+		})
+		updated.Status.ObservedGeneration = updated.Generation
+		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
+		changedStatus = true
+	}
 	for _, e := range updated.Spec.Env {
 		if strings.HasPrefix(strings.TrimSpace(e.Name), "KOCAO_") {
 			now := metav1.NewTime(r.Clock.Now())
@@ -193,6 +238,30 @@ func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Resolve the run class before the pod exists, so a run that may not use
+	// its class fails instead of being scheduled.
+	var scheduling operatorv1alpha1.HarnessRunSchedulingSpec
+	if updated.Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed && updated.Status.PodName == "" && updated.DeletionTimestamp.IsZero() {
+		resolved, err := r.resolveRunScheduling(ctx, updated)
+		var classErr *runClassError
+		if errors.As(err, &classErr) {
+			now := metav1.NewTime(r.Clock.Now())
+			setCondition(&updated.Status.Conditions, metav1.Condition{
+				Type:               ConditionFailed,
+				Status:             metav1.ConditionTrue,
+				Reason:             classErr.reason,
+				Message:            classErr.message,
+				LastTransitionTime: now,
+			})
+			updated.Status.ObservedGeneration = updated.Generation
+			updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
+			changedStatus = true
+		} else if err != nil {
+			return ctrl.Result{}, err
+		}
+		scheduling = resolved
+	}
+
 	// Create/observe pod if not terminal.
 	if updated.Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed {
 		if updated.Status.Phase == "" {
@@ -218,10 +287,15 @@ func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			workspacePVC := ""
 			displayName := ""
 			if sess != nil {
-				workspacePVC = sessionWorkspacePVCName(sess.Name)
+				workspacePVC = SessionWorkspacePVCName(sess.Name)
 				displayName = sess.Spec.DisplayName
 			}
 			pod := buildHarnessPod(updated, workspacePVC, displayName, r.PodImages)
+			applyRunScheduling(pod, scheduling)
+			if normalizeEgressMode(egressMode) == egressModeProxy {
+				proxyURL, _ := egressProxyURL()
+				applyEgressProxy(pod, proxyURL, runEgressAllowedHosts(updated, sess))
+			}
 			if err := controllerutil.SetControllerReference(updated, pod, r.Scheme); err != nil {
 				return ctrl.Result{}, err
 			}
@@ -256,6 +330,21 @@ func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 			} else {
 				changed, res, deleteNow := updateStatusFromPod(updated, &pod, r.Clock.Now())
 				changedStatus = changedStatus || changed
+				timeoutChanged, timeoutRequeue, err := r.enforceRunTimeout(ctx, updated, &pod, r.Clock.Now())
+				if err != nil {
+					return ctrl.Result{}, err
+				}
+				changedStatus = changedStatus || timeoutChanged
+				reportedMeta, reportedStatus := applySymphonyWorkerReport(updated, &pod)
+				changedMeta = changedMeta || reportedMeta
+				changedStatus = changedStatus || reportedStatus
+				prMeta, prStatus, prRequeue := r.reconcilePullRequest(ctx, updated, r.Clock.Now())
+				changedMeta = changedMeta || prMeta
+				changedStatus = changedStatus || prStatus
+				if prRequeue > 0 {
+					// Keep the run past its TTL until its pull request settles.
+					deleteNow = false
+				}
 				if changedMeta {
 					metaUpdated := updated.DeepCopy()
 					metaUpdated.Status = run.Status
@@ -282,6 +371,12 @@ func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 					}
 					return ctrl.Result{RequeueAfter: 200 * time.Millisecond}, nil
 				}
+				if prRequeue > 0 && (res.RequeueAfter == 0 || prRequeue < res.RequeueAfter) {
+					res.RequeueAfter = prRequeue
+				}
+				if timeoutRequeue > 0 && (res.RequeueAfter == 0 || timeoutRequeue < res.RequeueAfter) {
+					res.RequeueAfter = timeoutRequeue
+				}
 				if res.RequeueAfter > 0 {
 					return res, nil
 				}
@@ -289,6 +384,19 @@ func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
+	// Timed-out runs have no pod left to observe but still honour their TTL.
+	var ttlRequeue time.Duration
+	if updated.Status.Reason == ReasonTimedOut && updated.Status.CompletionTime != nil && updated.Spec.TTLSecondsAfterFinished != nil && updated.DeletionTimestamp.IsZero() {
+		expire := updated.Status.CompletionTime.Time.Add(time.Duration(*updated.Spec.TTLSecondsAfterFinished) * time.Second)
+		if !r.Clock.Now().Before(expire) {
+			if err := r.Delete(ctx, updated); err != nil && !apierrors.IsNotFound(err) {
+				return ctrl.Result{}, err
+			}
+			return ctrl.Result{RequeueAfter: 200 * time.Millisecond}, nil
+		}
+		ttlRequeue = expire.Sub(r.Clock.Now())
+	}
+
 	if changedMeta {
 		metaUpdated := updated.DeepCopy()
 		metaUpdated.Status = run.Status
@@ -307,7 +415,7 @@ func (r *HarnessRunReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: ttlRequeue}, nil
 }
 
 // Ensure networkingv1 is included in the operator scheme (defensive check).
@@ -341,6 +449,7 @@ func updateStatusFromPod(run *operatorv1alpha1.HarnessRun, pod *corev1.Pod, now
 		setPhase(operatorv1alpha1.HarnessRunPhaseSucceeded)
 		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionSucceeded, Status: metav1.ConditionTrue, Reason: "PodSucceeded", Message: "run pod completed successfully", LastTransitionTime: nowMeta})
 		clearCondition(&run.Status.Conditions, ConditionFailed)
+		observeBranchPush(run, pod, nowMeta)
 		changed = true
 		if run.Status.CompletionTime == nil {
 			run.Status.CompletionTime = &nowMeta
@@ -348,7 +457,8 @@ func updateStatusFromPod(run *operatorv1alpha1.HarnessRun, pod *corev1.Pod, now
 		}
 	case corev1.PodFailed:
 		setPhase(operatorv1alpha1.HarnessRunPhaseFailed)
-		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionFailed, Status: metav1.ConditionTrue, Reason: "PodFailed", Message: "run pod failed", LastTransitionTime: nowMeta})
+		reason, message := podFailureReason(pod)
+		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionFailed, Status: metav1.ConditionTrue, Reason: reason, Message: message, LastTransitionTime: nowMeta})
 		clearCondition(&run.Status.Conditions, ConditionSucceeded)
 		changed = true
 		if run.Status.CompletionTime == nil {
@@ -380,6 +490,20 @@ func updateStatusFromPod(run *operatorv1alpha1.HarnessRun, pod *corev1.Pod, now
 	return changed, ctrl.Result{}, false
 }
 
+// podFailureReason names the resource pressure that failed a pod, when there
+// was one, so OOM kills and evictions are not reported as plain failures.
+func podFailureReason(pod *corev1.Pod) (string, string) {
+	if pod.Status.Reason == "Evicted" {
+		return "Evicted", firstNonEmpty(strings.TrimSpace(pod.Status.Message), "run pod was evicted")
+	}
+	for _, status := range append(append([]corev1.ContainerStatus(nil), pod.Status.InitContainerStatuses...), pod.Status.ContainerStatuses...) {
+		if terminated := status.State.Terminated; terminated != nil && terminated.Reason == "OOMKilled" {
+			return "OOMKilled", fmt.Sprintf("container %q was killed for exceeding its memory limit", status.Name)
+		}
+	}
+	return "PodFailed", "run pod failed"
+}
+
 func observeStartupMetricsFromPod(run *operatorv1alpha1.HarnessRun, pod *corev1.Pod) bool {
 	var changed bool
 	metrics := run.Status.StartupMetrics
@@ -2,14 +2,20 @@ package controllers
 
 import (
 	"context"
+	"strings"
 	"testing"
 	"time"
 
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
 	corev1 "k8s.io/api/core/v1"
 	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	clocktesting "k8s.io/utils/clock/testing"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -1020,7 +1026,7 @@ func TestHarnessRunReconcile_WithSession_CreatesPVCMountAndEgressNetworkPolicy(t
 
 	// PVC exists.
 	var pvc corev1.PersistentVolumeClaim
-	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: sessionWorkspacePVCName("s1")}, &pvc); err != nil {
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: SessionWorkspacePVCName("s1")}, &pvc); err != nil {
 		t.Fatalf("get pvc: %v", err)
 	}
 
@@ -1045,7 +1051,7 @@ func TestHarnessRunReconcile_WithSession_CreatesPVCMountAndEgressNetworkPolicy(t
 	volOK := false
 	for _, v := range pod.Spec.Volumes {
 		if v.Name == "workspace" {
-			volOK = v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == sessionWorkspacePVCName("s1")
+			volOK = v.PersistentVolumeClaim != nil && v.PersistentVolumeClaim.ClaimName == SessionWorkspacePVCName("s1")
 			break
 		}
 	}
@@ -1053,3 +1059,695 @@ func TestHarnessRunReconcile_WithSession_CreatesPVCMountAndEgressNetworkPolicy(t
 		t.Fatalf("expected workspace PVC volume, got volumes=%#v", pod.Spec.Volumes)
 	}
 }
+
+func TestHarnessRunReconcile_SymphonyWorkerReportBecomesRunAnnotations(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+
+	run := &operatorv1alpha1.HarnessRun{
+		TypeMeta: metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "run-worker", Namespace: "default", Annotations: map[string]string{
+			AnnotationSymphonyTaskConfigMap: "run-worker-task",
+		}},
+		Spec: operatorv1alpha1.HarnessRunSpec{RepoURL: "https://example.com/repo", Image: "busybox", Command: []string{symphonyWorkerCommand}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.HarnessRun{}, &corev1.Pod{}).WithObjects(run).Build()
+	r := &HarnessRunReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(10, 0))}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+		t.Fatalf("reconcile 1: %v", err)
+	}
+
+	var updated operatorv1alpha1.HarnessRun
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	var pod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: updated.Status.PodName}, &pod); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name: "harness",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 1,
+			Message:  `{"threadId":"thread-1","turnId":"turn-2","lastEvent":"turn_failed","lastMessage":"tests failed","inputTokens":40,"outputTokens":2,"totalTokens":42,"secondsRunning":12.5,"error":"turn_failed: tests failed"}`,
+		}},
+	}}
+	if err := cl.Status().Update(context.Background(), &pod); err != nil {
+		t.Fatalf("update pod status: %v", err)
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+		t.Fatalf("reconcile 2: %v", err)
+	}
+
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+		t.Fatalf("get run 2: %v", err)
+	}
+	if updated.Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed {
+		t.Fatalf("phase = %q, want Failed", updated.Status.Phase)
+	}
+	if updated.Annotations[AnnotationSymphonyTotalTokens] != "42" || updated.Annotations[AnnotationSymphonySessionID] != "thread-1-turn-2" || updated.Annotations[AnnotationSymphonyRuntimeSeconds] != "12.500" {
+		t.Fatalf("annotations = %#v", updated.Annotations)
+	}
+	if got := conditionReason(updated.Status.Conditions, ConditionFailed); got != "WorkerExecutionFailed" {
+		t.Fatalf("failed reason = %q, want WorkerExecutionFailed", got)
+	}
+}
+
+func TestHarnessRunReconcile_SymphonyWorkerReportSurvivesPushLine(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+
+	run := &operatorv1alpha1.HarnessRun{
+		TypeMeta: metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "run-pushed", Namespace: "default", Annotations: map[string]string{
+			AnnotationSymphonyTaskConfigMap: "run-pushed-task",
+		}},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			RepoURL:     "https://github.com/withakay/kocao.git",
+			Image:       "busybox",
+			Command:     []string{symphonyWorkerCommand},
+			GitAuth:     &operatorv1alpha1.GitAuthSpec{SecretName: "gh"},
+			PullRequest: &operatorv1alpha1.HarnessRunPullRequestSpec{Branch: "symphony/kocao-42", Title: "Fix it (withakay/kocao#42)"},
+		},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "gh", Namespace: "default"}, Data: map[string][]byte{"token": []byte("github-token")}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.HarnessRun{}, &corev1.Pod{}).WithObjects(run, secret).Build()
+	stub := &stubPullRequestClient{open: githubsource.PullRequest{Number: 7, URL: "https://github.com/withakay/kocao/pull/7", State: githubsource.PullRequestOpen}, state: githubsource.PullRequestOpen}
+	r := &HarnessRunReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(10, 0)), PullRequests: stub}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+		t.Fatalf("reconcile 1: %v", err)
+	}
+
+	var updated operatorv1alpha1.HarnessRun
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	var pod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: updated.Status.PodName}, &pod); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	// The worker writes its report first; the entrypoint appends the push
+	// line after it.
+	pod.Status.Phase = corev1.PodSucceeded
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name: "harness",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			Message: `{"threadId":"thread-1","turnId":"turn-3","lastEvent":"turn_completed","inputTokens":90,"outputTokens":10,"totalTokens":100,"secondsRunning":30}` + "\nkocao-push: pushed\n",
+		}},
+	}}
+	if err := cl.Status().Update(context.Background(), &pod); err != nil {
+		t.Fatalf("update pod status: %v", err)
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+		t.Fatalf("reconcile 2: %v", err)
+	}
+
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+		t.Fatalf("get run 2: %v", err)
+	}
+	if updated.Annotations[AnnotationSymphonyTotalTokens] != "100" || updated.Annotations[AnnotationSymphonySessionID] != "thread-1-turn-3" {
+		t.Fatalf("annotations = %#v", updated.Annotations)
+	}
+	if got := conditionReason(updated.Status.Conditions, ConditionBranchPush); got != branchPushPushed {
+		t.Fatalf("branch push reason = %q, want %s", got, branchPushPushed)
+	}
+}
+
+func TestHarnessRunReconcile_SymphonyWorkerHookFailureNamesReason(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+
+	run := &operatorv1alpha1.HarnessRun{
+		TypeMeta: metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "run-hook", Namespace: "default", Annotations: map[string]string{
+			AnnotationSymphonyTaskConfigMap: "run-hook-task",
+		}},
+		Spec: operatorv1alpha1.HarnessRunSpec{RepoURL: "https://example.com/repo", Image: "busybox", Command: []string{symphonyWorkerCommand}},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.HarnessRun{}, &corev1.Pod{}).WithObjects(run).Build()
+	r := &HarnessRunReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(10, 0))}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+		t.Fatalf("reconcile 1: %v", err)
+	}
+
+	var updated operatorv1alpha1.HarnessRun
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	var pod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: updated.Status.PodName}, &pod); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	pod.Status.Phase = corev1.PodFailed
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		Name: "harness",
+		State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{
+			ExitCode: 1,
+			Message:  `{"hooks":[{"name":"before_run","exitCode":1,"output":"token=abc123"}],"failedHook":"before_run","error":"before_run hook exited with 1: token=abc123"}`,
+		}},
+	}}
+	if err := cl.Status().Update(context.Background(), &pod); err != nil {
+		t.Fatalf("update pod status: %v", err)
+	}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+		t.Fatalf("reconcile 2: %v", err)
+	}
+
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+		t.Fatalf("get run 2: %v", err)
+	}
+	if updated.Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed {
+		t.Fatalf("phase = %q, want Failed", updated.Status.Phase)
+	}
+	if got := conditionReason(updated.Status.Conditions, ConditionFailed); got != "BeforeRunHookFailed" {
+		t.Fatalf("failed reason = %q, want BeforeRunHookFailed", got)
+	}
+	if hooks := updated.Annotations[AnnotationSymphonyHooks]; !strings.Contains(hooks, `"before_run"`) || strings.Contains(hooks, "abc123") {
+		t.Fatalf("hooks annotation = %q, want redacted before_run result", hooks)
+	}
+}
+
+func TestBuildHarnessPod_SymphonyTaskConfigMapMounted(t *testing.T) {
+	run := &operatorv1alpha1.HarnessRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-task", Namespace: "default", Annotations: map[string]string{
+			AnnotationSymphonyTaskConfigMap: "run-task-task",
+		}},
+		Spec: operatorv1alpha1.HarnessRunSpec{RepoURL: "https://example.com/repo", Image: "busybox"},
+	}
+	pod := buildHarnessPod(run, "", "")
+
+	var found bool
+	for _, v := range pod.Spec.Volumes {
+		if v.Name == "symphony-task" {
+			found = v.ConfigMap != nil && v.ConfigMap.Name == "run-task-task"
+		}
+	}
+	if !found {
+		t.Fatalf("expected symphony-task ConfigMap volume, got %#v", pod.Spec.Volumes)
+	}
+	var taskEnv string
+	for _, e := range pod.Spec.Containers[0].Env {
+		if e.Name == "KOCAO_SYMPHONY_TASK" {
+			taskEnv = e.Value
+		}
+	}
+	if taskEnv != "/var/run/kocao/symphony/task.json" {
+		t.Fatalf("KOCAO_SYMPHONY_TASK = %q", taskEnv)
+	}
+}
+
+type stubPullRequestClient struct {
+	token  string
+	opened []githubsource.PullRequestRequest
+	open   githubsource.PullRequest
+	err    error
+	state  string
+}
+
+func (s *stubPullRequestClient) New(token string) (pullRequestClient, error) {
+	s.token = token
+	return s, nil
+}
+
+func (s *stubPullRequestClient) OpenPullRequest(_ context.Context, req githubsource.PullRequestRequest) (githubsource.PullRequest, error) {
+	s.opened = append(s.opened, req)
+	return s.open, s.err
+}
+
+func (s *stubPullRequestClient) GetPullRequest(_ context.Context, url string) (githubsource.PullRequest, error) {
+	return githubsource.PullRequest{Number: s.open.Number, URL: url, State: s.state}, nil
+}
+
+func TestHarnessRunReconcile_PullRequestOpenedAndTrackedUntilMerged(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+
+	newRun := func(name string) *operatorv1alpha1.HarnessRun {
+		return &operatorv1alpha1.HarnessRun{
+			TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec: operatorv1alpha1.HarnessRunSpec{
+				RepoURL: "https://github.com/withakay/kocao.git",
+				Image:   "busybox",
+				GitAuth: &operatorv1alpha1.GitAuthSpec{SecretName: "gh"},
+				PullRequest: &operatorv1alpha1.HarnessRunPullRequestSpec{
+					Branch: "symphony/kocao-42",
+					Title:  "Fix it (withakay/kocao#42)",
+					Body:   "Closes withakay/kocao#42",
+				},
+			},
+		}
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "gh", Namespace: "default"}, Data: map[string][]byte{"token": []byte("github-token")}}
+	merged := newRun("run-pr")
+	unchanged := newRun("run-no-changes")
+	lost := newRun("run-lost")
+	lost.Spec.Command = []string{"make", "fix"}
+	empty := newRun("run-command-no-changes")
+	empty.Spec.Command = []string{"make", "fix"}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.HarnessRun{}, &corev1.Pod{}).WithObjects(merged, unchanged, lost, empty, secret).Build()
+	stub := &stubPullRequestClient{open: githubsource.PullRequest{Number: 7, URL: "https://github.com/withakay/kocao/pull/7", State: githubsource.PullRequestOpen}, state: githubsource.PullRequestOpen}
+	r := &HarnessRunReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(10, 0)), PullRequests: stub}
+
+	succeed := func(run *operatorv1alpha1.HarnessRun, terminationMessage string) ctrl.Result {
+		t.Helper()
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+			t.Fatalf("reconcile %s: %v", run.Name, err)
+		}
+		var updated operatorv1alpha1.HarnessRun
+		if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+			t.Fatalf("get run: %v", err)
+		}
+		var pod corev1.Pod
+		if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: updated.Status.PodName}, &pod); err != nil {
+			t.Fatalf("get pod: %v", err)
+		}
+		pushBranch := ""
+		for _, ev := range pod.Spec.Containers[0].Env {
+			if ev.Name == "KOCAO_PUSH_BRANCH" {
+				pushBranch = ev.Value
+			}
+		}
+		if pushBranch != "symphony/kocao-42" {
+			t.Fatalf("KOCAO_PUSH_BRANCH = %q", pushBranch)
+		}
+		pod.Status.Phase = corev1.PodSucceeded
+		pod.Status.ContainerStatuses = []corev1.ContainerStatus{{Name: "harness", State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{Message: terminationMessage}}}}
+		if err := cl.Status().Update(context.Background(), &pod); err != nil {
+			t.Fatalf("update pod status: %v", err)
+		}
+		res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)})
+		if err != nil {
+			t.Fatalf("reconcile %s: %v", run.Name, err)
+		}
+		return res
+	}
+	get := func(run *operatorv1alpha1.HarnessRun) operatorv1alpha1.HarnessRun {
+		t.Helper()
+		var updated operatorv1alpha1.HarnessRun
+		if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+			t.Fatalf("get run: %v", err)
+		}
+		return updated
+	}
+
+	if res := succeed(merged, "kocao-push: pushed\n"); res.RequeueAfter != pullRequestPollInterval {
+		t.Fatalf("requeue = %v, want %v while the pull request is open", res.RequeueAfter, pullRequestPollInterval)
+	}
+	if stub.token != "github-token" || len(stub.opened) != 1 || stub.opened[0].Repository != "withakay/kocao" || stub.opened[0].Head != "symphony/kocao-42" {
+		t.Fatalf("token = %q, opened = %+v", stub.token, stub.opened)
+	}
+	updated := get(merged)
+	if updated.Annotations[AnnotationPullRequestURL] != "https://github.com/withakay/kocao/pull/7" || updated.Annotations[AnnotationPullRequestStatus] != githubsource.PullRequestOpen || updated.Annotations[AnnotationGitHubBranch] != "symphony/kocao-42" {
+		t.Fatalf("annotations = %#v", updated.Annotations)
+	}
+	if got := conditionReason(updated.Status.Conditions, ConditionPullRequest); got != "PullRequestOpen" {
+		t.Fatalf("pull request reason = %q, want PullRequestOpen", got)
+	}
+
+	stub.state = githubsource.PullRequestMerged
+	res, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(merged)})
+	if err != nil {
+		t.Fatalf("reconcile merged: %v", err)
+	}
+	if res.RequeueAfter != 0 {
+		t.Fatalf("requeue = %v after merge, want none", res.RequeueAfter)
+	}
+	updated = get(merged)
+	if updated.Annotations[AnnotationPullRequestStatus] != githubsource.PullRequestMerged || conditionReason(updated.Status.Conditions, ConditionPullRequest) != "PullRequestMerged" {
+		t.Fatalf("merged run = %#v, %+v", updated.Annotations, updated.Status.Conditions)
+	}
+	if len(stub.opened) != 1 {
+		t.Fatalf("opened %d pull requests, want 1", len(stub.opened))
+	}
+
+	stub.err = githubsource.ErrBranchNotFound
+	succeed(unchanged, "")
+	updated = get(unchanged)
+	if updated.Annotations[AnnotationPullRequestStatus] != PullRequestStatusNoChanges || conditionReason(updated.Status.Conditions, ConditionPullRequest) != "NoChanges" {
+		t.Fatalf("no-changes run = %#v, %+v", updated.Annotations, updated.Status.Conditions)
+	}
+
+	// A command run whose entrypoint never reported a push lost its work.
+	succeed(lost, "")
+	updated = get(lost)
+	if updated.Annotations[AnnotationPullRequestStatus] == PullRequestStatusNoChanges || conditionReason(updated.Status.Conditions, ConditionPullRequest) != "PullRequestFailed" {
+		t.Fatalf("unpushed command run = %#v, %+v", updated.Annotations, updated.Status.Conditions)
+	}
+	succeed(empty, "kocao-push: no-changes\n")
+	updated = get(empty)
+	if updated.Annotations[AnnotationPullRequestStatus] != PullRequestStatusNoChanges || conditionReason(updated.Status.Conditions, ConditionBranchPush) != "NoChanges" {
+		t.Fatalf("command run without changes = %#v, %+v", updated.Annotations, updated.Status.Conditions)
+	}
+}
+
+func TestBuildHarnessPod_PullRequestRunsCommandThroughEntrypoint(t *testing.T) {
+	run := &operatorv1alpha1.HarnessRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-command", Namespace: "default"},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			RepoURL:     "https://github.com/withakay/kocao.git",
+			Image:       "busybox",
+			Command:     []string{"make"},
+			Args:        []string{"fix"},
+			GitAuth:     &operatorv1alpha1.GitAuthSpec{SecretName: "gh"},
+			PullRequest: &operatorv1alpha1.HarnessRunPullRequestSpec{Branch: "kocao/fix"},
+		},
+	}
+	harness := buildHarnessPod(run, "", "").Spec.Containers[0]
+	if len(harness.Command) != 1 || harness.Command[0] != harnessEntrypointCommand {
+		t.Fatalf("command = %v, want the harness entrypoint", harness.Command)
+	}
+	if strings.Join(harness.Args, " ") != "make fix" {
+		t.Fatalf("args = %v, want the run command and args", harness.Args)
+	}
+
+	run.Spec.PullRequest = nil
+	harness = buildHarnessPod(run, "", "").Spec.Containers[0]
+	if strings.Join(harness.Command, " ") != "make" || strings.Join(harness.Args, " ") != "fix" {
+		t.Fatalf("command = %v args = %v, want the run's own", harness.Command, harness.Args)
+	}
+}
+
+func TestBuildHarnessPod_ResourcesFromProfileAndSpec(t *testing.T) {
+	run := &operatorv1alpha1.HarnessRun{
+		ObjectMeta: metav1.ObjectMeta{Name: "run-resources", Namespace: "default"},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			RepoURL:      "https://example.com/repo",
+			Image:        "busybox",
+			ImageProfile: &operatorv1alpha1.HarnessImageProfileSpec{Profile: operatorv1alpha1.HarnessImageProfileBase},
+			Resources: &corev1.ResourceRequirements{Requests: corev1.ResourceList{
+				corev1.ResourceMemory: resource.MustParse("3Gi"),
+			}},
+		},
+	}
+	pod := buildHarnessPod(run, "ws-pvc", "")
+
+	harness := pod.Spec.Containers[0].Resources
+	if got := harness.Requests[corev1.ResourceMemory]; got.String() != "3Gi" {
+		t.Fatalf("memory request = %s, want 3Gi", got.String())
+	}
+	// The request above the base profile's 2Gi limit raises the limit.
+	if got := harness.Limits[corev1.ResourceMemory]; got.String() != "3Gi" {
+		t.Fatalf("memory limit = %s, want 3Gi", got.String())
+	}
+	if got := harness.Requests[corev1.ResourceCPU]; got.String() != "250m" {
+		t.Fatalf("cpu request = %s, want base profile 250m", got.String())
+	}
+	if len(pod.Spec.InitContainers) == 0 {
+		t.Fatal("expected workspace-perms init container")
+	}
+	for _, c := range pod.Spec.InitContainers {
+		if _, ok := c.Resources.Limits[corev1.ResourceMemory]; !ok {
+			t.Fatalf("init container %q has no memory limit", c.Name)
+		}
+	}
+
+	run.Spec.ImageProfile = nil
+	run.Spec.Resources = nil
+	pod = buildHarnessPod(run, "", "")
+	if got := pod.Spec.Containers[0].Resources.Limits[corev1.ResourceEphemeralStorage]; got.String() != "20Gi" {
+		t.Fatalf("ephemeral-storage limit = %s, want full profile 20Gi", got.String())
+	}
+}
+
+func TestUpdateStatusFromPod_NamesOOMKillAndEviction(t *testing.T) {
+	for want, status := range map[string]corev1.PodStatus{
+		"OOMKilled": {Phase: corev1.PodFailed, ContainerStatuses: []corev1.ContainerStatus{{
+			Name:  "harness",
+			State: corev1.ContainerState{Terminated: &corev1.ContainerStateTerminated{ExitCode: 137, Reason: "OOMKilled"}},
+		}}},
+		"Evicted":   {Phase: corev1.PodFailed, Reason: "Evicted", Message: "The node was low on resource: memory."},
+		"PodFailed": {Phase: corev1.PodFailed},
+	} {
+		run := &operatorv1alpha1.HarnessRun{}
+		updateStatusFromPod(run, &corev1.Pod{Status: status}, time.Unix(10, 0))
+		if got := conditionReason(run.Status.Conditions, ConditionFailed); got != want {
+			t.Fatalf("failed reason = %q, want %q", got, want)
+		}
+	}
+}
+
+func TestHarnessRunReconcile_RunClassSchedulesPodAndRejectsDeniedClass(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+
+	classes := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: RunClassesConfigMapName, Namespace: "default"},
+		Data: map[string]string{
+			"large-build": "nodeSelector:\n  pool: build\ntolerations:\n- key: dedicated\n  value: build\n  effect: NoSchedule\npriorityClassName: kocao-batch\nallowedTeams: [platform]\n",
+		},
+	}
+	newRun := func(name, class, team string) *operatorv1alpha1.HarnessRun {
+		return &operatorv1alpha1.HarnessRun{
+			TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", Labels: map[string]string{LabelTeam: team}},
+			Spec: operatorv1alpha1.HarnessRunSpec{
+				RepoURL: "https://github.com/withakay/kocao",
+				Image:   "busybox",
+				Scheduling: &operatorv1alpha1.HarnessRunSchedulingSpec{
+					RunClass:     class,
+					NodeSelector: map[string]string{"zone": "a"},
+				},
+			},
+		}
+	}
+	allowed := newRun("run-allowed", "large-build", "platform")
+	denied := newRun("run-denied", "large-build", "web")
+	missing := newRun("run-missing", "gpu", "platform")
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.HarnessRun{}).WithObjects(classes, allowed, denied, missing).Build()
+	r := &HarnessRunReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(10, 0))}
+
+	reconcile := func(run *operatorv1alpha1.HarnessRun) operatorv1alpha1.HarnessRun {
+		t.Helper()
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(run)}); err != nil {
+			t.Fatalf("reconcile %s: %v", run.Name, err)
+		}
+		var updated operatorv1alpha1.HarnessRun
+		if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+			t.Fatalf("get run: %v", err)
+		}
+		return updated
+	}
+
+	got := reconcile(allowed)
+	var pod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: got.Status.PodName}, &pod); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	if pod.Spec.NodeSelector["pool"] != "build" || pod.Spec.NodeSelector["zone"] != "a" {
+		t.Fatalf("nodeSelector = %v", pod.Spec.NodeSelector)
+	}
+	if len(pod.Spec.Tolerations) != 1 || pod.Spec.Tolerations[0].Key != "dedicated" || pod.Spec.Tolerations[0].Effect != corev1.TaintEffectNoSchedule {
+		t.Fatalf("tolerations = %+v", pod.Spec.Tolerations)
+	}
+	if pod.Spec.PriorityClassName != "kocao-batch" {
+		t.Fatalf("priorityClassName = %q", pod.Spec.PriorityClassName)
+	}
+
+	for run, reason := range map[*operatorv1alpha1.HarnessRun]string{denied: ReasonRunClassDenied, missing: ReasonRunClassNotFound} {
+		got := reconcile(run)
+		if got.Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed || got.Status.PodName != "" {
+			t.Fatalf("%s: phase = %q pod = %q", run.Name, got.Status.Phase, got.Status.PodName)
+		}
+		if conditionReason(got.Status.Conditions, ConditionFailed) != reason {
+			t.Fatalf("%s: conditions = %+v, want reason %s", run.Name, got.Status.Conditions, reason)
+		}
+	}
+}
+
+func TestHarnessRunReconcile_ProxyEgressModeRoutesThroughEgressProxy(t *testing.T) {
+	t.Setenv(envEgressProxyURL, "http://kocao-egress-proxy.kocao-system.svc:8888")
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+
+	sess := &operatorv1alpha1.Session{
+		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "Session"},
+		ObjectMeta: metav1.ObjectMeta{Name: "s1", Namespace: "default", Annotations: map[string]string{AnnotationEgressHosts: "api.openai.com, registry.npmjs.org"}},
+		Spec:       operatorv1alpha1.SessionSpec{RepoURL: "https://example.com/repo"},
+	}
+	run := &operatorv1alpha1.HarnessRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "run-proxy", Namespace: "default"},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			WorkspaceSessionName: "s1",
+			RepoURL:              "https://example.com/repo",
+			Image:                "busybox:latest",
+			EgressMode:           "proxy",
+			EgressAllowedHosts:   []string{"Registry.npmjs.org", "*.pypi.org"},
+		},
+	}
+	invalid := &operatorv1alpha1.HarnessRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "run-invalid", Namespace: "default"},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			RepoURL:            "https://example.com/repo",
+			Image:              "busybox:latest",
+			EgressMode:         "proxy",
+			EgressAllowedHosts: []string{"https://registry.npmjs.org"},
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.HarnessRun{}, &corev1.Pod{}).WithObjects(sess, run, invalid).Build()
+	r := &HarnessRunReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(1, 0))}
+	for _, obj := range []*operatorv1alpha1.HarnessRun{run, invalid} {
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(obj)}); err != nil {
+			t.Fatalf("reconcile %s: %v", obj.Name, err)
+		}
+	}
+
+	var np networkingv1.NetworkPolicy
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: runEgressNetworkPolicyName("run-proxy")}, &np); err != nil {
+		t.Fatalf("get networkpolicy: %v", err)
+	}
+	proxyRule := np.Spec.Egress[len(np.Spec.Egress)-1]
+	if len(proxyRule.To) != 1 || proxyRule.To[0].PodSelector == nil || proxyRule.To[0].PodSelector.MatchLabels["app.kubernetes.io/name"] != "kocao-egress-proxy" || proxyRule.Ports[0].Port.IntValue() != 8888 {
+		t.Fatalf("proxy egress rule = %#v", proxyRule)
+	}
+
+	var updated operatorv1alpha1.HarnessRun
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(run), &updated); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	var pod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: updated.Status.PodName}, &pod); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	if got := pod.Annotations[AnnotationEgressHosts]; got != "registry.npmjs.org,*.pypi.org,api.openai.com" {
+		t.Fatalf("allowed hosts annotation = %q", got)
+	}
+	env := map[string]string{}
+	for _, ev := range pod.Spec.Containers[0].Env {
+		env[ev.Name] = ev.Value
+	}
+	if env["HTTPS_PROXY"] != "http://kocao-egress-proxy.kocao-system.svc:8888" || env["http_proxy"] != env["HTTPS_PROXY"] || !strings.Contains(env["NO_PROXY"], ".svc") {
+		t.Fatalf("proxy env = %v", env)
+	}
+
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(invalid), &updated); err != nil {
+		t.Fatalf("get run: %v", err)
+	}
+	if updated.Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed || conditionReason(updated.Status.Conditions, ConditionFailed) != "SpecInvalid" {
+		t.Fatalf("invalid hosts run phase = %q, conditions = %+v", updated.Status.Phase, updated.Status.Conditions)
+	}
+}
+
+func TestHarnessRunReconcile_IdleTimeoutWarnsThenStopsRun(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = corev1.AddToScheme(scheme)
+	_ = networkingv1.AddToScheme(scheme)
+	_ = operatorv1alpha1.AddToScheme(scheme)
+
+	idle, deadline := int64(600), int64(3600)
+	ttl := int32(60)
+	run := &operatorv1alpha1.HarnessRun{
+		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "run-idle", Namespace: "default"},
+		Spec: operatorv1alpha1.HarnessRunSpec{
+			RepoURL:                 "https://example.com/repo",
+			Image:                   "busybox",
+			IdleTimeoutSeconds:      &idle,
+			ActiveDeadlineSeconds:   &deadline,
+			TTLSecondsAfterFinished: &ttl,
+		},
+	}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.HarnessRun{}, &corev1.Pod{}).WithObjects(run).Build()
+	clk := clocktesting.NewFakeClock(time.Unix(100, 0))
+	recorder := record.NewFakeRecorder(10)
+	r := &HarnessRunReconciler{Client: cl, Scheme: scheme, Clock: clk, Recorder: recorder}
+	key := client.ObjectKeyFromObject(run)
+	reconcile := func(at int64) operatorv1alpha1.HarnessRun {
+		t.Helper()
+		clk.SetTime(time.Unix(at, 0))
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+			t.Fatalf("reconcile at %d: %v", at, err)
+		}
+		var got operatorv1alpha1.HarnessRun
+		if err := cl.Get(context.Background(), key, &got); err != nil {
+			t.Fatalf("get run: %v", err)
+		}
+		return got
+	}
+
+	got := reconcile(100)
+	var pod corev1.Pod
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: got.Status.PodName}, &pod); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	pod.Status.Phase = corev1.PodRunning
+	pod.Status.StartTime = &metav1.Time{Time: time.Unix(100, 0)}
+	if err := cl.Status().Update(context.Background(), &pod); err != nil {
+		t.Fatalf("update pod status: %v", err)
+	}
+
+	got = reconcile(590)
+	if !meta.IsStatusConditionTrue(got.Status.Conditions, ConditionTimeoutImminent) {
+		t.Fatalf("conditions = %+v, want %s", got.Status.Conditions, ConditionTimeoutImminent)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning TimeoutImminent") {
+		t.Fatalf("event = %q", event)
+	}
+
+	// Activity moves the idle deadline out of the warning window.
+	got.Status.LastActivityTime = &metav1.Time{Time: time.Unix(600, 0)}
+	if err := cl.Status().Update(context.Background(), &got); err != nil {
+		t.Fatalf("record activity: %v", err)
+	}
+	got = reconcile(610)
+	if meta.FindStatusCondition(got.Status.Conditions, ConditionTimeoutImminent) != nil || got.Status.Phase != operatorv1alpha1.HarnessRunPhaseRunning {
+		t.Fatalf("phase = %q, conditions = %+v", got.Status.Phase, got.Status.Conditions)
+	}
+
+	got = reconcile(1200)
+	if got.Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed || got.Status.Reason != ReasonTimedOut || conditionReason(got.Status.Conditions, ConditionFailed) != ReasonTimedOut {
+		t.Fatalf("phase = %q, reason = %q, conditions = %+v", got.Status.Phase, got.Status.Reason, got.Status.Conditions)
+	}
+	if got.Status.Message != "run was idle for more than 600s" {
+		t.Fatalf("message = %q", got.Status.Message)
+	}
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(&pod), &pod); !apierrors.IsNotFound(err) {
+		t.Fatalf("get pod after timeout: %v, want not found", err)
+	}
+	if event := <-recorder.Events; !strings.HasPrefix(event, "Warning TimedOut") {
+		t.Fatalf("event = %q", event)
+	}
+
+	clk.SetTime(time.Unix(1300, 0))
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: key}); err != nil {
+		t.Fatalf("reconcile after ttl: %v", err)
+	}
+	if err := cl.Get(context.Background(), key, &got); err == nil && got.DeletionTimestamp == nil {
+		t.Fatalf("expected timed-out run to be deleted after its TTL")
+	}
+}
+
+func TestNextRunTimeoutPicksEarlierDeadline(t *testing.T) {
+	idle, deadline := int64(600), int64(900)
+	run := &operatorv1alpha1.HarnessRun{
+		Spec:   operatorv1alpha1.HarnessRunSpec{IdleTimeoutSeconds: &idle, ActiveDeadlineSeconds: &deadline},
+		Status: operatorv1alpha1.HarnessRunStatus{StartTime: &metav1.Time{Time: time.Unix(0, 0)}, LastActivityTime: &metav1.Time{Time: time.Unix(500, 0)}},
+	}
+	timeout, ok := nextRunTimeout(run)
+	if !ok || !timeout.at.Equal(time.Unix(900, 0)) || timeout.reason != "ActiveDeadline" {
+		t.Fatalf("timeout = %+v, %v", timeout, ok)
+	}
+	run.Spec.ActiveDeadlineSeconds = nil
+	if timeout, _ = nextRunTimeout(run); !timeout.at.Equal(time.Unix(1100, 0)) || timeout.reason != "IdleTimeout" {
+		t.Fatalf("timeout = %+v", timeout)
+	}
+	run.Spec.IdleTimeoutSeconds = nil
+	if _, ok := nextRunTimeout(run); ok {
+		t.Fatal("expected no timeout without limits")
+	}
+}
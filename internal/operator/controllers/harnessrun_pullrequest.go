@@ -0,0 +1,225 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// PullRequestStatusNoChanges is recorded when a run with spec.pullRequest
+// succeeded without pushing its branch, because it left nothing to commit.
+const PullRequestStatusNoChanges = "no-changes"
+
+// The harness entrypoint ends its termination message with one of these
+// lines after pushing, or deciding not to push, a run's branch.
+const (
+	branchPushTerminationPrefix    = "kocao-push: "
+	branchPushTerminationPushed    = branchPushTerminationPrefix + "pushed"
+	branchPushTerminationNoChanges = branchPushTerminationPrefix + "no-changes"
+)
+
+// splitBranchPushLine separates the push line the harness entrypoint appends
+// to a termination message from whatever the command wrote before it, such as
+// a Symphony worker report.
+func splitBranchPushLine(message string) (body, push string) {
+	trimmed := strings.TrimRight(message, "\r\n\t ")
+	i := strings.LastIndexByte(trimmed, '\n')
+	last := strings.TrimSpace(trimmed[i+1:])
+	if !strings.HasPrefix(last, branchPushTerminationPrefix) {
+		return message, ""
+	}
+	if i < 0 {
+		return "", last
+	}
+	return trimmed[:i], last
+}
+
+// Reasons of the BranchPushed condition.
+const (
+	branchPushPushed    = "Pushed"
+	branchPushNoChanges = "NoChanges"
+)
+
+// observeBranchPush copies the harness container's push report into the
+// BranchPushed condition of a run with spec.pullRequest.
+func observeBranchPush(run *operatorv1alpha1.HarnessRun, pod *corev1.Pod, now metav1.Time) {
+	if run.Spec.PullRequest == nil {
+		return
+	}
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if status.Name != "harness" || terminated == nil {
+			continue
+		}
+		_, push := splitBranchPushLine(terminated.Message)
+		switch push {
+		case branchPushTerminationPushed:
+			setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionBranchPush, Status: metav1.ConditionTrue, Reason: branchPushPushed, Message: "harness pushed " + run.Spec.PullRequest.Branch, LastTransitionTime: now})
+		case branchPushTerminationNoChanges:
+			setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionBranchPush, Status: metav1.ConditionFalse, Reason: branchPushNoChanges, Message: "run left no changes to push", LastTransitionTime: now})
+		}
+	}
+}
+
+// pullRequestPollInterval is how often an open pull request is checked for
+// a merge or close.
+const pullRequestPollInterval = 2 * time.Minute
+
+type pullRequestClient interface {
+	OpenPullRequest(context.Context, githubsource.PullRequestRequest) (githubsource.PullRequest, error)
+	GetPullRequest(ctx context.Context, url string) (githubsource.PullRequest, error)
+}
+
+// pullRequestClientFactory builds a GitHub client from the run's git token.
+type pullRequestClientFactory interface {
+	New(token string) (pullRequestClient, error)
+}
+
+type defaultPullRequestClientFactory struct{}
+
+func (defaultPullRequestClientFactory) New(token string) (pullRequestClient, error) {
+	return githubsource.NewClient(token, githubsource.Options{})
+}
+
+// reconcilePullRequest opens the pull request of a succeeded run with
+// spec.pullRequest, then keeps the pull-request-status annotation current
+// until the pull request is merged or closed. It reports whether the run's
+// metadata and status changed, and when to check again.
+func (r *HarnessRunReconciler) reconcilePullRequest(ctx context.Context, run *operatorv1alpha1.HarnessRun, now time.Time) (bool, bool, time.Duration) {
+	spec := run.Spec.PullRequest
+	if spec == nil || run.Spec.GitAuth == nil || run.Status.Phase != operatorv1alpha1.HarnessRunPhaseSucceeded {
+		return false, false, 0
+	}
+	if run.Annotations == nil {
+		run.Annotations = map[string]string{}
+	}
+	url := strings.TrimSpace(run.Annotations[AnnotationPullRequestURL])
+	switch strings.TrimSpace(run.Annotations[AnnotationPullRequestStatus]) {
+	case githubsource.PullRequestMerged, githubsource.PullRequestClosed, PullRequestStatusNoChanges:
+		return false, false, 0
+	}
+	nowMeta := metav1.NewTime(now)
+	fail := func(err error) (bool, bool, time.Duration) {
+		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionPullRequest, Status: metav1.ConditionFalse, Reason: "PullRequestFailed", Message: sanitizeTelemetryMessage(err.Error()), LastTransitionTime: nowMeta})
+		return false, true, pullRequestPollInterval
+	}
+	repository, ok := githubRepositoryFromURL(run.Spec.RepoURL)
+	if !ok {
+		return fail(fmt.Errorf("repoURL %q is not a GitHub repository", run.Spec.RepoURL))
+	}
+	token, err := r.loadGitToken(ctx, run)
+	if err != nil {
+		return fail(err)
+	}
+	factory := r.PullRequests
+	if factory == nil {
+		factory = defaultPullRequestClientFactory{}
+	}
+	gh, err := factory.New(token)
+	if err != nil {
+		return fail(err)
+	}
+
+	var pr githubsource.PullRequest
+	if url == "" {
+		pr, err = gh.OpenPullRequest(ctx, githubsource.PullRequestRequest{
+			Repository: repository,
+			Head:       spec.Branch,
+			Base:       spec.Base,
+			Title:      spec.Title,
+			Body:       spec.Body,
+			Draft:      spec.Draft,
+		})
+		if errors.Is(err, githubsource.ErrBranchNotFound) && len(run.Spec.Command) != 0 && conditionReason(run.Status.Conditions, ConditionBranchPush) != branchPushNoChanges {
+			// The command succeeded but the entrypoint never said it had
+			// nothing to push, so the work was lost rather than absent.
+			return fail(fmt.Errorf("run succeeded but branch %q was not pushed", spec.Branch))
+		}
+		if errors.Is(err, githubsource.ErrBranchNotFound) {
+			run.Annotations[AnnotationGitHubBranch] = spec.Branch
+			run.Annotations[AnnotationPullRequestStatus] = PullRequestStatusNoChanges
+			setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionPullRequest, Status: metav1.ConditionFalse, Reason: "NoChanges", Message: "run left no changes to publish", LastTransitionTime: nowMeta})
+			return true, true, 0
+		}
+	} else {
+		pr, err = gh.GetPullRequest(ctx, url)
+	}
+	if err != nil {
+		return fail(err)
+	}
+
+	changedMeta := false
+	for key, value := range map[string]string{
+		AnnotationGitHubBranch:      spec.Branch,
+		AnnotationPullRequestURL:    pr.URL,
+		AnnotationPullRequestStatus: pr.State,
+	} {
+		if run.Annotations[key] != value {
+			run.Annotations[key] = value
+			changedMeta = true
+		}
+	}
+	changedStatus := false
+	if conditionReason(run.Status.Conditions, ConditionPullRequest) != pullRequestConditionReason(pr.State) {
+		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionPullRequest, Status: metav1.ConditionTrue, Reason: pullRequestConditionReason(pr.State), Message: pr.URL, LastTransitionTime: nowMeta})
+		changedStatus = true
+	}
+	if pr.State == githubsource.PullRequestOpen {
+		return changedMeta, changedStatus, pullRequestPollInterval
+	}
+	return changedMeta, changedStatus, 0
+}
+
+func (r *HarnessRunReconciler) loadGitToken(ctx context.Context, run *operatorv1alpha1.HarnessRun) (string, error) {
+	key := strings.TrimSpace(run.Spec.GitAuth.TokenKey)
+	if key == "" {
+		key = "token"
+	}
+	var secret corev1.Secret
+	if err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: run.Spec.GitAuth.SecretName}, &secret); err != nil {
+		return "", fmt.Errorf("load git auth secret %s: %w", run.Spec.GitAuth.SecretName, err)
+	}
+	token := strings.TrimSpace(string(secret.Data[key]))
+	if token == "" {
+		return "", fmt.Errorf("git auth secret %s has no %q token", run.Spec.GitAuth.SecretName, key)
+	}
+	return token, nil
+}
+
+func pullRequestConditionReason(state string) string {
+	switch state {
+	case githubsource.PullRequestMerged:
+		return "PullRequestMerged"
+	case githubsource.PullRequestClosed:
+		return "PullRequestClosed"
+	default:
+		return "PullRequestOpen"
+	}
+}
+
+// githubRepositoryFromURL returns owner/name for an https or ssh GitHub URL.
+func githubRepositoryFromURL(repoURL string) (string, bool) {
+	path := strings.TrimSpace(repoURL)
+	switch {
+	case strings.HasPrefix(path, "https://github.com/"):
+		path = strings.TrimPrefix(path, "https://github.com/")
+	case strings.HasPrefix(path, "git@github.com:"):
+		path = strings.TrimPrefix(path, "git@github.com:")
+	default:
+		return "", false
+	}
+	path = strings.TrimSuffix(strings.TrimSuffix(path, "/"), ".git")
+	owner, name, ok := strings.Cut(path, "/")
+	if !ok || owner == "" || name == "" || strings.Contains(name, "/") {
+		return "", false
+	}
+	return owner + "/" + name, true
+}
@@ -6,13 +6,17 @@ import (
 	"strings"
 
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/worker"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 const (
 	DefaultSidecarImage       = "kocao/kocao-sidecar:dev"
 	DefaultInitContainerImage = "busybox:1.37"
+
+	symphonyTaskMountPath = "/var/run/kocao/symphony"
 )
 
 // PodImages holds configurable container images used by the pod builder.
@@ -49,6 +53,8 @@ func buildHarnessPod(run *operatorv1alpha1.HarnessRun, workspacePVCName string,
 
 		agentOauthVolumeName    = "agent-oauth"
 		agentAuthLiveVolumeName = "agent-auth-live"
+
+		symphonyTaskVolumeName = "symphony-task"
 	)
 
 	var imgs PodImages
@@ -138,6 +144,28 @@ func buildHarnessPod(run *operatorv1alpha1.HarnessRun, workspacePVCName string,
 		}
 	}
 
+	// The entrypoint commits and pushes the repository once the command
+	// succeeds; the operator opens the pull request afterwards.
+	if pr := run.Spec.PullRequest; pr != nil && run.Spec.GitAuth != nil {
+		env = append(env,
+			corev1.EnvVar{Name: "KOCAO_PUSH_BRANCH", Value: pr.Branch},
+			corev1.EnvVar{Name: "KOCAO_PUSH_COMMIT_MESSAGE", Value: firstNonEmpty(pr.CommitMessage, pr.Title)},
+		)
+	}
+
+	// Symphony pod-mode workers read the task the operator rendered for this
+	// run from a ConfigMap.
+	if taskConfigMap := strings.TrimSpace(run.Annotations[AnnotationSymphonyTaskConfigMap]); taskConfigMap != "" {
+		volumes = append(volumes, corev1.Volume{
+			Name: symphonyTaskVolumeName,
+			VolumeSource: corev1.VolumeSource{ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{Name: taskConfigMap},
+			}},
+		})
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{Name: symphonyTaskVolumeName, MountPath: symphonyTaskMountPath, ReadOnly: true})
+		env = append(env, corev1.EnvVar{Name: "KOCAO_SYMPHONY_TASK", Value: symphonyTaskMountPath + "/" + worker.TaskFileName})
+	}
+
 	// Agent credential injection (tier-1: API key env vars, tier-2: OAuth file mounts).
 	agentSessionEnabled := run.Spec.AgentSession != nil && run.Spec.AgentSession.Enabled()
 	var envFrom []corev1.EnvFromSource
@@ -238,11 +266,18 @@ func buildHarnessPod(run *operatorv1alpha1.HarnessRun, workspacePVCName string,
 		}
 	}
 
+	command, args := run.Spec.Command, run.Spec.Args
+	if run.Spec.PullRequest != nil && run.Spec.GitAuth != nil && len(command) != 0 && command[0] != harnessEntrypointCommand {
+		// A command replaces the image entrypoint, which is what pushes the
+		// branch; run the command through it instead.
+		args = append(append([]string{}, command...), args...)
+		command = []string{harnessEntrypointCommand}
+	}
 	container := corev1.Container{
 		Name:         "harness",
 		Image:        run.Spec.Image,
-		Command:      run.Spec.Command,
-		Args:         run.Spec.Args,
+		Command:      command,
+		Args:         args,
 		WorkingDir:   run.Spec.WorkingDir,
 		Env:          env,
 		EnvFrom:      envFrom,
@@ -285,6 +320,16 @@ func buildHarnessPod(run *operatorv1alpha1.HarnessRun, workspacePVCName string,
 		}}, initContainers...)
 	}
 
+	// Every container carries requests and limits so one run cannot starve
+	// its node and the scheduler can bin-pack runs.
+	container.Resources = harnessContainerResources(run)
+	for i := range initContainers {
+		initContainers[i].Resources = auxiliaryContainerResources()
+	}
+	for i := range sidecarContainers {
+		sidecarContainers[i].Resources = auxiliaryContainerResources()
+	}
+
 	containers := []corev1.Container{container}
 	containers = append(containers, sidecarContainers...)
 
@@ -315,6 +360,33 @@ func buildHarnessPod(run *operatorv1alpha1.HarnessRun, workspacePVCName string,
 	return pod
 }
 
+// harnessContainerResources resolves spec.resources over the defaults of the
+// run's image profile. Runs without an explicit profile are sized for the
+// full profile, the compatibility fallback.
+func harnessContainerResources(run *operatorv1alpha1.HarnessRun) corev1.ResourceRequirements {
+	profile := operatorv1alpha1.HarnessImageProfileFull
+	if run.Spec.ImageProfile != nil && run.Spec.ImageProfile.Profile != "" {
+		profile = run.Spec.ImageProfile.Profile
+	}
+	return operatorv1alpha1.ResolveHarnessResources(profile, run.Spec.Resources)
+}
+
+// auxiliaryContainerResources sizes the init containers and kocao-sidecar,
+// which only copy files and watch credentials.
+func auxiliaryContainerResources() corev1.ResourceRequirements {
+	return corev1.ResourceRequirements{
+		Requests: corev1.ResourceList{
+			corev1.ResourceCPU:    resource.MustParse("10m"),
+			corev1.ResourceMemory: resource.MustParse("32Mi"),
+		},
+		Limits: corev1.ResourceList{
+			corev1.ResourceCPU:              resource.MustParse("100m"),
+			corev1.ResourceMemory:           resource.MustParse("128Mi"),
+			corev1.ResourceEphemeralStorage: resource.MustParse("64Mi"),
+		},
+	}
+}
+
 func sanitizeDNSLabel(s string) string {
 	// Kubernetes object names must be valid DNS labels. Keep this lightweight
 	// since it is only used as a GenerateName prefix.
@@ -0,0 +1,179 @@
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RunClassesConfigMapName is the ConfigMap, in the run's namespace, that
+	// defines run classes. Each key is a class name and each value a YAML
+	// document with nodeSelector, tolerations, affinity, priorityClassName,
+	// allowedOwners and allowedTeams.
+	RunClassesConfigMapName = "kocao-run-classes"
+	// DefaultRunClass applies to runs that name no class, when it is defined.
+	DefaultRunClass = "default"
+)
+
+// Failure reasons for runs whose class cannot be used.
+const (
+	ReasonRunClassNotFound = "RunClassNotFound"
+	ReasonRunClassInvalid  = "RunClassInvalid"
+	ReasonRunClassDenied   = "RunClassDenied"
+)
+
+// runClass is an admin-defined scheduling profile. A class with no allowed
+// owners or teams may be used by anyone; otherwise the run's owner or team
+// must be listed.
+type runClass struct {
+	NodeSelector      map[string]string   `json:"nodeSelector,omitempty"`
+	Tolerations       []corev1.Toleration `json:"tolerations,omitempty"`
+	Affinity          *corev1.Affinity    `json:"affinity,omitempty"`
+	PriorityClassName string              `json:"priorityClassName,omitempty"`
+	AllowedOwners     []string            `json:"allowedOwners,omitempty"`
+	AllowedTeams      []string            `json:"allowedTeams,omitempty"`
+}
+
+func (c runClass) allows(owner, team string) bool {
+	if len(c.AllowedOwners) == 0 && len(c.AllowedTeams) == 0 {
+		return true
+	}
+	for _, allowed := range c.AllowedOwners {
+		if owner != "" && strings.TrimSpace(allowed) == owner {
+			return true
+		}
+	}
+	for _, allowed := range c.AllowedTeams {
+		if team != "" && strings.TrimSpace(allowed) == team {
+			return true
+		}
+	}
+	return false
+}
+
+// runClassError fails a run whose class is missing, malformed or not allowed
+// to the run's owner.
+type runClassError struct {
+	reason  string
+	message string
+}
+
+func (e *runClassError) Error() string { return e.message }
+
+// resolveRunScheduling returns the scheduling of run's pod: its run class
+// with the spec's raw fields layered on top. Without a ConfigMap or a default
+// class, a run that names no class keeps only its raw fields.
+func (r *HarnessRunReconciler) resolveRunScheduling(ctx context.Context, run *operatorv1alpha1.HarnessRun) (operatorv1alpha1.HarnessRunSchedulingSpec, error) {
+	var spec operatorv1alpha1.HarnessRunSchedulingSpec
+	if run.Spec.Scheduling != nil {
+		spec = *run.Spec.Scheduling.DeepCopy()
+	}
+	requested := strings.TrimSpace(spec.RunClass)
+	name := firstNonEmpty(requested, DefaultRunClass)
+
+	class, found, err := loadRunClass(ctx, r.Client, run.Namespace, name)
+	if err != nil {
+		return spec, err
+	}
+	if !found {
+		if requested != "" {
+			return spec, &runClassError{reason: ReasonRunClassNotFound, message: fmt.Sprintf("run class %q is not defined in ConfigMap %s", requested, RunClassesConfigMapName)}
+		}
+		return spec, nil
+	}
+	owner := strings.TrimSpace(run.Annotations[AnnotationOwner])
+	team := strings.TrimSpace(run.Labels[LabelTeam])
+	if !class.allows(owner, team) {
+		return spec, &runClassError{reason: ReasonRunClassDenied, message: fmt.Sprintf("run class %q is not allowed for owner %q in team %q", name, owner, team)}
+	}
+	return mergeRunClass(class, spec), nil
+}
+
+// loadRunClass reads the named class from the run classes ConfigMap.
+func loadRunClass(ctx context.Context, c client.Reader, namespace, name string) (runClass, bool, error) {
+	var cm corev1.ConfigMap
+	err := c.Get(ctx, client.ObjectKey{Namespace: namespace, Name: RunClassesConfigMapName}, &cm)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return runClass{}, false, err
+	}
+	raw, ok := cm.Data[name]
+	if !ok {
+		return runClass{}, false, nil
+	}
+	var class runClass
+	if err := yaml.UnmarshalStrict([]byte(raw), &class); err != nil {
+		return runClass{}, true, &runClassError{reason: ReasonRunClassInvalid, message: fmt.Sprintf("run class %q is invalid: %v", name, err)}
+	}
+	return class, true, nil
+}
+
+// CheckRunClassAccess returns an error unless owner, or one of teams, may use
+// the named run class. The control-plane API calls it when a caller points
+// an existing resource, whose runs carry someone else's owner stamp, at a
+// class.
+func CheckRunClassAccess(ctx context.Context, c client.Reader, namespace, name, owner string, teams []string) error {
+	class, found, err := loadRunClass(ctx, c, namespace, name)
+	if err != nil {
+		return err
+	}
+	if !found {
+		return &runClassError{reason: ReasonRunClassNotFound, message: fmt.Sprintf("run class %q is not defined in ConfigMap %s", name, RunClassesConfigMapName)}
+	}
+	if class.allows(owner, "") {
+		return nil
+	}
+	for _, team := range teams {
+		if class.allows("", strings.TrimSpace(team)) {
+			return nil
+		}
+	}
+	return &runClassError{reason: ReasonRunClassDenied, message: fmt.Sprintf("run class %q is not allowed for %q", name, owner)}
+}
+
+// IsRunClassDenied reports whether err is a run class access or lookup failure
+// rather than an API error.
+func IsRunClassDenied(err error) bool {
+	var classErr *runClassError
+	return errors.As(err, &classErr)
+}
+
+// mergeRunClass layers spec over class. Node selector entries, affinity and
+// the priority class in spec win; tolerations from both apply.
+func mergeRunClass(class runClass, spec operatorv1alpha1.HarnessRunSchedulingSpec) operatorv1alpha1.HarnessRunSchedulingSpec {
+	out := spec
+	if len(class.NodeSelector) != 0 {
+		out.NodeSelector = make(map[string]string, len(class.NodeSelector)+len(spec.NodeSelector))
+		for key, value := range class.NodeSelector {
+			out.NodeSelector[key] = value
+		}
+		for key, value := range spec.NodeSelector {
+			out.NodeSelector[key] = value
+		}
+	}
+	if len(class.Tolerations) != 0 {
+		out.Tolerations = append(append([]corev1.Toleration(nil), class.Tolerations...), spec.Tolerations...)
+	}
+	if out.Affinity == nil {
+		out.Affinity = class.Affinity
+	}
+	if strings.TrimSpace(out.PriorityClassName) == "" {
+		out.PriorityClassName = class.PriorityClassName
+	}
+	return out
+}
+
+// applyRunScheduling sets the resolved scheduling on a harness pod.
+func applyRunScheduling(pod *corev1.Pod, scheduling operatorv1alpha1.HarnessRunSchedulingSpec) {
+	pod.Spec.NodeSelector = scheduling.NodeSelector
+	pod.Spec.Tolerations = scheduling.Tolerations
+	pod.Spec.Affinity = scheduling.Affinity
+	pod.Spec.PriorityClassName = strings.TrimSpace(scheduling.PriorityClassName)
+}
@@ -0,0 +1,123 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ReasonTimedOut is the failure reason of runs stopped for exceeding
+	// activeDeadlineSeconds or idleTimeoutSeconds.
+	ReasonTimedOut = "TimedOut"
+	// ReasonTimeoutImminent is the warning event sent shortly before a run
+	// times out.
+	ReasonTimeoutImminent = "TimeoutImminent"
+
+	// ConditionTimeoutImminent is true while a run is within
+	// runTimeoutWarningLead of its deadline.
+	ConditionTimeoutImminent = "TimeoutImminent"
+
+	runTimeoutWarningLead = 2 * time.Minute
+)
+
+// runTimeout is the next deadline of a run: the earlier of its active
+// deadline and its idle deadline.
+type runTimeout struct {
+	at      time.Time
+	reason  string
+	message string
+}
+
+// nextRunTimeout returns the run's next deadline, if it has one. Both limits
+// count from the run's start; the idle limit restarts at each recorded
+// activity.
+func nextRunTimeout(run *operatorv1alpha1.HarnessRun) (runTimeout, bool) {
+	start := run.CreationTimestamp.Time
+	if run.Status.StartTime != nil {
+		start = run.Status.StartTime.Time
+	}
+	if start.IsZero() {
+		return runTimeout{}, false
+	}
+	var next runTimeout
+	if limit := run.Spec.ActiveDeadlineSeconds; limit != nil && *limit > 0 {
+		next = runTimeout{
+			at:      start.Add(time.Duration(*limit) * time.Second),
+			reason:  "ActiveDeadline",
+			message: fmt.Sprintf("run exceeded its active deadline of %ds", *limit),
+		}
+	}
+	if limit := run.Spec.IdleTimeoutSeconds; limit != nil && *limit > 0 {
+		last := start
+		if activity := run.Status.LastActivityTime; activity != nil && activity.After(last) {
+			last = activity.Time
+		}
+		idle := last.Add(time.Duration(*limit) * time.Second)
+		if next.at.IsZero() || idle.Before(next.at) {
+			next = runTimeout{
+				at:      idle,
+				reason:  "IdleTimeout",
+				message: fmt.Sprintf("run was idle for more than %ds", *limit),
+			}
+		}
+	}
+	return next, !next.at.IsZero()
+}
+
+// enforceRunTimeout stops a running run past its deadline and warns shortly
+// before it. It returns whether the status changed and when to check again.
+func (r *HarnessRunReconciler) enforceRunTimeout(ctx context.Context, run *operatorv1alpha1.HarnessRun, pod *corev1.Pod, now time.Time) (bool, time.Duration, error) {
+	if run.Status.Phase != operatorv1alpha1.HarnessRunPhaseStarting && run.Status.Phase != operatorv1alpha1.HarnessRunPhaseRunning {
+		return false, 0, nil
+	}
+	timeout, ok := nextRunTimeout(run)
+	if !ok {
+		return false, 0, nil
+	}
+
+	nowMeta := metav1.NewTime(now)
+	remaining := timeout.at.Sub(now)
+	switch {
+	case remaining <= 0:
+		if err := r.Delete(ctx, pod); err != nil && !apierrors.IsNotFound(err) {
+			return false, 0, err
+		}
+		run.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
+		run.Status.Reason = ReasonTimedOut
+		run.Status.Message = timeout.message
+		if run.Status.CompletionTime == nil {
+			run.Status.CompletionTime = &nowMeta
+		}
+		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionFailed, Status: metav1.ConditionTrue, Reason: ReasonTimedOut, Message: timeout.message, LastTransitionTime: nowMeta})
+		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionRunning, Status: metav1.ConditionFalse, Reason: ReasonTimedOut, Message: timeout.message, LastTransitionTime: nowMeta})
+		clearCondition(&run.Status.Conditions, ConditionTimeoutImminent)
+		r.event(run, corev1.EventTypeWarning, ReasonTimedOut, timeout.message)
+		return true, 0, nil
+	case remaining <= runTimeoutWarningLead:
+		message := fmt.Sprintf("run will stop at %s (%s)", timeout.at.UTC().Format(time.RFC3339), timeout.reason)
+		if !meta.IsStatusConditionTrue(run.Status.Conditions, ConditionTimeoutImminent) {
+			r.event(run, corev1.EventTypeWarning, ReasonTimeoutImminent, message)
+		}
+		setCondition(&run.Status.Conditions, metav1.Condition{Type: ConditionTimeoutImminent, Status: metav1.ConditionTrue, Reason: timeout.reason, Message: message, LastTransitionTime: nowMeta})
+		return true, remaining, nil
+	default:
+		// Activity can move an idle deadline back out of the warning window.
+		changed := meta.FindStatusCondition(run.Status.Conditions, ConditionTimeoutImminent) != nil
+		clearCondition(&run.Status.Conditions, ConditionTimeoutImminent)
+		return changed, remaining - runTimeoutWarningLead, nil
+	}
+}
+
+func (r *HarnessRunReconciler) event(run *operatorv1alpha1.HarnessRun, eventType, reason, message string) {
+	if r.Recorder == nil {
+		return
+	}
+	r.Recorder.Event(run, eventType, reason, message)
+}
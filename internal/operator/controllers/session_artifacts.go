@@ -18,9 +18,13 @@ import (
 const (
 	envSessionStorageSize  = "CP_SESSION_STORAGE_SIZE"
 	envSessionStorageClass = "CP_SESSION_STORAGE_CLASS"
+
+	// VolumeSnapshotGroup is the API group of CSI volume snapshots.
+	VolumeSnapshotGroup = "snapshot.storage.k8s.io"
 )
 
-func sessionWorkspacePVCName(sessionName string) string {
+// SessionWorkspacePVCName is the name of a session's workspace PVC.
+func SessionWorkspacePVCName(sessionName string) string {
 	base := sanitizeDNSLabel(sessionName)
 	if base == "" {
 		base = "session"
@@ -60,9 +64,9 @@ func desiredSessionWorkspacePVC(sess *operatorv1alpha1.Session) *corev1.Persiste
 		"app.kubernetes.io/name":       "kocao-session",
 	}
 
-	return &corev1.PersistentVolumeClaim{
+	pvc := &corev1.PersistentVolumeClaim{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      sessionWorkspacePVCName(sess.Name),
+			Name:      SessionWorkspacePVCName(sess.Name),
 			Namespace: sess.Namespace,
 			Labels:    labels,
 		},
@@ -74,6 +78,12 @@ func desiredSessionWorkspacePVC(sess *operatorv1alpha1.Session) *corev1.Persiste
 			StorageClassName: storageClassName,
 		},
 	}
+	// Sessions restored from a snapshot start from its contents.
+	if snapshot := strings.TrimSpace(sess.Annotations[AnnotationRestoreVolumeSnapshot]); snapshot != "" {
+		group := VolumeSnapshotGroup
+		pvc.Spec.DataSource = &corev1.TypedLocalObjectReference{APIGroup: &group, Kind: "VolumeSnapshot", Name: snapshot}
+	}
+	return pvc
 }
 
 func ensureSessionWorkspacePVC(ctx context.Context, c client.Client, scheme *runtime.Scheme, sess *operatorv1alpha1.Session) error {
@@ -155,7 +155,42 @@ func TestSessionReconcile_CreatesWorkspacePVC(t *testing.T) {
 	}
 
 	var pvc corev1.PersistentVolumeClaim
-	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: sessionWorkspacePVCName(sess.Name)}, &pvc); err != nil {
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: SessionWorkspacePVCName(sess.Name)}, &pvc); err != nil {
 		t.Fatalf("get pvc: %v", err)
 	}
 }
+
+func TestSessionReconcile_RestoresWorkspacePVCFromVolumeSnapshot(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	sess := &operatorv1alpha1.Session{
+		TypeMeta: metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "Session"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "s-restore",
+			Namespace:   "default",
+			Annotations: map[string]string{AnnotationRestoreVolumeSnapshot: "kocao-snapshot-abc"},
+		},
+		Spec: operatorv1alpha1.SessionSpec{RepoURL: "https://example.com/repo"},
+	}
+
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.Session{}).Build()
+	if err := cl.Create(context.Background(), sess); err != nil {
+		t.Fatalf("create session: %v", err)
+	}
+
+	r := &SessionReconciler{Client: cl, Scheme: scheme}
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(sess)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	var pvc corev1.PersistentVolumeClaim
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: SessionWorkspacePVCName(sess.Name)}, &pvc); err != nil {
+		t.Fatalf("get pvc: %v", err)
+	}
+	ds := pvc.Spec.DataSource
+	if ds == nil || ds.APIGroup == nil || *ds.APIGroup != VolumeSnapshotGroup || ds.Kind != "VolumeSnapshot" || ds.Name != "kocao-snapshot-abc" {
+		t.Fatalf("dataSource = %+v", ds)
+	}
+}
@@ -0,0 +1,190 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SkipReasonBudgetExceeded marks items failed for reaching an attempt or item
+// budget, and eligible items held back while a daily budget is used up.
+const SkipReasonBudgetExceeded = "budget_exceeded"
+
+const symphonyBudgetDayLayout = "2006-01-02"
+
+// symphonyBudget applies runtime.budgets during one sync. Daily usage counts
+// each run once, when its finish is first observed.
+type symphonyBudget struct {
+	spec     operatorv1alpha1.SymphonyProjectBudgetSpec
+	status   operatorv1alpha1.SymphonyProjectBudgetStatus
+	exceeded map[string]operatorv1alpha1.SymphonyProjectSkipStatus
+}
+
+// newSymphonyBudget returns nil when the project sets no budget; every method
+// is a no-op on nil.
+func newSymphonyBudget(project *operatorv1alpha1.SymphonyProject, now time.Time) *symphonyBudget {
+	spec := project.Spec.Runtime.Budgets
+	if !spec.Enabled() {
+		return nil
+	}
+	b := &symphonyBudget{spec: spec, exceeded: map[string]operatorv1alpha1.SymphonyProjectSkipStatus{}}
+	if previous := project.Status.Budget; previous != nil {
+		for _, item := range previous.ExceededItems {
+			b.exceeded[item.ItemID] = item
+		}
+		if previous.Day == now.UTC().Format(symphonyBudgetDayLayout) {
+			b.status.TokensToday = previous.TokensToday
+			b.status.SecondsToday = previous.SecondsToday
+		}
+	}
+	b.status.Day = now.UTC().Format(symphonyBudgetDayLayout)
+	return b
+}
+
+// exceededItem returns the skip for an item already failed by a budget.
+func (b *symphonyBudget) exceededItem(candidate githubsource.CandidateItem) (githubsource.SkippedItem, bool) {
+	if b == nil {
+		return githubsource.SkippedItem{}, false
+	}
+	item, ok := b.exceeded[candidate.ItemID]
+	if !ok {
+		return githubsource.SkippedItem{}, false
+	}
+	observedAt := time.Time{}
+	if item.ObservedTime != nil {
+		observedAt = item.ObservedTime.Time
+	}
+	return budgetSkip(candidate, item.Message, observedAt), true
+}
+
+// finish counts a finished run against the daily budgets and fails the item
+// if the run reached its attempt budget or the item its item budget.
+// usedTokens and usedSeconds are what the item's earlier attempts spent.
+func (b *symphonyBudget) finish(candidate githubsource.CandidateItem, run operatorv1alpha1.HarnessRun, attempt int32, usedTokens int64, usedSeconds float64, now time.Time) (githubsource.SkippedItem, bool) {
+	if b == nil {
+		return githubsource.SkippedItem{}, false
+	}
+	tokens, seconds := runUsage(run)
+	b.status.TokensToday += tokens
+	b.status.SecondsToday += seconds
+
+	message := ""
+	switch {
+	case b.spec.MaxTokensPerAttempt > 0 && tokens >= b.spec.MaxTokensPerAttempt:
+		message = fmt.Sprintf("attempt %d used %d tokens of its %d token budget", attempt, tokens, b.spec.MaxTokensPerAttempt)
+	case b.spec.MaxSecondsPerAttempt > 0 && seconds >= float64(b.spec.MaxSecondsPerAttempt):
+		message = fmt.Sprintf("attempt %d ran %.0fs of its %ds budget", attempt, seconds, b.spec.MaxSecondsPerAttempt)
+	case b.spec.MaxTokensPerItem > 0 && usedTokens+tokens >= b.spec.MaxTokensPerItem:
+		message = fmt.Sprintf("item used %d tokens of its %d token budget over %d attempts", usedTokens+tokens, b.spec.MaxTokensPerItem, attempt)
+	case b.spec.MaxSecondsPerItem > 0 && usedSeconds+seconds >= float64(b.spec.MaxSecondsPerItem):
+		message = fmt.Sprintf("item ran %.0fs of its %ds budget over %d attempts", usedSeconds+seconds, b.spec.MaxSecondsPerItem, attempt)
+	default:
+		return githubsource.SkippedItem{}, false
+	}
+	observed := metav1.NewTime(now)
+	b.exceeded[candidate.ItemID] = operatorv1alpha1.SymphonyProjectSkipStatus{
+		ItemID:       candidate.ItemID,
+		Issue:        issueStatus(candidate.Issue),
+		Repository:   candidate.Issue.Repository,
+		Reason:       SkipReasonBudgetExceeded,
+		Message:      message,
+		ObservedTime: &observed,
+	}
+	return budgetSkip(candidate, message, now), true
+}
+
+// admit holds back candidate while a daily budget is used up.
+func (b *symphonyBudget) admit(candidate githubsource.CandidateItem, now time.Time) (githubsource.SkippedItem, bool) {
+	if b == nil {
+		return githubsource.SkippedItem{}, true
+	}
+	switch {
+	case b.spec.MaxTokensPerDay > 0 && b.status.TokensToday >= b.spec.MaxTokensPerDay:
+		return budgetSkip(candidate, fmt.Sprintf("held back: %d of %d daily tokens used; claims resume at 00:00 UTC", b.status.TokensToday, b.spec.MaxTokensPerDay), now), false
+	case b.spec.MaxSecondsPerDay > 0 && b.status.SecondsToday >= float64(b.spec.MaxSecondsPerDay):
+		return budgetSkip(candidate, fmt.Sprintf("held back: %.0fs of %ds daily runtime used; claims resume at 00:00 UTC", b.status.SecondsToday, b.spec.MaxSecondsPerDay), now), false
+	}
+	return githubsource.SkippedItem{}, true
+}
+
+// apply records the budget status on project. Exceeded items that left the
+// candidates are forgotten, so an item moved back onto the board starts over.
+func (b *symphonyBudget) apply(project *operatorv1alpha1.SymphonyProject, snapshot githubsource.Snapshot) {
+	if b == nil {
+		project.Status.Budget = nil
+		return
+	}
+	status := b.status
+	if b.spec.MaxTokensPerDay > 0 {
+		remaining := max(b.spec.MaxTokensPerDay-status.TokensToday, 0)
+		status.RemainingTokensToday = &remaining
+		status.Exhausted = status.Exhausted || remaining == 0
+	}
+	if b.spec.MaxSecondsPerDay > 0 {
+		remaining := max(b.spec.MaxSecondsPerDay-int64(status.SecondsToday), 0)
+		status.RemainingSecondsToday = &remaining
+		status.Exhausted = status.Exhausted || status.SecondsToday >= float64(b.spec.MaxSecondsPerDay)
+	}
+	for _, candidate := range snapshot.Candidates {
+		if item, ok := b.exceeded[candidate.ItemID]; ok {
+			status.ExceededItems = append(status.ExceededItems, item)
+		}
+	}
+	sort.Slice(status.ExceededItems, func(i, j int) bool { return status.ExceededItems[i].ItemID < status.ExceededItems[j].ItemID })
+	project.Status.Budget = &status
+}
+
+// symphonyAttemptBudget is what a claim's attempt may still spend: the
+// tightest of its attempt budget, its item budget and the daily budgets. Zero
+// means unlimited; a used-up budget still allows the one turn already owed.
+func symphonyAttemptBudget(project *operatorv1alpha1.SymphonyProject, claim operatorv1alpha1.SymphonyProjectClaimStatus) (int64, int64) {
+	spec := project.Spec.Runtime.Budgets
+	tokens := tightestBudget(spec.MaxTokensPerAttempt, spec.MaxTokensPerItem, claim.UsedTokens)
+	seconds := tightestBudget(spec.MaxSecondsPerAttempt, spec.MaxSecondsPerItem, int64(claim.UsedSeconds))
+	if status := project.Status.Budget; status != nil {
+		tokens = tightestBudget(tokens, spec.MaxTokensPerDay, status.TokensToday)
+		seconds = tightestBudget(seconds, spec.MaxSecondsPerDay, int64(status.SecondsToday))
+	}
+	return tokens, seconds
+}
+
+// tightestBudget combines a direct limit with what is left of a shared one.
+func tightestBudget(limit, shared, used int64) int64 {
+	if shared > 0 {
+		left := max(shared-used, 1)
+		if limit <= 0 || left < limit {
+			limit = left
+		}
+	}
+	return limit
+}
+
+func budgetSkip(candidate githubsource.CandidateItem, message string, observedAt time.Time) githubsource.SkippedItem {
+	issue := candidate.Issue
+	return githubsource.SkippedItem{
+		ItemID:     candidate.ItemID,
+		Repository: candidate.Issue.Repository,
+		Status:     candidate.Status,
+		Reason:     SkipReasonBudgetExceeded,
+		Message:    message,
+		Issue:      &issue,
+		ObservedAt: observedAt,
+	}
+}
+
+func runUsage(run operatorv1alpha1.HarnessRun) (int64, float64) {
+	return runAnnotationInt64(run, AnnotationSymphonyTotalTokens), runAnnotationFloat64(run, AnnotationSymphonyRuntimeSeconds)
+}
+
+// usedBefore is what an item's earlier attempts spent, carried on its claim
+// or, between attempts, on its retry.
+func usedBefore(claim operatorv1alpha1.SymphonyProjectClaimStatus, retry operatorv1alpha1.SymphonyProjectRetryStatus) (int64, float64) {
+	if claim.ItemID != "" {
+		return claim.UsedTokens, claim.UsedSeconds
+	}
+	return retry.UsedTokens, retry.UsedSeconds
+}
@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+	"github.com/withakay/kocao/internal/symphony/workflow"
+)
+
+// Skip reasons for eligible items held back by a concurrency cap. They are
+// reported next to the source's own skip reasons in RecentSkips.
+const (
+	SkipReasonConcurrencyLimit           = "concurrency_limit"
+	SkipReasonStatusConcurrencyLimit     = "status_concurrency_limit"
+	SkipReasonRepositoryConcurrencyLimit = "repository_concurrency_limit"
+)
+
+// symphonyConcurrency counts the claims a project holds and decides whether
+// another item may be admitted under the project-wide cap, the per-status caps
+// from runtime.maxConcurrentItemsByStatus, the per-repository caps and the
+// per-status caps each repository's WORKFLOW.md sets through
+// agent.max_concurrent_agents_by_state.
+type symphonyConcurrency struct {
+	max          int
+	byStatus     map[string]int
+	byRepo       map[string]int
+	byRepoStatus map[string]map[string]int
+
+	running         int
+	statusCount     map[string]int
+	repoCount       map[string]int
+	repoStatusCount map[string]map[string]int
+}
+
+func newSymphonyConcurrency(project *operatorv1alpha1.SymphonyProject) *symphonyConcurrency {
+	c := &symphonyConcurrency{
+		max:             int(project.Spec.Runtime.MaxConcurrentItems),
+		byStatus:        map[string]int{},
+		byRepo:          map[string]int{},
+		byRepoStatus:    map[string]map[string]int{},
+		statusCount:     map[string]int{},
+		repoCount:       map[string]int{},
+		repoStatusCount: map[string]map[string]int{},
+	}
+	if c.max <= 0 {
+		c.max = operatorv1alpha1.DefaultSymphonyMaxConcurrentItems
+	}
+	for status, limit := range project.Spec.Runtime.MaxConcurrentItemsByStatus {
+		if limit > 0 {
+			c.byStatus[statusKey(status)] = int(limit)
+		}
+	}
+	for _, repo := range project.Spec.Repositories {
+		key := repo.RepositoryKey()
+		if key == "" {
+			continue
+		}
+		if repo.MaxConcurrentItems > 0 {
+			c.byRepo[key] = int(repo.MaxConcurrentItems)
+		}
+		if limits := repositoryWorkflowStatusLimits(repo); len(limits) != 0 {
+			c.byRepoStatus[key] = limits
+		}
+	}
+	return c
+}
+
+// repositoryWorkflowStatusLimits reads agent.max_concurrent_agents_by_state
+// from the repository's WORKFLOW.md. A missing or invalid workflow sets no
+// caps here; the claim's worker reports that error when it runs.
+func repositoryWorkflowStatusLimits(repo operatorv1alpha1.SymphonyProjectRepositorySpec) map[string]int {
+	localPath := strings.TrimSpace(repo.LocalPath)
+	if localPath == "" {
+		return nil
+	}
+	resolved, err := filepath.Abs(localPath)
+	if err != nil {
+		return nil
+	}
+	def, err := workflow.Load(workflow.ResolvePath(resolved, repo.WorkflowPath))
+	if err != nil {
+		return nil
+	}
+	cfg, err := def.TypedConfig(os.Getenv)
+	if err != nil {
+		return nil
+	}
+	return cfg.Agent.MaxConcurrentAgentsByState
+}
+
+func statusKey(status string) string {
+	return strings.ToLower(strings.TrimSpace(status))
+}
+
+func candidateRepositoryKey(candidate githubsource.CandidateItem) string {
+	return strings.ToLower(strings.TrimSpace(candidate.Issue.Repository))
+}
+
+// hold counts a claim that is already running; running claims are never
+// revoked when a cap is lowered, they only block new admissions.
+func (c *symphonyConcurrency) hold(candidate githubsource.CandidateItem) {
+	status, repo := statusKey(candidate.Status), candidateRepositoryKey(candidate)
+	c.running++
+	c.statusCount[status]++
+	c.repoCount[repo]++
+	if c.repoStatusCount[repo] == nil {
+		c.repoStatusCount[repo] = map[string]int{}
+	}
+	c.repoStatusCount[repo][status]++
+}
+
+// admit holds a claim for candidate if every cap allows it. Otherwise it
+// returns the skip that explains which cap held the item back.
+func (c *symphonyConcurrency) admit(candidate githubsource.CandidateItem, now time.Time) (githubsource.SkippedItem, bool) {
+	status, repo := statusKey(candidate.Status), candidateRepositoryKey(candidate)
+	reason, message := "", ""
+	switch {
+	case c.running >= c.max:
+		reason = SkipReasonConcurrencyLimit
+		message = fmt.Sprintf("held back: %d of %d project slots in use", c.running, c.max)
+	case c.byStatus[status] > 0 && c.statusCount[status] >= c.byStatus[status]:
+		reason = SkipReasonStatusConcurrencyLimit
+		message = fmt.Sprintf("held back: %d of %d slots for status %q in use", c.statusCount[status], c.byStatus[status], candidate.Status)
+	case c.byRepo[repo] > 0 && c.repoCount[repo] >= c.byRepo[repo]:
+		reason = SkipReasonRepositoryConcurrencyLimit
+		message = fmt.Sprintf("held back: %d of %d slots for repository %s in use", c.repoCount[repo], c.byRepo[repo], candidate.Issue.Repository)
+	case c.byRepoStatus[repo][status] > 0 && c.repoStatusCount[repo][status] >= c.byRepoStatus[repo][status]:
+		reason = SkipReasonStatusConcurrencyLimit
+		message = fmt.Sprintf("held back: %d of %d slots for status %q in %s WORKFLOW.md in use", c.repoStatusCount[repo][status], c.byRepoStatus[repo][status], candidate.Status, candidate.Issue.Repository)
+	default:
+		c.hold(candidate)
+		return githubsource.SkippedItem{}, true
+	}
+	issue := candidate.Issue
+	return githubsource.SkippedItem{
+		ItemID:     candidate.ItemID,
+		Repository: candidate.Issue.Repository,
+		Status:     candidate.Status,
+		Reason:     reason,
+		Message:    message,
+		Issue:      &issue,
+		ObservedAt: now,
+	}, false
+}
@@ -2,6 +2,7 @@ package controllers
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"os"
@@ -15,7 +16,9 @@ import (
 	"github.com/withakay/kocao/internal/auditlog"
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
 	"github.com/withakay/kocao/internal/symphony/githubsource"
+	"github.com/withakay/kocao/internal/symphony/linearsource"
 	"github.com/withakay/kocao/internal/symphony/runner"
+	"github.com/withakay/kocao/internal/symphony/worker"
 	"github.com/withakay/kocao/internal/symphony/workflow"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -31,14 +34,46 @@ type symphonySourceLoader interface {
 	LoadProject(context.Context, githubsource.LoadOptions) (githubsource.Snapshot, error)
 }
 
+// symphonySourceFactory builds the tracker client for a project's source
+// kind. Every source normalizes into githubsource snapshots.
 type symphonySourceFactory interface {
-	New(token string) (symphonySourceLoader, error)
+	New(source operatorv1alpha1.SymphonyProjectSourceSpec, token string) (symphonySourceLoader, error)
 }
 
 type defaultSymphonySourceFactory struct{}
 
-func (defaultSymphonySourceFactory) New(token string) (symphonySourceLoader, error) {
-	return githubsource.NewClient(token, githubsource.Options{})
+func (defaultSymphonySourceFactory) New(source operatorv1alpha1.SymphonyProjectSourceSpec, token string) (symphonySourceLoader, error) {
+	switch source.Kind {
+	case "", operatorv1alpha1.SymphonySourceKindGitHub:
+		return githubsource.NewClient(token, githubsource.Options{})
+	case operatorv1alpha1.SymphonySourceKindLinear:
+		if source.Linear == nil {
+			return nil, fmt.Errorf("linear source requires spec.source.linear")
+		}
+		client, err := linearsource.NewClient(token, linearsource.Options{})
+		if err != nil {
+			return nil, err
+		}
+		return linearSymphonySource{client: client, project: *source.Linear}, nil
+	default:
+		return nil, fmt.Errorf("unsupported symphony source kind %q", source.Kind)
+	}
+}
+
+// linearSymphonySource adapts the Linear client to symphonySourceLoader; the
+// GitHub project and field name in the load options do not apply to Linear.
+type linearSymphonySource struct {
+	client  *linearsource.Client
+	project operatorv1alpha1.LinearProjectRef
+}
+
+func (s linearSymphonySource) LoadProject(ctx context.Context, opts githubsource.LoadOptions) (githubsource.Snapshot, error) {
+	return s.client.LoadProject(ctx, linearsource.LoadOptions{
+		Project:        s.project,
+		ActiveStates:   opts.ActiveStates,
+		TerminalStates: opts.TerminalStates,
+		Repositories:   opts.Repositories,
+	})
 }
 
 type symphonyWorkerExecution struct {
@@ -47,6 +82,12 @@ type symphonyWorkerExecution struct {
 	Claim       operatorv1alpha1.SymphonyProjectClaimStatus
 	Issue       githubsource.Issue
 	Title       string
+	WorkerMode  operatorv1alpha1.SymphonyWorkerMode
+
+	// MaxTotalTokens and MaxSeconds stop the attempt between turns once
+	// reached; zero leaves it unlimited.
+	MaxTotalTokens int64
+	MaxSeconds     int64
 }
 
 type symphonyWorkerResult struct {
@@ -64,6 +105,10 @@ type symphonyWorkerResult struct {
 	OutputTokens   int64
 	TotalTokens    int64
 	SecondsRunning float64
+	StopReason     string
+	Turns          []worker.TurnReport
+	Hooks          []worker.HookReport
+	FailedHook     string
 }
 
 type symphonyWorkerExecutor interface {
@@ -81,60 +126,135 @@ const (
 var sensitiveTelemetryPattern = regexp.MustCompile(`(?i)(bearer\s+[a-z0-9._-]+|github_pat_[a-z0-9_]+|gh[pousr]_[a-z0-9]+|sk-[a-z0-9_-]+|token\s*[=:]\s*\S+|authorization\s*[=:]\s*\S+|password\s*[=:]\s*\S+)`)
 
 func (defaultSymphonyWorkerExecutor) Execute(ctx context.Context, execReq symphonyWorkerExecution) (symphonyWorkerResult, error) {
-	repoPath := strings.TrimSpace(execReq.Repository.LocalPath)
-	if repoPath == "" {
+	if strings.TrimSpace(execReq.Repository.LocalPath) == "" {
 		return symphonyWorkerResult{}, nil
 	}
-	resolvedRepoPath, err := filepath.Abs(repoPath)
+	task, workflowPath, err := prepareSymphonyWorkerTask(execReq)
 	if err != nil {
 		return symphonyWorkerResult{}, err
 	}
+	workspacePath := filepath.Join(os.TempDir(), "kocao-symphony-workspaces", sanitizeDNSLabel(execReq.ProjectName), sanitizeDNSLabel(execReq.Issue.Repository+"-"+strconv.FormatInt(execReq.Issue.Number, 10)))
+	if err := os.MkdirAll(workspacePath, 0o755); err != nil {
+		return symphonyWorkerResult{}, err
+	}
+	report, err := worker.Run(ctx, task, workspacePath)
+	result := symphonyWorkerResultFromReport(report)
+	result.WorkflowPath = workflowPath
+	result.WorkspacePath = workspacePath
+	// Turns that ran before a failure are still worth reporting.
+	return result, err
+}
+
+func symphonyClaimExecution(project *operatorv1alpha1.SymphonyProject, repo operatorv1alpha1.SymphonyProjectRepositorySpec, claim operatorv1alpha1.SymphonyProjectClaimStatus) symphonyWorkerExecution {
+	maxTokens, maxSeconds := symphonyAttemptBudget(project, claim)
+	return symphonyWorkerExecution{
+		ProjectName:    project.Name,
+		Repository:     repo,
+		Claim:          claim,
+		Issue:          githubsource.Issue{Repository: claim.Issue.Repository, Number: claim.Issue.Number, NodeID: claim.Issue.NodeID, URL: claim.Issue.URL, Title: claim.Issue.Title},
+		Title:          fmt.Sprintf("%s#%d: %s", claim.Issue.Repository, claim.Issue.Number, claim.Issue.Title),
+		WorkerMode:     project.Spec.Runtime.WorkerMode,
+		MaxTotalTokens: maxTokens,
+		MaxSeconds:     maxSeconds,
+	}
+}
+
+// prepareSymphonyWorkerTask loads WORKFLOW.md from the repository's local
+// checkout and renders the prompt and hardened codex config for a claim. Both
+// worker modes run exactly this task; only where the turns execute differs.
+func prepareSymphonyWorkerTask(execReq symphonyWorkerExecution) (worker.Task, string, error) {
+	resolvedRepoPath, err := filepath.Abs(strings.TrimSpace(execReq.Repository.LocalPath))
+	if err != nil {
+		return worker.Task{}, "", err
+	}
 	workflowPath := workflow.ResolvePath(resolvedRepoPath, execReq.Repository.WorkflowPath)
 	def, err := workflow.Load(workflowPath)
 	if err != nil {
-		return symphonyWorkerResult{}, err
+		return worker.Task{}, "", err
 	}
 	cfg, err := def.TypedConfig(os.Getenv)
 	if err != nil {
-		return symphonyWorkerResult{}, err
+		return worker.Task{}, "", err
 	}
-	if err := enforceWorkflowSecurity(def, cfg); err != nil {
-		return symphonyWorkerResult{}, err
+	if err := enforceWorkflowSecurity(def, cfg, execReq.WorkerMode); err != nil {
+		return worker.Task{}, "", err
 	}
-	codexCfg := secureCodexConfig(cfg.Codex)
-	prompt, err := def.Render(issueTemplateData(execReq.Issue), int32PtrToIntPtr(execReq.Claim.Attempt))
-	if err != nil {
-		return symphonyWorkerResult{}, err
-	}
-	workspacePath := filepath.Join(os.TempDir(), "kocao-symphony-workspaces", sanitizeDNSLabel(execReq.ProjectName), sanitizeDNSLabel(execReq.Issue.Repository+"-"+strconv.FormatInt(execReq.Issue.Number, 10)))
-	if err := os.MkdirAll(workspacePath, 0o755); err != nil {
-		return symphonyWorkerResult{}, err
+	issue := issueTemplateData(execReq.Issue)
+	attempt := int32PtrToIntPtr(execReq.Claim.Attempt)
+	var prompt string
+	if cfg.Agent.MaxTurns > 1 {
+		prompt, err = def.RenderFirstTurn(issue, attempt, cfg.Agent.MaxTurns, cfg.Agent.CompletionMarker)
+	} else {
+		prompt, err = def.Render(issue, attempt)
 	}
-	started := time.Now().UTC()
-	runResult, err := runner.Run(ctx, runner.Options{Workspace: workspacePath, Title: execReq.Title, Prompts: []string{prompt}, Config: codexCfg})
 	if err != nil {
-		return symphonyWorkerResult{}, err
+		return worker.Task{}, "", err
 	}
-	lastTurnID := ""
-	if len(runResult.Turns) != 0 {
-		lastTurnID = runResult.Turns[len(runResult.Turns)-1].TurnID
+	// Render one continuation up front so a broken continuation section fails
+	// the claim now rather than after the first turn has run.
+	if cfg.Agent.MaxTurns > 1 {
+		if _, err := def.RenderContinuation(issue, attempt, 2, cfg.Agent.MaxTurns, cfg.Agent.CompletionMarker); err != nil {
+			return worker.Task{}, "", err
+		}
 	}
+	return worker.Task{
+		Title:                execReq.Title,
+		Prompt:               prompt,
+		Codex:                secureCodexConfig(cfg.Codex),
+		MaxTurns:             cfg.Agent.MaxTurns,
+		CompletionMarker:     cfg.Agent.CompletionMarker,
+		ContinuationTemplate: def.ContinuationTemplate,
+		Issue:                issue,
+		Attempt:              attempt,
+		Hooks:                cfg.Hooks,
+		MaxTotalTokens:       execReq.MaxTotalTokens,
+		MaxSeconds:           execReq.MaxSeconds,
+	}, workflowPath, nil
+}
+
+func symphonyWorkerResultFromReport(report worker.Report) symphonyWorkerResult {
 	return symphonyWorkerResult{
-		WorkflowPath:   workflowPath,
-		WorkspacePath:  workspacePath,
-		SessionID:      sessionID(runResult.ThreadID, lastTurnID),
-		ThreadID:       runResult.ThreadID,
-		TurnID:         lastTurnID,
-		ApprovalPolicy: codexCfg.ApprovalPolicy,
-		ThreadSandbox:  codexCfg.ThreadSandbox,
-		TurnSandbox:    codexCfg.TurnSandboxPolicy,
-		LastEvent:      runResult.LastEvent,
-		LastMessage:    sanitizeTelemetryMessage(runResult.LastMessage),
-		InputTokens:    int64(runResult.Usage.InputTokens),
-		OutputTokens:   int64(runResult.Usage.OutputTokens),
-		TotalTokens:    int64(runResult.Usage.TotalTokens),
-		SecondsRunning: time.Since(started).Seconds(),
-	}, nil
+		SessionID:      sessionID(report.ThreadID, report.TurnID),
+		ThreadID:       report.ThreadID,
+		TurnID:         report.TurnID,
+		ApprovalPolicy: report.ApprovalPolicy,
+		ThreadSandbox:  report.ThreadSandbox,
+		TurnSandbox:    report.TurnSandbox,
+		LastEvent:      report.LastEvent,
+		LastMessage:    sanitizeTelemetryMessage(report.LastMessage),
+		InputTokens:    report.InputTokens,
+		OutputTokens:   report.OutputTokens,
+		TotalTokens:    report.TotalTokens,
+		SecondsRunning: report.SecondsRunning,
+		StopReason:     report.StopReason,
+		Turns:          sanitizeTurnReports(report.Turns),
+		Hooks:          sanitizeHookReports(report.Hooks),
+		FailedHook:     report.FailedHook,
+	}
+}
+
+func sanitizeHookReports(hooks []worker.HookReport) []worker.HookReport {
+	if len(hooks) == 0 {
+		return nil
+	}
+	out := make([]worker.HookReport, len(hooks))
+	for i, hook := range hooks {
+		hook.Output = sanitizeTelemetryMessage(hook.Output)
+		out[i] = hook
+	}
+	return out
+}
+
+func sanitizeTurnReports(turns []worker.TurnReport) []worker.TurnReport {
+	if len(turns) == 0 {
+		return nil
+	}
+	out := make([]worker.TurnReport, len(turns))
+	for i, turn := range turns {
+		turn.Message = sanitizeTelemetryMessage(turn.Message)
+		out[i] = turn
+	}
+	return out
 }
 
 type SymphonyProjectReconciler struct {
@@ -204,6 +324,12 @@ func (r *SymphonyProjectReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	updated.Status.ObservedGeneration = updated.Generation
 
 	pollInterval := time.Duration(updated.Spec.Source.PollIntervalSec) * time.Second
+	if requestedAt := strings.TrimSpace(updated.Annotations[AnnotationSymphonyPlanRequestedAt]); requestedAt != "" && (updated.Status.Plan == nil || updated.Status.Plan.RequestedAt != requestedAt) {
+		// Plans are answered before the pause check so a project can be
+		// created paused and planned before it ever claims anything.
+		updated.Status.Plan = r.planProject(ctx, updated, sourceFactory, requestedAt, now)
+		changedStatus = true
+	}
 	if updated.Spec.Paused {
 		updated.Status.Phase = operatorv1alpha1.SymphonyProjectPhasePaused
 		updated.Status.LastError = ""
@@ -214,27 +340,21 @@ func (r *SymphonyProjectReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return r.commit(ctx, &project, updated, changedMeta, changedStatus, ctrl.Result{RequeueAfter: pollInterval})
 	}
 
-	token, err := r.loadGitHubToken(ctx, updated)
+	token, err := r.loadSourceToken(ctx, updated)
 	if err != nil {
 		r.setConfigError(updated, now, err)
 		changedStatus = true
 		return r.commit(ctx, &project, updated, changedMeta, changedStatus, ctrl.Result{RequeueAfter: pollInterval})
 	}
 
-	loader, err := sourceFactory.New(token)
+	loader, err := sourceFactory.New(updated.Spec.Source, token)
 	if err != nil {
-		r.setSourceError(updated, now, fmt.Errorf("build github source client: %w", err), pollInterval)
+		r.setSourceError(updated, now, fmt.Errorf("build %s source client: %w", updated.Spec.Source.Kind, err), pollInterval)
 		changedStatus = true
 		return r.commit(ctx, &project, updated, changedMeta, changedStatus, ctrl.Result{RequeueAfter: pollInterval})
 	}
 
-	snapshot, err := loader.LoadProject(ctx, githubsource.LoadOptions{
-		Project:        updated.Spec.Source.Project,
-		FieldName:      updated.Spec.Source.FieldName,
-		ActiveStates:   updated.Spec.Source.ActiveStates,
-		TerminalStates: updated.Spec.Source.TerminalStates,
-		Repositories:   updated.Spec.Repositories,
-	})
+	snapshot, err := loader.LoadProject(ctx, symphonyLoadOptions(updated))
 	if err != nil {
 		r.setSourceError(updated, now, err, pollInterval)
 		changedStatus = true
@@ -251,7 +371,7 @@ func (r *SymphonyProjectReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return r.commit(ctx, &project, updated, changedMeta, changedStatus, ctrl.Result{RequeueAfter: pollInterval})
 	}
 
-	reconcileProjectRuntime(updated, snapshot, runsByItem, now.Time)
+	transitions := reconcileProjectRuntime(updated, snapshot, runsByItem, now.Time)
 	if err := r.materializeActiveClaims(ctx, updated, runsByItem, now.Time); err != nil {
 		r.setLifecycleError(updated, now, err, pollInterval)
 		changedStatus = true
@@ -274,16 +394,24 @@ func (r *SymphonyProjectReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		changedStatus = true
 		return r.commit(ctx, &project, updated, changedMeta, changedStatus, ctrl.Result{RequeueAfter: pollInterval})
 	}
-	reconcileProjectRuntime(updated, snapshot, runsByItem, now.Time)
+	transitions = append(transitions, reconcileProjectRuntime(updated, snapshot, runsByItem, now.Time)...)
+	r.writeBackTransitions(ctx, updated, loader, snapshot, transitions, now)
 	updated.Status.Phase = operatorv1alpha1.SymphonyProjectPhaseReady
 	updated.Status.ObservedGeneration = updated.Generation
 	updated.Status.ResolvedFieldName = snapshot.ResolvedFieldName
+	updated.Status.ProjectNodeID = snapshot.ProjectID
 	updated.Status.LastSyncTime = &now
 	updated.Status.LastSuccessfulSync = &now
 	updated.Status.NextSyncTime = &metav1.Time{Time: now.Time.Add(pollInterval)}
 	updated.Status.LastError = ""
 	setCondition(&updated.Status.Conditions, metav1.Condition{Type: ConditionSource, Status: metav1.ConditionTrue, Reason: "SyncSucceeded", Message: "github project snapshot loaded", LastTransitionTime: now})
 	setCondition(&updated.Status.Conditions, metav1.Condition{Type: ConditionLifecycle, Status: metav1.ConditionTrue, Reason: "Polling", Message: "symphony orchestration is polling for work", LastTransitionTime: now})
+	if updated.Status.Budget != nil && updated.Status.Budget.Exhausted {
+		// Running claims are still tracked; only new claims wait for the
+		// daily budget to reset.
+		updated.Status.Phase = operatorv1alpha1.SymphonyProjectPhasePaused
+		setCondition(&updated.Status.Conditions, metav1.Condition{Type: ConditionLifecycle, Status: metav1.ConditionFalse, Reason: "BudgetExceeded", Message: "daily budget used up; new claims resume at 00:00 UTC", LastTransitionTime: now})
+	}
 	changedStatus = true
 
 	return r.commit(ctx, &project, updated, changedMeta, changedStatus, ctrl.Result{RequeueAfter: pollInterval})
@@ -352,13 +480,18 @@ func (r *SymphonyProjectReconciler) executeRunnableClaims(ctx context.Context, p
 		case operatorv1alpha1.HarnessRunPhaseSucceeded, operatorv1alpha1.HarnessRunPhaseFailed:
 			continue
 		}
-		result, execErr := workerExecutor.Execute(ctx, symphonyWorkerExecution{
-			ProjectName: project.Name,
-			Repository:  repo,
-			Claim:       claim,
-			Issue:       githubsource.Issue{Repository: claim.Issue.Repository, Number: claim.Issue.Number, NodeID: claim.Issue.NodeID, URL: claim.Issue.URL, Title: claim.Issue.Title},
-			Title:       fmt.Sprintf("%s#%d: %s", claim.Issue.Repository, claim.Issue.Number, claim.Issue.Title),
-		})
+		execReq := symphonyClaimExecution(project, repo, claim)
+		if project.Spec.Runtime.WorkerMode == operatorv1alpha1.SymphonyWorkerModePod {
+			// The harness pod runs the turns; its report reaches the run
+			// through the HarnessRun controller.
+			if err := r.ensureSymphonyWorkerTask(ctx, &run, execReq); err != nil {
+				if err := r.applyWorkerOutcome(ctx, &run, symphonyWorkerResult{}, err, now); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		result, execErr := workerExecutor.Execute(ctx, execReq)
 		if err := r.applyWorkerOutcome(ctx, &run, result, execErr, now); err != nil {
 			return err
 		}
@@ -495,20 +628,32 @@ func (r *SymphonyProjectReconciler) ensureClaimRun(ctx context.Context, project
 	if !apierrors.IsNotFound(err) {
 		return nil, err
 	}
+	annotations := symphonyObjectAnnotations(claim)
+	command := append([]string(nil), project.Spec.Runtime.Command...)
+	if project.Spec.Runtime.WorkerMode == operatorv1alpha1.SymphonyWorkerModePod && strings.TrimSpace(repo.LocalPath) != "" {
+		annotations[AnnotationSymphonyTaskConfigMap] = symphonyTaskConfigMapName(name)
+		if len(command) == 0 {
+			command = []string{symphonyWorkerCommand}
+		}
+	}
+	pullRequest := symphonyPullRequestSpec(project, repo, claim)
+	if pullRequest != nil && len(project.Spec.Runtime.Command) == 0 && len(command) == 1 && command[0] == symphonyWorkerCommand {
+		command = []string{harnessEntrypointCommand, symphonyWorkerCommand}
+	}
 	run = &operatorv1alpha1.HarnessRun{
 		TypeMeta: metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        name,
 			Namespace:   project.Namespace,
 			Labels:      symphonyObjectLabels(project, claim),
-			Annotations: symphonyObjectAnnotations(claim),
+			Annotations: annotations,
 		},
 		Spec: operatorv1alpha1.HarnessRunSpec{
 			WorkspaceSessionName:    session.Name,
 			RepoURL:                 repositoryRepoURL(repo),
 			RepoRevision:            claimRepoRevision(project.Spec.Runtime, repo),
 			Image:                   project.Spec.Runtime.Image,
-			Command:                 append([]string(nil), project.Spec.Runtime.Command...),
+			Command:                 command,
 			Args:                    append([]string(nil), project.Spec.Runtime.Args...),
 			WorkingDir:              project.Spec.Runtime.WorkingDir,
 			Env:                     append([]operatorv1alpha1.EnvVar(nil), project.Spec.Runtime.Env...),
@@ -516,8 +661,19 @@ func (r *SymphonyProjectReconciler) ensureClaimRun(ctx context.Context, project
 			AgentAuth:               repo.AgentAuth,
 			EgressMode:              claimEgressMode(project.Spec.Runtime, repo),
 			TTLSecondsAfterFinished: project.Spec.Runtime.TTLSecondsAfterFinished,
+			PullRequest:             pullRequest,
+			Scheduling:              project.Spec.Runtime.Scheduling.DeepCopy(),
+			EgressAllowedHosts:      append([]string(nil), project.Spec.Runtime.EgressAllowedHosts...),
 		},
 	}
+	// Runs inherit the project's ownership stamp so restricted run classes
+	// are checked against whoever created the project.
+	if owner := strings.TrimSpace(project.Annotations[AnnotationOwner]); owner != "" {
+		run.Annotations[AnnotationOwner] = owner
+	}
+	if team := strings.TrimSpace(project.Labels[LabelTeam]); team != "" {
+		run.Labels[LabelTeam] = team
+	}
 	if err := controllerutil.SetControllerReference(project, run, r.Scheme); err != nil {
 		return nil, err
 	}
@@ -539,77 +695,104 @@ func (r *SymphonyProjectReconciler) applyWorkerOutcome(ctx context.Context, run
 	if updated.Annotations == nil {
 		updated.Annotations = map[string]string{}
 	}
+	for key, value := range symphonyWorkerResultAnnotations(result) {
+		updated.Annotations[key] = value
+	}
+	startedAt := now
+	if result.SecondsRunning > 0 {
+		startedAt = now.Add(-time.Duration(result.SecondsRunning * float64(time.Second)))
+	}
+	started := metav1.NewTime(startedAt)
+	completed := metav1.NewTime(now)
+	updated.Status.StartTime = &started
+	updated.Status.CompletionTime = &completed
+	if execErr != nil {
+		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
+		updated.Status.Conditions = []metav1.Condition{{Type: ConditionFailed, Status: metav1.ConditionTrue, Reason: symphonyErrorReason(execErr), Message: execErr.Error(), LastTransitionTime: completed}}
+	} else {
+		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseSucceeded
+		updated.Status.Conditions = []metav1.Condition{{Type: ConditionSucceeded, Status: metav1.ConditionTrue, Reason: "WorkflowCompleted", Message: firstNonEmpty(result.LastMessage, "workflow execution completed"), LastTransitionTime: completed}}
+	}
+	metaUpdated := updated.DeepCopy()
+	metaUpdated.Status = original.Status
+	if err := r.Patch(ctx, metaUpdated, client.MergeFrom(original)); err != nil {
+		return err
+	}
+	var latest operatorv1alpha1.HarnessRun
+	if err := r.Get(ctx, client.ObjectKeyFromObject(run), &latest); err != nil {
+		return err
+	}
+	latest.Status = updated.Status
+	return r.Status().Update(ctx, &latest)
+}
+
+// symphonyWorkerResultAnnotations maps a worker result onto the run
+// annotations that feed the project's telemetry and token totals.
+func symphonyWorkerResultAnnotations(result symphonyWorkerResult) map[string]string {
+	annotations := map[string]string{}
 	if result.SessionID != "" {
-		updated.Annotations[AnnotationSymphonySessionID] = result.SessionID
+		annotations[AnnotationSymphonySessionID] = result.SessionID
 	}
 	if result.ThreadID != "" {
-		updated.Annotations[AnnotationSymphonyThreadID] = result.ThreadID
+		annotations[AnnotationSymphonyThreadID] = result.ThreadID
 	}
 	if result.TurnID != "" {
-		updated.Annotations[AnnotationSymphonyTurnID] = result.TurnID
+		annotations[AnnotationSymphonyTurnID] = result.TurnID
 	}
 	if result.LastEvent != "" {
-		updated.Annotations[AnnotationSymphonyLastEvent] = result.LastEvent
+		annotations[AnnotationSymphonyLastEvent] = result.LastEvent
 	}
 	if sanitized := sanitizeTelemetryMessage(result.LastMessage); sanitized != "" {
-		updated.Annotations[AnnotationSymphonyLastMessage] = sanitized
+		annotations[AnnotationSymphonyLastMessage] = sanitized
 	}
 	if result.WorkflowPath != "" || result.WorkspacePath != "" {
-		updated.Annotations[AnnotationSymphonyWorkflowPath] = "[redacted]"
-		updated.Annotations[AnnotationSymphonyWorkspacePath] = "[redacted]"
+		annotations[AnnotationSymphonyWorkflowPath] = "[redacted]"
+		annotations[AnnotationSymphonyWorkspacePath] = "[redacted]"
 	}
 	if policy, sandbox, turnSandbox := resultSecurityAnnotations(result); policy != "" || sandbox != "" || turnSandbox != "" {
 		if policy != "" {
-			updated.Annotations[AnnotationSymphonyApprovalPolicy] = policy
+			annotations[AnnotationSymphonyApprovalPolicy] = policy
 		}
 		if sandbox != "" {
-			updated.Annotations[AnnotationSymphonyThreadSandbox] = sandbox
+			annotations[AnnotationSymphonyThreadSandbox] = sandbox
 		}
 		if turnSandbox != "" {
-			updated.Annotations[AnnotationSymphonyTurnSandbox] = turnSandbox
+			annotations[AnnotationSymphonyTurnSandbox] = turnSandbox
 		}
 	}
 	if result.InputTokens > 0 {
-		updated.Annotations[AnnotationSymphonyInputTokens] = strconv.FormatInt(result.InputTokens, 10)
+		annotations[AnnotationSymphonyInputTokens] = strconv.FormatInt(result.InputTokens, 10)
 	}
 	if result.OutputTokens > 0 {
-		updated.Annotations[AnnotationSymphonyOutputTokens] = strconv.FormatInt(result.OutputTokens, 10)
+		annotations[AnnotationSymphonyOutputTokens] = strconv.FormatInt(result.OutputTokens, 10)
 	}
 	if result.TotalTokens > 0 {
-		updated.Annotations[AnnotationSymphonyTotalTokens] = strconv.FormatInt(result.TotalTokens, 10)
-	}
-	if result.SecondsRunning > 0 {
-		updated.Annotations[AnnotationSymphonyRuntimeSeconds] = strconv.FormatFloat(result.SecondsRunning, 'f', 3, 64)
+		annotations[AnnotationSymphonyTotalTokens] = strconv.FormatInt(result.TotalTokens, 10)
 	}
-	startedAt := now
-	if result.SecondsRunning > 0 {
-		startedAt = now.Add(-time.Duration(result.SecondsRunning * float64(time.Second)))
+	if result.StopReason != "" {
+		annotations[AnnotationSymphonyStopReason] = result.StopReason
 	}
-	started := metav1.NewTime(startedAt)
-	completed := metav1.NewTime(now)
-	updated.Status.StartTime = &started
-	updated.Status.CompletionTime = &completed
-	if execErr != nil {
-		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseFailed
-		updated.Status.Conditions = []metav1.Condition{{Type: ConditionFailed, Status: metav1.ConditionTrue, Reason: symphonyErrorReason(execErr), Message: execErr.Error(), LastTransitionTime: completed}}
-	} else {
-		updated.Status.Phase = operatorv1alpha1.HarnessRunPhaseSucceeded
-		updated.Status.Conditions = []metav1.Condition{{Type: ConditionSucceeded, Status: metav1.ConditionTrue, Reason: "WorkflowCompleted", Message: firstNonEmpty(result.LastMessage, "workflow execution completed"), LastTransitionTime: completed}}
+	if len(result.Turns) != 0 {
+		if data, err := json.Marshal(result.Turns); err == nil {
+			annotations[AnnotationSymphonyTurns] = string(data)
+		}
 	}
-	metaUpdated := updated.DeepCopy()
-	metaUpdated.Status = original.Status
-	if err := r.Patch(ctx, metaUpdated, client.MergeFrom(original)); err != nil {
-		return err
+	if len(result.Hooks) != 0 {
+		if data, err := json.Marshal(sanitizeHookReports(result.Hooks)); err == nil {
+			annotations[AnnotationSymphonyHooks] = string(data)
+		}
 	}
-	var latest operatorv1alpha1.HarnessRun
-	if err := r.Get(ctx, client.ObjectKeyFromObject(run), &latest); err != nil {
-		return err
+	if result.SecondsRunning > 0 {
+		annotations[AnnotationSymphonyRuntimeSeconds] = strconv.FormatFloat(result.SecondsRunning, 'f', 3, 64)
 	}
-	latest.Status = updated.Status
-	return r.Status().Update(ctx, &latest)
+	return annotations
 }
 
-func (r *SymphonyProjectReconciler) loadGitHubToken(ctx context.Context, project *operatorv1alpha1.SymphonyProject) (string, error) {
+func (r *SymphonyProjectReconciler) loadSourceToken(ctx context.Context, project *operatorv1alpha1.SymphonyProject) (string, error) {
+	kind := string(project.Spec.Source.Kind)
+	if kind == "" {
+		kind = string(operatorv1alpha1.SymphonySourceKindGitHub)
+	}
 	secretName := strings.TrimSpace(project.Spec.Source.TokenSecretRef.Name)
 	secretKey := strings.TrimSpace(project.Spec.Source.TokenSecretRef.Key)
 	if secretKey == "" {
@@ -617,19 +800,30 @@ func (r *SymphonyProjectReconciler) loadGitHubToken(ctx context.Context, project
 	}
 	var secret corev1.Secret
 	if err := r.Get(ctx, client.ObjectKey{Namespace: project.Namespace, Name: secretName}, &secret); err != nil {
-		return "", fmt.Errorf("load github token secret %s: %w", secretName, err)
+		return "", fmt.Errorf("load %s token secret %s: %w", kind, secretName, err)
 	}
 	tokenBytes, ok := secret.Data[secretKey]
 	if !ok {
-		return "", fmt.Errorf("github token secret %s missing key %q", secretName, secretKey)
+		return "", fmt.Errorf("%s token secret %s missing key %q", kind, secretName, secretKey)
 	}
 	token := strings.TrimSpace(string(tokenBytes))
 	if token == "" {
-		return "", fmt.Errorf("github token secret %s key %q is empty", secretName, secretKey)
+		return "", fmt.Errorf("%s token secret %s key %q is empty", kind, secretName, secretKey)
 	}
 	return token, nil
 }
 
+func symphonyLoadOptions(project *operatorv1alpha1.SymphonyProject) githubsource.LoadOptions {
+	return githubsource.LoadOptions{
+		Project:           project.Spec.Source.Project,
+		FieldName:         project.Spec.Source.FieldName,
+		ActiveStates:      project.Spec.Source.ActiveStates,
+		TerminalStates:    project.Spec.Source.TerminalStates,
+		Repositories:      project.Spec.Repositories,
+		PriorityFieldName: symphonyPriorityFieldName(project.Spec.Priority),
+	}
+}
+
 func (r *SymphonyProjectReconciler) listProjectRuns(ctx context.Context, project *operatorv1alpha1.SymphonyProject) (map[string]operatorv1alpha1.HarnessRun, error) {
 	var runs operatorv1alpha1.HarnessRunList
 	if err := r.List(ctx, &runs, client.InNamespace(project.Namespace), client.MatchingLabels{
@@ -700,7 +894,16 @@ func newerHarnessRun(left, right operatorv1alpha1.HarnessRun) bool {
 	return left.Name > right.Name
 }
 
-func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot githubsource.Snapshot, runsByItem map[string]operatorv1alpha1.HarnessRun, now time.Time) {
+// symphonyPendingAdmission is a due retry waiting for a concurrency slot.
+type symphonyPendingAdmission struct {
+	candidate githubsource.CandidateItem
+	retry     operatorv1alpha1.SymphonyProjectRetryStatus
+}
+
+// reconcileProjectRuntime rebuilds the runtime status from the snapshot and
+// the project's runs, and returns the claim, failure and success transitions
+// observed relative to the previous status.
+func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot githubsource.Snapshot, runsByItem map[string]operatorv1alpha1.HarnessRun, now time.Time) []symphonyItemTransition {
 	previousClaims := mapClaimsByItem(project.Status.ActiveClaims)
 	previousRetries := mapRetriesByItem(project.Status.RetryQueue)
 	limit := int(project.Spec.Runtime.ActiveStatusItemLimit)
@@ -711,34 +914,32 @@ func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot
 	if retryLimit <= 0 {
 		retryLimit = operatorv1alpha1.DefaultSymphonyRecentErrorLimit
 	}
-	maxConcurrent := int(project.Spec.Runtime.MaxConcurrentItems)
-	if maxConcurrent <= 0 {
-		maxConcurrent = operatorv1alpha1.DefaultSymphonyMaxConcurrentItems
-	}
+	concurrency := newSymphonyConcurrency(project)
+	budget := newSymphonyBudget(project, now)
+	candidates, ranks := rankSymphonyCandidates(project.Spec.Priority, snapshot.Candidates)
 
-	claims := make([]operatorv1alpha1.SymphonyProjectClaimStatus, 0, minInt(limit, maxConcurrent))
+	claims := make([]operatorv1alpha1.SymphonyProjectClaimStatus, 0, minInt(limit, concurrency.max))
 	retries := make([]operatorv1alpha1.SymphonyProjectRetryStatus, 0, retryLimit)
 	errors := make([]operatorv1alpha1.SymphonyProjectErrorStatus, 0, retryLimit)
 	events := make([]operatorv1alpha1.SymphonyProjectEventStatus, 0, retryLimit)
+	turnEvents := make([]operatorv1alpha1.SymphonyProjectEventStatus, 0)
 	completed := int32(0)
 	failed := int32(0)
 	totals := operatorv1alpha1.SymphonyProjectTokenTotalsStatus{}
 	consumed := map[string]struct{}{}
 	deferredRetry := map[string]operatorv1alpha1.SymphonyProjectRetryStatus{}
-	readyRetries := map[string]operatorv1alpha1.SymphonyProjectRetryStatus{}
-	readyRetryIDs := make([]string, 0)
+	// Items waiting for a slot are admitted only once every running claim is
+	// counted: due retries first, then fresh items, each in priority order.
+	readyRetries := make([]symphonyPendingAdmission, 0)
 	freshCandidates := make([]githubsource.CandidateItem, 0)
+	heldBack := make([]githubsource.SkippedItem, 0)
+	transitions := make([]symphonyItemTransition, 0)
 
-	for _, candidate := range snapshot.Candidates {
+	for _, candidate := range candidates {
 		if retry, ok := previousRetries[candidate.ItemID]; ok {
 			if _, claimed := previousClaims[candidate.ItemID]; !claimed {
 				if retry.ReadyAt != nil && !retry.ReadyAt.Time.After(now) {
-					if len(claims) < maxConcurrent {
-						claims = append(claims, buildClaimFromRetry(candidate, retry, now))
-						consumed[candidate.ItemID] = struct{}{}
-					} else {
-						deferredRetry[candidate.ItemID] = retry
-					}
+					readyRetries = append(readyRetries, symphonyPendingAdmission{candidate: candidate, retry: retry})
 					continue
 				}
 				deferredRetry[candidate.ItemID] = retry
@@ -754,17 +955,47 @@ func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot
 			if event, ok := buildEventStatus(candidate, run); ok {
 				events = append(events, event)
 			}
+			turnEvents = append(turnEvents, buildTurnEventStatuses(candidate, run)...)
+		}
+		if skip, ok := budget.exceededItem(candidate); ok {
+			heldBack = append(heldBack, skip)
+			continue
+		}
+		if hasRun {
+			previous, finished := previousClaims[candidate.ItemID]
+			finished = finished && run.Name == symphonyRunName(project, previous)
+			usedTokens, usedSeconds := usedBefore(previousClaims[candidate.ItemID], previousRetries[candidate.ItemID])
+			runTokens, runSeconds := runUsage(run)
 			switch run.Status.Phase {
 			case operatorv1alpha1.HarnessRunPhaseSucceeded:
+				if finished {
+					if skip, exceeded := budget.finish(candidate, run, previous.Attempt, usedTokens, usedSeconds, now); exceeded {
+						failed++
+						errorStatus := buildErrorStatus(candidate, run, previous.Attempt, now)
+						errorStatus.Reason = SkipReasonBudgetExceeded
+						errors = append(errors, errorStatus)
+						transitions = append(transitions, buildRunTransition(symphonyTransitionFailed, candidate, run, previous.Attempt, SkipReasonBudgetExceeded))
+						heldBack = append(heldBack, skip)
+						continue
+					}
+				}
 				completed++
+				if finished {
+					transitions = append(transitions, buildRunTransition(symphonyTransitionSucceeded, candidate, run, previous.Attempt, ""))
+				}
+				// A merged pull request settles the item; an open one waits for
+				// review instead of starting a continuation.
+				skip, merged, open := pullRequestOutcome(candidate, run, now)
+				if open {
+					heldBack = append(heldBack, skip)
+				}
+				if merged || open {
+					continue
+				}
 				if retry, ok := buildContinuationRetryStatus(candidate, previousClaims[candidate.ItemID], previousRetries[candidate.ItemID], now); ok {
+					retry.UsedTokens, retry.UsedSeconds = usedTokens+runTokens, usedSeconds+runSeconds
 					if retry.ReadyAt != nil && !retry.ReadyAt.Time.After(now) {
-						if len(claims) < maxConcurrent {
-							claims = append(claims, buildClaimFromRetry(candidate, retry, now))
-							consumed[candidate.ItemID] = struct{}{}
-						} else {
-							deferredRetry[candidate.ItemID] = retry
-						}
+						readyRetries = append(readyRetries, symphonyPendingAdmission{candidate: candidate, retry: retry})
 					} else {
 						deferredRetry[candidate.ItemID] = retry
 					}
@@ -773,18 +1004,28 @@ func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot
 			case operatorv1alpha1.HarnessRunPhasePending, operatorv1alpha1.HarnessRunPhaseStarting, operatorv1alpha1.HarnessRunPhaseRunning:
 				claims = append(claims, buildClaimStatus(candidate, run, previousClaims[candidate.ItemID], now))
 				consumed[candidate.ItemID] = struct{}{}
+				concurrency.hold(candidate)
 				continue
 			case operatorv1alpha1.HarnessRunPhaseFailed:
 				failed++
-				errors = append(errors, buildErrorStatus(candidate, run, previousClaimOrRetryAttempt(previousClaims[candidate.ItemID], previousRetries[candidate.ItemID]), now))
+				errorStatus := buildErrorStatus(candidate, run, previousClaimOrRetryAttempt(previousClaims[candidate.ItemID], previousRetries[candidate.ItemID]), now)
+				if finished {
+					if skip, exceeded := budget.finish(candidate, run, previous.Attempt, usedTokens, usedSeconds, now); exceeded {
+						errorStatus.Reason = SkipReasonBudgetExceeded
+						errors = append(errors, errorStatus)
+						transitions = append(transitions, buildRunTransition(symphonyTransitionFailed, candidate, run, previous.Attempt, SkipReasonBudgetExceeded))
+						heldBack = append(heldBack, skip)
+						continue
+					}
+				}
+				errors = append(errors, errorStatus)
+				if finished {
+					transitions = append(transitions, buildRunTransition(symphonyTransitionFailed, candidate, run, previous.Attempt, errorStatus.Reason))
+				}
 				if retry, ok := buildRetryStatus(candidate, run, previousClaims[candidate.ItemID], previousRetries[candidate.ItemID], project.Spec.Runtime, now); ok {
+					retry.UsedTokens, retry.UsedSeconds = usedTokens+runTokens, usedSeconds+runSeconds
 					if retry.ReadyAt != nil && !retry.ReadyAt.Time.After(now) {
-						if len(claims) < maxConcurrent {
-							claims = append(claims, buildClaimFromRetry(candidate, retry, now))
-							consumed[candidate.ItemID] = struct{}{}
-						} else {
-							deferredRetry[candidate.ItemID] = retry
-						}
+						readyRetries = append(readyRetries, symphonyPendingAdmission{candidate: candidate, retry: retry})
 					} else {
 						deferredRetry[candidate.ItemID] = retry
 					}
@@ -795,12 +1036,12 @@ func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot
 		if existing, ok := previousClaims[candidate.ItemID]; ok {
 			claims = append(claims, buildClaimWithoutRun(candidate, existing, now))
 			consumed[candidate.ItemID] = struct{}{}
+			concurrency.hold(candidate)
 			continue
 		}
 		if retry, ok := previousRetries[candidate.ItemID]; ok {
 			if retry.ReadyAt != nil && !retry.ReadyAt.Time.After(now) {
-				readyRetries[candidate.ItemID] = retry
-				readyRetryIDs = append(readyRetryIDs, candidate.ItemID)
+				readyRetries = append(readyRetries, symphonyPendingAdmission{candidate: candidate, retry: retry})
 			} else {
 				deferredRetry[candidate.ItemID] = retry
 			}
@@ -809,31 +1050,36 @@ func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot
 		freshCandidates = append(freshCandidates, candidate)
 	}
 
-	sort.Strings(readyRetryIDs)
-	for _, itemID := range readyRetryIDs {
-		retry, ok := readyRetries[itemID]
-		if !ok {
-			retry = previousRetries[itemID]
+	for _, pending := range readyRetries {
+		if _, ok := consumed[pending.candidate.ItemID]; ok {
+			continue
 		}
-		if len(claims) >= maxConcurrent {
-			deferredRetry[itemID] = retry
+		if skip, ok := budget.admit(pending.candidate, now); !ok {
+			deferredRetry[pending.candidate.ItemID] = pending.retry
+			heldBack = append(heldBack, skip)
 			continue
 		}
-		candidate, ok := findCandidate(snapshot.Candidates, itemID)
-		if !ok {
+		if skip, ok := concurrency.admit(pending.candidate, now); !ok {
+			deferredRetry[pending.candidate.ItemID] = pending.retry
+			heldBack = append(heldBack, skip)
 			continue
 		}
-		claims = append(claims, buildClaimFromRetry(candidate, retry, now))
-		consumed[itemID] = struct{}{}
+		claims = append(claims, buildClaimFromRetry(pending.candidate, pending.retry, now))
+		consumed[pending.candidate.ItemID] = struct{}{}
 	}
 
 	for _, candidate := range freshCandidates {
-		if len(claims) >= maxConcurrent {
-			break
-		}
 		if _, ok := consumed[candidate.ItemID]; ok {
 			continue
 		}
+		if skip, ok := budget.admit(candidate, now); !ok {
+			heldBack = append(heldBack, skip)
+			continue
+		}
+		if skip, ok := concurrency.admit(candidate, now); !ok {
+			heldBack = append(heldBack, skip)
+			continue
+		}
 		claims = append(claims, buildFreshClaim(candidate, now))
 		consumed[candidate.ItemID] = struct{}{}
 	}
@@ -848,15 +1094,39 @@ func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot
 	}
 
 	claims = truncateClaims(claims, limit)
+	for i := range claims {
+		claims[i].Rank = ranks[claims[i].ItemID]
+	}
+	for _, claim := range claims {
+		if previous, ok := previousClaims[claim.ItemID]; ok && previous.Attempt == claim.Attempt {
+			continue
+		}
+		transitions = append(transitions, symphonyItemTransition{
+			Kind:           symphonyTransitionClaimed,
+			ItemID:         claim.ItemID,
+			Issue:          claim.Issue,
+			Attempt:        claim.Attempt,
+			HarnessRunName: symphonyRunName(project, claim),
+		})
+	}
 	retries = truncateRetries(retries, retryLimit)
 	errors = truncateErrors(errors, retryLimit)
-	events = truncateEvents(events, retryLimit)
+	// Turn events follow every item's summary so one long run cannot push
+	// other items out of the list.
+	events = truncateEvents(append(events, turnEvents...), retryLimit)
 	project.Status.ActiveClaims = claims
 	project.Status.RetryQueue = retries
 	project.Status.RecentErrors = errors
 	project.Status.RecentEvents = events
 	project.Status.TokenTotals = totals
-	project.Status.RecentSkips = snapshotSkipsToStatus(snapshot.Skipped, int(project.Spec.Runtime.RecentSkipLimit))
+	budget.apply(project, snapshot)
+	// Held-back items lead RecentSkips: unlike source skips they are eligible
+	// and will be claimed as soon as a slot frees up, or, for budget_exceeded
+	// items, once the daily budget resets or the item leaves the board.
+	project.Status.RecentSkips = snapshotSkipsToStatus(append(heldBack, snapshot.Skipped...), int(project.Spec.Runtime.RecentSkipLimit))
+	for i := range project.Status.RecentSkips {
+		project.Status.RecentSkips[i].Rank = ranks[project.Status.RecentSkips[i].ItemID]
+	}
 	project.Status.UnsupportedRepos = append([]string(nil), snapshot.UnsupportedRepositories...)
 	project.Status.EligibleItems = int32(len(snapshot.Candidates))
 	project.Status.RunningItems = int32(len(claims))
@@ -864,6 +1134,7 @@ func reconcileProjectRuntime(project *operatorv1alpha1.SymphonyProject, snapshot
 	project.Status.CompletedItems = completed
 	project.Status.FailedItems = failed
 	project.Status.SkippedItems = int32(len(snapshot.Skipped))
+	return transitions
 }
 
 func buildClaimStatus(candidate githubsource.CandidateItem, run operatorv1alpha1.HarnessRun, previous operatorv1alpha1.SymphonyProjectClaimStatus, now time.Time) operatorv1alpha1.SymphonyProjectClaimStatus {
@@ -897,6 +1168,8 @@ func buildClaimWithoutRun(candidate githubsource.CandidateItem, previous operato
 		ClaimedAt:       claimedAt,
 		LastUpdatedTime: &lastUpdated,
 		RunRef:          previous.RunRef,
+		UsedTokens:      previous.UsedTokens,
+		UsedSeconds:     previous.UsedSeconds,
 	}
 }
 
@@ -950,9 +1223,56 @@ func buildEventStatus(candidate githubsource.CandidateItem, run operatorv1alpha1
 		Message:        strings.TrimSpace(run.Annotations[AnnotationSymphonyLastMessage]),
 		ObservedTime:   observedAt,
 		HarnessRunName: run.Name,
+		StopReason:     strings.TrimSpace(run.Annotations[AnnotationSymphonyStopReason]),
 	}, true
 }
 
+// symphonyTurnEventsPerRun caps the turn events one run contributes to
+// status.recentEvents.
+const symphonyTurnEventsPerRun = 5
+
+// buildTurnEventStatuses expands the run's newest per-turn results into one
+// event per turn, oldest first.
+func buildTurnEventStatuses(candidate githubsource.CandidateItem, run operatorv1alpha1.HarnessRun) []operatorv1alpha1.SymphonyProjectEventStatus {
+	raw := strings.TrimSpace(run.Annotations[AnnotationSymphonyTurns])
+	if raw == "" {
+		return nil
+	}
+	var turns []worker.TurnReport
+	if err := json.Unmarshal([]byte(raw), &turns); err != nil {
+		return nil
+	}
+	if len(turns) > symphonyTurnEventsPerRun {
+		turns = turns[len(turns)-symphonyTurnEventsPerRun:]
+	}
+	observedAt := run.Status.CompletionTime
+	if observedAt == nil {
+		observedAt = run.Status.StartTime
+	}
+	threadID := strings.TrimSpace(run.Annotations[AnnotationSymphonyThreadID])
+	out := make([]operatorv1alpha1.SymphonyProjectEventStatus, 0, len(turns))
+	for _, turn := range turns {
+		eventName := turn.Status
+		if eventName == "completed" {
+			eventName = runner.EventTurnCompleted
+		}
+		out = append(out, operatorv1alpha1.SymphonyProjectEventStatus{
+			ItemID:         candidate.ItemID,
+			Issue:          issueStatus(candidate.Issue),
+			SessionID:      sessionID(threadID, turn.TurnID),
+			ThreadID:       threadID,
+			TurnID:         turn.TurnID,
+			Event:          eventName,
+			Message:        sanitizeTelemetryMessage(turn.Message),
+			ObservedTime:   observedAt,
+			HarnessRunName: run.Name,
+			Turn:           int32(turn.Turn),
+			TotalTokens:    turn.TotalTokens,
+		})
+	}
+	return out
+}
+
 func buildContinuationRetryStatus(candidate githubsource.CandidateItem, previousClaim operatorv1alpha1.SymphonyProjectClaimStatus, previousRetry operatorv1alpha1.SymphonyProjectRetryStatus, now time.Time) (operatorv1alpha1.SymphonyProjectRetryStatus, bool) {
 	attempt := previousClaim.Attempt
 	if attempt <= 0 {
@@ -993,6 +1313,8 @@ func buildClaimFromRetry(candidate githubsource.CandidateItem, retry operatorv1a
 		Phase:           "Claimed",
 		ClaimedAt:       &claimedAt,
 		LastUpdatedTime: &lastUpdated,
+		UsedTokens:      retry.UsedTokens,
+		UsedSeconds:     retry.UsedSeconds,
 	}
 }
 
@@ -1189,15 +1511,6 @@ func mapRetriesByItem(items []operatorv1alpha1.SymphonyProjectRetryStatus) map[s
 	return mapped
 }
 
-func findCandidate(items []githubsource.CandidateItem, itemID string) (githubsource.CandidateItem, bool) {
-	for _, item := range items {
-		if item.ItemID == itemID {
-			return item, true
-		}
-	}
-	return githubsource.CandidateItem{}, false
-}
-
 func truncateClaims(items []operatorv1alpha1.SymphonyProjectClaimStatus, limit int) []operatorv1alpha1.SymphonyProjectClaimStatus {
 	if len(items) <= limit {
 		return items
@@ -1257,9 +1570,16 @@ func issueTemplateData(issue githubsource.Issue) map[string]any {
 	}
 }
 
-func enforceWorkflowSecurity(def workflow.Definition, cfg workflow.Config) error {
-	if strings.TrimSpace(cfg.Hooks.AfterCreate) != "" || strings.TrimSpace(cfg.Hooks.BeforeRun) != "" || strings.TrimSpace(cfg.Hooks.AfterRun) != "" || strings.TrimSpace(cfg.Hooks.BeforeRemove) != "" {
-		return &workflow.Error{Code: workflow.ErrCodeWorkflowValidationError, Path: def.Path, Err: fmt.Errorf("workflow hooks are disabled in kocao symphony workers")}
+// enforceWorkflowSecurity rejects workflow settings the worker cannot honour
+// safely. Hooks only ever run inside the claim's harness pod, so they need pod
+// worker mode; before_remove is always rejected because workspaces are
+// released without a harness pod to run it in.
+func enforceWorkflowSecurity(def workflow.Definition, cfg workflow.Config, mode operatorv1alpha1.SymphonyWorkerMode) error {
+	if strings.TrimSpace(cfg.Hooks.BeforeRemove) != "" {
+		return &workflow.Error{Code: workflow.ErrCodeWorkflowValidationError, Path: def.Path, Err: fmt.Errorf("workflow hook before_remove is not supported in kocao symphony workers")}
+	}
+	if mode != operatorv1alpha1.SymphonyWorkerModePod && (strings.TrimSpace(cfg.Hooks.AfterCreate) != "" || strings.TrimSpace(cfg.Hooks.BeforeRun) != "" || strings.TrimSpace(cfg.Hooks.AfterRun) != "") {
+		return &workflow.Error{Code: workflow.ErrCodeWorkflowValidationError, Path: def.Path, Err: fmt.Errorf("workflow hooks require runtime.workerMode=pod")}
 	}
 	return nil
 }
@@ -1311,6 +1631,10 @@ func symphonyErrorReason(err error) string {
 	if err == nil {
 		return ""
 	}
+	var hookErr *worker.HookError
+	if errors.As(err, &hookErr) {
+		return symphonyHookFailureReason(hookErr.Hook, hookErr.TimedOut)
+	}
 	var workflowErr *workflow.Error
 	if errors.As(err, &workflowErr) {
 		switch workflowErr.Code {
@@ -1325,6 +1649,22 @@ func symphonyErrorReason(err error) string {
 	return "WorkerExecutionFailed"
 }
 
+// symphonyHookFailureReason names a failed hook as a retry reason, e.g.
+// BeforeRunHookFailed or AfterCreateHookTimedOut.
+func symphonyHookFailureReason(hook string, timedOut bool) string {
+	var name strings.Builder
+	for _, part := range strings.Split(hook, "_") {
+		if part == "" {
+			continue
+		}
+		name.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	if timedOut {
+		return name.String() + "HookTimedOut"
+	}
+	return name.String() + "HookFailed"
+}
+
 func runAnnotationInt64(run operatorv1alpha1.HarnessRun, key string) int64 {
 	value := strings.TrimSpace(run.Annotations[key])
 	if value == "" {
@@ -1450,6 +1790,24 @@ func (r *SymphonyProjectReconciler) emitAuditEvents(ctx context.Context, origina
 		}
 		auditlog.AppendSymphony(ctx, r.Audit, "operator", "symphony.release", updated.Name, "allowed", map[string]any{"itemID": claim.ItemID, "repository": claim.Issue.Repository, "issueNumber": claim.Issue.Number, "phase": claim.Phase})
 	}
+	if budget := updated.Status.Budget; budget != nil {
+		before := original.Status.Budget
+		if budget.Exhausted && (before == nil || !before.Exhausted || before.Day != budget.Day) {
+			auditlog.AppendSymphony(ctx, r.Audit, "operator", "symphony.budget", updated.Name, "denied", map[string]any{"scope": "day", "day": budget.Day, "tokensToday": budget.TokensToday, "secondsToday": budget.SecondsToday})
+		}
+		exceededBefore := map[string]struct{}{}
+		if before != nil {
+			for _, item := range before.ExceededItems {
+				exceededBefore[item.ItemID] = struct{}{}
+			}
+		}
+		for _, item := range budget.ExceededItems {
+			if _, ok := exceededBefore[item.ItemID]; ok {
+				continue
+			}
+			auditlog.AppendSymphony(ctx, r.Audit, "operator", "symphony.budget", updated.Name, "denied", map[string]any{"scope": "item", "itemID": item.ItemID, "repository": item.Issue.Repository, "issueNumber": item.Issue.Number, "message": item.Message})
+		}
+	}
 }
 
 func (r *SymphonyProjectReconciler) SetupWithManager(mgr ctrl.Manager) error {
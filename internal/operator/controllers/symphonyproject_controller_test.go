@@ -8,6 +8,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"testing"
 	"time"
@@ -16,6 +17,7 @@ import (
 	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
 	"github.com/withakay/kocao/internal/symphony/githubsource"
 	"github.com/withakay/kocao/internal/symphony/runner"
+	"github.com/withakay/kocao/internal/symphony/worker"
 	"github.com/withakay/kocao/internal/symphony/workflow"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
@@ -33,7 +35,7 @@ type stubSymphonySourceFactory struct {
 	err    error
 }
 
-func (s stubSymphonySourceFactory) New(token string) (symphonySourceLoader, error) {
+func (s stubSymphonySourceFactory) New(_ operatorv1alpha1.SymphonyProjectSourceSpec, token string) (symphonySourceLoader, error) {
 	if s.err != nil {
 		return nil, s.err
 	}
@@ -54,6 +56,18 @@ func (s *stubSymphonySourceLoader) LoadProject(context.Context, githubsource.Loa
 	return s.snapshot, nil
 }
 
+// stubSymphonySourceWriter is a loader that also records tracker write-backs.
+type stubSymphonySourceWriter struct {
+	stubSymphonySourceLoader
+	requests []githubsource.WriteBackRequest
+	err      error
+}
+
+func (s *stubSymphonySourceWriter) WriteBack(_ context.Context, req githubsource.WriteBackRequest) error {
+	s.requests = append(s.requests, req)
+	return s.err
+}
+
 type stubWorkerExecutor struct {
 	result symphonyWorkerResult
 	err    error
@@ -341,6 +355,196 @@ func TestSymphonyProjectReconcile_WorkflowExecutionQueuesContinuationRetry(t *te
 	}
 }
 
+func TestSymphonyProjectReconcile_RecordsPerTurnEvents(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	project := newSymphonyProject("multi-turn")
+	project.Spec.Repositories[0].LocalPath = "/tmp/kocao"
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_test")}}
+	loader := &stubSymphonySourceLoader{snapshot: githubsource.Snapshot{ResolvedFieldName: "Status", Candidates: []githubsource.CandidateItem{{ItemID: "PVT_item_1", Issue: githubIssue("withakay/kocao", 503, "Multi turn")}}}}
+	executor := &stubWorkerExecutor{result: symphonyWorkerResult{
+		ThreadID:    "thread-1",
+		TurnID:      "turn-2",
+		LastEvent:   runner.EventTurnCompleted,
+		TotalTokens: 40,
+		StopReason:  runner.StopCompleted,
+		Turns: []worker.TurnReport{
+			{Turn: 1, TurnID: "turn-1", Status: "completed", Message: "opened a branch", TotalTokens: 25},
+			{Turn: 2, TurnID: "turn-2", Status: "completed", Message: "token=supersecret", TotalTokens: 40},
+		},
+	}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.SymphonyProject{}, &operatorv1alpha1.HarnessRun{}).WithObjects(project, secret).Build()
+	r := &SymphonyProjectReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(70, 0).UTC()), SourceFactory: stubSymphonySourceFactory{loader: loader}, WorkerExecutor: executor}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	var got operatorv1alpha1.SymphonyProject
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(project), &got); err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	events := got.Status.RecentEvents
+	if len(events) != 3 {
+		t.Fatalf("recent events = %#v, want summary plus two turns", events)
+	}
+	if events[0].Turn != 0 || events[0].StopReason != runner.StopCompleted {
+		t.Fatalf("summary event = %#v", events[0])
+	}
+	if events[1].Turn != 1 || events[1].TurnID != "turn-1" || events[1].Event != runner.EventTurnCompleted || events[1].SessionID != "thread-1-turn-1" || events[1].TotalTokens != 25 {
+		t.Fatalf("first turn event = %#v", events[1])
+	}
+	if events[2].Turn != 2 || events[2].Message != "[redacted]" {
+		t.Fatalf("second turn event = %#v", events[2])
+	}
+}
+
+func TestSymphonyProjectReconcile_CapsTurnEventsPerRunBehindSummaries(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	project := newSymphonyProject("long-runs")
+	project.Spec.Repositories[0].LocalPath = "/tmp/kocao"
+	project.Spec.Runtime.MaxConcurrentItems = 2
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_test")}}
+	loader := &stubSymphonySourceLoader{snapshot: githubsource.Snapshot{ResolvedFieldName: "Status", Candidates: []githubsource.CandidateItem{
+		{ItemID: "PVT_item_1", Issue: githubIssue("withakay/kocao", 511, "First")},
+		{ItemID: "PVT_item_2", Issue: githubIssue("withakay/kocao", 512, "Second")},
+	}}}
+	turns := make([]worker.TurnReport, 0, 20)
+	for i := 1; i <= 20; i++ {
+		turns = append(turns, worker.TurnReport{Turn: i, TurnID: fmt.Sprintf("turn-%d", i), Status: "completed"})
+	}
+	executor := &stubWorkerExecutor{result: symphonyWorkerResult{ThreadID: "thread-1", TurnID: "turn-20", LastEvent: runner.EventTurnCompleted, StopReason: runner.StopMaxTurns, Turns: turns}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.SymphonyProject{}, &operatorv1alpha1.HarnessRun{}).WithObjects(project, secret).Build()
+	r := &SymphonyProjectReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(70, 0).UTC()), SourceFactory: stubSymphonySourceFactory{loader: loader}, WorkerExecutor: executor}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	var got operatorv1alpha1.SymphonyProject
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(project), &got); err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	events := got.Status.RecentEvents
+	if len(events) != 2+2*symphonyTurnEventsPerRun {
+		t.Fatalf("recent events = %d, want two summaries plus %d turns each", len(events), symphonyTurnEventsPerRun)
+	}
+	if events[0].ItemID != "PVT_item_1" || events[0].Turn != 0 || events[1].ItemID != "PVT_item_2" || events[1].Turn != 0 {
+		t.Fatalf("summary events = %#v", events[:2])
+	}
+	if first := events[2]; first.ItemID != "PVT_item_1" || first.Turn != 20-symphonyTurnEventsPerRun+1 {
+		t.Fatalf("oldest kept turn event = %#v", first)
+	}
+	if last := events[len(events)-1]; last.ItemID != "PVT_item_2" || last.Turn != 20 {
+		t.Fatalf("newest turn event = %#v", last)
+	}
+}
+
+func TestSymphonyProjectReconcile_PodWorkerModeHandsRenderedTaskToHarnessPod(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	repoDir := t.TempDir()
+	workflowDoc := "---\ncodex:\n  command: codex app-server\n---\nFix {{.issue.title}} in {{.issue.repository}}."
+	if err := os.WriteFile(filepath.Join(repoDir, "WORKFLOW.md"), []byte(workflowDoc), 0o600); err != nil {
+		t.Fatalf("write workflow: %v", err)
+	}
+	project := newSymphonyProject("pod-worker")
+	project.Spec.Runtime.WorkerMode = operatorv1alpha1.SymphonyWorkerModePod
+	project.Spec.Repositories[0].LocalPath = repoDir
+	project.Spec.Runtime.Scheduling = &operatorv1alpha1.HarnessRunSchedulingSpec{RunClass: "large-build"}
+	project.Annotations = map[string]string{AnnotationOwner: "alice@example.com"}
+	project.Labels = map[string]string{LabelTeam: "platform"}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_test")}}
+	loader := &stubSymphonySourceLoader{snapshot: githubsource.Snapshot{ResolvedFieldName: "Status", Candidates: []githubsource.CandidateItem{{ItemID: "PVT_item_1", Issue: githubIssue("withakay/kocao", 601, "Pod worker")}}}}
+	executor := &stubWorkerExecutor{}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.SymphonyProject{}, &operatorv1alpha1.HarnessRun{}).WithObjects(project, secret).Build()
+	r := &SymphonyProjectReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(70, 0).UTC()), SourceFactory: stubSymphonySourceFactory{loader: loader}, WorkerExecutor: executor}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if executor.calls != 0 {
+		t.Fatalf("operator executor calls = %d, want 0 in pod mode", executor.calls)
+	}
+
+	var runList operatorv1alpha1.HarnessRunList
+	if err := cl.List(context.Background(), &runList, client.InNamespace(project.Namespace)); err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runList.Items) != 1 {
+		t.Fatalf("run count = %d, want 1", len(runList.Items))
+	}
+	run := runList.Items[0]
+	if len(run.Spec.Command) != 1 || run.Spec.Command[0] != symphonyWorkerCommand {
+		t.Fatalf("run command = %#v", run.Spec.Command)
+	}
+	if run.Spec.Scheduling == nil || run.Spec.Scheduling.RunClass != "large-build" || run.Annotations[AnnotationOwner] != "alice@example.com" || run.Labels[LabelTeam] != "platform" {
+		t.Fatalf("run scheduling = %#v, owner = %q, team = %q", run.Spec.Scheduling, run.Annotations[AnnotationOwner], run.Labels[LabelTeam])
+	}
+	if run.Status.Phase == operatorv1alpha1.HarnessRunPhaseSucceeded || run.Status.Phase == operatorv1alpha1.HarnessRunPhaseFailed {
+		t.Fatalf("run phase = %q, want the pod to own completion", run.Status.Phase)
+	}
+	configMapName := run.Annotations[AnnotationSymphonyTaskConfigMap]
+	if configMapName != symphonyTaskConfigMapName(run.Name) {
+		t.Fatalf("task configmap annotation = %q", configMapName)
+	}
+
+	var configMap corev1.ConfigMap
+	if err := cl.Get(context.Background(), client.ObjectKey{Namespace: project.Namespace, Name: configMapName}, &configMap); err != nil {
+		t.Fatalf("get task configmap: %v", err)
+	}
+	if len(configMap.OwnerReferences) != 1 || configMap.OwnerReferences[0].Name != run.Name {
+		t.Fatalf("task configmap owners = %#v", configMap.OwnerReferences)
+	}
+	var task struct {
+		Title  string               `json:"title"`
+		Prompt string               `json:"prompt"`
+		Codex  workflow.CodexConfig `json:"codex"`
+	}
+	if err := json.Unmarshal([]byte(configMap.Data["task.json"]), &task); err != nil {
+		t.Fatalf("decode task: %v", err)
+	}
+	if task.Prompt != "Fix Pod worker in withakay/kocao.\n\nWhen the work is complete, reply with SYMPHONY_DONE and nothing else." {
+		t.Fatalf("prompt = %q", task.Prompt)
+	}
+	if task.Codex.Command != "codex app-server" || task.Codex.ApprovalPolicy != defaultSymphonyApprovalPolicy || task.Codex.ThreadSandbox != defaultSymphonyThreadSandbox {
+		t.Fatalf("codex config = %#v", task.Codex)
+	}
+}
+
+func TestSymphonyProjectReconcile_PodWorkerModeFailsRunWhenWorkflowMissing(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	project := newSymphonyProject("pod-worker-missing")
+	project.Spec.Runtime.WorkerMode = operatorv1alpha1.SymphonyWorkerModePod
+	project.Spec.Repositories[0].LocalPath = t.TempDir()
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_test")}}
+	loader := &stubSymphonySourceLoader{snapshot: githubsource.Snapshot{ResolvedFieldName: "Status", Candidates: []githubsource.CandidateItem{{ItemID: "PVT_item_1", Issue: githubIssue("withakay/kocao", 602, "Missing workflow")}}}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.SymphonyProject{}, &operatorv1alpha1.HarnessRun{}).WithObjects(project, secret).Build()
+	r := &SymphonyProjectReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(70, 0).UTC()), SourceFactory: stubSymphonySourceFactory{loader: loader}, WorkerExecutor: &stubWorkerExecutor{}}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	var runList operatorv1alpha1.HarnessRunList
+	if err := cl.List(context.Background(), &runList, client.InNamespace(project.Namespace)); err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runList.Items) != 1 || runList.Items[0].Status.Phase != operatorv1alpha1.HarnessRunPhaseFailed {
+		t.Fatalf("runs = %#v", runList.Items)
+	}
+	if got := conditionReason(runList.Items[0].Status.Conditions, ConditionFailed); got != "WorkflowMissing" {
+		t.Fatalf("failed reason = %q, want WorkflowMissing", got)
+	}
+}
+
 func TestSymphonyProjectReconcile_WorkflowFailureBecomesRetryAndRecentError(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = operatorv1alpha1.AddToScheme(scheme)
@@ -377,6 +581,149 @@ func TestSymphonyProjectReconcile_WorkflowFailureBecomesRetryAndRecentError(t *t
 	}
 }
 
+func TestSymphonyProjectReconcile_WritesBackClaimAndSuccess(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	project := newSymphonyProject("writeback-success")
+	project.Spec.Source.ActiveStates = []string{"Todo", "In Progress"}
+	project.Spec.Repositories[0].LocalPath = "/tmp/kocao"
+	project.Spec.WriteBack = &operatorv1alpha1.SymphonyProjectWriteBackSpec{
+		Comment:    true,
+		RunURLBase: "https://kocao.example.com/",
+		OnClaim:    operatorv1alpha1.SymphonyProjectWriteBackActionSpec{Status: "In Progress", AddLabels: []string{"symphony"}},
+		OnSuccess:  operatorv1alpha1.SymphonyProjectWriteBackActionSpec{Status: "In Review", RemoveLabels: []string{"symphony"}},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_test")}}
+	writer := &stubSymphonySourceWriter{stubSymphonySourceLoader: stubSymphonySourceLoader{snapshot: githubsource.Snapshot{ProjectID: "PVT_project_1", ResolvedFieldName: "Status", Candidates: []githubsource.CandidateItem{{ItemID: "PVT_item_1", Issue: githubIssue("withakay/kocao", 701, "Write back")}}}}}
+	executor := &stubWorkerExecutor{result: symphonyWorkerResult{LastEvent: runner.EventTurnCompleted, InputTokens: 12, OutputTokens: 5, TotalTokens: 17}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.SymphonyProject{}, &operatorv1alpha1.HarnessRun{}).WithObjects(project, secret).Build()
+	audit := auditlog.New("", nil)
+	r := &SymphonyProjectReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(90, 0).UTC()), SourceFactory: stubSymphonySourceFactory{loader: writer}, WorkerExecutor: executor, Audit: audit}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(writer.requests) != 2 {
+		t.Fatalf("write-back requests = %#v, want claim and success", writer.requests)
+	}
+	claim, success := writer.requests[0], writer.requests[1]
+	if claim.Status != "In Progress" || claim.ProjectID != "PVT_project_1" || claim.ItemID != "PVT_item_1" || claim.IssueNodeID != "ISSUE_NODE" || claim.FieldName != "Status" || len(claim.AddLabels) != 1 {
+		t.Fatalf("claim write-back = %#v", claim)
+	}
+	if !strings.Contains(claim.CommentBody, "working on this issue") || !strings.Contains(claim.CommentBody, "(https://kocao.example.com/harness-runs/sym-writeback-success-withakay-kocao-701-a-1)") {
+		t.Fatalf("claim comment = %q", claim.CommentBody)
+	}
+	if success.Status != "In Review" || len(success.RemoveLabels) != 1 || success.CommentMarker != claim.CommentMarker {
+		t.Fatalf("success write-back = %#v", success)
+	}
+	if !strings.Contains(success.CommentBody, "finished this issue") || !strings.Contains(success.CommentBody, "Tokens: 17 total (12 input, 5 output)") {
+		t.Fatalf("success comment = %q", success.CommentBody)
+	}
+
+	var got operatorv1alpha1.SymphonyProject
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(project), &got); err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if conditionStatus(got.Status.Conditions, ConditionWriteBack) != metav1.ConditionTrue {
+		t.Fatalf("conditions = %#v", got.Status.Conditions)
+	}
+	if got.Status.ProjectNodeID != "PVT_project_1" {
+		t.Fatalf("projectNodeId = %q, want PVT_project_1", got.Status.ProjectNodeID)
+	}
+	events, err := audit.List(context.Background(), 20)
+	if err != nil {
+		t.Fatalf("list audit: %v", err)
+	}
+	writeBacks := 0
+	for _, event := range events {
+		if event.Action == "symphony.writeback" {
+			writeBacks++
+		}
+	}
+	if writeBacks != 2 {
+		t.Fatalf("write-back audit events = %d, want 2", writeBacks)
+	}
+
+	// The continuation retry is not ready yet, so nothing transitions again.
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+		t.Fatalf("second reconcile: %v", err)
+	}
+	if len(writer.requests) != 2 {
+		t.Fatalf("write-back requests after resync = %d, want 2", len(writer.requests))
+	}
+}
+
+func TestSymphonyProjectReconcile_WriteBackFailureDoesNotBlockOrchestration(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	project := newSymphonyProject("writeback-failure")
+	project.Spec.Repositories[0].LocalPath = "/tmp/kocao"
+	project.Spec.WriteBack = &operatorv1alpha1.SymphonyProjectWriteBackSpec{
+		OnFailure: operatorv1alpha1.SymphonyProjectWriteBackActionSpec{AddLabels: []string{"symphony:failed"}},
+	}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_test")}}
+	writer := &stubSymphonySourceWriter{
+		stubSymphonySourceLoader: stubSymphonySourceLoader{snapshot: githubsource.Snapshot{ProjectID: "PVT_project_1", ResolvedFieldName: "Status", Candidates: []githubsource.CandidateItem{{ItemID: "PVT_item_1", Issue: githubIssue("withakay/kocao", 702, "Write back failure")}}}},
+		err:                      errors.New("label \"symphony:failed\" does not exist"),
+	}
+	executor := &stubWorkerExecutor{err: errors.New("agent crashed")}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.SymphonyProject{}, &operatorv1alpha1.HarnessRun{}).WithObjects(project, secret).Build()
+	r := &SymphonyProjectReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(95, 0).UTC()), SourceFactory: stubSymphonySourceFactory{loader: writer}, WorkerExecutor: executor}
+
+	if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	// The claim has no configured action, so only the failure is written back.
+	if len(writer.requests) != 1 || writer.requests[0].AddLabels[0] != "symphony:failed" {
+		t.Fatalf("write-back requests = %#v", writer.requests)
+	}
+
+	var got operatorv1alpha1.SymphonyProject
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(project), &got); err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if got.Status.Phase != operatorv1alpha1.SymphonyProjectPhaseReady || len(got.Status.RetryQueue) != 1 {
+		t.Fatalf("status = %#v", got.Status)
+	}
+	if conditionStatus(got.Status.Conditions, ConditionWriteBack) != metav1.ConditionFalse {
+		t.Fatalf("conditions = %#v", got.Status.Conditions)
+	}
+}
+
+func TestDefaultSymphonySourceFactorySelectsSourceKind(t *testing.T) {
+	factory := defaultSymphonySourceFactory{}
+	loader, err := factory.New(operatorv1alpha1.SymphonyProjectSourceSpec{}, "github-token")
+	if err != nil {
+		t.Fatalf("github source: %v", err)
+	}
+	if _, ok := loader.(*githubsource.Client); !ok {
+		t.Fatalf("default source loader = %T, want *githubsource.Client", loader)
+	}
+
+	loader, err = factory.New(operatorv1alpha1.SymphonyProjectSourceSpec{
+		Kind:   operatorv1alpha1.SymphonySourceKindLinear,
+		Linear: &operatorv1alpha1.LinearProjectRef{TeamKey: "ENG"},
+	}, "lin_api_key")
+	if err != nil {
+		t.Fatalf("linear source: %v", err)
+	}
+	linear, ok := loader.(linearSymphonySource)
+	if !ok || linear.project.TeamKey != "ENG" {
+		t.Fatalf("linear source loader = %#v", loader)
+	}
+
+	if _, err := factory.New(operatorv1alpha1.SymphonyProjectSourceSpec{Kind: operatorv1alpha1.SymphonySourceKindLinear}, "lin_api_key"); err == nil {
+		t.Fatal("expected linear source without a team reference to fail")
+	}
+	if _, err := factory.New(operatorv1alpha1.SymphonyProjectSourceSpec{Kind: "jira"}, "token"); err == nil {
+		t.Fatal("expected unknown source kind to fail")
+	}
+}
+
 func TestSecureCodexConfigAppliesDefaults(t *testing.T) {
 	cfg := secureCodexConfig(workflow.CodexConfig{})
 	if cfg.ApprovalPolicy != defaultSymphonyApprovalPolicy {
@@ -391,7 +738,14 @@ func TestSecureCodexConfigAppliesDefaults(t *testing.T) {
 }
 
 func TestEnforceWorkflowSecurityRejectsHooks(t *testing.T) {
-	err := enforceWorkflowSecurity(workflow.Definition{Path: "/tmp/WORKFLOW.md"}, workflow.Config{Hooks: workflow.HooksConfig{BeforeRun: "echo hi"}})
+	def := workflow.Definition{Path: "/tmp/WORKFLOW.md"}
+	if err := enforceWorkflowSecurity(def, workflow.Config{Hooks: workflow.HooksConfig{BeforeRun: "echo hi"}}, operatorv1alpha1.SymphonyWorkerModePod); err != nil {
+		t.Fatalf("expected pod mode to allow before_run, got %v", err)
+	}
+	if err := enforceWorkflowSecurity(def, workflow.Config{Hooks: workflow.HooksConfig{BeforeRemove: "echo bye"}}, operatorv1alpha1.SymphonyWorkerModePod); err == nil {
+		t.Fatal("expected before_remove to be rejected in pod mode")
+	}
+	err := enforceWorkflowSecurity(def, workflow.Config{Hooks: workflow.HooksConfig{BeforeRun: "echo hi"}}, operatorv1alpha1.SymphonyWorkerModeOperator)
 	if err == nil {
 		t.Fatal("expected workflow hook security error")
 	}
@@ -404,6 +758,21 @@ func TestEnforceWorkflowSecurityRejectsHooks(t *testing.T) {
 	}
 }
 
+func TestSymphonyErrorReasonNamesHookFailures(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want string
+	}{
+		{err: &worker.HookError{Hook: worker.HookBeforeRun, ExitCode: 2}, want: "BeforeRunHookFailed"},
+		{err: fmt.Errorf("setup: %w", &worker.HookError{Hook: worker.HookAfterCreate, ExitCode: -1, TimedOut: true}), want: "AfterCreateHookTimedOut"},
+		{err: errors.New("boom"), want: "WorkerExecutionFailed"},
+	} {
+		if got := symphonyErrorReason(tc.err); got != tc.want {
+			t.Fatalf("symphonyErrorReason(%v) = %q, want %q", tc.err, got, tc.want)
+		}
+	}
+}
+
 func TestSanitizeTelemetryMessageRedactsSecrets(t *testing.T) {
 	message := sanitizeTelemetryMessage("authorization=Bearer secret-token token=abc123 github_pat_deadbeef")
 	if strings.Contains(message, "secret-token") || strings.Contains(message, "abc123") || strings.Contains(message, "github_pat_deadbeef") {
@@ -622,6 +991,114 @@ func TestSymphonyProjectReconcile_ReleasesActiveRunWhenItemLeavesBoard(t *testin
 	}
 }
 
+func TestSymphonyProjectReconcile_PlanReportsSyncWithoutCreatingObjects(t *testing.T) {
+	scheme := runtime.NewScheme()
+	_ = operatorv1alpha1.AddToScheme(scheme)
+	_ = corev1.AddToScheme(scheme)
+
+	repoDir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repoDir, "WORKFLOW.md"), []byte("---\ncodex:\n  command: codex app-server\n---\nFix {{.issue.title}} (attempt {{.attempt}})."), 0o600); err != nil {
+		t.Fatalf("write workflow: %v", err)
+	}
+	project := newSymphonyProject("plan")
+	project.Spec.Paused = true
+	project.Spec.Runtime.MaxConcurrentItems = 2
+	project.Spec.Repositories[0].LocalPath = repoDir
+	project.Annotations = map[string]string{AnnotationSymphonyPlanRequestedAt: "2026-01-02T03:04:05Z"}
+	readyAt := metav1.NewTime(time.Unix(10, 0).UTC())
+	project.Status.RetryQueue = []operatorv1alpha1.SymphonyProjectRetryStatus{{
+		ItemID:  "PVT_item_3",
+		Issue:   operatorv1alpha1.SymphonyProjectIssueRefStatus{Repository: "withakay/kocao", Number: 703, Title: "Retry me"},
+		Attempt: 1,
+		Reason:  "WorkflowExecutionFailed",
+		ReadyAt: &readyAt,
+	}}
+	secret := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "github-token", Namespace: "default"}, Data: map[string][]byte{"token": []byte("ghp_test")}}
+	run := &operatorv1alpha1.HarnessRun{
+		TypeMeta: metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "HarnessRun"},
+		ObjectMeta: metav1.ObjectMeta{Name: "leaving-run", Namespace: "default", Labels: map[string]string{
+			LabelSymphonyProjectName: project.Name,
+			LabelSymphonyProjectUID:  string(project.UID),
+			LabelSymphonyItemID:      "PVT_item_1",
+			LabelGitHubRepository:    "withakay-kocao",
+			LabelGitHubIssueNumber:   "701",
+		}},
+		Spec:   operatorv1alpha1.HarnessRunSpec{RepoURL: "https://github.com/withakay/kocao", Image: "ghcr.io/withakay/kocao-harness:latest"},
+		Status: operatorv1alpha1.HarnessRunStatus{Phase: operatorv1alpha1.HarnessRunPhaseRunning},
+	}
+	loader := &stubSymphonySourceLoader{snapshot: githubsource.Snapshot{
+		ResolvedFieldName: "Status",
+		Candidates: []githubsource.CandidateItem{
+			{ItemID: "PVT_item_2", Issue: githubIssue("withakay/kocao", 702, "Fresh")},
+			{ItemID: "PVT_item_3", Issue: githubIssue("withakay/kocao", 703, "Retry me")},
+			{ItemID: "PVT_item_4", Issue: githubIssue("withakay/kocao", 704, "Later")},
+		},
+		Skipped: []githubsource.SkippedItem{{ItemID: "PVT_item_9", Reason: githubsource.SkipReasonUnsupportedRepository, Message: "repo not allowed", Repository: "someone/else", ObservedAt: time.Unix(100, 0).UTC()}},
+	}}
+	cl := fake.NewClientBuilder().WithScheme(scheme).WithStatusSubresource(&operatorv1alpha1.SymphonyProject{}, &operatorv1alpha1.HarnessRun{}).WithObjects(project, secret, run).Build()
+	r := &SymphonyProjectReconciler{Client: cl, Scheme: scheme, Clock: clocktesting.NewFakeClock(time.Unix(100, 0).UTC()), SourceFactory: stubSymphonySourceFactory{loader: loader}}
+
+	for i := 0; i < 2; i++ {
+		if _, err := r.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKeyFromObject(project)}); err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+	}
+	if loader.loads != 1 {
+		t.Fatalf("loads = %d, want 1 for one plan request", loader.loads)
+	}
+
+	var got operatorv1alpha1.SymphonyProject
+	if err := cl.Get(context.Background(), client.ObjectKeyFromObject(project), &got); err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	if got.Status.Phase != operatorv1alpha1.SymphonyProjectPhasePaused || len(got.Status.ActiveClaims) != 0 || len(got.Status.RetryQueue) != 1 {
+		t.Fatalf("status changed beyond the plan: %#v", got.Status)
+	}
+	plan := got.Status.Plan
+	if plan == nil || plan.RequestedAt != "2026-01-02T03:04:05Z" || plan.Error != "" || plan.EligibleItems != 3 {
+		t.Fatalf("plan = %#v", plan)
+	}
+	want := []struct {
+		action  operatorv1alpha1.SymphonyPlanAction
+		itemID  string
+		attempt int32
+		reason  string
+		prompt  string
+	}{
+		{action: operatorv1alpha1.SymphonyPlanActionRelease, itemID: "PVT_item_1"},
+		{action: operatorv1alpha1.SymphonyPlanActionRetry, itemID: "PVT_item_3", attempt: 2, prompt: "Fix Retry me (attempt 2).\n\nWhen the work is complete, reply with SYMPHONY_DONE and nothing else."},
+		{action: operatorv1alpha1.SymphonyPlanActionClaim, itemID: "PVT_item_2", attempt: 1, prompt: "Fix Fresh (attempt 1).\n\nWhen the work is complete, reply with SYMPHONY_DONE and nothing else."},
+		{action: operatorv1alpha1.SymphonyPlanActionSkip, itemID: "PVT_item_4", reason: SkipReasonConcurrencyLimit},
+		{action: operatorv1alpha1.SymphonyPlanActionSkip, itemID: "PVT_item_9", reason: githubsource.SkipReasonUnsupportedRepository},
+	}
+	if len(plan.Items) != len(want) {
+		t.Fatalf("plan items = %#v", plan.Items)
+	}
+	for i, item := range plan.Items {
+		if item.Action != want[i].action || item.ItemID != want[i].itemID || item.Attempt != want[i].attempt || item.Reason != want[i].reason || item.Prompt != want[i].prompt || item.PromptError != "" {
+			t.Fatalf("plan item %d = %#v, want %+v", i, item, want[i])
+		}
+	}
+	if plan.Items[0].Issue.Number != 701 || plan.Items[0].HarnessRunName != "leaving-run" {
+		t.Fatalf("release item = %#v", plan.Items[0])
+	}
+
+	var runs operatorv1alpha1.HarnessRunList
+	if err := cl.List(context.Background(), &runs, client.InNamespace(project.Namespace)); err != nil {
+		t.Fatalf("list runs: %v", err)
+	}
+	if len(runs.Items) != 1 || runs.Items[0].Name != "leaving-run" {
+		t.Fatalf("runs = %#v, want only the pre-existing run", runs.Items)
+	}
+	var sessions operatorv1alpha1.SessionList
+	if err := cl.List(context.Background(), &sessions, client.InNamespace(project.Namespace)); err != nil {
+		t.Fatalf("list sessions: %v", err)
+	}
+	if len(sessions.Items) != 0 {
+		t.Fatalf("sessions = %#v, want none", sessions.Items)
+	}
+}
+
 func TestSymphonyProjectReconcile_SourceFailuresSurfaceErrorStatus(t *testing.T) {
 	scheme := runtime.NewScheme()
 	_ = operatorv1alpha1.AddToScheme(scheme)
@@ -653,6 +1130,126 @@ func TestSymphonyProjectReconcile_SourceFailuresSurfaceErrorStatus(t *testing.T)
 	}
 }
 
+func TestReconcileProjectRuntime_ConcurrencyCapsHoldBackItems(t *testing.T) {
+	repoDir := t.TempDir()
+	workflowDoc := "---\nagent:\n  max_concurrent_agents_by_state:\n    review: 1\n---\nFix {{.issue.title}}."
+	if err := os.WriteFile(filepath.Join(repoDir, "WORKFLOW.md"), []byte(workflowDoc), 0o600); err != nil {
+		t.Fatalf("write workflow: %v", err)
+	}
+	project := newSymphonyProject("caps")
+	project.Spec.Runtime.MaxConcurrentItems = 10
+	project.Spec.Runtime.MaxConcurrentItemsByStatus = map[string]int32{"In Progress": 1}
+	project.Spec.Repositories = []operatorv1alpha1.SymphonyProjectRepositorySpec{
+		{Owner: "withakay", Name: "kocao", LocalPath: repoDir},
+		{Owner: "withakay", Name: "other", MaxConcurrentItems: 1},
+	}
+	snapshot := githubsource.Snapshot{Candidates: []githubsource.CandidateItem{
+		{ItemID: "item-1", Status: "In progress", Issue: githubIssue("withakay/kocao", 1, "one")},
+		{ItemID: "item-2", Status: "In Progress", Issue: githubIssue("withakay/kocao", 2, "two")},
+		{ItemID: "item-3", Status: "Todo", Issue: githubIssue("withakay/other", 3, "three")},
+		{ItemID: "item-4", Status: "Todo", Issue: githubIssue("withakay/other", 4, "four")},
+		{ItemID: "item-5", Status: "Review", Issue: githubIssue("withakay/kocao", 5, "five")},
+		{ItemID: "item-6", Status: "Review", Issue: githubIssue("withakay/kocao", 6, "six")},
+		{ItemID: "item-7", Status: "Review", Issue: githubIssue("withakay/other", 7, "seven")},
+	}}
+
+	reconcileProjectRuntime(project, snapshot, map[string]operatorv1alpha1.HarnessRun{}, time.Unix(100, 0))
+
+	var claimed []string
+	for _, claim := range project.Status.ActiveClaims {
+		claimed = append(claimed, claim.ItemID)
+	}
+	if strings.Join(claimed, ",") != "item-1,item-3,item-5" {
+		t.Fatalf("claimed = %v, want item-1,item-3,item-5", claimed)
+	}
+	skips := map[string]string{}
+	for _, skip := range project.Status.RecentSkips {
+		skips[skip.ItemID] = skip.Reason
+	}
+	want := map[string]string{
+		"item-2": SkipReasonStatusConcurrencyLimit,
+		"item-4": SkipReasonRepositoryConcurrencyLimit,
+		"item-6": SkipReasonStatusConcurrencyLimit,
+		"item-7": SkipReasonRepositoryConcurrencyLimit,
+	}
+	if len(skips) != len(want) {
+		t.Fatalf("skips = %v, want %v", skips, want)
+	}
+	for itemID, reason := range want {
+		if skips[itemID] != reason {
+			t.Fatalf("skip reason for %s = %q, want %q (skips=%v)", itemID, skips[itemID], reason, skips)
+		}
+	}
+
+	project.Spec.Runtime.MaxConcurrentItems = 2
+	project.Status = operatorv1alpha1.SymphonyProjectStatus{}
+	reconcileProjectRuntime(project, snapshot, map[string]operatorv1alpha1.HarnessRun{}, time.Unix(100, 0))
+	if len(project.Status.ActiveClaims) != 2 || project.Status.RecentSkips[0].Reason != SkipReasonStatusConcurrencyLimit {
+		t.Fatalf("claims = %+v skips = %+v", project.Status.ActiveClaims, project.Status.RecentSkips)
+	}
+	last := project.Status.RecentSkips[len(project.Status.RecentSkips)-1]
+	if last.ItemID != "item-7" || last.Reason != SkipReasonConcurrencyLimit || !strings.Contains(last.Message, "2 of 2 project slots") {
+		t.Fatalf("last skip = %+v", last)
+	}
+}
+
+func TestReconcileProjectRuntime_RanksCandidatesByPriority(t *testing.T) {
+	number := func(v float64) *float64 { return &v }
+	issue := func(number int64, age time.Duration, labels ...string) githubsource.Issue {
+		out := githubIssue("withakay/kocao", number, "issue")
+		out.CreatedAt = time.Unix(1000, 0).Add(-age)
+		out.Labels = labels
+		return out
+	}
+	project := newSymphonyProject("ranked")
+	project.Spec.Runtime.MaxConcurrentItems = 2
+	project.Spec.Priority = &operatorv1alpha1.SymphonyProjectPrioritySpec{
+		Pinned:       []string{"withakay/kocao#6"},
+		FieldName:    "Priority",
+		FieldOrder:   []string{"Urgent", "High"},
+		LabelWeights: map[string]int32{"customer": 10, "chore": -5},
+		Age:          operatorv1alpha1.SymphonyPriorityAgeOldestFirst,
+	}
+	snapshot := githubsource.Snapshot{Candidates: []githubsource.CandidateItem{
+		{ItemID: "item-1", Status: "Todo", Issue: issue(1, time.Hour)},
+		{ItemID: "item-2", Status: "Todo", Issue: issue(2, time.Hour), Priority: githubsource.FieldValue{Name: "High"}},
+		{ItemID: "item-3", Status: "Todo", Issue: issue(3, time.Hour, "chore"), Priority: githubsource.FieldValue{Name: "Urgent"}},
+		{ItemID: "item-4", Status: "Todo", Issue: issue(4, time.Hour, "customer"), Priority: githubsource.FieldValue{Name: "Urgent"}},
+		{ItemID: "item-5", Status: "Todo", Issue: issue(5, 2*time.Hour)},
+		{ItemID: "item-6", Status: "Todo", Issue: issue(6, time.Minute)},
+	}}
+
+	ordered, ranks := rankSymphonyCandidates(project.Spec.Priority, snapshot.Candidates)
+	var got []string
+	for _, candidate := range ordered {
+		got = append(got, candidate.ItemID)
+	}
+	if strings.Join(got, ",") != "item-6,item-4,item-3,item-2,item-5,item-1" {
+		t.Fatalf("order = %v", got)
+	}
+	if ranks["item-6"] != 1 || ranks["item-1"] != 6 {
+		t.Fatalf("ranks = %v", ranks)
+	}
+
+	reconcileProjectRuntime(project, snapshot, map[string]operatorv1alpha1.HarnessRun{}, time.Unix(1000, 0))
+	if len(project.Status.ActiveClaims) != 2 || project.Status.ActiveClaims[0].ItemID != "item-6" || project.Status.ActiveClaims[1].Rank != 2 {
+		t.Fatalf("claims = %+v", project.Status.ActiveClaims)
+	}
+	if first := project.Status.RecentSkips[0]; first.ItemID != "item-3" || first.Rank != 3 || first.Reason != SkipReasonConcurrencyLimit {
+		t.Fatalf("first skip = %+v", first)
+	}
+
+	numbered := &operatorv1alpha1.SymphonyProjectPrioritySpec{FieldName: "Priority", HighestNumberFirst: true}
+	ordered, _ = rankSymphonyCandidates(numbered, []githubsource.CandidateItem{
+		{ItemID: "low", Priority: githubsource.FieldValue{Number: number(1)}},
+		{ItemID: "none"},
+		{ItemID: "high", Priority: githubsource.FieldValue{Number: number(8)}},
+	})
+	if ordered[0].ItemID != "high" || ordered[1].ItemID != "low" || ordered[2].ItemID != "none" {
+		t.Fatalf("number order = %+v", ordered)
+	}
+}
+
 func newSymphonyProject(name string) *operatorv1alpha1.SymphonyProject {
 	return &operatorv1alpha1.SymphonyProject{
 		TypeMeta:   metav1.TypeMeta{APIVersion: operatorv1alpha1.GroupVersion.String(), Kind: "SymphonyProject"},
@@ -686,3 +1283,129 @@ func conditionStatus(conditions []metav1.Condition, conditionType string) metav1
 	}
 	return metav1.ConditionUnknown
 }
+
+func TestReconcileProjectRuntime_BudgetsFailItemsAndHoldBackClaims(t *testing.T) {
+	project := newSymphonyProject("budgets")
+	project.Spec.Runtime.MaxConcurrentItems = 10
+	project.Spec.Runtime.Budgets = operatorv1alpha1.SymphonyProjectBudgetSpec{MaxTokensPerItem: 1000, MaxTokensPerDay: 1500}
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	claimed := metav1.NewTime(now.Add(-time.Hour))
+	claim := func(itemID string, number int64, usedTokens int64) operatorv1alpha1.SymphonyProjectClaimStatus {
+		return operatorv1alpha1.SymphonyProjectClaimStatus{ItemID: itemID, Issue: operatorv1alpha1.SymphonyProjectIssueRefStatus{Repository: "withakay/kocao", Number: number}, Attempt: 2, Phase: "Running", ClaimedAt: &claimed, UsedTokens: usedTokens}
+	}
+	project.Status.ActiveClaims = []operatorv1alpha1.SymphonyProjectClaimStatus{claim("item-1", 1, 700), claim("item-2", 2, 100)}
+	project.Status.Budget = &operatorv1alpha1.SymphonyProjectBudgetStatus{Day: "2026-03-01", TokensToday: 9000}
+	finishedRun := func(claim operatorv1alpha1.SymphonyProjectClaimStatus, tokens string) operatorv1alpha1.HarnessRun {
+		return operatorv1alpha1.HarnessRun{
+			ObjectMeta: metav1.ObjectMeta{Name: symphonyRunName(project, claim), Annotations: map[string]string{AnnotationSymphonyTotalTokens: tokens}},
+			Status:     operatorv1alpha1.HarnessRunStatus{Phase: operatorv1alpha1.HarnessRunPhaseSucceeded},
+		}
+	}
+	runs := map[string]operatorv1alpha1.HarnessRun{
+		"item-1": finishedRun(project.Status.ActiveClaims[0], "400"),
+		"item-2": finishedRun(project.Status.ActiveClaims[1], "200"),
+	}
+	snapshot := githubsource.Snapshot{Candidates: []githubsource.CandidateItem{
+		{ItemID: "item-1", Status: "Todo", Issue: githubIssue("withakay/kocao", 1, "one")},
+		{ItemID: "item-2", Status: "Todo", Issue: githubIssue("withakay/kocao", 2, "two")},
+		{ItemID: "item-3", Status: "Todo", Issue: githubIssue("withakay/kocao", 3, "three")},
+	}}
+
+	transitions := reconcileProjectRuntime(project, snapshot, runs, now)
+
+	budget := project.Status.Budget
+	if budget == nil || budget.Day != "2026-03-02" || budget.TokensToday != 600 || budget.RemainingTokensToday == nil || *budget.RemainingTokensToday != 900 || budget.Exhausted {
+		t.Fatalf("budget = %+v", budget)
+	}
+	if len(budget.ExceededItems) != 1 || budget.ExceededItems[0].ItemID != "item-1" || !strings.Contains(budget.ExceededItems[0].Message, "1100 tokens of its 1000 token budget") {
+		t.Fatalf("exceeded items = %+v", budget.ExceededItems)
+	}
+	if project.Status.FailedItems != 1 || project.Status.CompletedItems != 1 || len(project.Status.RecentErrors) != 1 || project.Status.RecentErrors[0].Reason != SkipReasonBudgetExceeded {
+		t.Fatalf("failed=%d completed=%d errors=%+v", project.Status.FailedItems, project.Status.CompletedItems, project.Status.RecentErrors)
+	}
+	if len(transitions) == 0 || transitions[0].Kind != symphonyTransitionFailed || transitions[0].Reason != SkipReasonBudgetExceeded {
+		t.Fatalf("transitions = %+v", transitions)
+	}
+	if len(project.Status.RetryQueue) != 1 || project.Status.RetryQueue[0].ItemID != "item-2" || project.Status.RetryQueue[0].UsedTokens != 300 {
+		t.Fatalf("retry queue = %+v", project.Status.RetryQueue)
+	}
+	if len(project.Status.ActiveClaims) != 1 || project.Status.ActiveClaims[0].ItemID != "item-3" {
+		t.Fatalf("claims = %+v", project.Status.ActiveClaims)
+	}
+	if tokens, _ := symphonyAttemptBudget(project, project.Status.ActiveClaims[0]); tokens != 900 {
+		t.Fatalf("attempt token budget = %d, want 900", tokens)
+	}
+	if len(project.Status.RecentSkips) == 0 || project.Status.RecentSkips[0].ItemID != "item-1" || project.Status.RecentSkips[0].Reason != SkipReasonBudgetExceeded {
+		t.Fatalf("skips = %+v", project.Status.RecentSkips)
+	}
+
+	// The next attempt of item-2 reaches its item budget and brings the day
+	// over its budget: item-2 fails and item-3 is held back.
+	retry := project.Status.RetryQueue[0]
+	next := buildClaimFromRetry(snapshot.Candidates[1], retry, now)
+	project.Status.ActiveClaims = []operatorv1alpha1.SymphonyProjectClaimStatus{next}
+	project.Status.RetryQueue = nil
+	runs = map[string]operatorv1alpha1.HarnessRun{"item-2": {
+		ObjectMeta: metav1.ObjectMeta{Name: symphonyRunName(project, next), Annotations: map[string]string{AnnotationSymphonyTotalTokens: "950"}},
+		Status:     operatorv1alpha1.HarnessRunStatus{Phase: operatorv1alpha1.HarnessRunPhaseFailed},
+	}}
+	snapshot.Candidates = snapshot.Candidates[1:]
+
+	reconcileProjectRuntime(project, snapshot, runs, now.Add(time.Hour))
+
+	budget = project.Status.Budget
+	if !budget.Exhausted || *budget.RemainingTokensToday != 0 || budget.TokensToday != 1550 || len(budget.ExceededItems) != 1 || budget.ExceededItems[0].ItemID != "item-2" {
+		t.Fatalf("budget = %+v", budget)
+	}
+	if len(project.Status.ActiveClaims) != 0 {
+		t.Fatalf("claims = %+v", project.Status.ActiveClaims)
+	}
+	if len(project.Status.RecentSkips) < 2 || project.Status.RecentSkips[1].ItemID != "item-3" || !strings.Contains(project.Status.RecentSkips[1].Message, "1550 of 1500 daily tokens used") {
+		t.Fatalf("skips = %+v", project.Status.RecentSkips)
+	}
+}
+
+func TestReconcileProjectRuntime_PullRequestMergeSettlesItem(t *testing.T) {
+	project := newSymphonyProject("pull-requests")
+	project.Spec.Runtime.MaxConcurrentItems = 10
+	project.Spec.PullRequests = &operatorv1alpha1.SymphonyProjectPullRequestSpec{Draft: true}
+	now := time.Date(2026, 3, 2, 12, 0, 0, 0, time.UTC)
+	claimed := metav1.NewTime(now.Add(-time.Hour))
+	repo := project.Spec.Repositories[0]
+	runs := map[string]operatorv1alpha1.HarnessRun{}
+	snapshot := githubsource.Snapshot{}
+	for i, status := range []string{githubsource.PullRequestOpen, githubsource.PullRequestMerged, githubsource.PullRequestClosed} {
+		itemID := "item-" + strconv.Itoa(i+1)
+		claim := operatorv1alpha1.SymphonyProjectClaimStatus{ItemID: itemID, Issue: operatorv1alpha1.SymphonyProjectIssueRefStatus{Repository: "withakay/kocao", Number: int64(i + 1), Title: status}, Attempt: 1, Phase: "Running", ClaimedAt: &claimed}
+		project.Status.ActiveClaims = append(project.Status.ActiveClaims, claim)
+		runs[itemID] = operatorv1alpha1.HarnessRun{
+			ObjectMeta: metav1.ObjectMeta{Name: symphonyRunName(project, claim), Annotations: map[string]string{
+				AnnotationPullRequestStatus: status,
+				AnnotationPullRequestURL:    "https://github.com/withakay/kocao/pull/" + strconv.Itoa(i+10),
+			}},
+			Spec:   operatorv1alpha1.HarnessRunSpec{PullRequest: symphonyPullRequestSpec(project, repo, claim)},
+			Status: operatorv1alpha1.HarnessRunStatus{Phase: operatorv1alpha1.HarnessRunPhaseSucceeded},
+		}
+		snapshot.Candidates = append(snapshot.Candidates, githubsource.CandidateItem{ItemID: itemID, Status: "Todo", Issue: githubIssue("withakay/kocao", int64(i+1), status)})
+	}
+
+	pr := runs["item-1"].Spec.PullRequest
+	if pr.Branch != "symphony/kocao-1" || pr.Title != "open (withakay/kocao#1)" || !strings.HasPrefix(pr.Body, "Closes withakay/kocao#1") || !pr.Draft {
+		t.Fatalf("pull request spec = %+v", pr)
+	}
+
+	transitions := reconcileProjectRuntime(project, snapshot, runs, now)
+
+	if project.Status.CompletedItems != 3 || len(transitions) != 3 {
+		t.Fatalf("completed=%d transitions=%+v", project.Status.CompletedItems, transitions)
+	}
+	if len(project.Status.RetryQueue) != 1 || project.Status.RetryQueue[0].ItemID != "item-3" || project.Status.RetryQueue[0].Reason != "Continuation" {
+		t.Fatalf("retry queue = %+v", project.Status.RetryQueue)
+	}
+	if len(project.Status.ActiveClaims) != 0 {
+		t.Fatalf("claims = %+v", project.Status.ActiveClaims)
+	}
+	if len(project.Status.RecentSkips) == 0 || project.Status.RecentSkips[0].ItemID != "item-1" || project.Status.RecentSkips[0].Reason != SkipReasonPullRequestOpen || !strings.Contains(project.Status.RecentSkips[0].Message, "/pull/10") {
+		t.Fatalf("skips = %+v", project.Status.RecentSkips)
+	}
+}
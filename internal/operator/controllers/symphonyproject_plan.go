@@ -0,0 +1,186 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// maxSymphonyPlanPromptBytes bounds each rendered prompt kept in a plan so a
+// plan with many claims stays well inside the object size limit.
+const maxSymphonyPlanPromptBytes = 8 << 10
+
+// planProject runs a sync against a copy of project and reports what it would
+// do. It reads the source and the project's runs but creates, deletes and
+// writes back nothing. Failures are reported in the plan, not returned.
+func (r *SymphonyProjectReconciler) planProject(ctx context.Context, project *operatorv1alpha1.SymphonyProject, sourceFactory symphonySourceFactory, requestedAt string, now metav1.Time) *operatorv1alpha1.SymphonyProjectPlanStatus {
+	plan := &operatorv1alpha1.SymphonyProjectPlanStatus{RequestedAt: requestedAt, GeneratedTime: &now}
+	token, err := r.loadSourceToken(ctx, project)
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	loader, err := sourceFactory.New(project.Spec.Source, token)
+	if err != nil {
+		plan.Error = fmt.Sprintf("build %s source client: %v", project.Spec.Source.Kind, err)
+		return plan
+	}
+	snapshot, err := loader.LoadProject(ctx, symphonyLoadOptions(project))
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	runsByItem, err := r.listProjectRuns(ctx, project)
+	if err != nil {
+		plan.Error = err.Error()
+		return plan
+	}
+	plan.EligibleItems = int32(len(snapshot.Candidates))
+	plan.Items = buildSymphonyPlanItems(project.DeepCopy(), snapshot, runsByItem, now.Time)
+	renderSymphonyPlanPrompts(project, plan.Items)
+	return plan
+}
+
+// buildSymphonyPlanItems applies one sync to project, which the caller must
+// own, and lists the outcome per item: releases first, then claims and
+// retries in rank order, waiting retries and skips.
+func buildSymphonyPlanItems(project *operatorv1alpha1.SymphonyProject, snapshot githubsource.Snapshot, runsByItem map[string]operatorv1alpha1.HarnessRun, now time.Time) []operatorv1alpha1.SymphonyProjectPlanItemStatus {
+	items := make([]operatorv1alpha1.SymphonyProjectPlanItemStatus, 0)
+	activeItems := make(map[string]struct{}, len(snapshot.Candidates))
+	for _, candidate := range snapshot.Candidates {
+		activeItems[candidate.ItemID] = struct{}{}
+	}
+	previousClaims := mapClaimsByItem(project.Status.ActiveClaims)
+	runs := make(map[string]operatorv1alpha1.HarnessRun, len(runsByItem))
+	releaseIDs := make([]string, 0)
+	for itemID, run := range runsByItem {
+		runs[itemID] = run
+		if _, ok := activeItems[itemID]; !ok {
+			releaseIDs = append(releaseIDs, itemID)
+		}
+	}
+	sort.Strings(releaseIDs)
+	// Mirror releaseInactiveRuns: only unfinished runs are stopped.
+	for _, itemID := range releaseIDs {
+		run := runs[itemID]
+		switch run.Status.Phase {
+		case operatorv1alpha1.HarnessRunPhasePending, operatorv1alpha1.HarnessRunPhaseStarting, operatorv1alpha1.HarnessRunPhaseRunning:
+		default:
+			continue
+		}
+		items = append(items, operatorv1alpha1.SymphonyProjectPlanItemStatus{
+			Action:         operatorv1alpha1.SymphonyPlanActionRelease,
+			ItemID:         itemID,
+			Issue:          releasedIssue(previousClaims[itemID], run),
+			Attempt:        previousClaims[itemID].Attempt,
+			Message:        "item is no longer eligible; its run would be stopped",
+			HarnessRunName: run.Name,
+		})
+		delete(runs, itemID)
+	}
+
+	claimed := map[string]struct{}{}
+	for _, transition := range reconcileProjectRuntime(project, snapshot, runs, now) {
+		if transition.Kind == symphonyTransitionClaimed {
+			claimed[transition.ItemID] = struct{}{}
+		}
+	}
+	for _, claim := range project.Status.ActiveClaims {
+		item := operatorv1alpha1.SymphonyProjectPlanItemStatus{
+			Action:         operatorv1alpha1.SymphonyPlanActionRunning,
+			ItemID:         claim.ItemID,
+			Issue:          claim.Issue,
+			Rank:           claim.Rank,
+			Attempt:        claim.Attempt,
+			HarnessRunName: symphonyRunName(project, claim),
+		}
+		if _, ok := claimed[claim.ItemID]; ok {
+			item.Action = operatorv1alpha1.SymphonyPlanActionClaim
+			if claim.Attempt > 1 {
+				item.Action = operatorv1alpha1.SymphonyPlanActionRetry
+			}
+		}
+		items = append(items, item)
+	}
+	for _, retry := range project.Status.RetryQueue {
+		items = append(items, operatorv1alpha1.SymphonyProjectPlanItemStatus{
+			Action:  operatorv1alpha1.SymphonyPlanActionBackoff,
+			ItemID:  retry.ItemID,
+			Issue:   retry.Issue,
+			Attempt: retry.Attempt,
+			Reason:  retry.Reason,
+			ReadyAt: retry.ReadyAt,
+		})
+	}
+	for _, skip := range project.Status.RecentSkips {
+		items = append(items, operatorv1alpha1.SymphonyProjectPlanItemStatus{
+			Action:  operatorv1alpha1.SymphonyPlanActionSkip,
+			ItemID:  skip.ItemID,
+			Issue:   skip.Issue,
+			Rank:    skip.Rank,
+			Reason:  skip.Reason,
+			Message: skip.Message,
+		})
+	}
+	return items
+}
+
+// renderSymphonyPlanPrompts renders WORKFLOW.md for every claim and retry
+// whose repository has a local checkout, exactly as the worker would.
+func renderSymphonyPlanPrompts(project *operatorv1alpha1.SymphonyProject, items []operatorv1alpha1.SymphonyProjectPlanItemStatus) {
+	repositories := map[string]operatorv1alpha1.SymphonyProjectRepositorySpec{}
+	for _, repo := range project.Spec.Repositories {
+		repositories[repo.RepositoryKey()] = repo
+	}
+	for i := range items {
+		item := &items[i]
+		if item.Action != operatorv1alpha1.SymphonyPlanActionClaim && item.Action != operatorv1alpha1.SymphonyPlanActionRetry {
+			continue
+		}
+		repo, ok := repositories[repositoryKeyFromStatus(item.Issue)]
+		if !ok || strings.TrimSpace(repo.LocalPath) == "" {
+			continue
+		}
+		claim := operatorv1alpha1.SymphonyProjectClaimStatus{ItemID: item.ItemID, Issue: item.Issue, Attempt: item.Attempt}
+		task, _, err := prepareSymphonyWorkerTask(symphonyClaimExecution(project, repo, claim))
+		if err != nil {
+			item.PromptError = sanitizeTelemetryMessage(err.Error())
+			continue
+		}
+		item.Prompt = truncateSymphonyPlanPrompt(task.Prompt)
+	}
+}
+
+func releasedIssue(previous operatorv1alpha1.SymphonyProjectClaimStatus, run operatorv1alpha1.HarnessRun) operatorv1alpha1.SymphonyProjectIssueRefStatus {
+	if previous.ItemID != "" {
+		return previous.Issue
+	}
+	issue := operatorv1alpha1.SymphonyProjectIssueRefStatus{
+		Repository: run.Labels[LabelGitHubRepository],
+		NodeID:     run.Annotations[AnnotationSymphonyIssueNode],
+		URL:        run.Annotations[AnnotationSymphonyIssueURL],
+	}
+	if number, err := strconv.ParseInt(run.Labels[LabelGitHubIssueNumber], 10, 64); err == nil {
+		issue.Number = number
+	}
+	return issue
+}
+
+func truncateSymphonyPlanPrompt(prompt string) string {
+	if len(prompt) <= maxSymphonyPlanPromptBytes {
+		return prompt
+	}
+	cut := maxSymphonyPlanPromptBytes
+	for cut > 0 && !utf8.RuneStart(prompt[cut]) {
+		cut--
+	}
+	return prompt[:cut] + "\n…"
+}
@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/worker"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+// symphonyWorkerCommand is the in-pod worker shipped in the harness image. It
+// is used as the run command in pod worker mode unless runtime.command is set.
+const symphonyWorkerCommand = "kocao-symphony-worker"
+
+func symphonyTaskConfigMapName(runName string) string {
+	return runName + "-task"
+}
+
+// ensureSymphonyWorkerTask renders the claim's task and stores it in the
+// ConfigMap the run's pod mounts. The ConfigMap is owned by the run, so it is
+// garbage collected with it; an existing ConfigMap is never re-rendered.
+func (r *SymphonyProjectReconciler) ensureSymphonyWorkerTask(ctx context.Context, run *operatorv1alpha1.HarnessRun, execReq symphonyWorkerExecution) error {
+	name := strings.TrimSpace(run.Annotations[AnnotationSymphonyTaskConfigMap])
+	if name == "" {
+		return nil
+	}
+	var existing corev1.ConfigMap
+	err := r.Get(ctx, client.ObjectKey{Namespace: run.Namespace, Name: name}, &existing)
+	if err == nil {
+		return nil
+	}
+	if !apierrors.IsNotFound(err) {
+		return err
+	}
+	task, _, err := prepareSymphonyWorkerTask(execReq)
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(task)
+	if err != nil {
+		return err
+	}
+	configMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: run.Namespace,
+			Labels:    run.Labels,
+		},
+		Data: map[string]string{worker.TaskFileName: string(data)},
+	}
+	if err := controllerutil.SetControllerReference(run, configMap, r.Scheme); err != nil {
+		return err
+	}
+	if err := r.Create(ctx, configMap); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+// applySymphonyWorkerReport copies the report a pod-mode worker left in the
+// harness container's termination message onto the run's annotations, and
+// names the worker failure on the Failed condition. It reports whether the
+// run's metadata and status changed.
+func applySymphonyWorkerReport(run *operatorv1alpha1.HarnessRun, pod *corev1.Pod) (bool, bool) {
+	if strings.TrimSpace(run.Annotations[AnnotationSymphonyTaskConfigMap]) == "" {
+		return false, false
+	}
+	var message string
+	for _, status := range pod.Status.ContainerStatuses {
+		if status.Name == "harness" && status.State.Terminated != nil {
+			message, _ = splitBranchPushLine(status.State.Terminated.Message)
+		}
+	}
+	if strings.TrimSpace(message) == "" {
+		return false, false
+	}
+	report, err := worker.DecodeReport(message)
+	if err != nil {
+		return false, false
+	}
+	changedMeta := false
+	for key, value := range symphonyWorkerResultAnnotations(symphonyWorkerResultFromReport(report)) {
+		if run.Annotations[key] != value {
+			run.Annotations[key] = value
+			changedMeta = true
+		}
+	}
+	changedStatus := false
+	if report.Error != "" && run.Status.Phase == operatorv1alpha1.HarnessRunPhaseFailed {
+		for i := range run.Status.Conditions {
+			if run.Status.Conditions[i].Type == ConditionFailed && run.Status.Conditions[i].Reason == "PodFailed" {
+				run.Status.Conditions[i].Reason = symphonyWorkerReportReason(report)
+				run.Status.Conditions[i].Message = fmt.Sprintf("symphony worker failed: %s", sanitizeTelemetryMessage(report.Error))
+				changedStatus = true
+			}
+		}
+	}
+	return changedMeta, changedStatus
+}
+
+// symphonyWorkerReportReason names why a pod-mode worker failed, so a failed
+// hook surfaces as its own retry reason.
+func symphonyWorkerReportReason(report worker.Report) string {
+	if report.FailedHook == "" {
+		return "WorkerExecutionFailed"
+	}
+	timedOut := false
+	for _, hook := range report.Hooks {
+		if hook.Name == report.FailedHook {
+			timedOut = hook.TimedOut
+		}
+	}
+	return symphonyHookFailureReason(report.FailedHook, timedOut)
+}
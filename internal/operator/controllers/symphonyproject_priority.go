@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+)
+
+// symphonyPriorityKey is what rankSymphonyCandidates compares for one
+// candidate; lower sorts first.
+type symphonyPriorityKey struct {
+	pin         int
+	hasField    bool
+	fieldIndex  int
+	fieldName   string
+	fieldNumber float64
+	labelWeight int
+	createdUnix int64
+}
+
+// rankSymphonyCandidates orders candidates by the project's priority spec and
+// returns them with each item's 1-based rank. Without a spec, or between items
+// the spec cannot tell apart, the board order is kept.
+func rankSymphonyCandidates(spec *operatorv1alpha1.SymphonyProjectPrioritySpec, candidates []githubsource.CandidateItem) ([]githubsource.CandidateItem, map[string]int32) {
+	ordered := append([]githubsource.CandidateItem(nil), candidates...)
+	if spec != nil {
+		keys := make(map[string]symphonyPriorityKey, len(ordered))
+		for _, candidate := range ordered {
+			keys[candidate.ItemID] = symphonyPriorityKeyFor(spec, candidate)
+		}
+		sort.SliceStable(ordered, func(i, j int) bool {
+			return lessSymphonyPriority(spec, keys[ordered[i].ItemID], keys[ordered[j].ItemID])
+		})
+	}
+	ranks := make(map[string]int32, len(ordered))
+	for i, candidate := range ordered {
+		if _, ok := ranks[candidate.ItemID]; !ok {
+			ranks[candidate.ItemID] = int32(i + 1)
+		}
+	}
+	return ordered, ranks
+}
+
+func symphonyPriorityKeyFor(spec *operatorv1alpha1.SymphonyProjectPrioritySpec, candidate githubsource.CandidateItem) symphonyPriorityKey {
+	key := symphonyPriorityKey{pin: len(spec.Pinned), createdUnix: candidate.Issue.CreatedAt.Unix()}
+	issueRef := strings.ToLower(candidate.Issue.Repository) + "#" + strconv.FormatInt(candidate.Issue.Number, 10)
+	for i, pin := range spec.Pinned {
+		pin = strings.TrimSpace(pin)
+		if pin == candidate.ItemID || strings.EqualFold(pin, issueRef) {
+			key.pin = i
+			break
+		}
+	}
+	if strings.TrimSpace(spec.FieldName) != "" {
+		switch value := candidate.Priority; {
+		case value.Number != nil:
+			key.hasField = true
+			key.fieldNumber = *value.Number
+			if spec.HighestNumberFirst {
+				key.fieldNumber = -key.fieldNumber
+			}
+		case value.Name != "":
+			key.hasField = true
+			key.fieldIndex = len(spec.FieldOrder)
+			for i, option := range spec.FieldOrder {
+				if strings.EqualFold(strings.TrimSpace(option), value.Name) {
+					key.fieldIndex = i
+					break
+				}
+			}
+			key.fieldName = strings.ToLower(value.Name)
+		}
+	}
+	for _, label := range candidate.Issue.Labels {
+		for name, weight := range spec.LabelWeights {
+			if strings.EqualFold(strings.TrimSpace(name), strings.TrimSpace(label)) {
+				key.labelWeight += int(weight)
+			}
+		}
+	}
+	return key
+}
+
+func lessSymphonyPriority(spec *operatorv1alpha1.SymphonyProjectPrioritySpec, left, right symphonyPriorityKey) bool {
+	if left.pin != right.pin {
+		return left.pin < right.pin
+	}
+	if left.hasField != right.hasField {
+		return left.hasField
+	}
+	if left.fieldIndex != right.fieldIndex {
+		return left.fieldIndex < right.fieldIndex
+	}
+	if left.fieldName != right.fieldName {
+		return left.fieldName < right.fieldName
+	}
+	if left.fieldNumber != right.fieldNumber {
+		return left.fieldNumber < right.fieldNumber
+	}
+	if left.labelWeight != right.labelWeight {
+		return left.labelWeight > right.labelWeight
+	}
+	switch spec.Age {
+	case operatorv1alpha1.SymphonyPriorityAgeOldestFirst:
+		return left.createdUnix < right.createdUnix
+	case operatorv1alpha1.SymphonyPriorityAgeNewestFirst:
+		return left.createdUnix > right.createdUnix
+	}
+	return false
+}
+
+// symphonyPriorityFieldName is the project field the source loads for ranking.
+func symphonyPriorityFieldName(spec *operatorv1alpha1.SymphonyProjectPrioritySpec) string {
+	if spec == nil {
+		return ""
+	}
+	return strings.TrimSpace(spec.FieldName)
+}
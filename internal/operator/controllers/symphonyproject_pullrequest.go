@@ -0,0 +1,82 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+)
+
+// SkipReasonPullRequestOpen marks items whose run succeeded and whose pull
+// request is waiting for review. They are not retried while it stays open.
+const SkipReasonPullRequestOpen = "pull_request_open"
+
+// harnessEntrypointCommand is the harness image entrypoint. Symphony runs that
+// publish pull requests wrap the worker in it so the workspace is cloned
+// before, and pushed after, the worker runs.
+const harnessEntrypointCommand = "/usr/local/bin/kocao-harness-entrypoint"
+
+// symphonyPullRequestSpec returns the pull request a claim's run publishes,
+// or nil when the project does not publish pull requests.
+func symphonyPullRequestSpec(project *operatorv1alpha1.SymphonyProject, repo operatorv1alpha1.SymphonyProjectRepositorySpec, claim operatorv1alpha1.SymphonyProjectClaimStatus) *operatorv1alpha1.HarnessRunPullRequestSpec {
+	spec := project.Spec.PullRequests
+	if spec == nil {
+		return nil
+	}
+	issue := claim.Issue
+	prefix := firstNonEmpty(strings.TrimSpace(spec.BranchPrefix), operatorv1alpha1.DefaultSymphonyPullRequestBranchPrefix)
+	_, name, _ := strings.Cut(repo.RepositoryKey(), "/")
+	branch := prefix + sanitizeDNSLabel(firstNonEmpty(name, repo.RepositoryKey())) + "-" + strconv.FormatInt(issue.Number, 10)
+
+	// GitHub closes the issue when the pull request merges; a Linear issue is
+	// linked by its URL instead.
+	reference := fmt.Sprintf("%s#%d", issue.Repository, issue.Number)
+	body := fmt.Sprintf("Closes %s\n\nOpened by Kocao Symphony project %s.", reference, project.Name)
+	if project.Spec.Source.Kind == operatorv1alpha1.SymphonySourceKindLinear && strings.TrimSpace(issue.URL) != "" {
+		body = fmt.Sprintf("Resolves %s\n\nOpened by Kocao Symphony project %s.", issue.URL, project.Name)
+	}
+	title := reference
+	if value := strings.TrimSpace(issue.Title); value != "" {
+		title = fmt.Sprintf("%s (%s)", value, reference)
+	}
+	return &operatorv1alpha1.HarnessRunPullRequestSpec{
+		Branch:        branch,
+		Base:          claimRepoRevision(project.Spec.Runtime, repo),
+		Title:         title,
+		Body:          body,
+		Draft:         spec.Draft,
+		CommitMessage: fmt.Sprintf("%s\n\nRefs %s", title, reference),
+	}
+}
+
+// pullRequestOutcome reports how a succeeded run's pull request settles its
+// item: merged is terminal success, open holds the item back, and a closed
+// pull request or a run without changes falls back to a continuation retry.
+func pullRequestOutcome(candidate githubsource.CandidateItem, run operatorv1alpha1.HarnessRun, now time.Time) (githubsource.SkippedItem, bool, bool) {
+	if run.Spec.PullRequest == nil {
+		return githubsource.SkippedItem{}, false, false
+	}
+	switch strings.TrimSpace(run.Annotations[AnnotationPullRequestStatus]) {
+	case githubsource.PullRequestMerged:
+		return githubsource.SkippedItem{}, true, false
+	case githubsource.PullRequestClosed, PullRequestStatusNoChanges:
+		return githubsource.SkippedItem{}, false, false
+	}
+	issue := candidate.Issue
+	message := "waiting for pull request to be opened"
+	if url := strings.TrimSpace(run.Annotations[AnnotationPullRequestURL]); url != "" {
+		message = "waiting for pull request " + url + " to be merged"
+	}
+	return githubsource.SkippedItem{
+		ItemID:     candidate.ItemID,
+		Repository: candidate.Issue.Repository,
+		Status:     candidate.Status,
+		Reason:     SkipReasonPullRequestOpen,
+		Message:    message,
+		Issue:      &issue,
+		ObservedAt: now,
+	}, false, true
+}
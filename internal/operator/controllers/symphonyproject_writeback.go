@@ -0,0 +1,157 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/withakay/kocao/internal/auditlog"
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// symphonySourceWriter is implemented by sources that can report progress
+// back to the tracker. Loaders that do not implement it leave the board alone.
+type symphonySourceWriter interface {
+	WriteBack(context.Context, githubsource.WriteBackRequest) error
+}
+
+type symphonyTransitionKind string
+
+const (
+	symphonyTransitionClaimed   symphonyTransitionKind = "claimed"
+	symphonyTransitionFailed    symphonyTransitionKind = "failed"
+	symphonyTransitionSucceeded symphonyTransitionKind = "succeeded"
+)
+
+// symphonyItemTransition is a claim, failure or success observed by
+// reconcileProjectRuntime for a single project item.
+type symphonyItemTransition struct {
+	Kind           symphonyTransitionKind
+	ItemID         string
+	Issue          operatorv1alpha1.SymphonyProjectIssueRefStatus
+	Attempt        int32
+	HarnessRunName string
+	Reason         string
+	PullRequestURL string
+	Tokens         operatorv1alpha1.SymphonyProjectTokenTotalsStatus
+}
+
+func buildRunTransition(kind symphonyTransitionKind, candidate githubsource.CandidateItem, run operatorv1alpha1.HarnessRun, attempt int32, reason string) symphonyItemTransition {
+	return symphonyItemTransition{
+		Kind:           kind,
+		ItemID:         candidate.ItemID,
+		Issue:          issueStatus(candidate.Issue),
+		Attempt:        attempt,
+		HarnessRunName: run.Name,
+		Reason:         reason,
+		PullRequestURL: strings.TrimSpace(run.Annotations[AnnotationPullRequestURL]),
+		Tokens: operatorv1alpha1.SymphonyProjectTokenTotalsStatus{
+			InputTokens:    runAnnotationInt64(run, AnnotationSymphonyInputTokens),
+			OutputTokens:   runAnnotationInt64(run, AnnotationSymphonyOutputTokens),
+			TotalTokens:    runAnnotationInt64(run, AnnotationSymphonyTotalTokens),
+			SecondsRunning: runAnnotationFloat64(run, AnnotationSymphonyRuntimeSeconds),
+		},
+	}
+}
+
+// writeBackTransitions applies the project's write-back actions for each
+// transition. Failures are surfaced on the SourceWriteBack condition and the
+// audit log; they never block orchestration.
+func (r *SymphonyProjectReconciler) writeBackTransitions(ctx context.Context, project *operatorv1alpha1.SymphonyProject, loader symphonySourceLoader, snapshot githubsource.Snapshot, transitions []symphonyItemTransition, now metav1.Time) {
+	spec := project.Spec.WriteBack
+	if spec == nil || len(transitions) == 0 {
+		return
+	}
+	writer, ok := loader.(symphonySourceWriter)
+	if !ok {
+		setCondition(&project.Status.Conditions, metav1.Condition{Type: ConditionWriteBack, Status: metav1.ConditionFalse, Reason: "Unsupported", Message: fmt.Sprintf("%s sources do not support write-back", project.Spec.Source.Kind), LastTransitionTime: now})
+		return
+	}
+
+	failures := make([]string, 0)
+	for _, transition := range transitions {
+		action := writeBackAction(spec, transition.Kind)
+		req := githubsource.WriteBackRequest{
+			ProjectID:    snapshot.ProjectID,
+			ItemID:       transition.ItemID,
+			IssueNodeID:  transition.Issue.NodeID,
+			Repository:   transition.Issue.Repository,
+			FieldName:    firstNonEmpty(snapshot.ResolvedFieldName, project.Spec.Source.FieldName),
+			Status:       strings.TrimSpace(action.Status),
+			AddLabels:    action.AddLabels,
+			RemoveLabels: action.RemoveLabels,
+		}
+		if spec.Comment {
+			req.CommentMarker = symphonyProgressCommentMarker(project, transition.ItemID)
+			req.CommentBody = symphonyProgressComment(spec, transition)
+		}
+		if req.Status == "" && len(req.AddLabels) == 0 && len(req.RemoveLabels) == 0 && req.CommentBody == "" {
+			continue
+		}
+		details := map[string]any{"itemID": transition.ItemID, "repository": transition.Issue.Repository, "issueNumber": transition.Issue.Number, "transition": string(transition.Kind), "attempt": transition.Attempt}
+		if req.Status != "" {
+			details["status"] = req.Status
+		}
+		if err := writer.WriteBack(ctx, req); err != nil {
+			details["error"] = err.Error()
+			auditlog.AppendSymphony(ctx, r.Audit, "operator", "symphony.writeback", project.Name, "error", details)
+			failures = append(failures, fmt.Sprintf("%s#%d %s: %v", transition.Issue.Repository, transition.Issue.Number, transition.Kind, err))
+			continue
+		}
+		auditlog.AppendSymphony(ctx, r.Audit, "operator", "symphony.writeback", project.Name, "allowed", details)
+	}
+	if len(failures) != 0 {
+		setCondition(&project.Status.Conditions, metav1.Condition{Type: ConditionWriteBack, Status: metav1.ConditionFalse, Reason: "WriteBackFailed", Message: strings.Join(failures, "; "), LastTransitionTime: now})
+		return
+	}
+	setCondition(&project.Status.Conditions, metav1.Condition{Type: ConditionWriteBack, Status: metav1.ConditionTrue, Reason: "WriteBackSucceeded", Message: "tracker updated for the latest item transitions", LastTransitionTime: now})
+}
+
+func writeBackAction(spec *operatorv1alpha1.SymphonyProjectWriteBackSpec, kind symphonyTransitionKind) operatorv1alpha1.SymphonyProjectWriteBackActionSpec {
+	switch kind {
+	case symphonyTransitionClaimed:
+		return spec.OnClaim
+	case symphonyTransitionFailed:
+		return spec.OnFailure
+	case symphonyTransitionSucceeded:
+		return spec.OnSuccess
+	default:
+		return operatorv1alpha1.SymphonyProjectWriteBackActionSpec{}
+	}
+}
+
+// symphonyProgressCommentMarker is a hidden HTML comment that lets later
+// transitions find and edit the progress comment instead of adding another.
+func symphonyProgressCommentMarker(project *operatorv1alpha1.SymphonyProject, itemID string) string {
+	return fmt.Sprintf("<!-- kocao-symphony:%s/%s:%s -->", project.Namespace, project.Name, itemID)
+}
+
+func symphonyProgressComment(spec *operatorv1alpha1.SymphonyProjectWriteBackSpec, transition symphonyItemTransition) string {
+	var headline string
+	switch transition.Kind {
+	case symphonyTransitionClaimed:
+		headline = "Symphony is working on this issue."
+	case symphonyTransitionFailed:
+		headline = fmt.Sprintf("Symphony attempt %d failed (%s).", transition.Attempt, firstNonEmpty(transition.Reason, "HarnessRunFailed"))
+	case symphonyTransitionSucceeded:
+		headline = "Symphony finished this issue."
+	}
+	var b strings.Builder
+	b.WriteString("**Kocao Symphony** — ")
+	b.WriteString(headline)
+	b.WriteString("\n\n")
+	run := "`" + transition.HarnessRunName + "`"
+	if base := strings.TrimRight(strings.TrimSpace(spec.RunURLBase), "/"); base != "" {
+		run = fmt.Sprintf("[%s](%s/harness-runs/%s)", transition.HarnessRunName, base, transition.HarnessRunName)
+	}
+	fmt.Fprintf(&b, "- Run: %s (attempt %d)\n", run, transition.Attempt)
+	if transition.Tokens.TotalTokens > 0 {
+		fmt.Fprintf(&b, "- Tokens: %d total (%d input, %d output)\n", transition.Tokens.TotalTokens, transition.Tokens.InputTokens, transition.Tokens.OutputTokens)
+	}
+	if transition.PullRequestURL != "" {
+		fmt.Fprintf(&b, "- Pull request: %s\n", transition.PullRequestURL)
+	}
+	return b.String()
+}
@@ -45,6 +45,9 @@ type LoadOptions struct {
 	ActiveStates   []string
 	TerminalStates []string
 	Repositories   []operatorv1alpha1.SymphonyProjectRepositorySpec
+	// PriorityFieldName, when set, also loads each item's value for that
+	// single-select or number field into CandidateItem.Priority.
+	PriorityFieldName string
 }
 
 type Snapshot struct {
@@ -57,9 +60,18 @@ type Snapshot struct {
 }
 
 type CandidateItem struct {
-	ItemID string
-	Status string
-	Issue  Issue
+	ItemID   string
+	Status   string
+	Issue    Issue
+	Priority FieldValue
+}
+
+// FieldValue is an item's value for the priority field: the option name of a
+// single-select field or the value of a number field. Both are empty when
+// the item has no value.
+type FieldValue struct {
+	Name   string
+	Number *float64
 }
 
 type SkippedItem struct {
@@ -126,7 +138,7 @@ func (c *Client) LoadProject(ctx context.Context, opts LoadOptions) (Snapshot, e
 	}
 	allowlist := makeRepositorySet(opts.Repositories)
 
-	project, err := c.loadProjectItems(ctx, opts.Project.Owner, opts.Project.Number, fieldName)
+	project, err := c.loadProjectItems(ctx, opts.Project.Owner, opts.Project.Number, fieldName, strings.TrimSpace(opts.PriorityFieldName))
 	if err != nil {
 		return Snapshot{}, err
 	}
@@ -210,7 +222,7 @@ func (c *Client) LoadProject(ctx context.Context, opts LoadOptions) (Snapshot, e
 				})
 				continue
 			}
-			snapshot.Candidates = append(snapshot.Candidates, CandidateItem{ItemID: item.ID, Status: status, Issue: issue})
+			snapshot.Candidates = append(snapshot.Candidates, CandidateItem{ItemID: item.ID, Status: status, Issue: issue, Priority: item.priorityValue()})
 		case "PullRequest":
 			repo := item.repositoryKey()
 			snapshot.Skipped = append(snapshot.Skipped, SkippedItem{
@@ -236,19 +248,27 @@ func (c *Client) LoadProject(ctx context.Context, opts LoadOptions) (Snapshot, e
 	return snapshot, nil
 }
 
-func (c *Client) loadProjectItems(ctx context.Context, owner string, number int64, fieldName string) (*graphQLProject, error) {
+func (c *Client) loadProjectItems(ctx context.Context, owner string, number int64, fieldName, priorityFieldName string) (*graphQLProject, error) {
 	var (
 		cursor string
 		result *graphQLProject
 	)
+	// The priority alias is skipped unless a field is configured; the
+	// variable still has to name a field.
+	withPriority := priorityFieldName != ""
+	if !withPriority {
+		priorityFieldName = fieldName
+	}
 	for {
 		resp, err := c.query(ctx, graphQLRequest{
 			Query: projectQuery,
 			Variables: map[string]any{
-				"owner":     owner,
-				"number":    number,
-				"cursor":    cursor,
-				"fieldName": fieldName,
+				"owner":             owner,
+				"number":            number,
+				"cursor":            cursor,
+				"fieldName":         fieldName,
+				"priorityFieldName": priorityFieldName,
+				"withPriority":      withPriority,
 			},
 		})
 		if err != nil {
@@ -273,13 +293,31 @@ func (c *Client) loadProjectItems(ctx context.Context, owner string, number int6
 }
 
 func (c *Client) query(ctx context.Context, reqBody graphQLRequest) (graphQLResponse, error) {
+	body, err := c.post(ctx, reqBody)
+	if err != nil {
+		return graphQLResponse{}, err
+	}
+	var decoded graphQLResponse
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return graphQLResponse{}, fmt.Errorf("decode github graphql response: %w", err)
+	}
+	if len(decoded.Errors) != 0 {
+		if decoded.Data.project() != nil && graphQLErrorsAreOwnerLookupOnly(decoded.Errors) {
+			return decoded, nil
+		}
+		return graphQLResponse{}, graphQLErrorsToError(decoded.Errors)
+	}
+	return decoded, nil
+}
+
+func (c *Client) post(ctx context.Context, reqBody graphQLRequest) ([]byte, error) {
 	body, err := json.Marshal(reqBody)
 	if err != nil {
-		return graphQLResponse{}, fmt.Errorf("encode github graphql request: %w", err)
+		return nil, fmt.Errorf("encode github graphql request: %w", err)
 	}
 	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL, bytes.NewReader(body))
 	if err != nil {
-		return graphQLResponse{}, fmt.Errorf("build github graphql request: %w", err)
+		return nil, fmt.Errorf("build github graphql request: %w", err)
 	}
 	req.Header.Set("Authorization", "Bearer "+c.token)
 	req.Header.Set("Content-Type", "application/json")
@@ -287,36 +325,33 @@ func (c *Client) query(ctx context.Context, reqBody graphQLRequest) (graphQLResp
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
-		return graphQLResponse{}, fmt.Errorf("execute github graphql request: %w", err)
+		return nil, fmt.Errorf("execute github graphql request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
-		return graphQLResponse{}, fmt.Errorf("github graphql returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+		return nil, fmt.Errorf("github graphql returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
 	}
-
-	var decoded graphQLResponse
-	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
-		return graphQLResponse{}, fmt.Errorf("decode github graphql response: %w", err)
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("read github graphql response: %w", err)
 	}
-	if len(decoded.Errors) != 0 {
-		if decoded.Data.project() != nil && graphQLErrorsAreOwnerLookupOnly(decoded.Errors) {
-			return decoded, nil
-		}
-		parts := make([]string, 0, len(decoded.Errors))
-		for _, item := range decoded.Errors {
-			msg := strings.TrimSpace(item.Message)
-			if msg != "" {
-				parts = append(parts, msg)
-			}
-		}
-		if len(parts) == 0 {
-			parts = append(parts, "unknown graphql error")
+	return respBody, nil
+}
+
+func graphQLErrorsToError(items []graphQLError) error {
+	parts := make([]string, 0, len(items))
+	for _, item := range items {
+		msg := strings.TrimSpace(item.Message)
+		if msg != "" {
+			parts = append(parts, msg)
 		}
-		return graphQLResponse{}, fmt.Errorf("github graphql error: %s", strings.Join(parts, "; "))
 	}
-	return decoded, nil
+	if len(parts) == 0 {
+		parts = append(parts, "unknown graphql error")
+	}
+	return fmt.Errorf("github graphql error: %s", strings.Join(parts, "; "))
 }
 
 func graphQLErrorsAreOwnerLookupOnly(items []graphQLError) bool {
@@ -464,9 +499,17 @@ type graphQLItem struct {
 	ID         string             `json:"id"`
 	IsArchived bool               `json:"isArchived"`
 	FieldValue *graphQLFieldValue `json:"fieldValueByName"`
+	Priority   *graphQLFieldValue `json:"priority"`
 	Content    graphQLItemContent `json:"content"`
 }
 
+func (i graphQLItem) priorityValue() FieldValue {
+	if i.Priority == nil {
+		return FieldValue{}
+	}
+	return FieldValue{Name: strings.TrimSpace(i.Priority.Name), Number: i.Priority.Number}
+}
+
 func (i graphQLItem) statusName() string {
 	if i.FieldValue == nil {
 		return ""
@@ -484,8 +527,9 @@ func (i graphQLItem) repositoryKey() string {
 }
 
 type graphQLFieldValue struct {
-	TypeName string `json:"__typename"`
-	Name     string `json:"name"`
+	TypeName string   `json:"__typename"`
+	Name     string   `json:"name"`
+	Number   *float64 `json:"number"`
 }
 
 type graphQLItemContent struct {
@@ -520,7 +564,7 @@ type graphQLLabel struct {
 	Name string `json:"name"`
 }
 
-const projectQuery = `query SymphonyProjectItems($owner: String!, $number: Int!, $cursor: String, $fieldName: String!) {
+const projectQuery = `query SymphonyProjectItems($owner: String!, $number: Int!, $cursor: String, $fieldName: String!, $priorityFieldName: String!, $withPriority: Boolean!) {
   organization(login: $owner) {
     projectV2(number: $number) {
       id
@@ -539,6 +583,15 @@ const projectQuery = `query SymphonyProjectItems($owner: String!, $number: Int!,
               name
             }
           }
+          priority: fieldValueByName(name: $priorityFieldName) @include(if: $withPriority) {
+            __typename
+            ... on ProjectV2ItemFieldSingleSelectValue {
+              name
+            }
+            ... on ProjectV2ItemFieldNumberValue {
+              number
+            }
+          }
           content {
             __typename
             ... on Issue {
@@ -598,6 +651,15 @@ const projectQuery = `query SymphonyProjectItems($owner: String!, $number: Int!,
               name
             }
           }
+          priority: fieldValueByName(name: $priorityFieldName) @include(if: $withPriority) {
+            __typename
+            ... on ProjectV2ItemFieldSingleSelectValue {
+              name
+            }
+            ... on ProjectV2ItemFieldNumberValue {
+              number
+            }
+          }
           content {
             __typename
             ... on Issue {
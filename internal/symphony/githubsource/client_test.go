@@ -223,6 +223,65 @@ func TestLoadProjectIgnoresUserLookupErrorForOrganizationProjects(t *testing.T)
 	}
 }
 
+func TestLoadProjectLoadsPriorityField(t *testing.T) {
+	var variables map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		variables = req.Variables
+		selectItem := issueItem("PVT_item_1", false, "Todo", "ISSUE_1", 1, "withakay", "kocao", false, "OPEN")
+		selectItem["priority"] = map[string]any{"__typename": "ProjectV2ItemFieldSingleSelectValue", "name": "P1"}
+		numberItem := issueItem("PVT_item_2", false, "Todo", "ISSUE_2", 2, "withakay", "kocao", false, "OPEN")
+		numberItem["priority"] = map[string]any{"__typename": "ProjectV2ItemFieldNumberValue", "number": 2.5}
+		writeGraphQLResponse(t, w, map[string]any{
+			"data": map[string]any{
+				"organization": map[string]any{
+					"projectV2": map[string]any{
+						"id":    "PVT_project",
+						"title": "Symphony",
+						"items": map[string]any{
+							"pageInfo": map[string]any{"hasNextPage": false, "endCursor": ""},
+							"nodes":    []map[string]any{selectItem, numberItem},
+						},
+					},
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("github-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	snapshot, err := client.LoadProject(context.Background(), LoadOptions{
+		Project:           operatorv1alpha1.GitHubProjectRef{Owner: "withakay", Number: 1},
+		ActiveStates:      []string{"Todo"},
+		TerminalStates:    []string{"Done"},
+		Repositories:      []operatorv1alpha1.SymphonyProjectRepositorySpec{{Owner: "withakay", Name: "kocao"}},
+		PriorityFieldName: "Priority",
+	})
+	if err != nil {
+		t.Fatalf("LoadProject error = %v", err)
+	}
+	if variables["priorityFieldName"] != "Priority" || variables["withPriority"] != true {
+		t.Fatalf("variables = %#v", variables)
+	}
+	if len(snapshot.Candidates) != 2 {
+		t.Fatalf("candidates = %#v", snapshot.Candidates)
+	}
+	if got := snapshot.Candidates[0].Priority; got.Name != "P1" || got.Number != nil {
+		t.Fatalf("single-select priority = %#v", got)
+	}
+	if got := snapshot.Candidates[1].Priority; got.Number == nil || *got.Number != 2.5 {
+		t.Fatalf("number priority = %#v", got)
+	}
+}
+
 func TestLoadProjectReturnsNonOwnerGraphQLErrors(t *testing.T) {
 	t.Helper()
 	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,149 @@
+package githubsource
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Pull request states, lower-cased as they appear in the
+// pull-request-status annotation.
+const (
+	PullRequestOpen   = "open"
+	PullRequestMerged = "merged"
+	PullRequestClosed = "closed"
+)
+
+// ErrBranchNotFound is returned by OpenPullRequest when the head branch was
+// never pushed, which is how a run without changes shows up.
+var ErrBranchNotFound = errors.New("github branch not found")
+
+// PullRequestRequest describes the pull request to open from Head. An empty
+// Base targets the repository's default branch.
+type PullRequestRequest struct {
+	Repository string
+	Head       string
+	Base       string
+	Title      string
+	Body       string
+	Draft      bool
+}
+
+type PullRequest struct {
+	Number int64
+	URL    string
+	State  string
+}
+
+// OpenPullRequest opens a pull request from req.Head, or returns the pull
+// request already open from it, so a retried call never opens a second one.
+func (c *Client) OpenPullRequest(ctx context.Context, req PullRequestRequest) (PullRequest, error) {
+	if c == nil {
+		return PullRequest{}, fmt.Errorf("github source client is nil")
+	}
+	owner, name, ok := strings.Cut(strings.TrimSpace(req.Repository), "/")
+	head := strings.TrimSpace(req.Head)
+	if !ok || owner == "" || name == "" || head == "" {
+		return PullRequest{}, fmt.Errorf("repository and head branch are required")
+	}
+	var repo struct {
+		Repository *struct {
+			ID               string `json:"id"`
+			DefaultBranchRef *struct {
+				Name string `json:"name"`
+			} `json:"defaultBranchRef"`
+			Ref *struct {
+				Name string `json:"name"`
+			} `json:"ref"`
+			PullRequests struct {
+				Nodes []graphQLPullRequest `json:"nodes"`
+			} `json:"pullRequests"`
+		} `json:"repository"`
+	}
+	if err := c.execute(ctx, pullRequestHeadQuery, map[string]any{"owner": owner, "name": name, "head": head, "qualifiedHead": "refs/heads/" + head}, &repo); err != nil {
+		return PullRequest{}, err
+	}
+	if repo.Repository == nil {
+		return PullRequest{}, fmt.Errorf("repository %s not found", req.Repository)
+	}
+	if len(repo.Repository.PullRequests.Nodes) != 0 {
+		return repo.Repository.PullRequests.Nodes[0].pullRequest(), nil
+	}
+	if repo.Repository.Ref == nil {
+		return PullRequest{}, fmt.Errorf("%w: %s", ErrBranchNotFound, head)
+	}
+	base := strings.TrimSpace(req.Base)
+	if base == "" && repo.Repository.DefaultBranchRef != nil {
+		base = repo.Repository.DefaultBranchRef.Name
+	}
+	if base == "" {
+		return PullRequest{}, fmt.Errorf("repository %s has no default branch", req.Repository)
+	}
+	var created struct {
+		CreatePullRequest struct {
+			PullRequest graphQLPullRequest `json:"pullRequest"`
+		} `json:"createPullRequest"`
+	}
+	if err := c.execute(ctx, createPullRequestMutation, map[string]any{"input": map[string]any{
+		"repositoryId": repo.Repository.ID,
+		"baseRefName":  base,
+		"headRefName":  head,
+		"title":        req.Title,
+		"body":         req.Body,
+		"draft":        req.Draft,
+	}}, &created); err != nil {
+		return PullRequest{}, err
+	}
+	return created.CreatePullRequest.PullRequest.pullRequest(), nil
+}
+
+// GetPullRequest returns the current state of the pull request at url.
+func (c *Client) GetPullRequest(ctx context.Context, url string) (PullRequest, error) {
+	if c == nil {
+		return PullRequest{}, fmt.Errorf("github source client is nil")
+	}
+	var resource struct {
+		Resource *graphQLPullRequest `json:"resource"`
+	}
+	if err := c.execute(ctx, pullRequestQuery, map[string]any{"url": url}, &resource); err != nil {
+		return PullRequest{}, err
+	}
+	if resource.Resource == nil || resource.Resource.URL == "" {
+		return PullRequest{}, fmt.Errorf("pull request %s not found", url)
+	}
+	return resource.Resource.pullRequest(), nil
+}
+
+type graphQLPullRequest struct {
+	Number int64  `json:"number"`
+	URL    string `json:"url"`
+	State  string `json:"state"`
+}
+
+func (p graphQLPullRequest) pullRequest() PullRequest {
+	return PullRequest{Number: p.Number, URL: p.URL, State: strings.ToLower(p.State)}
+}
+
+const pullRequestHeadQuery = `query KocaoPullRequestHead($owner: String!, $name: String!, $head: String!, $qualifiedHead: String!) {
+  repository(owner: $owner, name: $name) {
+    id
+    defaultBranchRef { name }
+    ref(qualifiedName: $qualifiedHead) { name }
+    pullRequests(headRefName: $head, states: [OPEN], first: 1) {
+      nodes { number url state }
+    }
+  }
+}`
+
+const createPullRequestMutation = `mutation KocaoCreatePullRequest($input: CreatePullRequestInput!) {
+  createPullRequest(input: $input) {
+    pullRequest { number url state }
+  }
+}`
+
+const pullRequestQuery = `query KocaoPullRequest($url: URI!) {
+  resource(url: $url) {
+    ... on PullRequest { number url state }
+  }
+}`
@@ -0,0 +1,104 @@
+package githubsource
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func newPullRequestServer(t *testing.T, ref any, open []map[string]any) (*httptest.Server, *[]recordedGraphQLCall) {
+	t.Helper()
+	calls := &[]recordedGraphQLCall{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		operation := strings.Fields(strings.SplitN(req.Query, "(", 2)[0])[1]
+		*calls = append(*calls, recordedGraphQLCall{Operation: operation, Variables: req.Variables})
+		var data map[string]any
+		switch operation {
+		case "KocaoPullRequestHead":
+			data = map[string]any{"repository": map[string]any{
+				"id":               "REPO_1",
+				"defaultBranchRef": map[string]any{"name": "main"},
+				"ref":              ref,
+				"pullRequests":     map[string]any{"nodes": open},
+			}}
+		case "KocaoCreatePullRequest":
+			data = map[string]any{"createPullRequest": map[string]any{"pullRequest": map[string]any{"number": 7, "url": "https://github.com/withakay/kocao/pull/7", "state": "OPEN"}}}
+		case "KocaoPullRequest":
+			data = map[string]any{"resource": map[string]any{"number": 7, "url": req.Variables["url"], "state": "MERGED"}}
+		default:
+			data = map[string]any{}
+		}
+		writeGraphQLResponse(t, w, map[string]any{"data": data})
+	}))
+	return srv, calls
+}
+
+func TestOpenPullRequestCreatesAgainstDefaultBranch(t *testing.T) {
+	srv, calls := newPullRequestServer(t, map[string]any{"name": "symphony/kocao-42"}, nil)
+	defer srv.Close()
+	client, err := NewClient("github-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+
+	pr, err := client.OpenPullRequest(context.Background(), PullRequestRequest{Repository: "withakay/kocao", Head: "symphony/kocao-42", Title: "Fix it", Body: "Closes withakay/kocao#42", Draft: true})
+	if err != nil {
+		t.Fatalf("OpenPullRequest error = %v", err)
+	}
+	if pr.Number != 7 || pr.State != PullRequestOpen || pr.URL != "https://github.com/withakay/kocao/pull/7" {
+		t.Fatalf("pull request = %+v", pr)
+	}
+	if len(*calls) != 2 || (*calls)[1].Operation != "KocaoCreatePullRequest" {
+		t.Fatalf("calls = %+v", *calls)
+	}
+	input := (*calls)[1].Variables["input"].(map[string]any)
+	if input["baseRefName"] != "main" || input["headRefName"] != "symphony/kocao-42" || input["repositoryId"] != "REPO_1" || input["draft"] != true {
+		t.Fatalf("create input = %+v", input)
+	}
+	if (*calls)[0].Variables["qualifiedHead"] != "refs/heads/symphony/kocao-42" {
+		t.Fatalf("head query variables = %+v", (*calls)[0].Variables)
+	}
+}
+
+func TestOpenPullRequestReusesOpenPullRequestAndReportsMissingBranch(t *testing.T) {
+	srv, calls := newPullRequestServer(t, map[string]any{"name": "fix"}, []map[string]any{{"number": 3, "url": "https://github.com/withakay/kocao/pull/3", "state": "OPEN"}})
+	defer srv.Close()
+	client, _ := NewClient("github-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+
+	pr, err := client.OpenPullRequest(context.Background(), PullRequestRequest{Repository: "withakay/kocao", Head: "fix", Title: "Fix"})
+	if err != nil || pr.Number != 3 {
+		t.Fatalf("OpenPullRequest = %+v, %v", pr, err)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("calls = %+v", *calls)
+	}
+
+	missing, _ := newPullRequestServer(t, nil, nil)
+	defer missing.Close()
+	client, _ = NewClient("github-token", Options{APIURL: missing.URL, HTTPClient: missing.Client()})
+	if _, err := client.OpenPullRequest(context.Background(), PullRequestRequest{Repository: "withakay/kocao", Head: "fix", Title: "Fix"}); !errors.Is(err, ErrBranchNotFound) {
+		t.Fatalf("OpenPullRequest error = %v, want ErrBranchNotFound", err)
+	}
+}
+
+func TestGetPullRequestReportsState(t *testing.T) {
+	srv, _ := newPullRequestServer(t, nil, nil)
+	defer srv.Close()
+	client, _ := NewClient("github-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+
+	pr, err := client.GetPullRequest(context.Background(), "https://github.com/withakay/kocao/pull/7")
+	if err != nil {
+		t.Fatalf("GetPullRequest error = %v", err)
+	}
+	if pr.State != PullRequestMerged || pr.Number != 7 {
+		t.Fatalf("pull request = %+v", pr)
+	}
+}
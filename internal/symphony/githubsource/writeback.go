@@ -0,0 +1,287 @@
+package githubsource
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// WriteBackRequest describes the board and issue updates for one item
+// transition. Empty fields are skipped, so a request with only a Status moves
+// the item without touching labels or comments.
+type WriteBackRequest struct {
+	ProjectID    string
+	ItemID       string
+	IssueNodeID  string
+	Repository   string
+	FieldName    string
+	Status       string
+	AddLabels    []string
+	RemoveLabels []string
+	// CommentMarker identifies the progress comment so later transitions edit
+	// it in place instead of posting a new one.
+	CommentMarker string
+	CommentBody   string
+}
+
+// WriteBack applies req to the GitHub Project item and its issue.
+func (c *Client) WriteBack(ctx context.Context, req WriteBackRequest) error {
+	if c == nil {
+		return fmt.Errorf("github source client is nil")
+	}
+	if status := strings.TrimSpace(req.Status); status != "" {
+		if err := c.setItemStatus(ctx, req.ProjectID, req.ItemID, req.FieldName, status); err != nil {
+			return fmt.Errorf("set project item status: %w", err)
+		}
+	}
+	if len(req.AddLabels) != 0 || len(req.RemoveLabels) != 0 {
+		if err := c.updateIssueLabels(ctx, req.IssueNodeID, req.Repository, req.AddLabels, req.RemoveLabels); err != nil {
+			return fmt.Errorf("update issue labels: %w", err)
+		}
+	}
+	if strings.TrimSpace(req.CommentBody) != "" {
+		if err := c.upsertProgressComment(ctx, req.IssueNodeID, req.CommentMarker, req.CommentBody); err != nil {
+			return fmt.Errorf("upsert progress comment: %w", err)
+		}
+	}
+	return nil
+}
+
+func (c *Client) setItemStatus(ctx context.Context, projectID, itemID, fieldName, status string) error {
+	if strings.TrimSpace(projectID) == "" || strings.TrimSpace(itemID) == "" {
+		return fmt.Errorf("project and item ids are required")
+	}
+	fieldName = strings.TrimSpace(fieldName)
+	if fieldName == "" {
+		fieldName = "Status"
+	}
+	var field struct {
+		Node *struct {
+			Field *struct {
+				TypeName string `json:"__typename"`
+				ID       string `json:"id"`
+				Options  []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"options"`
+			} `json:"field"`
+		} `json:"node"`
+	}
+	if err := c.execute(ctx, statusFieldQuery, map[string]any{"projectId": projectID, "fieldName": fieldName}, &field); err != nil {
+		return err
+	}
+	if field.Node == nil || field.Node.Field == nil || field.Node.Field.ID == "" {
+		return fmt.Errorf("single-select field %q not found on project", fieldName)
+	}
+	optionID := ""
+	for _, option := range field.Node.Field.Options {
+		if normalizeState(option.Name) == normalizeState(status) {
+			optionID = option.ID
+			break
+		}
+	}
+	if optionID == "" {
+		return fmt.Errorf("field %q has no option %q", fieldName, status)
+	}
+	return c.execute(ctx, setItemStatusMutation, map[string]any{
+		"projectId": projectID,
+		"itemId":    itemID,
+		"fieldId":   field.Node.Field.ID,
+		"optionId":  optionID,
+	}, nil)
+}
+
+func (c *Client) updateIssueLabels(ctx context.Context, issueID, repository string, add, remove []string) error {
+	owner, name, ok := strings.Cut(strings.TrimSpace(repository), "/")
+	if strings.TrimSpace(issueID) == "" || !ok || owner == "" || name == "" {
+		return fmt.Errorf("issue id and repository are required")
+	}
+	var labels struct {
+		Repository *struct {
+			Labels struct {
+				Nodes []struct {
+					ID   string `json:"id"`
+					Name string `json:"name"`
+				} `json:"nodes"`
+			} `json:"labels"`
+		} `json:"repository"`
+	}
+	if err := c.execute(ctx, repositoryLabelsQuery, map[string]any{"owner": owner, "name": name}, &labels); err != nil {
+		return err
+	}
+	if labels.Repository == nil {
+		return fmt.Errorf("repository %s not found", repository)
+	}
+	byName := make(map[string]string, len(labels.Repository.Labels.Nodes))
+	for _, label := range labels.Repository.Labels.Nodes {
+		byName[normalizeState(label.Name)] = label.ID
+	}
+
+	addIDs := make([]string, 0, len(add))
+	for _, label := range add {
+		if strings.TrimSpace(label) == "" {
+			continue
+		}
+		id, ok := byName[normalizeState(label)]
+		if !ok {
+			return fmt.Errorf("label %q does not exist in %s", label, repository)
+		}
+		addIDs = append(addIDs, id)
+	}
+	// Removing a label that was never created is a no-op rather than an error.
+	removeIDs := make([]string, 0, len(remove))
+	for _, label := range remove {
+		if id, ok := byName[normalizeState(label)]; ok {
+			removeIDs = append(removeIDs, id)
+		}
+	}
+	if len(removeIDs) != 0 {
+		if err := c.execute(ctx, removeLabelsMutation, map[string]any{"labelableId": issueID, "labelIds": removeIDs}, nil); err != nil {
+			return err
+		}
+	}
+	if len(addIDs) != 0 {
+		if err := c.execute(ctx, addLabelsMutation, map[string]any{"labelableId": issueID, "labelIds": addIDs}, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *Client) upsertProgressComment(ctx context.Context, issueID, marker, body string) error {
+	if strings.TrimSpace(issueID) == "" {
+		return fmt.Errorf("issue id is required")
+	}
+	marker = strings.TrimSpace(marker)
+	if marker != "" && !strings.Contains(body, marker) {
+		body = marker + "\n" + body
+	}
+	if marker != "" {
+		var comments struct {
+			Node *struct {
+				Comments struct {
+					Nodes []struct {
+						ID              string `json:"id"`
+						Body            string `json:"body"`
+						ViewerDidAuthor bool   `json:"viewerDidAuthor"`
+					} `json:"nodes"`
+				} `json:"comments"`
+			} `json:"node"`
+		}
+		if err := c.execute(ctx, issueCommentsQuery, map[string]any{"issueId": issueID}, &comments); err != nil {
+			return err
+		}
+		if comments.Node != nil {
+			for _, comment := range comments.Node.Comments.Nodes {
+				if comment.ViewerDidAuthor && strings.Contains(comment.Body, marker) {
+					if comment.Body == body {
+						return nil
+					}
+					return c.execute(ctx, updateCommentMutation, map[string]any{"id": comment.ID, "body": body}, nil)
+				}
+			}
+		}
+	}
+	return c.execute(ctx, addCommentMutation, map[string]any{"subjectId": issueID, "body": body}, nil)
+}
+
+// execute runs a query or mutation that does not need the owner lookup
+// fallbacks of query and decodes its data into out when out is non-nil.
+func (c *Client) execute(ctx context.Context, document string, variables map[string]any, out any) error {
+	body, err := c.post(ctx, graphQLRequest{Query: document, Variables: variables})
+	if err != nil {
+		return err
+	}
+	var decoded struct {
+		Data   json.RawMessage `json:"data"`
+		Errors []graphQLError  `json:"errors"`
+	}
+	if err := json.Unmarshal(body, &decoded); err != nil {
+		return fmt.Errorf("decode github graphql response: %w", err)
+	}
+	if len(decoded.Errors) != 0 {
+		return graphQLErrorsToError(decoded.Errors)
+	}
+	if out == nil || len(decoded.Data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(decoded.Data, out); err != nil {
+		return fmt.Errorf("decode github graphql data: %w", err)
+	}
+	return nil
+}
+
+const statusFieldQuery = `query SymphonyStatusField($projectId: ID!, $fieldName: String!) {
+  node(id: $projectId) {
+    ... on ProjectV2 {
+      field(name: $fieldName) {
+        __typename
+        ... on ProjectV2SingleSelectField {
+          id
+          options {
+            id
+            name
+          }
+        }
+      }
+    }
+  }
+}`
+
+const setItemStatusMutation = `mutation SymphonySetItemStatus($projectId: ID!, $itemId: ID!, $fieldId: ID!, $optionId: String!) {
+  updateProjectV2ItemFieldValue(input: {projectId: $projectId, itemId: $itemId, fieldId: $fieldId, value: {singleSelectOptionId: $optionId}}) {
+    projectV2Item {
+      id
+    }
+  }
+}`
+
+const repositoryLabelsQuery = `query SymphonyRepositoryLabels($owner: String!, $name: String!) {
+  repository(owner: $owner, name: $name) {
+    labels(first: 100) {
+      nodes {
+        id
+        name
+      }
+    }
+  }
+}`
+
+const addLabelsMutation = `mutation SymphonyAddLabels($labelableId: ID!, $labelIds: [ID!]!) {
+  addLabelsToLabelable(input: {labelableId: $labelableId, labelIds: $labelIds}) {
+    clientMutationId
+  }
+}`
+
+const removeLabelsMutation = `mutation SymphonyRemoveLabels($labelableId: ID!, $labelIds: [ID!]!) {
+  removeLabelsFromLabelable(input: {labelableId: $labelableId, labelIds: $labelIds}) {
+    clientMutationId
+  }
+}`
+
+const issueCommentsQuery = `query SymphonyIssueComments($issueId: ID!) {
+  node(id: $issueId) {
+    ... on Issue {
+      comments(last: 100) {
+        nodes {
+          id
+          body
+          viewerDidAuthor
+        }
+      }
+    }
+  }
+}`
+
+const addCommentMutation = `mutation SymphonyAddComment($subjectId: ID!, $body: String!) {
+  addComment(input: {subjectId: $subjectId, body: $body}) {
+    clientMutationId
+  }
+}`
+
+const updateCommentMutation = `mutation SymphonyUpdateComment($id: ID!, $body: String!) {
+  updateIssueComment(input: {id: $id, body: $body}) {
+    clientMutationId
+  }
+}`
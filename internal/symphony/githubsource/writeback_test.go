@@ -0,0 +1,137 @@
+package githubsource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type recordedGraphQLCall struct {
+	Operation string
+	Variables map[string]any
+}
+
+func newWriteBackServer(t *testing.T, comments []map[string]any) (*httptest.Server, *[]recordedGraphQLCall) {
+	t.Helper()
+	calls := &[]recordedGraphQLCall{}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req graphQLRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		operation := strings.Fields(strings.SplitN(req.Query, "(", 2)[0])[1]
+		*calls = append(*calls, recordedGraphQLCall{Operation: operation, Variables: req.Variables})
+		var data map[string]any
+		switch operation {
+		case "SymphonyStatusField":
+			data = map[string]any{"node": map[string]any{"field": map[string]any{
+				"__typename": "ProjectV2SingleSelectField",
+				"id":         "FIELD_status",
+				"options": []map[string]any{
+					{"id": "OPT_todo", "name": "Todo"},
+					{"id": "OPT_progress", "name": "In Progress"},
+				},
+			}}}
+		case "SymphonyRepositoryLabels":
+			data = map[string]any{"repository": map[string]any{"labels": map[string]any{"nodes": []map[string]any{
+				{"id": "LABEL_symphony", "name": "symphony"},
+				{"id": "LABEL_failed", "name": "symphony:failed"},
+			}}}}
+		case "SymphonyIssueComments":
+			data = map[string]any{"node": map[string]any{"comments": map[string]any{"nodes": comments}}}
+		default:
+			data = map[string]any{}
+		}
+		writeGraphQLResponse(t, w, map[string]any{"data": data})
+	}))
+	return srv, calls
+}
+
+func TestWriteBackMovesItemLabelsIssueAndPostsComment(t *testing.T) {
+	srv, calls := newWriteBackServer(t, []map[string]any{
+		{"id": "IC_other", "body": "<!-- kocao-symphony:demo:PVT_item_1 -->\nspoofed", "viewerDidAuthor": false},
+	})
+	defer srv.Close()
+	client, err := NewClient("github-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+
+	err = client.WriteBack(context.Background(), WriteBackRequest{
+		ProjectID:     "PVT_project_1",
+		ItemID:        "PVT_item_1",
+		IssueNodeID:   "ISSUE_1",
+		Repository:    "withakay/kocao",
+		FieldName:     "Status",
+		Status:        "in progress",
+		AddLabels:     []string{"symphony"},
+		RemoveLabels:  []string{"symphony:failed", "never-created"},
+		CommentMarker: "<!-- kocao-symphony:demo:PVT_item_1 -->",
+		CommentBody:   "Symphony claimed this issue.",
+	})
+	if err != nil {
+		t.Fatalf("WriteBack error = %v", err)
+	}
+
+	var operations []string
+	for _, call := range *calls {
+		operations = append(operations, call.Operation)
+	}
+	want := "SymphonyStatusField,SymphonySetItemStatus,SymphonyRepositoryLabels,SymphonyRemoveLabels,SymphonyAddLabels,SymphonyIssueComments,SymphonyAddComment"
+	if got := strings.Join(operations, ","); got != want {
+		t.Fatalf("operations = %s\nwant %s", got, want)
+	}
+	if got := (*calls)[1].Variables; got["optionId"] != "OPT_progress" || got["fieldId"] != "FIELD_status" || got["itemId"] != "PVT_item_1" {
+		t.Fatalf("status mutation variables = %#v", got)
+	}
+	if got := (*calls)[3].Variables["labelIds"].([]any); len(got) != 1 || got[0] != "LABEL_failed" {
+		t.Fatalf("removed labels = %#v", got)
+	}
+	body, _ := (*calls)[6].Variables["body"].(string)
+	if !strings.HasPrefix(body, "<!-- kocao-symphony:demo:PVT_item_1 -->\n") || !strings.Contains(body, "claimed") {
+		t.Fatalf("comment body = %q", body)
+	}
+}
+
+func TestWriteBackUpdatesExistingProgressComment(t *testing.T) {
+	marker := "<!-- kocao-symphony:demo:PVT_item_1 -->"
+	srv, calls := newWriteBackServer(t, []map[string]any{
+		{"id": "IC_mine", "body": marker + "\nSymphony claimed this issue.", "viewerDidAuthor": true},
+	})
+	defer srv.Close()
+	client, err := NewClient("github-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+
+	if err := client.WriteBack(context.Background(), WriteBackRequest{IssueNodeID: "ISSUE_1", CommentMarker: marker, CommentBody: "Symphony finished this issue."}); err != nil {
+		t.Fatalf("WriteBack error = %v", err)
+	}
+	if len(*calls) != 2 || (*calls)[1].Operation != "SymphonyUpdateComment" || (*calls)[1].Variables["id"] != "IC_mine" {
+		t.Fatalf("calls = %#v", *calls)
+	}
+
+	*calls = nil
+	if err := client.WriteBack(context.Background(), WriteBackRequest{IssueNodeID: "ISSUE_1", CommentMarker: marker, CommentBody: "Symphony claimed this issue."}); err != nil {
+		t.Fatalf("WriteBack error = %v", err)
+	}
+	if len(*calls) != 1 {
+		t.Fatalf("expected an unchanged comment to be left alone, calls = %#v", *calls)
+	}
+}
+
+func TestWriteBackRejectsUnknownStatusOption(t *testing.T) {
+	srv, _ := newWriteBackServer(t, nil)
+	defer srv.Close()
+	client, err := NewClient("github-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	err = client.WriteBack(context.Background(), WriteBackRequest{ProjectID: "PVT_project_1", ItemID: "PVT_item_1", Status: "In Review"})
+	if err == nil || !strings.Contains(err.Error(), `has no option "In Review"`) {
+		t.Fatalf("expected missing option error, got %v", err)
+	}
+}
@@ -0,0 +1,443 @@
+package linearsource
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+)
+
+// DefaultAPIURL matches the endpoint workflow.TypedConfig assumes for
+// tracker.kind: linear.
+const DefaultAPIURL = "https://api.linear.app/graphql"
+
+// ResolvedFieldName is reported in place of a GitHub Project field: Linear
+// issues carry their workflow state directly.
+const ResolvedFieldName = "state"
+
+type Options struct {
+	APIURL     string
+	HTTPClient *http.Client
+}
+
+type Client struct {
+	apiURL     string
+	httpClient *http.Client
+	token      string
+}
+
+type LoadOptions struct {
+	Project        operatorv1alpha1.LinearProjectRef
+	ActiveStates   []string
+	TerminalStates []string
+	Repositories   []operatorv1alpha1.SymphonyProjectRepositorySpec
+}
+
+func NewClient(token string, opts Options) (*Client, error) {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return nil, fmt.Errorf("linear api key is required")
+	}
+	apiURL := strings.TrimSpace(opts.APIURL)
+	if apiURL == "" {
+		apiURL = DefaultAPIURL
+	}
+	httpClient := opts.HTTPClient
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 15 * time.Second}
+	}
+	return &Client{apiURL: apiURL, httpClient: httpClient, token: token}, nil
+}
+
+// LoadProject lists the team's issues and normalizes them into the same
+// snapshot shape the GitHub source produces, so the reconciler can treat both
+// trackers alike.
+func (c *Client) LoadProject(ctx context.Context, opts LoadOptions) (githubsource.Snapshot, error) {
+	if c == nil {
+		return githubsource.Snapshot{}, fmt.Errorf("linear source client is nil")
+	}
+	teamKey := strings.TrimSpace(opts.Project.TeamKey)
+	if teamKey == "" {
+		return githubsource.Snapshot{}, fmt.Errorf("linear team key is required")
+	}
+	activeStates := makeStateSet(opts.ActiveStates)
+	if len(activeStates) == 0 {
+		return githubsource.Snapshot{}, fmt.Errorf("at least one active state is required")
+	}
+	terminalStates := makeStateSet(opts.TerminalStates)
+	if len(terminalStates) == 0 {
+		return githubsource.Snapshot{}, fmt.Errorf("at least one terminal state is required")
+	}
+	labelPrefix := strings.ToLower(strings.TrimSpace(opts.Project.RepositoryLabelPrefix))
+	if labelPrefix == "" {
+		labelPrefix = operatorv1alpha1.DefaultLinearRepositoryLabelPrefix
+	}
+	allowlist := map[string]struct{}{}
+	for _, repo := range opts.Repositories {
+		if key := repo.RepositoryKey(); key != "" {
+			allowlist[key] = struct{}{}
+		}
+	}
+	defaultRepository := ""
+	if len(allowlist) == 1 {
+		for key := range allowlist {
+			defaultRepository = key
+		}
+	}
+
+	projectName := strings.TrimSpace(opts.Project.ProjectName)
+	issues, err := c.loadIssues(ctx, teamKey, projectName)
+	if err != nil {
+		return githubsource.Snapshot{}, err
+	}
+
+	snapshot := githubsource.Snapshot{
+		ProjectID:         teamKey,
+		ProjectTitle:      teamKey,
+		ResolvedFieldName: ResolvedFieldName,
+	}
+	if projectName != "" {
+		snapshot.ProjectID = teamKey + "/" + projectName
+		snapshot.ProjectTitle = projectName
+	}
+	unsupportedSeen := map[string]struct{}{}
+
+	for _, node := range issues {
+		status := strings.TrimSpace(node.State.Name)
+		if node.ArchivedAt != "" {
+			snapshot.Skipped = append(snapshot.Skipped, githubsource.SkippedItem{
+				ItemID:      node.ID,
+				Status:      status,
+				Reason:      githubsource.SkipReasonArchived,
+				Message:     "linear issue is archived",
+				ObservedAt:  time.Now().UTC(),
+				WasArchived: true,
+			})
+			continue
+		}
+		repository := node.repositoryKey(labelPrefix)
+		if repository == "" {
+			repository = defaultRepository
+		}
+		issue, err := node.toIssue(repository)
+		if err != nil {
+			return githubsource.Snapshot{}, fmt.Errorf("normalize linear issue %q: %w", node.Identifier, err)
+		}
+		if repository == "" {
+			snapshot.Skipped = append(snapshot.Skipped, githubsource.SkippedItem{
+				ItemID:     node.ID,
+				Status:     status,
+				Reason:     githubsource.SkipReasonUnsupportedRepository,
+				Message:    fmt.Sprintf("linear issue %s has no %s<owner>/<name> label", node.Identifier, labelPrefix),
+				Issue:      &issue,
+				ObservedAt: time.Now().UTC(),
+			})
+			continue
+		}
+		if _, ok := allowlist[repository]; !ok {
+			if _, seen := unsupportedSeen[repository]; !seen {
+				unsupportedSeen[repository] = struct{}{}
+				snapshot.UnsupportedRepositories = append(snapshot.UnsupportedRepositories, repository)
+			}
+			snapshot.Skipped = append(snapshot.Skipped, githubsource.SkippedItem{
+				ItemID:     node.ID,
+				Repository: repository,
+				Status:     status,
+				Reason:     githubsource.SkipReasonUnsupportedRepository,
+				Message:    fmt.Sprintf("repository %s is not configured for this Symphony project", repository),
+				Issue:      &issue,
+				ObservedAt: time.Now().UTC(),
+			})
+			continue
+		}
+		if _, ok := terminalStates[normalizeState(status)]; ok {
+			snapshot.Skipped = append(snapshot.Skipped, githubsource.SkippedItem{
+				ItemID:     node.ID,
+				Repository: repository,
+				Status:     status,
+				Reason:     githubsource.SkipReasonTerminalState,
+				Message:    fmt.Sprintf("state %q is configured as terminal", status),
+				Issue:      &issue,
+				ObservedAt: time.Now().UTC(),
+			})
+			continue
+		}
+		if stateType := normalizeState(node.State.Type); stateType == "completed" || stateType == "canceled" {
+			snapshot.Skipped = append(snapshot.Skipped, githubsource.SkippedItem{
+				ItemID:     node.ID,
+				Repository: repository,
+				Status:     status,
+				Reason:     githubsource.SkipReasonIssueClosed,
+				Message:    fmt.Sprintf("linear issue is %s", stateType),
+				Issue:      &issue,
+				ObservedAt: time.Now().UTC(),
+			})
+			continue
+		}
+		if _, ok := activeStates[normalizeState(status)]; !ok {
+			snapshot.Skipped = append(snapshot.Skipped, githubsource.SkippedItem{
+				ItemID:     node.ID,
+				Repository: repository,
+				Status:     status,
+				Reason:     githubsource.SkipReasonInactiveState,
+				Message:    fmt.Sprintf("state %q is not configured as active", status),
+				Issue:      &issue,
+				ObservedAt: time.Now().UTC(),
+			})
+			continue
+		}
+		snapshot.Candidates = append(snapshot.Candidates, githubsource.CandidateItem{ItemID: node.ID, Status: status, Issue: issue, Priority: node.priorityValue()})
+	}
+
+	sort.Strings(snapshot.UnsupportedRepositories)
+	return snapshot, nil
+}
+
+func (c *Client) loadIssues(ctx context.Context, teamKey, projectName string) ([]issueNode, error) {
+	filter := map[string]any{"team": map[string]any{"key": map[string]any{"eq": teamKey}}}
+	if projectName != "" {
+		filter["project"] = map[string]any{"name": map[string]any{"eq": projectName}}
+	}
+	var (
+		cursor string
+		result []issueNode
+	)
+	for {
+		vars := map[string]any{"filter": filter}
+		if cursor != "" {
+			vars["cursor"] = cursor
+		}
+		resp, err := c.query(ctx, graphQLRequest{Query: issuesQuery, Variables: vars})
+		if err != nil {
+			return nil, err
+		}
+		if resp.Data.Issues == nil {
+			return nil, fmt.Errorf("linear graphql response is missing issues")
+		}
+		result = append(result, resp.Data.Issues.Nodes...)
+		if !resp.Data.Issues.PageInfo.HasNextPage {
+			return result, nil
+		}
+		cursor = resp.Data.Issues.PageInfo.EndCursor
+		if cursor == "" {
+			return nil, fmt.Errorf("linear issue pagination ended without a cursor")
+		}
+	}
+}
+
+func (c *Client) query(ctx context.Context, reqBody graphQLRequest) (graphQLResponse, error) {
+	body, err := json.Marshal(reqBody)
+	if err != nil {
+		return graphQLResponse{}, fmt.Errorf("encode linear graphql request: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return graphQLResponse{}, fmt.Errorf("build linear graphql request: %w", err)
+	}
+	req.Header.Set("Authorization", authorizationHeader(c.token))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return graphQLResponse{}, fmt.Errorf("execute linear graphql request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return graphQLResponse{}, fmt.Errorf("linear graphql returned %s: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+
+	var decoded graphQLResponse
+	if err := json.NewDecoder(resp.Body).Decode(&decoded); err != nil {
+		return graphQLResponse{}, fmt.Errorf("decode linear graphql response: %w", err)
+	}
+	if len(decoded.Errors) != 0 {
+		parts := make([]string, 0, len(decoded.Errors))
+		for _, item := range decoded.Errors {
+			if msg := strings.TrimSpace(item.Message); msg != "" {
+				parts = append(parts, msg)
+			}
+		}
+		if len(parts) == 0 {
+			parts = append(parts, "unknown graphql error")
+		}
+		return graphQLResponse{}, fmt.Errorf("linear graphql error: %s", strings.Join(parts, "; "))
+	}
+	return decoded, nil
+}
+
+// authorizationHeader sends personal API keys bare, as Linear expects, and
+// OAuth access tokens as bearer tokens.
+func authorizationHeader(token string) string {
+	if strings.HasPrefix(token, "lin_api_") {
+		return token
+	}
+	return "Bearer " + token
+}
+
+func makeStateSet(values []string) map[string]struct{} {
+	set := make(map[string]struct{}, len(values))
+	for _, value := range values {
+		if normalized := normalizeState(value); normalized != "" {
+			set[normalized] = struct{}{}
+		}
+	}
+	return set
+}
+
+func normalizeState(value string) string {
+	return strings.ToLower(strings.TrimSpace(value))
+}
+
+type graphQLRequest struct {
+	Query     string         `json:"query"`
+	Variables map[string]any `json:"variables,omitempty"`
+}
+
+type graphQLResponse struct {
+	Data   graphQLData    `json:"data"`
+	Errors []graphQLError `json:"errors"`
+}
+
+type graphQLError struct {
+	Message string `json:"message"`
+}
+
+type graphQLData struct {
+	Issues *issueConnection `json:"issues"`
+}
+
+type issueConnection struct {
+	Nodes    []issueNode `json:"nodes"`
+	PageInfo pageInfo    `json:"pageInfo"`
+}
+
+type pageInfo struct {
+	HasNextPage bool   `json:"hasNextPage"`
+	EndCursor   string `json:"endCursor"`
+}
+
+type issueNode struct {
+	ID          string          `json:"id"`
+	Identifier  string          `json:"identifier"`
+	Number      int64           `json:"number"`
+	Title       string          `json:"title"`
+	Description string          `json:"description"`
+	URL         string          `json:"url"`
+	CreatedAt   string          `json:"createdAt"`
+	UpdatedAt   string          `json:"updatedAt"`
+	ArchivedAt  string          `json:"archivedAt"`
+	State       issueState      `json:"state"`
+	Labels      labelConnection `json:"labels"`
+	// Priority is Linear's built-in priority: 0 for none, then 1 (urgent)
+	// through 4 (low).
+	Priority float64 `json:"priority"`
+}
+
+// priorityValue reports the issue's priority as a number field, lowest most
+// urgent, leaving it empty when the issue has no priority.
+func (n issueNode) priorityValue() githubsource.FieldValue {
+	if n.Priority <= 0 {
+		return githubsource.FieldValue{}
+	}
+	priority := n.Priority
+	return githubsource.FieldValue{Number: &priority}
+}
+
+type issueState struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type labelConnection struct {
+	Nodes []label `json:"nodes"`
+}
+
+type label struct {
+	Name string `json:"name"`
+}
+
+// repositoryKey returns the owner/name from the first label carrying prefix.
+func (n issueNode) repositoryKey(prefix string) string {
+	for _, l := range n.Labels.Nodes {
+		name := strings.ToLower(strings.TrimSpace(l.Name))
+		if !strings.HasPrefix(name, prefix) {
+			continue
+		}
+		owner, repo, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(name, prefix)), "/")
+		owner, repo = strings.TrimSpace(owner), strings.TrimSpace(repo)
+		if ok && owner != "" && repo != "" && !strings.Contains(repo, "/") {
+			return owner + "/" + repo
+		}
+	}
+	return ""
+}
+
+func (n issueNode) toIssue(repository string) (githubsource.Issue, error) {
+	createdAt, err := time.Parse(time.RFC3339, n.CreatedAt)
+	if err != nil {
+		return githubsource.Issue{}, fmt.Errorf("parse createdAt: %w", err)
+	}
+	updatedAt, err := time.Parse(time.RFC3339, n.UpdatedAt)
+	if err != nil {
+		return githubsource.Issue{}, fmt.Errorf("parse updatedAt: %w", err)
+	}
+	labels := make([]string, 0, len(n.Labels.Nodes))
+	for _, l := range n.Labels.Nodes {
+		if name := strings.TrimSpace(l.Name); name != "" {
+			labels = append(labels, name)
+		}
+	}
+	return githubsource.Issue{
+		NodeID:      n.ID,
+		Repository:  repository,
+		Number:      n.Number,
+		Title:       n.Title,
+		Body:        n.Description,
+		Labels:      labels,
+		URL:         n.URL,
+		CreatedAt:   createdAt.UTC(),
+		UpdatedAt:   updatedAt.UTC(),
+		ProjectItem: n.ID,
+	}, nil
+}
+
+const issuesQuery = `query SymphonyLinearIssues($filter: IssueFilter, $cursor: String) {
+  issues(first: 50, after: $cursor, filter: $filter, includeArchived: true) {
+    pageInfo {
+      hasNextPage
+      endCursor
+    }
+    nodes {
+      id
+      identifier
+      number
+      title
+      description
+      url
+      createdAt
+      updatedAt
+      archivedAt
+      priority
+      state {
+        name
+        type
+      }
+      labels(first: 20) {
+        nodes {
+          name
+        }
+      }
+    }
+  }
+}`
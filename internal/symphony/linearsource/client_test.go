@@ -0,0 +1,223 @@
+package linearsource
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	operatorv1alpha1 "github.com/withakay/kocao/internal/operator/api/v1alpha1"
+	"github.com/withakay/kocao/internal/symphony/githubsource"
+)
+
+func TestLoadProjectNormalizesCandidatesAndSkips(t *testing.T) {
+	var filter map[string]any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "lin_api_test" {
+			t.Fatalf("authorization header = %q", got)
+		}
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		filter, _ = req.Variables["filter"].(map[string]any)
+		urgent := linearIssue("lin_1", "ENG-1", 1, "Todo", "unstarted", "", "repo:withakay/kocao", "bug")
+		urgent["priority"] = 1
+		writeGraphQLResponse(t, w, map[string]any{
+			"data": map[string]any{
+				"issues": map[string]any{
+					"pageInfo": map[string]any{"hasNextPage": false, "endCursor": ""},
+					"nodes": []map[string]any{
+						urgent,
+						linearIssue("lin_2", "ENG-2", 2, "Done", "completed", "", "repo:withakay/kocao"),
+						linearIssue("lin_3", "ENG-3", 3, "Todo", "unstarted", "", "repo:someone/else"),
+						linearIssue("lin_4", "ENG-4", 4, "Todo", "unstarted", "2026-03-10T00:00:00Z", "repo:withakay/kocao"),
+						linearIssue("lin_5", "ENG-5", 5, "Backlog", "backlog", "", "Repo:Withakay/Kocao"),
+						linearIssue("lin_6", "ENG-6", 6, "Won't Fix", "canceled", "", "repo:withakay/kocao"),
+						linearIssue("lin_7", "ENG-7", 7, "Todo", "unstarted", ""),
+					},
+				},
+			},
+		})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("lin_api_test", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	snapshot, err := client.LoadProject(context.Background(), LoadOptions{
+		Project:        operatorv1alpha1.LinearProjectRef{TeamKey: "ENG", ProjectName: "Symphony"},
+		ActiveStates:   []string{"Todo", "In Progress"},
+		TerminalStates: []string{"Done"},
+		Repositories: []operatorv1alpha1.SymphonyProjectRepositorySpec{
+			{Owner: "withakay", Name: "kocao"},
+			{Owner: "withakay", Name: "other"},
+		},
+	})
+	if err != nil {
+		t.Fatalf("LoadProject error = %v", err)
+	}
+	if team, _ := filter["team"].(map[string]any); team == nil {
+		t.Fatalf("expected team filter, got %#v", filter)
+	}
+	if project, _ := filter["project"].(map[string]any); project == nil {
+		t.Fatalf("expected project filter, got %#v", filter)
+	}
+	if snapshot.ProjectID != "ENG/Symphony" || snapshot.ResolvedFieldName != ResolvedFieldName {
+		t.Fatalf("snapshot = %q / %q", snapshot.ProjectID, snapshot.ResolvedFieldName)
+	}
+	if len(snapshot.Candidates) != 1 {
+		t.Fatalf("candidates len = %d, want 1", len(snapshot.Candidates))
+	}
+	candidate := snapshot.Candidates[0]
+	if candidate.ItemID != "lin_1" || candidate.Issue.Repository != "withakay/kocao" || candidate.Issue.Number != 1 {
+		t.Fatalf("candidate = %+v", candidate)
+	}
+	if candidate.Issue.Body != "Description of ENG-1" || candidate.Issue.URL != "https://linear.app/acme/issue/ENG-1" {
+		t.Fatalf("candidate issue = %+v", candidate.Issue)
+	}
+	if candidate.Issue.CreatedAt.Format(time.RFC3339) != "2026-03-09T10:00:00Z" {
+		t.Fatalf("candidate createdAt = %s", candidate.Issue.CreatedAt.Format(time.RFC3339))
+	}
+	if candidate.Priority.Number == nil || *candidate.Priority.Number != 1 {
+		t.Fatalf("candidate priority = %#v", candidate.Priority)
+	}
+
+	if len(snapshot.Skipped) != 6 {
+		t.Fatalf("skipped len = %d, want 6", len(snapshot.Skipped))
+	}
+	assertSkipReason(t, snapshot.Skipped, "lin_2", githubsource.SkipReasonTerminalState)
+	assertSkipReason(t, snapshot.Skipped, "lin_3", githubsource.SkipReasonUnsupportedRepository)
+	assertSkipReason(t, snapshot.Skipped, "lin_4", githubsource.SkipReasonArchived)
+	assertSkipReason(t, snapshot.Skipped, "lin_5", githubsource.SkipReasonInactiveState)
+	assertSkipReason(t, snapshot.Skipped, "lin_6", githubsource.SkipReasonIssueClosed)
+	assertSkipReason(t, snapshot.Skipped, "lin_7", githubsource.SkipReasonUnsupportedRepository)
+	if len(snapshot.UnsupportedRepositories) != 1 || snapshot.UnsupportedRepositories[0] != "someone/else" {
+		t.Fatalf("unsupported repositories = %#v", snapshot.UnsupportedRepositories)
+	}
+}
+
+func TestLoadProjectPaginatesAndDefaultsSingleRepository(t *testing.T) {
+	var cursors []any
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); got != "Bearer oauth-token" {
+			t.Fatalf("authorization header = %q", got)
+		}
+		var req struct {
+			Variables map[string]any `json:"variables"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			t.Fatalf("decode request: %v", err)
+		}
+		cursors = append(cursors, req.Variables["cursor"])
+		page := map[string]any{
+			"pageInfo": map[string]any{"hasNextPage": true, "endCursor": "cursor-1"},
+			"nodes":    []map[string]any{linearIssue("lin_1", "OPS-1", 1, "Todo", "unstarted", "")},
+		}
+		if req.Variables["cursor"] == "cursor-1" {
+			page = map[string]any{
+				"pageInfo": map[string]any{"hasNextPage": false, "endCursor": ""},
+				"nodes":    []map[string]any{linearIssue("lin_2", "OPS-2", 2, "In Progress", "started", "")},
+			}
+		}
+		writeGraphQLResponse(t, w, map[string]any{"data": map[string]any{"issues": page}})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("oauth-token", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	snapshot, err := client.LoadProject(context.Background(), LoadOptions{
+		Project:        operatorv1alpha1.LinearProjectRef{TeamKey: "OPS"},
+		ActiveStates:   []string{"Todo", "In Progress"},
+		TerminalStates: []string{"Done"},
+		Repositories:   []operatorv1alpha1.SymphonyProjectRepositorySpec{{Owner: "withakay", Name: "kocao"}},
+	})
+	if err != nil {
+		t.Fatalf("LoadProject error = %v", err)
+	}
+	if len(cursors) != 2 || cursors[0] != nil || cursors[1] != "cursor-1" {
+		t.Fatalf("cursors = %#v", cursors)
+	}
+	if len(snapshot.Candidates) != 2 {
+		t.Fatalf("candidates len = %d, want 2", len(snapshot.Candidates))
+	}
+	for _, candidate := range snapshot.Candidates {
+		if candidate.Issue.Repository != "withakay/kocao" {
+			t.Fatalf("candidate %s repository = %q, want the only configured repository", candidate.ItemID, candidate.Issue.Repository)
+		}
+	}
+}
+
+func TestLoadProjectReturnsGraphQLErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeGraphQLResponse(t, w, map[string]any{
+			"errors": []map[string]any{{"message": "Authentication required"}},
+		})
+	}))
+	defer srv.Close()
+
+	client, err := NewClient("lin_api_test", Options{APIURL: srv.URL, HTTPClient: srv.Client()})
+	if err != nil {
+		t.Fatalf("NewClient error = %v", err)
+	}
+	_, err = client.LoadProject(context.Background(), LoadOptions{
+		Project:        operatorv1alpha1.LinearProjectRef{TeamKey: "ENG"},
+		ActiveStates:   []string{"Todo"},
+		TerminalStates: []string{"Done"},
+	})
+	if err == nil || !strings.Contains(err.Error(), "Authentication required") {
+		t.Fatalf("expected graphql error, got %v", err)
+	}
+}
+
+func assertSkipReason(t *testing.T, skipped []githubsource.SkippedItem, itemID, want string) {
+	t.Helper()
+	for _, item := range skipped {
+		if item.ItemID == itemID {
+			if item.Reason != want {
+				t.Fatalf("skip reason for %s = %q, want %q", itemID, item.Reason, want)
+			}
+			return
+		}
+	}
+	t.Fatalf("missing skipped item %s", itemID)
+}
+
+func linearIssue(id, identifier string, number int64, state, stateType, archivedAt string, labels ...string) map[string]any {
+	labelNodes := make([]map[string]any, 0, len(labels))
+	for _, name := range labels {
+		labelNodes = append(labelNodes, map[string]any{"name": name})
+	}
+	node := map[string]any{
+		"id":          id,
+		"identifier":  identifier,
+		"number":      number,
+		"title":       "Issue " + identifier,
+		"description": "Description of " + identifier,
+		"url":         "https://linear.app/acme/issue/" + identifier,
+		"createdAt":   "2026-03-09T10:00:00Z",
+		"updatedAt":   "2026-03-09T11:00:00Z",
+		"state":       map[string]any{"name": state, "type": stateType},
+		"labels":      map[string]any{"nodes": labelNodes},
+	}
+	if archivedAt != "" {
+		node["archivedAt"] = archivedAt
+	}
+	return node
+}
+
+func writeGraphQLResponse(t *testing.T, w http.ResponseWriter, payload map[string]any) {
+	t.Helper()
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(payload); err != nil {
+		t.Fatalf("encode response: %v", err)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"bufio"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os/exec"
@@ -27,6 +28,7 @@ const (
 	EventOtherMessage         = "other_message"
 	EventMalformed            = "malformed"
 	EventStartupFailed        = "startup_failed"
+	EventTurnStalled          = "turn_stalled"
 
 	ErrCodeInvalidWorkspaceCWD = "invalid_workspace_cwd"
 	ErrCodeResponseTimeout     = "response_timeout"
@@ -36,6 +38,14 @@ const (
 	ErrCodeTurnFailed          = "turn_failed"
 	ErrCodeTurnCancelled       = "turn_cancelled"
 	ErrCodeTurnInputRequired   = "turn_input_required"
+	ErrCodeTurnStalled         = "turn_stalled"
+
+	// StopCompleted, StopMaxTurns, StopPromptsExhausted and
+	// StopBudgetExceeded say why a successful run stopped issuing turns.
+	StopCompleted        = "completed"
+	StopMaxTurns         = "max_turns"
+	StopPromptsExhausted = "prompts_exhausted"
+	StopBudgetExceeded   = "budget_exceeded"
 )
 
 type Error struct {
@@ -72,12 +82,18 @@ type Event struct {
 	Payload           map[string]any `json:"payload,omitempty"`
 }
 
+// TurnResult is the outcome of one turn. Status is "completed" or, for the
+// turn that ended the run with an error, that error's code.
 type TurnResult struct {
 	TurnID    string    `json:"turnId"`
 	Status    string    `json:"status"`
 	StartedAt time.Time `json:"startedAt"`
 	EndedAt   time.Time `json:"endedAt"`
 	Usage     Usage     `json:"usage"`
+	Message   string    `json:"message,omitempty"`
+	// SignaledCompletion is set when the agent replied with the completion
+	// marker during the turn.
+	SignaledCompletion bool `json:"signaledCompletion,omitempty"`
 }
 
 type Result struct {
@@ -87,14 +103,30 @@ type Result struct {
 	RateLimits  map[string]any `json:"rateLimits,omitempty"`
 	LastEvent   string         `json:"lastEvent,omitempty"`
 	LastMessage string         `json:"lastMessage,omitempty"`
+	StopReason  string         `json:"stopReason,omitempty"`
 }
 
 type Options struct {
 	Workspace string
 	Title     string
-	Prompts   []string
-	Config    workflow.CodexConfig
-	OnEvent   func(Event)
+	// Prompts are sent in order, one per turn, on a single thread.
+	Prompts []string
+	// MaxTurns caps the turns on the thread, Prompts included. Zero means
+	// len(Prompts).
+	MaxTurns int
+	// Continue renders the prompt for turn (1-based) once Prompts are used up.
+	// Without it the run stops after the last prompt.
+	Continue func(turn int) (string, error)
+	// CompletionMarker, when set, ends the run after the turn in which the
+	// agent replies with it.
+	CompletionMarker string
+	Config           workflow.CodexConfig
+	OnEvent          func(Event)
+	// MaxTotalTokens and MaxDuration stop the run before another turn once
+	// the thread has used that many tokens or run that long. A turn already
+	// in progress is never cut short. Zero means no limit.
+	MaxTotalTokens int
+	MaxDuration    time.Duration
 }
 
 type rpcMessage struct {
@@ -156,25 +188,63 @@ func Run(ctx context.Context, opts Options) (Result, error) {
 	}
 	client.threadID = threadID
 
+	maxTurns := opts.MaxTurns
+	if maxTurns <= 0 {
+		maxTurns = len(opts.Prompts)
+	}
 	result := Result{ThreadID: threadID}
-	for _, prompt := range opts.Prompts {
-		turn, err := client.runTurn(ctx, opts.Config, threadID, opts.Title, prompt)
+	started := time.Now()
+	for len(result.Turns) < maxTurns {
+		number := len(result.Turns) + 1
+		var prompt string
+		switch {
+		case number > 1 && opts.MaxTotalTokens > 0 && client.lastUsage.TotalTokens >= opts.MaxTotalTokens,
+			number > 1 && opts.MaxDuration > 0 && time.Since(started) >= opts.MaxDuration:
+			result.StopReason = StopBudgetExceeded
+		case number <= len(opts.Prompts):
+			prompt = opts.Prompts[number-1]
+		case opts.Continue != nil:
+			prompt, err = opts.Continue(number)
+			if err != nil {
+				client.fillResult(&result)
+				return result, err
+			}
+		default:
+			result.StopReason = StopPromptsExhausted
+		}
+		if result.StopReason != "" {
+			break
+		}
+		turn, err := client.runTurn(ctx, opts.Config, threadID, opts.Title, prompt, opts.CompletionMarker)
+		if turn.TurnID != "" {
+			result.Turns = append(result.Turns, turn)
+		}
 		if err != nil {
-			result.Usage = client.lastUsage
-			result.RateLimits = cloneMap(client.rateLimits)
-			result.LastEvent = client.lastEvent
-			result.LastMessage = client.lastMsg
+			client.fillResult(&result)
 			return result, err
 		}
-		result.Turns = append(result.Turns, turn)
+		if turn.SignaledCompletion {
+			result.StopReason = StopCompleted
+			break
+		}
+	}
+	if result.StopReason == "" {
+		result.StopReason = StopMaxTurns
+		if opts.Continue == nil && len(result.Turns) == len(opts.Prompts) {
+			result.StopReason = StopPromptsExhausted
+		}
 	}
-	result.Usage = client.lastUsage
-	result.RateLimits = cloneMap(client.rateLimits)
-	result.LastEvent = client.lastEvent
-	result.LastMessage = client.lastMsg
+	client.fillResult(&result)
 	return result, nil
 }
 
+func (c *appServerClient) fillResult(result *Result) {
+	result.Usage = c.lastUsage
+	result.RateLimits = cloneMap(c.rateLimits)
+	result.LastEvent = c.lastEvent
+	result.LastMessage = c.lastMsg
+}
+
 func startClient(ctx context.Context, workdir string, cfg workflow.CodexConfig, onEvent func(Event)) (*appServerClient, error) {
 	command := strings.TrimSpace(cfg.Command)
 	if command == "" {
@@ -275,7 +345,9 @@ func (c *appServerClient) startThread(ctx context.Context, cfg workflow.CodexCon
 	return threadID, nil
 }
 
-func (c *appServerClient) runTurn(ctx context.Context, cfg workflow.CodexConfig, threadID, title, prompt string) (TurnResult, error) {
+// runTurn starts a turn and waits for it to finish. A failed turn is returned
+// alongside its error once the server has assigned it an id.
+func (c *appServerClient) runTurn(ctx context.Context, cfg workflow.CodexConfig, threadID, title, prompt, completionMarker string) (TurnResult, error) {
 	startedAt := time.Now().UTC()
 	params := map[string]any{
 		"threadId": threadID,
@@ -300,34 +372,101 @@ func (c *appServerClient) runTurn(ctx context.Context, cfg workflow.CodexConfig,
 	}
 	startedEvent := Event{Event: EventSessionStarted, Timestamp: startedAt, CodexAppServerPID: c.pid, ThreadID: threadID, TurnID: turnID, SessionID: sessionID(threadID, turnID)}
 	c.emit(startedEvent)
+	turn := TurnResult{TurnID: turnID, StartedAt: startedAt}
+	finish := func(status string, err error) (TurnResult, error) {
+		turn.Status = status
+		turn.EndedAt = time.Now().UTC()
+		turn.Usage = c.lastUsage
+		turn.Message = c.lastMsg
+		return turn, err
+	}
+	fail := func(err error) (TurnResult, error) {
+		var runnerErr *Error
+		if errors.As(err, &runnerErr) {
+			return finish(runnerErr.Code, err)
+		}
+		return finish(ErrCodeTurnFailed, err)
+	}
 	deadline := time.NewTimer(time.Duration(cfg.TurnTimeoutMS) * time.Millisecond)
 	defer deadline.Stop()
+	// A zero or negative stall timeout disables stall detection.
+	var stalled <-chan time.Time
+	var stallTimer *time.Timer
+	if cfg.StallTimeoutMS > 0 {
+		stallTimer = time.NewTimer(time.Duration(cfg.StallTimeoutMS) * time.Millisecond)
+		defer stallTimer.Stop()
+		stalled = stallTimer.C
+	}
+	// Streamed agent message text by item id, for completed items that do not
+	// repeat their text.
+	agentDeltas := map[string]string{}
 	for {
 		select {
 		case <-ctx.Done():
-			return TurnResult{}, ctx.Err()
+			return fail(ctx.Err())
 		case err := <-c.errs:
 			if err == io.EOF {
-				return TurnResult{}, &Error{Code: ErrCodePortExit, Err: io.EOF}
+				return fail(&Error{Code: ErrCodePortExit, Err: io.EOF})
 			}
-			return TurnResult{}, err
+			return fail(err)
 		case msg := <-c.events:
+			if stallTimer != nil {
+				stallTimer.Reset(time.Duration(cfg.StallTimeoutMS) * time.Millisecond)
+			}
 			outcome, event, err := c.handleEvent(msg, threadID, turnID)
 			if event.Event != "" {
 				c.emit(event)
 			}
+			if completionMarker != "" {
+				if text, ok := completedAgentMessage(msg, agentDeltas); ok && strings.Contains(text, completionMarker) {
+					turn.SignaledCompletion = true
+				}
+			}
 			if err != nil {
-				return TurnResult{}, err
+				return fail(err)
 			}
 			if outcome != "" {
-				return TurnResult{TurnID: turnID, Status: outcome, StartedAt: startedAt, EndedAt: time.Now().UTC(), Usage: c.lastUsage}, nil
+				return finish(outcome, nil)
 			}
+		case <-stalled:
+			message := fmt.Sprintf("no events for %dms", cfg.StallTimeoutMS)
+			c.lastEvent = EventTurnStalled
+			c.lastMsg = message
+			c.emit(Event{Event: EventTurnStalled, Timestamp: time.Now().UTC(), CodexAppServerPID: c.pid, ThreadID: threadID, TurnID: turnID, SessionID: sessionID(threadID, turnID), Message: message})
+			return fail(&Error{Code: ErrCodeTurnStalled, Err: fmt.Errorf("turn %s stalled: %s", turnID, message)})
 		case <-deadline.C:
-			return TurnResult{}, &Error{Code: ErrCodeTurnTimeout, Err: fmt.Errorf("turn %s exceeded timeout", turnID)}
+			return fail(&Error{Code: ErrCodeTurnTimeout, Err: fmt.Errorf("turn %s exceeded timeout", turnID)})
 		}
 	}
 }
 
+// completedAgentMessage returns the full text of an agent message once its
+// item completes. Only the agent's own replies can carry the completion
+// marker: echoed prompts, tool output and reasoning may quote it without
+// meaning it. Deltas are collected in deltas so a marker split across them
+// is still seen whole.
+func completedAgentMessage(msg rpcMessage, deltas map[string]string) (string, bool) {
+	switch msg.Method {
+	case "item/agentMessage/delta":
+		id := stringValue(msg.Params["itemId"])
+		deltas[id] += stringValue(msg.Params["delta"])
+		return "", false
+	case "item/completed":
+		item, ok := msg.Params["item"].(map[string]any)
+		if !ok || !strings.EqualFold(strings.TrimSpace(stringValue(item["type"])), "agentMessage") {
+			return "", false
+		}
+		id := stringValue(item["id"])
+		text := stringValue(item["text"])
+		if text == "" {
+			text = deltas[id]
+		}
+		delete(deltas, id)
+		return text, true
+	}
+	return "", false
+}
+
 func (c *appServerClient) handleEvent(msg rpcMessage, threadID, turnID string) (string, Event, error) {
 	now := time.Now().UTC()
 	event := Event{Timestamp: now, CodexAppServerPID: c.pid, ThreadID: threadID, TurnID: turnID, SessionID: sessionID(threadID, turnID), Method: msg.Method, Payload: msg.Params}
@@ -592,6 +731,8 @@ func nestedString(root map[string]any, keys ...string) string {
 
 func stringValue(value any) string {
 	switch typed := value.(type) {
+	case nil:
+		return ""
 	case string:
 		return typed
 	case fmt.Stringer:
@@ -132,6 +132,123 @@ func TestRunStartupTimeout(t *testing.T) {
 	}
 }
 
+func TestRunContinuesUntilCompletionMarker(t *testing.T) {
+	var continued []int
+	result, err := Run(context.Background(), Options{
+		Workspace:        t.TempDir(),
+		Title:            "ABC-123: Example",
+		Prompts:          []string{"first prompt"},
+		MaxTurns:         5,
+		CompletionMarker: "SYMPHONY_DONE",
+		Continue: func(turn int) (string, error) {
+			continued = append(continued, turn)
+			return "reply with SYMPHONY_DONE when finished", nil
+		},
+		Config: workflow.CodexConfig{Command: helperCommand(t, "marker"), ReadTimeoutMS: 1000, TurnTimeoutMS: 1000},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Turns) != 2 || result.StopReason != StopCompleted {
+		t.Fatalf("turns = %#v, stop reason = %q", result.Turns, result.StopReason)
+	}
+	if result.Turns[0].SignaledCompletion || !result.Turns[1].SignaledCompletion {
+		t.Fatalf("completion signals = %#v", result.Turns)
+	}
+	if len(continued) != 1 || continued[0] != 2 {
+		t.Fatalf("continuation turns = %v, want [2]", continued)
+	}
+}
+
+func TestRunIgnoresCompletionMarkerOutsideAgentMessages(t *testing.T) {
+	result, err := Run(context.Background(), Options{
+		Workspace:        t.TempDir(),
+		Title:            "ABC-123: Example",
+		Prompts:          []string{"first prompt"},
+		MaxTurns:         5,
+		CompletionMarker: "SYMPHONY_DONE",
+		Continue:         func(int) (string, error) { return "continue", nil },
+		Config:           workflow.CodexConfig{Command: helperCommand(t, "marker-tool"), ReadTimeoutMS: 1000, TurnTimeoutMS: 1000},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Turns) != 2 || result.StopReason != StopCompleted {
+		t.Fatalf("turns = %#v, stop reason = %q", result.Turns, result.StopReason)
+	}
+	if result.Turns[0].SignaledCompletion || !result.Turns[1].SignaledCompletion {
+		t.Fatalf("completion signals = %#v, want only the streamed agent reply to count", result.Turns)
+	}
+}
+
+func TestRunStopsAtMaxTurns(t *testing.T) {
+	result, err := Run(context.Background(), Options{
+		Workspace:        t.TempDir(),
+		Title:            "ABC-123: Example",
+		Prompts:          []string{"first prompt"},
+		MaxTurns:         3,
+		CompletionMarker: "SYMPHONY_DONE",
+		Continue:         func(int) (string, error) { return "continue", nil },
+		Config:           workflow.CodexConfig{Command: helperCommand(t, "success"), ReadTimeoutMS: 1000, TurnTimeoutMS: 1000},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Turns) != 3 || result.StopReason != StopMaxTurns {
+		t.Fatalf("turns = %d, stop reason = %q", len(result.Turns), result.StopReason)
+	}
+	if result.Usage.TotalTokens != 45 {
+		t.Fatalf("usage = %#v", result.Usage)
+	}
+}
+
+func TestRunStopsAtTokenBudget(t *testing.T) {
+	result, err := Run(context.Background(), Options{
+		Workspace:      t.TempDir(),
+		Title:          "ABC-123: Example",
+		Prompts:        []string{"first prompt"},
+		MaxTurns:       5,
+		MaxTotalTokens: 20,
+		Continue:       func(int) (string, error) { return "continue", nil },
+		Config:         workflow.CodexConfig{Command: helperCommand(t, "success"), ReadTimeoutMS: 1000, TurnTimeoutMS: 1000},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(result.Turns) != 2 || result.StopReason != StopBudgetExceeded {
+		t.Fatalf("turns = %d, stop reason = %q", len(result.Turns), result.StopReason)
+	}
+	if result.Usage.TotalTokens != 30 {
+		t.Fatalf("usage = %#v", result.Usage)
+	}
+}
+
+func TestRunFailsStalledTurn(t *testing.T) {
+	var events []Event
+	result, err := Run(context.Background(), Options{
+		Workspace: t.TempDir(),
+		Title:     "ABC-123: Example",
+		Prompts:   []string{"first prompt"},
+		Config: workflow.CodexConfig{
+			Command:        helperCommand(t, "stall"),
+			ReadTimeoutMS:  1000,
+			TurnTimeoutMS:  2000,
+			StallTimeoutMS: 100,
+		},
+		OnEvent: func(event Event) { events = append(events, event) },
+	})
+	var runnerErr *Error
+	if !errorsAs(err, &runnerErr) || runnerErr.Code != ErrCodeTurnStalled {
+		t.Fatalf("expected stall error, got %v", err)
+	}
+	if len(result.Turns) != 1 || result.Turns[0].Status != ErrCodeTurnStalled || result.LastEvent != EventTurnStalled {
+		t.Fatalf("result = %#v", result)
+	}
+	if !hasEvent(events, EventTurnStalled) {
+		t.Fatalf("events = %#v", events)
+	}
+}
+
 func helperCommand(t *testing.T, mode string) string {
 	t.Helper()
 	return fmt.Sprintf("GO_WANT_HELPER_PROCESS=1 %s -test.run=TestHelperProcess -- %s", os.Args[0], mode)
@@ -200,6 +317,35 @@ func TestHelperProcess(t *testing.T) {
 			case "hang":
 				time.Sleep(5 * time.Second)
 				return
+			case "marker":
+				// The echoed prompt quotes the marker; only the agent's reply on
+				// the second turn should count as completion.
+				writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/started", "params": map[string]any{"item": map[string]any{"type": "userMessage", "text": "reply with SYMPHONY_DONE when finished"}}})
+				reply := "still working"
+				if turnCount == 2 {
+					reply = "SYMPHONY_DONE"
+				}
+				writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/completed", "params": map[string]any{"item": map[string]any{"type": "agentMessage", "text": reply}}})
+				writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "turn/completed", "params": map[string]any{"turnId": turnID}})
+			case "marker-tool":
+				// Tool output and reasoning quote the marker on the first turn;
+				// the second turn streams it split across deltas.
+				if turnCount == 1 {
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/reasoning/textDelta", "params": map[string]any{"itemId": "r-1", "delta": "I should print SYMPHONY_DONE at the end"}})
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/completed", "params": map[string]any{"item": map[string]any{"type": "commandExecution", "id": "c-1", "command": "grep -r SYMPHONY_DONE", "aggregatedOutput": "WORKFLOW.md: reply with SYMPHONY_DONE"}}})
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/completed", "params": map[string]any{"item": map[string]any{"type": "mcpToolCall", "id": "t-1", "result": map[string]any{"text": "issue body: reply with SYMPHONY_DONE"}}}})
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/completed", "params": map[string]any{"item": map[string]any{"type": "reasoning", "id": "r-1", "text": "I should print SYMPHONY_DONE at the end"}}})
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/completed", "params": map[string]any{"item": map[string]any{"type": "agentMessage", "id": "m-1", "text": "still working"}}})
+				} else {
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/agentMessage/delta", "params": map[string]any{"itemId": "m-2", "delta": "All done. SYMPHONY_"}})
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/agentMessage/delta", "params": map[string]any{"itemId": "m-2", "delta": "DONE"}})
+					writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/completed", "params": map[string]any{"item": map[string]any{"type": "agentMessage", "id": "m-2"}}})
+				}
+				writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "turn/completed", "params": map[string]any{"turnId": turnID}})
+			case "stall":
+				writeHelperJSON(writer, map[string]any{"jsonrpc": "2.0", "method": "item/started", "params": map[string]any{"item": map[string]any{"type": "agentMessage", "text": "thinking"}}})
+				time.Sleep(5 * time.Second)
+				return
 			}
 		}
 	}
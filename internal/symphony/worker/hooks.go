@@ -0,0 +1,188 @@
+package worker
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/withakay/kocao/internal/symphony/workflow"
+)
+
+const (
+	HookAfterCreate = "after_create"
+	HookBeforeRun   = "before_run"
+	HookAfterRun    = "after_run"
+
+	// maxHookOutputBytes is the tail of a hook's combined stdout and stderr
+	// kept in the report.
+	maxHookOutputBytes = 512
+	// maxHookCaptureBytes bounds what is buffered while a hook runs.
+	maxHookCaptureBytes = 64 << 10
+	// hookWaitDelay is how long a timed-out hook's children may keep its
+	// output pipes open before they are closed under them.
+	hookWaitDelay = 2 * time.Second
+	// afterCreateMarker records that after_create succeeded for a workspace.
+	afterCreateMarker = "after-create.done"
+)
+
+// HookReport is the outcome of one lifecycle hook.
+type HookReport struct {
+	Name           string  `json:"name"`
+	ExitCode       int     `json:"exitCode"`
+	TimedOut       bool    `json:"timedOut,omitempty"`
+	SecondsRunning float64 `json:"secondsRunning,omitempty"`
+	Output         string  `json:"output,omitempty"`
+}
+
+// HookError is returned when a hook that gates the run fails.
+type HookError struct {
+	Hook     string
+	ExitCode int
+	TimedOut bool
+	Output   string
+}
+
+func (e *HookError) Error() string {
+	if e == nil {
+		return ""
+	}
+	if e.TimedOut {
+		return fmt.Sprintf("%s hook timed out", e.Hook)
+	}
+	if e.Output != "" {
+		return fmt.Sprintf("%s hook exited with %d: %s", e.Hook, e.ExitCode, e.Output)
+	}
+	return fmt.Sprintf("%s hook exited with %d", e.Hook, e.ExitCode)
+}
+
+// hookStateDir keeps hook bookkeeping next to the repository checkout, on the
+// workspace volume, so it survives later attempts without touching the repo.
+func hookStateDir(workspace string) string {
+	return filepath.Join(filepath.Dir(filepath.Clean(workspace)), ".kocao-symphony")
+}
+
+// runSetupHooks runs after_create, once per workspace, and then before_run.
+// Either failing stops the run.
+func runSetupHooks(ctx context.Context, hooks workflow.HooksConfig, workspace string, report *Report) error {
+	if script := strings.TrimSpace(hooks.AfterCreate); script != "" {
+		marker := filepath.Join(hookStateDir(workspace), afterCreateMarker)
+		if _, err := os.Stat(marker); err != nil {
+			if err := runGatingHook(ctx, HookAfterCreate, script, workspace, hooks.TimeoutMS, report); err != nil {
+				return err
+			}
+			if err := os.MkdirAll(filepath.Dir(marker), 0o755); err != nil {
+				return err
+			}
+			if err := os.WriteFile(marker, []byte(time.Now().UTC().Format(time.RFC3339)+"\n"), 0o644); err != nil {
+				return err
+			}
+		}
+	}
+	if script := strings.TrimSpace(hooks.BeforeRun); script != "" {
+		return runGatingHook(ctx, HookBeforeRun, script, workspace, hooks.TimeoutMS, report)
+	}
+	return nil
+}
+
+// runAfterRunHook runs after_run. Its failure is recorded but does not change
+// the outcome of the turns.
+func runAfterRunHook(ctx context.Context, hooks workflow.HooksConfig, workspace string, report *Report) {
+	script := strings.TrimSpace(hooks.AfterRun)
+	if script == "" {
+		return
+	}
+	report.Hooks = append(report.Hooks, runHook(ctx, HookAfterRun, script, workspace, hooks.TimeoutMS))
+}
+
+func runGatingHook(ctx context.Context, name, script, workspace string, timeoutMS int, report *Report) error {
+	result := runHook(ctx, name, script, workspace, timeoutMS)
+	report.Hooks = append(report.Hooks, result)
+	if result.ExitCode == 0 && !result.TimedOut {
+		return nil
+	}
+	report.FailedHook = name
+	return &HookError{Hook: name, ExitCode: result.ExitCode, TimedOut: result.TimedOut, Output: result.Output}
+}
+
+// runHook runs script with bash in workspace, as a separate process limited to
+// timeoutMS, and captures the tail of its combined output. The shell is not a
+// login shell, so the image's profile and rc files cannot change what the
+// hook sees.
+func runHook(ctx context.Context, name, script, workspace string, timeoutMS int) HookReport {
+	if timeoutMS <= 0 {
+		timeoutMS = 60000
+	}
+	hookCtx, cancel := context.WithTimeout(ctx, time.Duration(timeoutMS)*time.Millisecond)
+	defer cancel()
+
+	var output tailBuffer
+	cmd := exec.CommandContext(hookCtx, "bash", "--noprofile", "--norc", "-c", script)
+	cmd.Dir = workspace
+	cmd.Env = append(os.Environ(), "KOCAO_SYMPHONY_HOOK="+name)
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	cmd.WaitDelay = hookWaitDelay
+
+	started := time.Now()
+	err := cmd.Run()
+	result := HookReport{Name: name, SecondsRunning: time.Since(started).Seconds(), Output: tail(strings.TrimSpace(output.String()), maxHookOutputBytes)}
+	switch {
+	case err == nil:
+	case errors.Is(hookCtx.Err(), context.DeadlineExceeded):
+		result.TimedOut = true
+		result.ExitCode = -1
+	default:
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			result.ExitCode = exitErr.ExitCode()
+		} else {
+			result.ExitCode = -1
+			if result.Output == "" {
+				result.Output = tail(err.Error(), maxHookOutputBytes)
+			}
+		}
+	}
+	return result
+}
+
+// tailBuffer keeps the last maxHookCaptureBytes written to it.
+type tailBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Write(p)
+	if excess := b.buf.Len() - maxHookCaptureBytes; excess > 0 {
+		b.buf.Next(excess)
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// tail keeps the last limit bytes of value, marking the cut with an ellipsis
+// and never splitting a UTF-8 sequence.
+func tail(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	start := len(value) - limit + len("…")
+	for start < len(value) && !isRuneStart(value[start]) {
+		start++
+	}
+	return "…" + value[start:]
+}
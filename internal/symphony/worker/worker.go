@@ -0,0 +1,257 @@
+// Package worker runs the Codex turns for a single Symphony claim. The operator
+// calls it directly in operator worker mode; kocao-symphony-worker calls it
+// inside the harness pod in pod worker mode.
+package worker
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/withakay/kocao/internal/symphony/runner"
+	"github.com/withakay/kocao/internal/symphony/workflow"
+)
+
+const (
+	// TaskFileName is the ConfigMap key and file name of a rendered task.
+	TaskFileName = "task.json"
+	// MaxReportBytes keeps the report under the kubelet's 4096-byte
+	// termination message limit, which is how an in-pod worker hands its
+	// report back to the operator, with room for the push line the harness
+	// entrypoint appends after it.
+	MaxReportBytes = 4096 - 64
+	// maxTurnMessageBytes keeps per-turn messages short so a long run still
+	// reports most of its turns.
+	maxTurnMessageBytes = 160
+)
+
+// Task is a claim the operator has already rendered: the prompt comes from
+// WORKFLOW.md and Codex is the hardened codex configuration. Later turns are
+// rendered from ContinuationTemplate with Issue and Attempt, because their
+// prompts depend on the turn number.
+type Task struct {
+	Title                string               `json:"title"`
+	Prompt               string               `json:"prompt"`
+	Codex                workflow.CodexConfig `json:"codex"`
+	MaxTurns             int                  `json:"maxTurns,omitempty"`
+	CompletionMarker     string               `json:"completionMarker,omitempty"`
+	ContinuationTemplate string               `json:"continuationTemplate,omitempty"`
+	Issue                map[string]any       `json:"issue,omitempty"`
+	Attempt              *int                 `json:"attempt,omitempty"`
+	// Hooks are only ever set for tasks that run inside the harness pod.
+	Hooks workflow.HooksConfig `json:"hooks,omitempty"`
+	// MaxTotalTokens and MaxSeconds are what the attempt may still spend
+	// under the project's budgets; no further turn starts once either is
+	// reached. Zero means no limit.
+	MaxTotalTokens int64 `json:"maxTotalTokens,omitempty"`
+	MaxSeconds     int64 `json:"maxSeconds,omitempty"`
+}
+
+// TurnReport is the outcome of one turn. TotalTokens is the thread's running
+// total when the turn ended.
+type TurnReport struct {
+	Turn           int     `json:"turn"`
+	TurnID         string  `json:"turnId,omitempty"`
+	Status         string  `json:"status"`
+	Message        string  `json:"message,omitempty"`
+	TotalTokens    int64   `json:"totalTokens,omitempty"`
+	SecondsRunning float64 `json:"secondsRunning,omitempty"`
+}
+
+// Report summarizes a worker run. Error is set when the run failed.
+type Report struct {
+	ThreadID       string  `json:"threadId,omitempty"`
+	TurnID         string  `json:"turnId,omitempty"`
+	ApprovalPolicy string  `json:"approvalPolicy,omitempty"`
+	ThreadSandbox  string  `json:"threadSandbox,omitempty"`
+	TurnSandbox    string  `json:"turnSandbox,omitempty"`
+	LastEvent      string  `json:"lastEvent,omitempty"`
+	LastMessage    string  `json:"lastMessage,omitempty"`
+	InputTokens    int64   `json:"inputTokens,omitempty"`
+	OutputTokens   int64   `json:"outputTokens,omitempty"`
+	TotalTokens    int64   `json:"totalTokens,omitempty"`
+	SecondsRunning float64 `json:"secondsRunning,omitempty"`
+	StopReason     string  `json:"stopReason,omitempty"`
+	// Turns lists each turn in order; the oldest are dropped first when the
+	// report has to shrink.
+	Turns []TurnReport `json:"turns,omitempty"`
+	// Hooks lists the lifecycle hooks that ran; FailedHook names the one that
+	// stopped the run.
+	Hooks      []HookReport `json:"hooks,omitempty"`
+	FailedHook string       `json:"failedHook,omitempty"`
+	Error      string       `json:"error,omitempty"`
+}
+
+// Run executes task in workspace and reports its usage. The report is
+// populated even when Run returns an error. Setup hooks run before the first
+// turn and after_run runs once the turns are over, whatever their outcome.
+func Run(ctx context.Context, task Task, workspace string) (Report, error) {
+	report := Report{
+		ApprovalPolicy: task.Codex.ApprovalPolicy,
+		ThreadSandbox:  task.Codex.ThreadSandbox,
+		TurnSandbox:    task.Codex.TurnSandboxPolicy,
+	}
+	if strings.TrimSpace(task.Prompt) == "" {
+		err := fmt.Errorf("symphony task prompt is empty")
+		report.Error = err.Error()
+		return report, err
+	}
+	if err := runSetupHooks(ctx, task.Hooks, workspace, &report); err != nil {
+		report.Error = err.Error()
+		return report, err
+	}
+	started := time.Now().UTC()
+	opts := runner.Options{
+		Workspace:      workspace,
+		Title:          task.Title,
+		Prompts:        []string{task.Prompt},
+		Config:         task.Codex,
+		MaxTotalTokens: int(task.MaxTotalTokens),
+		MaxDuration:    time.Duration(task.MaxSeconds) * time.Second,
+	}
+	if task.MaxTurns > 1 {
+		def := workflow.Definition{ContinuationTemplate: task.ContinuationTemplate}
+		opts.MaxTurns = task.MaxTurns
+		opts.CompletionMarker = firstNonEmpty(task.CompletionMarker, workflow.DefaultCompletionMarker)
+		opts.Continue = func(turn int) (string, error) {
+			return def.RenderContinuation(task.Issue, task.Attempt, turn, task.MaxTurns, opts.CompletionMarker)
+		}
+	}
+	result, err := runner.Run(ctx, opts)
+	report.SecondsRunning = time.Since(started).Seconds()
+	report.ThreadID = result.ThreadID
+	if len(result.Turns) != 0 {
+		report.TurnID = result.Turns[len(result.Turns)-1].TurnID
+	}
+	report.LastEvent = result.LastEvent
+	report.LastMessage = result.LastMessage
+	report.InputTokens = int64(result.Usage.InputTokens)
+	report.OutputTokens = int64(result.Usage.OutputTokens)
+	report.TotalTokens = int64(result.Usage.TotalTokens)
+	report.StopReason = result.StopReason
+	for i, turn := range result.Turns {
+		report.Turns = append(report.Turns, TurnReport{
+			Turn:           i + 1,
+			TurnID:         turn.TurnID,
+			Status:         turn.Status,
+			Message:        clip(turn.Message, maxTurnMessageBytes),
+			TotalTokens:    int64(turn.Usage.TotalTokens),
+			SecondsRunning: turn.EndedAt.Sub(turn.StartedAt).Seconds(),
+		})
+	}
+	runAfterRunHook(ctx, task.Hooks, workspace, &report)
+	if err != nil {
+		report.Error = err.Error()
+		return report, err
+	}
+	return report, nil
+}
+
+// ReadTask loads a task written by the operator.
+func ReadTask(path string) (Task, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Task{}, fmt.Errorf("read symphony task: %w", err)
+	}
+	var task Task
+	if err := json.Unmarshal(data, &task); err != nil {
+		return Task{}, fmt.Errorf("decode symphony task: %w", err)
+	}
+	if strings.TrimSpace(task.Prompt) == "" {
+		return Task{}, fmt.Errorf("symphony task %s has no prompt", path)
+	}
+	return task, nil
+}
+
+// EncodeReport marshals report, shortening the free-text fields until it
+// fits in MaxReportBytes.
+func EncodeReport(report Report) ([]byte, error) {
+	for {
+		data, err := json.Marshal(report)
+		if err != nil {
+			return nil, err
+		}
+		excess := len(data) - MaxReportBytes
+		if excess <= 0 {
+			return data, nil
+		}
+		switch {
+		case len(report.LastMessage) > 0:
+			report.LastMessage = truncate(report.LastMessage, excess)
+		case len(report.Turns) > 0:
+			report.Turns = report.Turns[1:]
+		case hookOutputBytes(report.Hooks) > 0:
+			report.Hooks = dropHookOutput(report.Hooks)
+		case len(report.Error) > 0:
+			report.Error = truncate(report.Error, excess)
+		default:
+			return nil, fmt.Errorf("symphony report exceeds %d bytes", MaxReportBytes)
+		}
+	}
+}
+
+// DecodeReport parses a report written by EncodeReport.
+func DecodeReport(data string) (Report, error) {
+	var report Report
+	if err := json.Unmarshal([]byte(strings.TrimSpace(data)), &report); err != nil {
+		return Report{}, fmt.Errorf("decode symphony report: %w", err)
+	}
+	return report, nil
+}
+
+// truncate drops at least excess bytes from the end of value, plus room for
+// the ellipsis, without splitting a UTF-8 sequence.
+func truncate(value string, excess int) string {
+	keep := len(value) - excess - len("…")
+	if keep <= 0 {
+		return ""
+	}
+	for keep > 0 && !isRuneStart(value[keep]) {
+		keep--
+	}
+	return value[:keep] + "…"
+}
+
+func hookOutputBytes(hooks []HookReport) int {
+	n := 0
+	for _, hook := range hooks {
+		n += len(hook.Output)
+	}
+	return n
+}
+
+// dropHookOutput clears the first hook output that is still set, keeping the
+// hook results themselves.
+func dropHookOutput(hooks []HookReport) []HookReport {
+	out := append([]HookReport(nil), hooks...)
+	for i := range out {
+		if out[i].Output != "" {
+			out[i].Output = ""
+			break
+		}
+	}
+	return out
+}
+
+func clip(value string, limit int) string {
+	if len(value) <= limit {
+		return value
+	}
+	return truncate(value, len(value)-limit)
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if trimmed := strings.TrimSpace(value); trimmed != "" {
+			return trimmed
+		}
+	}
+	return ""
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
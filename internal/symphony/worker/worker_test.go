@@ -0,0 +1,152 @@
+package worker
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/withakay/kocao/internal/symphony/workflow"
+)
+
+func TestEncodeReportFitsTerminationMessage(t *testing.T) {
+	report := Report{
+		ThreadID:    "thread-1",
+		TurnID:      "turn-1",
+		LastMessage: strings.Repeat("é", MaxReportBytes),
+		TotalTokens: 42,
+	}
+	data, err := EncodeReport(report)
+	if err != nil {
+		t.Fatalf("EncodeReport error = %v", err)
+	}
+	if len(data) > MaxReportBytes {
+		t.Fatalf("encoded report is %d bytes, want <= %d", len(data), MaxReportBytes)
+	}
+	decoded, err := DecodeReport(string(data))
+	if err != nil {
+		t.Fatalf("DecodeReport error = %v", err)
+	}
+	if decoded.TotalTokens != 42 || decoded.ThreadID != "thread-1" {
+		t.Fatalf("decoded report = %+v", decoded)
+	}
+	if !strings.HasSuffix(decoded.LastMessage, "…") || !strings.HasPrefix(decoded.LastMessage, "é") {
+		t.Fatalf("last message was not truncated cleanly: %q", decoded.LastMessage[len(decoded.LastMessage)-8:])
+	}
+}
+
+func TestReadTaskRequiresPrompt(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, TaskFileName)
+	if err := os.WriteFile(path, []byte(`{"title":"withakay/kocao#1: Fix","prompt":"Fix it","codex":{"command":"codex app-server","approvalPolicy":"untrusted"}}`), 0o600); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	task, err := ReadTask(path)
+	if err != nil {
+		t.Fatalf("ReadTask error = %v", err)
+	}
+	if task.Prompt != "Fix it" || task.Codex.Command != "codex app-server" || task.Codex.ApprovalPolicy != "untrusted" {
+		t.Fatalf("task = %+v", task)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"title":"empty"}`), 0o600); err != nil {
+		t.Fatalf("write task: %v", err)
+	}
+	if _, err := ReadTask(path); err == nil || !strings.Contains(err.Error(), "has no prompt") {
+		t.Fatalf("expected missing prompt error, got %v", err)
+	}
+}
+
+func TestRunRejectsEmptyPrompt(t *testing.T) {
+	report, err := Run(context.Background(), Task{Title: "empty"}, t.TempDir())
+	if err == nil || report.Error == "" {
+		t.Fatalf("expected empty prompt error, got report=%+v err=%v", report, err)
+	}
+}
+
+func TestEncodeReportDropsOldestTurnsFirst(t *testing.T) {
+	report := Report{ThreadID: "thread-1", StopReason: "max_turns", Error: "turn_stalled: no events"}
+	for i := 1; i <= 40; i++ {
+		report.Turns = append(report.Turns, TurnReport{Turn: i, TurnID: "turn", Status: "completed", Message: strings.Repeat("x", maxTurnMessageBytes), TotalTokens: int64(i)})
+	}
+	data, err := EncodeReport(report)
+	if err != nil {
+		t.Fatalf("EncodeReport error = %v", err)
+	}
+	if len(data) > MaxReportBytes {
+		t.Fatalf("encoded report is %d bytes, want <= %d", len(data), MaxReportBytes)
+	}
+	decoded, err := DecodeReport(string(data))
+	if err != nil {
+		t.Fatalf("DecodeReport error = %v", err)
+	}
+	if len(decoded.Turns) == 0 || len(decoded.Turns) == 40 || decoded.Turns[len(decoded.Turns)-1].Turn != 40 {
+		t.Fatalf("kept turns = %d, last = %+v", len(decoded.Turns), decoded.Turns[len(decoded.Turns)-1])
+	}
+	if decoded.Error != report.Error {
+		t.Fatalf("error was truncated before turns: %q", decoded.Error)
+	}
+}
+
+func TestRunSetupHooksRunsAfterCreateOnce(t *testing.T) {
+	workspace := filepath.Join(t.TempDir(), "repo")
+	if err := os.MkdirAll(workspace, 0o755); err != nil {
+		t.Fatalf("mkdir workspace: %v", err)
+	}
+	hooks := workflow.HooksConfig{AfterCreate: "echo created >> created.log", BeforeRun: "echo ready; echo warn >&2", TimeoutMS: 30000}
+	for i := 0; i < 2; i++ {
+		var report Report
+		if err := runSetupHooks(context.Background(), hooks, workspace, &report); err != nil {
+			t.Fatalf("runSetupHooks %d error = %v (report=%+v)", i, err, report)
+		}
+		last := report.Hooks[len(report.Hooks)-1]
+		if last.Name != HookBeforeRun || last.ExitCode != 0 || !strings.Contains(last.Output, "ready") || !strings.Contains(last.Output, "warn") {
+			t.Fatalf("before_run report = %+v", last)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(workspace, "created.log"))
+	if err != nil {
+		t.Fatalf("read created.log: %v", err)
+	}
+	if strings.Count(string(data), "created") != 1 {
+		t.Fatalf("after_create ran %d times, want 1", strings.Count(string(data), "created"))
+	}
+}
+
+func TestRunSetupHooksReportsFailureAndTimeout(t *testing.T) {
+	workspace := t.TempDir()
+	var report Report
+	err := runSetupHooks(context.Background(), workflow.HooksConfig{BeforeRun: "echo broken; exit 3", TimeoutMS: 30000}, workspace, &report)
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) || hookErr.Hook != HookBeforeRun || hookErr.ExitCode != 3 || hookErr.TimedOut {
+		t.Fatalf("expected before_run exit 3, got %v", err)
+	}
+	if report.FailedHook != HookBeforeRun || report.Hooks[0].Output != "broken" {
+		t.Fatalf("report = %+v", report)
+	}
+
+	report = Report{}
+	err = runSetupHooks(context.Background(), workflow.HooksConfig{BeforeRun: "sleep 30", TimeoutMS: 100}, workspace, &report)
+	if !errors.As(err, &hookErr) || !hookErr.TimedOut {
+		t.Fatalf("expected before_run timeout, got %v", err)
+	}
+	if !report.Hooks[0].TimedOut || report.Hooks[0].SecondsRunning > 10 {
+		t.Fatalf("timeout report = %+v", report.Hooks[0])
+	}
+}
+
+func TestRunHookSkipsShellProfiles(t *testing.T) {
+	home := t.TempDir()
+	for _, name := range []string{".bash_profile", ".profile", ".bashrc"} {
+		if err := os.WriteFile(filepath.Join(home, name), []byte("echo sourced-"+name+"\n"), 0o600); err != nil {
+			t.Fatalf("write %s: %v", name, err)
+		}
+	}
+	t.Setenv("HOME", home)
+	result := runHook(context.Background(), HookBeforeRun, "echo hook", t.TempDir(), 30000)
+	if result.ExitCode != 0 || result.Output != "hook" {
+		t.Fatalf("hook report = %+v, want only the hook's own output", result)
+	}
+}
@@ -15,6 +15,24 @@ import (
 
 const DefaultFileName = "WORKFLOW.md"
 
+// ContinuationDelimiter separates the first-turn prompt from the continuation
+// prompt in the body of WORKFLOW.md. It is an HTML comment so the file still
+// renders cleanly as Markdown.
+const ContinuationDelimiter = "<!-- symphony:continuation -->"
+
+// DefaultCompletionMarker is what the agent replies with once the work is
+// done, unless agent.completion_marker overrides it.
+const DefaultCompletionMarker = "SYMPHONY_DONE"
+
+// DefaultContinuationTemplate is sent on later turns when WORKFLOW.md has no
+// continuation section.
+const DefaultContinuationTemplate = `Continue working on the task from the previous turn (turn {{ .turn }} of at most {{ .max_turns }}).
+If the work is complete, reply with {{ .completion_marker }} and nothing else.`
+
+// completionInstruction is appended to the first prompt of a multi-turn run
+// whose template does not mention the completion marker itself.
+const completionInstruction = "When the work is complete, reply with %s and nothing else."
+
 const (
 	ErrCodeMissingWorkflowFile       = "missing_workflow_file"
 	ErrCodeWorkflowParseError        = "workflow_parse_error"
@@ -46,6 +64,9 @@ type Definition struct {
 	Path           string
 	Config         map[string]any
 	PromptTemplate string
+	// ContinuationTemplate is the prompt for turns after the first; empty
+	// means DefaultContinuationTemplate.
+	ContinuationTemplate string
 }
 
 type TrackerConfig struct {
@@ -66,11 +87,11 @@ type WorkspaceConfig struct {
 }
 
 type HooksConfig struct {
-	AfterCreate  string
-	BeforeRun    string
-	AfterRun     string
-	BeforeRemove string
-	TimeoutMS    int
+	AfterCreate  string `json:"afterCreate,omitempty"`
+	BeforeRun    string `json:"beforeRun,omitempty"`
+	AfterRun     string `json:"afterRun,omitempty"`
+	BeforeRemove string `json:"beforeRemove,omitempty"`
+	TimeoutMS    int    `json:"timeoutMs,omitempty"`
 }
 
 type AgentConfig struct {
@@ -78,16 +99,17 @@ type AgentConfig struct {
 	MaxTurns                   int
 	MaxRetryBackoffMS          int
 	MaxConcurrentAgentsByState map[string]int
+	CompletionMarker           string
 }
 
 type CodexConfig struct {
-	Command           string
-	ApprovalPolicy    string
-	ThreadSandbox     string
-	TurnSandboxPolicy string
-	TurnTimeoutMS     int
-	ReadTimeoutMS     int
-	StallTimeoutMS    int
+	Command           string `json:"command,omitempty"`
+	ApprovalPolicy    string `json:"approvalPolicy,omitempty"`
+	ThreadSandbox     string `json:"threadSandbox,omitempty"`
+	TurnSandboxPolicy string `json:"turnSandboxPolicy,omitempty"`
+	TurnTimeoutMS     int    `json:"turnTimeoutMs,omitempty"`
+	ReadTimeoutMS     int    `json:"readTimeoutMs,omitempty"`
+	StallTimeoutMS    int    `json:"stallTimeoutMs,omitempty"`
 }
 
 type Config struct {
@@ -131,7 +153,8 @@ func Load(path string) (Definition, error) {
 	if err != nil {
 		return Definition{}, err
 	}
-	return Definition{Path: path, Config: config, PromptTemplate: prompt}, nil
+	prompt, continuation, _ := strings.Cut(prompt, ContinuationDelimiter)
+	return Definition{Path: path, Config: config, PromptTemplate: strings.TrimSpace(prompt), ContinuationTemplate: strings.TrimSpace(continuation)}, nil
 }
 
 func (d Definition) TypedConfig(getenv func(string) string) (Config, error) {
@@ -177,6 +200,7 @@ func (d Definition) TypedConfig(getenv func(string) string) (Config, error) {
 			MaxTurns:                   positiveOrDefault(intValue(agentMap["max_turns"], 20), 20),
 			MaxRetryBackoffMS:          positiveOrDefault(intValue(agentMap["max_retry_backoff_ms"], 300000), 300000),
 			MaxConcurrentAgentsByState: stateLimitMap(agentMap["max_concurrent_agents_by_state"]),
+			CompletionMarker:           strings.TrimSpace(defaultString(stringValue(agentMap["completion_marker"]), DefaultCompletionMarker)),
 		},
 		Codex: CodexConfig{
 			Command:           strings.TrimSpace(defaultString(stringValue(codexMap["command"]), "codex app-server")),
@@ -205,11 +229,56 @@ func (d Definition) TypedConfig(getenv func(string) string) (Config, error) {
 }
 
 func (d Definition) Render(issue map[string]any, attempt *int) (string, error) {
+	return d.render(d.PromptTemplate, templateData(issue, attempt))
+}
+
+// RenderFirstTurn renders the first prompt of a run of up to maxTurns turns.
+// The template also sees turn, max_turns and completion_marker. When it does
+// not mention the marker, an instruction to reply with it is appended, so the
+// agent can end the run after the first turn.
+func (d Definition) RenderFirstTurn(issue map[string]any, attempt *int, maxTurns int, completionMarker string) (string, error) {
+	marker := defaultString(completionMarker, DefaultCompletionMarker)
+	data := templateData(issue, attempt)
+	data["turn"] = 1
+	data["max_turns"] = maxTurns
+	data["completion_marker"] = marker
+	out, err := d.render(d.PromptTemplate, data)
+	if err != nil || strings.Contains(out, marker) {
+		return out, err
+	}
+	return strings.TrimRight(out, "\n") + "\n\n" + fmt.Sprintf(completionInstruction, marker), nil
+}
+
+// RenderContinuation renders the prompt for a later turn. Besides issue and
+// attempt, the template sees turn (1-based), max_turns and completion_marker.
+func (d Definition) RenderContinuation(issue map[string]any, attempt *int, turn, maxTurns int, completionMarker string) (string, error) {
+	source := d.ContinuationTemplate
+	if strings.TrimSpace(source) == "" {
+		source = DefaultContinuationTemplate
+	}
+	data := templateData(issue, attempt)
+	data["turn"] = turn
+	data["max_turns"] = maxTurns
+	data["completion_marker"] = defaultString(completionMarker, DefaultCompletionMarker)
+	return d.render(source, data)
+}
+
+func templateData(issue map[string]any, attempt *int) map[string]any {
+	data := map[string]any{"issue": issue}
+	if attempt != nil {
+		data["attempt"] = *attempt
+	} else {
+		data["attempt"] = nil
+	}
+	return data
+}
+
+func (d Definition) render(source string, data map[string]any) (string, error) {
 	funcs := template.FuncMap{
 		"lower": strings.ToLower,
 		"upper": strings.ToUpper,
 		"trim":  strings.TrimSpace,
-		"join":  func(items []string, sep string) string { return strings.Join(items, sep) },
+		"join":  func(items any, sep string) string { return strings.Join(stringSlice(items), sep) },
 		"default": func(fallback, value any) any {
 			if value == nil {
 				return fallback
@@ -223,16 +292,10 @@ func (d Definition) Render(issue map[string]any, attempt *int) (string, error) {
 			return value
 		},
 	}
-	tmpl, err := template.New("workflow").Funcs(funcs).Option("missingkey=error").Parse(d.PromptTemplate)
+	tmpl, err := template.New("workflow").Funcs(funcs).Option("missingkey=error").Parse(source)
 	if err != nil {
 		return "", &Error{Code: ErrCodeTemplateParseError, Path: d.Path, Err: err}
 	}
-	data := map[string]any{"issue": issue}
-	if attempt != nil {
-		data["attempt"] = *attempt
-	} else {
-		data["attempt"] = nil
-	}
 	var out bytes.Buffer
 	if err := tmpl.Execute(&out, data); err != nil {
 		return "", &Error{Code: ErrCodeTemplateRenderError, Path: d.Path, Err: err}
@@ -240,6 +303,23 @@ func (d Definition) Render(issue map[string]any, attempt *int) (string, error) {
 	return strings.TrimSpace(out.String()), nil
 }
 
+// stringSlice accepts []any as well as []string so templates keep working on
+// issue data that has been through a JSON round trip.
+func stringSlice(items any) []string {
+	switch typed := items.(type) {
+	case []string:
+		return typed
+	case []any:
+		out := make([]string, 0, len(typed))
+		for _, item := range typed {
+			out = append(out, stringValue(item))
+		}
+		return out
+	default:
+		return nil
+	}
+}
+
 func parseDocument(path, raw string) (map[string]any, string, error) {
 	if !strings.HasPrefix(raw, "---") {
 		return map[string]any{}, strings.TrimSpace(raw), nil
@@ -159,3 +159,79 @@ func TestRenderFailsOnUnknownFunction(t *testing.T) {
 		t.Fatalf("error code = %q", workflowErr.Code)
 	}
 }
+
+func TestLoadSplitsContinuationSection(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, DefaultFileName)
+	content := strings.Join([]string{
+		"---",
+		"agent:",
+		"  max_turns: 4",
+		"  completion_marker: ALL_DONE",
+		"---",
+		"Fix {{.issue.title}} ({{join .issue.labels \", \"}}).",
+		ContinuationDelimiter,
+		"Turn {{.turn}}/{{.max_turns}} on {{.issue.title}}; say {{.completion_marker}} when finished.",
+	}, "\n")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatalf("write workflow: %v", err)
+	}
+	def, err := Load(path)
+	if err != nil {
+		t.Fatalf("load workflow: %v", err)
+	}
+	cfg, err := def.TypedConfig(func(string) string { return "" })
+	if err != nil {
+		t.Fatalf("typed config: %v", err)
+	}
+	if cfg.Agent.MaxTurns != 4 || cfg.Agent.CompletionMarker != "ALL_DONE" {
+		t.Fatalf("agent config = %#v", cfg.Agent)
+	}
+	// Labels arrive as []any once the issue has been through a JSON round trip.
+	issue := map[string]any{"title": "hello", "labels": []any{"bug", "p1"}}
+	first, err := def.Render(issue, nil)
+	if err != nil {
+		t.Fatalf("render: %v", err)
+	}
+	if first != "Fix hello (bug, p1)." {
+		t.Fatalf("first prompt = %q", first)
+	}
+	next, err := def.RenderContinuation(issue, nil, 2, cfg.Agent.MaxTurns, cfg.Agent.CompletionMarker)
+	if err != nil {
+		t.Fatalf("render continuation: %v", err)
+	}
+	if next != "Turn 2/4 on hello; say ALL_DONE when finished." {
+		t.Fatalf("continuation prompt = %q", next)
+	}
+}
+
+func TestRenderFirstTurnAddsCompletionInstructionUnlessTemplateHasMarker(t *testing.T) {
+	def := Definition{Path: DefaultFileName, PromptTemplate: "Fix {{.issue.title}}"}
+	out, err := def.RenderFirstTurn(map[string]any{"title": "hello"}, nil, 4, "ALL_DONE")
+	if err != nil {
+		t.Fatalf("render first turn: %v", err)
+	}
+	if out != "Fix hello\n\nWhen the work is complete, reply with ALL_DONE and nothing else." {
+		t.Fatalf("first prompt = %q", out)
+	}
+
+	def.PromptTemplate = "Fix {{.issue.title}} in {{.max_turns}} turns, then say {{.completion_marker}}."
+	out, err = def.RenderFirstTurn(map[string]any{"title": "hello"}, nil, 4, "")
+	if err != nil {
+		t.Fatalf("render first turn: %v", err)
+	}
+	if out != "Fix hello in 4 turns, then say "+DefaultCompletionMarker+"." {
+		t.Fatalf("first prompt = %q", out)
+	}
+}
+
+func TestRenderContinuationDefaultsTemplateAndMarker(t *testing.T) {
+	def := Definition{Path: DefaultFileName, PromptTemplate: "Fix it"}
+	out, err := def.RenderContinuation(map[string]any{}, nil, 3, 20, "")
+	if err != nil {
+		t.Fatalf("render continuation: %v", err)
+	}
+	if !strings.Contains(out, "turn 3 of at most 20") || !strings.Contains(out, DefaultCompletionMarker) {
+		t.Fatalf("continuation prompt = %q", out)
+	}
+}
@@ -4,6 +4,22 @@ export type WorkspaceSession = {
   repoURL?: string
   phase?: string
   createdAt?: string
+  restoredFrom?: string
+  restorePhase?: 'Pending' | 'Ready' | 'Failed'
+}
+
+export type WorkspaceSnapshot = {
+  id: string
+  workspaceSessionId: string
+  name?: string
+  method: 'volumeSnapshot' | 'archive'
+  phase: 'Pending' | 'Ready' | 'Failed'
+  volumeSnapshotName?: string
+  artifactDigest?: string
+  sizeBytes?: number
+  error?: string
+  createdAt: string
+  readyAt?: string
 }
 
 export type AgentSessionInfo = {
@@ -26,6 +42,9 @@ export type HarnessRun = {
   gitHubBranch?: string
   pullRequestURL?: string
   pullRequestStatus?: string
+  reason?: string
+  message?: string
+  lastActivityAt?: string
 }
 
 export type AgentSessionState = {
@@ -48,6 +67,7 @@ export type AgentSessionEvent = {
 export type RemoteAgentAvailability = 'idle' | 'busy' | 'offline'
 
 export type RemoteAgentTaskState =
+  | 'queued'
   | 'assigned'
   | 'running'
   | 'completed'
@@ -73,6 +93,7 @@ export type RemoteAgentPool = {
   displayName?: string
   description?: string
   workspaceSessionId?: string
+  maxConcurrency?: number
   createdAt?: string
   updatedAt?: string
 }
@@ -90,11 +111,19 @@ export type RemoteAgent = {
   availability?: RemoteAgentAvailability
   currentTaskId?: string
   lastActivityAt?: string
+  lastHeartbeatAt?: string
   currentSession?: RemoteAgentSessionBinding
+  availabilityHistory?: RemoteAgentAvailabilityEvent[]
   createdAt?: string
   updatedAt?: string
 }
 
+export type RemoteAgentAvailabilityEvent = {
+  at: string
+  availability: RemoteAgentAvailability
+  reason?: string
+}
+
 export type RemoteAgentArtifactRef = {
   id: string
   name: string
@@ -133,21 +162,54 @@ export type RemoteAgentTaskBase = {
   workspaceSessionId?: string
   prompt?: string
   state: RemoteAgentTaskState
+  priority?: number
+  queuePosition?: number
+  waitSeconds?: number
   timeoutSeconds?: number
   attempt?: number
   retryCount?: number
   currentSession?: RemoteAgentSessionBinding
   createdAt?: string
+  queuedAt?: string
   assignedAt?: string
   startedAt?: string
   completedAt?: string
   cancelledAt?: string
   lastTransitionAt?: string
+  workflowId?: string
+  workflowStep?: string
   result?: RemoteAgentTaskResult
 }
 
 export type RemoteAgentTask = RemoteAgentTaskBase
 
+export type RemoteAgentWorkflowState = 'running' | 'completed' | 'failed' | 'cancelled'
+
+export type RemoteAgentWorkflowStep = {
+  name: string
+  dependsOn?: string[]
+  target: { agentId?: string; agentName?: string; poolName?: string; workspaceSessionId?: string }
+  prompt: string
+  priority?: number
+  timeoutSeconds?: number
+  taskId?: string
+  state?: RemoteAgentTaskState | 'pending' | 'blocked'
+  waitingReason?: string
+}
+
+export type RemoteAgentWorkflow = {
+  id: string
+  name?: string
+  requestedBy?: string
+  team?: string
+  state: RemoteAgentWorkflowState
+  steps: RemoteAgentWorkflowStep[]
+  createdAt?: string
+  completedAt?: string
+  cancelledAt?: string
+  lastTransitionAt?: string
+}
+
 export type RemoteAgentTaskArtifactsResponse = {
   taskId: string
   inputArtifacts?: RemoteAgentArtifactRef[]
@@ -251,6 +313,9 @@ export type SymphonyProjectClaim = {
   claimedAt?: string
   lastUpdatedTime?: string
   runRef?: SymphonyProjectRunRef
+  rank?: number
+  usedTokens?: number
+  usedSeconds?: number
 }
 
 export type SymphonyProjectRetry = {
@@ -260,6 +325,8 @@ export type SymphonyProjectRetry = {
   reason?: string
   readyAt?: string
   lastErrorTime?: string
+  usedTokens?: number
+  usedSeconds?: number
 }
 
 export type SymphonyProjectSkip = {
@@ -269,6 +336,7 @@ export type SymphonyProjectSkip = {
   reason?: string
   message?: string
   observedTime?: string
+  rank?: number
 }
 
 export type SymphonyProjectError = {
@@ -290,6 +358,9 @@ export type SymphonyProjectEvent = {
   message?: string
   observedTime?: string
   harnessRunName?: string
+  turn?: number
+  totalTokens?: number
+  stopReason?: string
 }
 
 export type SymphonyProjectTokenTotals = {
@@ -307,15 +378,22 @@ export type SymphonyProjectRepository = {
   workflowPath?: string
   branch?: string
   egressMode?: string
+  maxConcurrentItems?: number
 }
 
 export type SymphonyProjectSpec = {
   paused?: boolean
   source: {
+    kind?: 'github' | 'linear'
     project: {
       owner: string
       number: number
     }
+    linear?: {
+      teamKey: string
+      projectName?: string
+      repositoryLabelPrefix?: string
+    }
     tokenSecretRef: {
       name: string
       key?: string
@@ -332,6 +410,7 @@ export type SymphonyProjectSpec = {
     args?: string[]
     workingDir?: string
     maxConcurrentItems?: number
+    maxConcurrentItemsByStatus?: Record<string, number>
     retryBaseDelaySeconds?: number
     retryMaxDelaySeconds?: number
     ttlSecondsAfterFinished?: number | null
@@ -340,7 +419,57 @@ export type SymphonyProjectSpec = {
     activeStatusItemLimit?: number
     defaultRepoRevision?: string
     defaultEgressMode?: string
+    workerMode?: 'operator' | 'pod'
+    budgets?: SymphonyProjectBudgets
+    scheduling?: {
+      runClass?: string
+    }
+    egressAllowedHosts?: string[]
+  }
+  writeBack?: {
+    comment?: boolean
+    runURLBase?: string
+    onClaim?: SymphonyProjectWriteBackAction
+    onFailure?: SymphonyProjectWriteBackAction
+    onSuccess?: SymphonyProjectWriteBackAction
   }
+  priority?: {
+    pinned?: string[]
+    fieldName?: string
+    fieldOrder?: string[]
+    highestNumberFirst?: boolean
+    labelWeights?: Record<string, number>
+    age?: 'oldestFirst' | 'newestFirst'
+  }
+  pullRequests?: {
+    branchPrefix?: string
+    draft?: boolean
+  }
+}
+
+export type SymphonyProjectBudgets = {
+  maxTokensPerAttempt?: number
+  maxSecondsPerAttempt?: number
+  maxTokensPerItem?: number
+  maxSecondsPerItem?: number
+  maxTokensPerDay?: number
+  maxSecondsPerDay?: number
+}
+
+export type SymphonyProjectBudgetStatus = {
+  day?: string
+  tokensToday?: number
+  secondsToday?: number
+  remainingTokensToday?: number
+  remainingSecondsToday?: number
+  exhausted?: boolean
+  exceededItems?: SymphonyProjectSkip[]
+}
+
+export type SymphonyProjectWriteBackAction = {
+  status?: string
+  addLabels?: string[]
+  removeLabels?: string[]
 }
 
 export type SymphonyProjectStatus = {
@@ -348,6 +477,7 @@ export type SymphonyProjectStatus = {
   phase?: string
   conditions?: SymphonyProjectCondition[]
   resolvedFieldName?: string
+  projectNodeId?: string
   lastSyncTime?: string
   lastSuccessfulSyncTime?: string
   nextSyncTime?: string
@@ -365,6 +495,37 @@ export type SymphonyProjectStatus = {
   completedItems?: number
   failedItems?: number
   skippedItems?: number
+  plan?: SymphonyProjectPlanStatus
+  budget?: SymphonyProjectBudgetStatus
+}
+
+export type SymphonyProjectPlanItem = {
+  action: 'claim' | 'retry' | 'running' | 'backoff' | 'skip' | 'release'
+  itemId?: string
+  issue?: SymphonyProjectIssueRef
+  rank?: number
+  attempt?: number
+  reason?: string
+  message?: string
+  readyAt?: string
+  harnessRunName?: string
+  prompt?: string
+  promptError?: string
+}
+
+export type SymphonyProjectPlanStatus = {
+  requestedAt?: string
+  generatedTime?: string
+  eligibleItems?: number
+  items?: SymphonyProjectPlanItem[]
+  error?: string
+}
+
+export type SymphonyPlan = {
+  name: string
+  requestedAt?: string
+  ready: boolean
+  plan?: SymphonyProjectPlanStatus
 }
 
 export type SymphonyProject = {
@@ -439,6 +600,20 @@ export const api = {
     apiFetch<WorkspaceSession>('/api/v1/workspace-sessions', { method: 'POST', body: { repoURL, displayName }, token }),
   deleteWorkspaceSession: (token: string, id: string) =>
     apiFetch<{ deleted: boolean }>(`/api/v1/workspace-sessions/${encodeURIComponent(id)}`, { method: 'DELETE', token }),
+  listWorkspaceSnapshots: (token: string, workspaceSessionID: string) =>
+    apiFetch<{ snapshots: WorkspaceSnapshot[] }>(`/api/v1/workspace-sessions/${encodeURIComponent(workspaceSessionID)}/snapshots`, { token }),
+  createWorkspaceSnapshot: (token: string, workspaceSessionID: string, name?: string) =>
+    apiFetch<WorkspaceSnapshot>(`/api/v1/workspace-sessions/${encodeURIComponent(workspaceSessionID)}/snapshots`, {
+      method: 'POST',
+      body: { name },
+      token,
+    }),
+  restoreWorkspaceSnapshot: (token: string, snapshotID: string, displayName?: string) =>
+    apiFetch<WorkspaceSession>(`/api/v1/workspace-snapshots/${encodeURIComponent(snapshotID)}/restore`, {
+      method: 'POST',
+      body: { displayName },
+      token,
+    }),
 
   listHarnessRuns: (token: string, workspaceSessionID?: string) => {
     const q = workspaceSessionID ? `?workspaceSessionID=${encodeURIComponent(workspaceSessionID)}` : ''
@@ -557,4 +732,8 @@ export const api = {
     apiFetch<SymphonyProject>(`/api/v1/symphony-projects/${encodeURIComponent(projectName)}/resume`, { method: 'POST', token }),
   refreshSymphonyProject: (token: string, projectName: string) =>
     apiFetch<SymphonyProject>(`/api/v1/symphony-projects/${encodeURIComponent(projectName)}/refresh`, { method: 'POST', token }),
+  requestSymphonyPlan: (token: string, projectName: string) =>
+    apiFetch<SymphonyPlan>(`/api/v1/symphony-projects/${encodeURIComponent(projectName)}/plan`, { method: 'POST', token }),
+  getSymphonyPlan: (token: string, projectName: string) =>
+    apiFetch<SymphonyPlan>(`/api/v1/symphony-projects/${encodeURIComponent(projectName)}/plan`, { token }),
 }
@@ -172,6 +172,7 @@ export function RunDetailPage() {
               <DetailRow label="Revision">{run.repoRevision && run.repoRevision.trim() !== '' ? run.repoRevision : '\u2014'}</DetailRow>
               <DetailRow label="Image">{run.image}</DetailRow>
               <DetailRow label="Phase"><StatusPill phase={run.phase} /></DetailRow>
+              {run.reason ? <DetailRow label="Reason">{run.message ? `${run.reason}: ${run.message}` : run.reason}</DetailRow> : null}
               <DetailRow label="Pod">{run.podName && run.podName.trim() !== '' ? run.podName : '\u2014'}</DetailRow>
             </>
           ) : (
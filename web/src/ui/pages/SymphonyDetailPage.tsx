@@ -27,6 +27,7 @@ function ClaimRows({ claims }: { claims: SymphonyProjectClaim[] }) {
     <Table label="active symphony claims table">
       <thead>
         <tr className="border-b border-border/40">
+          <Th>Rank</Th>
           <Th>Issue</Th>
           <Th>Attempt</Th>
           <Th>Phase</Th>
@@ -36,10 +37,11 @@ function ClaimRows({ claims }: { claims: SymphonyProjectClaim[] }) {
       </thead>
       <tbody>
         {claims.length === 0 ? (
-          <EmptyRow cols={5} loading={false} message="No active claims." />
+          <EmptyRow cols={6} loading={false} message="No active claims." />
         ) : (
           claims.map((claim) => (
             <tr key={claim.itemId} className="border-b border-border/20 last:border-b-0">
+              <Td>{claim.rank || '—'}</Td>
               <Td className="font-mono text-xs">
                 {claim.issue?.url ? (
                   <a className="text-primary hover:underline" href={claim.issue.url} target="_blank" rel="noreferrer">
@@ -146,10 +148,14 @@ function EventRows({ events }: { events: SymphonyProjectEvent[] }) {
           <EmptyRow cols={5} loading={false} message="No recent events." />
         ) : (
           events.map((event) => (
-            <tr key={`${event.itemId}:${event.turnId ?? event.event ?? 'event'}`} className="border-b border-border/20 last:border-b-0">
+            <tr key={`${event.itemId}:${event.harnessRunName ?? ''}:${event.turn ?? 0}:${event.turnId ?? event.event ?? 'event'}`} className="border-b border-border/20 last:border-b-0">
               <Td className="font-mono text-xs">{issueLabel(event.issue?.repository, event.issue?.number, event.issue?.title)}</Td>
               <Td>
-                <div className="font-medium">{event.event || '—'}</div>
+                <div className="font-medium">
+                  {event.turn ? `turn ${event.turn}: ` : ''}
+                  {event.event || '—'}
+                  {event.stopReason ? ` (${event.stopReason})` : ''}
+                </div>
                 <div className="text-xs text-muted-foreground">{event.message || '—'}</div>
               </Td>
               <Td className="font-mono text-xs">{event.sessionId || event.threadId || '—'}</Td>
@@ -170,6 +176,7 @@ function SkipRows({ skips }: { skips: SymphonyProjectSkip[] }) {
     <Table label="recent symphony skips table">
       <thead>
         <tr className="border-b border-border/40">
+          <Th>Rank</Th>
           <Th>Issue</Th>
           <Th>Reason</Th>
           <Th>Message</Th>
@@ -178,10 +185,11 @@ function SkipRows({ skips }: { skips: SymphonyProjectSkip[] }) {
       </thead>
       <tbody>
         {skips.length === 0 ? (
-          <EmptyRow cols={4} loading={false} message="No recent skips." />
+          <EmptyRow cols={5} loading={false} message="No recent skips." />
         ) : (
           skips.map((skip) => (
             <tr key={skip.itemId} className="border-b border-border/20 last:border-b-0">
+              <Td>{skip.rank || '—'}</Td>
               <Td className="font-mono text-xs">{issueLabel(skip.issue?.repository ?? skip.repository, skip.issue?.number, skip.issue?.title)}</Td>
               <Td>{skip.reason || '—'}</Td>
               <Td>{skip.message || '—'}</Td>
@@ -44,7 +44,7 @@ export function RemoteAgentAvailabilityBadge({ availability }: { availability?:
 export function RemoteAgentTaskStateBadge({ state }: { state: RemoteAgentTaskState }) {
   const variant =
     state === 'running' ? 'info' :
-    state === 'assigned' ? 'warn' :
+    state === 'assigned' || state === 'queued' ? 'warn' :
     state === 'completed' ? 'ok' :
     state === 'failed' || state === 'timed_out' || state === 'cancelled' ? 'bad' :
     'neutral'
@@ -251,6 +251,7 @@ export function AgentDetailSections({ agent, task }: { agent: RemoteAgent; task?
 
       <CollapsibleSection title="Timeline" defaultOpen={true}>
         <DetailRow label="Last Activity">{formatTimestamp(agent.lastActivityAt)}</DetailRow>
+        <DetailRow label="Last Heartbeat">{formatTimestamp(agent.lastHeartbeatAt)}</DetailRow>
         <DetailRow label="Created">{formatTimestamp(agent.createdAt)}</DetailRow>
         <DetailRow label="Updated">{formatTimestamp(agent.updatedAt)}</DetailRow>
       </CollapsibleSection>